@@ -0,0 +1,33 @@
+package myshoes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/whywaita/myshoes/pkg/web"
+)
+
+// RequestCapacity request generic instances to a target
+func (c *Client) RequestCapacity(ctx context.Context, targetID string, param web.CapacityRequestParam) (*web.CapacityResponse, error) {
+	spath := fmt.Sprintf("/target/%s/capacity", targetID)
+
+	jb, err := json.Marshal(param)
+	if err != nil {
+		return nil, fmt.Errorf("failed to json.Marshal: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, spath, bytes.NewBuffer(jb))
+	if err != nil {
+		return nil, fmt.Errorf(errCreateRequest, err)
+	}
+
+	var resp web.CapacityResponse
+	if err := c.request(req, &resp); err != nil {
+		return nil, fmt.Errorf(errRequest, err)
+	}
+
+	return &resp, nil
+}
@@ -0,0 +1,26 @@
+package myshoes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/whywaita/myshoes/pkg/runner"
+)
+
+// GetGCReport get a report of last cycle in runner manager
+func (c *Client) GetGCReport(ctx context.Context) (*runner.GCReport, error) {
+	spath := "/runners/gc-report"
+
+	req, err := c.newRequest(ctx, http.MethodGet, spath, nil)
+	if err != nil {
+		return nil, fmt.Errorf(errCreateRequest, err)
+	}
+
+	var report runner.GCReport
+	if err := c.request(req, &report); err != nil {
+		return nil, fmt.Errorf(errRequest, err)
+	}
+
+	return &report, nil
+}
@@ -0,0 +1,26 @@
+package myshoes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/whywaita/myshoes/pkg/web"
+)
+
+// GetJob get position and ETA of a queued job
+func (c *Client) GetJob(ctx context.Context, jobID string) (*web.JobQueueStatus, error) {
+	spath := fmt.Sprintf("/jobs/%s", jobID)
+
+	req, err := c.newRequest(ctx, http.MethodGet, spath, nil)
+	if err != nil {
+		return nil, fmt.Errorf(errCreateRequest, err)
+	}
+
+	var status web.JobQueueStatus
+	if err := c.request(req, &status); err != nil {
+		return nil, fmt.Errorf(errRequest, err)
+	}
+
+	return &status, nil
+}
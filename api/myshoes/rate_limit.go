@@ -0,0 +1,26 @@
+package myshoes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/whywaita/myshoes/pkg/gh"
+)
+
+// ListRateLimits get a list of tracked GitHub rate limit per scope
+func (c *Client) ListRateLimits(ctx context.Context) ([]gh.RateLimit, error) {
+	spath := "/rate-limits"
+
+	req, err := c.newRequest(ctx, http.MethodGet, spath, nil)
+	if err != nil {
+		return nil, fmt.Errorf(errCreateRequest, err)
+	}
+
+	var rateLimits []gh.RateLimit
+	if err := c.request(req, &rateLimits); err != nil {
+		return nil, fmt.Errorf(errRequest, err)
+	}
+
+	return rateLimits, nil
+}
@@ -104,3 +104,96 @@ func (c *Client) ListTarget(ctx context.Context) ([]web.UserTarget, error) {
 
 	return targets, nil
 }
+
+// ListTargetByExternalRef get a list of target that has external reference ID
+func (c *Client) ListTargetByExternalRef(ctx context.Context, externalRef string) ([]web.UserTarget, error) {
+	spath := "/target"
+
+	req, err := c.newRequest(ctx, http.MethodGet, spath, nil)
+	if err != nil {
+		return nil, fmt.Errorf(errCreateRequest, err)
+	}
+	q := req.URL.Query()
+	q.Set("external_ref", externalRef)
+	req.URL.RawQuery = q.Encode()
+
+	var targets []web.UserTarget
+	if err := c.request(req, &targets); err != nil {
+		return nil, fmt.Errorf(errRequest, err)
+	}
+
+	return targets, nil
+}
+
+// ListDeletedTargets get a list of soft deleted target
+func (c *Client) ListDeletedTargets(ctx context.Context) ([]web.UserTarget, error) {
+	spath := "/targets/deleted"
+
+	req, err := c.newRequest(ctx, http.MethodGet, spath, nil)
+	if err != nil {
+		return nil, fmt.Errorf(errCreateRequest, err)
+	}
+
+	var targets []web.UserTarget
+	if err := c.request(req, &targets); err != nil {
+		return nil, fmt.Errorf(errRequest, err)
+	}
+
+	return targets, nil
+}
+
+// RestoreTarget restore a soft deleted target
+func (c *Client) RestoreTarget(ctx context.Context, targetID string) (*web.UserTarget, error) {
+	spath := fmt.Sprintf("/target/%s/restore", targetID)
+
+	req, err := c.newRequest(ctx, http.MethodPost, spath, nil)
+	if err != nil {
+		return nil, fmt.Errorf(errCreateRequest, err)
+	}
+
+	var target web.UserTarget
+	if err := c.request(req, &target); err != nil {
+		return nil, fmt.Errorf(errRequest, err)
+	}
+
+	return &target, nil
+}
+
+// ExportTargets get all targets for backup
+func (c *Client) ExportTargets(ctx context.Context) ([]web.ExportTarget, error) {
+	spath := "/targets/export"
+
+	req, err := c.newRequest(ctx, http.MethodGet, spath, nil)
+	if err != nil {
+		return nil, fmt.Errorf(errCreateRequest, err)
+	}
+
+	var targets []web.ExportTarget
+	if err := c.request(req, &targets); err != nil {
+		return nil, fmt.Errorf(errRequest, err)
+	}
+
+	return targets, nil
+}
+
+// ImportTargets restore targets from backup
+func (c *Client) ImportTargets(ctx context.Context, targets []web.ExportTarget) (*web.ImportResult, error) {
+	spath := "/targets/import"
+
+	jb, err := json.Marshal(targets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to json.Marshal: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, spath, bytes.NewBuffer(jb))
+	if err != nil {
+		return nil, fmt.Errorf(errCreateRequest, err)
+	}
+
+	var result web.ImportResult
+	if err := c.request(req, &result); err != nil {
+		return nil, fmt.Errorf(errRequest, err)
+	}
+
+	return &result, nil
+}
@@ -90,15 +90,75 @@ func (ResourceType) EnumDescriptor() ([]byte, []int) {
 	return file_myshoes_proto_rawDescGZIP(), []int{0}
 }
 
+type DeleteReason int32
+
+const (
+	DeleteReason_UnknownReason DeleteReason = 0
+	DeleteReason_JobCompleted  DeleteReason = 1
+	DeleteReason_Zombie        DeleteReason = 2
+	DeleteReason_TTL           DeleteReason = 3
+	DeleteReason_Drain         DeleteReason = 4
+	DeleteReason_Manual        DeleteReason = 5
+)
+
+// Enum value maps for DeleteReason.
+var (
+	DeleteReason_name = map[int32]string{
+		0: "UnknownReason",
+		1: "JobCompleted",
+		2: "Zombie",
+		3: "TTL",
+		4: "Drain",
+		5: "Manual",
+	}
+	DeleteReason_value = map[string]int32{
+		"UnknownReason": 0,
+		"JobCompleted":  1,
+		"Zombie":        2,
+		"TTL":           3,
+		"Drain":         4,
+		"Manual":        5,
+	}
+)
+
+func (x DeleteReason) Enum() *DeleteReason {
+	p := new(DeleteReason)
+	*p = x
+	return p
+}
+
+func (x DeleteReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DeleteReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_myshoes_proto_enumTypes[1].Descriptor()
+}
+
+func (DeleteReason) Type() protoreflect.EnumType {
+	return &file_myshoes_proto_enumTypes[1]
+}
+
+func (x DeleteReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DeleteReason.Descriptor instead.
+func (DeleteReason) EnumDescriptor() ([]byte, []int) {
+	return file_myshoes_proto_rawDescGZIP(), []int{1}
+}
+
 type AddInstanceRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	RunnerName   string       `protobuf:"bytes,1,opt,name=runner_name,json=runnerName,proto3" json:"runner_name,omitempty"`
-	SetupScript  string       `protobuf:"bytes,2,opt,name=setup_script,json=setupScript,proto3" json:"setup_script,omitempty"`
-	ResourceType ResourceType `protobuf:"varint,3,opt,name=resource_type,json=resourceType,proto3,enum=whywaita.myshoes.ResourceType" json:"resource_type,omitempty"`
-	Labels       []string     `protobuf:"bytes,4,rep,name=labels,proto3" json:"labels,omitempty"`
+	RunnerName      string            `protobuf:"bytes,1,opt,name=runner_name,json=runnerName,proto3" json:"runner_name,omitempty"`
+	SetupScript     string            `protobuf:"bytes,2,opt,name=setup_script,json=setupScript,proto3" json:"setup_script,omitempty"`
+	ResourceType    ResourceType      `protobuf:"varint,3,opt,name=resource_type,json=resourceType,proto3,enum=whywaita.myshoes.ResourceType" json:"resource_type,omitempty"`
+	Labels          []string          `protobuf:"bytes,4,rep,name=labels,proto3" json:"labels,omitempty"`
+	PlacementParams string            `protobuf:"bytes,5,opt,name=placement_params,json=placementParams,proto3" json:"placement_params,omitempty"`
+	Metadata        map[string]string `protobuf:"bytes,6,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
 func (x *AddInstanceRequest) Reset() {
@@ -161,6 +221,20 @@ func (x *AddInstanceRequest) GetLabels() []string {
 	return nil
 }
 
+func (x *AddInstanceRequest) GetPlacementParams() string {
+	if x != nil {
+		return x.PlacementParams
+	}
+	return ""
+}
+
+func (x *AddInstanceRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
 type AddInstanceResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -237,8 +311,9 @@ type DeleteInstanceRequest struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	CloudId string   `protobuf:"bytes,1,opt,name=cloud_id,json=cloudId,proto3" json:"cloud_id,omitempty"`
-	Labels  []string `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty"`
+	CloudId string       `protobuf:"bytes,1,opt,name=cloud_id,json=cloudId,proto3" json:"cloud_id,omitempty"`
+	Labels  []string     `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty"`
+	Reason  DeleteReason `protobuf:"varint,3,opt,name=reason,proto3,enum=whywaita.myshoes.DeleteReason" json:"reason,omitempty"`
 }
 
 func (x *DeleteInstanceRequest) Reset() {
@@ -287,6 +362,13 @@ func (x *DeleteInstanceRequest) GetLabels() []string {
 	return nil
 }
 
+func (x *DeleteInstanceRequest) GetReason() DeleteReason {
+	if x != nil {
+		return x.Reason
+	}
+	return DeleteReason_UnknownReason
+}
+
 type DeleteInstanceResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -325,12 +407,223 @@ func (*DeleteInstanceResponse) Descriptor() ([]byte, []int) {
 	return file_myshoes_proto_rawDescGZIP(), []int{3}
 }
 
+type GetCapabilitiesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ProtocolVersion uint32   `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	Capabilities    []string `protobuf:"bytes,2,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+}
+
+func (x *GetCapabilitiesRequest) Reset() {
+	*x = GetCapabilitiesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_myshoes_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCapabilitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCapabilitiesRequest) ProtoMessage() {}
+
+func (x *GetCapabilitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_myshoes_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCapabilitiesRequest.ProtoReflect.Descriptor instead.
+func (*GetCapabilitiesRequest) Descriptor() ([]byte, []int) {
+	return file_myshoes_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetCapabilitiesRequest) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *GetCapabilitiesRequest) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+type GetCapabilitiesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ProtocolVersion    uint32   `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	MinProtocolVersion uint32   `protobuf:"varint,2,opt,name=min_protocol_version,json=minProtocolVersion,proto3" json:"min_protocol_version,omitempty"`
+	Capabilities       []string `protobuf:"bytes,3,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+}
+
+func (x *GetCapabilitiesResponse) Reset() {
+	*x = GetCapabilitiesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_myshoes_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCapabilitiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCapabilitiesResponse) ProtoMessage() {}
+
+func (x *GetCapabilitiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_myshoes_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCapabilitiesResponse.ProtoReflect.Descriptor instead.
+func (*GetCapabilitiesResponse) Descriptor() ([]byte, []int) {
+	return file_myshoes_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetCapabilitiesResponse) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *GetCapabilitiesResponse) GetMinProtocolVersion() uint32 {
+	if x != nil {
+		return x.MinProtocolVersion
+	}
+	return 0
+}
+
+func (x *GetCapabilitiesResponse) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+type GetCapacityRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetCapacityRequest) Reset() {
+	*x = GetCapacityRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_myshoes_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCapacityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCapacityRequest) ProtoMessage() {}
+
+func (x *GetCapacityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_myshoes_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCapacityRequest.ProtoReflect.Descriptor instead.
+func (*GetCapacityRequest) Descriptor() ([]byte, []int) {
+	return file_myshoes_proto_rawDescGZIP(), []int{6}
+}
+
+type GetCapacityResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CurrentCapacity uint32 `protobuf:"varint,1,opt,name=current_capacity,json=currentCapacity,proto3" json:"current_capacity,omitempty"`
+	MaxCapacity     uint32 `protobuf:"varint,2,opt,name=max_capacity,json=maxCapacity,proto3" json:"max_capacity,omitempty"`
+}
+
+func (x *GetCapacityResponse) Reset() {
+	*x = GetCapacityResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_myshoes_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCapacityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCapacityResponse) ProtoMessage() {}
+
+func (x *GetCapacityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_myshoes_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCapacityResponse.ProtoReflect.Descriptor instead.
+func (*GetCapacityResponse) Descriptor() ([]byte, []int) {
+	return file_myshoes_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetCapacityResponse) GetCurrentCapacity() uint32 {
+	if x != nil {
+		return x.CurrentCapacity
+	}
+	return 0
+}
+
+func (x *GetCapacityResponse) GetMaxCapacity() uint32 {
+	if x != nil {
+		return x.MaxCapacity
+	}
+	return 0
+}
+
 var File_myshoes_proto protoreflect.FileDescriptor
 
 var file_myshoes_proto_rawDesc = []byte{
 	0x0a, 0x0d, 0x6d, 0x79, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
 	0x10, 0x77, 0x68, 0x79, 0x77, 0x61, 0x69, 0x74, 0x61, 0x2e, 0x6d, 0x79, 0x73, 0x68, 0x6f, 0x65,
-	0x73, 0x22, 0xb5, 0x01, 0x0a, 0x12, 0x41, 0x64, 0x64, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63,
+	0x73, 0x22, 0xed, 0x02, 0x0a, 0x12, 0x41, 0x64, 0x64, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63,
 	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x72, 0x75, 0x6e, 0x6e,
 	0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x72,
 	0x75, 0x6e, 0x6e, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x65, 0x74,
@@ -341,49 +634,107 @@ var file_myshoes_proto_rawDesc = []byte{
 	0x79, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54,
 	0x79, 0x70, 0x65, 0x52, 0x0c, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x79, 0x70,
 	0x65, 0x12, 0x16, 0x0a, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28,
-	0x09, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x22, 0xb3, 0x01, 0x0a, 0x13, 0x41, 0x64,
-	0x64, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a,
-	0x73, 0x68, 0x6f, 0x65, 0x73, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x09, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x69,
-	0x70, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x09, 0x69, 0x70, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x43, 0x0a, 0x0d, 0x72, 0x65,
-	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28,
-	0x0e, 0x32, 0x1e, 0x2e, 0x77, 0x68, 0x79, 0x77, 0x61, 0x69, 0x74, 0x61, 0x2e, 0x6d, 0x79, 0x73,
-	0x68, 0x6f, 0x65, 0x73, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x79, 0x70,
-	0x65, 0x52, 0x0c, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x22,
-	0x4a, 0x0a, 0x15, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63,
-	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x6c, 0x6f, 0x75,
-	0x64, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6c, 0x6f, 0x75,
-	0x64, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x02, 0x20,
-	0x03, 0x28, 0x09, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x22, 0x18, 0x0a, 0x16, 0x44,
-	0x65, 0x6c, 0x65, 0x74, 0x65, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2a, 0x85, 0x01, 0x0a, 0x0c, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72,
-	0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x6e, 0x6b, 0x6e, 0x6f, 0x77,
-	0x6e, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x4e, 0x61, 0x6e, 0x6f, 0x10, 0x01, 0x12, 0x09, 0x0a,
-	0x05, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x10, 0x02, 0x12, 0x09, 0x0a, 0x05, 0x53, 0x6d, 0x61, 0x6c,
-	0x6c, 0x10, 0x03, 0x12, 0x0a, 0x0a, 0x06, 0x4d, 0x65, 0x64, 0x69, 0x75, 0x6d, 0x10, 0x04, 0x12,
-	0x09, 0x0a, 0x05, 0x4c, 0x61, 0x72, 0x67, 0x65, 0x10, 0x05, 0x12, 0x0a, 0x0a, 0x06, 0x58, 0x4c,
-	0x61, 0x72, 0x67, 0x65, 0x10, 0x06, 0x12, 0x0b, 0x0a, 0x07, 0x58, 0x4c, 0x61, 0x72, 0x67, 0x65,
-	0x32, 0x10, 0x07, 0x12, 0x0b, 0x0a, 0x07, 0x58, 0x4c, 0x61, 0x72, 0x67, 0x65, 0x33, 0x10, 0x08,
-	0x12, 0x0b, 0x0a, 0x07, 0x58, 0x4c, 0x61, 0x72, 0x67, 0x65, 0x34, 0x10, 0x09, 0x32, 0xcc, 0x01,
-	0x0a, 0x05, 0x53, 0x68, 0x6f, 0x65, 0x73, 0x12, 0x5c, 0x0a, 0x0b, 0x41, 0x64, 0x64, 0x49, 0x6e,
-	0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x24, 0x2e, 0x77, 0x68, 0x79, 0x77, 0x61, 0x69, 0x74,
+	0x09, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x6c, 0x61,
+	0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0f, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x50, 0x61,
+	0x72, 0x61, 0x6d, 0x73, 0x12, 0x4e, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x32, 0x2e, 0x77, 0x68, 0x79, 0x77, 0x61, 0x69, 0x74,
 	0x61, 0x2e, 0x6d, 0x79, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x2e, 0x41, 0x64, 0x64, 0x49, 0x6e, 0x73,
-	0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x25, 0x2e, 0x77,
+	0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x4d, 0x65, 0x74,
+	0x61, 0x64, 0x61, 0x74, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x1a, 0x3b, 0x0a, 0x0d, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x22, 0xb3, 0x01, 0x0a, 0x13, 0x41, 0x64, 0x64, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63,
+	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x6c, 0x6f,
+	0x75, 0x64, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6c, 0x6f,
+	0x75, 0x64, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x5f, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x69, 0x70, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x69, 0x70, 0x41, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x12, 0x43, 0x0a, 0x0d, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1e, 0x2e, 0x77, 0x68, 0x79, 0x77,
+	0x61, 0x69, 0x74, 0x61, 0x2e, 0x6d, 0x79, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x2e, 0x52, 0x65, 0x73,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0c, 0x72, 0x65, 0x73, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x22, 0x82, 0x01, 0x0a, 0x15, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06,
+	0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x6c, 0x61,
+	0x62, 0x65, 0x6c, 0x73, 0x12, 0x36, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x1e, 0x2e, 0x77, 0x68, 0x79, 0x77, 0x61, 0x69, 0x74, 0x61, 0x2e,
+	0x6d, 0x79, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65,
+	0x61, 0x73, 0x6f, 0x6e, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x22, 0x18, 0x0a, 0x16,
+	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x67, 0x0a, 0x16, 0x47, 0x65, 0x74, 0x43, 0x61, 0x70,
+	0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x29, 0x0a, 0x10, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x5f, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x63, 0x6f, 0x6c, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x22, 0x0a, 0x0c, 0x63,
+	0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x22,
+	0x9a, 0x01, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74,
+	0x69, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x30, 0x0a, 0x14, 0x6d, 0x69, 0x6e, 0x5f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x12, 0x6d, 0x69, 0x6e, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x22, 0x0a, 0x0c, 0x63, 0x61, 0x70, 0x61,
+	0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c,
+	0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x22, 0x14, 0x0a, 0x12,
+	0x47, 0x65, 0x74, 0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x22, 0x63, 0x0a, 0x13, 0x47, 0x65, 0x74, 0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74,
+	0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x63, 0x75, 0x72,
+	0x72, 0x65, 0x6e, 0x74, 0x5f, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x0f, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x43, 0x61, 0x70, 0x61,
+	0x63, 0x69, 0x74, 0x79, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x5f, 0x63, 0x61, 0x70, 0x61,
+	0x63, 0x69, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x43,
+	0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x2a, 0x85, 0x01, 0x0a, 0x0c, 0x52, 0x65, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x6e, 0x6b, 0x6e,
+	0x6f, 0x77, 0x6e, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x4e, 0x61, 0x6e, 0x6f, 0x10, 0x01, 0x12,
+	0x09, 0x0a, 0x05, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x10, 0x02, 0x12, 0x09, 0x0a, 0x05, 0x53, 0x6d,
+	0x61, 0x6c, 0x6c, 0x10, 0x03, 0x12, 0x0a, 0x0a, 0x06, 0x4d, 0x65, 0x64, 0x69, 0x75, 0x6d, 0x10,
+	0x04, 0x12, 0x09, 0x0a, 0x05, 0x4c, 0x61, 0x72, 0x67, 0x65, 0x10, 0x05, 0x12, 0x0a, 0x0a, 0x06,
+	0x58, 0x4c, 0x61, 0x72, 0x67, 0x65, 0x10, 0x06, 0x12, 0x0b, 0x0a, 0x07, 0x58, 0x4c, 0x61, 0x72,
+	0x67, 0x65, 0x32, 0x10, 0x07, 0x12, 0x0b, 0x0a, 0x07, 0x58, 0x4c, 0x61, 0x72, 0x67, 0x65, 0x33,
+	0x10, 0x08, 0x12, 0x0b, 0x0a, 0x07, 0x58, 0x4c, 0x61, 0x72, 0x67, 0x65, 0x34, 0x10, 0x09, 0x2a,
+	0x5f, 0x0a, 0x0c, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12,
+	0x11, 0x0a, 0x0d, 0x55, 0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e,
+	0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x4a, 0x6f, 0x62, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74,
+	0x65, 0x64, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x5a, 0x6f, 0x6d, 0x62, 0x69, 0x65, 0x10, 0x02,
+	0x12, 0x07, 0x0a, 0x03, 0x54, 0x54, 0x4c, 0x10, 0x03, 0x12, 0x09, 0x0a, 0x05, 0x44, 0x72, 0x61,
+	0x69, 0x6e, 0x10, 0x04, 0x12, 0x0a, 0x0a, 0x06, 0x4d, 0x61, 0x6e, 0x75, 0x61, 0x6c, 0x10, 0x05,
+	0x32, 0x94, 0x03, 0x0a, 0x05, 0x53, 0x68, 0x6f, 0x65, 0x73, 0x12, 0x5c, 0x0a, 0x0b, 0x41, 0x64,
+	0x64, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x24, 0x2e, 0x77, 0x68, 0x79, 0x77,
+	0x61, 0x69, 0x74, 0x61, 0x2e, 0x6d, 0x79, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x2e, 0x41, 0x64, 0x64,
+	0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x25, 0x2e, 0x77, 0x68, 0x79, 0x77, 0x61, 0x69, 0x74, 0x61, 0x2e, 0x6d, 0x79, 0x73, 0x68, 0x6f,
+	0x65, 0x73, 0x2e, 0x41, 0x64, 0x64, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x65, 0x0a, 0x0e, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x27, 0x2e, 0x77, 0x68, 0x79,
+	0x77, 0x61, 0x69, 0x74, 0x61, 0x2e, 0x6d, 0x79, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x2e, 0x44, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x28, 0x2e, 0x77, 0x68, 0x79, 0x77, 0x61, 0x69, 0x74, 0x61, 0x2e, 0x6d,
+	0x79, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x49, 0x6e, 0x73,
+	0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12,
+	0x68, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69,
+	0x65, 0x73, 0x12, 0x28, 0x2e, 0x77, 0x68, 0x79, 0x77, 0x61, 0x69, 0x74, 0x61, 0x2e, 0x6d, 0x79,
+	0x73, 0x68, 0x6f, 0x65, 0x73, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c,
+	0x69, 0x74, 0x69, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x29, 0x2e, 0x77,
 	0x68, 0x79, 0x77, 0x61, 0x69, 0x74, 0x61, 0x2e, 0x6d, 0x79, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x2e,
-	0x41, 0x64, 0x64, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x65, 0x0a, 0x0e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x49,
-	0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x27, 0x2e, 0x77, 0x68, 0x79, 0x77, 0x61, 0x69,
-	0x74, 0x61, 0x2e, 0x6d, 0x79, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74,
-	0x65, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x28, 0x2e, 0x77, 0x68, 0x79, 0x77, 0x61, 0x69, 0x74, 0x61, 0x2e, 0x6d, 0x79, 0x73, 0x68,
-	0x6f, 0x65, 0x73, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e,
-	0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x2a, 0x5a, 0x28,
-	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x77, 0x68, 0x79, 0x77, 0x61,
-	0x69, 0x74, 0x61, 0x2f, 0x6d, 0x79, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x2f, 0x61, 0x70, 0x69, 0x2f,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x67, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x47, 0x65, 0x74, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x5c, 0x0a, 0x0b, 0x47, 0x65, 0x74,
+	0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x12, 0x24, 0x2e, 0x77, 0x68, 0x79, 0x77, 0x61,
+	0x69, 0x74, 0x61, 0x2e, 0x6d, 0x79, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x2e, 0x47, 0x65, 0x74, 0x43,
+	0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x25,
+	0x2e, 0x77, 0x68, 0x79, 0x77, 0x61, 0x69, 0x74, 0x61, 0x2e, 0x6d, 0x79, 0x73, 0x68, 0x6f, 0x65,
+	0x73, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75,
+	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x77, 0x68, 0x79, 0x77, 0x61, 0x69, 0x74, 0x61, 0x2f, 0x6d,
+	0x79, 0x73, 0x68, 0x6f, 0x65, 0x73, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2e, 0x67, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -398,27 +749,39 @@ func file_myshoes_proto_rawDescGZIP() []byte {
 	return file_myshoes_proto_rawDescData
 }
 
-var file_myshoes_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_myshoes_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_myshoes_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_myshoes_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_myshoes_proto_goTypes = []interface{}{
-	(ResourceType)(0),              // 0: whywaita.myshoes.ResourceType
-	(*AddInstanceRequest)(nil),     // 1: whywaita.myshoes.AddInstanceRequest
-	(*AddInstanceResponse)(nil),    // 2: whywaita.myshoes.AddInstanceResponse
-	(*DeleteInstanceRequest)(nil),  // 3: whywaita.myshoes.DeleteInstanceRequest
-	(*DeleteInstanceResponse)(nil), // 4: whywaita.myshoes.DeleteInstanceResponse
+	(ResourceType)(0),               // 0: whywaita.myshoes.ResourceType
+	(DeleteReason)(0),               // 1: whywaita.myshoes.DeleteReason
+	(*AddInstanceRequest)(nil),      // 2: whywaita.myshoes.AddInstanceRequest
+	(*AddInstanceResponse)(nil),     // 3: whywaita.myshoes.AddInstanceResponse
+	(*DeleteInstanceRequest)(nil),   // 4: whywaita.myshoes.DeleteInstanceRequest
+	(*DeleteInstanceResponse)(nil),  // 5: whywaita.myshoes.DeleteInstanceResponse
+	(*GetCapabilitiesRequest)(nil),  // 6: whywaita.myshoes.GetCapabilitiesRequest
+	(*GetCapabilitiesResponse)(nil), // 7: whywaita.myshoes.GetCapabilitiesResponse
+	(*GetCapacityRequest)(nil),      // 8: whywaita.myshoes.GetCapacityRequest
+	(*GetCapacityResponse)(nil),     // 9: whywaita.myshoes.GetCapacityResponse
+	nil,                             // 10: whywaita.myshoes.AddInstanceRequest.MetadataEntry
 }
 var file_myshoes_proto_depIdxs = []int32{
-	0, // 0: whywaita.myshoes.AddInstanceRequest.resource_type:type_name -> whywaita.myshoes.ResourceType
-	0, // 1: whywaita.myshoes.AddInstanceResponse.resource_type:type_name -> whywaita.myshoes.ResourceType
-	1, // 2: whywaita.myshoes.Shoes.AddInstance:input_type -> whywaita.myshoes.AddInstanceRequest
-	3, // 3: whywaita.myshoes.Shoes.DeleteInstance:input_type -> whywaita.myshoes.DeleteInstanceRequest
-	2, // 4: whywaita.myshoes.Shoes.AddInstance:output_type -> whywaita.myshoes.AddInstanceResponse
-	4, // 5: whywaita.myshoes.Shoes.DeleteInstance:output_type -> whywaita.myshoes.DeleteInstanceResponse
-	4, // [4:6] is the sub-list for method output_type
-	2, // [2:4] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	0,  // 0: whywaita.myshoes.AddInstanceRequest.resource_type:type_name -> whywaita.myshoes.ResourceType
+	10, // 1: whywaita.myshoes.AddInstanceRequest.metadata:type_name -> whywaita.myshoes.AddInstanceRequest.MetadataEntry
+	0,  // 2: whywaita.myshoes.AddInstanceResponse.resource_type:type_name -> whywaita.myshoes.ResourceType
+	1,  // 3: whywaita.myshoes.DeleteInstanceRequest.reason:type_name -> whywaita.myshoes.DeleteReason
+	2,  // 4: whywaita.myshoes.Shoes.AddInstance:input_type -> whywaita.myshoes.AddInstanceRequest
+	4,  // 5: whywaita.myshoes.Shoes.DeleteInstance:input_type -> whywaita.myshoes.DeleteInstanceRequest
+	6,  // 6: whywaita.myshoes.Shoes.GetCapabilities:input_type -> whywaita.myshoes.GetCapabilitiesRequest
+	8,  // 7: whywaita.myshoes.Shoes.GetCapacity:input_type -> whywaita.myshoes.GetCapacityRequest
+	3,  // 8: whywaita.myshoes.Shoes.AddInstance:output_type -> whywaita.myshoes.AddInstanceResponse
+	5,  // 9: whywaita.myshoes.Shoes.DeleteInstance:output_type -> whywaita.myshoes.DeleteInstanceResponse
+	7,  // 10: whywaita.myshoes.Shoes.GetCapabilities:output_type -> whywaita.myshoes.GetCapabilitiesResponse
+	9,  // 11: whywaita.myshoes.Shoes.GetCapacity:output_type -> whywaita.myshoes.GetCapacityResponse
+	8,  // [8:12] is the sub-list for method output_type
+	4,  // [4:8] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_myshoes_proto_init() }
@@ -475,14 +838,62 @@ func file_myshoes_proto_init() {
 				return nil
 			}
 		}
+		file_myshoes_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetCapabilitiesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_myshoes_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetCapabilitiesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_myshoes_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetCapacityRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_myshoes_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetCapacityResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_myshoes_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   4,
+			NumEnums:      2,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,8 +19,10 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	Shoes_AddInstance_FullMethodName    = "/whywaita.myshoes.Shoes/AddInstance"
-	Shoes_DeleteInstance_FullMethodName = "/whywaita.myshoes.Shoes/DeleteInstance"
+	Shoes_AddInstance_FullMethodName     = "/whywaita.myshoes.Shoes/AddInstance"
+	Shoes_DeleteInstance_FullMethodName  = "/whywaita.myshoes.Shoes/DeleteInstance"
+	Shoes_GetCapabilities_FullMethodName = "/whywaita.myshoes.Shoes/GetCapabilities"
+	Shoes_GetCapacity_FullMethodName     = "/whywaita.myshoes.Shoes/GetCapacity"
 )
 
 // ShoesClient is the client API for Shoes service.
@@ -29,6 +31,12 @@ const (
 type ShoesClient interface {
 	AddInstance(ctx context.Context, in *AddInstanceRequest, opts ...grpc.CallOption) (*AddInstanceResponse, error)
 	DeleteInstance(ctx context.Context, in *DeleteInstanceRequest, opts ...grpc.CallOption) (*DeleteInstanceResponse, error)
+	// GetCapabilities negotiate version of protocol, myshoes use new fields only if provider advertises capability of them.
+	// provider that does not implement it is treated as protocol version 1 without capabilities
+	GetCapabilities(ctx context.Context, in *GetCapabilitiesRequest, opts ...grpc.CallOption) (*GetCapabilitiesResponse, error)
+	// GetCapacity report current and max number of instances of provider, myshoes stops creating instances while provider is saturated.
+	// it is called only if provider advertises "capacity" capability
+	GetCapacity(ctx context.Context, in *GetCapacityRequest, opts ...grpc.CallOption) (*GetCapacityResponse, error)
 }
 
 type shoesClient struct {
@@ -57,12 +65,36 @@ func (c *shoesClient) DeleteInstance(ctx context.Context, in *DeleteInstanceRequ
 	return out, nil
 }
 
+func (c *shoesClient) GetCapabilities(ctx context.Context, in *GetCapabilitiesRequest, opts ...grpc.CallOption) (*GetCapabilitiesResponse, error) {
+	out := new(GetCapabilitiesResponse)
+	err := c.cc.Invoke(ctx, Shoes_GetCapabilities_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shoesClient) GetCapacity(ctx context.Context, in *GetCapacityRequest, opts ...grpc.CallOption) (*GetCapacityResponse, error) {
+	out := new(GetCapacityResponse)
+	err := c.cc.Invoke(ctx, Shoes_GetCapacity_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ShoesServer is the server API for Shoes service.
 // All implementations must embed UnimplementedShoesServer
 // for forward compatibility
 type ShoesServer interface {
 	AddInstance(context.Context, *AddInstanceRequest) (*AddInstanceResponse, error)
 	DeleteInstance(context.Context, *DeleteInstanceRequest) (*DeleteInstanceResponse, error)
+	// GetCapabilities negotiate version of protocol, myshoes use new fields only if provider advertises capability of them.
+	// provider that does not implement it is treated as protocol version 1 without capabilities
+	GetCapabilities(context.Context, *GetCapabilitiesRequest) (*GetCapabilitiesResponse, error)
+	// GetCapacity report current and max number of instances of provider, myshoes stops creating instances while provider is saturated.
+	// it is called only if provider advertises "capacity" capability
+	GetCapacity(context.Context, *GetCapacityRequest) (*GetCapacityResponse, error)
 	mustEmbedUnimplementedShoesServer()
 }
 
@@ -76,6 +108,12 @@ func (UnimplementedShoesServer) AddInstance(context.Context, *AddInstanceRequest
 func (UnimplementedShoesServer) DeleteInstance(context.Context, *DeleteInstanceRequest) (*DeleteInstanceResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteInstance not implemented")
 }
+func (UnimplementedShoesServer) GetCapabilities(context.Context, *GetCapabilitiesRequest) (*GetCapabilitiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCapabilities not implemented")
+}
+func (UnimplementedShoesServer) GetCapacity(context.Context, *GetCapacityRequest) (*GetCapacityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCapacity not implemented")
+}
 func (UnimplementedShoesServer) mustEmbedUnimplementedShoesServer() {}
 
 // UnsafeShoesServer may be embedded to opt out of forward compatibility for this service.
@@ -125,6 +163,42 @@ func _Shoes_DeleteInstance_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Shoes_GetCapabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCapabilitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShoesServer).GetCapabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shoes_GetCapabilities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShoesServer).GetCapabilities(ctx, req.(*GetCapabilitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shoes_GetCapacity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCapacityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShoesServer).GetCapacity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shoes_GetCapacity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShoesServer).GetCapacity(ctx, req.(*GetCapacityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Shoes_ServiceDesc is the grpc.ServiceDesc for Shoes service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -140,6 +214,14 @@ var Shoes_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteInstance",
 			Handler:    _Shoes_DeleteInstance_Handler,
 		},
+		{
+			MethodName: "GetCapabilities",
+			Handler:    _Shoes_GetCapabilities_Handler,
+		},
+		{
+			MethodName: "GetCapacity",
+			Handler:    _Shoes_GetCapacity_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "myshoes.proto",
@@ -2,28 +2,70 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
-	"strings"
-	"time"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/whywaita/myshoes/pkg/config"
 	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/encrypt"
+	"github.com/whywaita/myshoes/pkg/datastore/historystore"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
 	"github.com/whywaita/myshoes/pkg/datastore/mysql"
+	"github.com/whywaita/myshoes/pkg/datastore/retry"
+	"github.com/whywaita/myshoes/pkg/datastore/sqlite"
+	"github.com/whywaita/myshoes/pkg/datastore/trace"
+	"github.com/whywaita/myshoes/pkg/event/export"
 	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/lock"
 	"github.com/whywaita/myshoes/pkg/logger"
+	"github.com/whywaita/myshoes/pkg/profiling"
 	"github.com/whywaita/myshoes/pkg/runner"
+	"github.com/whywaita/myshoes/pkg/scaleset"
+	"github.com/whywaita/myshoes/pkg/shoes"
+	"github.com/whywaita/myshoes/pkg/shoes/docker"
+	"github.com/whywaita/myshoes/pkg/shoes/ec2"
 	"github.com/whywaita/myshoes/pkg/starter"
-	"github.com/whywaita/myshoes/pkg/starter/safety/unlimited"
+	"github.com/whywaita/myshoes/pkg/starter/safety/capacity"
+	"github.com/whywaita/myshoes/pkg/starter/schedule"
+	"github.com/whywaita/myshoes/pkg/starter/schedule/flat"
+	"github.com/whywaita/myshoes/pkg/starter/schedule/window"
+	"github.com/whywaita/myshoes/pkg/watchdog"
 	"github.com/whywaita/myshoes/pkg/web"
 
 	"golang.org/x/sync/errgroup"
 )
 
+var (
+	devMode     = flag.Bool("dev", false, "use on-memory datastore, all data will be lost when myshoes is exited (for development)")
+	migrateDown = flag.Int("migrate-down", 0, "rollback the number of migrations and exit")
+)
+
+// migrator is datastore that has versioned schema
+type migrator interface {
+	Migrate(ctx context.Context) error
+	MigrateDown(ctx context.Context, steps int) error
+}
+
 func init() {
+	shoes.RegisterBuiltin(ec2.Name, ec2.New)
+	shoes.RegisterBuiltin(docker.Name, docker.New)
+}
+
+// setup load configuration, it needs parsed flags
+func setup() {
 	config.Load()
-	mysqlURL := config.LoadMySQLURL()
-	config.Config.MySQLDSN = mysqlURL
+	logger.Setup(config.Config.LogFormat, config.Config.LogLevel)
+	config.Config.SQLitePath = config.LoadSQLitePath()
+	if !*devMode && config.Config.SQLitePath == "" {
+		mysqlURL := config.LoadMySQLURL()
+		config.Config.MySQLDSN = mysqlURL
+		config.Config.MySQLReadDSN = config.LoadMySQLReadURL()
+	}
 
 	if err := gh.InitializeCache(config.Config.GitHub.AppID, config.Config.GitHub.PEMByte); err != nil {
 		log.Panicf("failed to create a cache: %+v", err)
@@ -31,6 +73,16 @@ func init() {
 }
 
 func main() {
+	flag.Parse()
+	setup()
+
+	if *migrateDown > 0 {
+		if err := runMigrateDown(*migrateDown); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
 	myshoes, err := newShoes()
 	if err != nil {
 		log.Fatalln(err)
@@ -43,54 +95,157 @@ func main() {
 
 type myShoes struct {
 	ds    datastore.Datastore
+	lock  lock.Locker
 	start *starter.Starter
 	run   *runner.Manager
 }
 
 // newShoes create myshoes.
 func newShoes() (*myShoes, error) {
+	idGenerator, err := datastore.NewIDGenerator(config.Config.IDGenerator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create id generator: %w", err)
+	}
+	datastore.SetIDGenerator(idGenerator)
+
 	notifyEnqueueCh := make(chan struct{}, 1)
 
-	ds, err := mysql.New(config.Config.MySQLDSN, notifyEnqueueCh)
+	ds, err := newDatastore(notifyEnqueueCh)
 	if err != nil {
-		return nil, fmt.Errorf("failed to mysql.New: %w", err)
+		return nil, fmt.Errorf("failed to create datastore: %w", err)
+	}
+	if m, ok := ds.(migrator); ok && config.Config.AutoMigration {
+		if err := m.Migrate(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to migrate datastore: %w", err)
+		}
+	}
+	// create locker before wrapping, datastore may be lock.Keeper (e.g. MySQL in compat mode)
+	locker, err := lock.New(ds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create locker: %w", err)
+	}
+	if ms, ok := ds.(*mysql.MySQL); ok && config.Config.DatastoreRetryMax > 0 {
+		ds = retry.Wrap(ms, mysql.IsTransient, config.Config.DatastoreRetryMax, config.Config.DatastoreRetryBackoff)
+	}
+	if config.Config.HistoryStoreBackend != "" {
+		store, err := historystore.New(config.Config.HistoryStoreBackend, config.Config.HistoryStoreEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create history store: %w", err)
+		}
+		ds = historystore.Wrap(ds, store)
+	}
+	if config.Config.DatastoreSlowQuery > 0 {
+		ds = trace.Wrap(ds, trace.LogTracer{Threshold: config.Config.DatastoreSlowQuery})
+	}
+	if len(config.Config.EncryptionKey) != 0 {
+		wrapper, err := encrypt.NewLocalKeyWrapper(config.Config.EncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create key wrapper: %w", err)
+		}
+		ds = encrypt.Wrap(ds, encrypt.New(wrapper))
 	}
 
-	unlimit := unlimited.Unlimited{}
-	s := starter.New(ds, unlimit, config.Config.RunnerVersion, notifyEnqueueCh)
+	var sc schedule.Schedule = flat.Flat{}
+	if len(config.Config.CostWindows) > 0 {
+		sc, err = window.New(config.Config.CostWindows, config.Config.CostScheduleLocation, config.Config.CostScheduleMaxDelay, config.Config.CostScheduleLabel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cost schedule: %w", err)
+		}
+	}
+	s := starter.New(ds, capacity.New(ds), sc, config.Config.RunnerVersion, notifyEnqueueCh)
 
 	manager := runner.New(ds, config.Config.RunnerVersion)
 
 	return &myShoes{
 		ds:    ds,
+		lock:  locker,
 		start: s,
 		run:   manager,
 	}, nil
 }
 
-// Run start services.
-func (m *myShoes) Run() error {
-	eg, ctx := errgroup.WithContext(context.Background())
-
-	for {
-		logger.Logf(false, "start getting lock...")
-		isLocked, err := m.ds.IsLocked(ctx)
+// newDatastore create datastore. use on-memory if dev mode, SQLite if SQLITE_PATH is set, MySQL if not.
+func newDatastore(notifyEnqueueCh chan<- struct{}) (datastore.Datastore, error) {
+	if *devMode {
+		logger.Logf(false, "WARNING: dev mode is enabled, use on-memory datastore")
+		ds, err := memory.New(notifyEnqueueCh)
 		if err != nil {
-			return fmt.Errorf("failed to check lock: %w", err)
+			return nil, fmt.Errorf("failed to memory.New: %w", err)
 		}
+		return ds, nil
+	}
 
-		if strings.EqualFold(isLocked, datastore.IsNotLocked) {
-			if err := m.ds.GetLock(ctx); err != nil {
-				return fmt.Errorf("failed to get lock: %w", err)
-			}
+	if config.Config.SQLitePath != "" {
+		ds, err := sqlite.New(config.Config.SQLitePath, notifyEnqueueCh)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sqlite.New: %w", err)
+		}
+		return ds, nil
+	}
 
-			logger.Logf(false, "get lock successfully!")
-			break
+	ds, err := mysql.New(config.Config.MySQLDSN, notifyEnqueueCh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mysql.New: %w", err)
+	}
+	if config.Config.MySQLReadDSN != "" {
+		if err := ds.ConnectReadReplica(config.Config.MySQLReadDSN); err != nil {
+			return nil, fmt.Errorf("failed to connect read replica: %w", err)
 		}
+	}
+	return ds, nil
+}
 
-		time.Sleep(time.Second)
+// runMigrateDown rollback migrations of datastore
+func runMigrateDown(steps int) error {
+	ds, err := newDatastore(nil)
+	if err != nil {
+		return fmt.Errorf("failed to create datastore: %w", err)
+	}
+	m, ok := ds.(migrator)
+	if !ok {
+		return fmt.Errorf("datastore is not support migration")
 	}
+	if err := m.MigrateDown(context.Background(), steps); err != nil {
+		return fmt.Errorf("failed to rollback migrations: %w", err)
+	}
+	return nil
+}
 
+// Run start services. all services are stopped by SIGINT or SIGTERM
+func (m *myShoes) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	eg, ctx := errgroup.WithContext(ctx)
+
+	if gh.IsPATMode() {
+		logger.Logf(false, "authenticate by a personal access token, it is limited (shared rate limit, no installation discovery and sync of webhook deliveries)")
+	}
+	if _, err := runner.GetIdentityVerifier(config.Config.RunnerIdentityVerification); err != nil {
+		return fmt.Errorf("invalid %s: %w", config.EnvRunnerIdentityVerification, err)
+	}
+	if err := starter.ValidateResourceTypeLabels(); err != nil {
+		return fmt.Errorf("invalid %s: %w", config.EnvResourceTypeLabels, err)
+	}
+	if err := shoes.ValidatePluginRoutes(); err != nil {
+		return fmt.Errorf("invalid %s: %w", config.EnvShoesPluginRoutes, err)
+	}
+	if err := shoes.ValidateBuiltins(); err != nil {
+		return fmt.Errorf("invalid built-in provider: %w", err)
+	}
+
+	// optional features are gated by version of GHES
+	if err := gh.DetectGHESVersion(ctx); err != nil {
+		logger.Logf(false, "failed to detect version of GitHub Enterprise Server, optional features are not gated: %+v", err)
+	}
+	// misconfigured GitHub Apps fail in provisioning with cryptic errors, So check it before serving
+	if err := gh.ValidateAppPermissions(ctx); err != nil {
+		if config.Config.Strict && errors.Is(err, gh.ErrInsufficientPermissions) {
+			return fmt.Errorf("invalid GitHub Apps: %w", err)
+		}
+		logger.Logf(false, "WARNING: failed to validate permissions of GitHub Apps: %+v", err)
+	}
+
+	// all replicas serve webhook and REST API, jobs are stored in datastore
 	eg.Go(func() error {
 		if err := web.Serve(ctx, m.ds); err != nil {
 			logger.Logf(false, "failed to web.Serve: %+v", err)
@@ -99,19 +254,115 @@ func (m *myShoes) Run() error {
 		return nil
 	})
 	eg.Go(func() error {
-		if err := m.start.Loop(ctx); err != nil {
+		if err := gh.LoopRecoveryProbe(ctx); err != nil {
+			logger.Logf(false, "failed to GitHub recovery probe: %+v", err)
+			return fmt.Errorf("failed to GitHub recovery probe loop: %w", err)
+		}
+		return nil
+	})
+	eg.Go(func() error {
+		if err := gh.LoopRefreshInstallationTokens(ctx); err != nil {
+			logger.Logf(false, "failed to refresh installation tokens: %+v", err)
+			return fmt.Errorf("failed to refresh installation tokens loop: %w", err)
+		}
+		return nil
+	})
+	eg.Go(func() error {
+		if err := profiling.Start(ctx); err != nil {
+			logger.Logf(false, "failed to continuous profiling: %+v", err)
+			return fmt.Errorf("failed to continuous profiling: %w", err)
+		}
+		return nil
+	})
+	if config.Config.EventExportBackend != "" {
+		exporter, err := export.New(config.Config.EventExportBackend, config.Config.EventExportEndpoint)
+		if err != nil {
+			return fmt.Errorf("failed to create event exporter: %w", err)
+		}
+		eg.Go(func() error {
+			return export.Run(ctx, exporter, config.Config.EventExportPrefix)
+		})
+	}
+	// only leader process jobs and runners, standby replicas take over if leader is lost
+	eg.Go(func() error {
+		if err := lock.NewElector(m.lock).Run(ctx, m.lead); err != nil {
+			logger.Logf(false, "failed to leader election: %+v", err)
+			return fmt.Errorf("failed to leader election: %w", err)
+		}
+		return nil
+	})
+
+	if err := eg.Wait(); err != nil {
+		return fmt.Errorf("failed to wait errgroup: %w", err)
+	}
+
+	return nil
+}
+
+// lead start loops that need to run only in leader. ctx is canceled when leadership is lost
+func (m *myShoes) lead(ctx context.Context) error {
+	eg, ctx := errgroup.WithContext(ctx)
+
+	eg.Go(func() error {
+		if err := watchdog.Supervise(ctx, "starter", config.Config.LoopWatchdogTimeout, m.start.Loop); err != nil {
 			logger.Logf(false, "failed to starter manager: %+v", err)
 			return fmt.Errorf("failed to starter loop: %w", err)
 		}
 		return nil
 	})
 	eg.Go(func() error {
-		if err := m.run.Loop(ctx); err != nil {
+		if err := watchdog.Supervise(ctx, "runner", config.Config.LoopWatchdogTimeout, m.run.Loop); err != nil {
 			logger.Logf(false, "failed to runner manager: %+v", err)
 			return fmt.Errorf("failed to runner loop: %w", err)
 		}
 		return nil
 	})
+	eg.Go(func() error {
+		// plugin processes are used only in leader, killed when leadership is lost
+		if err := shoes.LoopHealthCheck(ctx, config.Config.PluginHealthInterval); err != nil {
+			logger.Logf(false, "failed to health check of plugins: %+v", err)
+			return fmt.Errorf("failed to health check of plugins loop: %w", err)
+		}
+		return nil
+	})
+	if config.Config.ScaleSetName != "" {
+		eg.Go(func() error {
+			if err := scaleset.New(m.ds, config.Config.ScaleSetName, config.Config.ScaleSetRunnerGroupID).Loop(ctx); err != nil {
+				logger.Logf(false, "failed to scale set manager: %+v", err)
+				return fmt.Errorf("failed to scale set loop: %w", err)
+			}
+			return nil
+		})
+	}
+	eg.Go(func() error {
+		// jobs that queued while no leader is running are recovered from webhook deliveries
+		if err := web.LoopSyncDeliveries(ctx, m.ds, config.Config.WebhookSyncLookback, config.Config.WebhookSyncInterval); err != nil {
+			logger.Logf(false, "failed to sync webhook deliveries: %+v", err)
+			return fmt.Errorf("failed to sync webhook deliveries loop: %w", err)
+		}
+		return nil
+	})
+	eg.Go(func() error {
+		if err := web.LoopPollQueuedJobs(ctx, m.ds, config.Config.QueuedJobPollInterval); err != nil {
+			logger.Logf(false, "failed to poll queued jobs: %+v", err)
+			return fmt.Errorf("failed to poll queued jobs loop: %w", err)
+		}
+		return nil
+	})
+	eg.Go(func() error {
+		if err := web.LoopDiscoverTargets(ctx, m.ds, config.Config.TargetAutoRegisterResourceType, config.Config.TargetAutoRegisterInterval); err != nil {
+			logger.Logf(false, "failed to discover targets: %+v", err)
+			return fmt.Errorf("failed to discover targets loop: %w", err)
+		}
+		return nil
+	})
+	eg.Go(func() error {
+		if err := datastore.RunJanitor(ctx, m.ds, config.Config.JobRetention, config.Config.RunnerHistoryRetention); err != nil {
+			logger.Logf(false, "failed to datastore janitor: %+v", err)
+			return fmt.Errorf("failed to datastore janitor loop: %w", err)
+		}
+		return nil
+	})
 
 	if err := eg.Wait(); err != nil {
 		return fmt.Errorf("failed to wait errgroup: %w", err)
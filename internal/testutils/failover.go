@@ -0,0 +1,406 @@
+package testutils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/lock"
+	"github.com/whywaita/myshoes/pkg/runner"
+	"github.com/whywaita/myshoes/pkg/shoes"
+	"github.com/whywaita/myshoes/pkg/starter"
+	"github.com/whywaita/myshoes/pkg/starter/safety/unlimited"
+	"github.com/whywaita/myshoes/pkg/starter/schedule/flat"
+)
+
+const (
+	// failoverRounds is number of leader kills in a run of RunFailover
+	failoverRounds = 5
+	// failoverJobs is number of jobs that are enqueued in a round
+	failoverJobs = 20
+	// failoverShoesName is name of built-in shoes-provider that record dispatched jobs
+	failoverShoesName = "failover"
+	// failoverRunnerVersion is version of runner in setup script, it is not fetched from GitHub
+	failoverRunnerVersion = "v2.311.0"
+)
+
+// FailoverBackend is lock backend that is shared by replicas in RunFailover
+type FailoverBackend struct {
+	// Addr is address of backend, replicas connect to it through a proxy that is killed to partition leader
+	Addr string
+	// TTL is TTL of lock, it is set to LOCK_TTL
+	TTL time.Duration
+	// NewLocker create locker of a replica that connects to backend by addr (address of the proxy)
+	NewLocker func(t *testing.T, addr string) lock.Locker
+}
+
+// RunFailover run replicas of myshoes that run starter.Loop while they are leader, partition leader from lock backend
+// while jobs are enqueued to ds, and verify that there is never more than one leader, standby take over and all jobs
+// are dispatched to shoes-provider exactly once. ds is shared by replicas, it is reachable from partitioned leader.
+func RunFailover(t *testing.T, ds datastore.Datastore, backend FailoverBackend) {
+	t.Helper()
+
+	oldConfig, oldInterval := config.Config, lock.ElectionInterval
+	t.Cleanup(func() {
+		config.Config, lock.ElectionInterval = oldConfig, oldInterval
+	})
+	config.Config.GitHubURL = "https://github.com"
+	config.Config.ShoesPluginPath = config.BuiltinPluginPrefix + failoverShoesName
+	config.Config.RunnerTokenDelivery = config.RunnerTokenDeliveryCallback
+	config.Config.GitHub.AppSecret = []byte("secret")
+	config.Config.MaxConnectionsToBackend = 50
+	config.Config.Strict = false
+	config.Config.JobTTL = 0
+	config.Config.LockTTL = backend.TTL
+	lock.ElectionInterval = 10 * time.Millisecond
+
+	ctx := context.Background()
+	targetID := datastore.NewID()
+	if err := ds.CreateTarget(ctx, datastore.Target{
+		UUID:           targetID,
+		Scope:          "octocat/hello-world",
+		GitHubToken:    "gh-token",
+		TokenExpiredAt: time.Now().Add(time.Hour),
+		ResourceType:   datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	c := &failoverCluster{t: t, ds: ds, backend: backend, shoes: registerFailoverShoes()}
+	for _, name := range []string{"myshoes-0", "myshoes-1"} {
+		c.start(name)
+	}
+	defer c.stop()
+
+	var enqueued []uuid.UUID
+	leader := c.waitLeader(nil)
+	for round := 0; round < failoverRounds; round++ {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < failoverJobs; j++ {
+				job := datastore.Job{
+					UUID:           datastore.NewID(),
+					Repository:     "octocat/hello-world",
+					CheckEventJSON: fmt.Sprintf(`{"action":"queued","workflow_job":{"id":%d,"run_id":1,"labels":["self-hosted"]}}`, round*failoverJobs+j+1),
+					TargetID:       targetID,
+				}
+				if _, err := ds.EnqueueJob(ctx, job); err != nil {
+					t.Errorf("failed to enqueue job: %+v", err)
+					return
+				}
+				mu.Lock()
+				enqueued = append(enqueued, job.UUID)
+				mu.Unlock()
+				c.notify()
+				time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+			}
+		}()
+
+		// kill leader in the middle of enqueueing
+		time.Sleep(time.Duration(rand.Intn(50)) * time.Millisecond)
+		c.kill(leader)
+		newLeader := c.waitLeader(leader)
+
+		// restart killed replica as a new standby
+		c.start(fmt.Sprintf("myshoes-%d", round+2))
+		wg.Wait()
+		leader = newLeader
+	}
+
+	deadline := time.Now().Add(10 * backend.TTL)
+	for {
+		jobs, err := ds.ListJobs(ctx, datastore.ListOption{})
+		if err != nil {
+			t.Fatalf("failed to list jobs: %+v", err)
+		}
+		if len(jobs) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d jobs are lost, they are not dispatched", len(jobs))
+		}
+		c.notify()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	dispatched := c.shoes.dispatched()
+	if len(dispatched) != len(enqueued) {
+		t.Errorf("want %d jobs are dispatched, but got %d", len(enqueued), len(dispatched))
+	}
+	for _, id := range enqueued {
+		by, ok := dispatched[runner.ToName(id.String())]
+		switch {
+		case !ok:
+			t.Errorf("job is lost (job ID: %s)", id)
+		case len(by) != 1:
+			t.Errorf("job is dispatched %d times (job ID: %s, replicas: %v)", len(by), id, by)
+		}
+	}
+}
+
+// failoverCluster is replicas of myshoes that share a datastore and a lock backend
+type failoverCluster struct {
+	t       *testing.T
+	ds      datastore.Datastore
+	backend FailoverBackend
+	shoes   *failoverShoes
+
+	mu       sync.Mutex
+	replicas []*failoverReplica
+	leader   *failoverReplica
+}
+
+// failoverReplica is a replica of myshoes in failoverCluster
+type failoverReplica struct {
+	name     string
+	proxy    *chaosProxy
+	notifyCh chan struct{}
+	leading  atomic.Bool
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// replicaKey is key of context that has name of replica, shoes-provider record it
+type replicaKey struct{}
+
+// start run a replica that run starter.Loop while it is leader
+func (c *failoverCluster) start(name string) {
+	proxy := newChaosProxy(c.t, c.backend.Addr)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), replicaKey{}, name))
+	r := &failoverReplica{
+		name:     name,
+		proxy:    proxy,
+		notifyCh: make(chan struct{}, 1),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	s := starter.New(c.ds, unlimited.Unlimited{}, flat.Flat{}, failoverRunnerVersion, r.notifyCh)
+	elector := lock.NewElector(c.backend.NewLocker(c.t, proxy.l.Addr().String()))
+
+	c.mu.Lock()
+	c.replicas = append(c.replicas, r)
+	c.mu.Unlock()
+
+	go func() {
+		defer close(r.done)
+		err := elector.Run(ctx, func(ctx context.Context) error {
+			c.lead(r)
+			defer r.leading.Store(false)
+			return s.Loop(ctx)
+		})
+		if err != nil {
+			c.t.Logf("elector of %s is stopped: %+v", name, err)
+		}
+	}()
+}
+
+// lead mark r as leader, and check that other replica is not leader
+func (c *failoverCluster) lead(r *failoverReplica) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, other := range c.replicas {
+		if other != r && other.leading.Load() {
+			c.t.Errorf("split brain: %s become leader while %s is leader", r.name, other.name)
+		}
+	}
+	r.leading.Store(true)
+	c.leader = r
+}
+
+// kill cut connections of a replica to lock backend without releasing lock, like a network partition.
+// the replica keeps running and dispatching jobs until it notices that the lock is lost by itself.
+func (c *failoverCluster) kill(r *failoverReplica) {
+	r.proxy.kill()
+}
+
+// notify wake up starters of all replicas, as same as enqueueing a job notify starter
+func (c *failoverCluster) notify() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, r := range c.replicas {
+		select {
+		case r.notifyCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// waitLeader wait until a replica except exclude is leader, and return it
+func (c *failoverCluster) waitLeader(exclude *failoverReplica) *failoverReplica {
+	deadline := time.Now().Add(10 * c.backend.TTL)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		leader := c.leader
+		c.mu.Unlock()
+		if leader != nil && leader != exclude && leader.leading.Load() {
+			return leader
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.t.Fatalf("standby does not take over leader in %s", 10*c.backend.TTL)
+	return nil
+}
+
+// stop stop all replicas, and wait for them
+func (c *failoverCluster) stop() {
+	c.mu.Lock()
+	replicas := c.replicas
+	c.mu.Unlock()
+	for _, r := range replicas {
+		r.cancel()
+		r.proxy.kill()
+	}
+	for _, r := range replicas {
+		<-r.done
+	}
+}
+
+// failoverShoes is built-in shoes-provider that record replicas that create an instance for each runner
+type failoverShoes struct {
+	mu   sync.Mutex
+	runs map[string][]string // key: runner name, value: names of replicas
+}
+
+var (
+	failoverShoesOnce     sync.Once
+	failoverShoesProvider = &failoverShoes{}
+)
+
+// registerFailoverShoes register failoverShoes as built-in provider, and reset records of it
+func registerFailoverShoes() *failoverShoes {
+	failoverShoesOnce.Do(func() {
+		shoes.RegisterBuiltin(failoverShoesName, func() (shoes.Client, error) {
+			return failoverShoesProvider, nil
+		})
+	})
+	failoverShoesProvider.mu.Lock()
+	defer failoverShoesProvider.mu.Unlock()
+	failoverShoesProvider.runs = map[string][]string{}
+	return failoverShoesProvider
+}
+
+func (f *failoverShoes) dispatched() map[string][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	runs := make(map[string][]string, len(f.runs))
+	for k, v := range f.runs {
+		runs[k] = v
+	}
+	return runs
+}
+
+// AddInstance record runner, instance is not created if request is canceled
+func (f *failoverShoes) AddInstance(ctx context.Context, runnerName, setupScript string, resourceType datastore.ResourceType, labels []string, placementParams string, metadata map[string]string) (string, string, string, datastore.ResourceType, error) {
+	if err := ctx.Err(); err != nil {
+		return "", "", "", datastore.ResourceTypeUnknown, err
+	}
+	replica, _ := ctx.Value(replicaKey{}).(string)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.runs[runnerName] = append(f.runs[runnerName], replica)
+	return "cloud-" + runnerName, "127.0.0.1", failoverShoesName, resourceType, nil
+}
+
+// DeleteInstance do nothing
+func (f *failoverShoes) DeleteInstance(ctx context.Context, cloudID string, labels []string, reason shoes.DeleteReason) error {
+	return nil
+}
+
+// Capabilities return no capability
+func (f *failoverShoes) Capabilities() shoes.Capabilities {
+	return shoes.NewCapabilities()
+}
+
+// GetCapacity is not supported
+func (f *failoverShoes) GetCapacity(ctx context.Context) (shoes.Capacity, error) {
+	return shoes.Capacity{}, shoes.ErrCapacityNotSupported
+}
+
+// chaosProxy forward connections to lock backend. kill drop all connections and refuse new connections
+// to emulate a partitioned instance, so lock of the instance is not released and expired by TTL.
+type chaosProxy struct {
+	l      net.Listener
+	target string
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+func newChaosProxy(t *testing.T, target string) *chaosProxy {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %+v", err)
+	}
+	p := &chaosProxy{l: l, target: target, conns: map[net.Conn]struct{}{}}
+	go p.serve()
+	return p
+}
+
+func (p *chaosProxy) serve() {
+	for {
+		conn, err := p.l.Accept()
+		if err != nil {
+			return
+		}
+		upstream, err := net.Dial("tcp", p.target)
+		if err != nil {
+			conn.Close()
+			continue
+		}
+		if !p.track(conn, upstream) {
+			conn.Close()
+			upstream.Close()
+			return
+		}
+		go func() {
+			defer p.untrack(conn, upstream)
+			go io.Copy(upstream, conn)
+			io.Copy(conn, upstream)
+		}()
+	}
+}
+
+func (p *chaosProxy) track(conns ...net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conns == nil {
+		return false
+	}
+	for _, c := range conns {
+		p.conns[c] = struct{}{}
+	}
+	return true
+}
+
+func (p *chaosProxy) untrack(conns ...net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, c := range conns {
+		c.Close()
+		delete(p.conns, c)
+	}
+}
+
+func (p *chaosProxy) kill() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.l.Close()
+	for c := range p.conns {
+		c.Close()
+	}
+	p.conns = nil
+}
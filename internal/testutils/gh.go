@@ -0,0 +1,33 @@
+package testutils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/gh"
+)
+
+// RegisterGitHubHost register GitHub host (e.g. GHES) as additional host that has dummy GitHub Apps
+func RegisterGitHubHost(t testing.TB, url string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate private key: %+v", err)
+	}
+	pemByte := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	oldHosts := config.Config.GitHubHosts
+	t.Cleanup(func() { config.Config.GitHubHosts = oldHosts })
+	config.Config.GitHubHosts = []config.GitHubHost{{
+		URL: url,
+		App: config.GitHubApp{AppID: 1, PEMByte: pemByte, PEM: key},
+	}}
+	if err := gh.InitializeCache(1, pemByte); err != nil {
+		t.Fatalf("failed to initialize GitHub hosts: %+v", err)
+	}
+}
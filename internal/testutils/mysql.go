@@ -1,42 +1,26 @@
 package testutils
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"os"
-	"path"
-	"runtime"
-	"strings"
+	"testing"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	"github.com/whywaita/myshoes/pkg/datastore"
 )
 
-const schemaDirRelativePathFormat = "%s/../../pkg/datastore/mysql/%s"
+// migrationTable is table of applied migrations, it is not truncated
+const migrationTable = "schema_migrations"
 
-func execSchema(fpath string) {
-	b, err := os.ReadFile(fpath)
-	if err != nil {
-		log.Fatalf("schema reading error: %v", err)
-	}
-
-	queries := strings.Split(string(b), ";")
-
-	for _, query := range queries[:len(queries)-1] {
-		_, err = testDB.Exec(query)
-		if err != nil {
-			log.Fatalf("exec schema error: %v, query: %s", err, query)
-		}
+// migrateTables create tables by migrations, as same as myshoes does in starting
+func migrateTables() {
+	if err := testMySQL.Migrate(context.Background()); err != nil {
+		log.Fatalf("migrate error: %v", err)
 	}
 }
 
-func createTablesIfNotExist() {
-	_, pwd, _, _ := runtime.Caller(0)
-	schemaPath := fmt.Sprintf(schemaDirRelativePathFormat, path.Dir(pwd), "schema.sql")
-	execSchema(schemaPath)
-}
-
 func truncateTables() {
 	rows, err := testDB.Query("SHOW TABLES")
 	if err != nil {
@@ -51,6 +35,9 @@ func truncateTables() {
 			log.Fatalf("show table error: %#v", err)
 			continue
 		}
+		if tableName == migrationTable {
+			continue
+		}
 
 		cmds := []string{
 			"SET FOREIGN_KEY_CHECKS = 0",
@@ -94,3 +81,33 @@ func GetTestDB() (*sqlx.DB, func()) {
 
 	return testDB, func() { truncateTables() }
 }
+
+// GetTestDSN return DSN of test MySQL, it is used for creating other datastore (e.g. other replica of myshoes)
+func GetTestDSN() string {
+	if testDSN == "" {
+		panic("testDSN is not initialized yet")
+	}
+
+	return testDSN
+}
+
+// CreateTestDatabase create an empty database in test MySQL, and return DSN of it. database is dropped in cleanup of t
+func CreateTestDatabase(t *testing.T, name string) string {
+	t.Helper()
+
+	if _, err := testDB.Exec(fmt.Sprintf("CREATE DATABASE `%s`", name)); err != nil {
+		t.Fatalf("failed to create database: %+v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := testDB.Exec(fmt.Sprintf("DROP DATABASE `%s`", name)); err != nil {
+			t.Logf("failed to drop database: %+v", err)
+		}
+	})
+
+	cfg, err := mysql.ParseDSN(GetTestDSN())
+	if err != nil {
+		t.Fatalf("failed to parse DSN: %+v", err)
+	}
+	cfg.DBName = name
+	return cfg.FormatDSN()
+}
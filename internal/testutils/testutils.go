@@ -21,6 +21,8 @@ const (
 var (
 	testDB        *sqlx.DB
 	testDatastore datastore.Datastore
+	testMySQL     *mysql.MySQL
+	testDSN       string
 
 	testURL string
 )
@@ -42,11 +44,12 @@ func IntegrationTestRunner(m *testing.M) int {
 	// exponential backoff-retry, because the application in the container might not be ready to accept connections yet
 	if err := pool.Retry(func() error {
 		var err error
-		dsn := fmt.Sprintf("root:%s@(localhost:%s)/mysql", mysqlRootPassword, resource.GetPort("3306/tcp"))
-		testDatastore, err = mysql.New(dsn, make(chan<- struct{}))
+		testDSN = fmt.Sprintf("root:%s@(localhost:%s)/mysql", mysqlRootPassword, resource.GetPort("3306/tcp"))
+		testMySQL, err = mysql.New(testDSN, make(chan<- struct{}))
 		if err != nil {
 			log.Fatalf("failed to create datastore instance: %s", err)
 		}
+		testDatastore = testMySQL
 
 		testDB, err = sqlx.Open("mysql", fmt.Sprintf("root:%s@(localhost:%s)/mysql?parseTime=true&loc=UTC", mysqlRootPassword, resource.GetPort("3306/tcp")))
 		if err != nil {
@@ -57,7 +60,7 @@ func IntegrationTestRunner(m *testing.M) int {
 		log.Fatalf("Could not connect to docker: %s", err)
 	}
 
-	createTablesIfNotExist()
+	migrateTables()
 	//SetupDefaultFixtures()
 
 	mux := web.NewMux(testDatastore)
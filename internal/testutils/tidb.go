@@ -0,0 +1,48 @@
+package testutils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ory/dockertest/v3"
+
+	"github.com/whywaita/myshoes/pkg/datastore/mysql"
+)
+
+const tidbImageTag = "v7.5.0"
+
+// GetTestTiDB start a TiDB container, return datastore in compat mode and DSN of it.
+// schema is not created, test must apply migrations.
+func GetTestTiDB(t *testing.T) (*mysql.MySQL, string) {
+	t.Helper()
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Fatalf("Could not connect to docker: %s", err)
+	}
+	resource, err := pool.Run("pingcap/tidb", tidbImageTag, nil)
+	if err != nil {
+		t.Fatalf("Could not start resource: %s", err)
+	}
+	t.Cleanup(func() {
+		if err := pool.Purge(resource); err != nil {
+			t.Logf("Could not purge resource: %s", err)
+		}
+	})
+
+	dsn := fmt.Sprintf("root@(localhost:%s)/test", resource.GetPort("4000/tcp"))
+	var ds *mysql.MySQL
+	if err := pool.Retry(func() error {
+		var err error
+		ds, err = mysql.New(dsn, make(chan struct{}, 1))
+		if err != nil {
+			return err
+		}
+		return ds.Conn.Ping()
+	}); err != nil {
+		t.Fatalf("Could not connect to TiDB: %s", err)
+	}
+	ds.CompatMode = true
+
+	return ds, dsn
+}
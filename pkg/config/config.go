@@ -2,7 +2,10 @@ package config
 
 import (
 	"crypto/rsa"
+	"encoding/json"
+	"log/slog"
 	"strings"
+	"time"
 )
 
 // Config is config value
@@ -10,50 +13,361 @@ var Config Conf
 
 // Conf is type of Config
 type Conf struct {
-	GitHub GitHubApp
+	GitHub         GitHubApp
+	GitHubAuthMode string // "app" (default) or "pat"
+	GitHubPAT      string // personal access token, only used in "pat" mode
 
 	MySQLDSN              string
+	MySQLReadDSN          string        // optional, read replica for heavy list queries
+	MySQLCompatMode       bool          // use portable SQL for MySQL compatible databases (e.g. TiDB, Aurora MySQL)
+	MySQLTLSCAPath        string        // optional, CA certificate for verifying MySQL server
+	MySQLTLSCertPath      string        // optional, client certificate for MySQL
+	MySQLTLSKeyPath       string        // optional, private key of client certificate
+	MySQLAuthMode         string        // "password" (default) or "aws_iam"
+	MySQLAWSRegion        string        // region of RDS in aws_iam mode
+	DBMaxOpenConns        int           // 0 is unlimited
+	DBMaxIdleConns        int           // 0 is no idle connections
+	DBConnMaxLifetime     time.Duration // 0 is reused forever
+	DatastoreRetryMax     int           // max number of retries for transient errors in datastore, 0 is disabled
+	DatastoreRetryBackoff time.Duration // initial backoff of retries, doubled in each retry
+	DatastoreSlowQuery    time.Duration // log datastore calls slower than it, 0 is disabled
+	SQLitePath            string
+	AutoMigration         bool
+	IDGenerator           string
+	EncryptionKey         []byte // optional, master key for encrypting tokens in datastore
 	Port                  int
 	ShoesPluginPath       string
 	ShoesPluginOutputPath string
+	ShoesPlugins          map[string]string  // optional, additional plugins, key: name, value: path of binary
+	ShoesPluginRoutes     []ShoesPluginRoute // routes of jobs to additional plugins, first matched is used, default plugin if no route is matched
+	PluginHealthInterval  time.Duration      // interval of health check to plugin processes, 0 is disabled
 	RunnerUser            string
 
-	Debug           bool
-	Strict          bool // check to registered runner before delete job
-	ModeWebhookType ModeWebhookType
+	Debug               bool
+	LogFormat           string        // "text" (default) or "json"
+	LogLevel            slog.Level    // initial level, can be changed at runtime
+	LogSamplingInterval time.Duration // 0 is disabled
+	Strict              bool          // check to registered runner before delete job
+	GCDryRun            bool          // report runners that will be deleted without deleting
+	ModeWebhookType     ModeWebhookType
+
+	RepositoryDispatchTypes []string // event_type of repository_dispatch that myshoes provision a runner, empty is disabled
 
 	MaxConnectionsToBackend int64
 	MaxConcurrencyDeleting  int64
+	JobTTL                  time.Duration // 0 is disabled
+	JobRetention            time.Duration // 0 is disabled
+	RunnerHistoryRetention  time.Duration // 0 is disabled
+	LoopWatchdogTimeout     time.Duration // 0 is disabled
+	WebhookSyncLookback     time.Duration // max age of missed webhook deliveries that synced on startup, 0 is disabled
+	WebhookSyncInterval     time.Duration // 0 is only on startup
+	QueuedJobPollInterval   time.Duration // 0 is disabled
+	BurstMaxRunners         int           // max number of runners that pre-provisioned for a workflow run, 0 is disabled
+
+	TargetAutoRegisterResourceType string        // optional, resource type of auto-registered targets, empty is disabled
+	TargetAutoRegisterInterval     time.Duration // interval of discovering installations of GitHub Apps
+	TargetDeleteOnUninstall        bool          // soft delete targets instead of suspending when GitHub Apps is uninstalled
+
+	GitHubURL       string
+	GitHubAPIURL    string       // optional, override API endpoint in GHES
+	GitHubUploadURL string       // optional, override upload endpoint in GHES
+	GitHubHosts     []GitHubHost // optional, additional GitHub hosts that have own GitHub Apps
+	RunnerVersion   string
+
+	GitHubConnectTimeout     time.Duration
+	GitHubReadTimeout        time.Duration
+	GitHubTimeout            time.Duration
+	GitHubRetryMax           int           // max number of retries in rate limited, 0 is disabled
+	GitHubRetryMaxWait       time.Duration // max total time of waiting retries in a request
+	GitHubRunnersCacheTTL    time.Duration // TTL of cached list of runners per scope
+	GitHubTokenRefreshBefore time.Duration // cached installation token is refreshed before expiry by this
+
+	GitHubDailyBudget          int64            // 0 is unlimited
+	GitHubDailyBudgetOverrides map[string]int64 // key: scope, value: daily budget of scope
+
+	RunnerHookURL      string // optional, outgoing webhook for runner lifecycle
+	RunnerHookSecret   []byte
+	RunnerHookBlocking bool
+	RunnerHookTimeout  time.Duration
+
+	RunnerCallbackURL    string              // optional, URL of myshoes that reachable from runner for reporting progress of setup script
+	RunnerTokenDelivery  string              // "embed" (default) or "callback"
+	RunnerTokenTicketTTL time.Duration       // lifetime of URL for fetching registration token in callback mode
+	RunnerLabels         []string            // optional, labels that are injected to all runners
+	RunnerPlatformLabels []string            // OS and architecture labels of runners, jobs that request other platform labels are not provisioned
+	ResourceTypeLabels   []ResourceTypeLabel // labels in runs-on that override resource type of target, first matched is used
+
+	RunnerIdentityVerification string // "token" (default), "ip" or name of registered verifier
+
+	ScaleSetName          string // optional, name of runner scale set, empty is disabled
+	ScaleSetRunnerGroupID int    // ID of runner group that scale set is registered
+
+	AdminToken string // optional, bearer token for destructive admin endpoints (e.g. DELETE /data), empty is disabled
+
+	CostWindows          []CostWindow   // optional, time-of-day windows that provisioning is cheaper
+	CostScheduleLocation *time.Location // time zone of CostWindows
+	CostScheduleMaxDelay time.Duration  // max delay of low priority job for waiting a cost window
+	CostScheduleLabel    string         // runs-on label of low priority job
+
+	EventExportBackend  string // optional, "nats" or "kafka"
+	EventExportEndpoint string // URL of NATS server or Kafka REST Proxy
+	EventExportPrefix   string // prefix of subject (topic in Kafka)
+
+	HistoryStoreBackend  string // optional, "clickhouse"
+	HistoryStoreEndpoint string // URL of ClickHouse HTTP interface
+
+	LockBackend  string // "datastore" (default), "redis" or "etcd"
+	LockEndpoint string // host:port in redis, URL of gRPC gateway in etcd
+	LockPassword string // optional, password of redis
+	LockKey      string
+	LockTTL      time.Duration
+
+	ProfilingBackend  string        // optional, "pprof" or "pyroscope", needs build with -tags profiling
+	ProfilingEndpoint string        // listen address of pprof, URL of Pyroscope server
+	ProfilingInterval time.Duration // interval of pushing profiles to Pyroscope
+
+	EC2Region                string            // region of built-in ec2 provider
+	EC2LaunchTemplate        string            // ID (lt-...) or name of launch template
+	EC2LaunchTemplateVersion string            // version of launch template, "$Default" if empty
+	EC2InstanceTypes         map[string]string // optional, key: resource type, value: instance type that overrides launch template
+	EC2Spot                  bool              // launch spot instances, fall back to on-demand if spot capacity is not available
+	EC2Tags                  map[string]string // optional, tags of instances and volumes
+	EC2MaxInstances          int               // max number of instances that reported as capacity, 0 is unlimited
+
+	DockerHost          string // endpoint of Docker daemon of built-in docker provider, unix:// or tcp://
+	DockerRunnerImage   string // image of runner containers
+	DockerRunnerNetwork string // optional, network of runner containers, default network of Docker if empty
+	DockerMountSocket   bool   // mount socket of Docker daemon to runner containers for jobs that use docker
+	DockerMaxContainers int    // max number of runner containers that reported as capacity, 0 is unlimited
+
+	ShoesPluginTLSCAPath   string // optional, CA certificate for verifying remote plugin server (grpcs://)
+	ShoesPluginTLSCertPath string // optional, client certificate for remote plugin server
+	ShoesPluginTLSKeyPath  string // optional, private key of client certificate
+}
+
+// ResourceTypeLabel is a label in runs-on of job that requests resource type (e.g. myshoes-4core -> large)
+type ResourceTypeLabel struct {
+	Label        string
+	ResourceType string
+}
+
+// ShoesPluginRoute is a route of jobs to additional plugin (e.g. label:windows -> windows)
+type ShoesPluginRoute struct {
+	Kind   string // one of ShoesPluginRouteTarget, ShoesPluginRouteResourceType and ShoesPluginRouteLabel
+	Value  string // scope of target, resource type or label in runs-on
+	Plugin string // name of plugin in ShoesPlugins
+}
+
+// kinds of ShoesPluginRoute
+const (
+	ShoesPluginRouteTarget       = "target"
+	ShoesPluginRouteResourceType = "resource_type"
+	ShoesPluginRouteLabel        = "label"
+)
+
+// CostWindow is a time-of-day window that provisioning is cheaper (e.g. night in region B)
+type CostWindow struct {
+	Start string `json:"start"` // "15:04" format
+	End   string `json:"end"`   // "15:04" format, window is over midnight if End is before Start
 
-	GitHubURL     string
-	RunnerVersion string
+	// ResourceType override resource type of target in window, empty is not override
+	ResourceType string `json:"resource_type,omitempty"`
+	// PlacementParams override keys of placement parameters of target in window
+	PlacementParams json.RawMessage `json:"placement_params,omitempty"`
+}
+
+// GitHubHost is an additional GitHub (github.com or GitHub Enterprise Server) that served in same instance
+type GitHubHost struct {
+	URL              string `json:"url"`
+	APIURL           string `json:"api_url,omitempty"`    // optional, override API endpoint in GHES
+	UploadURL        string `json:"upload_url,omitempty"` // optional, override upload endpoint in GHES
+	AppID            int64  `json:"app_id"`
+	PrivateKeyBase64 string `json:"private_key_base64"`
+	AppSecret        string `json:"app_secret"` // comma-separated in rotation
+
+	App GitHubApp `json:"-"`
 }
 
 // GitHubApp is type of config value
 type GitHubApp struct {
-	AppID     int64
-	AppSecret []byte
-	PEMByte   []byte
-	PEM       *rsa.PrivateKey
+	AppID      int64
+	AppSecret  []byte   // current secret, it is used for signing
+	AppSecrets [][]byte // all accepted secrets including AppSecret, for rotation of secret
+	PEMByte    []byte
+	PEM        *rsa.PrivateKey
+}
+
+// Secrets return all accepted secrets, first one is current secret
+func (ga GitHubApp) Secrets() [][]byte {
+	if len(ga.AppSecrets) == 0 {
+		return [][]byte{ga.AppSecret}
+	}
+	return ga.AppSecrets
 }
 
 // Config Environment keys
 const (
-	EnvGitHubAppID               = "GITHUB_APP_ID"
-	EnvGitHubAppSecret           = "GITHUB_APP_SECRET"
-	EnvGitHubAppPrivateKeyBase64 = "GITHUB_PRIVATE_KEY_BASE64"
-	EnvMySQLURL                  = "MYSQL_URL"
-	EnvPort                      = "PORT"
-	EnvShoesPluginPath           = "PLUGIN"
-	EnvShoesPluginOutputPath     = "PLUGIN_OUTPUT"
-	EnvRunnerUser                = "RUNNER_USER"
-	EnvDebug                     = "DEBUG"
-	EnvStrict                    = "STRICT"
-	EnvModeWebhookType           = "MODE_WEBHOOK_TYPE"
-	EnvMaxConnectionsToBackend   = "MAX_CONNECTIONS_TO_BACKEND"
-	EnvMaxConcurrencyDeleting    = "MAX_CONCURRENCY_DELETING"
-	EnvGitHubURL                 = "GITHUB_URL"
-	EnvRunnerVersion             = "RUNNER_VERSION"
+	EnvGitHubAppID                    = "GITHUB_APP_ID"
+	EnvGitHubAppSecret                = "GITHUB_APP_SECRET"
+	EnvGitHubAuthMode                 = "GITHUB_AUTH_MODE"
+	EnvGitHubPAT                      = "GITHUB_PAT"
+	EnvGitHubAppPrivateKeyBase64      = "GITHUB_PRIVATE_KEY_BASE64"
+	EnvMySQLURL                       = "MYSQL_URL"
+	EnvMySQLReadURL                   = "MYSQL_READ_URL"
+	EnvMySQLCompatMode                = "MYSQL_COMPAT_MODE"
+	EnvMySQLTLSCAPath                 = "MYSQL_TLS_CA_PATH"
+	EnvMySQLTLSCertPath               = "MYSQL_TLS_CERT_PATH"
+	EnvMySQLTLSKeyPath                = "MYSQL_TLS_KEY_PATH"
+	EnvMySQLAuthMode                  = "MYSQL_AUTH_MODE"
+	EnvMySQLAWSRegion                 = "MYSQL_AWS_REGION"
+	EnvDBMaxOpenConns                 = "DB_MAX_OPEN_CONNS"
+	EnvDBMaxIdleConns                 = "DB_MAX_IDLE_CONNS"
+	EnvDBConnMaxLifetime              = "DB_CONN_MAX_LIFETIME"
+	EnvDatastoreRetryMax              = "DATASTORE_RETRY_MAX"
+	EnvDatastoreRetryBackoff          = "DATASTORE_RETRY_BACKOFF"
+	EnvDatastoreSlowQuery             = "DATASTORE_SLOW_QUERY"
+	EnvSQLitePath                     = "SQLITE_PATH"
+	EnvAutoMigration                  = "AUTO_MIGRATION"
+	EnvIDGenerator                    = "ID_GENERATOR"
+	EnvEncryptionKeyBase64            = "DATASTORE_ENCRYPTION_KEY_BASE64"
+	EnvPort                           = "PORT"
+	EnvShoesPluginPath                = "PLUGIN"
+	EnvShoesPluginOutputPath          = "PLUGIN_OUTPUT"
+	EnvShoesPlugins                   = "ADDITIONAL_PLUGINS"
+	EnvShoesPluginRoutes              = "PLUGIN_ROUTES"
+	EnvPluginHealthInterval           = "PLUGIN_HEALTH_CHECK_INTERVAL"
+	EnvRunnerUser                     = "RUNNER_USER"
+	EnvDebug                          = "DEBUG"
+	EnvLogFormat                      = "LOG_FORMAT"
+	EnvLogLevel                       = "LOG_LEVEL"
+	EnvLogSamplingInterval            = "LOG_SAMPLING_INTERVAL"
+	EnvStrict                         = "STRICT"
+	EnvGCDryRun                       = "GC_DRY_RUN"
+	EnvModeWebhookType                = "MODE_WEBHOOK_TYPE"
+	EnvRepositoryDispatchTypes        = "REPOSITORY_DISPATCH_TYPES"
+	EnvMaxConnectionsToBackend        = "MAX_CONNECTIONS_TO_BACKEND"
+	EnvMaxConcurrencyDeleting         = "MAX_CONCURRENCY_DELETING"
+	EnvJobTTL                         = "JOB_TTL"
+	EnvJobRetention                   = "JOB_RETENTION"
+	EnvRunnerHistoryRetention         = "RUNNER_HISTORY_RETENTION"
+	EnvLoopWatchdogTimeout            = "LOOP_WATCHDOG_TIMEOUT"
+	EnvWebhookSyncLookback            = "WEBHOOK_SYNC_LOOKBACK"
+	EnvWebhookSyncInterval            = "WEBHOOK_SYNC_INTERVAL"
+	EnvQueuedJobPollInterval          = "QUEUED_JOB_POLL_INTERVAL"
+	EnvBurstMaxRunners                = "BURST_MAX_RUNNERS"
+	EnvTargetAutoRegisterResourceType = "TARGET_AUTO_REGISTER_RESOURCE_TYPE"
+	EnvTargetAutoRegisterInterval     = "TARGET_AUTO_REGISTER_INTERVAL"
+	EnvTargetDeleteOnUninstall        = "TARGET_DELETE_ON_UNINSTALL"
+	EnvGitHubURL                      = "GITHUB_URL"
+	EnvGitHubAPIURL                   = "GITHUB_API_URL"
+	EnvGitHubUploadURL                = "GITHUB_UPLOAD_URL"
+	EnvGitHubHosts                    = "GITHUB_HOSTS"
+	EnvRunnerVersion                  = "RUNNER_VERSION"
+	EnvGitHubConnectTimeout           = "GITHUB_CONNECT_TIMEOUT"
+	EnvGitHubReadTimeout              = "GITHUB_READ_TIMEOUT"
+	EnvGitHubTimeout                  = "GITHUB_TIMEOUT"
+	EnvGitHubRetryMax                 = "GITHUB_RETRY_MAX"
+	EnvGitHubRetryMaxWait             = "GITHUB_RETRY_MAX_WAIT"
+	EnvGitHubRunnersCacheTTL          = "GITHUB_RUNNERS_CACHE_TTL"
+	EnvGitHubTokenRefreshBefore       = "GITHUB_TOKEN_REFRESH_BEFORE"
+	EnvGitHubDailyBudget              = "GITHUB_DAILY_BUDGET"
+	EnvGitHubDailyBudgetOverride      = "GITHUB_DAILY_BUDGET_OVERRIDES"
+	EnvRunnerHookURL                  = "RUNNER_HOOK_URL"
+	EnvRunnerHookSecret               = "RUNNER_HOOK_SECRET"
+	EnvRunnerHookBlocking             = "RUNNER_HOOK_BLOCKING"
+	EnvRunnerHookTimeout              = "RUNNER_HOOK_TIMEOUT"
+	EnvRunnerCallbackURL              = "RUNNER_CALLBACK_URL"
+	EnvRunnerTokenDelivery            = "RUNNER_TOKEN_DELIVERY"
+	EnvRunnerTokenTicketTTL           = "RUNNER_TOKEN_TICKET_TTL"
+	EnvRunnerLabels                   = "RUNNER_LABELS"
+	EnvRunnerPlatformLabels           = "RUNNER_PLATFORM_LABELS"
+	EnvResourceTypeLabels             = "RESOURCE_TYPE_LABELS"
+	EnvRunnerIdentityVerification     = "RUNNER_IDENTITY_VERIFICATION"
+	EnvScaleSetName                   = "SCALE_SET_NAME"
+	EnvScaleSetRunnerGroupID          = "SCALE_SET_RUNNER_GROUP_ID"
+	EnvAdminToken                     = "ADMIN_TOKEN"
+	EnvCostSchedule                   = "COST_SCHEDULE"
+	EnvCostScheduleTimeZone           = "COST_SCHEDULE_TIMEZONE"
+	EnvCostScheduleMaxDelay           = "COST_SCHEDULE_MAX_DELAY"
+	EnvCostScheduleLabel              = "COST_SCHEDULE_LABEL"
+	EnvEventExportBackend             = "EVENT_EXPORT_BACKEND"
+	EnvEventExportEndpoint            = "EVENT_EXPORT_ENDPOINT"
+	EnvEventExportPrefix              = "EVENT_EXPORT_PREFIX"
+	EnvHistoryStoreBackend            = "HISTORY_STORE_BACKEND"
+	EnvHistoryStoreEndpoint           = "HISTORY_STORE_ENDPOINT"
+	EnvLockBackend                    = "LOCK_BACKEND"
+	EnvLockEndpoint                   = "LOCK_ENDPOINT"
+	EnvLockPassword                   = "LOCK_PASSWORD"
+	EnvLockKey                        = "LOCK_KEY"
+	EnvLockTTL                        = "LOCK_TTL"
+	EnvProfilingBackend               = "PROFILING_BACKEND"
+	EnvProfilingEndpoint              = "PROFILING_ENDPOINT"
+	EnvProfilingInterval              = "PROFILING_INTERVAL"
+	EnvEC2Region                      = "EC2_REGION"
+	EnvEC2LaunchTemplate              = "EC2_LAUNCH_TEMPLATE"
+	EnvEC2LaunchTemplateVersion       = "EC2_LAUNCH_TEMPLATE_VERSION"
+	EnvEC2InstanceTypes               = "EC2_INSTANCE_TYPES"
+	EnvEC2Spot                        = "EC2_SPOT"
+	EnvEC2Tags                        = "EC2_TAGS"
+	EnvEC2MaxInstances                = "EC2_MAX_INSTANCES"
+	EnvDockerHost                     = "DOCKER_HOST"
+	EnvDockerRunnerImage              = "DOCKER_RUNNER_IMAGE"
+	EnvDockerRunnerNetwork            = "DOCKER_RUNNER_NETWORK"
+	EnvDockerMountSocket              = "DOCKER_MOUNT_SOCKET"
+	EnvDockerMaxContainers            = "DOCKER_MAX_CONTAINERS"
+	EnvShoesPluginTLSCAPath           = "PLUGIN_TLS_CA_PATH"
+	EnvShoesPluginTLSCertPath         = "PLUGIN_TLS_CERT_PATH"
+	EnvShoesPluginTLSKeyPath          = "PLUGIN_TLS_KEY_PATH"
+)
+
+// RunnerTokenDelivery values
+const (
+	// RunnerTokenDeliveryEmbed embed registration token in user data
+	RunnerTokenDeliveryEmbed = "embed"
+	// RunnerTokenDeliveryCallback fetch registration token from myshoes at boot
+	RunnerTokenDeliveryCallback = "callback"
+)
+
+// RunnerIdentityVerification values
+const (
+	// RunnerIdentityToken verify only signed token of runner
+	RunnerIdentityToken = "token"
+	// RunnerIdentityIP verify IP address of caller is same as reported by shoes-provider
+	RunnerIdentityIP = "ip"
+)
+
+// GitHubAuthMode values
+const (
+	// GitHubAuthModeApp authenticate by installation tokens of GitHub Apps
+	GitHubAuthModeApp = "app"
+	// GitHubAuthModePAT authenticate by a personal access token, some features are not available
+	GitHubAuthModePAT = "pat"
+)
+
+// ProfilingBackend values
+const (
+	// ProfilingBackendPprof serve net/http/pprof endpoints (e.g. for scraping by Parca)
+	ProfilingBackendPprof = "pprof"
+	// ProfilingBackendPyroscope push profiles to Pyroscope
+	ProfilingBackendPyroscope = "pyroscope"
+)
+
+// MinLockTTL is minimum of LOCK_TTL, the lock is refreshed in TTL / 3 and redis rejects TTL less than 1ms
+const MinLockTTL = 1 * time.Second
+
+// BuiltinPluginPrefix is prefix of plugin path that use provider built in myshoes instead of plugin binary (e.g. builtin:ec2)
+const BuiltinPluginPrefix = "builtin:"
+
+// schemes of plugin path that connect to plugin server running remotely instead of starting plugin binary (e.g. grpc://shoes.internal:8080)
+const (
+	RemotePluginScheme    = "grpc://"
+	RemotePluginTLSScheme = "grpcs://"
+)
+
+// MySQLAuthMode values
+const (
+	MySQLAuthModePassword = "password"
+	MySQLAuthModeAWSIAM   = "aws_iam"
 )
 
 // ModeWebhookType is type value for GitHub webhook
@@ -1,18 +1,22 @@
 package config
 
 import (
+	"crypto/rsa"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-version"
 )
@@ -21,11 +25,27 @@ import (
 func Load() {
 	c := LoadWithDefault()
 
-	ga := LoadGitHubApps()
-	c.GitHub = *ga
+	if c.GitHubAuthMode == GitHubAuthModePAT {
+		c.GitHubPAT = os.Getenv(EnvGitHubPAT)
+		if c.GitHubPAT == "" {
+			log.Panicf("%s must be set if %s is %s", EnvGitHubPAT, EnvGitHubAuthMode, GitHubAuthModePAT)
+		}
+		c.GitHub = GitHubApp{}
+		loadGitHubAppSecrets(&c.GitHub)
+	} else {
+		ga := LoadGitHubApps()
+		c.GitHub = *ga
+		c.GitHubHosts = LoadGitHubHosts(c.GitHubURL)
+	}
 
 	pluginPath := LoadPluginPath()
 	c.ShoesPluginPath = pluginPath
+	c.ShoesPlugins = LoadAdditionalPlugins()
+	for _, r := range c.ShoesPluginRoutes {
+		if _, ok := c.ShoesPlugins[r.Plugin]; !ok {
+			log.Panicf("plugin %s in %s is not found in %s", r.Plugin, EnvShoesPluginRoutes, EnvShoesPlugins)
+		}
+	}
 
 	Config = c
 }
@@ -54,12 +74,130 @@ func LoadWithDefault() Conf {
 	if os.Getenv(EnvDebug) == "true" {
 		c.Debug = true
 	}
+	c.LogFormat = "text"
+	if os.Getenv(EnvLogFormat) != "" {
+		c.LogFormat = os.Getenv(EnvLogFormat)
+	}
+	switch c.LogFormat {
+	case "text", "json":
+	default:
+		log.Panicf("%s must be text or json (got: %s)", EnvLogFormat, c.LogFormat)
+	}
+	c.LogLevel = slog.LevelInfo
+	if c.Debug {
+		c.LogLevel = slog.LevelDebug
+	}
+	if os.Getenv(EnvLogLevel) != "" {
+		if err := c.LogLevel.UnmarshalText([]byte(os.Getenv(EnvLogLevel))); err != nil {
+			log.Panicf("failed to parse %s: %+v", EnvLogLevel, err)
+		}
+		c.Debug = c.LogLevel <= slog.LevelDebug
+	}
+	c.LogSamplingInterval = 1 * time.Minute
+	if os.Getenv(EnvLogSamplingInterval) != "" {
+		c.LogSamplingInterval = mustParseDuration(EnvLogSamplingInterval)
+	}
 
 	c.Strict = true
 	if os.Getenv(EnvStrict) == "false" {
 		c.Strict = false
 	}
 
+	c.GCDryRun = false
+	if os.Getenv(EnvGCDryRun) == "true" {
+		c.GCDryRun = true
+	}
+
+	c.AutoMigration = true
+	if os.Getenv(EnvAutoMigration) == "false" {
+		c.AutoMigration = false
+	}
+
+	if os.Getenv(EnvMySQLCompatMode) == "true" {
+		c.MySQLCompatMode = true
+	}
+
+	c.MySQLTLSCAPath = os.Getenv(EnvMySQLTLSCAPath)
+	c.MySQLTLSCertPath = os.Getenv(EnvMySQLTLSCertPath)
+	c.MySQLTLSKeyPath = os.Getenv(EnvMySQLTLSKeyPath)
+	if (c.MySQLTLSCertPath == "") != (c.MySQLTLSKeyPath == "") {
+		log.Panicf("%s and %s must be set together", EnvMySQLTLSCertPath, EnvMySQLTLSKeyPath)
+	}
+	c.MySQLAuthMode = MySQLAuthModePassword
+	if os.Getenv(EnvMySQLAuthMode) != "" {
+		c.MySQLAuthMode = os.Getenv(EnvMySQLAuthMode)
+	}
+	switch c.MySQLAuthMode {
+	case MySQLAuthModePassword:
+	case MySQLAuthModeAWSIAM:
+		c.MySQLAWSRegion = os.Getenv("AWS_REGION")
+		if os.Getenv(EnvMySQLAWSRegion) != "" {
+			c.MySQLAWSRegion = os.Getenv(EnvMySQLAWSRegion)
+		}
+		if c.MySQLAWSRegion == "" {
+			log.Panicf("%s or AWS_REGION must be set if %s is %s", EnvMySQLAWSRegion, EnvMySQLAuthMode, MySQLAuthModeAWSIAM)
+		}
+	default:
+		log.Panicf("%s must be %s or %s (got: %s)", EnvMySQLAuthMode, MySQLAuthModePassword, MySQLAuthModeAWSIAM, c.MySQLAuthMode)
+	}
+
+	c.DBMaxOpenConns = 0
+	if os.Getenv(EnvDBMaxOpenConns) != "" {
+		n, err := strconv.Atoi(os.Getenv(EnvDBMaxOpenConns))
+		if err != nil || n < 0 {
+			log.Panicf("failed to parse %s (must be positive integer or 0): %+v", EnvDBMaxOpenConns, err)
+		}
+		c.DBMaxOpenConns = n
+	}
+	// same as default of database/sql
+	c.DBMaxIdleConns = 2
+	if os.Getenv(EnvDBMaxIdleConns) != "" {
+		n, err := strconv.Atoi(os.Getenv(EnvDBMaxIdleConns))
+		if err != nil || n < 0 {
+			log.Panicf("failed to parse %s (must be positive integer or 0): %+v", EnvDBMaxIdleConns, err)
+		}
+		c.DBMaxIdleConns = n
+	}
+	c.DBConnMaxLifetime = 0
+	if os.Getenv(EnvDBConnMaxLifetime) != "" {
+		c.DBConnMaxLifetime = mustParseDuration(EnvDBConnMaxLifetime)
+	}
+
+	// cover failover of MySQL (about 10 seconds) by default, total of backoff is about 16 seconds
+	c.DatastoreRetryMax = 7
+	if os.Getenv(EnvDatastoreRetryMax) != "" {
+		n, err := strconv.Atoi(os.Getenv(EnvDatastoreRetryMax))
+		if err != nil || n < 0 {
+			log.Panicf("failed to parse %s (must be positive integer or 0): %+v", EnvDatastoreRetryMax, err)
+		}
+		c.DatastoreRetryMax = n
+	}
+	c.DatastoreRetryBackoff = 200 * time.Millisecond
+	if os.Getenv(EnvDatastoreRetryBackoff) != "" {
+		c.DatastoreRetryBackoff = mustParseDuration(EnvDatastoreRetryBackoff)
+	}
+	if os.Getenv(EnvDatastoreSlowQuery) != "" {
+		c.DatastoreSlowQuery = mustParseDuration(EnvDatastoreSlowQuery)
+	}
+
+	c.IDGenerator = "uuidv4"
+	if os.Getenv(EnvIDGenerator) != "" {
+		c.IDGenerator = os.Getenv(EnvIDGenerator)
+	}
+
+	if os.Getenv(EnvEncryptionKeyBase64) != "" {
+		key, err := base64.StdEncoding.DecodeString(os.Getenv(EnvEncryptionKeyBase64))
+		if err != nil {
+			log.Panicf("failed to decode base64 %s: %+v", EnvEncryptionKeyBase64, err)
+		}
+		switch len(key) {
+		case 16, 24, 32:
+		default:
+			log.Panicf("%s must be 16, 24 or 32 bytes (AES-128, AES-192 or AES-256), but got %d bytes", EnvEncryptionKeyBase64, len(key))
+		}
+		c.EncryptionKey = key
+	}
+
 	c.ModeWebhookType = ModeWebhookTypeWorkflowJob
 	if os.Getenv(EnvModeWebhookType) != "" {
 		mwt := marshalModeWebhookType(os.Getenv(EnvModeWebhookType))
@@ -75,6 +213,15 @@ func LoadWithDefault() Conf {
 		c.ModeWebhookType = mwt
 	}
 
+	if os.Getenv(EnvRepositoryDispatchTypes) != "" {
+		for _, t := range strings.Split(os.Getenv(EnvRepositoryDispatchTypes), ",") {
+			if strings.TrimSpace(t) == "" {
+				continue
+			}
+			c.RepositoryDispatchTypes = append(c.RepositoryDispatchTypes, strings.TrimSpace(t))
+		}
+	}
+
 	c.MaxConnectionsToBackend = 50
 	if os.Getenv(EnvMaxConnectionsToBackend) != "" {
 		numberPB, err := strconv.ParseInt(os.Getenv(EnvMaxConnectionsToBackend), 10, 64)
@@ -91,22 +238,69 @@ func LoadWithDefault() Conf {
 		}
 		c.MaxConcurrencyDeleting = numberCD
 	}
-
-	c.GitHubURL = "https://github.com"
-	if os.Getenv(EnvGitHubURL) != "" {
-		u, err := url.Parse(os.Getenv(EnvGitHubURL))
-		if err != nil {
-			log.Panicf("failed to parse URL %s: %+v", os.Getenv(EnvGitHubURL), err)
+	// GitHub will cancel a job that queued over 24 hours
+	c.JobTTL = 24 * time.Hour
+	if os.Getenv(EnvJobTTL) != "" {
+		c.JobTTL = mustParseDuration(EnvJobTTL)
+	}
+	if os.Getenv(EnvJobRetention) != "" {
+		c.JobRetention = mustParseDuration(EnvJobRetention)
+	}
+	if os.Getenv(EnvRunnerHistoryRetention) != "" {
+		c.RunnerHistoryRetention = mustParseDuration(EnvRunnerHistoryRetention)
+	}
+	c.LoopWatchdogTimeout = 15 * time.Minute
+	if os.Getenv(EnvLoopWatchdogTimeout) != "" {
+		c.LoopWatchdogTimeout = mustParseDuration(EnvLoopWatchdogTimeout)
+	}
+	c.WebhookSyncLookback = 1 * time.Hour
+	if os.Getenv(EnvWebhookSyncLookback) != "" {
+		c.WebhookSyncLookback = mustParseDuration(EnvWebhookSyncLookback)
+	}
+	c.WebhookSyncInterval = 10 * time.Minute
+	if os.Getenv(EnvWebhookSyncInterval) != "" {
+		c.WebhookSyncInterval = mustParseDuration(EnvWebhookSyncInterval)
+	}
+	if os.Getenv(EnvQueuedJobPollInterval) != "" {
+		c.QueuedJobPollInterval = mustParseDuration(EnvQueuedJobPollInterval)
+	}
+	if os.Getenv(EnvBurstMaxRunners) != "" {
+		n, err := strconv.Atoi(os.Getenv(EnvBurstMaxRunners))
+		if err != nil || n < 0 {
+			log.Panicf("failed to parse %s (must be positive integer or 0): %+v", EnvBurstMaxRunners, err)
 		}
+		c.BurstMaxRunners = n
+	}
+	c.TargetAutoRegisterResourceType = os.Getenv(EnvTargetAutoRegisterResourceType)
+	c.TargetAutoRegisterInterval = 10 * time.Minute
+	if os.Getenv(EnvTargetAutoRegisterInterval) != "" {
+		c.TargetAutoRegisterInterval = mustParseDuration(EnvTargetAutoRegisterInterval)
+	}
 
-		if strings.EqualFold(u.Scheme, "") {
-			log.Panicf("%s must has scheme (value: %s)", EnvGitHubURL, os.Getenv(EnvGitHubURL))
-		}
-		if strings.EqualFold(u.Host, "") {
-			log.Panicf("%s must has host (value: %s)", EnvGitHubURL, os.Getenv(EnvGitHubURL))
-		}
+	c.TargetDeleteOnUninstall = false
+	if os.Getenv(EnvTargetDeleteOnUninstall) == "true" {
+		c.TargetDeleteOnUninstall = true
+	}
+
+	c.GitHubAuthMode = GitHubAuthModeApp
+	if os.Getenv(EnvGitHubAuthMode) != "" {
+		c.GitHubAuthMode = os.Getenv(EnvGitHubAuthMode)
+	}
+	switch c.GitHubAuthMode {
+	case GitHubAuthModeApp, GitHubAuthModePAT:
+	default:
+		log.Panicf("%s must be %s or %s (got: %s)", EnvGitHubAuthMode, GitHubAuthModeApp, GitHubAuthModePAT, c.GitHubAuthMode)
+	}
 
-		c.GitHubURL = os.Getenv(EnvGitHubURL)
+	c.GitHubURL = "https://github.com"
+	if os.Getenv(EnvGitHubURL) != "" {
+		c.GitHubURL = mustParseURL(EnvGitHubURL)
+	}
+	if os.Getenv(EnvGitHubAPIURL) != "" {
+		c.GitHubAPIURL = mustParseURL(EnvGitHubAPIURL)
+	}
+	if os.Getenv(EnvGitHubUploadURL) != "" {
+		c.GitHubUploadURL = mustParseURL(EnvGitHubUploadURL)
 	}
 
 	if os.Getenv(EnvRunnerVersion) == "" {
@@ -126,15 +320,396 @@ func LoadWithDefault() Conf {
 		}
 	}
 
+	c.GitHubConnectTimeout = 10 * time.Second
+	if os.Getenv(EnvGitHubConnectTimeout) != "" {
+		c.GitHubConnectTimeout = mustParseDuration(EnvGitHubConnectTimeout)
+	}
+	c.GitHubReadTimeout = 30 * time.Second
+	if os.Getenv(EnvGitHubReadTimeout) != "" {
+		c.GitHubReadTimeout = mustParseDuration(EnvGitHubReadTimeout)
+	}
+	c.GitHubTimeout = 60 * time.Second
+	if os.Getenv(EnvGitHubTimeout) != "" {
+		c.GitHubTimeout = mustParseDuration(EnvGitHubTimeout)
+	}
+	c.GitHubRetryMax = 3
+	if os.Getenv(EnvGitHubRetryMax) != "" {
+		retryMax, err := strconv.Atoi(os.Getenv(EnvGitHubRetryMax))
+		if err != nil {
+			log.Panicf("failed to convert int %s: %+v", EnvGitHubRetryMax, err)
+		}
+		c.GitHubRetryMax = retryMax
+	}
+	c.GitHubRetryMaxWait = 30 * time.Second
+	if os.Getenv(EnvGitHubRetryMaxWait) != "" {
+		c.GitHubRetryMaxWait = mustParseDuration(EnvGitHubRetryMaxWait)
+	}
+	c.GitHubRunnersCacheTTL = 30 * time.Second
+	if os.Getenv(EnvGitHubRunnersCacheTTL) != "" {
+		c.GitHubRunnersCacheTTL = mustParseDuration(EnvGitHubRunnersCacheTTL)
+	}
+
+	c.GitHubTokenRefreshBefore = 15 * time.Minute
+	if os.Getenv(EnvGitHubTokenRefreshBefore) != "" {
+		c.GitHubTokenRefreshBefore = mustParseDuration(EnvGitHubTokenRefreshBefore)
+	}
+
+	if os.Getenv(EnvGitHubDailyBudget) != "" {
+		budget, err := strconv.ParseInt(os.Getenv(EnvGitHubDailyBudget), 10, 64)
+		if err != nil {
+			log.Panicf("failed to convert int64 %s: %+v", EnvGitHubDailyBudget, err)
+		}
+		c.GitHubDailyBudget = budget
+	}
+	c.GitHubDailyBudgetOverrides = map[string]int64{}
+	if os.Getenv(EnvGitHubDailyBudgetOverride) != "" {
+		for _, o := range strings.Split(os.Getenv(EnvGitHubDailyBudgetOverride), ",") {
+			if strings.TrimSpace(o) == "" {
+				continue
+			}
+			scope, value, found := strings.Cut(strings.TrimSpace(o), "=")
+			if !found {
+				log.Panicf("%s must be scope=budget (got: %s)", EnvGitHubDailyBudgetOverride, o)
+			}
+			budget, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				log.Panicf("failed to convert int64 %s (scope: %s): %+v", EnvGitHubDailyBudgetOverride, scope, err)
+			}
+			c.GitHubDailyBudgetOverrides[scope] = budget
+		}
+	}
+
+	if os.Getenv(EnvRunnerHookURL) != "" {
+		c.RunnerHookURL = mustParseURL(EnvRunnerHookURL)
+	}
+	c.RunnerHookSecret = []byte(os.Getenv(EnvRunnerHookSecret))
+	if os.Getenv(EnvRunnerHookBlocking) == "true" {
+		c.RunnerHookBlocking = true
+	}
+	c.RunnerHookTimeout = 10 * time.Second
+	if os.Getenv(EnvRunnerHookTimeout) != "" {
+		c.RunnerHookTimeout = mustParseDuration(EnvRunnerHookTimeout)
+	}
+	if os.Getenv(EnvRunnerCallbackURL) != "" {
+		c.RunnerCallbackURL = strings.TrimSuffix(mustParseURL(EnvRunnerCallbackURL), "/")
+	}
+	c.RunnerTokenDelivery = RunnerTokenDeliveryEmbed
+	if os.Getenv(EnvRunnerTokenDelivery) != "" {
+		c.RunnerTokenDelivery = os.Getenv(EnvRunnerTokenDelivery)
+	}
+	switch c.RunnerTokenDelivery {
+	case RunnerTokenDeliveryEmbed:
+	case RunnerTokenDeliveryCallback:
+		if c.RunnerCallbackURL == "" {
+			log.Panicf("%s must be set if %s is %s", EnvRunnerCallbackURL, EnvRunnerTokenDelivery, RunnerTokenDeliveryCallback)
+		}
+	default:
+		log.Panicf("%s must be %s or %s (got: %s)", EnvRunnerTokenDelivery, RunnerTokenDeliveryEmbed, RunnerTokenDeliveryCallback, c.RunnerTokenDelivery)
+	}
+	c.RunnerTokenTicketTTL = 30 * time.Minute
+	if os.Getenv(EnvRunnerTokenTicketTTL) != "" {
+		c.RunnerTokenTicketTTL = mustParseDuration(EnvRunnerTokenTicketTTL)
+	}
+	c.RunnerIdentityVerification = RunnerIdentityToken
+	if os.Getenv(EnvRunnerIdentityVerification) != "" {
+		c.RunnerIdentityVerification = os.Getenv(EnvRunnerIdentityVerification)
+	}
+	if os.Getenv(EnvRunnerLabels) != "" {
+		for _, l := range strings.Split(os.Getenv(EnvRunnerLabels), ",") {
+			l = strings.TrimSpace(l)
+			if l == "" {
+				continue
+			}
+			if strings.ContainsAny(l, " \t") {
+				log.Panicf("%s must not include whitespace in label (got: %q)", EnvRunnerLabels, l)
+			}
+			c.RunnerLabels = append(c.RunnerLabels, l)
+		}
+	}
+	c.RunnerPlatformLabels = []string{"linux", "x64", "arm64"}
+	if os.Getenv(EnvRunnerPlatformLabels) != "" {
+		c.RunnerPlatformLabels = nil
+		for _, l := range strings.Split(os.Getenv(EnvRunnerPlatformLabels), ",") {
+			if l = strings.TrimSpace(l); l != "" {
+				c.RunnerPlatformLabels = append(c.RunnerPlatformLabels, l)
+			}
+		}
+	}
+
+	if os.Getenv(EnvResourceTypeLabels) != "" {
+		for _, m := range strings.Split(os.Getenv(EnvResourceTypeLabels), ",") {
+			if strings.TrimSpace(m) == "" {
+				continue
+			}
+			label, resourceType, found := strings.Cut(strings.TrimSpace(m), "=")
+			if !found || strings.TrimSpace(label) == "" || strings.TrimSpace(resourceType) == "" {
+				log.Panicf("%s must be label=resource_type (got: %s)", EnvResourceTypeLabels, m)
+			}
+			c.ResourceTypeLabels = append(c.ResourceTypeLabels, ResourceTypeLabel{
+				Label:        strings.TrimSpace(label),
+				ResourceType: strings.TrimSpace(resourceType),
+			})
+		}
+	}
+
+	if os.Getenv(EnvShoesPluginRoutes) != "" {
+		for _, r := range strings.Split(os.Getenv(EnvShoesPluginRoutes), ",") {
+			if strings.TrimSpace(r) == "" {
+				continue
+			}
+			match, plugin, found := strings.Cut(strings.TrimSpace(r), "=")
+			kind, value, foundKind := strings.Cut(match, ":")
+			if !found || !foundKind || strings.TrimSpace(value) == "" || strings.TrimSpace(plugin) == "" {
+				log.Panicf("%s must be kind:value=plugin (got: %s)", EnvShoesPluginRoutes, r)
+			}
+			switch strings.TrimSpace(kind) {
+			case ShoesPluginRouteTarget, ShoesPluginRouteResourceType, ShoesPluginRouteLabel:
+			default:
+				log.Panicf("kind of %s must be %s, %s or %s (got: %s)", EnvShoesPluginRoutes, ShoesPluginRouteTarget, ShoesPluginRouteResourceType, ShoesPluginRouteLabel, kind)
+			}
+			c.ShoesPluginRoutes = append(c.ShoesPluginRoutes, ShoesPluginRoute{
+				Kind:   strings.TrimSpace(kind),
+				Value:  strings.TrimSpace(value),
+				Plugin: strings.TrimSpace(plugin),
+			})
+		}
+	}
+
+	c.ScaleSetName = os.Getenv(EnvScaleSetName)
+	if strings.EqualFold(c.ScaleSetName, "myshoes") || strings.EqualFold(c.ScaleSetName, "self-hosted") {
+		log.Panicf("%s must not be %s, it is handled by webhook", EnvScaleSetName, c.ScaleSetName)
+	}
+	c.ScaleSetRunnerGroupID = 1
+	if os.Getenv(EnvScaleSetRunnerGroupID) != "" {
+		n, err := strconv.Atoi(os.Getenv(EnvScaleSetRunnerGroupID))
+		if err != nil || n <= 0 {
+			log.Panicf("failed to parse %s (must be positive integer): %+v", EnvScaleSetRunnerGroupID, err)
+		}
+		c.ScaleSetRunnerGroupID = n
+	}
+	c.AdminToken = os.Getenv(EnvAdminToken)
+
+	if os.Getenv(EnvCostSchedule) != "" {
+		if err := json.Unmarshal([]byte(os.Getenv(EnvCostSchedule)), &c.CostWindows); err != nil {
+			log.Panicf("failed to parse %s: %+v", EnvCostSchedule, err)
+		}
+		for _, w := range c.CostWindows {
+			if _, err := time.Parse("15:04", w.Start); err != nil {
+				log.Panicf("start of %s must be 15:04 format (got: %s)", EnvCostSchedule, w.Start)
+			}
+			if _, err := time.Parse("15:04", w.End); err != nil {
+				log.Panicf("end of %s must be 15:04 format (got: %s)", EnvCostSchedule, w.End)
+			}
+		}
+	}
+	c.CostScheduleLocation = time.UTC
+	if os.Getenv(EnvCostScheduleTimeZone) != "" {
+		loc, err := time.LoadLocation(os.Getenv(EnvCostScheduleTimeZone))
+		if err != nil {
+			log.Panicf("failed to load time zone %s: %+v", EnvCostScheduleTimeZone, err)
+		}
+		c.CostScheduleLocation = loc
+	}
+	c.CostScheduleMaxDelay = 6 * time.Hour
+	if os.Getenv(EnvCostScheduleMaxDelay) != "" {
+		c.CostScheduleMaxDelay = mustParseDuration(EnvCostScheduleMaxDelay)
+	}
+	if len(c.CostWindows) > 0 && c.JobTTL != 0 && c.CostScheduleMaxDelay >= c.JobTTL {
+		log.Panicf("%s must be less than %s, job is expired before provisioning", EnvCostScheduleMaxDelay, EnvJobTTL)
+	}
+	c.CostScheduleLabel = "myshoes-low-priority"
+	if os.Getenv(EnvCostScheduleLabel) != "" {
+		c.CostScheduleLabel = os.Getenv(EnvCostScheduleLabel)
+	}
+
+	c.EventExportBackend = os.Getenv(EnvEventExportBackend)
+	switch c.EventExportBackend {
+	case "":
+	case "nats", "kafka":
+		c.EventExportEndpoint = os.Getenv(EnvEventExportEndpoint)
+		if c.EventExportEndpoint == "" {
+			log.Panicf("%s must be set if %s is set", EnvEventExportEndpoint, EnvEventExportBackend)
+		}
+	default:
+		log.Panicf("%s is invalid event export backend (value: nats or kafka)", c.EventExportBackend)
+	}
+	c.EventExportPrefix = "myshoes"
+	if v, ok := os.LookupEnv(EnvEventExportPrefix); ok {
+		c.EventExportPrefix = v
+	}
+
+	c.HistoryStoreBackend = os.Getenv(EnvHistoryStoreBackend)
+	switch c.HistoryStoreBackend {
+	case "":
+	case "clickhouse":
+		c.HistoryStoreEndpoint = os.Getenv(EnvHistoryStoreEndpoint)
+		if c.HistoryStoreEndpoint == "" {
+			log.Panicf("%s must be set if %s is set", EnvHistoryStoreEndpoint, EnvHistoryStoreBackend)
+		}
+	default:
+		log.Panicf("%s is invalid history store backend (value: clickhouse)", c.HistoryStoreBackend)
+	}
+
+	c.LockBackend = "datastore"
+	if os.Getenv(EnvLockBackend) != "" {
+		c.LockBackend = os.Getenv(EnvLockBackend)
+	}
+	switch c.LockBackend {
+	case "datastore":
+	case "redis":
+		if os.Getenv(EnvLockEndpoint) == "" {
+			log.Panicf("%s must be set if %s is redis", EnvLockEndpoint, EnvLockBackend)
+		}
+		c.LockEndpoint = os.Getenv(EnvLockEndpoint)
+	case "etcd":
+		if os.Getenv(EnvLockEndpoint) == "" {
+			log.Panicf("%s must be set if %s is etcd", EnvLockEndpoint, EnvLockBackend)
+		}
+		c.LockEndpoint = mustParseURL(EnvLockEndpoint)
+	default:
+		log.Panicf("%s is invalid lock backend (value: datastore, redis or etcd)", c.LockBackend)
+	}
+	c.LockPassword = os.Getenv(EnvLockPassword)
+	c.LockKey = "myshoes"
+	if os.Getenv(EnvLockKey) != "" {
+		c.LockKey = os.Getenv(EnvLockKey)
+	}
+	c.LockTTL = 15 * time.Second
+	if os.Getenv(EnvLockTTL) != "" {
+		c.LockTTL = mustParseDuration(EnvLockTTL)
+	}
+	if c.LockTTL < MinLockTTL {
+		log.Panicf("%s must be at least %s (got: %s)", EnvLockTTL, MinLockTTL, c.LockTTL)
+	}
+
+	c.ProfilingBackend = os.Getenv(EnvProfilingBackend)
+	c.ProfilingEndpoint = os.Getenv(EnvProfilingEndpoint)
+	switch c.ProfilingBackend {
+	case "":
+	case ProfilingBackendPprof, ProfilingBackendPyroscope:
+		if c.ProfilingEndpoint == "" {
+			log.Panicf("%s must be set if %s is %s", EnvProfilingEndpoint, EnvProfilingBackend, c.ProfilingBackend)
+		}
+	default:
+		log.Panicf("%s must be %s or %s (got: %s)", EnvProfilingBackend, ProfilingBackendPprof, ProfilingBackendPyroscope, c.ProfilingBackend)
+	}
+	c.ProfilingInterval = 15 * time.Second
+	if os.Getenv(EnvProfilingInterval) != "" {
+		c.ProfilingInterval = mustParseDuration(EnvProfilingInterval)
+	}
+
+	c.PluginHealthInterval = 30 * time.Second
+	if os.Getenv(EnvPluginHealthInterval) != "" {
+		c.PluginHealthInterval = mustParseDuration(EnvPluginHealthInterval)
+	}
 	c.ShoesPluginOutputPath = "."
 	if os.Getenv(EnvShoesPluginOutputPath) != "" {
 		c.ShoesPluginOutputPath = os.Getenv(EnvShoesPluginOutputPath)
 	}
 
+	c.EC2Region = os.Getenv("AWS_REGION")
+	if os.Getenv(EnvEC2Region) != "" {
+		c.EC2Region = os.Getenv(EnvEC2Region)
+	}
+	c.EC2LaunchTemplate = os.Getenv(EnvEC2LaunchTemplate)
+	c.EC2LaunchTemplateVersion = "$Default"
+	if os.Getenv(EnvEC2LaunchTemplateVersion) != "" {
+		c.EC2LaunchTemplateVersion = os.Getenv(EnvEC2LaunchTemplateVersion)
+	}
+	c.EC2InstanceTypes = mustParseKeyValues(EnvEC2InstanceTypes, "resource_type=instance_type")
+	if os.Getenv(EnvEC2Spot) == "true" {
+		c.EC2Spot = true
+	}
+	c.EC2Tags = mustParseKeyValues(EnvEC2Tags, "key=value")
+	if os.Getenv(EnvEC2MaxInstances) != "" {
+		n, err := strconv.Atoi(os.Getenv(EnvEC2MaxInstances))
+		if err != nil || n < 0 {
+			log.Panicf("failed to parse %s (must be positive integer or 0): %+v", EnvEC2MaxInstances, err)
+		}
+		c.EC2MaxInstances = n
+	}
+
+	c.DockerHost = "unix:///var/run/docker.sock"
+	if os.Getenv(EnvDockerHost) != "" {
+		c.DockerHost = os.Getenv(EnvDockerHost)
+	}
+	c.DockerRunnerImage = "ubuntu:22.04"
+	if os.Getenv(EnvDockerRunnerImage) != "" {
+		c.DockerRunnerImage = os.Getenv(EnvDockerRunnerImage)
+	}
+	c.DockerRunnerNetwork = os.Getenv(EnvDockerRunnerNetwork)
+	if os.Getenv(EnvDockerMountSocket) == "true" {
+		c.DockerMountSocket = true
+	}
+	if os.Getenv(EnvDockerMaxContainers) != "" {
+		n, err := strconv.Atoi(os.Getenv(EnvDockerMaxContainers))
+		if err != nil || n < 0 {
+			log.Panicf("failed to parse %s (must be positive integer or 0): %+v", EnvDockerMaxContainers, err)
+		}
+		c.DockerMaxContainers = n
+	}
+
+	c.ShoesPluginTLSCAPath = os.Getenv(EnvShoesPluginTLSCAPath)
+	c.ShoesPluginTLSCertPath = os.Getenv(EnvShoesPluginTLSCertPath)
+	c.ShoesPluginTLSKeyPath = os.Getenv(EnvShoesPluginTLSKeyPath)
+	if (c.ShoesPluginTLSCertPath == "") != (c.ShoesPluginTLSKeyPath == "") {
+		log.Panicf("%s and %s must be set together", EnvShoesPluginTLSCertPath, EnvShoesPluginTLSKeyPath)
+	}
+
 	Config = c
 	return c
 }
 
+// mustParseKeyValues parse comma separated key=value in environment key (e.g. a=b,c=d)
+func mustParseKeyValues(envKey, format string) map[string]string {
+	values := map[string]string{}
+	if os.Getenv(envKey) == "" {
+		return values
+	}
+	for _, kv := range strings.Split(os.Getenv(envKey), ",") {
+		if strings.TrimSpace(kv) == "" {
+			continue
+		}
+		key, value, found := strings.Cut(strings.TrimSpace(kv), "=")
+		if !found || strings.TrimSpace(key) == "" {
+			log.Panicf("%s must be %s (got: %s)", envKey, format, kv)
+		}
+		values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return values
+}
+
+// mustParseURL validate URL in environment key, URL must have scheme and host
+func mustParseURL(envKey string) string {
+	value := os.Getenv(envKey)
+	u, err := url.Parse(value)
+	if err != nil {
+		log.Panicf("failed to parse URL %s: %+v", value, err)
+	}
+
+	if strings.EqualFold(u.Scheme, "") {
+		log.Panicf("%s must has scheme (value: %s)", envKey, value)
+	}
+	if strings.EqualFold(u.Host, "") {
+		log.Panicf("%s must has host (value: %s)", envKey, value)
+	}
+
+	return value
+}
+
+// mustParseDuration parse duration in environment key (e.g. "30s", "1m")
+func mustParseDuration(envKey string) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(envKey))
+	if err != nil {
+		log.Panicf("failed to parse %s: %+v", envKey, err)
+	}
+	if d < 0 {
+		log.Panicf("%s must be positive (value: %s)", envKey, os.Getenv(envKey))
+	}
+
+	return d
+}
+
 // LoadGitHubApps load config for GitHub Apps
 func LoadGitHubApps() *GitHubApp {
 	var ga GitHubApp
@@ -148,29 +723,98 @@ func LoadGitHubApps() *GitHubApp {
 	if pemBase64ed == "" {
 		log.Panicf("%s must be set", EnvGitHubAppPrivateKeyBase64)
 	}
-	pemByte, err := base64.StdEncoding.DecodeString(pemBase64ed)
+	pemByte, key, err := parsePrivateKey(pemBase64ed)
 	if err != nil {
-		log.Panicf("failed to decode base64 %s: %+v", EnvGitHubAppPrivateKeyBase64, err)
+		log.Panicf("%s is invalid: %+v", EnvGitHubAppPrivateKeyBase64, err)
 	}
 	ga.PEMByte = pemByte
+	ga.PEM = key
+
+	loadGitHubAppSecrets(&ga)
+
+	return &ga
+}
+
+// loadGitHubAppSecrets load secrets of webhook, it is required in all auth modes
+func loadGitHubAppSecrets(ga *GitHubApp) {
+	appSecret := os.Getenv(EnvGitHubAppSecret)
+	if appSecret == "" {
+		log.Panicf("%s must be set", EnvGitHubAppSecret)
+	}
+	if !setAppSecrets(ga, appSecret) {
+		log.Panicf("%s must be set", EnvGitHubAppSecret)
+	}
+}
+
+// setAppSecrets set comma-separated secrets, they are accepted in rotation and first one is current secret.
+// return false if no secret is set
+func setAppSecrets(ga *GitHubApp, appSecret string) bool {
+	for _, secret := range strings.Split(appSecret, ",") {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			ga.AppSecrets = append(ga.AppSecrets, []byte(secret))
+		}
+	}
+	if len(ga.AppSecrets) == 0 {
+		return false
+	}
+	ga.AppSecret = ga.AppSecrets[0]
+	return true
+}
 
+// parsePrivateKey decode base64-encoded private key of GitHub Apps
+func parsePrivateKey(pemBase64ed string) ([]byte, *rsa.PrivateKey, error) {
+	pemByte, err := base64.StdEncoding.DecodeString(pemBase64ed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode base64: %w", err)
+	}
 	block, _ := pem.Decode(pemByte)
 	if block == nil {
-		log.Panicf("%s is invalid format, please input private key ", EnvGitHubAppPrivateKeyBase64)
+		return nil, nil, fmt.Errorf("invalid format, please input private key")
 	}
 	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
 	if err != nil {
-		log.Panicf("%s is invalid format, failed to parse private key: %+v", EnvGitHubAppPrivateKeyBase64, err)
+		return nil, nil, fmt.Errorf("invalid format, failed to parse private key: %w", err)
 	}
-	ga.PEM = key
+	return pemByte, key, nil
+}
 
-	appSecret := os.Getenv(EnvGitHubAppSecret)
-	if appSecret == "" {
-		log.Panicf("%s must be set", EnvGitHubAppSecret)
+// LoadGitHubHosts load additional GitHub hosts that have own GitHub Apps.
+// primaryURL is GITHUB_URL, it can not be set as additional host
+func LoadGitHubHosts(primaryURL string) []GitHubHost {
+	if os.Getenv(EnvGitHubHosts) == "" {
+		return nil
+	}
+	var hosts []GitHubHost
+	if err := json.Unmarshal([]byte(os.Getenv(EnvGitHubHosts)), &hosts); err != nil {
+		log.Panicf("failed to parse %s: %+v", EnvGitHubHosts, err)
 	}
-	ga.AppSecret = []byte(appSecret)
 
-	return &ga
+	seen := map[string]struct{}{strings.ToLower(strings.TrimSuffix(primaryURL, "/")): {}}
+	for i := range hosts {
+		h := &hosts[i]
+		u, err := url.Parse(h.URL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			log.Panicf("url of %s must has scheme and host (value: %s)", EnvGitHubHosts, h.URL)
+		}
+		h.URL = strings.TrimSuffix(h.URL, "/")
+		key := strings.ToLower(h.URL)
+		if _, ok := seen[key]; ok {
+			log.Panicf("%s is duplicated in %s and %s", h.URL, EnvGitHubURL, EnvGitHubHosts)
+		}
+		seen[key] = struct{}{}
+
+		h.App.AppID = h.AppID
+		pemByte, privateKey, err := parsePrivateKey(h.PrivateKeyBase64)
+		if err != nil {
+			log.Panicf("private key of %s in %s is invalid: %+v", h.URL, EnvGitHubHosts, err)
+		}
+		h.App.PEMByte = pemByte
+		h.App.PEM = privateKey
+		if !setAppSecrets(&h.App, h.AppSecret) {
+			log.Panicf("app_secret of %s in %s must be set", h.URL, EnvGitHubHosts)
+		}
+	}
+	return hosts
 }
 
 // LoadMySQLURL load MySQL URL from environment
@@ -182,12 +826,33 @@ func LoadMySQLURL() string {
 	return mysqlURL
 }
 
+// LoadMySQLReadURL load MySQL URL of read replica from environment, return empty if not set
+func LoadMySQLReadURL() string {
+	return os.Getenv(EnvMySQLReadURL)
+}
+
+// LoadSQLitePath load SQLite file path from environment, return empty if not set
+func LoadSQLitePath() string {
+	return os.Getenv(EnvSQLitePath)
+}
+
 // LoadPluginPath load plugin path from environment
 func LoadPluginPath() string {
 	pluginPath := os.Getenv(EnvShoesPluginPath)
 	if pluginPath == "" {
 		log.Panicf("%s must be set", EnvShoesPluginPath)
 	}
+	if strings.HasPrefix(pluginPath, BuiltinPluginPrefix) {
+		log.Printf("use built-in provider %s\n", strings.TrimPrefix(pluginPath, BuiltinPluginPrefix))
+		return pluginPath
+	}
+	if IsRemotePlugin(pluginPath) {
+		if err := checkRemotePlugin(pluginPath); err != nil {
+			log.Panicf("invalid remote plugin: %+v", err)
+		}
+		log.Printf("use remote plugin %s\n", pluginPath)
+		return pluginPath
+	}
 	fp, err := fetch(pluginPath)
 	if err != nil {
 		log.Panicf("failed to fetch plugin binary: %+v", err)
@@ -200,6 +865,65 @@ func LoadPluginPath() string {
 	return absPath
 }
 
+// LoadAdditionalPlugins load additional plugins from environment, e.g. windows=https://example.com/shoes-windows,gpu=./shoes-gpu
+func LoadAdditionalPlugins() map[string]string {
+	if os.Getenv(EnvShoesPlugins) == "" {
+		return nil
+	}
+
+	plugins := map[string]string{}
+	for _, p := range strings.Split(os.Getenv(EnvShoesPlugins), ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		name, pluginPath, found := strings.Cut(strings.TrimSpace(p), "=")
+		if !found || strings.TrimSpace(name) == "" || strings.TrimSpace(pluginPath) == "" {
+			log.Panicf("%s must be name=path (got: %s)", EnvShoesPlugins, p)
+		}
+		if strings.HasPrefix(strings.TrimSpace(pluginPath), BuiltinPluginPrefix) {
+			log.Printf("use built-in provider %s as %s\n", strings.TrimPrefix(strings.TrimSpace(pluginPath), BuiltinPluginPrefix), name)
+			plugins[strings.TrimSpace(name)] = strings.TrimSpace(pluginPath)
+			continue
+		}
+		if IsRemotePlugin(strings.TrimSpace(pluginPath)) {
+			if err := checkRemotePlugin(strings.TrimSpace(pluginPath)); err != nil {
+				log.Panicf("invalid remote plugin of %s: %+v", name, err)
+			}
+			log.Printf("use remote plugin %s as %s\n", strings.TrimSpace(pluginPath), name)
+			plugins[strings.TrimSpace(name)] = strings.TrimSpace(pluginPath)
+			continue
+		}
+		fp, err := fetch(strings.TrimSpace(pluginPath))
+		if err != nil {
+			log.Panicf("failed to fetch plugin binary of %s: %+v", name, err)
+		}
+		absPath, err := checkBinary(fp)
+		if err != nil {
+			log.Panicf("failed to check plugin binary of %s: %+v", name, err)
+		}
+		log.Printf("use plugin path of %s is %s\n", name, absPath)
+		plugins[strings.TrimSpace(name)] = absPath
+	}
+	return plugins
+}
+
+// IsRemotePlugin return true if plugin path is address of plugin server (grpc:// or grpcs://)
+func IsRemotePlugin(pluginPath string) bool {
+	return strings.HasPrefix(pluginPath, RemotePluginScheme) || strings.HasPrefix(pluginPath, RemotePluginTLSScheme)
+}
+
+// checkRemotePlugin check plugin path of remote plugin is grpc://host:port or grpcs://host:port
+func checkRemotePlugin(pluginPath string) error {
+	u, err := url.Parse(pluginPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse plugin path: %w", err)
+	}
+	if u.Hostname() == "" || u.Port() == "" || u.Path != "" {
+		return fmt.Errorf("plugin path must be %shost:port or %shost:port (got: %s)", RemotePluginScheme, RemotePluginTLSScheme, pluginPath)
+	}
+	return nil
+}
+
 func checkBinary(p string) (string, error) {
 	if _, err := os.Stat(p); err != nil {
 		return "", fmt.Errorf("failed to stat file: %w", err)
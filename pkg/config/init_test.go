@@ -0,0 +1,84 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSetAppSecrets(t *testing.T) {
+	tests := []struct {
+		input       string
+		wantCurrent []byte
+		wantSecrets [][]byte
+		wantOK      bool
+	}{
+		{
+			input:       "secret",
+			wantCurrent: []byte("secret"),
+			wantSecrets: [][]byte{[]byte("secret")},
+			wantOK:      true,
+		},
+		{
+			input:       "new-secret, old-secret,older-secret",
+			wantCurrent: []byte("new-secret"),
+			wantSecrets: [][]byte{[]byte("new-secret"), []byte("old-secret"), []byte("older-secret")},
+			wantOK:      true,
+		},
+		{
+			input:       ",new-secret,,old-secret,",
+			wantCurrent: []byte("new-secret"),
+			wantSecrets: [][]byte{[]byte("new-secret"), []byte("old-secret")},
+			wantOK:      true,
+		},
+		{
+			input:  " , ",
+			wantOK: false,
+		},
+	}
+
+	for _, test := range tests {
+		var ga GitHubApp
+		ok := setAppSecrets(&ga, test.input)
+		if ok != test.wantOK {
+			t.Fatalf("setAppSecrets(%q) want %t, but got %t", test.input, test.wantOK, ok)
+		}
+		if !ok {
+			continue
+		}
+		if !reflect.DeepEqual(ga.AppSecret, test.wantCurrent) {
+			t.Errorf("setAppSecrets(%q) want current secret %s, but got %s", test.input, test.wantCurrent, ga.AppSecret)
+		}
+		if !reflect.DeepEqual(ga.Secrets(), test.wantSecrets) {
+			t.Errorf("setAppSecrets(%q) want secrets %s, but got %s", test.input, test.wantSecrets, ga.Secrets())
+		}
+	}
+}
+
+func TestLoadWithDefault_LockTTL(t *testing.T) {
+	tests := []struct {
+		input     string
+		want      time.Duration
+		wantPanic bool
+	}{
+		{input: "", want: 15 * time.Second},
+		{input: "1s", want: 1 * time.Second},
+		{input: "0", wantPanic: true},
+		{input: "500ms", wantPanic: true},
+	}
+
+	for _, test := range tests {
+		t.Setenv(EnvLockTTL, test.input)
+		func() {
+			defer func() {
+				if r := recover(); (r != nil) != test.wantPanic {
+					t.Errorf("LOCK_TTL=%q want panic %t, but got %v", test.input, test.wantPanic, r)
+				}
+			}()
+			c := LoadWithDefault()
+			if c.LockTTL != test.want {
+				t.Errorf("LOCK_TTL=%q want %s, but got %s", test.input, test.want, c.LockTTL)
+			}
+		}()
+	}
+}
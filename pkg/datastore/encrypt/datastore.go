@@ -0,0 +1,141 @@
+package encrypt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// Datastore is datastore.Datastore that encrypt tokens at rest.
+// token columns are encrypted before write and decrypted after read.
+type Datastore struct {
+	datastore.Datastore
+
+	encrypter *Encrypter
+}
+
+// Wrap wrap datastore by encrypter
+func Wrap(ds datastore.Datastore, encrypter *Encrypter) *Datastore {
+	return &Datastore{
+		Datastore: ds,
+		encrypter: encrypter,
+	}
+}
+
+// CreateTarget create a target with encrypted token
+func (d *Datastore) CreateTarget(ctx context.Context, target datastore.Target) error {
+	token, err := d.encrypter.Encrypt(target.GitHubToken)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt token: %w", err)
+	}
+	target.GitHubToken = token
+
+	return d.Datastore.CreateTarget(ctx, target)
+}
+
+// GetTarget get a target with decrypted token
+func (d *Datastore) GetTarget(ctx context.Context, id uuid.UUID) (*datastore.Target, error) {
+	t, err := d.Datastore.GetTarget(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.decryptTarget(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetTargetByScope get a target from scope with decrypted token
+func (d *Datastore) GetTargetByScope(ctx context.Context, gheDomain, scope string) (*datastore.Target, error) {
+	t, err := d.Datastore.GetTargetByScope(ctx, gheDomain, scope)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.decryptTarget(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ListTargets get a page of targets with decrypted token
+func (d *Datastore) ListTargets(ctx context.Context, opt datastore.ListOption) ([]datastore.Target, error) {
+	ts, err := d.Datastore.ListTargets(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	return d.decryptTargets(ts)
+}
+
+// ListDeletedTargets get a page of soft deleted targets with decrypted token
+func (d *Datastore) ListDeletedTargets(ctx context.Context, opt datastore.ListOption) ([]datastore.Target, error) {
+	ts, err := d.Datastore.ListDeletedTargets(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	return d.decryptTargets(ts)
+}
+
+// ListTargetsByExternalRef get targets that has external reference ID with decrypted token
+func (d *Datastore) ListTargetsByExternalRef(ctx context.Context, externalRef string) ([]datastore.Target, error) {
+	ts, err := d.Datastore.ListTargetsByExternalRef(ctx, externalRef)
+	if err != nil {
+		return nil, err
+	}
+	return d.decryptTargets(ts)
+}
+
+// ExportTargets get all targets with decrypted token
+func (d *Datastore) ExportTargets(ctx context.Context) ([]datastore.Target, error) {
+	ts, err := d.Datastore.ExportTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return d.decryptTargets(ts)
+}
+
+// ImportTargets create targets with encrypted token
+func (d *Datastore) ImportTargets(ctx context.Context, targets []datastore.Target) (int64, error) {
+	encrypted := make([]datastore.Target, 0, len(targets))
+	for _, t := range targets {
+		token, err := d.encrypter.Encrypt(t.GitHubToken)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encrypt token (target ID: %s): %w", t.UUID, err)
+		}
+		t.GitHubToken = token
+		encrypted = append(encrypted, t)
+	}
+
+	return d.Datastore.ImportTargets(ctx, encrypted)
+}
+
+// UpdateToken update encrypted token in target
+func (d *Datastore) UpdateToken(ctx context.Context, targetID uuid.UUID, newToken string, newExpiredAt time.Time) error {
+	token, err := d.encrypter.Encrypt(newToken)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt token: %w", err)
+	}
+
+	return d.Datastore.UpdateToken(ctx, targetID, token, newExpiredAt)
+}
+
+func (d *Datastore) decryptTarget(t *datastore.Target) error {
+	token, err := d.encrypter.Decrypt(t.GitHubToken)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt token (target ID: %s): %w", t.UUID, err)
+	}
+	t.GitHubToken = token
+	return nil
+}
+
+func (d *Datastore) decryptTargets(ts []datastore.Target) ([]datastore.Target, error) {
+	for i := range ts {
+		if err := d.decryptTarget(&ts[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ts, nil
+}
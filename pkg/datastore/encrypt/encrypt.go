@@ -0,0 +1,161 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// prefix of encrypted value. value that has not prefix is plaintext (e.g. written before enabling encryption)
+const prefix = "enc:v1:"
+
+// ErrInvalidCiphertext is error for broken encrypted value
+var ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+// KeyWrapper encrypt and decrypt a data key by a master key.
+// It can implement by KMS (e.g. AWS KMS, Cloud KMS).
+type KeyWrapper interface {
+	Wrap(dataKey []byte) ([]byte, error)
+	Unwrap(wrappedKey []byte) ([]byte, error)
+}
+
+// LocalKeyWrapper is KeyWrapper that use AES-GCM master key in config
+type LocalKeyWrapper struct {
+	aead cipher.AEAD
+}
+
+// NewLocalKeyWrapper create LocalKeyWrapper. masterKey must be 16, 24 or 32 bytes.
+func NewLocalKeyWrapper(masterKey []byte) (*LocalKeyWrapper, error) {
+	aead, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher from master key: %w", err)
+	}
+
+	return &LocalKeyWrapper{aead: aead}, nil
+}
+
+// Wrap encrypt a data key
+func (l *LocalKeyWrapper) Wrap(dataKey []byte) ([]byte, error) {
+	return seal(l.aead, dataKey)
+}
+
+// Unwrap decrypt a data key
+func (l *LocalKeyWrapper) Unwrap(wrappedKey []byte) ([]byte, error) {
+	return open(l.aead, wrappedKey)
+}
+
+// Encrypter encrypt value by envelope encryption.
+// a data key is generated for each value, and data key is encrypted by KeyWrapper.
+type Encrypter struct {
+	wrapper KeyWrapper
+}
+
+// New create Encrypter
+func New(wrapper KeyWrapper) *Encrypter {
+	return &Encrypter{wrapper: wrapper}
+}
+
+// Encrypt encrypt plaintext
+func (e *Encrypter) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher from data key: %w", err)
+	}
+	ciphertext, err := seal(aead, []byte(plaintext))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt value: %w", err)
+	}
+	wrappedKey, err := e.wrapper.Wrap(dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	return prefix + base64.RawStdEncoding.EncodeToString(wrappedKey) + ":" + base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt decrypt value. return as it is if value is not encrypted.
+func (e *Encrypter) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	s := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(s) != 2 {
+		return "", ErrInvalidCiphertext
+	}
+	wrappedKey, err := base64.RawStdEncoding.DecodeString(s[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to decode data key: %w", err)
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(s[1])
+	if err != nil {
+		return "", fmt.Errorf("failed to decode value: %w", err)
+	}
+
+	dataKey, err := e.wrapper.Unwrap(wrappedKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher from data key: %w", err)
+	}
+	plaintext, err := open(aead, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// IsEncrypted check value is encrypted by Encrypter
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return aead, nil
+}
+
+// seal encrypt plaintext, return nonce + ciphertext
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypt nonce + ciphertext
+func open(aead cipher.AEAD, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	nonce, body := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, body, nil)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,67 @@
+package datastore
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/event"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// HistoryResourceType is type of resource in StateHistory
+type HistoryResourceType string
+
+// HistoryResourceType values
+const (
+	HistoryResourceJob    HistoryResourceType = "job"
+	HistoryResourceRunner HistoryResourceType = "runner"
+)
+
+// HistoryStatus is status of resource in StateHistory
+type HistoryStatus string
+
+// HistoryStatus values
+const (
+	HistoryStatusEnqueued   HistoryStatus = "enqueued"
+	HistoryStatusDispatched HistoryStatus = "dispatched"
+	HistoryStatusDeferred   HistoryStatus = "deferred"
+	HistoryStatusCreated    HistoryStatus = "created"
+	HistoryStatusRegistered HistoryStatus = "registered"
+	HistoryStatusDeleting   HistoryStatus = "deleting"
+	HistoryStatusDeleted    HistoryStatus = "deleted"
+	HistoryStatusExpired    HistoryStatus = "expired"
+	HistoryStatusFailed     HistoryStatus = "failed"
+	// HistoryStatusTokenIssued is that registration token is fetched by instance, it is issued only once per runner
+	HistoryStatusTokenIssued HistoryStatus = "token_issued"
+	// HistoryStatusWaiting is that job is removed from queue because it is waiting for approval of deployment environment
+	HistoryStatusWaiting HistoryStatus = "waiting"
+)
+
+// StateHistory is a record of status transition in job or runner
+type StateHistory struct {
+	ResourceType HistoryResourceType `db:"resource_type" json:"resource_type"`
+	ResourceID   uuid.UUID           `db:"resource_id" json:"resource_id"`
+	Status       HistoryStatus       `db:"status" json:"status"`
+	Reason       string              `db:"reason" json:"reason"`
+	CreatedAt    time.Time           `db:"created_at" json:"created_at"`
+}
+
+// RecordHistory record status transition to datastore, and publish it to event.TopicStateChanged.
+// history is for debugging, So failure of recording is only logged, and it is sampled if datastore is under pressure.
+func RecordHistory(ctx context.Context, ds Datastore, resourceType HistoryResourceType, resourceID uuid.UUID, status HistoryStatus, reason string) {
+	h := StateHistory{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Status:       status,
+		Reason:       reason,
+	}
+	// histories are sampled under pressure of datastore, all of them are published
+	if pressure.shouldWrite() {
+		if err := ds.CreateStateHistory(ctx, h); err != nil {
+			logger.Logf(false, "failed to record history (%s: %s, status: %s): %+v", resourceType, resourceID, status, err)
+		}
+	}
+	event.Publish(ctx, event.TopicStateChanged, h)
+}
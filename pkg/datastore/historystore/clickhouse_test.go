@@ -0,0 +1,91 @@
+package historystore
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+func TestClickHouse(t *testing.T) {
+	testRunnerID := uuid.FromStringOrNil("5b3bd8a4-8f3b-4d2a-9a4d-0f7e3b5a1c2d")
+	testCreatedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var inserted string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "default" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if got := r.URL.Query().Get("database"); got != "myshoes" {
+			t.Errorf("invalid database (got: %s)", got)
+		}
+		query := r.URL.Query().Get("query")
+		switch {
+		case strings.HasPrefix(query, "INSERT INTO state_histories"):
+			b, _ := io.ReadAll(r.Body)
+			inserted = string(b)
+		case strings.HasPrefix(query, "SELECT"):
+			if got := r.URL.Query().Get("param_id"); got != testRunnerID.String() {
+				t.Errorf("invalid param_id (got: %s)", got)
+			}
+			io.WriteString(w, `{"resource_type":"runner","resource_id":"5b3bd8a4-8f3b-4d2a-9a4d-0f7e3b5a1c2d","status":"created","reason":"","created_at":"2024-01-02T03:04:05.000Z"}`+"\n")
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, "Code: 62. DB::Exception: Syntax error")
+		}
+	}))
+	defer ts.Close()
+
+	c, err := NewClickHouse(strings.Replace(ts.URL, "http://", "http://default:secret@", 1) + "/myshoes")
+	if err != nil {
+		t.Fatalf("failed to create store: %+v", err)
+	}
+
+	history := datastore.StateHistory{
+		ResourceType: datastore.HistoryResourceRunner,
+		ResourceID:   testRunnerID,
+		Status:       datastore.HistoryStatusCreated,
+		CreatedAt:    testCreatedAt,
+	}
+	if err := c.CreateStateHistory(context.Background(), history); err != nil {
+		t.Fatalf("failed to create history: %+v", err)
+	}
+	wantInserted := `{"resource_type":"runner","resource_id":"5b3bd8a4-8f3b-4d2a-9a4d-0f7e3b5a1c2d","status":"created","reason":"","created_at":"2024-01-02T03:04:05Z"}`
+	if inserted != wantInserted {
+		t.Errorf("want %s, but got %s", wantInserted, inserted)
+	}
+
+	got, err := c.ListStateHistories(context.Background(), testRunnerID)
+	if err != nil {
+		t.Fatalf("failed to list histories: %+v", err)
+	}
+	if diff := cmp.Diff([]datastore.StateHistory{history}, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClickHouse_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, "Code: 60. DB::Exception: Table myshoes.state_histories does not exist")
+	}))
+	defer ts.Close()
+
+	c, err := NewClickHouse(ts.URL)
+	if err != nil {
+		t.Fatalf("failed to create store: %+v", err)
+	}
+	err = c.CreateStateHistory(context.Background(), datastore.StateHistory{ResourceID: uuid.NewV4()})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("want error of ClickHouse, but got %+v", err)
+	}
+}
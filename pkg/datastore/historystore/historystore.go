@@ -0,0 +1,61 @@
+package historystore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// Store is persistence of state histories, it is used instead of datastore for high-volume records
+type Store interface {
+	// CreateStateHistory record a status transition of job or runner
+	CreateStateHistory(ctx context.Context, history datastore.StateHistory) error
+	// ListStateHistories get status transitions of job or runner, sorted by created_at
+	ListStateHistories(ctx context.Context, resourceID uuid.UUID) ([]datastore.StateHistory, error)
+	// PurgeStateHistories delete histories that created before `before`, up to limit rows. return number of deleted rows
+	PurgeStateHistories(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// Datastore is datastore.Datastore that route state histories to Store.
+// other records are stored in wrapped datastore, so transactional datastore is kept small.
+type Datastore struct {
+	datastore.Datastore
+
+	store Store
+}
+
+// Wrap wrap datastore, state histories are stored in store
+func Wrap(ds datastore.Datastore, store Store) *Datastore {
+	return &Datastore{
+		Datastore: ds,
+		store:     store,
+	}
+}
+
+// New create Store
+func New(backend, endpoint string) (Store, error) {
+	switch backend {
+	case "clickhouse":
+		return NewClickHouse(endpoint)
+	}
+	return nil, fmt.Errorf("unknown history store backend: %s", backend)
+}
+
+// CreateStateHistory record a status transition of job or runner
+func (d *Datastore) CreateStateHistory(ctx context.Context, history datastore.StateHistory) error {
+	return d.store.CreateStateHistory(ctx, history)
+}
+
+// ListStateHistories get status transitions of job or runner, sorted by created_at
+func (d *Datastore) ListStateHistories(ctx context.Context, resourceID uuid.UUID) ([]datastore.StateHistory, error) {
+	return d.store.ListStateHistories(ctx, resourceID)
+}
+
+// PurgeStateHistories delete histories that created before `before`, up to limit rows. return number of deleted rows
+func (d *Datastore) PurgeStateHistories(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return d.store.PurgeStateHistories(ctx, before, limit)
+}
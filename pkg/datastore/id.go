@@ -0,0 +1,108 @@
+package datastore
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// IDGenerator generate ID of resources (target, job, runner)
+type IDGenerator interface {
+	Generate() uuid.UUID
+}
+
+// ID generator types
+const (
+	IDGeneratorUUIDv4 = "uuidv4"
+	IDGeneratorULID   = "ulid"
+)
+
+var (
+	idGeneratorMu sync.RWMutex
+	idGenerator   IDGenerator = UUIDv4Generator{}
+)
+
+// NewIDGenerator create IDGenerator from name
+func NewIDGenerator(name string) (IDGenerator, error) {
+	switch name {
+	case "", IDGeneratorUUIDv4:
+		return UUIDv4Generator{}, nil
+	case IDGeneratorULID:
+		return &ULIDGenerator{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown id generator: %s", name)
+}
+
+// SetIDGenerator set IDGenerator that is used by NewID
+func SetIDGenerator(g IDGenerator) {
+	idGeneratorMu.Lock()
+	defer idGeneratorMu.Unlock()
+
+	idGenerator = g
+}
+
+// NewID generate a new ID
+func NewID() uuid.UUID {
+	idGeneratorMu.RLock()
+	defer idGeneratorMu.RUnlock()
+
+	return idGenerator.Generate()
+}
+
+// UUIDv4Generator generate random UUID (version 4)
+type UUIDv4Generator struct{}
+
+// Generate generate a new ID
+func (UUIDv4Generator) Generate() uuid.UUID {
+	return uuid.NewV4()
+}
+
+// ULIDGenerator generate ULID (https://github.com/ulid/spec) as uuid.UUID.
+// first 48 bits is unix time in milliseconds, So IDs are sortable by created time.
+// IDs are monotonic in same milliseconds.
+type ULIDGenerator struct {
+	mu       sync.Mutex
+	lastMs   uint64
+	lastRand [10]byte
+}
+
+// Generate generate a new ID
+func (g *ULIDGenerator) Generate() uuid.UUID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(time.Now().UnixMilli())
+	if ms <= g.lastMs {
+		// same milliseconds (or clock is back), increment random part
+		ms = g.lastMs
+		incrementBytes(g.lastRand[:])
+	} else {
+		if _, err := rand.Read(g.lastRand[:]); err != nil {
+			// crypto/rand is not available, fallback to UUID v4
+			return uuid.NewV4()
+		}
+		g.lastMs = ms
+	}
+
+	var u uuid.UUID
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], ms)
+	copy(u[0:6], ts[2:8])
+	copy(u[6:16], g.lastRand[:])
+
+	return u
+}
+
+func incrementBytes(b []byte) {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return
+		}
+	}
+}
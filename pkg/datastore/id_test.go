@@ -0,0 +1,44 @@
+package datastore
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewIDGenerator(t *testing.T) {
+	tests := []struct {
+		input string
+		err   bool
+	}{
+		{input: "", err: false},
+		{input: IDGeneratorUUIDv4, err: false},
+		{input: IDGeneratorULID, err: false},
+		{input: "unknown", err: true},
+	}
+
+	for _, test := range tests {
+		_, err := NewIDGenerator(test.input)
+		if !test.err && err != nil {
+			t.Fatalf("failed to create id generator: %+v", err)
+		}
+		if test.err && err == nil {
+			t.Fatalf("must be error, but not error (input: %s)", test.input)
+		}
+	}
+}
+
+func TestULIDGenerator_Generate(t *testing.T) {
+	g := &ULIDGenerator{}
+
+	prev := g.Generate()
+	for i := 0; i < 1000; i++ {
+		got := g.Generate()
+		if bytes.Compare(prev.Bytes(), got.Bytes()) >= 0 {
+			t.Fatalf("ID must be sortable, but %s >= %s", prev, got)
+		}
+		if prev.String() >= got.String() {
+			t.Fatalf("string of ID must be sortable, but %s >= %s", prev, got)
+		}
+		prev = got
+	}
+}
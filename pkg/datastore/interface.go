@@ -18,6 +18,8 @@ import (
 // Error values
 var (
 	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned if a row is updated by other process after it is read
+	ErrConflict = errors.New("conflict")
 )
 
 // Lock values
@@ -30,25 +32,107 @@ var (
 type Datastore interface {
 	CreateTarget(ctx context.Context, target Target) error
 	GetTarget(ctx context.Context, id uuid.UUID) (*Target, error)
-	GetTargetByScope(ctx context.Context, scope string) (*Target, error)
-	ListTargets(ctx context.Context) ([]Target, error)
+	// GetTargetByScope get a target from scope in GitHub host of gheDomain, empty gheDomain is github.com
+	GetTargetByScope(ctx context.Context, gheDomain, scope string) (*Target, error)
+	// ListTargets get a page of not deleted targets, sorted by uuid
+	ListTargets(ctx context.Context, opt ListOption) ([]Target, error)
+	// ListDeletedTargets get a page of soft deleted targets, sorted by uuid
+	ListDeletedTargets(ctx context.Context, opt ListOption) ([]Target, error)
+	// ListTargetsByExternalRef get not deleted targets that has external reference ID
+	ListTargetsByExternalRef(ctx context.Context, externalRef string) ([]Target, error)
+	// DeleteTarget soft delete a target, configuration is kept and can be restored by RestoreTarget
 	DeleteTarget(ctx context.Context, id uuid.UUID) error
+	// RestoreTarget restore a soft deleted target as active. return ErrNotFound if target is not deleted
+	RestoreTarget(ctx context.Context, id uuid.UUID) error
 
 	// Deprecated: Use datastore.UpdateTargetStatus.
 	UpdateTargetStatus(ctx context.Context, targetID uuid.UUID, newStatus TargetStatus, description string) error
 	UpdateToken(ctx context.Context, targetID uuid.UUID, newToken string, newExpiredAt time.Time) error
 
 	UpdateTargetParam(ctx context.Context, targetID uuid.UUID, newResourceType ResourceType, newProviderURL sql.NullString) error
-
-	EnqueueJob(ctx context.Context, job Job) error
-	ListJobs(ctx context.Context) ([]Job, error)
+	UpdateTargetPlacementParams(ctx context.Context, targetID uuid.UUID, newPlacementParams sql.NullString) error
+	UpdateTargetUserDataFormat(ctx context.Context, targetID uuid.UUID, newFormat UserDataFormat) error
+	UpdateTargetRunnerRegistration(ctx context.Context, targetID uuid.UUID, newRegistration RunnerRegistration) error
+	UpdateTargetRunnerLabels(ctx context.Context, targetID uuid.UUID, newLabels sql.NullString) error
+	UpdateTargetQuietHours(ctx context.Context, targetID uuid.UUID, newQuietHours sql.NullString) error
+	UpdateTargetRootless(ctx context.Context, targetID uuid.UUID, newRuntime RootlessRuntime) error
+	UpdateTargetJobFilters(ctx context.Context, targetID uuid.UUID, newJobFilters sql.NullString) error
+
+	// ExportTargets get all targets include deleted targets for backup
+	ExportTargets(ctx context.Context) ([]Target, error)
+	// ImportTargets create targets in a transaction for restore, skip targets that already exist by uuid or scope. return number of created targets
+	ImportTargets(ctx context.Context, targets []Target) (int64, error)
+	// PurgeScope delete targets in scope (repository, or organization and its repositories) and all of jobs, runners and histories of them in a transaction.
+	// return ErrNotFound if no target is in scope
+	PurgeScope(ctx context.Context, scope string) (*PurgeResult, error)
+	// RenameScope rename scope of targets in scope (repository, or organization and its repositories) and repository of jobs in GitHub host of gheDomain in a transaction (e.g. repository is transferred).
+	// empty gheDomain is github.com. return ErrNotFound if no target is in scope, ErrConflict if a target already exists in renamed scope. return number of renamed targets
+	RenameScope(ctx context.Context, gheDomain, scope, newScope string) (int64, error)
+
+	// EnqueueJob add a job, return the stored job.
+	// if a job that has same DedupKey is already enqueued, the existing job is returned instead of adding it
+	EnqueueJob(ctx context.Context, job Job) (*Job, error)
+	// GetJobByDedupKey get a job in queue that has DedupKey, return ErrNotFound if it is not enqueued
+	GetJobByDedupKey(ctx context.Context, dedupKey string) (*Job, error)
+	// ListJobs get a page of jobs, sorted by uuid
+	ListJobs(ctx context.Context, opt ListOption) ([]Job, error)
+	// ListReadyJobs get jobs that can dispatch at now (not_before is null or passed)
+	ListReadyJobs(ctx context.Context, now time.Time) ([]Job, error)
+	// CountPendingJobs count jobs in queue of target, count all jobs if targetID is uuid.Nil
+	CountPendingJobs(ctx context.Context, targetID uuid.UUID) (int, error)
+	// OldestPendingJobAge get elapsed time since the oldest job in queue was created, return 0 if queue is empty
+	OldestPendingJobAge(ctx context.Context) (time.Duration, error)
+	// ListJobsByExternalRef get jobs that has external reference ID
+	ListJobsByExternalRef(ctx context.Context, externalRef string) ([]Job, error)
+	// DeferJob update not_before of job, job will not dispatch before notBefore
+	DeferJob(ctx context.Context, id uuid.UUID, notBefore time.Time) error
 	DeleteJob(ctx context.Context, id uuid.UUID) error
+	// DeleteJobsBulk delete jobs by a statement. return number of deleted jobs
+	DeleteJobsBulk(ctx context.Context, ids []uuid.UUID) (int64, error)
 
 	CreateRunner(ctx context.Context, runner Runner) error
-	ListRunners(ctx context.Context) ([]Runner, error)
+	// ListRunners get a page of not deleted runners, sorted by uuid
+	ListRunners(ctx context.Context, opt ListOption) ([]Runner, error)
 	ListRunnersByTargetID(ctx context.Context, targetID uuid.UUID) ([]Runner, error)
 	GetRunner(ctx context.Context, id uuid.UUID) (*Runner, error)
+	// UpdateRunnerWithVersion update ip_address, cloud_id and runner_name of a runner only if version is not changed from read (runner.Version).
+	// return ErrConflict if runner is updated or deleted by other process
+	UpdateRunnerWithVersion(ctx context.Context, runner Runner) error
+	// MarkRunnerTokenIssued set time that registration token of a running runner is issued, only if it is not issued yet.
+	// return ErrConflict if token is already issued or runner is not running
+	MarkRunnerTokenIssued(ctx context.Context, id uuid.UUID, issuedAt time.Time) error
 	DeleteRunner(ctx context.Context, id uuid.UUID, deletedAt time.Time, reason RunnerStatus) error
+	// DeleteRunnerWithVersion delete a runner only if version is not changed from read.
+	// return ErrConflict if runner is updated, ErrNotFound if runner is already deleted
+	DeleteRunnerWithVersion(ctx context.Context, id uuid.UUID, version int64, deletedAt time.Time, reason RunnerStatus) error
+	// DeleteRunnersBulk delete runners by a statement per table in a transaction, only if version is not changed from read (runner.Version).
+	// runners that already deleted are skipped, return IDs of deleted runners.
+	// return ErrConflict with deleted runners if some runners are updated by other process, they are not deleted
+	DeleteRunnersBulk(ctx context.Context, runners []Runner, deletedAt time.Time, reason RunnerStatus) ([]uuid.UUID, error)
+	// PurgeDeletedRunners delete history of runners that deleted before `before`, up to limit runners. return number of deleted runners
+	PurgeDeletedRunners(ctx context.Context, before time.Time, limit int) (int64, error)
+
+	CreateRunnerHookResult(ctx context.Context, result RunnerHookResult) error
+	ListRunnerHookResults(ctx context.Context, runnerID uuid.UUID) ([]RunnerHookResult, error)
+
+	// CreateStateHistory record a status transition of job or runner
+	CreateStateHistory(ctx context.Context, history StateHistory) error
+	// ListStateHistories get status transitions of job or runner, sorted by created_at
+	ListStateHistories(ctx context.Context, resourceID uuid.UUID) ([]StateHistory, error)
+	// PurgeStateHistories delete histories that created before `before`, up to limit rows. return number of deleted rows
+	PurgeStateHistories(ctx context.Context, before time.Time, limit int) (int64, error)
+
+	// CreateJobHistory record that a job is received
+	CreateJobHistory(ctx context.Context, history JobHistory) error
+	// SetJobHistoryTime set time of event in history of job, time that already set is not overwritten
+	SetJobHistoryTime(ctx context.Context, jobID uuid.UUID, event JobHistoryEvent, at time.Time) error
+	// SetJobHistoryTimeByGitHubJobID set time of event in history of job that has ID of job in GitHub
+	SetJobHistoryTimeByGitHubJobID(ctx context.Context, githubJobID int64, event JobHistoryEvent, at time.Time) error
+	// ListJobHistories get histories of jobs that received since `since`, sorted by received_at in descending order.
+	// histories of all targets are returned if targetID is uuid.Nil, limit 0 is unlimited
+	ListJobHistories(ctx context.Context, targetID uuid.UUID, since time.Time, limit int) ([]JobHistory, error)
+	// PurgeJobHistories delete histories that received before `before`, up to limit rows. return number of deleted rows
+	PurgeJobHistories(ctx context.Context, before time.Time, limit int) (int64, error)
 
 	// Lock
 	GetLock(ctx context.Context) error
@@ -64,12 +148,26 @@ type Target struct {
 	TokenExpiredAt time.Time      `db:"token_expired_at" json:"token_expired_at"`
 	GHEDomain      sql.NullString `db:"ghe_domain" json:"ghe_domain"`
 
-	ResourceType      ResourceType   `db:"resource_type" json:"resource_type"`
-	ProviderURL       sql.NullString `db:"provider_url" json:"provider_url"`
-	Status            TargetStatus   `db:"status" json:"status"`
-	StatusDescription sql.NullString `db:"status_description" json:"status_description"`
-	CreatedAt         time.Time      `db:"created_at" json:"created_at"`
-	UpdatedAt         time.Time      `db:"updated_at" json:"updated_at"`
+	ResourceType       ResourceType       `db:"resource_type" json:"resource_type"`
+	ProviderURL        sql.NullString     `db:"provider_url" json:"provider_url"`
+	Status             TargetStatus       `db:"status" json:"status"`
+	StatusDescription  sql.NullString     `db:"status_description" json:"status_description"`
+	ExternalRef        sql.NullString     `db:"external_ref" json:"external_ref"`               // ID in external system (e.g. CMDB), set by creator
+	PlacementParams    sql.NullString     `db:"placement_params" json:"placement_params"`       // JSON object, pass through to shoes-provider
+	UserDataFormat     UserDataFormat     `db:"user_data_format" json:"user_data_format"`       // format of setup script, empty is shell script
+	RunnerRegistration RunnerRegistration `db:"runner_registration" json:"runner_registration"` // method of registering runner, empty is registration token
+	RunnerLabels       sql.NullString     `db:"runner_labels" json:"runner_labels"`             // comma-separated labels that are injected to runners
+	QuietHours         sql.NullString     `db:"quiet_hours" json:"quiet_hours"`                 // JSON object of QuietHours, non-urgent deletions are deferred in windows
+	Rootless           RootlessRuntime    `db:"rootless" json:"rootless"`                       // container runtime of rootless runner, empty is rootful docker
+	JobFilters         sql.NullString     `db:"job_filters" json:"job_filters"`                 // JSON object of JobFilters, refused jobs are not enqueued
+	DeletedAt          sql.NullTime       `db:"deleted_at" json:"deleted_at"`                   // soft deleted time
+	CreatedAt          time.Time          `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time          `db:"updated_at" json:"updated_at"`
+}
+
+// NullGHEDomain return value of ghe_domain column, github.com (empty) is NULL
+func NullGHEDomain(gheDomain string) sql.NullString {
+	return sql.NullString{String: gheDomain, Valid: gheDomain != ""}
 }
 
 // OwnerRepo return :owner and :repo
@@ -77,6 +175,47 @@ func (t *Target) OwnerRepo() (string, string) {
 	return gh.DivideScope(t.Scope)
 }
 
+// WithGitHubHost set GitHub host of target (ghe_domain) to context, clients of GitHub are created in the host
+func (t *Target) WithGitHubHost(ctx context.Context) (context.Context, error) {
+	h, err := gh.LookupHost(t.GHEDomain.String)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GitHub host of target: %w", err)
+	}
+	return gh.WithHost(ctx, h), nil
+}
+
+// InjectedRunnerLabels return labels that are injected to runners of target
+func (t *Target) InjectedRunnerLabels() []string {
+	if !t.RunnerLabels.Valid {
+		return nil
+	}
+	return ParseRunnerLabels(t.RunnerLabels.String)
+}
+
+// InQuietHours return true if now is in quiet hours of target
+func (t *Target) InQuietHours(now time.Time) bool {
+	if !t.QuietHours.Valid || t.QuietHours.String == "" {
+		return false
+	}
+	q, err := ParseQuietHours([]byte(t.QuietHours.String))
+	if err != nil {
+		return false
+	}
+	return q.Contains(now)
+}
+
+// ParsedJobFilters return job filters of target, nil if not set or invalid
+func (t *Target) ParsedJobFilters() *JobFilters {
+	if !t.JobFilters.Valid || t.JobFilters.String == "" {
+		return nil
+	}
+	f, err := ParseJobFilters([]byte(t.JobFilters.String))
+	if err != nil {
+		return nil
+	}
+	return f
+}
+
 // CanReceiveJob check status in target
 func (t *Target) CanReceiveJob() bool {
 	switch t.Status {
@@ -87,9 +226,23 @@ func (t *Target) CanReceiveJob() bool {
 	return true
 }
 
-// ListTargets get list of target that can receive job
+// ListTargets get list of target that can receive job.
+// ListTargets load all targets, use WalkTargets for iterating in pages.
 func ListTargets(ctx context.Context, ds Datastore) ([]Target, error) {
-	targets, err := ds.ListTargets(ctx)
+	var result []Target
+	if err := WalkTargets(ctx, ds, func(targets []Target) error {
+		result = append(result, targets...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ListTargetsByExternalRef get list of target that has externalRef and can receive job
+func ListTargetsByExternalRef(ctx context.Context, ds Datastore, externalRef string) ([]Target, error) {
+	targets, err := ds.ListTargetsByExternalRef(ctx, externalRef)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get targets from datastore: %w", err)
 	}
@@ -105,6 +258,37 @@ func ListTargets(ctx context.Context, ds Datastore) ([]Target, error) {
 	return result, nil
 }
 
+// DeleteJobsByTargetID delete queued jobs of target, return number of deleted jobs
+func DeleteJobsByTargetID(ctx context.Context, ds Datastore, targetID uuid.UUID) (int, error) {
+	var ids []uuid.UUID
+	if err := WalkJobs(ctx, ds, func(jobs []Job) error {
+		for _, j := range jobs {
+			if uuid.Equal(j.TargetID, targetID) {
+				ids = append(ids, j.UUID)
+			}
+		}
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("failed to get jobs: %w", err)
+	}
+
+	var deleted int
+	for len(ids) > 0 {
+		chunk := ids[:min(len(ids), DefaultPageSize)]
+		ids = ids[len(chunk):]
+
+		n, err := ds.DeleteJobsBulk(ctx, chunk)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete jobs: %w", err)
+		}
+		deleted += int(n)
+		for _, id := range chunk {
+			RecordHistory(ctx, ds, HistoryResourceJob, id, HistoryStatusDeleted, "target is deleted")
+		}
+	}
+	return deleted, nil
+}
+
 // UpdateTargetStatus update datastore
 func UpdateTargetStatus(ctx context.Context, ds Datastore, targetID uuid.UUID, newStatus TargetStatus, description string) error {
 	target, err := ds.GetTarget(ctx, targetID)
@@ -125,16 +309,17 @@ func UpdateTargetStatus(ctx context.Context, ds Datastore, targetID uuid.UUID, n
 	return nil
 }
 
-// SearchRepo search datastore.Target from datastore
+// SearchRepo search datastore.Target from datastore in GitHub host of ctx (gh.HostFrom)
 // format of repo is "orgs/repos"
 func SearchRepo(ctx context.Context, ds Datastore, repo string) (*Target, error) {
 	sep := strings.Split(repo, "/")
 	if len(sep) != 2 {
 		return nil, fmt.Errorf("incorrect repo format ex: orgs/repo")
 	}
+	gheDomain := gh.HostFrom(ctx).GHEDomain()
 
 	// use repo scope if set repo
-	repoTarget, err := ds.GetTargetByScope(ctx, repo)
+	repoTarget, err := ds.GetTargetByScope(ctx, gheDomain, repo)
 	if err == nil && repoTarget.CanReceiveJob() {
 		return repoTarget, nil
 	} else if err != nil && err != ErrNotFound {
@@ -143,7 +328,7 @@ func SearchRepo(ctx context.Context, ds Datastore, repo string) (*Target, error)
 
 	// repo is not found, so search org target
 	org := sep[0]
-	orgTarget, err := ds.GetTargetByScope(ctx, org)
+	orgTarget, err := ds.GetTargetByScope(ctx, gheDomain, org)
 	if err != nil || !orgTarget.CanReceiveJob() {
 		return nil, fmt.Errorf("failed to get target from organization: %w", err)
 	}
@@ -151,6 +336,21 @@ func SearchRepo(ctx context.Context, ds Datastore, repo string) (*Target, error)
 	return orgTarget, nil
 }
 
+// SearchRepoWithEnterprise search datastore.Target like SearchRepo, and search target of enterprise if repo and org target are not found.
+// enterprise is slug of enterprise that repo belongs to, empty is not belong to enterprise
+func SearchRepoWithEnterprise(ctx context.Context, ds Datastore, repo, enterprise string) (*Target, error) {
+	target, err := SearchRepo(ctx, ds, repo)
+	if err == nil || enterprise == "" {
+		return target, err
+	}
+
+	enterpriseTarget, eerr := ds.GetTargetByScope(ctx, gh.HostFrom(ctx).GHEDomain(), gh.EnterpriseScope(enterprise))
+	if eerr != nil || !enterpriseTarget.CanReceiveJob() {
+		return nil, fmt.Errorf("failed to get target from enterprise (%s): %w", eerr, err)
+	}
+	return enterpriseTarget, nil
+}
+
 // TargetStatus is status for target
 type TargetStatus string
 
@@ -170,10 +370,21 @@ type Job struct {
 	Repository     string         `db:"repository"` // repo (:owner/:repo)
 	CheckEventJSON string         `db:"check_event"`
 	TargetID       uuid.UUID      `db:"target_id"`
+	NotBefore      sql.NullTime   `db:"not_before" json:"not_before"`     // job will not dispatch before this time
+	ExternalRef    sql.NullString `db:"external_ref" json:"external_ref"` // ID in external system (e.g. delivery ID of webhook)
+	DedupKey       sql.NullString `db:"dedup_key" json:"dedup_key"`       // unique key for deduplication (e.g. ID of workflow job)
 	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
 	UpdatedAt      time.Time      `db:"updated_at" json:"updated_at"`
 }
 
+// IsReady check that job can dispatch at now
+func (j *Job) IsReady(now time.Time) bool {
+	if !j.NotBefore.Valid {
+		return true
+	}
+	return !j.NotBefore.Time.After(now)
+}
+
 // RepoURL return repository URL that send webhook.
 func (j *Job) RepoURL() string {
 	serverURL := "https://github.com"
@@ -205,11 +416,24 @@ type Runner struct {
 	ProviderURL    sql.NullString `db:"provider_url" json:"provider_url"`
 	RepositoryURL  string         `db:"repository_url"`
 	RequestWebhook string         `db:"request_webhook"`
+	RunnerName     sql.NullString `db:"runner_name" json:"runner_name"` // name in GitHub, only set if runner is adopted (not named by myshoes)
+	Version        int64          `db:"version"`                        // incremented on every update of runner, for optimistic concurrency control
 	CreatedAt      time.Time      `db:"created_at"`
 	UpdatedAt      time.Time      `db:"updated_at"`
 	DeletedAt      sql.NullTime   `db:"deleted_at"`
 }
 
+// RunnerHookResult is a result of calling runner hook
+type RunnerHookResult struct {
+	RunnerID   uuid.UUID `db:"runner_id" json:"runner_id"`
+	Event      string    `db:"event" json:"event"`
+	Success    bool      `db:"success" json:"success"`
+	Blocking   bool      `db:"blocking" json:"blocking"`
+	StatusCode int       `db:"status_code" json:"status_code"`
+	Message    string    `db:"message" json:"message"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
 // RunnerStatus is status for runner
 type RunnerStatus string
 
@@ -218,4 +442,5 @@ const (
 	RunnerStatusCreated        RunnerStatus = "created"
 	RunnerStatusCompleted                   = "completed"
 	RunnerStatusReachHardLimit              = "reach_hard_limit"
+	RunnerStatusTargetDeleted               = "target_deleted"
 )
@@ -0,0 +1,85 @@
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+var (
+	// JanitorInterval is interval of purging old rows
+	JanitorInterval = 1 * time.Hour
+	// JanitorBatchSize is number of rows that deleted in one query
+	JanitorBatchSize = 1000
+)
+
+// RunJanitor purge history of finished jobs and deleted runners that older than retention periodically.
+// jobs in queue are not purged (they are waiting for dispatch), they are expired by JOB_TTL in starter.
+// retention is disabled if value is 0.
+func RunJanitor(ctx context.Context, ds Datastore, jobRetention, runnerRetention time.Duration) error {
+	if jobRetention == 0 && runnerRetention == 0 {
+		logger.Logf(true, "retention is disabled, janitor is not started")
+		return nil
+	}
+	logger.Logf(false, "start janitor (job history retention: %s, runner history retention: %s)", jobRetention, runnerRetention)
+
+	ticker := time.NewTicker(JanitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if IsUnderPressure() {
+				logger.Logf(false, "datastore is under pressure, defer purging to next interval")
+				continue
+			}
+			now := time.Now().UTC()
+			if jobRetention != 0 {
+				deleted, err := purge(ctx, now.Add(-jobRetention), ds.PurgeJobHistories)
+				if err != nil {
+					logger.Logf(false, "failed to purge job histories: %+v", err)
+				}
+				logger.Logf(true, "purged %d job histories", deleted)
+			}
+			if runnerRetention != 0 {
+				deleted, err := purge(ctx, now.Add(-runnerRetention), ds.PurgeDeletedRunners)
+				if err != nil {
+					logger.Logf(false, "failed to purge deleted runners: %+v", err)
+				}
+				logger.Logf(true, "purged %d deleted runners", deleted)
+
+				deleted, err = purge(ctx, now.Add(-runnerRetention), ds.PurgeStateHistories)
+				if err != nil {
+					logger.Logf(false, "failed to purge state histories: %+v", err)
+				}
+				logger.Logf(true, "purged %d state histories", deleted)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// purge call purgeFunc in batches until all rows are deleted
+func purge(ctx context.Context, before time.Time, purgeFunc func(ctx context.Context, before time.Time, limit int) (int64, error)) (int64, error) {
+	var total int64
+	for {
+		deleted, err := purgeFunc(ctx, before, JanitorBatchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to purge (deleted: %d): %w", total, err)
+		}
+		total += deleted
+
+		if deleted < int64(JanitorBatchSize) {
+			return total, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		default:
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
+)
+
+func TestRunJanitor_PendingJobSurvive(t *testing.T) {
+	oldInterval := datastore.JanitorInterval
+	defer func() { datastore.JanitorInterval = oldInterval }()
+	datastore.JanitorInterval = 10 * time.Millisecond
+
+	ds, _ := memory.New(nil)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	targetID := uuid.NewV4()
+	if err := ds.CreateTarget(ctx, datastore.Target{
+		UUID:         targetID,
+		Scope:        "octocat/hello-world",
+		ResourceType: datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	// pending job that is older than retention (e.g. delayed by not_before), it is still waiting for dispatch
+	pendingJobID := uuid.NewV4()
+	if _, err := ds.EnqueueJob(ctx, datastore.Job{
+		UUID:      pendingJobID,
+		TargetID:  targetID,
+		CreatedAt: now.Add(-48 * time.Hour),
+	}); err != nil {
+		t.Fatalf("failed to enqueue job: %+v", err)
+	}
+	// history of finished job that is older than retention
+	if err := ds.CreateJobHistory(ctx, datastore.JobHistory{
+		JobID:      uuid.NewV4(),
+		TargetID:   targetID,
+		ReceivedAt: now.Add(-48 * time.Hour),
+	}); err != nil {
+		t.Fatalf("failed to create job history: %+v", err)
+	}
+
+	janitorCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if err := datastore.RunJanitor(janitorCtx, ds, 24*time.Hour, 0); err != nil {
+		t.Fatalf("failed to run janitor: %+v", err)
+	}
+
+	jobs, err := ds.ListJobs(ctx, datastore.ListOption{})
+	if err != nil {
+		t.Fatalf("failed to list jobs: %+v", err)
+	}
+	if len(jobs) != 1 || !uuid.Equal(jobs[0].UUID, pendingJobID) {
+		t.Fatalf("pending job must not be purged, but got %+v", jobs)
+	}
+	histories, err := ds.ListJobHistories(ctx, uuid.Nil, time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("failed to list job histories: %+v", err)
+	}
+	if len(histories) != 0 {
+		t.Fatalf("job history older than retention must be purged, but got %+v", histories)
+	}
+}
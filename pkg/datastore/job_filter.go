@@ -0,0 +1,145 @@
+package datastore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// JobFilters is rules that decide whether target provisions runners for a job.
+// a job that matches one of deny rules is refused, and a job must match one of allow rules if allow rules are set.
+type JobFilters struct {
+	Allow []JobFilterRule `json:"allow,omitempty"`
+	Deny  []JobFilterRule `json:"deny,omitempty"`
+
+	// ExcludeBots refuse jobs that triggered by KnownBots (e.g. version updates of Dependabot)
+	ExcludeBots bool `json:"exclude_bots,omitempty"`
+}
+
+// KnownBots is actors of bots in GitHub that trigger jobs
+var KnownBots = []string{"dependabot[bot]", "github-actions[bot]"}
+
+// JobFilterRuleExcludeBots is rule name of jobs that refused by ExcludeBots
+const JobFilterRuleExcludeBots = "exclude-bots"
+
+// JobFilterRule matches a job if all set conditions match.
+// actor, repository and label are case-insensitive, and "*" matches any characters.
+type JobFilterRule struct {
+	Actor      string `json:"actor,omitempty"`      // login of user that triggered the job (e.g. dependabot[bot])
+	Repository string `json:"repository,omitempty"` // full name of repository (e.g. octocat/*)
+	Label      string `json:"label,omitempty"`      // one of labels that the job requests
+	Fork       *bool  `json:"fork,omitempty"`       // the job is triggered by a pull request from fork repository
+}
+
+// JobAttributes is attributes of a job that are evaluated by JobFilters
+type JobAttributes struct {
+	Actor      string
+	Repository string
+	Labels     []string
+	Fork       bool
+}
+
+// ParseJobFilters parse value of Target.JobFilters
+func ParseJobFilters(raw []byte) (*JobFilters, error) {
+	var f JobFilters
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job filters: %w", err)
+	}
+	if len(f.Allow) == 0 && len(f.Deny) == 0 && !f.ExcludeBots {
+		return nil, fmt.Errorf("allow, deny or exclude_bots must be set")
+	}
+	for i, r := range append(f.Allow, f.Deny...) {
+		if r.Actor == "" && r.Repository == "" && r.Label == "" && r.Fork == nil {
+			return nil, fmt.Errorf("rule %d has no condition", i)
+		}
+	}
+	return &f, nil
+}
+
+// ValidateJobFilters check format of job filters
+func ValidateJobFilters(raw []byte) error {
+	_, err := ParseJobFilters(raw)
+	return err
+}
+
+// UseFork return true if one of rules has fork condition
+func (f JobFilters) UseFork() bool {
+	for _, r := range append(f.Allow, f.Deny...) {
+		if r.Fork != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate return true if job is allowed, and rule that decided it (e.g. exclude-bots, deny[0], allow[1], no-allow-match)
+func (f JobFilters) Evaluate(job JobAttributes) (bool, string) {
+	if f.ExcludeBots && IsKnownBot(job.Actor) {
+		return false, JobFilterRuleExcludeBots
+	}
+	for i, r := range f.Deny {
+		if r.Match(job) {
+			return false, fmt.Sprintf("deny[%d]", i)
+		}
+	}
+	if len(f.Allow) == 0 {
+		return true, "no-deny-match"
+	}
+	for i, r := range f.Allow {
+		if r.Match(job) {
+			return true, fmt.Sprintf("allow[%d]", i)
+		}
+	}
+	return false, "no-allow-match"
+}
+
+// IsKnownBot return true if actor is one of KnownBots
+func IsKnownBot(actor string) bool {
+	for _, b := range KnownBots {
+		if strings.EqualFold(b, actor) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match return true if job matches all set conditions of rule
+func (r JobFilterRule) Match(job JobAttributes) bool {
+	if r.Actor != "" && !matchPattern(r.Actor, job.Actor) {
+		return false
+	}
+	if r.Repository != "" && !matchPattern(r.Repository, job.Repository) {
+		return false
+	}
+	if r.Label != "" {
+		found := false
+		for _, l := range job.Labels {
+			if matchPattern(r.Label, l) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if r.Fork != nil && *r.Fork != job.Fork {
+		return false
+	}
+	return true
+}
+
+// matchPattern match value with pattern case-insensitively, "*" in pattern matches any characters.
+// other characters are literal, So "dependabot[bot]" matches itself
+func matchPattern(pattern, value string) bool {
+	if !strings.Contains(pattern, "*") {
+		return strings.EqualFold(pattern, value)
+	}
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	re := regexp.MustCompile("(?i)^" + strings.Join(parts, ".*") + "$")
+	return re.MatchString(value)
+}
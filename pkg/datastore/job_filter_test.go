@@ -0,0 +1,97 @@
+package datastore
+
+import "testing"
+
+func TestValidateJobFilters(t *testing.T) {
+	tests := []struct {
+		input string
+		err   bool
+	}{
+		{input: `{"deny": [{"actor": "dependabot[bot]"}, {"fork": true}]}`, err: false},
+		{input: `{"allow": [{"repository": "octocat/*", "label": "gpu"}]}`, err: false},
+		{input: `{"exclude_bots": true}`, err: false},
+		{input: `{}`, err: true},
+		{input: `{"exclude_bots": false}`, err: true},
+		{input: `{"deny": [{}]}`, err: true},
+		{input: `[]`, err: true},
+	}
+
+	for _, test := range tests {
+		err := ValidateJobFilters([]byte(test.input))
+		if !test.err && err != nil {
+			t.Fatalf("failed to validate (input: %s): %+v", test.input, err)
+		}
+		if test.err && err == nil {
+			t.Fatalf("must be error, but not error (input: %s)", test.input)
+		}
+	}
+}
+
+func TestJobFilters_Evaluate(t *testing.T) {
+	fork := true
+	f := JobFilters{
+		Allow: []JobFilterRule{{Repository: "octocat/*"}, {Label: "gpu"}},
+		Deny:  []JobFilterRule{{Actor: "dependabot[bot]"}, {Fork: &fork}},
+	}
+
+	tests := []struct {
+		input   JobAttributes
+		allowed bool
+		rule    string
+	}{
+		{
+			input:   JobAttributes{Actor: "octocat", Repository: "octocat/hello-world"},
+			allowed: true,
+			rule:    "allow[0]",
+		},
+		{
+			input:   JobAttributes{Actor: "Dependabot[bot]", Repository: "octocat/hello-world"},
+			allowed: false,
+			rule:    "deny[0]",
+		},
+		{
+			input:   JobAttributes{Actor: "octocat", Repository: "octocat/hello-world", Fork: true},
+			allowed: false,
+			rule:    "deny[1]",
+		},
+		{
+			input:   JobAttributes{Actor: "octocat", Repository: "whywaita/myshoes", Labels: []string{"self-hosted", "GPU"}},
+			allowed: true,
+			rule:    "allow[1]",
+		},
+		{
+			input:   JobAttributes{Actor: "octocat", Repository: "whywaita/myshoes", Labels: []string{"self-hosted"}},
+			allowed: false,
+			rule:    "no-allow-match",
+		},
+	}
+
+	for _, test := range tests {
+		allowed, rule := f.Evaluate(test.input)
+		if allowed != test.allowed || rule != test.rule {
+			t.Fatalf("want (%t, %s), but got (%t, %s) (input: %+v)", test.allowed, test.rule, allowed, rule, test.input)
+		}
+	}
+}
+
+func TestJobFilters_EvaluateExcludeBots(t *testing.T) {
+	f := JobFilters{ExcludeBots: true}
+
+	tests := []struct {
+		actor   string
+		allowed bool
+		rule    string
+	}{
+		{actor: "octocat", allowed: true, rule: "no-deny-match"},
+		{actor: "dependabot[bot]", allowed: false, rule: JobFilterRuleExcludeBots},
+		{actor: "github-actions[bot]", allowed: false, rule: JobFilterRuleExcludeBots},
+		{actor: "renovate[bot]", allowed: true, rule: "no-deny-match"},
+	}
+
+	for _, test := range tests {
+		allowed, rule := f.Evaluate(JobAttributes{Actor: test.actor, Repository: "octocat/hello-world"})
+		if allowed != test.allowed || rule != test.rule {
+			t.Fatalf("want (%t, %s), but got (%t, %s) (actor: %s)", test.allowed, test.rule, allowed, rule, test.actor)
+		}
+	}
+}
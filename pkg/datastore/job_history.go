@@ -0,0 +1,95 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// JobHistoryEvent is a point of job lifecycle that recorded in JobHistory
+type JobHistoryEvent string
+
+// JobHistoryEvent values
+const (
+	JobHistoryRunnerCreated    JobHistoryEvent = "runner_created"
+	JobHistoryRunnerRegistered JobHistoryEvent = "runner_registered"
+	JobHistoryStarted          JobHistoryEvent = "started"
+	JobHistoryCompleted        JobHistoryEvent = "completed"
+)
+
+// JobHistory is timestamps of a job from receiving webhook to completion, it is kept after job is dequeued.
+type JobHistory struct {
+	JobID    uuid.UUID `db:"job_id" json:"job_id"`
+	TargetID uuid.UUID `db:"target_id" json:"target_id"`
+	// GitHubJobID is ID of workflow job (or check run) in GitHub, for matching events of job started / completed
+	GitHubJobID sql.NullInt64 `db:"github_job_id" json:"github_job_id"`
+
+	ReceivedAt         time.Time    `db:"received_at" json:"received_at"`
+	RunnerCreatedAt    sql.NullTime `db:"runner_created_at" json:"runner_created_at"`
+	RunnerRegisteredAt sql.NullTime `db:"runner_registered_at" json:"runner_registered_at"`
+	StartedAt          sql.NullTime `db:"started_at" json:"started_at"`
+	CompletedAt        sql.NullTime `db:"completed_at" json:"completed_at"`
+}
+
+// Set set time of event, time that already set is not overwritten
+func (h *JobHistory) Set(event JobHistoryEvent, at time.Time) {
+	var t *sql.NullTime
+	switch event {
+	case JobHistoryRunnerCreated:
+		t = &h.RunnerCreatedAt
+	case JobHistoryRunnerRegistered:
+		t = &h.RunnerRegisteredAt
+	case JobHistoryStarted:
+		t = &h.StartedAt
+	case JobHistoryCompleted:
+		t = &h.CompletedAt
+	default:
+		return
+	}
+	if !t.Valid {
+		*t = sql.NullTime{Time: at.UTC(), Valid: true}
+	}
+}
+
+// RecordJobReceived record job history of received job.
+// history is for measuring, So failure of recording is only logged, and it is skipped if datastore is under pressure.
+func RecordJobReceived(ctx context.Context, ds Datastore, job Job, githubJobID int64) {
+	if IsUnderPressure() {
+		return
+	}
+	h := JobHistory{
+		JobID:      job.UUID,
+		TargetID:   job.TargetID,
+		ReceivedAt: time.Now().UTC(),
+	}
+	if githubJobID != 0 {
+		h.GitHubJobID = sql.NullInt64{Int64: githubJobID, Valid: true}
+	}
+	if err := ds.CreateJobHistory(ctx, h); err != nil {
+		logger.Logf(false, "failed to record job history (job: %s): %+v", job.UUID, err)
+	}
+}
+
+// RecordJobEvent record time of event to job history, failure of recording is only logged
+func RecordJobEvent(ctx context.Context, ds Datastore, jobID uuid.UUID, event JobHistoryEvent) {
+	if IsUnderPressure() {
+		return
+	}
+	if err := ds.SetJobHistoryTime(ctx, jobID, event, time.Now().UTC()); err != nil {
+		logger.Logf(false, "failed to record job history (job: %s, event: %s): %+v", jobID, event, err)
+	}
+}
+
+// RecordGitHubJobEvent record time of event to job history that has ID of job in GitHub, failure of recording is only logged
+func RecordGitHubJobEvent(ctx context.Context, ds Datastore, githubJobID int64, event JobHistoryEvent, at time.Time) {
+	if IsUnderPressure() {
+		return
+	}
+	if err := ds.SetJobHistoryTimeByGitHubJobID(ctx, githubJobID, event, at); err != nil {
+		logger.Logf(false, "failed to record job history (GitHub job ID: %d, event: %s): %+v", githubJobID, event, err)
+	}
+}
@@ -0,0 +1,46 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// maxRunnerLabelLength is max length of a label of runner in GitHub
+const maxRunnerLabelLength = 256
+
+// ValidateRunnerLabels check labels can be injected to runner.
+// label must not be empty, and must not include comma and whitespace because labels are passed to config.sh as comma-separated
+func ValidateRunnerLabels(labels []string) error {
+	for _, l := range labels {
+		if l == "" {
+			return fmt.Errorf("runner_labels must not include empty label")
+		}
+		if len(l) > maxRunnerLabelLength {
+			return fmt.Errorf("runner_labels must be shorter than %d characters (got: %s)", maxRunnerLabelLength, l)
+		}
+		if strings.ContainsAny(l, ", \t\r\n") {
+			return fmt.Errorf("runner_labels must not include comma and whitespace (got: %q)", l)
+		}
+	}
+	return nil
+}
+
+// ToRunnerLabels convert labels to value of Target.RunnerLabels, empty labels is converted to NULL
+func ToRunnerLabels(labels []string) sql.NullString {
+	if len(labels) == 0 {
+		return sql.NullString{Valid: false}
+	}
+	return sql.NullString{String: strings.Join(labels, ","), Valid: true}
+}
+
+// ParseRunnerLabels split comma-separated labels
+func ParseRunnerLabels(s string) []string {
+	var labels []string
+	for _, l := range strings.Split(s, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			labels = append(labels, l)
+		}
+	}
+	return labels
+}
@@ -0,0 +1,55 @@
+package datastore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateRunnerLabels(t *testing.T) {
+	tests := []struct {
+		input []string
+		err   bool
+	}{
+		{input: nil, err: false},
+		{input: []string{"myshoes", "production", "image-v1.2.3"}, err: false},
+		{input: []string{""}, err: true},
+		{input: []string{"a,b"}, err: true},
+		{input: []string{"with space"}, err: true},
+		{input: []string{strings.Repeat("a", maxRunnerLabelLength+1)}, err: true},
+	}
+
+	for _, test := range tests {
+		err := ValidateRunnerLabels(test.input)
+		if !test.err && err != nil {
+			t.Fatalf("must not be error (input: %v): %+v", test.input, err)
+		}
+		if test.err && err == nil {
+			t.Fatalf("must be error (input: %v)", test.input)
+		}
+	}
+}
+
+func TestParseRunnerLabels(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: "", want: nil},
+		{input: "myshoes", want: []string{"myshoes"}},
+		{input: "myshoes, production,,image-v1", want: []string{"myshoes", "production", "image-v1"}},
+	}
+
+	for _, test := range tests {
+		got := ParseRunnerLabels(test.input)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	labels := []string{"myshoes", "production"}
+	if diff := cmp.Diff(labels, ParseRunnerLabels(ToRunnerLabels(labels).String)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
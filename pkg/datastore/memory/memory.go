@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,26 +14,40 @@ import (
 	"github.com/whywaita/myshoes/pkg/datastore"
 )
 
-// Memory is implement datastore on-memory
+// Memory is implement datastore on-memory.
+// Memory is for development and testing, all data will be lost when process is exited.
 type Memory struct {
 	mu      *sync.RWMutex
 	targets map[uuid.UUID]datastore.Target
 	jobs    map[uuid.UUID]datastore.Job
 	runners map[uuid.UUID]datastore.Runner
+	// tokenIssued is time that registration token of runner is issued
+	tokenIssued map[uuid.UUID]time.Time
+	hooks       map[uuid.UUID][]datastore.RunnerHookResult
+	history     []datastore.StateHistory
+	jobHist     map[uuid.UUID]datastore.JobHistory
+	locked      bool
+
+	notifyEnqueueCh chan<- struct{}
 }
 
-// New create map
-func New() (*Memory, error) {
+// New create map. notifyEnqueueCh is nullable
+func New(notifyEnqueueCh chan<- struct{}) (*Memory, error) {
 	m := &sync.RWMutex{}
 	t := map[uuid.UUID]datastore.Target{}
 	j := map[uuid.UUID]datastore.Job{}
 	r := map[uuid.UUID]datastore.Runner{}
 
 	return &Memory{
-		mu:      m,
-		targets: t,
-		jobs:    j,
-		runners: r,
+		mu:          m,
+		targets:     t,
+		jobs:        j,
+		runners:     r,
+		tokenIssued: map[uuid.UUID]time.Time{},
+		hooks:       map[uuid.UUID][]datastore.RunnerHookResult{},
+		jobHist:     map[uuid.UUID]datastore.JobHistory{},
+
+		notifyEnqueueCh: notifyEnqueueCh,
 	}, nil
 }
 
@@ -40,6 +56,21 @@ func (m *Memory) CreateTarget(ctx context.Context, target datastore.Target) erro
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	for _, t := range m.targets {
+		if t.Scope == target.Scope && t.GHEDomain == target.GHEDomain {
+			return fmt.Errorf("%s is already exist", target.Scope)
+		}
+	}
+
+	now := time.Now().UTC()
+	if target.Status == "" {
+		target.Status = datastore.TargetStatusActive
+	}
+	if target.CreatedAt.IsZero() {
+		target.CreatedAt = now
+	}
+	target.UpdatedAt = now
+
 	m.targets[target.UUID] = target
 	return nil
 }
@@ -56,13 +87,13 @@ func (m *Memory) GetTarget(ctx context.Context, id uuid.UUID) (*datastore.Target
 	return &t, nil
 }
 
-// GetTargetByScope get a target from scope
-func (m *Memory) GetTargetByScope(ctx context.Context, scope string) (*datastore.Target, error) {
+// GetTargetByScope get a target from scope in GitHub host
+func (m *Memory) GetTargetByScope(ctx context.Context, gheDomain, scope string) (*datastore.Target, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	for _, t := range m.targets {
-		if t.Scope == scope {
+		if t.Scope == scope && t.GHEDomain == datastore.NullGHEDomain(gheDomain) {
 			// found
 			return &t, nil
 
@@ -72,26 +103,85 @@ func (m *Memory) GetTargetByScope(ctx context.Context, scope string) (*datastore
 	return nil, datastore.ErrNotFound
 }
 
-// ListTargets get a all targets
-func (m *Memory) ListTargets(ctx context.Context) ([]datastore.Target, error) {
+// ListTargets get a page of targets
+func (m *Memory) ListTargets(ctx context.Context, opt datastore.ListOption) ([]datastore.Target, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	var targets []datastore.Target
 
 	for _, t := range m.targets {
-		targets = append(targets, t)
+		if !t.DeletedAt.Valid {
+			targets = append(targets, t)
+		}
+	}
+
+	return datastore.Paginate(targets, func(t datastore.Target) uuid.UUID { return t.UUID }, opt), nil
+}
+
+// ListDeletedTargets get a page of soft deleted targets
+func (m *Memory) ListDeletedTargets(ctx context.Context, opt datastore.ListOption) ([]datastore.Target, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var targets []datastore.Target
+	for _, t := range m.targets {
+		if t.DeletedAt.Valid {
+			targets = append(targets, t)
+		}
+	}
+
+	return datastore.Paginate(targets, func(t datastore.Target) uuid.UUID { return t.UUID }, opt), nil
+}
+
+// ListTargetsByExternalRef get targets that has external reference ID
+func (m *Memory) ListTargetsByExternalRef(ctx context.Context, externalRef string) ([]datastore.Target, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var targets []datastore.Target
+	for _, t := range m.targets {
+		if t.ExternalRef.Valid && t.ExternalRef.String == externalRef && !t.DeletedAt.Valid {
+			targets = append(targets, t)
+		}
 	}
 
 	return targets, nil
 }
 
-// DeleteTarget delete a target
+// DeleteTarget soft delete a target
 func (m *Memory) DeleteTarget(ctx context.Context, id uuid.UUID) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	delete(m.targets, id)
+	t, ok := m.targets[id]
+	if !ok {
+		return datastore.ErrNotFound
+	}
+	now := time.Now().UTC()
+	t.Status = datastore.TargetStatusDeleted
+	t.DeletedAt = sql.NullTime{Time: now, Valid: true}
+	t.UpdatedAt = now
+
+	m.targets[id] = t
+	return nil
+}
+
+// RestoreTarget restore a soft deleted target
+func (m *Memory) RestoreTarget(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.targets[id]
+	if !ok || !t.DeletedAt.Valid {
+		return datastore.ErrNotFound
+	}
+	t.Status = datastore.TargetStatusActive
+	t.StatusDescription = sql.NullString{}
+	t.DeletedAt = sql.NullTime{}
+	t.UpdatedAt = time.Now().UTC()
+
+	m.targets[id] = t
 	return nil
 }
 
@@ -102,7 +192,7 @@ func (m *Memory) UpdateTargetStatus(ctx context.Context, targetID uuid.UUID, new
 
 	t, ok := m.targets[targetID]
 	if !ok {
-		return fmt.Errorf("not found")
+		return datastore.ErrNotFound
 	}
 
 	t.Status = newStatus
@@ -112,6 +202,7 @@ func (m *Memory) UpdateTargetStatus(ctx context.Context, targetID uuid.UUID, new
 		t.StatusDescription.Valid = false
 	}
 	t.StatusDescription.String = description
+	t.UpdatedAt = time.Now().UTC()
 
 	m.targets[targetID] = t
 
@@ -125,45 +216,343 @@ func (m *Memory) UpdateToken(ctx context.Context, targetID uuid.UUID, newToken s
 
 	t, ok := m.targets[targetID]
 	if !ok {
-		return fmt.Errorf("not found")
+		return datastore.ErrNotFound
 	}
 	t.GitHubToken = newToken
 	t.TokenExpiredAt = newExpiredAt
+	t.UpdatedAt = time.Now().UTC()
 
 	m.targets[targetID] = t
 	return nil
 }
 
 // UpdateTargetParam update parameter of target
-func (m *Memory) UpdateTargetParam(ctx context.Context, targetID uuid.UUID, newResourceType datastore.ResourceType, newProviderURL string) error {
+func (m *Memory) UpdateTargetParam(ctx context.Context, targetID uuid.UUID, newResourceType datastore.ResourceType, newProviderURL sql.NullString) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	t, ok := m.targets[targetID]
 	if !ok {
-		return fmt.Errorf("not found")
+		return datastore.ErrNotFound
 	}
 	t.ResourceType = newResourceType
-	t.ProviderURL = sql.NullString{
-		String: newProviderURL,
-		Valid:  true,
+	t.ProviderURL = newProviderURL
+	t.UpdatedAt = time.Now().UTC()
+
+	m.targets[targetID] = t
+	return nil
+}
+
+// UpdateTargetPlacementParams update placement parameters of target
+func (m *Memory) UpdateTargetPlacementParams(ctx context.Context, targetID uuid.UUID, newPlacementParams sql.NullString) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.targets[targetID]
+	if !ok {
+		return datastore.ErrNotFound
 	}
+	t.PlacementParams = newPlacementParams
+	t.UpdatedAt = time.Now().UTC()
 
 	m.targets[targetID] = t
 	return nil
 }
 
-// EnqueueJob add a job
-func (m *Memory) EnqueueJob(ctx context.Context, job datastore.Job) error {
+// UpdateTargetUserDataFormat update format of user data of target
+func (m *Memory) UpdateTargetUserDataFormat(ctx context.Context, targetID uuid.UUID, newFormat datastore.UserDataFormat) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.jobs[job.UUID] = job
+	t, ok := m.targets[targetID]
+	if !ok {
+		return datastore.ErrNotFound
+	}
+	t.UserDataFormat = newFormat
+	t.UpdatedAt = time.Now().UTC()
+
+	m.targets[targetID] = t
+	return nil
+}
+
+// UpdateTargetRunnerLabels update labels that are injected to runners of target
+func (m *Memory) UpdateTargetRunnerLabels(ctx context.Context, targetID uuid.UUID, newLabels sql.NullString) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.targets[targetID]
+	if !ok {
+		return datastore.ErrNotFound
+	}
+	t.RunnerLabels = newLabels
+	t.UpdatedAt = time.Now().UTC()
+
+	m.targets[targetID] = t
+	return nil
+}
+
+// UpdateTargetQuietHours update quiet hours of target
+func (m *Memory) UpdateTargetQuietHours(ctx context.Context, targetID uuid.UUID, newQuietHours sql.NullString) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.targets[targetID]
+	if !ok {
+		return datastore.ErrNotFound
+	}
+	t.QuietHours = newQuietHours
+	t.UpdatedAt = time.Now().UTC()
+
+	m.targets[targetID] = t
 	return nil
 }
 
-// ListJobs get all jobs
-func (m *Memory) ListJobs(ctx context.Context) ([]datastore.Job, error) {
+// UpdateTargetRootless update rootless container runtime of target
+func (m *Memory) UpdateTargetRootless(ctx context.Context, targetID uuid.UUID, newRuntime datastore.RootlessRuntime) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.targets[targetID]
+	if !ok {
+		return datastore.ErrNotFound
+	}
+	t.Rootless = newRuntime
+	t.UpdatedAt = time.Now().UTC()
+
+	m.targets[targetID] = t
+	return nil
+}
+
+// UpdateTargetJobFilters update job filters of target
+func (m *Memory) UpdateTargetJobFilters(ctx context.Context, targetID uuid.UUID, newJobFilters sql.NullString) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.targets[targetID]
+	if !ok {
+		return datastore.ErrNotFound
+	}
+	t.JobFilters = newJobFilters
+	t.UpdatedAt = time.Now().UTC()
+
+	m.targets[targetID] = t
+	return nil
+}
+
+// UpdateTargetRunnerRegistration update method of registering runner of target
+func (m *Memory) UpdateTargetRunnerRegistration(ctx context.Context, targetID uuid.UUID, newRegistration datastore.RunnerRegistration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.targets[targetID]
+	if !ok {
+		return datastore.ErrNotFound
+	}
+	t.RunnerRegistration = newRegistration
+	t.UpdatedAt = time.Now().UTC()
+
+	m.targets[targetID] = t
+	return nil
+}
+
+// ExportTargets get all targets include deleted targets for backup
+func (m *Memory) ExportTargets(ctx context.Context) ([]datastore.Target, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ts []datastore.Target
+	for _, t := range m.targets {
+		ts = append(ts, t)
+	}
+	sort.SliceStable(ts, func(i, j int) bool {
+		return ts[i].CreatedAt.Before(ts[j].CreatedAt)
+	})
+	return ts, nil
+}
+
+// ImportTargets create targets for restore, skip targets that already exist by uuid or scope
+func (m *Memory) ImportTargets(ctx context.Context, targets []datastore.Target) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var imported int64
+	for _, target := range targets {
+		if m.existTarget(target) {
+			continue
+		}
+
+		now := time.Now().UTC()
+		if target.Status == "" {
+			target.Status = datastore.TargetStatusActive
+		}
+		if target.CreatedAt.IsZero() {
+			target.CreatedAt = now
+		}
+		target.UpdatedAt = now
+		m.targets[target.UUID] = target
+		imported++
+	}
+	return imported, nil
+}
+
+// RenameScope rename scope of targets in scope and repository of jobs in GitHub host of gheDomain
+func (m *Memory) RenameScope(ctx context.Context, gheDomain, scope, newScope string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	domain := datastore.NullGHEDomain(gheDomain)
+	var targetIDs []uuid.UUID
+	var exists bool
+	for id, t := range m.targets {
+		if t.GHEDomain != domain {
+			continue
+		}
+		if datastore.InScope(t.Scope, scope) {
+			targetIDs = append(targetIDs, id)
+		}
+		if datastore.InScope(t.Scope, newScope) {
+			exists = true
+		}
+	}
+	if len(targetIDs) == 0 {
+		return 0, datastore.ErrNotFound
+	}
+	if exists {
+		return 0, fmt.Errorf("target already exists in %s: %w", newScope, datastore.ErrConflict)
+	}
+
+	now := time.Now().UTC()
+	for _, id := range targetIDs {
+		t := m.targets[id]
+		t.Scope = datastore.RenamedScope(t.Scope, scope, newScope)
+		t.UpdatedAt = now
+		m.targets[id] = t
+	}
+	for id, j := range m.jobs {
+		if j.GHEDomain == domain && datastore.InScope(j.Repository, scope) {
+			j.Repository = datastore.RenamedScope(j.Repository, scope, newScope)
+			m.jobs[id] = j
+		}
+	}
+	return int64(len(targetIDs)), nil
+}
+
+// PurgeScope delete targets in scope and all of jobs, runners and histories of them
+func (m *Memory) PurgeScope(ctx context.Context, scope string) (*datastore.PurgeResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result datastore.PurgeResult
+	targetIDs := map[uuid.UUID]struct{}{}
+	for id, t := range m.targets {
+		if datastore.InScope(t.Scope, scope) {
+			targetIDs[id] = struct{}{}
+			delete(m.targets, id)
+			result.Targets++
+		}
+	}
+	if len(targetIDs) == 0 {
+		return nil, datastore.ErrNotFound
+	}
+
+	resourceIDs := map[uuid.UUID]struct{}{}
+	for id, r := range m.runners {
+		if _, ok := targetIDs[r.TargetID]; ok {
+			resourceIDs[id] = struct{}{}
+			delete(m.runners, id)
+			delete(m.tokenIssued, id)
+			delete(m.hooks, id)
+			result.Runners++
+		}
+	}
+	for id, j := range m.jobs {
+		if _, ok := targetIDs[j.TargetID]; ok {
+			resourceIDs[id] = struct{}{}
+			delete(m.jobs, id)
+			result.Jobs++
+		}
+	}
+	for id, h := range m.jobHist {
+		if _, ok := targetIDs[h.TargetID]; ok {
+			resourceIDs[id] = struct{}{}
+			delete(m.jobHist, id)
+			result.Histories++
+		}
+	}
+
+	var histories []datastore.StateHistory
+	for _, h := range m.history {
+		if _, ok := resourceIDs[h.ResourceID]; ok {
+			result.Histories++
+			continue
+		}
+		histories = append(histories, h)
+	}
+	m.history = histories
+
+	return &result, nil
+}
+
+func (m *Memory) existTarget(target datastore.Target) bool {
+	if _, ok := m.targets[target.UUID]; ok {
+		return true
+	}
+	for _, t := range m.targets {
+		if t.Scope == target.Scope && t.GHEDomain == target.GHEDomain {
+			return true
+		}
+	}
+	return false
+}
+
+// EnqueueJob add a job, return the existing job if job that has same DedupKey is already enqueued
+func (m *Memory) EnqueueJob(ctx context.Context, job datastore.Job) (*datastore.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if job.DedupKey.Valid {
+		for _, j := range m.jobs {
+			if j.DedupKey.Valid && j.DedupKey.String == job.DedupKey.String {
+				existing := j
+				return &existing, nil
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = now
+	}
+	job.UpdatedAt = now
+
+	m.jobs[job.UUID] = job
+
+	select {
+	case m.notifyEnqueueCh <- struct{}{}:
+		// notified to starter
+	default:
+		// no capacity on channel (or nil channel), do not block
+	}
+
+	return &job, nil
+}
+
+// GetJobByDedupKey get a job in queue that has DedupKey
+func (m *Memory) GetJobByDedupKey(ctx context.Context, dedupKey string) (*datastore.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, j := range m.jobs {
+		if j.DedupKey.Valid && j.DedupKey.String == dedupKey {
+			job := j
+			return &job, nil
+		}
+	}
+	return nil, datastore.ErrNotFound
+}
+
+// ListJobs get a page of jobs
+func (m *Memory) ListJobs(ctx context.Context, opt datastore.ListOption) ([]datastore.Job, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -172,9 +561,87 @@ func (m *Memory) ListJobs(ctx context.Context) ([]datastore.Job, error) {
 		jobs = append(jobs, j)
 	}
 
+	return datastore.Paginate(jobs, func(j datastore.Job) uuid.UUID { return j.UUID }, opt), nil
+}
+
+// ListReadyJobs get jobs that can dispatch at now
+func (m *Memory) ListReadyJobs(ctx context.Context, now time.Time) ([]datastore.Job, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var jobs []datastore.Job
+	for _, j := range m.jobs {
+		if j.IsReady(now) {
+			jobs = append(jobs, j)
+		}
+	}
+
+	return jobs, nil
+}
+
+// CountPendingJobs count jobs in queue of target
+func (m *Memory) CountPendingJobs(ctx context.Context, targetID uuid.UUID) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int
+	for _, j := range m.jobs {
+		if uuid.Equal(targetID, uuid.Nil) || uuid.Equal(j.TargetID, targetID) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// OldestPendingJobAge get elapsed time since the oldest job in queue was created
+func (m *Memory) OldestPendingJobAge(ctx context.Context) (time.Duration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var oldest time.Time
+	for _, j := range m.jobs {
+		if oldest.IsZero() || j.CreatedAt.Before(oldest) {
+			oldest = j.CreatedAt
+		}
+	}
+	if oldest.IsZero() {
+		return 0, nil
+	}
+
+	return time.Since(oldest), nil
+}
+
+// ListJobsByExternalRef get jobs that has external reference ID
+func (m *Memory) ListJobsByExternalRef(ctx context.Context, externalRef string) ([]datastore.Job, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var jobs []datastore.Job
+	for _, j := range m.jobs {
+		if j.ExternalRef.Valid && j.ExternalRef.String == externalRef {
+			jobs = append(jobs, j)
+		}
+	}
+
 	return jobs, nil
 }
 
+// DeferJob update not_before of job
+func (m *Memory) DeferJob(ctx context.Context, id uuid.UUID, notBefore time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return datastore.ErrNotFound
+	}
+	j.NotBefore = sql.NullTime{Time: notBefore.UTC(), Valid: true}
+	j.UpdatedAt = time.Now().UTC()
+	m.jobs[id] = j
+	return nil
+}
+
 // DeleteJob delete a job
 func (m *Memory) DeleteJob(ctx context.Context, id uuid.UUID) error {
 	m.mu.Lock()
@@ -184,36 +651,69 @@ func (m *Memory) DeleteJob(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// DeleteJobsBulk delete jobs
+func (m *Memory) DeleteJobsBulk(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int64
+	for _, id := range ids {
+		if _, ok := m.jobs[id]; ok {
+			delete(m.jobs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
 // CreateRunner add a runner
 func (m *Memory) CreateRunner(ctx context.Context, runner datastore.Runner) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if _, ok := m.targets[runner.TargetID]; !ok {
+		return fmt.Errorf("target is not found (target ID: %s)", runner.TargetID)
+	}
+
+	now := time.Now().UTC()
+	if runner.CreatedAt.IsZero() {
+		runner.CreatedAt = now
+	}
+	runner.UpdatedAt = now
+	runner.Status = datastore.RunnerStatusCreated
+	runner.Deleted = false
+
 	m.runners[runner.UUID] = runner
 
 	return nil
 }
 
-// ListRunners get a all runners
-func (m *Memory) ListRunners(ctx context.Context) ([]datastore.Runner, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// ListRunners get a page of not deleted runners
+func (m *Memory) ListRunners(ctx context.Context, opt datastore.ListOption) ([]datastore.Runner, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
 	var runners []datastore.Runner
 	for _, r := range m.runners {
+		if r.Deleted {
+			continue
+		}
 		runners = append(runners, r)
 	}
 
-	return runners, nil
+	return datastore.Paginate(runners, func(r datastore.Runner) uuid.UUID { return r.UUID }, opt), nil
 }
 
 // ListRunnersByTargetID get a not deleted runners that has target_id
 func (m *Memory) ListRunnersByTargetID(ctx context.Context, targetID uuid.UUID) ([]datastore.Runner, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
 	var runners []datastore.Runner
 	for _, r := range m.runners {
+		if r.Deleted {
+			continue
+		}
 		if uuid.Equal(r.TargetID, targetID) {
 			runners = append(runners, r)
 		}
@@ -224,8 +724,8 @@ func (m *Memory) ListRunnersByTargetID(ctx context.Context, targetID uuid.UUID)
 
 // GetRunner get a runner
 func (m *Memory) GetRunner(ctx context.Context, id uuid.UUID) (*datastore.Runner, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
 	r, ok := m.runners[id]
 	if !ok {
@@ -235,21 +735,295 @@ func (m *Memory) GetRunner(ctx context.Context, id uuid.UUID) (*datastore.Runner
 	return &r, nil
 }
 
+// UpdateRunnerWithVersion update a runner only if version is not changed
+func (m *Memory) UpdateRunnerWithVersion(ctx context.Context, runner datastore.Runner) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.runners[runner.UUID]
+	if !ok || r.Deleted || r.Version != runner.Version {
+		return datastore.ErrConflict
+	}
+	r.IPAddress = runner.IPAddress
+	r.CloudID = runner.CloudID
+	r.RunnerName = runner.RunnerName
+	r.Version++
+	r.UpdatedAt = time.Now().UTC()
+	m.runners[runner.UUID] = r
+	return nil
+}
+
+// MarkRunnerTokenIssued set time that token of a runner is issued only if it is not set
+func (m *Memory) MarkRunnerTokenIssued(ctx context.Context, id uuid.UUID, issuedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.runners[id]
+	if !ok || r.Deleted {
+		return datastore.ErrConflict
+	}
+	if _, issued := m.tokenIssued[id]; issued {
+		return datastore.ErrConflict
+	}
+	m.tokenIssued[id] = issuedAt
+	return nil
+}
+
 // DeleteRunner delete a runner
 func (m *Memory) DeleteRunner(ctx context.Context, id uuid.UUID, deletedAt time.Time, reason datastore.RunnerStatus) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	delete(m.runners, id)
+	r, ok := m.runners[id]
+	if !ok {
+		return datastore.ErrNotFound
+	}
+	m.deleteRunner(r, deletedAt, reason)
+	return nil
+}
+
+// DeleteRunnerWithVersion delete a runner only if version is not changed
+func (m *Memory) DeleteRunnerWithVersion(ctx context.Context, id uuid.UUID, version int64, deletedAt time.Time, reason datastore.RunnerStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.runners[id]
+	switch {
+	case !ok || r.Version != version:
+		return datastore.ErrConflict
+	case r.Deleted:
+		return datastore.ErrNotFound
+	}
+	m.deleteRunner(r, deletedAt, reason)
 	return nil
 }
 
+// DeleteRunnersBulk delete runners only if version is not changed, runners that already deleted are skipped
+func (m *Memory) DeleteRunnersBulk(ctx context.Context, runners []datastore.Runner, deletedAt time.Time, reason datastore.RunnerStatus) ([]uuid.UUID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted []uuid.UUID
+	var conflicted []string
+	for _, runner := range runners {
+		r, ok := m.runners[runner.UUID]
+		switch {
+		case !ok || r.Deleted:
+			continue
+		case r.Version != runner.Version:
+			conflicted = append(conflicted, fmt.Sprintf("%s (version: %d)", runner.UUID, runner.Version))
+			continue
+		}
+		m.deleteRunner(r, deletedAt, reason)
+		deleted = append(deleted, runner.UUID)
+	}
+	if len(conflicted) > 0 {
+		return deleted, fmt.Errorf("runners are updated by other process (runner uuid: %s): %w", strings.Join(conflicted, ", "), datastore.ErrConflict)
+	}
+	return deleted, nil
+}
+
+func (m *Memory) deleteRunner(r datastore.Runner, deletedAt time.Time, reason datastore.RunnerStatus) {
+	r.Version++
+	r.Deleted = true
+	r.Status = reason
+	r.DeletedAt = sql.NullTime{
+		Time:  deletedAt,
+		Valid: true,
+	}
+	r.UpdatedAt = time.Now().UTC()
+
+	m.runners[r.UUID] = r
+}
+
+// PurgeDeletedRunners delete history of runners that deleted before `before`
+func (m *Memory) PurgeDeletedRunners(ctx context.Context, before time.Time, limit int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int64
+	for id, r := range m.runners {
+		if deleted >= int64(limit) {
+			break
+		}
+		if r.Deleted && r.DeletedAt.Valid && r.DeletedAt.Time.Before(before) {
+			delete(m.runners, id)
+			delete(m.tokenIssued, id)
+			delete(m.hooks, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// CreateRunnerHookResult record a result of runner hook
+func (m *Memory) CreateRunnerHookResult(ctx context.Context, result datastore.RunnerHookResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if result.CreatedAt.IsZero() {
+		result.CreatedAt = time.Now().UTC()
+	}
+	m.hooks[result.RunnerID] = append(m.hooks[result.RunnerID], result)
+	return nil
+}
+
+// ListRunnerHookResults get results of runner hook
+func (m *Memory) ListRunnerHookResults(ctx context.Context, runnerID uuid.UUID) ([]datastore.RunnerHookResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := make([]datastore.RunnerHookResult, len(m.hooks[runnerID]))
+	copy(results, m.hooks[runnerID])
+	return results, nil
+}
+
+// CreateStateHistory record a status transition of job or runner
+func (m *Memory) CreateStateHistory(ctx context.Context, history datastore.StateHistory) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if history.CreatedAt.IsZero() {
+		history.CreatedAt = time.Now().UTC()
+	}
+	m.history = append(m.history, history)
+	return nil
+}
+
+// ListStateHistories get status transitions of job or runner
+func (m *Memory) ListStateHistories(ctx context.Context, resourceID uuid.UUID) ([]datastore.StateHistory, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var histories []datastore.StateHistory
+	for _, h := range m.history {
+		if uuid.Equal(h.ResourceID, resourceID) {
+			histories = append(histories, h)
+		}
+	}
+	return histories, nil
+}
+
+// PurgeStateHistories delete histories that created before `before`
+func (m *Memory) PurgeStateHistories(ctx context.Context, before time.Time, limit int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int64
+	var histories []datastore.StateHistory
+	for _, h := range m.history {
+		if deleted < int64(limit) && h.CreatedAt.Before(before) {
+			deleted++
+			continue
+		}
+		histories = append(histories, h)
+	}
+	m.history = histories
+	return deleted, nil
+}
+
+// CreateJobHistory record that a job is received
+func (m *Memory) CreateJobHistory(ctx context.Context, history datastore.JobHistory) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.jobHist[history.JobID]; ok {
+		return fmt.Errorf("history of job %s is already exist", history.JobID)
+	}
+	if history.ReceivedAt.IsZero() {
+		history.ReceivedAt = time.Now().UTC()
+	}
+	m.jobHist[history.JobID] = history
+	return nil
+}
+
+// SetJobHistoryTime set time of event in history of job
+func (m *Memory) SetJobHistoryTime(ctx context.Context, jobID uuid.UUID, event datastore.JobHistoryEvent, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.jobHist[jobID]
+	if !ok {
+		return nil
+	}
+	h.Set(event, at)
+	m.jobHist[jobID] = h
+	return nil
+}
+
+// SetJobHistoryTimeByGitHubJobID set time of event in history of job that has ID of job in GitHub
+func (m *Memory) SetJobHistoryTimeByGitHubJobID(ctx context.Context, githubJobID int64, event datastore.JobHistoryEvent, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, h := range m.jobHist {
+		if h.GitHubJobID.Valid && h.GitHubJobID.Int64 == githubJobID {
+			h.Set(event, at)
+			m.jobHist[id] = h
+		}
+	}
+	return nil
+}
+
+// ListJobHistories get histories of jobs that received since `since`
+func (m *Memory) ListJobHistories(ctx context.Context, targetID uuid.UUID, since time.Time, limit int) ([]datastore.JobHistory, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var histories []datastore.JobHistory
+	for _, h := range m.jobHist {
+		if h.ReceivedAt.Before(since) {
+			continue
+		}
+		if !uuid.Equal(targetID, uuid.Nil) && !uuid.Equal(h.TargetID, targetID) {
+			continue
+		}
+		histories = append(histories, h)
+	}
+	sort.SliceStable(histories, func(i, j int) bool {
+		return histories[i].ReceivedAt.After(histories[j].ReceivedAt)
+	})
+	if limit > 0 && len(histories) > limit {
+		histories = histories[:limit]
+	}
+	return histories, nil
+}
+
+// PurgeJobHistories delete histories that received before `before`
+func (m *Memory) PurgeJobHistories(ctx context.Context, before time.Time, limit int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int64
+	for id, h := range m.jobHist {
+		if deleted >= int64(limit) {
+			break
+		}
+		if h.ReceivedAt.Before(before) {
+			delete(m.jobHist, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
 // GetLock get lock
 func (m *Memory) GetLock(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.locked = true
 	return nil
 }
 
 // IsLocked return status of lock
 func (m *Memory) IsLocked(ctx context.Context) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.locked {
+		return datastore.IsLocked, nil
+	}
 	return datastore.IsNotLocked, nil
 }
@@ -0,0 +1,157 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
+)
+
+var (
+	testTargetID = uuid.FromStringOrNil("8cc1a4d5-4bf1-43d5-8d8f-3a3b7c5a4f7e")
+	testJobID    = uuid.FromStringOrNil("1b4e5b7a-e3c1-4829-9cfd-eac4183f2c95")
+	testRunnerID = uuid.FromStringOrNil("7943c6d4-5b3e-4d57-8fb8-1a5b2b3e9c0d")
+)
+
+func TestMemory_Target(t *testing.T) {
+	ds, _ := memory.New(nil)
+	ctx := context.Background()
+
+	if err := ds.CreateTarget(ctx, datastore.Target{
+		UUID:         testTargetID,
+		Scope:        "octocat/hello-world",
+		ResourceType: datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	got, err := ds.GetTargetByScope(ctx, "", "octocat/hello-world")
+	if err != nil {
+		t.Fatalf("failed to get target by scope: %+v", err)
+	}
+	if got.Status != datastore.TargetStatusActive {
+		t.Fatalf("want status %s, but got %s", datastore.TargetStatusActive, got.Status)
+	}
+
+	if err := ds.DeleteTarget(ctx, testTargetID); err != nil {
+		t.Fatalf("failed to delete target: %+v", err)
+	}
+	got, err = ds.GetTarget(ctx, testTargetID)
+	if err != nil {
+		t.Fatalf("failed to get target: %+v", err)
+	}
+	if got.Status != datastore.TargetStatusDeleted {
+		t.Fatalf("want status %s, but got %s", datastore.TargetStatusDeleted, got.Status)
+	}
+
+	if _, err := ds.GetTarget(ctx, uuid.NewV4()); !errors.Is(err, datastore.ErrNotFound) {
+		t.Fatalf("want ErrNotFound, but got %+v", err)
+	}
+}
+
+func TestMemory_JobAndRunner(t *testing.T) {
+	notifyCh := make(chan struct{}, 1)
+	ds, _ := memory.New(notifyCh)
+	ctx := context.Background()
+
+	if err := ds.CreateTarget(ctx, datastore.Target{
+		UUID:         testTargetID,
+		Scope:        "octocat/hello-world",
+		ResourceType: datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	if _, err := ds.EnqueueJob(ctx, datastore.Job{
+		UUID:     testJobID,
+		TargetID: testTargetID,
+	}); err != nil {
+		t.Fatalf("failed to enqueue job: %+v", err)
+	}
+	select {
+	case <-notifyCh:
+	default:
+		t.Fatalf("enqueue is not notified")
+	}
+
+	jobs, err := ds.ListReadyJobs(ctx, time.Now().UTC())
+	if err != nil {
+		t.Fatalf("failed to list jobs: %+v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("want 1 job, but got %d", len(jobs))
+	}
+
+	if err := ds.CreateRunner(ctx, datastore.Runner{
+		UUID:     testRunnerID,
+		TargetID: testTargetID,
+	}); err != nil {
+		t.Fatalf("failed to create runner: %+v", err)
+	}
+	if err := ds.UpdateRunnerWithVersion(ctx, datastore.Runner{UUID: testRunnerID, CloudID: "mycloud-uuid", Version: 0}); err != nil {
+		t.Fatalf("failed to update runner: %+v", err)
+	}
+	if err := ds.UpdateRunnerWithVersion(ctx, datastore.Runner{UUID: testRunnerID, CloudID: "mycloud-stale", Version: 0}); !errors.Is(err, datastore.ErrConflict) {
+		t.Fatalf("want ErrConflict by stale version, but got %+v", err)
+	}
+	if err := ds.DeleteRunnerWithVersion(ctx, testRunnerID, 0, time.Now().UTC(), datastore.RunnerStatusCompleted); !errors.Is(err, datastore.ErrConflict) {
+		t.Fatalf("want ErrConflict by stale version, but got %+v", err)
+	}
+	if err := ds.DeleteRunner(ctx, testRunnerID, time.Now().UTC(), datastore.RunnerStatusCompleted); err != nil {
+		t.Fatalf("failed to delete runner: %+v", err)
+	}
+
+	runners, err := ds.ListRunnersByTargetID(ctx, testTargetID)
+	if err != nil {
+		t.Fatalf("failed to list runners: %+v", err)
+	}
+	if len(runners) != 0 {
+		t.Fatalf("want 0 runners, but got %d", len(runners))
+	}
+	r, err := ds.GetRunner(ctx, testRunnerID)
+	if err != nil {
+		t.Fatalf("failed to get runner: %+v", err)
+	}
+	if !r.Deleted || r.Status != datastore.RunnerStatusCompleted || r.CloudID != "mycloud-uuid" || r.Version != 2 {
+		t.Fatalf("runner must be updated once and deleted with reason, but got %+v", r)
+	}
+}
+
+func TestMemory_Purge(t *testing.T) {
+	ds, _ := memory.New(nil)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	if err := ds.CreateTarget(ctx, datastore.Target{
+		UUID:         testTargetID,
+		Scope:        "octocat/hello-world",
+		ResourceType: datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+	if err := ds.CreateRunner(ctx, datastore.Runner{
+		UUID:     testRunnerID,
+		TargetID: testTargetID,
+	}); err != nil {
+		t.Fatalf("failed to create runner: %+v", err)
+	}
+	if err := ds.DeleteRunner(ctx, testRunnerID, now.Add(-48*time.Hour), datastore.RunnerStatusCompleted); err != nil {
+		t.Fatalf("failed to delete runner: %+v", err)
+	}
+
+	deleted, err := ds.PurgeDeletedRunners(ctx, now.Add(-24*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("failed to purge deleted runners: %+v", err)
+	}
+	if deleted != 1 {
+		t.Fatalf("want 1 deleted runner, but got %d", deleted)
+	}
+	if _, err := ds.GetRunner(ctx, testRunnerID); !errors.Is(err, datastore.ErrNotFound) {
+		t.Fatalf("want ErrNotFound, but got %+v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// Migration is a versioned schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// file name format: {version}_{name}.up.sql or {version}_{name}.down.sql
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Load read migrations in dir of fsys, sorted by version
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration directory: %w", err)
+	}
+
+	migrations := map[int64]*Migration{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		matched := migrationFileRegexp.FindStringSubmatch(e.Name())
+		if matched == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", e.Name())
+		}
+		version, err := strconv.ParseInt(matched[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse version (file: %s): %w", e.Name(), err)
+		}
+		b, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file (file: %s): %w", e.Name(), err)
+		}
+
+		m, ok := migrations[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matched[2]}
+			migrations[version] = m
+		}
+		if m.Name != matched[2] {
+			return nil, fmt.Errorf("version %d has different names (%s, %s)", version, m.Name, matched[2])
+		}
+
+		switch matched[3] {
+		case "up":
+			m.Up = string(b)
+		case "down":
+			m.Down = string(b)
+		}
+	}
+
+	var result []Migration
+	for _, m := range migrations {
+		if m.Up == "" {
+			return nil, fmt.Errorf("version %d has not up migration", m.Version)
+		}
+		result = append(result, *m)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+
+	return result, nil
+}
+
+// Migrator apply migrations to database
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+
+	// splitStatements execute statements one by one.
+	// need to set if driver can't execute multiple statements at once (e.g. MySQL without multiStatements).
+	splitStatements bool
+}
+
+// New create a Migrator
+func New(db *sqlx.DB, migrations []Migration, splitStatements bool) *Migrator {
+	return &Migrator{
+		db:              db,
+		migrations:      migrations,
+		splitStatements: splitStatements,
+	}
+}
+
+const queryCreateVersionTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL PRIMARY KEY,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int64]struct{}, error) {
+	if _, err := m.db.ExecContext(ctx, queryCreateVersionTable); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var versions []int64
+	if err := m.db.SelectContext(ctx, &versions, `SELECT version FROM schema_migrations`); err != nil {
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	applied := map[int64]struct{}{}
+	for _, v := range versions {
+		applied[v] = struct{}{}
+	}
+	return applied, nil
+}
+
+// Version return the latest applied version, return 0 if no applied
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get applied versions: %w", err)
+	}
+
+	var latest int64
+	for v := range applied {
+		if v > latest {
+			latest = v
+		}
+	}
+	return latest, nil
+}
+
+// Up apply all migrations that not applied yet
+func (m *Migrator) Up(ctx context.Context) error {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get applied versions: %w", err)
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		logger.Logf(false, "apply migration %d_%s", migration.Version, migration.Name)
+		if err := m.exec(ctx, migration.Up); err != nil {
+			return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if _, err := m.db.ExecContext(ctx, `INSERT INTO schema_migrations(version) VALUES (?)`, migration.Version); err != nil {
+			return fmt.Errorf("failed to record version %d: %w", migration.Version, err)
+		}
+	}
+
+	return nil
+}
+
+// Down rollback applied migrations, the number of steps from latest
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get applied versions: %w", err)
+	}
+
+	for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return fmt.Errorf("migration %d_%s has not down migration", migration.Version, migration.Name)
+		}
+
+		logger.Logf(false, "rollback migration %d_%s", migration.Version, migration.Name)
+		if err := m.exec(ctx, migration.Down); err != nil {
+			return fmt.Errorf("failed to rollback migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if _, err := m.db.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, migration.Version); err != nil {
+			return fmt.Errorf("failed to delete version %d: %w", migration.Version, err)
+		}
+		steps--
+	}
+
+	return nil
+}
+
+func (m *Migrator) exec(ctx context.Context, query string) error {
+	if !m.splitStatements {
+		if _, err := m.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+		return nil
+	}
+
+	for _, q := range strings.Split(query, ";") {
+		if strings.TrimSpace(q) == "" {
+			continue
+		}
+		if _, err := m.db.ExecContext(ctx, q); err != nil {
+			return fmt.Errorf("failed to execute query (query: %s): %w", q, err)
+		}
+	}
+	return nil
+}
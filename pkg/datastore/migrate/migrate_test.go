@@ -0,0 +1,66 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		input fstest.MapFS
+		want  []Migration
+		err   bool
+	}{
+		{
+			input: fstest.MapFS{
+				"migrations/0002_add_column.up.sql":   {Data: []byte("ALTER TABLE a ADD COLUMN b INT;")},
+				"migrations/0002_add_column.down.sql": {Data: []byte("ALTER TABLE a DROP COLUMN b;")},
+				"migrations/0001_initial.up.sql":      {Data: []byte("CREATE TABLE a (id INT);")},
+			},
+			want: []Migration{
+				{
+					Version: 1,
+					Name:    "initial",
+					Up:      "CREATE TABLE a (id INT);",
+				},
+				{
+					Version: 2,
+					Name:    "add_column",
+					Up:      "ALTER TABLE a ADD COLUMN b INT;",
+					Down:    "ALTER TABLE a DROP COLUMN b;",
+				},
+			},
+			err: false,
+		},
+		{
+			input: fstest.MapFS{
+				"migrations/initial.up.sql": {Data: []byte("CREATE TABLE a (id INT);")},
+			},
+			want: nil,
+			err:  true,
+		},
+		{
+			input: fstest.MapFS{
+				"migrations/0001_initial.down.sql": {Data: []byte("DROP TABLE a;")},
+			},
+			want: nil,
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := Load(test.input, "migrations")
+		if !test.err && err != nil {
+			t.Fatalf("failed to load migrations: %+v", err)
+		}
+		if test.err && err == nil {
+			t.Fatalf("must be error, but not error")
+		}
+
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// ExportTargets get all targets include deleted targets for backup
+func (m *MySQL) ExportTargets(ctx context.Context) (_ []datastore.Target, err error) {
+	defer observe("ExportTargets", time.Now(), &err)
+
+	var ts []datastore.Target
+	query := `SELECT uuid, scope, ghe_domain, github_token, token_expired_at, resource_type, provider_url, status, status_description, external_ref, placement_params, user_data_format, runner_registration, runner_labels, quiet_hours, rootless, job_filters, deleted_at, created_at, updated_at FROM targets ORDER BY created_at`
+	if err := m.reader(ctx).SelectContext(ctx, &ts, query); err != nil {
+		return nil, fmt.Errorf("failed to SELECT query: %w", err)
+	}
+
+	return ts, nil
+}
+
+// ImportTargets create targets in a transaction for restore, skip targets that already exist by uuid or scope
+func (m *MySQL) ImportTargets(ctx context.Context, targets []datastore.Target) (_ int64, err error) {
+	defer observe("ImportTargets", time.Now(), &err)
+
+	tx, err := m.Conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var imported int64
+	for _, t := range targets {
+		var count int
+		query := `SELECT COUNT(*) FROM targets WHERE uuid = ? OR (scope = ? AND ghe_domain <=> ?)`
+		if err := tx.GetContext(ctx, &count, query, t.UUID.String(), t.Scope, t.GHEDomain); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to SELECT query: %w", err)
+		}
+		if count != 0 {
+			continue
+		}
+
+		query = `INSERT INTO targets(uuid, scope, ghe_domain, github_token, token_expired_at, resource_type, provider_url, status, status_description, external_ref, placement_params, user_data_format, runner_registration, runner_labels, quiet_hours, rootless, job_filters, deleted_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		if _, err := tx.ExecContext(
+			ctx,
+			query,
+			t.UUID.String(),
+			t.Scope,
+			t.GHEDomain,
+			t.GitHubToken,
+			t.TokenExpiredAt.Format("2006-01-02 15:04:05"),
+			t.ResourceType,
+			t.ProviderURL,
+			t.Status,
+			t.StatusDescription,
+			t.ExternalRef,
+			t.PlacementParams,
+			t.UserDataFormat,
+			t.RunnerRegistration,
+			t.RunnerLabels,
+			t.QuietHours,
+			t.Rootless,
+			t.JobFilters,
+			t.DeletedAt,
+			t.CreatedAt.Format("2006-01-02 15:04:05"),
+		); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to execute INSERT query (scope: %s): %w", t.Scope, err)
+		}
+		imported++
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to execute COMMIT: %w", err)
+	}
+	return imported, nil
+}
@@ -0,0 +1,97 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/whywaita/myshoes/internal/testutils"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+func TestMySQL_ImportTargets(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+
+	if err := testDatastore.CreateTarget(context.Background(), datastore.Target{
+		UUID:           testTargetID,
+		Scope:          testScopeRepo,
+		GitHubToken:    testGitHubToken,
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	tests := []struct {
+		input        []datastore.Target
+		wantImported int64
+		want         []datastore.Target
+		err          bool
+	}{
+		{
+			input: []datastore.Target{
+				{
+					// already exist
+					UUID:           testTargetID,
+					Scope:          testScopeRepo,
+					TokenExpiredAt: testTime,
+					ResourceType:   datastore.ResourceTypeLarge,
+					Status:         datastore.TargetStatusActive,
+					CreatedAt:      testTime,
+				},
+				{
+					UUID:           testTargetID2,
+					Scope:          testScopeOrg,
+					TokenExpiredAt: testTime,
+					ResourceType:   datastore.ResourceTypeMicro,
+					Status:         datastore.TargetStatusSuspend,
+					CreatedAt:      testTime,
+				},
+			},
+			wantImported: 1,
+			want: []datastore.Target{
+				{
+					UUID:           testTargetID,
+					Scope:          testScopeRepo,
+					GitHubToken:    testGitHubToken,
+					TokenExpiredAt: testTime,
+					ResourceType:   datastore.ResourceTypeNano,
+					Status:         datastore.TargetStatusActive,
+				},
+				{
+					UUID:           testTargetID2,
+					Scope:          testScopeOrg,
+					TokenExpiredAt: testTime,
+					ResourceType:   datastore.ResourceTypeMicro,
+					Status:         datastore.TargetStatusSuspend,
+				},
+			},
+			err: false,
+		},
+	}
+
+	for _, test := range tests {
+		imported, err := testDatastore.ImportTargets(context.Background(), test.input)
+		if !test.err && err != nil {
+			t.Fatalf("failed to import targets: %+v", err)
+		}
+		if imported != test.wantImported {
+			t.Errorf("mismatch imported (want: %d, got: %d)", test.wantImported, imported)
+		}
+
+		got, err := testDatastore.ExportTargets(context.Background())
+		if err != nil {
+			t.Fatalf("failed to export targets: %+v", err)
+		}
+		for i := range got {
+			got[i].CreatedAt = time.Time{}
+			got[i].UpdatedAt = time.Time{}
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
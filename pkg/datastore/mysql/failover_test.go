@@ -0,0 +1,63 @@
+package mysql_test
+
+import (
+	"testing"
+	"time"
+
+	drivermysql "github.com/go-sql-driver/mysql"
+
+	"github.com/whywaita/myshoes/internal/testutils"
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore/mysql"
+	"github.com/whywaita/myshoes/pkg/lock"
+)
+
+// TestMySQL_Failover run replicas that dispatch jobs by starter with lock in MySQL, partition leader from MySQL
+// while jobs are enqueued, and verify that standby take over and all jobs are dispatched exactly once.
+func TestMySQL_Failover(t *testing.T) {
+	dsn := testutils.GetTestDSN()
+	cfg, err := drivermysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("failed to parse DSN: %+v", err)
+	}
+	oldDSN := config.Config.MySQLDSN
+	defer func() { config.Config.MySQLDSN = oldDSN }()
+	config.Config.MySQLDSN = dsn
+
+	tests := []struct {
+		name       string
+		compatMode bool
+	}{
+		{
+			name:       "GET_LOCK",
+			compatMode: false,
+		},
+		{
+			name:       "lease",
+			compatMode: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ds, teardown := testutils.GetTestDatastore()
+			defer teardown()
+
+			testutils.RunFailover(t, ds, testutils.FailoverBackend{
+				Addr: cfg.Addr,
+				TTL:  1 * time.Second,
+				NewLocker: func(t *testing.T, addr string) lock.Locker {
+					replicaCfg := cfg.Clone()
+					replicaCfg.Addr = addr
+					m, err := mysql.New(replicaCfg.FormatDSN(), nil)
+					if err != nil {
+						t.Fatalf("failed to create datastore: %+v", err)
+					}
+					t.Cleanup(func() { m.Conn.Close() })
+					m.CompatMode = test.compatMode
+					return m
+				},
+			})
+		})
+	}
+}
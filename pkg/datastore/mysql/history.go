@@ -0,0 +1,52 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// CreateStateHistory record a status transition of job or runner
+func (m *MySQL) CreateStateHistory(ctx context.Context, history datastore.StateHistory) (err error) {
+	defer observe("CreateStateHistory", time.Now(), &err)
+
+	query := `INSERT INTO state_histories(resource_type, resource_id, status, reason) VALUES (?, ?, ?, ?)`
+	if _, err := m.Conn.ExecContext(ctx, query, history.ResourceType, history.ResourceID.String(), history.Status, history.Reason); err != nil {
+		return fmt.Errorf("failed to execute INSERT query: %w", err)
+	}
+
+	return nil
+}
+
+// ListStateHistories get status transitions of job or runner
+func (m *MySQL) ListStateHistories(ctx context.Context, resourceID uuid.UUID) (_ []datastore.StateHistory, err error) {
+	defer observe("ListStateHistories", time.Now(), &err)
+
+	var histories []datastore.StateHistory
+	query := `SELECT resource_type, resource_id, status, reason, created_at FROM state_histories WHERE resource_id = ? ORDER BY created_at, id`
+	if err := m.reader(ctx).SelectContext(ctx, &histories, query, resourceID.String()); err != nil {
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	return histories, nil
+}
+
+// PurgeStateHistories delete histories that created before `before`
+func (m *MySQL) PurgeStateHistories(ctx context.Context, before time.Time, limit int) (_ int64, err error) {
+	defer observe("PurgeStateHistories", time.Now(), &err)
+
+	query := `DELETE FROM state_histories WHERE created_at < ? ORDER BY created_at LIMIT ?`
+	result, err := m.Conn.ExecContext(ctx, query, before.UTC(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute DELETE query: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return deleted, nil
+}
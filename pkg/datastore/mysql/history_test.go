@@ -0,0 +1,81 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/whywaita/myshoes/internal/testutils"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+func TestMySQL_StateHistory(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+
+	tests := []struct {
+		input []datastore.StateHistory
+		want  []datastore.StateHistory
+		err   bool
+	}{
+		{
+			input: []datastore.StateHistory{
+				{
+					ResourceType: datastore.HistoryResourceRunner,
+					ResourceID:   testRunnerID,
+					Status:       datastore.HistoryStatusCreated,
+					Reason:       "cloud ID: 1",
+				},
+				{
+					ResourceType: datastore.HistoryResourceRunner,
+					ResourceID:   testRunnerID,
+					Status:       datastore.HistoryStatusDeleted,
+					Reason:       "completed",
+				},
+				{
+					ResourceType: datastore.HistoryResourceJob,
+					ResourceID:   testJobID,
+					Status:       datastore.HistoryStatusEnqueued,
+				},
+			},
+			want: []datastore.StateHistory{
+				{
+					ResourceType: datastore.HistoryResourceRunner,
+					ResourceID:   testRunnerID,
+					Status:       datastore.HistoryStatusCreated,
+					Reason:       "cloud ID: 1",
+				},
+				{
+					ResourceType: datastore.HistoryResourceRunner,
+					ResourceID:   testRunnerID,
+					Status:       datastore.HistoryStatusDeleted,
+					Reason:       "completed",
+				},
+			},
+			err: false,
+		},
+	}
+
+	for _, test := range tests {
+		for _, input := range test.input {
+			err := testDatastore.CreateStateHistory(context.Background(), input)
+			if !test.err && err != nil {
+				t.Fatalf("failed to create history: %+v", err)
+			}
+		}
+
+		got, err := testDatastore.ListStateHistories(context.Background(), testRunnerID)
+		if err != nil {
+			t.Fatalf("failed to list histories: %+v", err)
+		}
+		for i := range got {
+			got[i].CreatedAt = time.Time{}
+		}
+
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package mysql
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	// iamTokenTTL is lifetime of authentication token in RDS
+	iamTokenTTL = 15 * time.Minute
+	// iamTokenRefreshBefore is margin of regenerating token before expiry
+	iamTokenRefreshBefore = 5 * time.Minute
+)
+
+// awsCredentials is credentials of AWS, loaded from environment (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN)
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+func loadAWSCredentials() (*awsCredentials, error) {
+	c := &awsCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	return c, nil
+}
+
+// iamConnector is driver.Connector that authenticate by IAM token of RDS.
+// token is regenerated before expiry, it is used only when a new connection is opened.
+type iamConnector struct {
+	cfg    *mysql.Config
+	region string
+
+	mu          sync.Mutex
+	token       string
+	generatedAt time.Time
+}
+
+func newIAMConnector(cfg *mysql.Config, region string) *iamConnector {
+	cfg = cfg.Clone()
+	// token is sent as cleartext password, it is protected by TLS
+	cfg.AllowCleartextPasswords = true
+	return &iamConnector{
+		cfg:    cfg,
+		region: region,
+	}
+}
+
+// Connect open a new connection with valid token
+func (c *iamConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	token, err := c.getToken(time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get IAM token: %w", err)
+	}
+
+	cfg := c.cfg.Clone()
+	cfg.Passwd = token
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connector: %w", err)
+	}
+	return connector.Connect(ctx)
+}
+
+// Driver return MySQL driver
+func (c *iamConnector) Driver() driver.Driver {
+	return &mysql.MySQLDriver{}
+}
+
+func (c *iamConnector) getToken(now time.Time) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && now.Before(c.generatedAt.Add(iamTokenTTL-iamTokenRefreshBefore)) {
+		return c.token, nil
+	}
+
+	creds, err := loadAWSCredentials()
+	if err != nil {
+		return "", fmt.Errorf("failed to load AWS credentials: %w", err)
+	}
+	token := buildIAMToken(c.cfg.Addr, c.region, c.cfg.User, *creds, now)
+	c.token = token
+	c.generatedAt = now
+	return token, nil
+}
+
+// buildIAMToken build authentication token of RDS, it is presigned URL of "connect" action by Signature Version 4
+func buildIAMToken(endpoint, region, dbUser string, creds awsCredentials, now time.Time) string {
+	const service = "rds-db"
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+
+	params := map[string]string{
+		"Action":              "connect",
+		"DBUser":              dbUser,
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    creds.AccessKeyID + "/" + scope,
+		"X-Amz-Date":          amzDate,
+		"X-Amz-Expires":       fmt.Sprintf("%d", int(iamTokenTTL.Seconds())),
+		"X-Amz-SignedHeaders": "host",
+	}
+	if creds.SessionToken != "" {
+		params["X-Amz-Security-Token"] = creds.SessionToken
+	}
+	query := canonicalQuery(params)
+
+	emptyPayloadHash := sha256.Sum256(nil)
+	canonicalRequest := strings.Join([]string{
+		"GET",
+		"/",
+		query,
+		"host:" + endpoint + "\n",
+		"host",
+		hex.EncodeToString(emptyPayloadHash[:]),
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	return fmt.Sprintf("%s/?%s&X-Amz-Signature=%s", endpoint, query, signature)
+}
+
+// canonicalQuery encode query sorted by key, space is encoded to %20 in Signature Version 4
+func canonicalQuery(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, escape(k)+"="+escape(params[k]))
+	}
+	return strings.Join(pairs, "&")
+}
+
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -5,16 +5,33 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/jmoiron/sqlx"
 	uuid "github.com/satori/go.uuid"
 	"github.com/whywaita/myshoes/pkg/datastore"
 )
 
-// EnqueueJob add a job
-func (m *MySQL) EnqueueJob(ctx context.Context, job datastore.Job) error {
-	query := `INSERT INTO jobs(uuid, ghe_domain, repository, check_event, target_id) VALUES (?, ?, ?, ?, ?)`
-	if _, err := m.Conn.ExecContext(ctx, query, job.UUID, job.GHEDomain, job.Repository, job.CheckEventJSON, job.TargetID.String()); err != nil {
-		return fmt.Errorf("failed to execute INSERT query: %w", err)
+// EnqueueJob add a job, return the existing job if job that has same dedup_key is already enqueued
+func (m *MySQL) EnqueueJob(ctx context.Context, job datastore.Job) (_ *datastore.Job, err error) {
+	defer observe("EnqueueJob", time.Now(), &err)
+
+	query := `INSERT INTO jobs(uuid, ghe_domain, repository, check_event, target_id, not_before, external_ref, dedup_key) VALUES (?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE dedup_key = dedup_key`
+	result, err := m.Conn.ExecContext(ctx, query, job.UUID, job.GHEDomain, job.Repository, job.CheckEventJSON, job.TargetID.String(), job.NotBefore, job.ExternalRef, job.DedupKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute INSERT query: %w", err)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if inserted == 0 {
+		if !job.DedupKey.Valid {
+			// conflicted by uuid
+			return nil, fmt.Errorf("job is already exist (job ID: %s)", job.UUID)
+		}
+		// already enqueued
+		return m.GetJobByDedupKey(ctx, job.DedupKey.String)
 	}
 
 	select {
@@ -24,14 +41,51 @@ func (m *MySQL) EnqueueJob(ctx context.Context, job datastore.Job) error {
 		// no capacity on channel, do not block
 	}
 
-	return nil
+	return &job, nil
+}
+
+// GetJobByDedupKey get a job in queue that has dedup_key
+func (m *MySQL) GetJobByDedupKey(ctx context.Context, dedupKey string) (_ *datastore.Job, err error) {
+	defer observe("GetJobByDedupKey", time.Now(), &err)
+
+	var job datastore.Job
+	query := `SELECT uuid, ghe_domain, repository, check_event, target_id, not_before, external_ref, dedup_key, created_at, updated_at FROM jobs WHERE dedup_key = ?`
+	if err := m.Conn.GetContext(ctx, &job, query, dedupKey); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datastore.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	return &job, nil
+}
+
+// ListJobs get a page of jobs
+func (m *MySQL) ListJobs(ctx context.Context, opt datastore.ListOption) (_ []datastore.Job, err error) {
+	defer observe("ListJobs", time.Now(), &err)
+
+	var jobs []datastore.Job
+	query := `SELECT uuid, ghe_domain, repository, check_event, target_id, not_before, external_ref, dedup_key, created_at, updated_at FROM jobs`
+	clause, args := opt.Clause("uuid")
+	if err := m.reader(ctx).SelectContext(ctx, &jobs, query+clause, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datastore.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	return jobs, nil
 }
 
-// ListJobs get all jobs
-func (m *MySQL) ListJobs(ctx context.Context) ([]datastore.Job, error) {
+// ListReadyJobs get jobs that can dispatch at now
+func (m *MySQL) ListReadyJobs(ctx context.Context, now time.Time) (_ []datastore.Job, err error) {
+	defer observe("ListReadyJobs", time.Now(), &err)
+
 	var jobs []datastore.Job
-	query := `SELECT uuid, ghe_domain, repository, check_event, target_id, created_at, updated_at FROM jobs`
-	if err := m.Conn.SelectContext(ctx, &jobs, query); err != nil {
+	query := `SELECT uuid, ghe_domain, repository, check_event, target_id, not_before, external_ref, dedup_key, created_at, updated_at FROM jobs WHERE not_before IS NULL OR not_before <= ?`
+	if err := m.reader(ctx).SelectContext(ctx, &jobs, query, now.UTC()); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, datastore.ErrNotFound
 		}
@@ -42,8 +96,69 @@ func (m *MySQL) ListJobs(ctx context.Context) ([]datastore.Job, error) {
 	return jobs, nil
 }
 
+// CountPendingJobs count jobs in queue of target
+func (m *MySQL) CountPendingJobs(ctx context.Context, targetID uuid.UUID) (_ int, err error) {
+	defer observe("CountPendingJobs", time.Now(), &err)
+
+	var count int
+	query := `SELECT COUNT(*) FROM jobs`
+	var args []interface{}
+	if !uuid.Equal(targetID, uuid.Nil) {
+		query += ` WHERE target_id = ?`
+		args = append(args, targetID.String())
+	}
+	if err := m.reader(ctx).GetContext(ctx, &count, query, args...); err != nil {
+		return 0, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	return count, nil
+}
+
+// OldestPendingJobAge get elapsed time since the oldest job in queue was created
+func (m *MySQL) OldestPendingJobAge(ctx context.Context) (_ time.Duration, err error) {
+	defer observe("OldestPendingJobAge", time.Now(), &err)
+
+	var oldest sql.NullTime
+	query := `SELECT MIN(created_at) FROM jobs`
+	if err := m.reader(ctx).GetContext(ctx, &oldest, query); err != nil {
+		return 0, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+	if !oldest.Valid {
+		return 0, nil
+	}
+
+	return time.Since(oldest.Time), nil
+}
+
+// ListJobsByExternalRef get jobs that has external reference ID
+func (m *MySQL) ListJobsByExternalRef(ctx context.Context, externalRef string) (_ []datastore.Job, err error) {
+	defer observe("ListJobsByExternalRef", time.Now(), &err)
+
+	var jobs []datastore.Job
+	query := `SELECT uuid, ghe_domain, repository, check_event, target_id, not_before, external_ref, dedup_key, created_at, updated_at FROM jobs WHERE external_ref = ?`
+	if err := m.reader(ctx).SelectContext(ctx, &jobs, query, externalRef); err != nil {
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// DeferJob update not_before of job
+func (m *MySQL) DeferJob(ctx context.Context, id uuid.UUID, notBefore time.Time) (err error) {
+	defer observe("DeferJob", time.Now(), &err)
+
+	query := `UPDATE jobs SET not_before = ? WHERE uuid = ?`
+	if _, err := m.Conn.ExecContext(ctx, query, notBefore.UTC(), id.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
 // DeleteJob delete a job
-func (m *MySQL) DeleteJob(ctx context.Context, id uuid.UUID) error {
+func (m *MySQL) DeleteJob(ctx context.Context, id uuid.UUID) (err error) {
+	defer observe("DeleteJob", time.Now(), &err)
+
 	query := `DELETE FROM jobs WHERE uuid = ?`
 	if _, err := m.Conn.ExecContext(ctx, query, id.String()); err != nil {
 		return fmt.Errorf("failed to execute DELETE query: %w", err)
@@ -51,3 +166,26 @@ func (m *MySQL) DeleteJob(ctx context.Context, id uuid.UUID) error {
 
 	return nil
 }
+
+// DeleteJobsBulk delete jobs by a statement
+func (m *MySQL) DeleteJobsBulk(ctx context.Context, ids []uuid.UUID) (_ int64, err error) {
+	defer observe("DeleteJobsBulk", time.Now(), &err)
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	query, args, err := sqlx.In(`DELETE FROM jobs WHERE uuid IN (?)`, uuidsToStrings(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create IN query: %w", err)
+	}
+	result, err := m.Conn.ExecContext(ctx, m.Conn.Rebind(query), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute DELETE query: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,74 @@
+package mysql_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/whywaita/myshoes/internal/testutils"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+func TestMySQL_JobHistory(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+
+	ctx := context.Background()
+	received := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	created := received.Add(10 * time.Second)
+	started := received.Add(time.Minute)
+	completed := received.Add(5 * time.Minute)
+
+	if err := testDatastore.CreateJobHistory(ctx, datastore.JobHistory{
+		JobID:       testJobID,
+		TargetID:    testTargetID,
+		GitHubJobID: sql.NullInt64{Int64: 100, Valid: true},
+		ReceivedAt:  received,
+	}); err != nil {
+		t.Fatalf("failed to create job history: %+v", err)
+	}
+
+	if err := testDatastore.SetJobHistoryTime(ctx, testJobID, datastore.JobHistoryRunnerCreated, created); err != nil {
+		t.Fatalf("failed to set runner_created_at: %+v", err)
+	}
+	// already set time is not overwritten
+	if err := testDatastore.SetJobHistoryTime(ctx, testJobID, datastore.JobHistoryRunnerCreated, completed); err != nil {
+		t.Fatalf("failed to set runner_created_at: %+v", err)
+	}
+	if err := testDatastore.SetJobHistoryTimeByGitHubJobID(ctx, 100, datastore.JobHistoryStarted, started); err != nil {
+		t.Fatalf("failed to set started_at: %+v", err)
+	}
+	if err := testDatastore.SetJobHistoryTimeByGitHubJobID(ctx, 100, datastore.JobHistoryCompleted, completed); err != nil {
+		t.Fatalf("failed to set completed_at: %+v", err)
+	}
+
+	want := []datastore.JobHistory{
+		{
+			JobID:           testJobID,
+			TargetID:        testTargetID,
+			GitHubJobID:     sql.NullInt64{Int64: 100, Valid: true},
+			ReceivedAt:      received,
+			RunnerCreatedAt: sql.NullTime{Time: created, Valid: true},
+			StartedAt:       sql.NullTime{Time: started, Valid: true},
+			CompletedAt:     sql.NullTime{Time: completed, Valid: true},
+		},
+	}
+	got, err := testDatastore.ListJobHistories(ctx, testTargetID, received.Add(-time.Hour), 0)
+	if err != nil {
+		t.Fatalf("failed to list job histories: %+v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	purged, err := testDatastore.PurgeJobHistories(ctx, received.Add(time.Second), 100)
+	if err != nil {
+		t.Fatalf("failed to purge job histories: %+v", err)
+	}
+	if purged != 1 {
+		t.Errorf("purged must be 1, but got %d", purged)
+	}
+}
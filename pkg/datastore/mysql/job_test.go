@@ -59,7 +59,7 @@ func TestMySQL_EnqueueJob(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		err := testDatastore.EnqueueJob(context.Background(), test.input)
+		_, err := testDatastore.EnqueueJob(context.Background(), test.input)
 		if !test.err && err != nil {
 			t.Fatalf("failed to enqueue job: %+v", err)
 		}
@@ -77,6 +77,54 @@ func TestMySQL_EnqueueJob(t *testing.T) {
 	}
 }
 
+func TestMySQL_EnqueueJobDedup(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+
+	if err := testDatastore.CreateTarget(context.Background(), datastore.Target{
+		UUID:           testTargetID,
+		Scope:          testScopeRepo,
+		GitHubToken:    testGitHubToken,
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	dedupKey := sql.NullString{String: "workflow_job:1", Valid: true}
+	first, err := testDatastore.EnqueueJob(context.Background(), datastore.Job{
+		UUID:           testJobID,
+		Repository:     testScopeRepo,
+		CheckEventJSON: `{"example": "json"}`,
+		TargetID:       testTargetID,
+		DedupKey:       dedupKey,
+	})
+	if err != nil {
+		t.Fatalf("failed to enqueue job: %+v", err)
+	}
+	second, err := testDatastore.EnqueueJob(context.Background(), datastore.Job{
+		UUID:           uuid.NewV4(),
+		Repository:     testScopeRepo,
+		CheckEventJSON: `{"example": "json"}`,
+		TargetID:       testTargetID,
+		DedupKey:       dedupKey,
+	})
+	if err != nil {
+		t.Fatalf("failed to enqueue duplicated job: %+v", err)
+	}
+	if !uuid.Equal(first.UUID, second.UUID) {
+		t.Errorf("duplicated job must return existing job, want %s but got %s", first.UUID, second.UUID)
+	}
+
+	got, err := testDatastore.ListJobs(context.Background(), datastore.ListOption{})
+	if err != nil {
+		t.Fatalf("failed to get jobs: %+v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("incorrect length jobs, want: 1 but got: %d", len(got))
+	}
+}
+
 func TestMySQL_ListJobs(t *testing.T) {
 	testDatastore, teardown := testutils.GetTestDatastore()
 	defer teardown()
@@ -122,13 +170,103 @@ func TestMySQL_ListJobs(t *testing.T) {
 
 	for _, test := range tests {
 		for _, input := range test.input {
-			err := testDatastore.EnqueueJob(context.Background(), input)
+			_, err := testDatastore.EnqueueJob(context.Background(), input)
 			if !test.err && err != nil {
 				t.Fatalf("failed to enqueue job: %+v", err)
 			}
 		}
 
-		got, err := testDatastore.ListJobs(context.Background())
+		got, err := testDatastore.ListJobs(context.Background(), datastore.ListOption{})
+		if err != nil {
+			t.Fatalf("failed to get jobs: %+v", err)
+		}
+		if len(test.want) != len(got) {
+			t.Fatalf("incorrect length jobs, want: %d but got: %d", len(test.want), len(got))
+		}
+		for i := range got {
+			got[i].CreatedAt = time.Time{}
+			got[i].UpdatedAt = time.Time{}
+		}
+
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestMySQL_ListReadyJobs(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+
+	if err := testDatastore.CreateTarget(context.Background(), datastore.Target{
+		UUID:  testTargetID,
+		Scope: testScopeRepo,
+		GHEDomain: sql.NullString{
+			Valid: false,
+		},
+		GitHubToken:    testGitHubToken,
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	now := time.Date(2037, 9, 30, 0, 0, 0, 0, time.UTC)
+	delayedJobID := uuid.FromStringOrNil("9f2d5a3e-0c1b-4f6e-8a7d-2b3c4d5e6f70")
+
+	tests := []struct {
+		input []datastore.Job
+		want  []datastore.Job
+		err   bool
+	}{
+		{
+			input: []datastore.Job{
+				{
+					UUID:           testJobID,
+					Repository:     testScopeRepo,
+					CheckEventJSON: `{"example": "json"}`,
+					TargetID:       testTargetID,
+					NotBefore: sql.NullTime{
+						Time:  now.Add(-1 * time.Minute),
+						Valid: true,
+					},
+				},
+				{
+					UUID:           delayedJobID,
+					Repository:     testScopeRepo,
+					CheckEventJSON: `{"example": "json"}`,
+					TargetID:       testTargetID,
+					NotBefore: sql.NullTime{
+						Time:  now.Add(1 * time.Minute),
+						Valid: true,
+					},
+				},
+			},
+			want: []datastore.Job{
+				{
+					UUID:           testJobID,
+					Repository:     testScopeRepo,
+					CheckEventJSON: `{"example": "json"}`,
+					TargetID:       testTargetID,
+					NotBefore: sql.NullTime{
+						Time:  now.Add(-1 * time.Minute),
+						Valid: true,
+					},
+				},
+			},
+			err: false,
+		},
+	}
+
+	for _, test := range tests {
+		for _, input := range test.input {
+			_, err := testDatastore.EnqueueJob(context.Background(), input)
+			if !test.err && err != nil {
+				t.Fatalf("failed to enqueue job: %+v", err)
+			}
+		}
+
+		got, err := testDatastore.ListReadyJobs(context.Background(), now)
 		if err != nil {
 			t.Fatalf("failed to get jobs: %+v", err)
 		}
@@ -164,7 +302,7 @@ func TestMySQL_DeleteJob(t *testing.T) {
 		t.Fatalf("failed to create target: %+v", err)
 	}
 
-	if err := testDatastore.EnqueueJob(context.Background(), datastore.Job{
+	if _, err := testDatastore.EnqueueJob(context.Background(), datastore.Job{
 		UUID:           testJobID,
 		Repository:     testScopeRepo,
 		CheckEventJSON: `{"example": "json"}`,
@@ -206,6 +344,149 @@ func TestMySQL_DeleteJob(t *testing.T) {
 	}
 }
 
+func TestMySQL_DeleteJobsBulk(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+
+	if err := testDatastore.CreateTarget(context.Background(), datastore.Target{
+		UUID:           testTargetID,
+		Scope:          testScopeRepo,
+		GitHubToken:    testGitHubToken,
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		id := uuid.NewV4()
+		if _, err := testDatastore.EnqueueJob(context.Background(), datastore.Job{
+			UUID:           id,
+			Repository:     testScopeRepo,
+			CheckEventJSON: `{"example": "json"}`,
+			TargetID:       testTargetID,
+		}); err != nil {
+			t.Fatalf("failed to enqueue job: %+v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	tests := []struct {
+		input []uuid.UUID
+		want  int64
+		left  int
+	}{
+		{
+			input: nil,
+			want:  0,
+			left:  3,
+		},
+		{
+			// not exist job is ignored
+			input: []uuid.UUID{ids[0], ids[1], uuid.NewV4()},
+			want:  2,
+			left:  1,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := testDatastore.DeleteJobsBulk(context.Background(), test.input)
+		if err != nil {
+			t.Fatalf("failed to delete jobs: %+v", err)
+		}
+		if got != test.want {
+			t.Errorf("want %d deleted jobs, but got %d", test.want, got)
+		}
+		jobs, err := testDatastore.ListJobs(context.Background(), datastore.ListOption{})
+		if err != nil {
+			t.Fatalf("failed to list jobs: %+v", err)
+		}
+		if len(jobs) != test.left {
+			t.Errorf("want %d jobs left, but got %d", test.left, len(jobs))
+		}
+	}
+}
+
+func TestMySQL_CountPendingJobs(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+	testDB, _ := testutils.GetTestDB()
+
+	if err := testDatastore.CreateTarget(context.Background(), datastore.Target{
+		UUID:  testTargetID,
+		Scope: testScopeRepo,
+		GHEDomain: sql.NullString{
+			Valid: false,
+		},
+		GitHubToken:    testGitHubToken,
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	age, err := testDatastore.OldestPendingJobAge(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get age of oldest job: %+v", err)
+	}
+	if age != 0 {
+		t.Fatalf("age of oldest job must be 0 if queue is empty, but got: %s", age)
+	}
+
+	if _, err := testDatastore.EnqueueJob(context.Background(), datastore.Job{
+		UUID:           testJobID,
+		Repository:     testScopeRepo,
+		CheckEventJSON: `{"example": "json"}`,
+		TargetID:       testTargetID,
+	}); err != nil {
+		t.Fatalf("failed to enqueue job: %+v", err)
+	}
+	if _, err := testDB.Exec(`UPDATE jobs SET created_at = ? WHERE uuid = ?`, time.Now().UTC().Add(-1*time.Hour), testJobID.String()); err != nil {
+		t.Fatalf("failed to update created_at: %+v", err)
+	}
+
+	tests := []struct {
+		input uuid.UUID
+		want  int
+		err   bool
+	}{
+		{
+			input: testTargetID,
+			want:  1,
+			err:   false,
+		},
+		{
+			input: uuid.Nil,
+			want:  1,
+			err:   false,
+		},
+		{
+			input: uuid.NewV4(),
+			want:  0,
+			err:   false,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := testDatastore.CountPendingJobs(context.Background(), test.input)
+		if !test.err && err != nil {
+			t.Fatalf("failed to count jobs: %+v", err)
+		}
+		if got != test.want {
+			t.Fatalf("incorrect number of jobs, want: %d but got: %d", test.want, got)
+		}
+	}
+
+	age, err = testDatastore.OldestPendingJobAge(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get age of oldest job: %+v", err)
+	}
+	if age < 59*time.Minute {
+		t.Fatalf("age of oldest job must be about 1 hour, but got: %s", age)
+	}
+}
+
 func getJobFromSQL(testDB *sqlx.DB, id uuid.UUID) (*datastore.Job, error) {
 	var j datastore.Job
 	query := `SELECT uuid, ghe_domain, repository, check_event, target_id FROM jobs WHERE uuid = ?`
@@ -2,40 +2,43 @@ package mysql
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
 	"github.com/whywaita/myshoes/pkg/config"
 	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/lock"
+	"github.com/whywaita/myshoes/pkg/logger"
 )
 
 // GetLock get lock
-func (m *MySQL) GetLock(ctx context.Context) error {
-	var res int
-
-	cfg, err := mysql.ParseDSN(config.Config.MySQLDSN)
-	if err != nil {
-		return fmt.Errorf("failed to parse DSN: %w", err)
-	}
-	lockKey := cfg.DBName
+func (m *MySQL) GetLock(ctx context.Context) (err error) {
+	defer observe("GetLock", time.Now(), &err)
 
-	query := fmt.Sprintf(`SELECT GET_LOCK('%s', 10)`, lockKey)
-	if err := m.Conn.GetContext(ctx, &res, query); err != nil {
-		return fmt.Errorf("failed to GET_LOCK: %w", err)
+	if m.CompatMode {
+		return m.getLeaseLock(ctx)
 	}
-
-	return nil
+	return m.getSessionLock(ctx)
 }
 
 // IsLocked return status of lock
-func (m *MySQL) IsLocked(ctx context.Context) (string, error) {
+func (m *MySQL) IsLocked(ctx context.Context) (_ string, err error) {
+	defer observe("IsLocked", time.Now(), &err)
+
+	if m.CompatMode {
+		return m.isLeaseLocked(ctx)
+	}
+
 	var res int
 
-	cfg, err := mysql.ParseDSN(config.Config.MySQLDSN)
+	lockKey, err := getLockKey()
 	if err != nil {
-		return "", fmt.Errorf("failed to parse DSN: %w", err)
+		return "", err
 	}
-	lockKey := cfg.DBName
 
 	query := fmt.Sprintf(`SELECT IS_FREE_LOCK('%s')`, lockKey)
 	if err := m.Conn.GetContext(ctx, &res, query); err != nil {
@@ -51,3 +54,217 @@ func (m *MySQL) IsLocked(ctx context.Context) (string, error) {
 
 	return "", fmt.Errorf("IS_FREE_LOCK return NULL")
 }
+
+// Lost return channel that closed when lock is lost (e.g. session of GET_LOCK() is disconnected, lease is taken over)
+func (m *MySQL) Lost() <-chan struct{} {
+	m.lockMu.Lock()
+	defer m.lockMu.Unlock()
+	return m.lost
+}
+
+func getLockKey() (string, error) {
+	cfg, err := mysql.ParseDSN(config.Config.MySQLDSN)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse DSN: %w", err)
+	}
+	return cfg.DBName, nil
+}
+
+// getSessionLock get lock by GET_LOCK(). lock is bound to session, so it is held in dedicated connection
+// that is not returned to pool (pooled connection is closed by DB_CONN_MAX_LIFETIME and lock is released silently).
+func (m *MySQL) getSessionLock(ctx context.Context) error {
+	lockKey, err := getLockKey()
+	if err != nil {
+		return err
+	}
+
+	conn, err := m.Conn.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	// not wait, other instance is leader if lock is held
+	var res sql.NullInt64
+	if err := conn.GetContext(ctx, &res, `SELECT GET_LOCK(?, 0)`, lockKey); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to GET_LOCK: %w", err)
+	}
+	if !res.Valid {
+		conn.Close()
+		return fmt.Errorf("GET_LOCK return NULL")
+	}
+	if res.Int64 != 1 {
+		conn.Close()
+		return lock.ErrNotAcquired
+	}
+
+	lost := make(chan struct{})
+	m.lockMu.Lock()
+	m.lost = lost
+	m.lockMu.Unlock()
+
+	go m.keepaliveSession(ctx, conn, lockKey, lost)
+
+	// lock is released as soon as session of previous leader is disconnected, but previous leader notices it
+	// in next keepalive. wait for it before leading, so there is never more than one leader.
+	wait := time.NewTimer(takeoverDelay())
+	defer wait.Stop()
+	select {
+	case <-wait.C:
+		return nil
+	case <-lost:
+		return lock.ErrNotAcquired
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// takeoverDelay return duration until previous leader notices that session of lock is lost.
+// it is longer than an interval of keepalive and a timeout of check in keepaliveSession.
+func takeoverDelay() time.Duration {
+	return 3 * keepaliveInterval()
+}
+
+// keepaliveSession check that session of conn still hold lock, and release lock when ctx is done
+func (m *MySQL) keepaliveSession(ctx context.Context, conn *sqlx.Conn, lockKey string, lost chan struct{}) {
+	defer conn.Close()
+
+	ticker := time.NewTicker(keepaliveInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, keepaliveInterval())
+			var held sql.NullBool
+			err := conn.GetContext(checkCtx, &held, `SELECT IS_USED_LOCK(?) = CONNECTION_ID()`, lockKey)
+			cancel()
+			if ctx.Err() != nil {
+				continue
+			}
+			if err != nil || !held.Bool {
+				logger.Logf(false, "session of lock is lost (name: %s, err: %+v)", lockKey, err)
+				// session may be alive and hold lock, discard it for releasing lock
+				_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+				close(lost)
+				return
+			}
+		case <-ctx.Done():
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if _, err := conn.ExecContext(releaseCtx, `SELECT RELEASE_LOCK(?)`, lockKey); err != nil {
+				logger.Logf(false, "failed to release lock: %+v", err)
+				_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+			}
+			cancel()
+			return
+		}
+	}
+}
+
+// keepaliveInterval return interval of checking lock is held
+func keepaliveInterval() time.Duration {
+	if config.Config.LockTTL <= 0 {
+		return 5 * time.Second
+	}
+	return config.Config.LockTTL / 3
+}
+
+// getLeaseLock get lock by lease in locks table, GET_LOCK() is not supported in some MySQL compatible databases (e.g. TiDB),
+// and is released silently in failover (e.g. Aurora MySQL).
+func (m *MySQL) getLeaseLock(ctx context.Context) error {
+	lockKey, err := getLockKey()
+	if err != nil {
+		return err
+	}
+
+	// take over lock only if lease is expired, and extend lease only if lock is held by own
+	query := `INSERT INTO locks(name, owner, expired_at) VALUES (?, ?, NOW(6) + INTERVAL ? MICROSECOND)
+ON DUPLICATE KEY UPDATE owner = IF(expired_at < NOW(6), VALUES(owner), owner), expired_at = IF(owner = VALUES(owner), VALUES(expired_at), expired_at)`
+	sent := time.Now()
+	if _, err := m.Conn.ExecContext(ctx, query, lockKey, m.lockOwner, config.Config.LockTTL.Microseconds()); err != nil {
+		return fmt.Errorf("failed to execute INSERT query: %w", err)
+	}
+
+	var owner string
+	if err := m.Conn.GetContext(ctx, &owner, `SELECT owner FROM locks WHERE name = ?`, lockKey); err != nil {
+		return fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+	if owner != m.lockOwner {
+		return lock.ErrNotAcquired
+	}
+
+	lost := make(chan struct{})
+	m.lockMu.Lock()
+	m.lost = lost
+	m.lockMu.Unlock()
+
+	go m.keepaliveLease(ctx, lockKey, lost, sent.Add(config.Config.LockTTL))
+	return nil
+}
+
+func (m *MySQL) isLeaseLocked(ctx context.Context) (string, error) {
+	lockKey, err := getLockKey()
+	if err != nil {
+		return "", err
+	}
+
+	var count int
+	if err := m.Conn.GetContext(ctx, &count, `SELECT COUNT(*) FROM locks WHERE name = ? AND expired_at >= NOW(6)`, lockKey); err != nil {
+		return "", fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+	if count == 0 {
+		return datastore.IsNotLocked, nil
+	}
+	return datastore.IsLocked, nil
+}
+
+// keepaliveLease extend lease until ctx is done. lost is closed if lease is taken over,
+// or if lease is not extended until just before expireAt, so this instance step down before other instance take over it.
+func (m *MySQL) keepaliveLease(ctx context.Context, lockKey string, lost chan struct{}, expireAt time.Time) {
+	ttl := config.Config.LockTTL
+	ticker := time.NewTicker(keepaliveInterval())
+	defer ticker.Stop()
+	stepDown := time.NewTimer(lock.UntilStepDown(ttl, expireAt))
+	defer func() { stepDown.Stop() }()
+
+	for {
+		select {
+		case <-ticker.C:
+			sent := time.Now()
+			extendCtx, cancel := context.WithDeadline(ctx, expireAt)
+			query := `UPDATE locks SET expired_at = NOW(6) + INTERVAL ? MICROSECOND WHERE name = ? AND owner = ?`
+			result, err := m.Conn.ExecContext(extendCtx, query, ttl.Microseconds(), lockKey, m.lockOwner)
+			cancel()
+			if err != nil {
+				// retry in next tick, lock is alive until expireAt
+				logger.Logf(false, "failed to extend lease of lock: %+v", err)
+				continue
+			}
+			if n, err := result.RowsAffected(); err == nil && n == 0 {
+				logger.Logf(false, "lease of lock is lost (name: %s)", lockKey)
+				close(lost)
+				return
+			}
+			expireAt = sent.Add(ttl)
+			stepDown.Stop()
+			stepDown = time.NewTimer(lock.UntilStepDown(ttl, expireAt))
+		case <-stepDown.C:
+			logger.Logf(false, "lease of lock is not extended until it expires (name: %s)", lockKey)
+			close(lost)
+			return
+		case <-ctx.Done():
+			m.releaseLease(lockKey)
+			return
+		}
+	}
+}
+
+// releaseLease delete lock if it is held by own
+func (m *MySQL) releaseLease(lockKey string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := m.Conn.ExecContext(ctx, `DELETE FROM locks WHERE name = ? AND owner = ?`, lockKey, m.lockOwner); err != nil {
+		logger.Logf(false, "failed to release lock: %+v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package mysql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/whywaita/myshoes/internal/testutils"
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/mysql"
+	"github.com/whywaita/myshoes/pkg/lock"
+)
+
+func TestMySQL_GetLock(t *testing.T) {
+	dsn := testutils.GetTestDSN()
+	oldDSN, oldTTL := config.Config.MySQLDSN, config.Config.LockTTL
+	defer func() {
+		config.Config.MySQLDSN, config.Config.LockTTL = oldDSN, oldTTL
+	}()
+	config.Config.MySQLDSN = dsn
+	config.Config.LockTTL = 3 * time.Second
+	ctx := context.Background()
+
+	leader, err := mysql.New(dsn, nil)
+	if err != nil {
+		t.Fatalf("failed to create datastore: %+v", err)
+	}
+	defer leader.Conn.Close()
+	// connection that run GET_LOCK() must not be recycled in pool
+	leader.Conn.SetConnMaxLifetime(100 * time.Millisecond)
+	standby, err := mysql.New(dsn, nil)
+	if err != nil {
+		t.Fatalf("failed to create datastore: %+v", err)
+	}
+	defer standby.Conn.Close()
+
+	lockCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := leader.GetLock(lockCtx); err != nil {
+		t.Fatalf("failed to get lock: %+v", err)
+	}
+	if err := standby.GetLock(ctx); !errors.Is(err, lock.ErrNotAcquired) {
+		t.Fatalf("lock held by other must not be acquired, but got %+v", err)
+	}
+
+	time.Sleep(2 * config.Config.LockTTL)
+	select {
+	case <-leader.Lost():
+		t.Fatalf("lock must be kept while session is alive")
+	default:
+	}
+	if err := standby.GetLock(ctx); !errors.Is(err, lock.ErrNotAcquired) {
+		t.Fatalf("lock must be kept after connection lifetime, but got %+v", err)
+	}
+
+	// session of leader is killed (e.g. failover of database), leader must notice it
+	var id int64
+	if err := standby.Conn.GetContext(ctx, &id, `SELECT IS_USED_LOCK('mysql')`); err != nil {
+		t.Fatalf("failed to get session of lock: %+v", err)
+	}
+	if _, err := standby.Conn.ExecContext(ctx, `KILL ?`, id); err != nil {
+		t.Fatalf("failed to kill session of lock: %+v", err)
+	}
+	select {
+	case <-leader.Lost():
+	case <-time.After(2 * config.Config.LockTTL):
+		t.Fatalf("Lost must be closed after session is killed")
+	}
+
+	status, err := standby.IsLocked(ctx)
+	if err != nil {
+		t.Fatalf("failed to check lock: %+v", err)
+	}
+	if status != datastore.IsNotLocked {
+		t.Fatalf("lock must be released, but got %s", status)
+	}
+	standbyCtx, cancelStandby := context.WithCancel(ctx)
+	defer cancelStandby()
+	started := time.Now()
+	if err := standby.GetLock(standbyCtx); err != nil {
+		t.Fatalf("standby must get released lock: %+v", err)
+	}
+	// previous leader may not notice lost session yet, standby must wait for it
+	if elapsed := time.Since(started); elapsed < config.Config.LockTTL {
+		t.Errorf("standby must wait %s before leading, but waited %s", config.Config.LockTTL, elapsed)
+	}
+}
+
+func TestMySQL_GetLeaseLock_StepDown(t *testing.T) {
+	dsn := testutils.GetTestDSN()
+	oldDSN, oldTTL := config.Config.MySQLDSN, config.Config.LockTTL
+	defer func() {
+		config.Config.MySQLDSN, config.Config.LockTTL = oldDSN, oldTTL
+	}()
+	config.Config.MySQLDSN = dsn
+	config.Config.LockTTL = 3 * time.Second
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leader, err := mysql.New(dsn, nil)
+	if err != nil {
+		t.Fatalf("failed to create datastore: %+v", err)
+	}
+	leader.CompatMode = true
+	got := time.Now()
+	if err := leader.GetLock(ctx); err != nil {
+		t.Fatalf("failed to get lock: %+v", err)
+	}
+
+	// database is unreachable, lease can not be extended
+	leader.Conn.Close()
+	select {
+	case <-leader.Lost():
+		if elapsed := time.Since(got); elapsed >= config.Config.LockTTL {
+			t.Fatalf("must step down before lease expires, but stepped down after %s", elapsed)
+		}
+	case <-time.After(2 * config.Config.LockTTL):
+		t.Fatalf("Lost must be closed before lease expires")
+	}
+}
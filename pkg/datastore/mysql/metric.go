@@ -0,0 +1,114 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+const (
+	namespace = "myshoes"
+	subsystem = "mysql"
+)
+
+var (
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "query_duration_seconds",
+		Help:      "Latency of queries to MySQL per query family.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"query"})
+	queryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "query_errors_total",
+		Help:      "Total number of failed queries to MySQL per query family.",
+	}, []string{"query"})
+
+	poolStats = &poolStatsCollector{}
+)
+
+func init() {
+	prometheus.MustRegister(queryDuration, queryErrors, poolStats)
+}
+
+// observe record latency and error of query family. call it by defer with named error
+func observe(query string, start time.Time, err *error) {
+	elapsed := time.Since(start)
+	queryDuration.WithLabelValues(query).Observe(elapsed.Seconds())
+	datastore.ObserveCall(elapsed, *err)
+	if *err != nil && !errors.Is(*err, datastore.ErrNotFound) {
+		queryErrors.WithLabelValues(query).Inc()
+	}
+}
+
+var (
+	poolOpenConnectionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystem, "pool_open_connections"),
+		"Number of established connections both in use and idle.",
+		nil, nil,
+	)
+	poolInUseDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystem, "pool_in_use_connections"),
+		"Number of connections currently in use.",
+		nil, nil,
+	)
+	poolIdleDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystem, "pool_idle_connections"),
+		"Number of idle connections.",
+		nil, nil,
+	)
+	poolWaitCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystem, "pool_wait_count_total"),
+		"Total number of connections waited for.",
+		nil, nil,
+	)
+	poolWaitDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystem, "pool_wait_duration_seconds_total"),
+		"Total time blocked waiting for a new connection.",
+		nil, nil,
+	)
+)
+
+// poolStatsCollector collect stats of connection pool in the latest connection
+type poolStatsCollector struct {
+	mu sync.RWMutex
+	db *sql.DB
+}
+
+func (c *poolStatsCollector) set(db *sql.DB) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.db = db
+}
+
+// Describe implement prometheus.Collector
+func (c *poolStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolOpenConnectionsDesc
+	ch <- poolInUseDesc
+	ch <- poolIdleDesc
+	ch <- poolWaitCountDesc
+	ch <- poolWaitDurationDesc
+}
+
+// Collect implement prometheus.Collector
+func (c *poolStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	db := c.db
+	c.mu.RUnlock()
+	if db == nil {
+		return
+	}
+
+	stats := db.Stats()
+	ch <- prometheus.MustNewConstMetric(poolOpenConnectionsDesc, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(poolInUseDesc, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(poolWaitCountDesc, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(poolWaitDurationDesc, prometheus.CounterValue, stats.WaitDuration.Seconds())
+}
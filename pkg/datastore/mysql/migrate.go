@@ -0,0 +1,40 @@
+package mysql
+
+import (
+	"context"
+	"embed"
+	"fmt"
+
+	"github.com/whywaita/myshoes/pkg/datastore/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+func (m *MySQL) newMigrator() (*migrate.Migrator, error) {
+	migrations, err := migrate.Load(migrationFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	// MySQL driver can't execute multiple statements without multiStatements
+	return migrate.New(m.Conn, migrations, true), nil
+}
+
+// Migrate apply all migrations that not applied yet
+func (m *MySQL) Migrate(ctx context.Context) error {
+	migrator, err := m.newMigrator()
+	if err != nil {
+		return fmt.Errorf("failed to create migrator: %w", err)
+	}
+	return migrator.Up(ctx)
+}
+
+// MigrateDown rollback migrations, the number of steps from latest
+func (m *MySQL) MigrateDown(ctx context.Context, steps int) error {
+	migrator, err := m.newMigrator()
+	if err != nil {
+		return fmt.Errorf("failed to create migrator: %w", err)
+	}
+	return migrator.Down(ctx, steps)
+}
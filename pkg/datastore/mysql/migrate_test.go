@@ -0,0 +1,134 @@
+package mysql_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/whywaita/myshoes/internal/testutils"
+	"github.com/whywaita/myshoes/pkg/datastore/mysql"
+)
+
+// describeSchema return definitions of columns, indexes and foreign keys in database, except table of migrations
+func describeSchema(t *testing.T, db *sqlx.DB, dbName string) []string {
+	t.Helper()
+
+	var columns []struct {
+		Table    string  `db:"TABLE_NAME"`
+		Column   string  `db:"COLUMN_NAME"`
+		Type     string  `db:"COLUMN_TYPE"`
+		Nullable string  `db:"IS_NULLABLE"`
+		Default  *string `db:"COLUMN_DEFAULT"`
+		Extra    string  `db:"EXTRA"`
+	}
+	if err := db.Select(&columns, `SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, EXTRA
+FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME != 'schema_migrations' ORDER BY TABLE_NAME, ORDINAL_POSITION`, dbName); err != nil {
+		t.Fatalf("failed to get columns: %+v", err)
+	}
+	var indexes []struct {
+		Table     string `db:"TABLE_NAME"`
+		Index     string `db:"INDEX_NAME"`
+		NonUnique int    `db:"NON_UNIQUE"`
+		Column    string `db:"COLUMN_NAME"`
+	}
+	if err := db.Select(&indexes, `SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, COLUMN_NAME
+FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = ? AND TABLE_NAME != 'schema_migrations' ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX`, dbName); err != nil {
+		t.Fatalf("failed to get indexes: %+v", err)
+	}
+	var foreignKeys []struct {
+		Table      string `db:"TABLE_NAME"`
+		Constraint string `db:"CONSTRAINT_NAME"`
+		Referenced string `db:"REFERENCED_TABLE_NAME"`
+		DeleteRule string `db:"DELETE_RULE"`
+	}
+	if err := db.Select(&foreignKeys, `SELECT TABLE_NAME, CONSTRAINT_NAME, REFERENCED_TABLE_NAME, DELETE_RULE
+FROM information_schema.REFERENTIAL_CONSTRAINTS WHERE CONSTRAINT_SCHEMA = ? ORDER BY TABLE_NAME, CONSTRAINT_NAME`, dbName); err != nil {
+		t.Fatalf("failed to get foreign keys: %+v", err)
+	}
+
+	var schema []string
+	for _, c := range columns {
+		def := "NULL"
+		if c.Default != nil {
+			def = *c.Default
+		}
+		schema = append(schema, fmt.Sprintf("column %s.%s %s nullable=%s default=%s %s", c.Table, c.Column, c.Type, c.Nullable, def, c.Extra))
+	}
+	for _, i := range indexes {
+		schema = append(schema, fmt.Sprintf("index %s.%s non_unique=%d %s", i.Table, i.Index, i.NonUnique, i.Column))
+	}
+	for _, f := range foreignKeys {
+		schema = append(schema, fmt.Sprintf("foreign key %s.%s references %s on delete %s", f.Table, f.Constraint, f.Referenced, f.DeleteRule))
+	}
+	return schema
+}
+
+// newMigrateTestDatastore create datastore in an empty database
+func newMigrateTestDatastore(t *testing.T, dbName string) *mysql.MySQL {
+	t.Helper()
+
+	ds, err := mysql.New(testutils.CreateTestDatabase(t, dbName), nil)
+	if err != nil {
+		t.Fatalf("failed to create datastore: %+v", err)
+	}
+	t.Cleanup(func() { ds.Conn.Close() })
+	return ds
+}
+
+func TestMySQL_Migrate_Schema(t *testing.T) {
+	ctx := context.Background()
+
+	migrated := newMigrateTestDatastore(t, "myshoes_migrated")
+	if err := migrated.Migrate(ctx); err != nil {
+		t.Fatalf("failed to migrate: %+v", err)
+	}
+
+	// schema.sql is schema for reference, it must be same as result of migrations
+	applied := newMigrateTestDatastore(t, "myshoes_schema")
+	b, err := os.ReadFile("schema.sql")
+	if err != nil {
+		t.Fatalf("failed to read schema.sql: %+v", err)
+	}
+	queries := strings.Split(string(b), ";")
+	for _, query := range queries[:len(queries)-1] {
+		if _, err := applied.Conn.ExecContext(ctx, query); err != nil {
+			t.Fatalf("failed to exec schema.sql: %+v (query: %s)", err, query)
+		}
+	}
+
+	want := describeSchema(t, applied.Conn, "myshoes_schema")
+	got := describeSchema(t, migrated.Conn, "myshoes_migrated")
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("schema by migrations is not same as schema.sql, please update schema.sql (-schema.sql +migrations):\n%s", diff)
+	}
+}
+
+func TestMySQL_Migrate_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	ds := newMigrateTestDatastore(t, "myshoes_round_trip")
+
+	if err := ds.Migrate(ctx); err != nil {
+		t.Fatalf("failed to migrate: %+v", err)
+	}
+	want := describeSchema(t, ds.Conn, "myshoes_round_trip")
+
+	// rollback all migrations
+	if err := ds.MigrateDown(ctx, 1000); err != nil {
+		t.Fatalf("failed to rollback migrations: %+v", err)
+	}
+	if rest := describeSchema(t, ds.Conn, "myshoes_round_trip"); len(rest) != 0 {
+		t.Fatalf("all tables must be dropped by rollback, but remain:\n%s", strings.Join(rest, "\n"))
+	}
+
+	if err := ds.Migrate(ctx); err != nil {
+		t.Fatalf("failed to migrate again: %+v", err)
+	}
+	if diff := cmp.Diff(want, describeSchema(t, ds.Conn, "myshoes_round_trip")); diff != "" {
+		t.Errorf("schema must be same after rollback and migrate again (-first +second):\n%s", diff)
+	}
+}
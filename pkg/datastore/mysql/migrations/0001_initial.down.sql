@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS `jobs`;
+DROP TABLE IF EXISTS `runners_deleted`;
+DROP TABLE IF EXISTS `runners_running`;
+DROP TABLE IF EXISTS `runner_detail`;
+DROP TABLE IF EXISTS `runners`;
+DROP TABLE IF EXISTS `targets`;
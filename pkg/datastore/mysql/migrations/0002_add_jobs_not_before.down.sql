@@ -0,0 +1,2 @@
+ALTER TABLE `jobs` DROP KEY `idx_job_not_before`;
+ALTER TABLE `jobs` DROP COLUMN `not_before`;
@@ -0,0 +1,2 @@
+ALTER TABLE `jobs` ADD COLUMN `not_before` TIMESTAMP NULL AFTER `target_id`;
+ALTER TABLE `jobs` ADD KEY `idx_job_not_before` (`not_before`);
@@ -0,0 +1,4 @@
+ALTER TABLE `jobs` DROP KEY `idx_job_external_ref`;
+ALTER TABLE `jobs` DROP COLUMN `external_ref`;
+ALTER TABLE `targets` DROP KEY `idx_target_external_ref`;
+ALTER TABLE `targets` DROP COLUMN `external_ref`;
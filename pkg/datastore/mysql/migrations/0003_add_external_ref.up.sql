@@ -0,0 +1,4 @@
+ALTER TABLE `targets` ADD COLUMN `external_ref` VARCHAR(255) AFTER `status_description`;
+ALTER TABLE `targets` ADD KEY `idx_target_external_ref` (`external_ref`);
+ALTER TABLE `jobs` ADD COLUMN `external_ref` VARCHAR(255) AFTER `not_before`;
+ALTER TABLE `jobs` ADD KEY `idx_job_external_ref` (`external_ref`);
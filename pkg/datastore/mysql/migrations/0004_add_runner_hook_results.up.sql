@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `runner_hook_results` (
+    `runner_id` VARCHAR(36) NOT NULL,
+    `event` VARCHAR(255) NOT NULL,
+    `success` BOOLEAN NOT NULL,
+    `blocking` BOOLEAN NOT NULL,
+    `status_code` INT NOT NULL DEFAULT 0,
+    `message` TEXT NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    KEY `idx_runner_hook_results_runner_id` (`runner_id`)
+);
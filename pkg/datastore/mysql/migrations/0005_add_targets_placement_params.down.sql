@@ -0,0 +1 @@
+ALTER TABLE `targets` DROP COLUMN `placement_params`;
@@ -0,0 +1 @@
+ALTER TABLE `targets` ADD COLUMN `placement_params` TEXT AFTER `external_ref`;
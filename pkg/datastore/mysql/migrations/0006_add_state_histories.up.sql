@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `state_histories` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `resource_type` VARCHAR(255) NOT NULL,
+    `resource_id` VARCHAR(36) NOT NULL,
+    `status` VARCHAR(255) NOT NULL,
+    `reason` TEXT NOT NULL,
+    `created_at` TIMESTAMP(6) NOT NULL DEFAULT current_timestamp(6),
+    KEY `idx_state_histories_resource_id` (`resource_id`),
+    KEY `idx_state_histories_created_at` (`created_at`)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE `jobs` DROP KEY `idx_job_dedup_key`;
+ALTER TABLE `jobs` DROP COLUMN `dedup_key`;
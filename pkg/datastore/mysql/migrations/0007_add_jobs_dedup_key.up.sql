@@ -0,0 +1,2 @@
+ALTER TABLE `jobs` ADD COLUMN `dedup_key` VARCHAR(255) AFTER `external_ref`;
+ALTER TABLE `jobs` ADD UNIQUE KEY `idx_job_dedup_key` (`dedup_key`);
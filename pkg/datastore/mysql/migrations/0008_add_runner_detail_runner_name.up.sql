@@ -0,0 +1 @@
+ALTER TABLE `runner_detail` ADD COLUMN `runner_name` VARCHAR(255) AFTER `request_webhook`;
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS `locks` (
+    `name` VARCHAR(255) NOT NULL PRIMARY KEY,
+    `owner` VARCHAR(255) NOT NULL,
+    `expired_at` TIMESTAMP(6) NOT NULL
+);
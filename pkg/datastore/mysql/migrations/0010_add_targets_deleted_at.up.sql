@@ -0,0 +1,2 @@
+ALTER TABLE `targets` ADD COLUMN `deleted_at` TIMESTAMP NULL AFTER `placement_params`;
+UPDATE `targets` SET `deleted_at` = `updated_at`, `updated_at` = `updated_at` WHERE `status` = 'deleted';
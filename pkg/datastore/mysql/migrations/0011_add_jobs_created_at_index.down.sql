@@ -0,0 +1 @@
+ALTER TABLE `jobs` DROP KEY `idx_job_created_at`;
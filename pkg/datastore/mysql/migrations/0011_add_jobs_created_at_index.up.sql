@@ -0,0 +1 @@
+ALTER TABLE `jobs` ADD KEY `idx_job_created_at` (`created_at`);
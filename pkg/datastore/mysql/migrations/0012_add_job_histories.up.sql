@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `job_histories` (
+    `job_id` VARCHAR(36) NOT NULL PRIMARY KEY,
+    `target_id` VARCHAR(36) NOT NULL,
+    `github_job_id` BIGINT,
+    `received_at` TIMESTAMP(6) NOT NULL DEFAULT current_timestamp(6),
+    `runner_created_at` TIMESTAMP(6) NULL,
+    `runner_registered_at` TIMESTAMP(6) NULL,
+    `started_at` TIMESTAMP(6) NULL,
+    `completed_at` TIMESTAMP(6) NULL,
+    KEY `idx_job_histories_target_id` (`target_id`),
+    KEY `idx_job_histories_github_job_id` (`github_job_id`),
+    KEY `idx_job_histories_received_at` (`received_at`)
+);
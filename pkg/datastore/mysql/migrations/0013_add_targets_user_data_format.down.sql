@@ -0,0 +1 @@
+ALTER TABLE `targets` DROP COLUMN `user_data_format`;
@@ -0,0 +1 @@
+ALTER TABLE `runner_detail` ADD COLUMN `version` BIGINT NOT NULL DEFAULT 0 AFTER `runner_name`;
@@ -0,0 +1 @@
+ALTER TABLE `targets` ADD COLUMN `runner_labels` TEXT AFTER `runner_registration`;
@@ -0,0 +1 @@
+ALTER TABLE `targets` ADD COLUMN `quiet_hours` TEXT AFTER `runner_labels`;
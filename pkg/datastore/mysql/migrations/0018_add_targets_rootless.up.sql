@@ -0,0 +1 @@
+ALTER TABLE `targets` ADD COLUMN `rootless` VARCHAR(255) NOT NULL DEFAULT '' AFTER `quiet_hours`;
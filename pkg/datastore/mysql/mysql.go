@@ -1,42 +1,109 @@
 package mysql
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
 )
 
 // MySQL is implement datastore in MySQL
 type MySQL struct {
-	Conn *sqlx.DB
+	Conn     *sqlx.DB
+	ReadConn *sqlx.DB // optional, read replica
+	// CompatMode use portable SQL for MySQL compatible databases (e.g. TiDB, Aurora MySQL)
+	CompatMode bool
 
 	notifyEnqueueCh chan<- struct{}
+
+	lockOwner string
+	lockMu    sync.Mutex
+	lost      chan struct{}
 }
 
 // New create mysql connection
 func New(dsn string, notifyEnqueueCh chan<- struct{}) (*MySQL, error) {
-	u, err := getMySQLURL(dsn)
+	conn, err := open(dsn)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get MySQL URL: %w", err)
+		return nil, err
 	}
 
-	conn, err := sqlx.Open("mysql", u)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create mysql connection: %w", err)
-	}
+	poolStats.set(conn.DB)
 
 	return &MySQL{
 		Conn:            conn,
+		CompatMode:      config.Config.MySQLCompatMode,
 		notifyEnqueueCh: notifyEnqueueCh,
+		lockOwner:       newLockOwner(),
 	}, nil
 }
 
-func getMySQLURL(dsn string) (string, error) {
+// ConnectReadReplica connect to read replica. read-only queries are sent to replica if ctx is marked by datastore.WithReadReplica
+func (m *MySQL) ConnectReadReplica(dsn string) error {
+	conn, err := open(dsn)
+	if err != nil {
+		return err
+	}
+	m.ReadConn = conn
+	return nil
+}
+
+// reader return connection for read-only query
+func (m *MySQL) reader(ctx context.Context) *sqlx.DB {
+	if m.ReadConn != nil && datastore.CanUseReadReplica(ctx) {
+		return m.ReadConn
+	}
+	return m.Conn
+}
+
+// open create connection pool. connection is authenticated by IAM token if MYSQL_AUTH_MODE is aws_iam
+func open(dsn string) (*sqlx.DB, error) {
+	c, err := getMySQLConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MySQL config: %w", err)
+	}
+
+	var conn *sqlx.DB
+	if config.Config.MySQLAuthMode == config.MySQLAuthModeAWSIAM {
+		conn = sqlx.NewDb(sql.OpenDB(newIAMConnector(c, config.Config.MySQLAWSRegion)), "mysql")
+	} else {
+		conn, err = sqlx.Open("mysql", c.FormatDSN())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create mysql connection: %w", err)
+		}
+	}
+	setPoolParams(conn)
+	return conn, nil
+}
+
+// setPoolParams set parameters of connection pool from config
+func setPoolParams(conn *sqlx.DB) {
+	conn.SetMaxOpenConns(config.Config.DBMaxOpenConns)
+	conn.SetMaxIdleConns(config.Config.DBMaxIdleConns)
+	conn.SetConnMaxLifetime(config.Config.DBConnMaxLifetime)
+}
+
+// newLockOwner generate a value that identify this process as owner of lock
+func newLockOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%s", hostname, uuid.NewV4())
+}
+
+func getMySQLConfig(dsn string) (*mysql.Config, error) {
 	c, err := mysql.ParseDSN(dsn)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse DSN: %w", err)
+		return nil, fmt.Errorf("failed to parse DSN: %w", err)
 	}
 
 	c.Loc = time.UTC
@@ -49,5 +116,13 @@ func getMySQLURL(dsn string) (string, error) {
 
 	c.InterpolateParams = true
 
-	return c.FormatDSN(), nil
+	tlsConfig, err := registerTLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to register TLS config: %w", err)
+	}
+	if tlsConfig != "" {
+		c.TLSConfig = tlsConfig
+	}
+
+	return c, nil
 }
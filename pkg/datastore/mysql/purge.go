@@ -0,0 +1,128 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// PurgeScope delete targets in scope and all of jobs, runners and histories of them in a transaction
+func (m *MySQL) PurgeScope(ctx context.Context, scope string) (_ *datastore.PurgeResult, err error) {
+	defer observe("PurgeScope", time.Now(), &err)
+
+	tx, err := m.Conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	result, err := purgeScope(ctx, tx, scope)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to execute COMMIT: %w", err)
+	}
+	return result, nil
+}
+
+func purgeScope(ctx context.Context, tx *sqlx.Tx, scope string) (*datastore.PurgeResult, error) {
+	condition, args := datastore.ScopeCondition("scope", scope)
+	var targetIDs []string
+	if err := tx.SelectContext(ctx, &targetIDs, `SELECT uuid FROM targets WHERE `+condition+` FOR UPDATE`, args...); err != nil {
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+	if len(targetIDs) == 0 {
+		return nil, datastore.ErrNotFound
+	}
+
+	runnerIDs, err := selectIn(ctx, tx, `SELECT runner_id FROM runner_detail WHERE target_id IN (?)`, targetIDs)
+	if err != nil {
+		return nil, err
+	}
+	jobIDs, err := selectIn(ctx, tx, `SELECT uuid FROM jobs WHERE target_id IN (?) UNION SELECT job_id FROM job_histories WHERE target_id IN (?)`, targetIDs, targetIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var result datastore.PurgeResult
+	for _, d := range []struct {
+		query   string
+		ids     []string
+		counter *int64
+	}{
+		{`DELETE FROM state_histories WHERE resource_id IN (?)`, append(append([]string{}, runnerIDs...), jobIDs...), &result.Histories},
+		{`DELETE FROM job_histories WHERE target_id IN (?)`, targetIDs, &result.Histories},
+		{`DELETE FROM jobs WHERE target_id IN (?)`, targetIDs, &result.Jobs},
+		{`DELETE FROM runner_hook_results WHERE runner_id IN (?)`, runnerIDs, nil},
+		{`DELETE FROM runners_running WHERE runner_id IN (?)`, runnerIDs, nil},
+		{`DELETE FROM runners_deleted WHERE runner_id IN (?)`, runnerIDs, nil},
+		{`DELETE FROM runner_detail WHERE runner_id IN (?)`, runnerIDs, nil},
+		{`DELETE FROM runners WHERE uuid IN (?)`, runnerIDs, &result.Runners},
+		{`DELETE FROM targets WHERE uuid IN (?)`, targetIDs, &result.Targets},
+	} {
+		deleted, err := execIn(ctx, tx, d.query, d.ids)
+		if err != nil {
+			return nil, err
+		}
+		if d.counter != nil {
+			*d.counter += deleted
+		}
+	}
+
+	return &result, nil
+}
+
+// selectIn execute SELECT query that has IN (?) clauses, all of idsList must not be empty
+func selectIn(ctx context.Context, tx *sqlx.Tx, query string, idsList ...[]string) ([]string, error) {
+	args := make([]interface{}, 0, len(idsList))
+	for _, ids := range idsList {
+		args = append(args, ids)
+	}
+	q, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create IN query: %w", err)
+	}
+
+	var result []string
+	if err := tx.SelectContext(ctx, &result, tx.Rebind(q), args...); err != nil {
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+	return result, nil
+}
+
+// uuidsToStrings convert ids to strings for IN (?) clause
+func uuidsToStrings(ids []uuid.UUID) []string {
+	s := make([]string, 0, len(ids))
+	for _, id := range ids {
+		s = append(s, id.String())
+	}
+	return s
+}
+
+// execIn execute query that has a IN (?) clause, it is skipped if ids is empty
+func execIn(ctx context.Context, tx *sqlx.Tx, query string, ids []string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	q, args, err := sqlx.In(query, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create IN query: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, tx.Rebind(q), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute DELETE query: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return deleted, nil
+}
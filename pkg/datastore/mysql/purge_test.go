@@ -0,0 +1,78 @@
+package mysql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/internal/testutils"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+func TestMySQL_PurgeScope(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+
+	ctx := context.Background()
+	otherTargetID := uuid.NewV4()
+	for _, target := range []datastore.Target{
+		{UUID: testTargetID, Scope: testScopeRepo},
+		{UUID: otherTargetID, Scope: "octocat-other"},
+	} {
+		target.GitHubToken = testGitHubToken
+		target.TokenExpiredAt = testTime
+		target.ResourceType = datastore.ResourceTypeNano
+		if err := testDatastore.CreateTarget(ctx, target); err != nil {
+			t.Fatalf("failed to create target: %+v", err)
+		}
+	}
+	if err := testDatastore.CreateRunner(ctx, datastore.Runner{
+		UUID:           testRunnerID,
+		ShoesType:      "shoes-test",
+		TargetID:       testTargetID,
+		CloudID:        "mycloud-uuid",
+		ResourceType:   datastore.ResourceTypeNano,
+		RepositoryURL:  "https://github.com/octocat/Hello-World",
+		RequestWebhook: "{}",
+	}); err != nil {
+		t.Fatalf("failed to create runner: %+v", err)
+	}
+	if _, err := testDatastore.EnqueueJob(ctx, datastore.Job{
+		UUID:           testJobID,
+		TargetID:       testTargetID,
+		Repository:     testScopeRepo,
+		CheckEventJSON: "{}",
+	}); err != nil {
+		t.Fatalf("failed to enqueue job: %+v", err)
+	}
+	if err := testDatastore.CreateStateHistory(ctx, datastore.StateHistory{
+		ResourceType: datastore.HistoryResourceRunner,
+		ResourceID:   testRunnerID,
+		Status:       datastore.HistoryStatusCreated,
+	}); err != nil {
+		t.Fatalf("failed to create history: %+v", err)
+	}
+
+	// "octocat" must not match "octocat-other"
+	got, err := testDatastore.PurgeScope(ctx, testScopeOrg)
+	if err != nil {
+		t.Fatalf("failed to purge scope: %+v", err)
+	}
+	want := &datastore.PurgeResult{Targets: 1, Runners: 1, Jobs: 1, Histories: 1}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := testDatastore.GetTarget(ctx, testTargetID); !errors.Is(err, datastore.ErrNotFound) {
+		t.Errorf("target must be purged, but got err: %+v", err)
+	}
+	if _, err := testDatastore.GetTarget(ctx, otherTargetID); err != nil {
+		t.Errorf("target out of scope must not be purged: %+v", err)
+	}
+	if _, err := testDatastore.PurgeScope(ctx, testScopeOrg); !errors.Is(err, datastore.ErrNotFound) {
+		t.Errorf("PurgeScope must return ErrNotFound if no target in scope, but got: %+v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package mysql_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/internal/testutils"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+func TestMySQL_RenameScope(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+
+	ctx := context.Background()
+	otherTargetID := uuid.NewV4()
+	gheTargetID := uuid.NewV4()
+	for _, target := range []datastore.Target{
+		{UUID: testTargetID, Scope: testScopeRepo},
+		{UUID: otherTargetID, Scope: "octocat-other"},
+		{UUID: gheTargetID, Scope: testScopeRepo, GHEDomain: sql.NullString{String: "https://github.example.com", Valid: true}},
+	} {
+		target.GitHubToken = testGitHubToken
+		target.TokenExpiredAt = testTime
+		target.ResourceType = datastore.ResourceTypeNano
+		if err := testDatastore.CreateTarget(ctx, target); err != nil {
+			t.Fatalf("failed to create target: %+v", err)
+		}
+	}
+	if _, err := testDatastore.EnqueueJob(ctx, datastore.Job{
+		UUID:           testJobID,
+		TargetID:       testTargetID,
+		Repository:     testScopeRepo,
+		CheckEventJSON: "{}",
+	}); err != nil {
+		t.Fatalf("failed to enqueue job: %+v", err)
+	}
+
+	// "octocat" must not match "octocat-other"
+	got, err := testDatastore.RenameScope(ctx, "", testScopeOrg, "octo-org")
+	if err != nil {
+		t.Fatalf("failed to rename scope: %+v", err)
+	}
+	if got != 1 {
+		t.Errorf("must rename 1 target, but got %d", got)
+	}
+
+	target, err := testDatastore.GetTarget(ctx, testTargetID)
+	if err != nil {
+		t.Fatalf("failed to get target: %+v", err)
+	}
+	if target.Scope != "octo-org/hello-world" {
+		t.Errorf("scope of target must be renamed, but got %s", target.Scope)
+	}
+	other, err := testDatastore.GetTarget(ctx, otherTargetID)
+	if err != nil {
+		t.Fatalf("failed to get target: %+v", err)
+	}
+	if other.Scope != "octocat-other" {
+		t.Errorf("target out of scope must not be renamed, but got %s", other.Scope)
+	}
+	gheTarget, err := testDatastore.GetTarget(ctx, gheTargetID)
+	if err != nil {
+		t.Fatalf("failed to get target: %+v", err)
+	}
+	if gheTarget.Scope != testScopeRepo {
+		t.Errorf("target in other GitHub host must not be renamed, but got %s", gheTarget.Scope)
+	}
+	jobs, err := testDatastore.ListJobs(ctx, datastore.ListOption{})
+	if err != nil {
+		t.Fatalf("failed to list jobs: %+v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Repository != "octo-org/hello-world" {
+		t.Errorf("repository of job must be renamed, but got %+v", jobs)
+	}
+
+	if _, err := testDatastore.RenameScope(ctx, "", testScopeOrg, "octo-org"); !errors.Is(err, datastore.ErrNotFound) {
+		t.Errorf("RenameScope must return ErrNotFound if no target in scope, but got: %+v", err)
+	}
+	if _, err := testDatastore.RenameScope(ctx, "", "octocat-other", "octo-org"); !errors.Is(err, datastore.ErrConflict) {
+		t.Errorf("RenameScope must return ErrConflict if target exists in new scope, but got: %+v", err)
+	}
+}
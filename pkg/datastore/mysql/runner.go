@@ -5,14 +5,18 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	uuid "github.com/satori/go.uuid"
 	"github.com/whywaita/myshoes/pkg/datastore"
 )
 
 // CreateRunner add a runner
-func (m *MySQL) CreateRunner(ctx context.Context, runner datastore.Runner) error {
+func (m *MySQL) CreateRunner(ctx context.Context, runner datastore.Runner) (err error) {
+	defer observe("CreateRunner", time.Now(), &err)
+
 	tx := m.Conn.MustBegin()
 
 	queryRunner := `INSERT INTO runners(uuid) VALUES (?)`
@@ -21,8 +25,8 @@ func (m *MySQL) CreateRunner(ctx context.Context, runner datastore.Runner) error
 		return fmt.Errorf("failed to execute INSERT query runners: %w", err)
 	}
 
-	queryDetail := `INSERT INTO runner_detail(runner_id, shoes_type, ip_address, target_id, cloud_id, resource_type, runner_user, repository_url, request_webhook, provider_url) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	if _, err := tx.ExecContext(ctx, queryDetail, runner.UUID.String(), runner.ShoesType, runner.IPAddress, runner.TargetID.String(), runner.CloudID, runner.ResourceType, runner.RunnerUser, runner.RepositoryURL, runner.RequestWebhook, runner.ProviderURL); err != nil {
+	queryDetail := `INSERT INTO runner_detail(runner_id, shoes_type, ip_address, target_id, cloud_id, resource_type, runner_user, repository_url, request_webhook, provider_url, runner_name) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if _, err := tx.ExecContext(ctx, queryDetail, runner.UUID.String(), runner.ShoesType, runner.IPAddress, runner.TargetID.String(), runner.CloudID, runner.ResourceType, runner.RunnerUser, runner.RepositoryURL, runner.RequestWebhook, runner.ProviderURL, runner.RunnerName); err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to execute INSERT query runner_detail: %w", err)
 	}
@@ -40,12 +44,15 @@ func (m *MySQL) CreateRunner(ctx context.Context, runner datastore.Runner) error
 	return nil
 }
 
-// ListRunners get a not deleted runners
-func (m *MySQL) ListRunners(ctx context.Context) ([]datastore.Runner, error) {
+// ListRunners get a page of not deleted runners
+func (m *MySQL) ListRunners(ctx context.Context, opt datastore.ListOption) (_ []datastore.Runner, err error) {
+	defer observe("ListRunners", time.Now(), &err)
+
 	var runners []datastore.Runner
-	query := `SELECT runner.runner_id, detail.shoes_type, detail.ip_address, detail.target_id, detail.cloud_id, detail.created_at, detail.updated_at, detail.resource_type, detail.repository_url, detail.request_webhook, detail.runner_user, detail.provider_url
+	query := `SELECT runner.runner_id, detail.shoes_type, detail.ip_address, detail.target_id, detail.cloud_id, detail.created_at, detail.updated_at, detail.resource_type, detail.repository_url, detail.request_webhook, detail.runner_user, detail.provider_url, detail.runner_name, detail.version
  FROM runners_running AS runner JOIN runner_detail AS detail ON runner.runner_id = detail.runner_id`
-	err := m.Conn.SelectContext(ctx, &runners, query)
+	clause, args := opt.Clause("runner.runner_id")
+	err = m.reader(ctx).SelectContext(ctx, &runners, query+clause, args...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, datastore.ErrNotFound
@@ -58,11 +65,13 @@ func (m *MySQL) ListRunners(ctx context.Context) ([]datastore.Runner, error) {
 }
 
 // ListRunnersByTargetID get a not deleted runners that has target_id
-func (m *MySQL) ListRunnersByTargetID(ctx context.Context, targetID uuid.UUID) ([]datastore.Runner, error) {
+func (m *MySQL) ListRunnersByTargetID(ctx context.Context, targetID uuid.UUID) (_ []datastore.Runner, err error) {
+	defer observe("ListRunnersByTargetID", time.Now(), &err)
+
 	var runners []datastore.Runner
-	query := `SELECT runner.runner_id, detail.shoes_type, detail.ip_address, detail.target_id, detail.cloud_id, detail.created_at, detail.updated_at, detail.resource_type, detail.repository_url, detail.request_webhook, detail.runner_user, detail.provider_url
+	query := `SELECT runner.runner_id, detail.shoes_type, detail.ip_address, detail.target_id, detail.cloud_id, detail.created_at, detail.updated_at, detail.resource_type, detail.repository_url, detail.request_webhook, detail.runner_user, detail.provider_url, detail.runner_name, detail.version
  FROM runners_running AS runner JOIN runner_detail AS detail ON runner.runner_id = detail.runner_id WHERE detail.target_id = ?`
-	err := m.Conn.SelectContext(ctx, &runners, query, targetID)
+	err = m.reader(ctx).SelectContext(ctx, &runners, query, targetID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, datastore.ErrNotFound
@@ -75,11 +84,13 @@ func (m *MySQL) ListRunnersByTargetID(ctx context.Context, targetID uuid.UUID) (
 }
 
 // GetRunner get a runner
-func (m *MySQL) GetRunner(ctx context.Context, id uuid.UUID) (*datastore.Runner, error) {
+func (m *MySQL) GetRunner(ctx context.Context, id uuid.UUID) (_ *datastore.Runner, err error) {
+	defer observe("GetRunner", time.Now(), &err)
+
 	var r datastore.Runner
 
-	query := `SELECT runner_id, shoes_type, ip_address, target_id, cloud_id, created_at, updated_at, resource_type, repository_url, request_webhook, runner_user, provider_url FROM runner_detail WHERE runner_id = ?`
-	if err := m.Conn.GetContext(ctx, &r, query, id.String()); err != nil {
+	query := `SELECT runner_id, shoes_type, ip_address, target_id, cloud_id, created_at, updated_at, resource_type, repository_url, request_webhook, runner_user, provider_url, runner_name, version FROM runner_detail WHERE runner_id = ?`
+	if err := m.reader(ctx).GetContext(ctx, &r, query, id.String()); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, datastore.ErrNotFound
 		}
@@ -90,15 +101,106 @@ func (m *MySQL) GetRunner(ctx context.Context, id uuid.UUID) (*datastore.Runner,
 	return &r, nil
 }
 
+// UpdateRunnerWithVersion update a runner only if version is not changed
+func (m *MySQL) UpdateRunnerWithVersion(ctx context.Context, runner datastore.Runner) (err error) {
+	defer observe("UpdateRunnerWithVersion", time.Now(), &err)
+
+	query := `UPDATE runner_detail SET ip_address = ?, cloud_id = ?, runner_name = ?, version = version + 1
+ WHERE runner_id = ? AND version = ? AND runner_id IN (SELECT runner_id FROM runners_running)`
+	result, err := m.Conn.ExecContext(ctx, query, runner.IPAddress, runner.CloudID, runner.RunnerName, runner.UUID.String(), runner.Version)
+	if err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if updated == 0 {
+		return datastore.ErrConflict
+	}
+
+	return nil
+}
+
+// MarkRunnerTokenIssued set token_issued_at of a runner only if it is not set
+func (m *MySQL) MarkRunnerTokenIssued(ctx context.Context, id uuid.UUID, issuedAt time.Time) (err error) {
+	defer observe("MarkRunnerTokenIssued", time.Now(), &err)
+
+	query := `UPDATE runner_detail SET token_issued_at = ?
+ WHERE runner_id = ? AND token_issued_at IS NULL AND runner_id IN (SELECT runner_id FROM runners_running)`
+	result, err := m.Conn.ExecContext(ctx, query, issuedAt.UTC(), id.String())
+	if err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if updated == 0 {
+		return datastore.ErrConflict
+	}
+
+	return nil
+}
+
 // DeleteRunner delete a runner
-func (m *MySQL) DeleteRunner(ctx context.Context, id uuid.UUID, deletedAt time.Time, reason datastore.RunnerStatus) error {
-	tx := m.Conn.MustBegin()
+func (m *MySQL) DeleteRunner(ctx context.Context, id uuid.UUID, deletedAt time.Time, reason datastore.RunnerStatus) (err error) {
+	defer observe("DeleteRunner", time.Now(), &err)
+
+	return m.deleteRunner(ctx, id, nil, reason)
+}
+
+// DeleteRunnerWithVersion delete a runner only if version is not changed
+func (m *MySQL) DeleteRunnerWithVersion(ctx context.Context, id uuid.UUID, version int64, deletedAt time.Time, reason datastore.RunnerStatus) (err error) {
+	defer observe("DeleteRunnerWithVersion", time.Now(), &err)
+
+	return m.deleteRunner(ctx, id, &version, reason)
+}
+
+// deleteRunner move a runner to deleted and increment version of it.
+// if version is not nil, it is compare-and-swap and fail if runner is updated or deleted by other process
+func (m *MySQL) deleteRunner(ctx context.Context, id uuid.UUID, version *int64, reason datastore.RunnerStatus) error {
+	tx, err := m.Conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	queryVersion := `UPDATE runner_detail SET version = version + 1 WHERE runner_id = ?`
+	args := []interface{}{id.String()}
+	if version != nil {
+		queryVersion += ` AND version = ?`
+		args = append(args, *version)
+	}
+	result, err := tx.ExecContext(ctx, queryVersion, args...)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if version != nil && updated == 0 {
+		tx.Rollback()
+		return datastore.ErrConflict
+	}
 
 	queryDelete := `DELETE FROM runners_running WHERE runner_id = ?`
-	if _, err := tx.ExecContext(ctx, queryDelete, id.String()); err != nil {
+	result, err = tx.ExecContext(ctx, queryDelete, id.String())
+	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to execute DELETE query: %w", err)
 	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if version != nil && deleted == 0 {
+		tx.Rollback()
+		return datastore.ErrNotFound
+	}
 
 	queryInsert := `INSERT INTO runners_deleted(runner_id, reason) VALUES (?, ?)`
 	if _, err := tx.ExecContext(ctx, queryInsert, id.String(), reason); err != nil {
@@ -113,3 +215,187 @@ func (m *MySQL) DeleteRunner(ctx context.Context, id uuid.UUID, deletedAt time.T
 
 	return nil
 }
+
+// DeleteRunnersBulk delete runners by a statement per table only if version is not changed, runners that already deleted are skipped
+func (m *MySQL) DeleteRunnersBulk(ctx context.Context, runners []datastore.Runner, deletedAt time.Time, reason datastore.RunnerStatus) (_ []uuid.UUID, err error) {
+	defer observe("DeleteRunnersBulk", time.Now(), &err)
+
+	if len(runners) == 0 {
+		return nil, nil
+	}
+
+	tx, err := m.Conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(runners))
+	for _, r := range runners {
+		ids = append(ids, r.UUID)
+	}
+	querySelect, args, err := sqlx.In(`SELECT d.runner_id, d.version FROM runner_detail d JOIN runners_running r ON d.runner_id = r.runner_id WHERE d.runner_id IN (?) FOR UPDATE`, uuidsToStrings(ids))
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to create IN query: %w", err)
+	}
+	var running []runnerVersion
+	if err := tx.SelectContext(ctx, &running, tx.Rebind(querySelect), args...); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+	matched, conflicted := splitByVersion(runners, running)
+	if len(matched) == 0 {
+		tx.Rollback()
+		return nil, conflictedRunnersError(conflicted)
+	}
+
+	queryVersion := `UPDATE runner_detail SET version = version + 1 WHERE (runner_id, version) IN (` + strings.TrimSuffix(strings.Repeat("(?, ?), ", len(matched)), ", ") + `)`
+	args = make([]interface{}, 0, len(matched)*2)
+	matchedIDs := make([]string, 0, len(matched))
+	for _, r := range matched {
+		args = append(args, r.UUID.String(), r.Version)
+		matchedIDs = append(matchedIDs, r.UUID.String())
+	}
+	result, err := tx.ExecContext(ctx, queryVersion, args...)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err != nil || updated != int64(len(matched)) {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to update version of %d runners (updated: %d): %w", len(matched), updated, datastore.ErrConflict)
+	}
+	if _, err := execIn(ctx, tx, `DELETE FROM runners_running WHERE runner_id IN (?)`, matchedIDs); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	queryInsert, args, err := sqlx.In(`INSERT INTO runners_deleted(runner_id, reason) SELECT runner_id, ? FROM runner_detail WHERE runner_id IN (?)`, reason, matchedIDs)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to create IN query: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, tx.Rebind(queryInsert), args...); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to execute INSERT query: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to execute COMMIT: %w", err)
+	}
+
+	deleted := make([]uuid.UUID, 0, len(matched))
+	for _, r := range matched {
+		deleted = append(deleted, r.UUID)
+	}
+	return deleted, conflictedRunnersError(conflicted)
+}
+
+// runnerVersion is version of a running runner in datastore
+type runnerVersion struct {
+	ID      string `db:"runner_id"`
+	Version int64  `db:"version"`
+}
+
+// splitByVersion split runners into runners that version is not changed from running and runners that are updated by other process.
+// runners that are not running (already deleted) are in neither
+func splitByVersion(runners []datastore.Runner, running []runnerVersion) ([]datastore.Runner, []datastore.Runner) {
+	versions := make(map[string]int64, len(running))
+	for _, r := range running {
+		versions[r.ID] = r.Version
+	}
+
+	var matched, conflicted []datastore.Runner
+	for _, r := range runners {
+		v, ok := versions[r.UUID.String()]
+		switch {
+		case !ok:
+		case v == r.Version:
+			matched = append(matched, r)
+		default:
+			conflicted = append(conflicted, r)
+		}
+	}
+	return matched, conflicted
+}
+
+// conflictedRunnersError return error that wraps datastore.ErrConflict, nil if no runner is conflicted
+func conflictedRunnersError(conflicted []datastore.Runner) error {
+	if len(conflicted) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(conflicted))
+	for _, r := range conflicted {
+		ids = append(ids, fmt.Sprintf("%s (version: %d)", r.UUID, r.Version))
+	}
+	return fmt.Errorf("runners are updated by other process (runner uuid: %s): %w", strings.Join(ids, ", "), datastore.ErrConflict)
+}
+
+// PurgeDeletedRunners delete history of runners that deleted before `before`
+func (m *MySQL) PurgeDeletedRunners(ctx context.Context, before time.Time, limit int) (_ int64, err error) {
+	defer observe("PurgeDeletedRunners", time.Now(), &err)
+
+	var ids []string
+	querySelect := `SELECT runner_id FROM runners_deleted WHERE created_at < ? ORDER BY created_at LIMIT ?`
+	if err := m.Conn.SelectContext(ctx, &ids, querySelect, before.UTC(), limit); err != nil {
+		return 0, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	tx, err := m.Conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// runners_running and runners_deleted will be deleted by ON DELETE CASCADE
+	for _, query := range []string{
+		`DELETE FROM runner_hook_results WHERE runner_id IN (?)`,
+		`DELETE FROM runner_detail WHERE runner_id IN (?)`,
+		`DELETE FROM runners WHERE uuid IN (?)`,
+	} {
+		q, args, err := sqlx.In(query, ids)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to create IN query: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, tx.Rebind(q), args...); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to execute DELETE query: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to execute COMMIT: %w", err)
+	}
+
+	return int64(len(ids)), nil
+}
+
+// CreateRunnerHookResult record a result of runner hook
+func (m *MySQL) CreateRunnerHookResult(ctx context.Context, result datastore.RunnerHookResult) (err error) {
+	defer observe("CreateRunnerHookResult", time.Now(), &err)
+
+	query := `INSERT INTO runner_hook_results(runner_id, event, success, blocking, status_code, message) VALUES (?, ?, ?, ?, ?, ?)`
+	if _, err := m.Conn.ExecContext(ctx, query, result.RunnerID.String(), result.Event, result.Success, result.Blocking, result.StatusCode, result.Message); err != nil {
+		return fmt.Errorf("failed to execute INSERT query: %w", err)
+	}
+
+	return nil
+}
+
+// ListRunnerHookResults get results of runner hook
+func (m *MySQL) ListRunnerHookResults(ctx context.Context, runnerID uuid.UUID) (_ []datastore.RunnerHookResult, err error) {
+	defer observe("ListRunnerHookResults", time.Now(), &err)
+
+	var results []datastore.RunnerHookResult
+	query := `SELECT runner_id, event, success, blocking, status_code, message, created_at FROM runner_hook_results WHERE runner_id = ? ORDER BY created_at`
+	if err := m.reader(ctx).SelectContext(ctx, &results, query, runnerID.String()); err != nil {
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	return results, nil
+}
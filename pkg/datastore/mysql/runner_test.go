@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
 	"testing"
 	"time"
 
@@ -174,7 +175,7 @@ func TestMySQL_ListRunners(t *testing.T) {
 			}
 		}
 
-		got, err := testDatastore.ListRunners(context.Background())
+		got, err := testDatastore.ListRunners(context.Background(), datastore.ListOption{})
 		if err != nil {
 			t.Fatalf("failed to get runners: %+v", err)
 		}
@@ -230,7 +231,7 @@ func TestMySQL_ListRunnersNotReturnDeleted(t *testing.T) {
 		t.Fatalf("failed to delete runner: %+v", err)
 	}
 
-	got, err := testDatastore.ListRunners(context.Background())
+	got, err := testDatastore.ListRunners(context.Background(), datastore.ListOption{})
 	if err != nil {
 		t.Fatalf("failed to get runners: %+v", err)
 	}
@@ -399,6 +400,246 @@ func TestMySQL_DeleteRunner(t *testing.T) {
 	}
 }
 
+func TestMySQL_DeleteRunnerWithVersion(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+	testDB, _ := testutils.GetTestDB()
+
+	if err := testDatastore.CreateTarget(context.Background(), datastore.Target{
+		UUID:           testTargetID,
+		Scope:          testScopeRepo,
+		GitHubToken:    testGitHubToken,
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	if err := testDatastore.CreateRunner(context.Background(), datastore.Runner{
+		UUID:           testRunnerID,
+		ShoesType:      "shoes-test",
+		TargetID:       testTargetID,
+		CloudID:        "mycloud-uuid",
+		ResourceType:   datastore.ResourceTypeNano,
+		RepositoryURL:  "https://github.com/octocat/Hello-World",
+		RequestWebhook: "{}",
+	}); err != nil {
+		t.Fatalf("failed to create runner: %+v", err)
+	}
+
+	tests := []struct {
+		version int64
+		wantErr error
+	}{
+		{
+			// stale version
+			version: 1,
+			wantErr: datastore.ErrConflict,
+		},
+		{
+			version: 0,
+			wantErr: nil,
+		},
+		{
+			// already deleted by other process
+			version: 1,
+			wantErr: datastore.ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		err := testDatastore.DeleteRunnerWithVersion(context.Background(), testRunnerID, test.version, time.Now().UTC(), datastore.RunnerStatusCompleted)
+		if !errors.Is(err, test.wantErr) {
+			t.Fatalf("want error %+v, but got %+v", test.wantErr, err)
+		}
+	}
+
+	got, err := testDatastore.GetRunner(context.Background(), testRunnerID)
+	if err != nil {
+		t.Fatalf("failed to get runner: %+v", err)
+	}
+	if got.Version != 1 {
+		t.Errorf("version must be incremented once, but got %d", got.Version)
+	}
+	if _, err := getDeletedRunnerFromSQL(testDB, testRunnerID); err != nil {
+		t.Fatalf("%s is not exist in runners_deleted: %+v", testRunnerID, err)
+	}
+}
+
+func TestMySQL_UpdateRunnerWithVersion(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+
+	if err := testDatastore.CreateTarget(context.Background(), datastore.Target{
+		UUID:           testTargetID,
+		Scope:          testScopeRepo,
+		GitHubToken:    testGitHubToken,
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	r := datastore.Runner{
+		UUID:           testRunnerID,
+		ShoesType:      "shoes-test",
+		TargetID:       testTargetID,
+		ResourceType:   datastore.ResourceTypeNano,
+		RepositoryURL:  "https://github.com/octocat/Hello-World",
+		RequestWebhook: "{}",
+	}
+	if err := testDatastore.CreateRunner(context.Background(), r); err != nil {
+		t.Fatalf("failed to create runner: %+v", err)
+	}
+
+	tests := []struct {
+		version int64
+		cloudID string
+		wantErr error
+	}{
+		{
+			version: 0,
+			cloudID: "mycloud-uuid",
+			wantErr: nil,
+		},
+		{
+			// stale version, updated by other process
+			version: 0,
+			cloudID: "mycloud-stale",
+			wantErr: datastore.ErrConflict,
+		},
+	}
+
+	for _, test := range tests {
+		r.Version = test.version
+		r.CloudID = test.cloudID
+		err := testDatastore.UpdateRunnerWithVersion(context.Background(), r)
+		if !errors.Is(err, test.wantErr) {
+			t.Fatalf("want error %+v, but got %+v", test.wantErr, err)
+		}
+	}
+
+	got, err := testDatastore.GetRunner(context.Background(), testRunnerID)
+	if err != nil {
+		t.Fatalf("failed to get runner: %+v", err)
+	}
+	if got.CloudID != "mycloud-uuid" || got.Version != 1 {
+		t.Errorf("want cloud ID mycloud-uuid and version 1, but got %s and %d", got.CloudID, got.Version)
+	}
+
+	if err := testDatastore.DeleteRunner(context.Background(), testRunnerID, time.Now().UTC(), datastore.RunnerStatusCompleted); err != nil {
+		t.Fatalf("failed to delete runner: %+v", err)
+	}
+	r.Version = 2
+	if err := testDatastore.UpdateRunnerWithVersion(context.Background(), r); !errors.Is(err, datastore.ErrConflict) {
+		t.Fatalf("deleted runner must not be updated, but got %+v", err)
+	}
+}
+
+func TestMySQL_MarkRunnerTokenIssued(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+	ctx := context.Background()
+
+	if err := testDatastore.CreateTarget(ctx, datastore.Target{
+		UUID:           testTargetID,
+		Scope:          testScopeRepo,
+		GitHubToken:    testGitHubToken,
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+	if err := testDatastore.CreateRunner(ctx, datastore.Runner{
+		UUID:           testRunnerID,
+		ShoesType:      "shoes-test",
+		TargetID:       testTargetID,
+		ResourceType:   datastore.ResourceTypeNano,
+		RepositoryURL:  "https://github.com/octocat/Hello-World",
+		RequestWebhook: "{}",
+	}); err != nil {
+		t.Fatalf("failed to create runner: %+v", err)
+	}
+
+	if err := testDatastore.MarkRunnerTokenIssued(ctx, testRunnerID, time.Now()); err != nil {
+		t.Fatalf("failed to mark token issued: %+v", err)
+	}
+	if err := testDatastore.MarkRunnerTokenIssued(ctx, testRunnerID, time.Now()); !errors.Is(err, datastore.ErrConflict) {
+		t.Fatalf("token must be issued only once, want ErrConflict but got %+v", err)
+	}
+}
+
+func TestMySQL_DeleteRunnersBulk(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+	testDB, _ := testutils.GetTestDB()
+
+	if err := testDatastore.CreateTarget(context.Background(), datastore.Target{
+		UUID:           testTargetID,
+		Scope:          testScopeRepo,
+		GitHubToken:    testGitHubToken,
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	var ids []uuid.UUID
+	var read []datastore.Runner
+	for i := 0; i < 4; i++ {
+		id := uuid.NewV4()
+		if err := testDatastore.CreateRunner(context.Background(), datastore.Runner{
+			UUID:           id,
+			ShoesType:      "shoes-test",
+			TargetID:       testTargetID,
+			CloudID:        "mycloud-uuid",
+			ResourceType:   datastore.ResourceTypeNano,
+			RepositoryURL:  "https://github.com/octocat/Hello-World",
+			RequestWebhook: "{}",
+		}); err != nil {
+			t.Fatalf("failed to create runner: %+v", err)
+		}
+		r, err := testDatastore.GetRunner(context.Background(), id)
+		if err != nil {
+			t.Fatalf("failed to get runner: %+v", err)
+		}
+		ids = append(ids, id)
+		read = append(read, *r)
+	}
+	// deleted by other process before
+	if err := testDatastore.DeleteRunner(context.Background(), ids[2], time.Now().UTC(), datastore.RunnerStatusCompleted); err != nil {
+		t.Fatalf("failed to delete runner: %+v", err)
+	}
+	// updated by other process after read
+	if err := testDatastore.UpdateRunnerWithVersion(context.Background(), read[3]); err != nil {
+		t.Fatalf("failed to update runner: %+v", err)
+	}
+
+	got, err := testDatastore.DeleteRunnersBulk(context.Background(), read, time.Now().UTC(), datastore.RunnerStatusReachHardLimit)
+	if !errors.Is(err, datastore.ErrConflict) {
+		t.Fatalf("want ErrConflict for updated runner, but got %+v", err)
+	}
+	want := []uuid.UUID{ids[0], ids[1]}
+	sort.Slice(want, func(i, j int) bool { return want[i].String() < want[j].String() })
+	sort.Slice(got, func(i, j int) bool { return got[i].String() < got[j].String() })
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	runners, err := testDatastore.ListRunnersByTargetID(context.Background(), testTargetID)
+	if err != nil {
+		t.Fatalf("failed to list runners: %+v", err)
+	}
+	if len(runners) != 1 || !uuid.Equal(runners[0].UUID, ids[3]) {
+		t.Errorf("only updated runner must be running, but got %v", runners)
+	}
+	for _, id := range ids[:3] {
+		if _, err := getDeletedRunnerFromSQL(testDB, id); err != nil {
+			t.Fatalf("%s is not exist in runners_deleted: %+v", id, err)
+		}
+	}
+}
+
 func getRunnerFromSQL(testDB *sqlx.DB, id uuid.UUID) (*datastore.Runner, error) {
 	var r datastore.Runner
 	query := `SELECT runner_id, shoes_type, ip_address, target_id, cloud_id, created_at, updated_at, resource_type, repository_url, request_webhook, runner_user, provider_url FROM runner_detail WHERE runner_id = ?`
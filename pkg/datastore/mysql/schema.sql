@@ -8,9 +8,19 @@ CREATE TABLE `targets` (
     `provider_url` VARCHAR(255),
     `status` VARCHAR(255) NOT NULL DEFAULT 'active',
     `status_description` VARCHAR(255),
+    `external_ref` VARCHAR(255),
+    `placement_params` TEXT,
+    `user_data_format` VARCHAR(255) NOT NULL DEFAULT '',
+    `runner_registration` VARCHAR(255) NOT NULL DEFAULT '',
+    `runner_labels` TEXT,
+    `quiet_hours` TEXT,
+    `rootless` VARCHAR(255) NOT NULL DEFAULT '',
+    `job_filters` TEXT,
+    `deleted_at` TIMESTAMP NULL,
     `created_at` TIMESTAMP NOT NULL DEFAULT current_timestamp,
     `updated_at` TIMESTAMP NOT NULL DEFAULT current_timestamp ON UPDATE current_timestamp,
-    UNIQUE KEY `ghe_domain_scope` (`ghe_domain`, `scope`)
+    UNIQUE KEY `ghe_domain_scope` (`ghe_domain`, `scope`),
+    KEY `idx_target_external_ref` (`external_ref`)
 );
 
 CREATE TABLE `runners` (
@@ -29,6 +39,9 @@ CREATE TABLE `runner_detail` (
     `provider_url` VARCHAR(255),
     `repository_url` VARCHAR(255) NOT NULL,
     `request_webhook` TEXT NOT NULL,
+    `runner_name` VARCHAR(255),
+    `version` BIGINT NOT NULL DEFAULT 0,
+    `token_issued_at` TIMESTAMP(6) NULL,
     `created_at` TIMESTAMP NOT NULL DEFAULT current_timestamp,
     `updated_at` TIMESTAMP NOT NULL DEFAULT current_timestamp ON UPDATE current_timestamp,
     KEY `fk_runner_target_id` (`target_id`),
@@ -52,14 +65,63 @@ CREATE TABLE `runners_deleted` (
     CONSTRAINT `runners_deleted_ibfk_1` FOREIGN KEY fk_runner_deleted_id(`runner_id`) REFERENCES runners(`uuid`) ON DELETE CASCADE
 );
 
+CREATE TABLE `runner_hook_results` (
+    `runner_id` VARCHAR(36) NOT NULL,
+    `event` VARCHAR(255) NOT NULL,
+    `success` BOOLEAN NOT NULL,
+    `blocking` BOOLEAN NOT NULL,
+    `status_code` INT NOT NULL DEFAULT 0,
+    `message` TEXT NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    KEY `idx_runner_hook_results_runner_id` (`runner_id`)
+);
+
+CREATE TABLE `state_histories` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `resource_type` VARCHAR(255) NOT NULL,
+    `resource_id` VARCHAR(36) NOT NULL,
+    `status` VARCHAR(255) NOT NULL,
+    `reason` TEXT NOT NULL,
+    `created_at` TIMESTAMP(6) NOT NULL DEFAULT current_timestamp(6),
+    KEY `idx_state_histories_resource_id` (`resource_id`),
+    KEY `idx_state_histories_created_at` (`created_at`)
+);
+
+CREATE TABLE `job_histories` (
+    `job_id` VARCHAR(36) NOT NULL PRIMARY KEY,
+    `target_id` VARCHAR(36) NOT NULL,
+    `github_job_id` BIGINT,
+    `received_at` TIMESTAMP(6) NOT NULL DEFAULT current_timestamp(6),
+    `runner_created_at` TIMESTAMP(6) NULL,
+    `runner_registered_at` TIMESTAMP(6) NULL,
+    `started_at` TIMESTAMP(6) NULL,
+    `completed_at` TIMESTAMP(6) NULL,
+    KEY `idx_job_histories_target_id` (`target_id`),
+    KEY `idx_job_histories_github_job_id` (`github_job_id`),
+    KEY `idx_job_histories_received_at` (`received_at`)
+);
+
 CREATE TABLE `jobs` (
     `uuid` VARCHAR(36) NOT NULL PRIMARY KEY,
     `ghe_domain` VARCHAR(255),
     `repository` VARCHAR(255) NOT NULL,
     `check_event` TEXT NOT NULL,
     `target_id` VARCHAR(36) NOT NULL,
+    `not_before` TIMESTAMP NULL,
+    `external_ref` VARCHAR(255),
+    `dedup_key` VARCHAR(255),
     `created_at` TIMESTAMP NOT NULL DEFAULT current_timestamp,
     `updated_at` TIMESTAMP NOT NULL DEFAULT current_timestamp ON UPDATE current_timestamp,
+    KEY `idx_job_not_before` (`not_before`),
+    KEY `idx_job_external_ref` (`external_ref`),
+    KEY `idx_job_created_at` (`created_at`),
+    UNIQUE KEY `idx_job_dedup_key` (`dedup_key`),
     KEY `fk_job_target_id` (`target_id`),
     CONSTRAINT `jobs_ibfk_1` FOREIGN KEY fk_job_target_id(`target_id`) REFERENCES targets(`uuid`) ON DELETE RESTRICT
 );
+
+CREATE TABLE `locks` (
+    `name` VARCHAR(255) NOT NULL PRIMARY KEY,
+    `owner` VARCHAR(255) NOT NULL,
+    `expired_at` TIMESTAMP(6) NOT NULL
+);
@@ -12,10 +12,12 @@ import (
 )
 
 // CreateTarget create a target
-func (m *MySQL) CreateTarget(ctx context.Context, target datastore.Target) error {
+func (m *MySQL) CreateTarget(ctx context.Context, target datastore.Target) (err error) {
+	defer observe("CreateTarget", time.Now(), &err)
+
 	expiredAtRFC3339 := target.TokenExpiredAt.Format("2006-01-02 15:04:05")
 
-	query := `INSERT INTO targets(uuid, scope, ghe_domain, github_token, token_expired_at, resource_type, provider_url) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO targets(uuid, scope, ghe_domain, github_token, token_expired_at, resource_type, provider_url, external_ref, placement_params, user_data_format, runner_registration, runner_labels, quiet_hours, rootless, job_filters) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	if _, err := m.Conn.ExecContext(
 		ctx,
 		query,
@@ -26,6 +28,14 @@ func (m *MySQL) CreateTarget(ctx context.Context, target datastore.Target) error
 		expiredAtRFC3339,
 		target.ResourceType,
 		target.ProviderURL,
+		target.ExternalRef,
+		target.PlacementParams,
+		target.UserDataFormat,
+		target.RunnerRegistration,
+		target.RunnerLabels,
+		target.QuietHours,
+		target.Rootless,
+		target.JobFilters,
 	); err != nil {
 		return fmt.Errorf("failed to execute INSERT query: %w", err)
 	}
@@ -34,10 +44,12 @@ func (m *MySQL) CreateTarget(ctx context.Context, target datastore.Target) error
 }
 
 // GetTarget get a target
-func (m *MySQL) GetTarget(ctx context.Context, id uuid.UUID) (*datastore.Target, error) {
+func (m *MySQL) GetTarget(ctx context.Context, id uuid.UUID) (_ *datastore.Target, err error) {
+	defer observe("GetTarget", time.Now(), &err)
+
 	var t datastore.Target
-	query := `SELECT uuid, scope, github_token, token_expired_at, resource_type, provider_url, status, status_description, created_at, updated_at FROM targets WHERE uuid = ?`
-	if err := m.Conn.GetContext(ctx, &t, query, id.String()); err != nil {
+	query := `SELECT uuid, scope, ghe_domain, github_token, token_expired_at, resource_type, provider_url, status, status_description, external_ref, placement_params, user_data_format, runner_registration, runner_labels, quiet_hours, rootless, job_filters, deleted_at, created_at, updated_at FROM targets WHERE uuid = ?`
+	if err := m.reader(ctx).GetContext(ctx, &t, query, id.String()); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, datastore.ErrNotFound
 		}
@@ -48,11 +60,13 @@ func (m *MySQL) GetTarget(ctx context.Context, id uuid.UUID) (*datastore.Target,
 	return &t, nil
 }
 
-// GetTargetByScope get a target from scope
-func (m *MySQL) GetTargetByScope(ctx context.Context, scope string) (*datastore.Target, error) {
+// GetTargetByScope get a target from scope in GitHub host
+func (m *MySQL) GetTargetByScope(ctx context.Context, gheDomain, scope string) (_ *datastore.Target, err error) {
+	defer observe("GetTargetByScope", time.Now(), &err)
+
 	var t datastore.Target
-	query := fmt.Sprintf(`SELECT uuid, scope, github_token, token_expired_at, resource_type, provider_url, status, status_description, created_at, updated_at FROM targets WHERE scope = "%s"`, scope)
-	if err := m.Conn.GetContext(ctx, &t, query); err != nil {
+	query := `SELECT uuid, scope, ghe_domain, github_token, token_expired_at, resource_type, provider_url, status, status_description, external_ref, placement_params, user_data_format, runner_registration, runner_labels, quiet_hours, rootless, job_filters, deleted_at, created_at, updated_at FROM targets WHERE ghe_domain <=> ? AND scope = ?`
+	if err := m.reader(ctx).GetContext(ctx, &t, query, datastore.NullGHEDomain(gheDomain), scope); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, datastore.ErrNotFound
 		}
@@ -63,29 +77,83 @@ func (m *MySQL) GetTargetByScope(ctx context.Context, scope string) (*datastore.
 	return &t, nil
 }
 
-// ListTargets get a all target
-func (m *MySQL) ListTargets(ctx context.Context) ([]datastore.Target, error) {
+// ListTargets get a page of targets
+func (m *MySQL) ListTargets(ctx context.Context, opt datastore.ListOption) (_ []datastore.Target, err error) {
+	defer observe("ListTargets", time.Now(), &err)
+
 	var ts []datastore.Target
-	query := `SELECT uuid, scope, github_token, token_expired_at, resource_type, provider_url, status, status_description, created_at, updated_at FROM targets`
-	if err := m.Conn.SelectContext(ctx, &ts, query); err != nil {
+	query := `SELECT uuid, scope, ghe_domain, github_token, token_expired_at, resource_type, provider_url, status, status_description, external_ref, placement_params, user_data_format, runner_registration, runner_labels, quiet_hours, rootless, job_filters, deleted_at, created_at, updated_at FROM targets`
+	clause, args := opt.Clause("uuid", "deleted_at IS NULL")
+	if err := m.reader(ctx).SelectContext(ctx, &ts, query+clause, args...); err != nil {
 		return nil, fmt.Errorf("failed to SELECT query: %w", err)
 	}
 
 	return ts, nil
 }
 
-// DeleteTarget delete a target
-func (m *MySQL) DeleteTarget(ctx context.Context, id uuid.UUID) error {
-	query := `UPDATE targets SET status = "deleted" WHERE uuid = ?`
-	if _, err := m.Conn.ExecContext(ctx, query, id.String()); err != nil {
+// ListDeletedTargets get a page of soft deleted targets
+func (m *MySQL) ListDeletedTargets(ctx context.Context, opt datastore.ListOption) (_ []datastore.Target, err error) {
+	defer observe("ListDeletedTargets", time.Now(), &err)
+
+	var ts []datastore.Target
+	query := `SELECT uuid, scope, ghe_domain, github_token, token_expired_at, resource_type, provider_url, status, status_description, external_ref, placement_params, user_data_format, runner_registration, runner_labels, quiet_hours, rootless, job_filters, deleted_at, created_at, updated_at FROM targets`
+	clause, args := opt.Clause("uuid", "deleted_at IS NOT NULL")
+	if err := m.reader(ctx).SelectContext(ctx, &ts, query+clause, args...); err != nil {
+		return nil, fmt.Errorf("failed to SELECT query: %w", err)
+	}
+
+	return ts, nil
+}
+
+// ListTargetsByExternalRef get targets that has external reference ID
+func (m *MySQL) ListTargetsByExternalRef(ctx context.Context, externalRef string) (_ []datastore.Target, err error) {
+	defer observe("ListTargetsByExternalRef", time.Now(), &err)
+
+	var ts []datastore.Target
+	query := `SELECT uuid, scope, ghe_domain, github_token, token_expired_at, resource_type, provider_url, status, status_description, external_ref, placement_params, user_data_format, runner_registration, runner_labels, quiet_hours, rootless, job_filters, deleted_at, created_at, updated_at FROM targets WHERE external_ref = ? AND deleted_at IS NULL`
+	if err := m.reader(ctx).SelectContext(ctx, &ts, query, externalRef); err != nil {
+		return nil, fmt.Errorf("failed to SELECT query: %w", err)
+	}
+
+	return ts, nil
+}
+
+// DeleteTarget soft delete a target
+func (m *MySQL) DeleteTarget(ctx context.Context, id uuid.UUID) (err error) {
+	defer observe("DeleteTarget", time.Now(), &err)
+
+	query := `UPDATE targets SET status = "deleted", deleted_at = ? WHERE uuid = ?`
+	if _, err := m.Conn.ExecContext(ctx, query, time.Now().UTC(), id.String()); err != nil {
 		return fmt.Errorf("failed to execute DELETE query: %w", err)
 	}
 
 	return nil
 }
 
+// RestoreTarget restore a soft deleted target
+func (m *MySQL) RestoreTarget(ctx context.Context, id uuid.UUID) (err error) {
+	defer observe("RestoreTarget", time.Now(), &err)
+
+	query := `UPDATE targets SET status = "active", status_description = NULL, deleted_at = NULL WHERE uuid = ? AND deleted_at IS NOT NULL`
+	result, err := m.Conn.ExecContext(ctx, query, id.String())
+	if err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+	restored, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if restored == 0 {
+		return datastore.ErrNotFound
+	}
+
+	return nil
+}
+
 // UpdateTargetStatus update status in target
-func (m *MySQL) UpdateTargetStatus(ctx context.Context, targetID uuid.UUID, newStatus datastore.TargetStatus, description string) error {
+func (m *MySQL) UpdateTargetStatus(ctx context.Context, targetID uuid.UUID, newStatus datastore.TargetStatus, description string) (err error) {
+	defer observe("UpdateTargetStatus", time.Now(), &err)
+
 	query := `UPDATE targets SET status = ?, status_description = ? WHERE uuid = ?`
 	if _, err := m.Conn.ExecContext(ctx, query, newStatus, description, targetID.String()); err != nil {
 		return fmt.Errorf("failed to execute UPDATE query: %w", err)
@@ -95,7 +163,9 @@ func (m *MySQL) UpdateTargetStatus(ctx context.Context, targetID uuid.UUID, newS
 }
 
 // UpdateToken update token in target
-func (m *MySQL) UpdateToken(ctx context.Context, targetID uuid.UUID, newToken string, newExpiredAt time.Time) error {
+func (m *MySQL) UpdateToken(ctx context.Context, targetID uuid.UUID, newToken string, newExpiredAt time.Time) (err error) {
+	defer observe("UpdateToken", time.Now(), &err)
+
 	query := `UPDATE targets SET github_token = ?, token_expired_at = ? WHERE uuid = ?`
 	if _, err := m.Conn.ExecContext(ctx, query, newToken, newExpiredAt, targetID.String()); err != nil {
 		return fmt.Errorf("failed to execute UPDATE query: %w", err)
@@ -105,7 +175,9 @@ func (m *MySQL) UpdateToken(ctx context.Context, targetID uuid.UUID, newToken st
 }
 
 // UpdateTargetParam update parameter of target
-func (m *MySQL) UpdateTargetParam(ctx context.Context, targetID uuid.UUID, newResourceType datastore.ResourceType, newProviderURL sql.NullString) error {
+func (m *MySQL) UpdateTargetParam(ctx context.Context, targetID uuid.UUID, newResourceType datastore.ResourceType, newProviderURL sql.NullString) (err error) {
+	defer observe("UpdateTargetParam", time.Now(), &err)
+
 	query := `UPDATE targets SET resource_type = ?, provider_url = ? WHERE uuid = ?`
 	if _, err := m.Conn.ExecContext(ctx, query, newResourceType, newProviderURL, targetID.String()); err != nil {
 		return fmt.Errorf("failed to execute UPDATE query: %w", err)
@@ -113,3 +185,87 @@ func (m *MySQL) UpdateTargetParam(ctx context.Context, targetID uuid.UUID, newRe
 
 	return nil
 }
+
+// UpdateTargetPlacementParams update placement parameters of target
+func (m *MySQL) UpdateTargetPlacementParams(ctx context.Context, targetID uuid.UUID, newPlacementParams sql.NullString) (err error) {
+	defer observe("UpdateTargetPlacementParams", time.Now(), &err)
+
+	query := `UPDATE targets SET placement_params = ? WHERE uuid = ?`
+	if _, err := m.Conn.ExecContext(ctx, query, newPlacementParams, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTargetUserDataFormat update format of user data of target
+func (m *MySQL) UpdateTargetUserDataFormat(ctx context.Context, targetID uuid.UUID, newFormat datastore.UserDataFormat) (err error) {
+	defer observe("UpdateTargetUserDataFormat", time.Now(), &err)
+
+	query := `UPDATE targets SET user_data_format = ? WHERE uuid = ?`
+	if _, err := m.Conn.ExecContext(ctx, query, newFormat, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTargetRunnerRegistration update method of registering runner of target
+func (m *MySQL) UpdateTargetRunnerRegistration(ctx context.Context, targetID uuid.UUID, newRegistration datastore.RunnerRegistration) (err error) {
+	defer observe("UpdateTargetRunnerRegistration", time.Now(), &err)
+
+	query := `UPDATE targets SET runner_registration = ? WHERE uuid = ?`
+	if _, err := m.Conn.ExecContext(ctx, query, newRegistration, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTargetRunnerLabels update labels that are injected to runners of target
+func (m *MySQL) UpdateTargetRunnerLabels(ctx context.Context, targetID uuid.UUID, newLabels sql.NullString) (err error) {
+	defer observe("UpdateTargetRunnerLabels", time.Now(), &err)
+
+	query := `UPDATE targets SET runner_labels = ? WHERE uuid = ?`
+	if _, err := m.Conn.ExecContext(ctx, query, newLabels, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTargetQuietHours update quiet hours of target
+func (m *MySQL) UpdateTargetQuietHours(ctx context.Context, targetID uuid.UUID, newQuietHours sql.NullString) (err error) {
+	defer observe("UpdateTargetQuietHours", time.Now(), &err)
+
+	query := `UPDATE targets SET quiet_hours = ? WHERE uuid = ?`
+	if _, err := m.Conn.ExecContext(ctx, query, newQuietHours, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTargetRootless update rootless container runtime of target
+func (m *MySQL) UpdateTargetRootless(ctx context.Context, targetID uuid.UUID, newRuntime datastore.RootlessRuntime) (err error) {
+	defer observe("UpdateTargetRootless", time.Now(), &err)
+
+	query := `UPDATE targets SET rootless = ? WHERE uuid = ?`
+	if _, err := m.Conn.ExecContext(ctx, query, newRuntime, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTargetJobFilters update job filters of target
+func (m *MySQL) UpdateTargetJobFilters(ctx context.Context, targetID uuid.UUID, newJobFilters sql.NullString) (err error) {
+	defer observe("UpdateTargetJobFilters", time.Now(), &err)
+
+	query := `UPDATE targets SET job_filters = ? WHERE uuid = ?`
+	if _, err := m.Conn.ExecContext(ctx, query, newJobFilters, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
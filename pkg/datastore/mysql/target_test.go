@@ -14,6 +14,7 @@ import (
 
 	"github.com/whywaita/myshoes/internal/testutils"
 	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
 )
 
 var testTargetID = uuid.FromStringOrNil("8a72d42c-372c-4e0d-9c6a-4304d44af137")
@@ -176,6 +177,54 @@ func TestMySQL_GetTarget(t *testing.T) {
 	}
 }
 
+func TestMySQL_GetTargetGHEDomain(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+	ctx := context.Background()
+	gheDomain := "https://github.example.com"
+	testutils.RegisterGitHubHost(t, gheDomain)
+
+	if err := testDatastore.CreateTarget(ctx, datastore.Target{
+		UUID:           testTargetID,
+		Scope:          testScopeRepo,
+		GHEDomain:      sql.NullString{String: gheDomain, Valid: true},
+		GitHubToken:    testGitHubToken,
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	got, err := testDatastore.GetTarget(ctx, testTargetID)
+	if err != nil {
+		t.Fatalf("failed to get target: %+v", err)
+	}
+	byScope, err := testDatastore.GetTargetByScope(ctx, gheDomain, testScopeRepo)
+	if err != nil {
+		t.Fatalf("failed to get target by scope: %+v", err)
+	}
+	listed, err := testDatastore.ListTargets(ctx, datastore.ListOption{})
+	if err != nil {
+		t.Fatalf("failed to list targets: %+v", err)
+	}
+	if len(listed) != 1 {
+		t.Fatalf("want 1 target, but got %d", len(listed))
+	}
+
+	for _, target := range []datastore.Target{*got, *byScope, listed[0]} {
+		if target.GHEDomain.String != gheDomain {
+			t.Fatalf("want ghe_domain %s, but got %+v", gheDomain, target.GHEDomain)
+		}
+		hostCtx, err := target.WithGitHubHost(ctx)
+		if err != nil {
+			t.Fatalf("failed to get GitHub host of target: %+v", err)
+		}
+		if h := gh.HostFrom(hostCtx); h.URL() != gheDomain {
+			t.Fatalf("want GitHub host %s, but got %s", gheDomain, h.URL())
+		}
+	}
+}
+
 func TestMySQL_GetTargetByScope(t *testing.T) {
 	testDatastore, teardown := testutils.GetTestDatastore()
 	defer teardown()
@@ -327,7 +376,7 @@ func TestMySQL_GetTargetByScope(t *testing.T) {
 			t.Fatalf("failed to prepare function: %+v", err)
 		}
 
-		got, err := testDatastore.GetTargetByScope(context.Background(), test.input)
+		got, err := testDatastore.GetTargetByScope(context.Background(), "", test.input)
 		if err != nil {
 			t.Fatalf("failed to get target: %+v", err)
 		}
@@ -344,6 +393,38 @@ func TestMySQL_GetTargetByScope(t *testing.T) {
 	}
 }
 
+func TestMySQL_GetTargetByScopeInHosts(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+	ctx := context.Background()
+	gheDomain := "https://github.example.com"
+
+	for _, target := range []datastore.Target{
+		{UUID: testTargetID, Scope: testScopeOrg},
+		{UUID: testTargetID2, Scope: testScopeOrg, GHEDomain: sql.NullString{String: gheDomain, Valid: true}},
+	} {
+		target.GitHubToken = testGitHubToken
+		target.TokenExpiredAt = testTime
+		target.ResourceType = datastore.ResourceTypeNano
+		if err := testDatastore.CreateTarget(ctx, target); err != nil {
+			t.Fatalf("failed to create target: %+v", err)
+		}
+	}
+
+	for domain, want := range map[string]uuid.UUID{"": testTargetID, gheDomain: testTargetID2} {
+		got, err := testDatastore.GetTargetByScope(ctx, domain, testScopeOrg)
+		if err != nil {
+			t.Fatalf("failed to get target by scope (ghe_domain: %q): %+v", domain, err)
+		}
+		if !uuid.Equal(got.UUID, want) {
+			t.Errorf("want target %s in ghe_domain %q, but got %s", want, domain, got.UUID)
+		}
+	}
+	if _, err := testDatastore.GetTargetByScope(ctx, "https://other.example.com", testScopeOrg); !errors.Is(err, datastore.ErrNotFound) {
+		t.Errorf("target in other host must not be found, but got %+v", err)
+	}
+}
+
 func TestMySQL_ListTargets(t *testing.T) {
 	testDatastore, teardown := testutils.GetTestDatastore()
 	defer teardown()
@@ -388,7 +469,7 @@ func TestMySQL_ListTargets(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		got, err := testDatastore.ListTargets(context.Background())
+		got, err := testDatastore.ListTargets(context.Background(), datastore.ListOption{})
 		if !test.err && err != nil {
 			t.Fatalf("failed to list targets: %+v", err)
 		}
@@ -465,6 +546,73 @@ func TestMySQL_DeleteTarget(t *testing.T) {
 	}
 }
 
+func TestMySQL_RestoreTarget(t *testing.T) {
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+	testDB, _ := testutils.GetTestDB()
+
+	if err := testDatastore.CreateTarget(context.Background(), datastore.Target{
+		UUID:           testTargetID,
+		Scope:          testScopeRepo,
+		GitHubToken:    testGitHubToken,
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	if err := testDatastore.RestoreTarget(context.Background(), testTargetID); !errors.Is(err, datastore.ErrNotFound) {
+		t.Fatalf("not deleted target must not be restored: %+v", err)
+	}
+
+	if err := testDatastore.DeleteTarget(context.Background(), testTargetID); err != nil {
+		t.Fatalf("failed to delete target: %+v", err)
+	}
+	active, err := testDatastore.ListTargets(context.Background(), datastore.ListOption{})
+	if err != nil {
+		t.Fatalf("failed to list targets: %+v", err)
+	}
+	if len(active) != 0 {
+		t.Fatalf("deleted target must not be listed: %+v", active)
+	}
+	deleted, err := testDatastore.ListDeletedTargets(context.Background(), datastore.ListOption{})
+	if err != nil {
+		t.Fatalf("failed to list deleted targets: %+v", err)
+	}
+	if len(deleted) != 1 || !uuid.Equal(deleted[0].UUID, testTargetID) || !deleted[0].DeletedAt.Valid {
+		t.Fatalf("deleted target must be listed: %+v", deleted)
+	}
+
+	if err := testDatastore.RestoreTarget(context.Background(), testTargetID); err != nil {
+		t.Fatalf("failed to restore target: %+v", err)
+	}
+	got, err := getTargetFromSQL(testDB, testTargetID)
+	if err != nil {
+		t.Fatalf("failed to get target from SQL: %+v", err)
+	}
+	got.CreatedAt = time.Time{}
+	got.UpdatedAt = time.Time{}
+
+	want := &datastore.Target{
+		UUID:           testTargetID,
+		Scope:          testScopeRepo,
+		GitHubToken:    testGitHubToken,
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+		Status:         datastore.TargetStatusActive,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	deleted, err = testDatastore.ListDeletedTargets(context.Background(), datastore.ListOption{})
+	if err != nil {
+		t.Fatalf("failed to list deleted targets: %+v", err)
+	}
+	if len(deleted) != 0 {
+		t.Fatalf("restored target must not be listed as deleted: %+v", deleted)
+	}
+}
+
 func TestMySQL_UpdateStatus(t *testing.T) {
 	testDatastore, teardown := testutils.GetTestDatastore()
 	defer teardown()
@@ -0,0 +1,114 @@
+package mysql_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/internal/testutils"
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/mysql"
+	"github.com/whywaita/myshoes/pkg/lock"
+)
+
+func TestTiDB_CompatMode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip TiDB test in short mode")
+	}
+
+	ds, dsn := testutils.GetTestTiDB(t)
+	oldDSN, oldTTL := config.Config.MySQLDSN, config.Config.LockTTL
+	defer func() {
+		config.Config.MySQLDSN, config.Config.LockTTL = oldDSN, oldTTL
+	}()
+	config.Config.MySQLDSN = dsn
+	config.Config.LockTTL = 3 * time.Second
+	ctx := context.Background()
+
+	// all migrations must be applied and rollbacked in TiDB
+	if err := ds.Migrate(ctx); err != nil {
+		t.Fatalf("failed to migrate: %+v", err)
+	}
+	if err := ds.MigrateDown(ctx, 100); err != nil {
+		t.Fatalf("failed to rollback migrations: %+v", err)
+	}
+	if err := ds.Migrate(ctx); err != nil {
+		t.Fatalf("failed to migrate again: %+v", err)
+	}
+
+	t.Run("lock", func(t *testing.T) {
+		lockCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		if err := ds.GetLock(lockCtx); err != nil {
+			t.Fatalf("failed to get lock: %+v", err)
+		}
+		got, err := ds.IsLocked(ctx)
+		if err != nil {
+			t.Fatalf("failed to check lock: %+v", err)
+		}
+		if got != datastore.IsLocked {
+			t.Errorf("lock must be locked, but got %s", got)
+		}
+
+		other, err := mysql.New(dsn, nil)
+		if err != nil {
+			t.Fatalf("failed to create other datastore: %+v", err)
+		}
+		other.CompatMode = true
+		if err := other.GetLock(ctx); !errors.Is(err, lock.ErrNotAcquired) {
+			t.Errorf("lock held by other must not be acquired, but got %+v", err)
+		}
+
+		// lease is extended by keepalive
+		time.Sleep(2 * config.Config.LockTTL)
+		if err := other.GetLock(ctx); !errors.Is(err, lock.ErrNotAcquired) {
+			t.Errorf("lock must be kept alive, but got %+v", err)
+		}
+
+		cancel()
+		time.Sleep(time.Second)
+		got, err = ds.IsLocked(ctx)
+		if err != nil {
+			t.Fatalf("failed to check lock: %+v", err)
+		}
+		if got != datastore.IsNotLocked {
+			t.Errorf("lock must be released, but got %s", got)
+		}
+	})
+
+	t.Run("enqueue job with dedup key", func(t *testing.T) {
+		targetID := uuid.NewV4()
+		if err := ds.CreateTarget(ctx, datastore.Target{
+			UUID:           targetID,
+			Scope:          testScopeRepo,
+			GitHubToken:    testGitHubToken,
+			TokenExpiredAt: testTime,
+			ResourceType:   datastore.ResourceTypeNano,
+		}); err != nil {
+			t.Fatalf("failed to create target: %+v", err)
+		}
+
+		dedupKey := sql.NullString{String: "workflow_job:1", Valid: true}
+		first, err := ds.EnqueueJob(ctx, datastore.Job{UUID: uuid.NewV4(), Repository: testScopeRepo, CheckEventJSON: "{}", TargetID: targetID, DedupKey: dedupKey})
+		if err != nil {
+			t.Fatalf("failed to enqueue job: %+v", err)
+		}
+		second, err := ds.EnqueueJob(ctx, datastore.Job{UUID: uuid.NewV4(), Repository: testScopeRepo, CheckEventJSON: "{}", TargetID: targetID, DedupKey: dedupKey})
+		if err != nil {
+			t.Fatalf("failed to enqueue duplicated job: %+v", err)
+		}
+		if !uuid.Equal(first.UUID, second.UUID) {
+			t.Errorf("duplicated job must return existing job, want %s but got %s", first.UUID, second.UUID)
+		}
+
+		if err := ds.DeleteJob(ctx, first.UUID); err != nil {
+			t.Fatalf("failed to delete job: %+v", err)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package mysql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+// tlsConfigName is name of TLS config that registered to MySQL driver
+const tlsConfigName = "myshoes"
+
+// registerTLSConfig register TLS config from config.Config to MySQL driver.
+// return name of TLS config that set to DSN, empty if TLS is not configured.
+func registerTLSConfig() (string, error) {
+	if config.Config.MySQLTLSCAPath == "" && config.Config.MySQLTLSCertPath == "" {
+		if config.Config.MySQLAuthMode == config.MySQLAuthModeAWSIAM {
+			// IAM authentication requires TLS, verify server by system root CAs
+			return "true", nil
+		}
+		return "", nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if config.Config.MySQLTLSCAPath != "" {
+		ca, err := os.ReadFile(config.Config.MySQLTLSCAPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return "", fmt.Errorf("failed to parse CA certificate (path: %s)", config.Config.MySQLTLSCAPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.Config.MySQLTLSCertPath != "" {
+		cert, err := tls.LoadX509KeyPair(config.Config.MySQLTLSCertPath, config.Config.MySQLTLSKeyPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if err := mysql.RegisterTLSConfig(tlsConfigName, tlsConfig); err != nil {
+		return "", fmt.Errorf("failed to register TLS config: %w", err)
+	}
+	return tlsConfigName, nil
+}
@@ -0,0 +1,47 @@
+package mysql
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// error numbers of MySQL that is resolved by retry
+const (
+	errLockWaitTimeout         = 1205
+	errLockDeadlock            = 1213
+	errServerShutdown          = 1053
+	errOptionPreventsStatement = 1290 // --read-only is enabled in old primary while failover
+	errReadOnlyMode            = 1836
+	errConnectionKilled        = 1927
+)
+
+// IsTransient return true if err is resolved by retry (e.g. deadlock, connection reset, failover)
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case errLockWaitTimeout, errLockDeadlock, errServerShutdown, errOptionPreventsStatement, errReadOnlyMode, errConnectionKilled:
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,136 @@
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// DefaultPageSize is number of items in a page when iterating List* methods
+var DefaultPageSize = 500
+
+// ListOption is option of pagination in List* methods.
+// items are sorted by uuid, Cursor is uuid of the last item in previous page (uuid.Nil is first page).
+// Limit is max number of items in a page, 0 is unlimited.
+type ListOption struct {
+	Cursor uuid.UUID
+	Limit  int
+}
+
+// Clause return WHERE / ORDER BY / LIMIT clause for column of uuid and args of it.
+// conditions (e.g. "deleted_at IS NULL") are joined to WHERE clause by AND
+func (o ListOption) Clause(column string, conditions ...string) (string, []interface{}) {
+	var clause string
+	var args []interface{}
+	if !uuid.Equal(o.Cursor, uuid.Nil) {
+		conditions = append(conditions, fmt.Sprintf("%s > ?", column))
+		args = append(args, o.Cursor.String())
+	}
+	if len(conditions) != 0 {
+		clause += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	clause += fmt.Sprintf(" ORDER BY %s", column)
+	if o.Limit > 0 {
+		clause += " LIMIT ?"
+		args = append(args, o.Limit)
+	}
+	return clause, args
+}
+
+// Paginate return a page of items by ListOption, for datastore that is not SQL
+func Paginate[T any](items []T, id func(T) uuid.UUID, opt ListOption) []T {
+	sort.SliceStable(items, func(i, j int) bool {
+		return id(items[i]).String() < id(items[j]).String()
+	})
+
+	var page []T
+	for _, item := range items {
+		if !uuid.Equal(opt.Cursor, uuid.Nil) && id(item).String() <= opt.Cursor.String() {
+			continue
+		}
+		if opt.Limit > 0 && len(page) >= opt.Limit {
+			break
+		}
+		page = append(page, item)
+	}
+	return page
+}
+
+// ListTargetsPage get a page of target that can receive job.
+// next is cursor of next page, uuid.Nil if it is the last page.
+func ListTargetsPage(ctx context.Context, ds Datastore, opt ListOption) ([]Target, uuid.UUID, error) {
+	targets, err := ds.ListTargets(ctx, opt)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("failed to get targets from datastore: %w", err)
+	}
+
+	next := uuid.Nil
+	if opt.Limit > 0 && len(targets) == opt.Limit {
+		next = targets[len(targets)-1].UUID
+	}
+
+	var result []Target
+	for _, t := range targets {
+		if t.CanReceiveJob() {
+			result = append(result, t)
+		}
+	}
+	return result, next, nil
+}
+
+// WalkTargets call fn with each page of target that can receive job
+func WalkTargets(ctx context.Context, ds Datastore, fn func(targets []Target) error) error {
+	opt := ListOption{Limit: DefaultPageSize}
+	for {
+		targets, next, err := ListTargetsPage(ctx, ds, opt)
+		if err != nil {
+			return err
+		}
+		if err := fn(targets); err != nil {
+			return err
+		}
+		if uuid.Equal(next, uuid.Nil) {
+			return nil
+		}
+		opt.Cursor = next
+	}
+}
+
+// WalkRunners call fn with each page of not deleted runner
+func WalkRunners(ctx context.Context, ds Datastore, fn func(runners []Runner) error) error {
+	opt := ListOption{Limit: DefaultPageSize}
+	for {
+		runners, err := ds.ListRunners(ctx, opt)
+		if err != nil {
+			return fmt.Errorf("failed to get runners from datastore: %w", err)
+		}
+		if err := fn(runners); err != nil {
+			return err
+		}
+		if len(runners) < opt.Limit {
+			return nil
+		}
+		opt.Cursor = runners[len(runners)-1].UUID
+	}
+}
+
+// WalkJobs call fn with each page of job
+func WalkJobs(ctx context.Context, ds Datastore, fn func(jobs []Job) error) error {
+	opt := ListOption{Limit: DefaultPageSize}
+	for {
+		jobs, err := ds.ListJobs(ctx, opt)
+		if err != nil {
+			return fmt.Errorf("failed to get jobs from datastore: %w", err)
+		}
+		if err := fn(jobs); err != nil {
+			return err
+		}
+		if len(jobs) < opt.Limit {
+			return nil
+		}
+		opt.Cursor = jobs[len(jobs)-1].UUID
+	}
+}
@@ -0,0 +1,96 @@
+package datastore
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestListOption_Clause(t *testing.T) {
+	cursor := uuid.FromStringOrNil("7943c0d2-3b6c-4d8b-8a52-1c8e1c2f8a10")
+
+	tests := []struct {
+		input      ListOption
+		conditions []string
+		wantClause string
+		wantArgs   []interface{}
+	}{
+		{
+			input:      ListOption{},
+			wantClause: " ORDER BY uuid",
+			wantArgs:   nil,
+		},
+		{
+			input:      ListOption{Limit: 10},
+			wantClause: " ORDER BY uuid LIMIT ?",
+			wantArgs:   []interface{}{10},
+		},
+		{
+			input:      ListOption{Cursor: cursor, Limit: 10},
+			wantClause: " WHERE uuid > ? ORDER BY uuid LIMIT ?",
+			wantArgs:   []interface{}{cursor.String(), 10},
+		},
+		{
+			input:      ListOption{Limit: 10},
+			conditions: []string{"deleted_at IS NULL"},
+			wantClause: " WHERE deleted_at IS NULL ORDER BY uuid LIMIT ?",
+			wantArgs:   []interface{}{10},
+		},
+		{
+			input:      ListOption{Cursor: cursor, Limit: 10},
+			conditions: []string{"deleted_at IS NULL"},
+			wantClause: " WHERE deleted_at IS NULL AND uuid > ? ORDER BY uuid LIMIT ?",
+			wantArgs:   []interface{}{cursor.String(), 10},
+		},
+	}
+
+	for _, test := range tests {
+		gotClause, gotArgs := test.input.Clause("uuid", test.conditions...)
+		if gotClause != test.wantClause {
+			t.Errorf("want %q, but got %q", test.wantClause, gotClause)
+		}
+		if diff := cmp.Diff(test.wantArgs, gotArgs); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	ids := []uuid.UUID{
+		uuid.FromStringOrNil("33333333-3333-3333-3333-333333333333"),
+		uuid.FromStringOrNil("11111111-1111-1111-1111-111111111111"),
+		uuid.FromStringOrNil("22222222-2222-2222-2222-222222222222"),
+	}
+	id := func(u uuid.UUID) uuid.UUID { return u }
+
+	tests := []struct {
+		input ListOption
+		want  []uuid.UUID
+	}{
+		{
+			input: ListOption{},
+			want:  []uuid.UUID{ids[1], ids[2], ids[0]},
+		},
+		{
+			input: ListOption{Limit: 2},
+			want:  []uuid.UUID{ids[1], ids[2]},
+		},
+		{
+			input: ListOption{Cursor: ids[2], Limit: 2},
+			want:  []uuid.UUID{ids[0]},
+		},
+		{
+			input: ListOption{Cursor: ids[0], Limit: 2},
+			want:  nil,
+		},
+	}
+
+	for _, test := range tests {
+		items := append([]uuid.UUID{}, ids...)
+		got := Paginate(items, id, test.input)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package datastore
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MaxPlacementParamsSize is max size of placement parameters
+const MaxPlacementParamsSize = 4096
+
+// ValidatePlacementParams check format of placement parameters.
+// placement parameters must be a JSON object, and value must be string, number, bool or array of string.
+// e.g. {"subnet_id": "subnet-xxx", "security_group_ids": ["sg-xxx"], "zone": "ap-northeast-1a"}
+func ValidatePlacementParams(raw []byte) error {
+	if len(raw) > MaxPlacementParamsSize {
+		return fmt.Errorf("placement parameters must be less than %d bytes", MaxPlacementParamsSize)
+	}
+
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return fmt.Errorf("placement parameters must be JSON object: %w", err)
+	}
+	if params == nil {
+		return fmt.Errorf("placement parameters must be JSON object")
+	}
+
+	for key, value := range params {
+		if key == "" {
+			return fmt.Errorf("key of placement parameters must not be empty")
+		}
+
+		var v interface{}
+		if err := json.Unmarshal(value, &v); err != nil {
+			return fmt.Errorf("invalid value in %s: %w", key, err)
+		}
+		switch vv := v.(type) {
+		case string, float64, bool:
+			continue
+		case []interface{}:
+			for _, e := range vv {
+				if _, ok := e.(string); !ok {
+					return fmt.Errorf("%s must be array of string", key)
+				}
+			}
+		default:
+			return fmt.Errorf("%s has invalid type (must be string, number, bool or array of string)", key)
+		}
+	}
+
+	return nil
+}
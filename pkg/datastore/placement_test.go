@@ -0,0 +1,49 @@
+package datastore
+
+import "testing"
+
+func TestValidatePlacementParams(t *testing.T) {
+	tests := []struct {
+		input string
+		err   bool
+	}{
+		{
+			input: `{"subnet_id": "subnet-xxx", "security_group_ids": ["sg-xxx", "sg-yyy"], "zone": "ap-northeast-1a"}`,
+			err:   false,
+		},
+		{
+			input: `{"public_ip": false, "priority": 10}`,
+			err:   false,
+		},
+		{
+			input: `{}`,
+			err:   false,
+		},
+		{
+			input: `["subnet-xxx"]`,
+			err:   true,
+		},
+		{
+			input: `null`,
+			err:   true,
+		},
+		{
+			input: `{"network": {"subnet_id": "subnet-xxx"}}`,
+			err:   true,
+		},
+		{
+			input: `{"ports": [22, 443]}`,
+			err:   true,
+		},
+	}
+
+	for _, test := range tests {
+		err := ValidatePlacementParams([]byte(test.input))
+		if !test.err && err != nil {
+			t.Fatalf("failed to validate (input: %s): %+v", test.input, err)
+		}
+		if test.err && err == nil {
+			t.Fatalf("must be error, but not error (input: %s)", test.input)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+var (
+	// PressureWindow is window of counting datastore calls to detect pressure
+	PressureWindow = 1 * time.Minute
+	// PressureMinCalls is minimum number of calls in PressureWindow to detect pressure
+	PressureMinCalls = 20
+	// PressureSlowCall is latency that a call is counted as slow
+	PressureSlowCall = 500 * time.Millisecond
+	// PressureSlowRate is threshold of rate of slow calls to detect pressure
+	PressureSlowRate = 0.5
+	// PressureErrorRate is threshold of error rate to detect pressure
+	PressureErrorRate = 0.3
+	// PressureSampleRate is 1 of N non-critical records that are written under pressure
+	PressureSampleRate = 10
+
+	pressure = &pressureTracker{}
+)
+
+// pressureBuckets is number of buckets in PressureWindow, calls are counted per bucket
+const pressureBuckets = 60
+
+// callBucket is count of calls in a slot of PressureWindow (e.g. 1 second in 1 minute window)
+type callBucket struct {
+	slot   int64 // index of slot from unix epoch, bucket is reset when it is reused for new slot
+	calls  int
+	slow   int
+	failed int
+}
+
+// pressureTracker tracks latency and errors of datastore calls.
+// pressure is entered if calls are slow or failed over thresholds in PressureWindow,
+// and left after a whole PressureWindow is under thresholds.
+type pressureTracker struct {
+	mu      sync.Mutex
+	buckets [pressureBuckets]callBucket // ring of slots in PressureWindow
+	overAt  time.Time                   // last time that calls were over thresholds, zero is not under pressure
+
+	sampled atomic.Int64
+}
+
+// ObserveCall record a result of datastore call for detecting pressure
+func ObserveCall(elapsed time.Duration, err error) {
+	pressure.record(time.Now(), elapsed, isFailure(err))
+}
+
+// isFailure return true if err is failure of datastore.
+// not found, conflict and canceled by caller are results of a healthy datastore
+func isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	return !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrConflict) && !errors.Is(err, context.Canceled)
+}
+
+func (p *pressureTracker) record(now time.Time, elapsed time.Duration, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	width := int64(PressureWindow / pressureBuckets)
+	if width <= 0 {
+		width = 1
+	}
+	slot := now.UnixNano() / width
+	b := &p.buckets[slot%pressureBuckets]
+	if b.slot != slot {
+		*b = callBucket{slot: slot}
+	}
+	b.calls++
+	if elapsed >= PressureSlowCall {
+		b.slow++
+	}
+	if failed {
+		b.failed++
+	}
+
+	var calls, slow, failures int
+	for _, b := range p.buckets {
+		if slot-b.slot < pressureBuckets {
+			calls += b.calls
+			slow += b.slow
+			failures += b.failed
+		}
+	}
+	n := float64(calls)
+	over := calls >= PressureMinCalls && (float64(slow)/n >= PressureSlowRate || float64(failures)/n >= PressureErrorRate)
+
+	switch {
+	case over:
+		if p.overAt.IsZero() {
+			logger.Logf(false, "datastore is under pressure (slow: %d, failed: %d of %d calls in %s), will shed non-critical writes", slow, failures, calls, PressureWindow)
+		}
+		p.overAt = now
+	case !p.overAt.IsZero() && now.Sub(p.overAt) >= PressureWindow:
+		// recover after a whole window is under thresholds
+		logger.Logf(false, "datastore is recovered from pressure, will resume non-critical writes")
+		p.overAt = time.Time{}
+	}
+}
+
+func (p *pressureTracker) isUnderPressure() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return !p.overAt.IsZero()
+}
+
+// shouldWrite return true if non-critical record should be written, 1 of PressureSampleRate records is written under pressure
+func (p *pressureTracker) shouldWrite() bool {
+	if !p.isUnderPressure() || PressureSampleRate <= 1 {
+		return true
+	}
+	return p.sampled.Add(1)%int64(PressureSampleRate) == 0
+}
+
+// IsUnderPressure return true if datastore is under pressure.
+// non-critical writes (e.g. histories, purges, metrics) are shed, job intake and dispatch are not affected.
+func IsUnderPressure() bool {
+	return pressure.isUnderPressure()
+}
@@ -0,0 +1,130 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPressureTracker(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		slow    int
+		failed  int
+		healthy int
+		want    bool
+	}{
+		{name: "healthy", healthy: 30, want: false},
+		{name: "few calls", slow: PressureMinCalls - 1, want: false},
+		{name: "slow", slow: 15, healthy: 10, want: true},
+		{name: "failed", failed: 10, healthy: 20, want: true},
+	}
+
+	for _, test := range tests {
+		p := &pressureTracker{}
+		now := base
+		for i := 0; i < test.slow; i++ {
+			p.record(now, PressureSlowCall, false)
+		}
+		for i := 0; i < test.failed; i++ {
+			p.record(now, 0, true)
+		}
+		for i := 0; i < test.healthy; i++ {
+			p.record(now, 0, false)
+		}
+		if got := p.isUnderPressure(); got != test.want {
+			t.Errorf("%s: want %t, but got %t", test.name, test.want, got)
+		}
+	}
+}
+
+func TestPressureTracker_Recover(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := &pressureTracker{}
+
+	for i := 0; i < PressureMinCalls; i++ {
+		p.record(base, 0, true)
+	}
+	if !p.isUnderPressure() {
+		t.Fatalf("must be under pressure")
+	}
+
+	// healthy calls in a window are not enough, failures are still in window
+	p.record(base.Add(PressureWindow/2), 0, false)
+	if !p.isUnderPressure() {
+		t.Fatalf("must be under pressure before a whole window is passed")
+	}
+
+	p.record(base.Add(PressureWindow/2+PressureWindow+time.Second), 0, false)
+	if p.isUnderPressure() {
+		t.Fatalf("must be recovered after a whole window is under thresholds")
+	}
+}
+
+func TestPressureTracker_ShouldWrite(t *testing.T) {
+	p := &pressureTracker{}
+	if !p.shouldWrite() {
+		t.Fatalf("all records must be written if not under pressure")
+	}
+
+	now := time.Now()
+	for i := 0; i < PressureMinCalls; i++ {
+		p.record(now, 0, true)
+	}
+	var written int
+	for i := 0; i < PressureSampleRate*3; i++ {
+		if p.shouldWrite() {
+			written++
+		}
+	}
+	if written != 3 {
+		t.Fatalf("want 3 records are written, but got %d", written)
+	}
+}
+
+func TestIsFailure(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: ErrNotFound, want: false},
+		{err: fmt.Errorf("failed to update runner: %w", ErrConflict), want: false},
+		{err: fmt.Errorf("failed to execute query: %w", context.Canceled), want: false},
+		{err: context.DeadlineExceeded, want: true},
+		{err: errors.New("connection refused"), want: true},
+	}
+
+	for _, test := range tests {
+		if got := isFailure(test.err); got != test.want {
+			t.Errorf("isFailure(%+v) want %t, but got %t", test.err, test.want, got)
+		}
+	}
+}
+
+func TestPressureTracker_Window(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := &pressureTracker{}
+
+	// failures are spread in window, they are counted together
+	for i := 0; i < PressureMinCalls; i++ {
+		p.record(base.Add(time.Duration(i)*PressureWindow/time.Duration(2*PressureMinCalls)), 0, true)
+	}
+	if !p.isUnderPressure() {
+		t.Fatalf("must be under pressure")
+	}
+
+	// failures that are out of window are not counted
+	p = &pressureTracker{}
+	for i := 0; i < PressureMinCalls-1; i++ {
+		p.record(base, 0, true)
+	}
+	p.record(base.Add(PressureWindow+time.Second), 0, true)
+	if p.isUnderPressure() {
+		t.Fatalf("failures out of window must not be counted")
+	}
+}
@@ -0,0 +1,29 @@
+package datastore
+
+import "strings"
+
+// PurgeResult is number of deleted records by PurgeScope
+type PurgeResult struct {
+	Targets int64 `json:"targets"`
+	Runners int64 `json:"runners"`
+	Jobs    int64 `json:"jobs"`
+	// Histories is number of state histories and job histories
+	Histories int64 `json:"histories"`
+}
+
+// InScope return true if targetScope is scope, or a repository in organization of scope
+func InScope(targetScope, scope string) bool {
+	if targetScope == scope {
+		return true
+	}
+	return !strings.Contains(scope, "/") && strings.HasPrefix(targetScope, scope+"/")
+}
+
+// ScopeCondition return condition of scope column for InScope and args of it
+func ScopeCondition(column, scope string) (string, []interface{}) {
+	if strings.Contains(scope, "/") {
+		return column + " = ?", []interface{}{scope}
+	}
+	// "_" in organization name is not escaped in LIKE, but it is not allowed in GitHub
+	return "(" + column + " = ? OR " + column + " LIKE ?)", []interface{}{scope, scope + "/%"}
+}
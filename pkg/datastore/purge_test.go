@@ -0,0 +1,24 @@
+package datastore
+
+import "testing"
+
+func TestInScope(t *testing.T) {
+	tests := []struct {
+		targetScope string
+		scope       string
+		want        bool
+	}{
+		{targetScope: "octocat", scope: "octocat", want: true},
+		{targetScope: "octocat/hello-world", scope: "octocat", want: true},
+		{targetScope: "octocat/hello-world", scope: "octocat/hello-world", want: true},
+		{targetScope: "octocat", scope: "octocat/hello-world", want: false},
+		{targetScope: "octocat/hello-world-2", scope: "octocat/hello-world", want: false},
+		{targetScope: "octocat-other", scope: "octocat", want: false},
+	}
+
+	for _, test := range tests {
+		if got := InScope(test.targetScope, test.scope); got != test.want {
+			t.Errorf("InScope(%q, %q) must be %t, but got %t", test.targetScope, test.scope, test.want, got)
+		}
+	}
+}
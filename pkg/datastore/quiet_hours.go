@@ -0,0 +1,107 @@
+package datastore
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// QuietHours is time-of-day windows that runner manager defer non-urgent deletions of runners in target
+type QuietHours struct {
+	TimeZone string       `json:"timezone"` // IANA time zone name, empty is UTC
+	Windows  []TimeWindow `json:"windows"`
+}
+
+// TimeWindow is a time-of-day window, format of start and end is "15:04".
+// window over midnight (e.g. 22:00 - 06:00) is allowed, same start and end is all day.
+type TimeWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// ParseTimeOfDay parse "15:04" to minutes from midnight
+func ParseTimeOfDay(in string) (int, error) {
+	t, err := time.Parse("15:04", in)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse time of day %s: %w", in, err)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// InTimeWindow return true if minute is in window from start to end (minutes from midnight)
+func InTimeWindow(start, end, minute int) bool {
+	switch {
+	case start == end:
+		// all day
+		return true
+	case start < end:
+		return start <= minute && minute < end
+	default:
+		// over midnight
+		return start <= minute || minute < end
+	}
+}
+
+// ParseQuietHours parse value of Target.QuietHours
+func ParseQuietHours(raw []byte) (*QuietHours, error) {
+	var q QuietHours
+	if err := json.Unmarshal(raw, &q); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal quiet hours: %w", err)
+	}
+	if _, err := q.location(); err != nil {
+		return nil, err
+	}
+	if len(q.Windows) == 0 {
+		return nil, fmt.Errorf("windows must be set")
+	}
+	for _, w := range q.Windows {
+		if _, err := ParseTimeOfDay(w.Start); err != nil {
+			return nil, fmt.Errorf("invalid start: %w", err)
+		}
+		if _, err := ParseTimeOfDay(w.End); err != nil {
+			return nil, fmt.Errorf("invalid end: %w", err)
+		}
+	}
+	return &q, nil
+}
+
+// ValidateQuietHours check format of quiet hours
+func ValidateQuietHours(raw []byte) error {
+	_, err := ParseQuietHours(raw)
+	return err
+}
+
+func (q QuietHours) location() (*time.Location, error) {
+	if q.TimeZone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(q.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %s: %w", q.TimeZone, err)
+	}
+	return loc, nil
+}
+
+// Contains return true if now is in one of windows
+func (q QuietHours) Contains(now time.Time) bool {
+	loc, err := q.location()
+	if err != nil {
+		return false
+	}
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	for _, w := range q.Windows {
+		start, err := ParseTimeOfDay(w.Start)
+		if err != nil {
+			continue
+		}
+		end, err := ParseTimeOfDay(w.End)
+		if err != nil {
+			continue
+		}
+		if InTimeWindow(start, end, minute) {
+			return true
+		}
+	}
+	return false
+}
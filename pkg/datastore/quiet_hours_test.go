@@ -0,0 +1,53 @@
+package datastore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateQuietHours(t *testing.T) {
+	tests := []struct {
+		input string
+		err   bool
+	}{
+		{input: `{"windows": [{"start": "09:00", "end": "18:00"}]}`, err: false},
+		{input: `{"timezone": "Asia/Tokyo", "windows": [{"start": "22:00", "end": "06:00"}]}`, err: false},
+		{input: `{"windows": []}`, err: true},
+		{input: `{"windows": [{"start": "9am", "end": "18:00"}]}`, err: true},
+		{input: `{"timezone": "Not/Exist", "windows": [{"start": "09:00", "end": "18:00"}]}`, err: true},
+		{input: `[]`, err: true},
+	}
+
+	for _, test := range tests {
+		err := ValidateQuietHours([]byte(test.input))
+		if !test.err && err != nil {
+			t.Fatalf("failed to validate (input: %s): %+v", test.input, err)
+		}
+		if test.err && err == nil {
+			t.Fatalf("must be error, but not error (input: %s)", test.input)
+		}
+	}
+}
+
+func TestQuietHours_Contains(t *testing.T) {
+	q := QuietHours{Windows: []TimeWindow{{Start: "09:00", End: "18:00"}, {Start: "23:00", End: "01:00"}}}
+
+	tests := []struct {
+		now  time.Time
+		want bool
+	}{
+		{now: time.Date(2024, 1, 1, 8, 59, 0, 0, time.UTC), want: false},
+		{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), want: true},
+		{now: time.Date(2024, 1, 1, 17, 59, 0, 0, time.UTC), want: true},
+		{now: time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC), want: false},
+		{now: time.Date(2024, 1, 1, 23, 30, 0, 0, time.UTC), want: true},
+		{now: time.Date(2024, 1, 2, 0, 30, 0, 0, time.UTC), want: true},
+		{now: time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC), want: false},
+	}
+
+	for _, test := range tests {
+		if got := q.Contains(test.now); got != test.want {
+			t.Errorf("%s: want %t, but got %t", test.now, test.want, got)
+		}
+	}
+}
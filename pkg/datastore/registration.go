@@ -0,0 +1,23 @@
+package datastore
+
+import "fmt"
+
+// RunnerRegistration is method of registering runner to GitHub
+type RunnerRegistration string
+
+// RunnerRegistration values
+const (
+	// RunnerRegistrationToken is registration token, it is default (empty value)
+	RunnerRegistrationToken RunnerRegistration = "token"
+	// RunnerRegistrationJIT is just-in-time config, it is single-use and bound to a runner
+	RunnerRegistrationJIT RunnerRegistration = "jit"
+)
+
+// ValidateRunnerRegistration check method is supported, empty is valid as RunnerRegistrationToken
+func ValidateRunnerRegistration(registration RunnerRegistration) error {
+	switch registration {
+	case "", RunnerRegistrationToken, RunnerRegistrationJIT:
+		return nil
+	}
+	return fmt.Errorf("runner_registration must be one of %s, %s (got: %s)", RunnerRegistrationToken, RunnerRegistrationJIT, registration)
+}
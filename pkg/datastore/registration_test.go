@@ -0,0 +1,25 @@
+package datastore
+
+import "testing"
+
+func TestValidateRunnerRegistration(t *testing.T) {
+	tests := []struct {
+		input RunnerRegistration
+		err   bool
+	}{
+		{input: "", err: false},
+		{input: RunnerRegistrationToken, err: false},
+		{input: RunnerRegistrationJIT, err: false},
+		{input: "jitconfig", err: true},
+	}
+
+	for _, test := range tests {
+		err := ValidateRunnerRegistration(test.input)
+		if !test.err && err != nil {
+			t.Fatalf("must not be error (input: %s): %+v", test.input, err)
+		}
+		if test.err && err == nil {
+			t.Fatalf("must be error (input: %s)", test.input)
+		}
+	}
+}
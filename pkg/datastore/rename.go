@@ -0,0 +1,12 @@
+package datastore
+
+import "strings"
+
+// RenamedScope return scope of target after scope is renamed to newScope.
+// targetScope that is not in scope (see InScope) is returned as it is
+func RenamedScope(targetScope, scope, newScope string) string {
+	if !InScope(targetScope, scope) {
+		return targetScope
+	}
+	return newScope + strings.TrimPrefix(targetScope, scope)
+}
@@ -0,0 +1,25 @@
+package datastore
+
+import "testing"
+
+func TestRenamedScope(t *testing.T) {
+	tests := []struct {
+		targetScope string
+		scope       string
+		newScope    string
+		want        string
+	}{
+		{targetScope: "octocat/hello-world", scope: "octocat/hello-world", newScope: "octocat/hello", want: "octocat/hello"},
+		{targetScope: "octocat/hello-world", scope: "octocat/hello-world", newScope: "octo-org/hello-world", want: "octo-org/hello-world"},
+		{targetScope: "octocat", scope: "octocat", newScope: "octo-org", want: "octo-org"},
+		{targetScope: "octocat/hello-world", scope: "octocat", newScope: "octo-org", want: "octo-org/hello-world"},
+		{targetScope: "octocat-other", scope: "octocat", newScope: "octo-org", want: "octocat-other"},
+		{targetScope: "octocat", scope: "octocat/hello-world", newScope: "octocat/hello", want: "octocat"},
+	}
+
+	for _, test := range tests {
+		if got := RenamedScope(test.targetScope, test.scope, test.newScope); got != test.want {
+			t.Errorf("RenamedScope(%q, %q, %q) must be %q, but got %q", test.targetScope, test.scope, test.newScope, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package datastore
+
+import "context"
+
+type readReplicaKey struct{}
+
+// WithReadReplica mark ctx that queries can be served by read replica.
+// use it for read-only calls that allow stale data by replication lag (e.g. metrics, list APIs).
+func WithReadReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readReplicaKey{}, true)
+}
+
+// CanUseReadReplica return true if ctx is marked by WithReadReplica
+func CanUseReadReplica(ctx context.Context) bool {
+	v, ok := ctx.Value(readReplicaKey{}).(bool)
+	return ok && v
+}
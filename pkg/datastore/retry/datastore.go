@@ -0,0 +1,389 @@
+package retry
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// CreateTarget call CreateTarget with retry
+func (d *Datastore) CreateTarget(ctx context.Context, target datastore.Target) error {
+	return doErr(ctx, d, "CreateTarget", func() error {
+		return d.Datastore.CreateTarget(ctx, target)
+	})
+}
+
+// GetTarget call GetTarget with retry
+func (d *Datastore) GetTarget(ctx context.Context, id uuid.UUID) (*datastore.Target, error) {
+	return do(ctx, d, "GetTarget", func() (*datastore.Target, error) {
+		return d.Datastore.GetTarget(ctx, id)
+	})
+}
+
+// GetTargetByScope call GetTargetByScope with retry
+func (d *Datastore) GetTargetByScope(ctx context.Context, gheDomain, scope string) (*datastore.Target, error) {
+	return do(ctx, d, "GetTargetByScope", func() (*datastore.Target, error) {
+		return d.Datastore.GetTargetByScope(ctx, gheDomain, scope)
+	})
+}
+
+// ListTargets call ListTargets with retry
+func (d *Datastore) ListTargets(ctx context.Context, opt datastore.ListOption) ([]datastore.Target, error) {
+	return do(ctx, d, "ListTargets", func() ([]datastore.Target, error) {
+		return d.Datastore.ListTargets(ctx, opt)
+	})
+}
+
+// ListDeletedTargets call ListDeletedTargets with retry
+func (d *Datastore) ListDeletedTargets(ctx context.Context, opt datastore.ListOption) ([]datastore.Target, error) {
+	return do(ctx, d, "ListDeletedTargets", func() ([]datastore.Target, error) {
+		return d.Datastore.ListDeletedTargets(ctx, opt)
+	})
+}
+
+// ListTargetsByExternalRef call ListTargetsByExternalRef with retry
+func (d *Datastore) ListTargetsByExternalRef(ctx context.Context, externalRef string) ([]datastore.Target, error) {
+	return do(ctx, d, "ListTargetsByExternalRef", func() ([]datastore.Target, error) {
+		return d.Datastore.ListTargetsByExternalRef(ctx, externalRef)
+	})
+}
+
+// DeleteTarget call DeleteTarget with retry
+func (d *Datastore) DeleteTarget(ctx context.Context, id uuid.UUID) error {
+	return doErr(ctx, d, "DeleteTarget", func() error {
+		return d.Datastore.DeleteTarget(ctx, id)
+	})
+}
+
+// RestoreTarget call RestoreTarget with retry
+func (d *Datastore) RestoreTarget(ctx context.Context, id uuid.UUID) error {
+	return doErr(ctx, d, "RestoreTarget", func() error {
+		return d.Datastore.RestoreTarget(ctx, id)
+	})
+}
+
+// UpdateTargetStatus call UpdateTargetStatus with retry
+func (d *Datastore) UpdateTargetStatus(ctx context.Context, targetID uuid.UUID, newStatus datastore.TargetStatus, description string) error {
+	return doErr(ctx, d, "UpdateTargetStatus", func() error {
+		return d.Datastore.UpdateTargetStatus(ctx, targetID, newStatus, description)
+	})
+}
+
+// UpdateToken call UpdateToken with retry
+func (d *Datastore) UpdateToken(ctx context.Context, targetID uuid.UUID, newToken string, newExpiredAt time.Time) error {
+	return doErr(ctx, d, "UpdateToken", func() error {
+		return d.Datastore.UpdateToken(ctx, targetID, newToken, newExpiredAt)
+	})
+}
+
+// UpdateTargetParam call UpdateTargetParam with retry
+func (d *Datastore) UpdateTargetParam(ctx context.Context, targetID uuid.UUID, newResourceType datastore.ResourceType, newProviderURL sql.NullString) error {
+	return doErr(ctx, d, "UpdateTargetParam", func() error {
+		return d.Datastore.UpdateTargetParam(ctx, targetID, newResourceType, newProviderURL)
+	})
+}
+
+// UpdateTargetPlacementParams call UpdateTargetPlacementParams with retry
+func (d *Datastore) UpdateTargetPlacementParams(ctx context.Context, targetID uuid.UUID, newPlacementParams sql.NullString) error {
+	return doErr(ctx, d, "UpdateTargetPlacementParams", func() error {
+		return d.Datastore.UpdateTargetPlacementParams(ctx, targetID, newPlacementParams)
+	})
+}
+
+// UpdateTargetUserDataFormat call UpdateTargetUserDataFormat with retry
+func (d *Datastore) UpdateTargetUserDataFormat(ctx context.Context, targetID uuid.UUID, newFormat datastore.UserDataFormat) error {
+	return doErr(ctx, d, "UpdateTargetUserDataFormat", func() error {
+		return d.Datastore.UpdateTargetUserDataFormat(ctx, targetID, newFormat)
+	})
+}
+
+// UpdateTargetRunnerRegistration call UpdateTargetRunnerRegistration with retry
+func (d *Datastore) UpdateTargetRunnerRegistration(ctx context.Context, targetID uuid.UUID, newRegistration datastore.RunnerRegistration) error {
+	return doErr(ctx, d, "UpdateTargetRunnerRegistration", func() error {
+		return d.Datastore.UpdateTargetRunnerRegistration(ctx, targetID, newRegistration)
+	})
+}
+
+// UpdateTargetRunnerLabels call UpdateTargetRunnerLabels with retry
+func (d *Datastore) UpdateTargetRunnerLabels(ctx context.Context, targetID uuid.UUID, newLabels sql.NullString) error {
+	return doErr(ctx, d, "UpdateTargetRunnerLabels", func() error {
+		return d.Datastore.UpdateTargetRunnerLabels(ctx, targetID, newLabels)
+	})
+}
+
+// UpdateTargetQuietHours call UpdateTargetQuietHours with retry
+func (d *Datastore) UpdateTargetQuietHours(ctx context.Context, targetID uuid.UUID, newQuietHours sql.NullString) error {
+	return doErr(ctx, d, "UpdateTargetQuietHours", func() error {
+		return d.Datastore.UpdateTargetQuietHours(ctx, targetID, newQuietHours)
+	})
+}
+
+// UpdateTargetRootless call UpdateTargetRootless with retry
+func (d *Datastore) UpdateTargetRootless(ctx context.Context, targetID uuid.UUID, newRuntime datastore.RootlessRuntime) error {
+	return doErr(ctx, d, "UpdateTargetRootless", func() error {
+		return d.Datastore.UpdateTargetRootless(ctx, targetID, newRuntime)
+	})
+}
+
+// UpdateTargetJobFilters call UpdateTargetJobFilters with retry
+func (d *Datastore) UpdateTargetJobFilters(ctx context.Context, targetID uuid.UUID, newJobFilters sql.NullString) error {
+	return doErr(ctx, d, "UpdateTargetJobFilters", func() error {
+		return d.Datastore.UpdateTargetJobFilters(ctx, targetID, newJobFilters)
+	})
+}
+
+// ExportTargets call ExportTargets with retry
+func (d *Datastore) ExportTargets(ctx context.Context) ([]datastore.Target, error) {
+	return do(ctx, d, "ExportTargets", func() ([]datastore.Target, error) {
+		return d.Datastore.ExportTargets(ctx)
+	})
+}
+
+// ImportTargets call ImportTargets with retry
+func (d *Datastore) ImportTargets(ctx context.Context, targets []datastore.Target) (int64, error) {
+	return do(ctx, d, "ImportTargets", func() (int64, error) {
+		return d.Datastore.ImportTargets(ctx, targets)
+	})
+}
+
+// PurgeScope call PurgeScope with retry
+func (d *Datastore) PurgeScope(ctx context.Context, scope string) (*datastore.PurgeResult, error) {
+	return do(ctx, d, "PurgeScope", func() (*datastore.PurgeResult, error) {
+		return d.Datastore.PurgeScope(ctx, scope)
+	})
+}
+
+// RenameScope call RenameScope with retry
+func (d *Datastore) RenameScope(ctx context.Context, gheDomain, scope, newScope string) (int64, error) {
+	return do(ctx, d, "RenameScope", func() (int64, error) {
+		return d.Datastore.RenameScope(ctx, gheDomain, scope, newScope)
+	})
+}
+
+// EnqueueJob call EnqueueJob with retry
+func (d *Datastore) EnqueueJob(ctx context.Context, job datastore.Job) (*datastore.Job, error) {
+	return do(ctx, d, "EnqueueJob", func() (*datastore.Job, error) {
+		return d.Datastore.EnqueueJob(ctx, job)
+	})
+}
+
+// GetJobByDedupKey call GetJobByDedupKey with retry
+func (d *Datastore) GetJobByDedupKey(ctx context.Context, dedupKey string) (*datastore.Job, error) {
+	return do(ctx, d, "GetJobByDedupKey", func() (*datastore.Job, error) {
+		return d.Datastore.GetJobByDedupKey(ctx, dedupKey)
+	})
+}
+
+// ListJobs call ListJobs with retry
+func (d *Datastore) ListJobs(ctx context.Context, opt datastore.ListOption) ([]datastore.Job, error) {
+	return do(ctx, d, "ListJobs", func() ([]datastore.Job, error) {
+		return d.Datastore.ListJobs(ctx, opt)
+	})
+}
+
+// ListReadyJobs call ListReadyJobs with retry
+func (d *Datastore) ListReadyJobs(ctx context.Context, now time.Time) ([]datastore.Job, error) {
+	return do(ctx, d, "ListReadyJobs", func() ([]datastore.Job, error) {
+		return d.Datastore.ListReadyJobs(ctx, now)
+	})
+}
+
+// CountPendingJobs call CountPendingJobs with retry
+func (d *Datastore) CountPendingJobs(ctx context.Context, targetID uuid.UUID) (int, error) {
+	return do(ctx, d, "CountPendingJobs", func() (int, error) {
+		return d.Datastore.CountPendingJobs(ctx, targetID)
+	})
+}
+
+// OldestPendingJobAge call OldestPendingJobAge with retry
+func (d *Datastore) OldestPendingJobAge(ctx context.Context) (time.Duration, error) {
+	return do(ctx, d, "OldestPendingJobAge", func() (time.Duration, error) {
+		return d.Datastore.OldestPendingJobAge(ctx)
+	})
+}
+
+// ListJobsByExternalRef call ListJobsByExternalRef with retry
+func (d *Datastore) ListJobsByExternalRef(ctx context.Context, externalRef string) ([]datastore.Job, error) {
+	return do(ctx, d, "ListJobsByExternalRef", func() ([]datastore.Job, error) {
+		return d.Datastore.ListJobsByExternalRef(ctx, externalRef)
+	})
+}
+
+// DeferJob call DeferJob with retry
+func (d *Datastore) DeferJob(ctx context.Context, id uuid.UUID, notBefore time.Time) error {
+	return doErr(ctx, d, "DeferJob", func() error {
+		return d.Datastore.DeferJob(ctx, id, notBefore)
+	})
+}
+
+// DeleteJob call DeleteJob with retry
+func (d *Datastore) DeleteJob(ctx context.Context, id uuid.UUID) error {
+	return doErr(ctx, d, "DeleteJob", func() error {
+		return d.Datastore.DeleteJob(ctx, id)
+	})
+}
+
+// DeleteJobsBulk call DeleteJobsBulk with retry
+func (d *Datastore) DeleteJobsBulk(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	return do(ctx, d, "DeleteJobsBulk", func() (int64, error) {
+		return d.Datastore.DeleteJobsBulk(ctx, ids)
+	})
+}
+
+// CreateRunner call CreateRunner with retry
+func (d *Datastore) CreateRunner(ctx context.Context, runner datastore.Runner) error {
+	return doErr(ctx, d, "CreateRunner", func() error {
+		return d.Datastore.CreateRunner(ctx, runner)
+	})
+}
+
+// ListRunners call ListRunners with retry
+func (d *Datastore) ListRunners(ctx context.Context, opt datastore.ListOption) ([]datastore.Runner, error) {
+	return do(ctx, d, "ListRunners", func() ([]datastore.Runner, error) {
+		return d.Datastore.ListRunners(ctx, opt)
+	})
+}
+
+// ListRunnersByTargetID call ListRunnersByTargetID with retry
+func (d *Datastore) ListRunnersByTargetID(ctx context.Context, targetID uuid.UUID) ([]datastore.Runner, error) {
+	return do(ctx, d, "ListRunnersByTargetID", func() ([]datastore.Runner, error) {
+		return d.Datastore.ListRunnersByTargetID(ctx, targetID)
+	})
+}
+
+// GetRunner call GetRunner with retry
+func (d *Datastore) GetRunner(ctx context.Context, id uuid.UUID) (*datastore.Runner, error) {
+	return do(ctx, d, "GetRunner", func() (*datastore.Runner, error) {
+		return d.Datastore.GetRunner(ctx, id)
+	})
+}
+
+// UpdateRunnerWithVersion call UpdateRunnerWithVersion with retry
+func (d *Datastore) UpdateRunnerWithVersion(ctx context.Context, runner datastore.Runner) error {
+	return doErr(ctx, d, "UpdateRunnerWithVersion", func() error {
+		return d.Datastore.UpdateRunnerWithVersion(ctx, runner)
+	})
+}
+
+// MarkRunnerTokenIssued call MarkRunnerTokenIssued with retry
+func (d *Datastore) MarkRunnerTokenIssued(ctx context.Context, id uuid.UUID, issuedAt time.Time) error {
+	return doErr(ctx, d, "MarkRunnerTokenIssued", func() error {
+		return d.Datastore.MarkRunnerTokenIssued(ctx, id, issuedAt)
+	})
+}
+
+// DeleteRunner call DeleteRunner with retry
+func (d *Datastore) DeleteRunner(ctx context.Context, id uuid.UUID, deletedAt time.Time, reason datastore.RunnerStatus) error {
+	return doErr(ctx, d, "DeleteRunner", func() error {
+		return d.Datastore.DeleteRunner(ctx, id, deletedAt, reason)
+	})
+}
+
+// DeleteRunnerWithVersion call DeleteRunnerWithVersion with retry
+func (d *Datastore) DeleteRunnerWithVersion(ctx context.Context, id uuid.UUID, version int64, deletedAt time.Time, reason datastore.RunnerStatus) error {
+	return doErr(ctx, d, "DeleteRunnerWithVersion", func() error {
+		return d.Datastore.DeleteRunnerWithVersion(ctx, id, version, deletedAt, reason)
+	})
+}
+
+// DeleteRunnersBulk call DeleteRunnersBulk with retry
+func (d *Datastore) DeleteRunnersBulk(ctx context.Context, runners []datastore.Runner, deletedAt time.Time, reason datastore.RunnerStatus) ([]uuid.UUID, error) {
+	return do(ctx, d, "DeleteRunnersBulk", func() ([]uuid.UUID, error) {
+		return d.Datastore.DeleteRunnersBulk(ctx, runners, deletedAt, reason)
+	})
+}
+
+// PurgeDeletedRunners call PurgeDeletedRunners with retry
+func (d *Datastore) PurgeDeletedRunners(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return do(ctx, d, "PurgeDeletedRunners", func() (int64, error) {
+		return d.Datastore.PurgeDeletedRunners(ctx, before, limit)
+	})
+}
+
+// CreateRunnerHookResult call CreateRunnerHookResult with retry
+func (d *Datastore) CreateRunnerHookResult(ctx context.Context, result datastore.RunnerHookResult) error {
+	return doErr(ctx, d, "CreateRunnerHookResult", func() error {
+		return d.Datastore.CreateRunnerHookResult(ctx, result)
+	})
+}
+
+// ListRunnerHookResults call ListRunnerHookResults with retry
+func (d *Datastore) ListRunnerHookResults(ctx context.Context, runnerID uuid.UUID) ([]datastore.RunnerHookResult, error) {
+	return do(ctx, d, "ListRunnerHookResults", func() ([]datastore.RunnerHookResult, error) {
+		return d.Datastore.ListRunnerHookResults(ctx, runnerID)
+	})
+}
+
+// CreateStateHistory call CreateStateHistory with retry
+func (d *Datastore) CreateStateHistory(ctx context.Context, history datastore.StateHistory) error {
+	return doErr(ctx, d, "CreateStateHistory", func() error {
+		return d.Datastore.CreateStateHistory(ctx, history)
+	})
+}
+
+// ListStateHistories call ListStateHistories with retry
+func (d *Datastore) ListStateHistories(ctx context.Context, resourceID uuid.UUID) ([]datastore.StateHistory, error) {
+	return do(ctx, d, "ListStateHistories", func() ([]datastore.StateHistory, error) {
+		return d.Datastore.ListStateHistories(ctx, resourceID)
+	})
+}
+
+// PurgeStateHistories call PurgeStateHistories with retry
+func (d *Datastore) PurgeStateHistories(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return do(ctx, d, "PurgeStateHistories", func() (int64, error) {
+		return d.Datastore.PurgeStateHistories(ctx, before, limit)
+	})
+}
+
+// CreateJobHistory call CreateJobHistory with retry
+func (d *Datastore) CreateJobHistory(ctx context.Context, history datastore.JobHistory) error {
+	return doErr(ctx, d, "CreateJobHistory", func() error {
+		return d.Datastore.CreateJobHistory(ctx, history)
+	})
+}
+
+// SetJobHistoryTime call SetJobHistoryTime with retry
+func (d *Datastore) SetJobHistoryTime(ctx context.Context, jobID uuid.UUID, event datastore.JobHistoryEvent, at time.Time) error {
+	return doErr(ctx, d, "SetJobHistoryTime", func() error {
+		return d.Datastore.SetJobHistoryTime(ctx, jobID, event, at)
+	})
+}
+
+// SetJobHistoryTimeByGitHubJobID call SetJobHistoryTimeByGitHubJobID with retry
+func (d *Datastore) SetJobHistoryTimeByGitHubJobID(ctx context.Context, githubJobID int64, event datastore.JobHistoryEvent, at time.Time) error {
+	return doErr(ctx, d, "SetJobHistoryTimeByGitHubJobID", func() error {
+		return d.Datastore.SetJobHistoryTimeByGitHubJobID(ctx, githubJobID, event, at)
+	})
+}
+
+// ListJobHistories call ListJobHistories with retry
+func (d *Datastore) ListJobHistories(ctx context.Context, targetID uuid.UUID, since time.Time, limit int) ([]datastore.JobHistory, error) {
+	return do(ctx, d, "ListJobHistories", func() ([]datastore.JobHistory, error) {
+		return d.Datastore.ListJobHistories(ctx, targetID, since, limit)
+	})
+}
+
+// PurgeJobHistories call PurgeJobHistories with retry
+func (d *Datastore) PurgeJobHistories(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return do(ctx, d, "PurgeJobHistories", func() (int64, error) {
+		return d.Datastore.PurgeJobHistories(ctx, before, limit)
+	})
+}
+
+// GetLock call GetLock with retry
+func (d *Datastore) GetLock(ctx context.Context) error {
+	return doErr(ctx, d, "GetLock", func() error {
+		return d.Datastore.GetLock(ctx)
+	})
+}
+
+// IsLocked call IsLocked with retry
+func (d *Datastore) IsLocked(ctx context.Context) (string, error) {
+	return do(ctx, d, "IsLocked", func() (string, error) {
+		return d.Datastore.IsLocked(ctx)
+	})
+}
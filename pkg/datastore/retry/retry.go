@@ -0,0 +1,88 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// MaxBackoff is max duration of waiting between retries
+var MaxBackoff = 5 * time.Second
+
+var (
+	retries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "myshoes",
+		Subsystem: "datastore",
+		Name:      "retries_total",
+		Help:      "Total number of retries for transient errors per method.",
+	}, []string{"method"})
+	exhausted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "myshoes",
+		Subsystem: "datastore",
+		Name:      "retries_exhausted_total",
+		Help:      "Total number of calls that failed after all retries per method.",
+	}, []string{"method"})
+)
+
+func init() {
+	prometheus.MustRegister(retries, exhausted)
+}
+
+// Datastore is datastore.Datastore that retry calls with backoff if error is transient (e.g. deadlock, failover)
+type Datastore struct {
+	datastore.Datastore
+
+	isTransient func(error) bool
+	maxRetries  int
+	backoff     time.Duration
+}
+
+// Wrap wrap datastore by retry. isTransient classify errors that is resolved by retry.
+// backoff is doubled in each retry up to MaxBackoff.
+func Wrap(ds datastore.Datastore, isTransient func(error) bool, maxRetries int, backoff time.Duration) *Datastore {
+	return &Datastore{
+		Datastore:   ds,
+		isTransient: isTransient,
+		maxRetries:  maxRetries,
+		backoff:     backoff,
+	}
+}
+
+// do call fn until it succeeds, non transient error is returned, or retries is exhausted
+func do[T any](ctx context.Context, d *Datastore, method string, fn func() (T, error)) (T, error) {
+	wait := d.backoff
+	for i := 0; ; i++ {
+		result, err := fn()
+		if err == nil || errors.Is(err, datastore.ErrNotFound) || errors.Is(err, datastore.ErrConflict) || !d.isTransient(err) {
+			return result, err
+		}
+		if i >= d.maxRetries {
+			exhausted.WithLabelValues(method).Inc()
+			return result, err
+		}
+
+		retries.WithLabelValues(method).Inc()
+		logger.Logf(true, "transient error in %s, will retry after %s (%d/%d): %+v", method, wait, i+1, d.maxRetries, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return result, err
+		}
+		wait *= 2
+		if wait > MaxBackoff {
+			wait = MaxBackoff
+		}
+	}
+}
+
+func doErr(ctx context.Context, d *Datastore, method string, fn func() error) error {
+	_, err := do(ctx, d, method, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
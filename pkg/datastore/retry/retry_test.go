@@ -0,0 +1,67 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
+)
+
+var errTransient = errors.New("transient")
+
+// flaky is datastore that GetTarget fail until failures is zero
+type flaky struct {
+	datastore.Datastore
+
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flaky) GetTarget(ctx context.Context, id uuid.UUID) (*datastore.Target, error) {
+	f.calls++
+	if f.failures > 0 {
+		f.failures--
+		return nil, f.err
+	}
+	return f.Datastore.GetTarget(ctx, id)
+}
+
+func TestDatastore_GetTarget(t *testing.T) {
+	testTargetID := uuid.FromStringOrNil("8cc1a4d5-4bf1-43d5-8d8f-3a3b7c5a4f7e")
+	isTransient := func(err error) bool { return errors.Is(err, errTransient) }
+
+	tests := []struct {
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   error
+	}{
+		{failures: 0, err: errTransient, wantCalls: 1, wantErr: nil},
+		{failures: 2, err: errTransient, wantCalls: 3, wantErr: nil},
+		{failures: 5, err: errTransient, wantCalls: 4, wantErr: errTransient},
+		{failures: 2, err: datastore.ErrNotFound, wantCalls: 1, wantErr: datastore.ErrNotFound},
+	}
+
+	for _, test := range tests {
+		m, _ := memory.New(nil)
+		if err := m.CreateTarget(context.Background(), datastore.Target{UUID: testTargetID, Scope: "octocat"}); err != nil {
+			t.Fatalf("failed to create target: %+v", err)
+		}
+		f := &flaky{Datastore: m, failures: test.failures, err: test.err}
+		ds := Wrap(f, isTransient, 3, time.Millisecond)
+
+		_, err := ds.GetTarget(context.Background(), testTargetID)
+		if !errors.Is(err, test.wantErr) {
+			t.Fatalf("want error %+v, but got %+v", test.wantErr, err)
+		}
+		if f.calls != test.wantCalls {
+			t.Fatalf("want %d calls, but got %d", test.wantCalls, f.calls)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package datastore
+
+import "fmt"
+
+// RootlessRuntime is container runtime that runs under non-root runner user with user namespaces
+type RootlessRuntime string
+
+// RootlessRuntime values
+const (
+	// RootlessRuntimeNone is rootful docker that runner user belongs to docker group, it is default (empty value)
+	RootlessRuntimeNone RootlessRuntime = ""
+	// RootlessRuntimeDocker is rootless Docker, dockerd runs as a systemd user service of runner user
+	RootlessRuntimeDocker RootlessRuntime = "docker"
+	// RootlessRuntimePodman is rootless Podman, Docker-compatible socket runs as a systemd user service of runner user
+	RootlessRuntimePodman RootlessRuntime = "podman"
+)
+
+// ValidateRootlessRuntime check runtime is supported, empty is valid as RootlessRuntimeNone
+func ValidateRootlessRuntime(runtime RootlessRuntime) error {
+	switch runtime {
+	case RootlessRuntimeNone, RootlessRuntimeDocker, RootlessRuntimePodman:
+		return nil
+	}
+	return fmt.Errorf("rootless must be one of %s, %s (got: %s)", RootlessRuntimeDocker, RootlessRuntimePodman, runtime)
+}
@@ -0,0 +1,25 @@
+package datastore
+
+import "testing"
+
+func TestValidateRootlessRuntime(t *testing.T) {
+	tests := []struct {
+		input RootlessRuntime
+		err   bool
+	}{
+		{input: RootlessRuntimeNone, err: false},
+		{input: RootlessRuntimeDocker, err: false},
+		{input: RootlessRuntimePodman, err: false},
+		{input: "containerd", err: true},
+	}
+
+	for _, test := range tests {
+		err := ValidateRootlessRuntime(test.input)
+		if !test.err && err != nil {
+			t.Fatalf("must not be error (input: %s): %+v", test.input, err)
+		}
+		if test.err && err == nil {
+			t.Fatalf("must be error (input: %s)", test.input)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// CreateStateHistory record a status transition of job or runner
+func (s *SQLite) CreateStateHistory(ctx context.Context, history datastore.StateHistory) error {
+	query := `INSERT INTO state_histories(resource_type, resource_id, status, reason) VALUES (?, ?, ?, ?)`
+	if _, err := s.Conn.ExecContext(ctx, query, history.ResourceType, history.ResourceID.String(), history.Status, history.Reason); err != nil {
+		return fmt.Errorf("failed to execute INSERT query: %w", err)
+	}
+
+	return nil
+}
+
+// ListStateHistories get status transitions of job or runner
+func (s *SQLite) ListStateHistories(ctx context.Context, resourceID uuid.UUID) ([]datastore.StateHistory, error) {
+	var histories []datastore.StateHistory
+	query := `SELECT resource_type, resource_id, status, reason, created_at FROM state_histories WHERE resource_id = ? ORDER BY created_at, id`
+	if err := s.Conn.SelectContext(ctx, &histories, query, resourceID.String()); err != nil {
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	return histories, nil
+}
+
+// PurgeStateHistories delete histories that created before `before`
+func (s *SQLite) PurgeStateHistories(ctx context.Context, before time.Time, limit int) (int64, error) {
+	query := `DELETE FROM state_histories WHERE id IN (SELECT id FROM state_histories WHERE created_at < ? ORDER BY created_at LIMIT ?)`
+	result, err := s.Conn.ExecContext(ctx, query, before.UTC(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute DELETE query: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return deleted, nil
+}
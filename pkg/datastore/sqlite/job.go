@@ -0,0 +1,168 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// EnqueueJob add a job, return the existing job if job that has same dedup_key is already enqueued
+func (s *SQLite) EnqueueJob(ctx context.Context, job datastore.Job) (*datastore.Job, error) {
+	query := `INSERT INTO jobs(uuid, ghe_domain, repository, check_event, target_id, not_before, external_ref, dedup_key) VALUES (?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT(dedup_key) DO NOTHING`
+	result, err := s.Conn.ExecContext(ctx, query, job.UUID, job.GHEDomain, job.Repository, job.CheckEventJSON, job.TargetID.String(), job.NotBefore, job.ExternalRef, job.DedupKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute INSERT query: %w", err)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if inserted == 0 {
+		// already enqueued
+		return s.GetJobByDedupKey(ctx, job.DedupKey.String)
+	}
+
+	select {
+	case s.notifyEnqueueCh <- struct{}{}:
+		// notified to starter
+	default:
+		// no capacity on channel, do not block
+	}
+
+	return &job, nil
+}
+
+// GetJobByDedupKey get a job in queue that has dedup_key
+func (s *SQLite) GetJobByDedupKey(ctx context.Context, dedupKey string) (*datastore.Job, error) {
+	var job datastore.Job
+	query := `SELECT uuid, ghe_domain, repository, check_event, target_id, not_before, external_ref, dedup_key, created_at, updated_at FROM jobs WHERE dedup_key = ?`
+	if err := s.Conn.GetContext(ctx, &job, query, dedupKey); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datastore.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	return &job, nil
+}
+
+// ListJobs get a page of jobs
+func (s *SQLite) ListJobs(ctx context.Context, opt datastore.ListOption) ([]datastore.Job, error) {
+	var jobs []datastore.Job
+	query := `SELECT uuid, ghe_domain, repository, check_event, target_id, not_before, external_ref, dedup_key, created_at, updated_at FROM jobs`
+	clause, args := opt.Clause("uuid")
+	if err := s.Conn.SelectContext(ctx, &jobs, query+clause, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datastore.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// ListReadyJobs get jobs that can dispatch at now
+func (s *SQLite) ListReadyJobs(ctx context.Context, now time.Time) ([]datastore.Job, error) {
+	var jobs []datastore.Job
+	query := `SELECT uuid, ghe_domain, repository, check_event, target_id, not_before, external_ref, dedup_key, created_at, updated_at FROM jobs WHERE not_before IS NULL OR not_before <= ?`
+	if err := s.Conn.SelectContext(ctx, &jobs, query, now.UTC()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datastore.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// CountPendingJobs count jobs in queue of target
+func (s *SQLite) CountPendingJobs(ctx context.Context, targetID uuid.UUID) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM jobs`
+	var args []interface{}
+	if !uuid.Equal(targetID, uuid.Nil) {
+		query += ` WHERE target_id = ?`
+		args = append(args, targetID.String())
+	}
+	if err := s.Conn.GetContext(ctx, &count, query, args...); err != nil {
+		return 0, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	return count, nil
+}
+
+// OldestPendingJobAge get elapsed time since the oldest job in queue was created
+func (s *SQLite) OldestPendingJobAge(ctx context.Context) (time.Duration, error) {
+	// MIN() lose declared type of column in SQLite, so it can not scan to time.Time
+	var oldest []time.Time
+	query := `SELECT created_at FROM jobs ORDER BY created_at LIMIT 1`
+	if err := s.Conn.SelectContext(ctx, &oldest, query); err != nil {
+		return 0, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+	if len(oldest) == 0 {
+		return 0, nil
+	}
+
+	return time.Since(oldest[0]), nil
+}
+
+// ListJobsByExternalRef get jobs that has external reference ID
+func (s *SQLite) ListJobsByExternalRef(ctx context.Context, externalRef string) ([]datastore.Job, error) {
+	var jobs []datastore.Job
+	query := `SELECT uuid, ghe_domain, repository, check_event, target_id, not_before, external_ref, dedup_key, created_at, updated_at FROM jobs WHERE external_ref = ?`
+	if err := s.Conn.SelectContext(ctx, &jobs, query, externalRef); err != nil {
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// DeferJob update not_before of job
+func (s *SQLite) DeferJob(ctx context.Context, id uuid.UUID, notBefore time.Time) error {
+	query := `UPDATE jobs SET not_before = ? WHERE uuid = ?`
+	if _, err := s.Conn.ExecContext(ctx, query, notBefore.UTC(), id.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteJob delete a job
+func (s *SQLite) DeleteJob(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM jobs WHERE uuid = ?`
+	if _, err := s.Conn.ExecContext(ctx, query, id.String()); err != nil {
+		return fmt.Errorf("failed to execute DELETE query: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteJobsBulk delete jobs by a statement
+func (s *SQLite) DeleteJobsBulk(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	query, args, err := sqlx.In(`DELETE FROM jobs WHERE uuid IN (?)`, uuidsToStrings(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create IN query: %w", err)
+	}
+	result, err := s.Conn.ExecContext(ctx, s.Conn.Rebind(query), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute DELETE query: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return deleted, nil
+}
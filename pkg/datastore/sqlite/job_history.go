@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// jobHistoryColumn return column of event in job_histories
+func jobHistoryColumn(event datastore.JobHistoryEvent) (string, error) {
+	switch event {
+	case datastore.JobHistoryRunnerCreated:
+		return "runner_created_at", nil
+	case datastore.JobHistoryRunnerRegistered:
+		return "runner_registered_at", nil
+	case datastore.JobHistoryStarted:
+		return "started_at", nil
+	case datastore.JobHistoryCompleted:
+		return "completed_at", nil
+	}
+	return "", fmt.Errorf("unknown event of job history: %s", event)
+}
+
+// CreateJobHistory record that a job is received
+func (s *SQLite) CreateJobHistory(ctx context.Context, history datastore.JobHistory) error {
+	query := `INSERT INTO job_histories(job_id, target_id, github_job_id, received_at) VALUES (?, ?, ?, ?)`
+	if _, err := s.Conn.ExecContext(ctx, query, history.JobID.String(), history.TargetID.String(), history.GitHubJobID, history.ReceivedAt.UTC()); err != nil {
+		return fmt.Errorf("failed to execute INSERT query: %w", err)
+	}
+
+	return nil
+}
+
+// SetJobHistoryTime set time of event in history of job
+func (s *SQLite) SetJobHistoryTime(ctx context.Context, jobID uuid.UUID, event datastore.JobHistoryEvent, at time.Time) error {
+	column, err := jobHistoryColumn(event)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`UPDATE job_histories SET %s = ? WHERE job_id = ? AND %s IS NULL`, column, column)
+	if _, err := s.Conn.ExecContext(ctx, query, at.UTC(), jobID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// SetJobHistoryTimeByGitHubJobID set time of event in history of job that has ID of job in GitHub
+func (s *SQLite) SetJobHistoryTimeByGitHubJobID(ctx context.Context, githubJobID int64, event datastore.JobHistoryEvent, at time.Time) error {
+	column, err := jobHistoryColumn(event)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`UPDATE job_histories SET %s = ? WHERE github_job_id = ? AND %s IS NULL`, column, column)
+	if _, err := s.Conn.ExecContext(ctx, query, at.UTC(), githubJobID); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// ListJobHistories get histories of jobs that received since `since`
+func (s *SQLite) ListJobHistories(ctx context.Context, targetID uuid.UUID, since time.Time, limit int) ([]datastore.JobHistory, error) {
+	var histories []datastore.JobHistory
+	query := `SELECT job_id, target_id, github_job_id, received_at, runner_created_at, runner_registered_at, started_at, completed_at FROM job_histories WHERE received_at >= ?`
+	args := []interface{}{since.UTC()}
+	if !uuid.Equal(targetID, uuid.Nil) {
+		query += ` AND target_id = ?`
+		args = append(args, targetID.String())
+	}
+	query += ` ORDER BY received_at DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	if err := s.Conn.SelectContext(ctx, &histories, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	return histories, nil
+}
+
+// PurgeJobHistories delete histories that received before `before`
+func (s *SQLite) PurgeJobHistories(ctx context.Context, before time.Time, limit int) (int64, error) {
+	query := `DELETE FROM job_histories WHERE job_id IN (SELECT job_id FROM job_histories WHERE received_at < ? ORDER BY received_at LIMIT ?)`
+	result, err := s.Conn.ExecContext(ctx, query, before.UTC(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute DELETE query: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,111 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+func TestSQLite_EnqueueJob(t *testing.T) {
+	notifyCh := make(chan struct{}, 1)
+	ds := newTestSQLite(t, notifyCh)
+	ctx := context.Background()
+	createTestTarget(t, ds)
+
+	job := datastore.Job{
+		UUID:           testJobID,
+		Repository:     testScopeRepo,
+		CheckEventJSON: "{}",
+		TargetID:       testTargetID,
+		DedupKey:       sql.NullString{String: "workflow_job:1", Valid: true},
+	}
+	if _, err := ds.EnqueueJob(ctx, job); err != nil {
+		t.Fatalf("failed to enqueue job: %+v", err)
+	}
+	select {
+	case <-notifyCh:
+	default:
+		t.Fatalf("enqueue is not notified")
+	}
+
+	// same dedup key return the existing job
+	job.UUID = uuid.NewV4()
+	got, err := ds.EnqueueJob(ctx, job)
+	if err != nil {
+		t.Fatalf("failed to enqueue duplicated job: %+v", err)
+	}
+	if !uuid.Equal(got.UUID, testJobID) {
+		t.Fatalf("want existing job %s, but got %s", testJobID, got.UUID)
+	}
+	select {
+	case <-notifyCh:
+		t.Fatalf("duplicated job must not be notified")
+	default:
+	}
+
+	count, err := ds.CountPendingJobs(ctx, testTargetID)
+	if err != nil {
+		t.Fatalf("failed to count pending jobs: %+v", err)
+	}
+	if count != 1 {
+		t.Fatalf("want 1 pending job, but got %d", count)
+	}
+}
+
+func TestSQLite_ListReadyJobs(t *testing.T) {
+	ds := newTestSQLite(t, nil)
+	ctx := context.Background()
+	createTestTarget(t, ds)
+	now := time.Now().UTC()
+
+	delayedID := uuid.NewV4()
+	for _, job := range []datastore.Job{
+		{UUID: testJobID, Repository: testScopeRepo, CheckEventJSON: "{}", TargetID: testTargetID},
+		{UUID: delayedID, Repository: testScopeRepo, CheckEventJSON: "{}", TargetID: testTargetID, NotBefore: sql.NullTime{Time: now.Add(1 * time.Hour), Valid: true}},
+	} {
+		if _, err := ds.EnqueueJob(ctx, job); err != nil {
+			t.Fatalf("failed to enqueue job: %+v", err)
+		}
+	}
+
+	tests := []struct {
+		now  time.Time
+		want int
+	}{
+		{now: now, want: 1},
+		{now: now.Add(2 * time.Hour), want: 2},
+	}
+	for _, test := range tests {
+		jobs, err := ds.ListReadyJobs(ctx, test.now)
+		if err != nil {
+			t.Fatalf("failed to list ready jobs: %+v", err)
+		}
+		if len(jobs) != test.want {
+			t.Errorf("want %d ready jobs at %s, but got %d", test.want, test.now, len(jobs))
+		}
+	}
+
+	if err := ds.DeferJob(ctx, testJobID, now.Add(1*time.Hour)); err != nil {
+		t.Fatalf("failed to defer job: %+v", err)
+	}
+	jobs, err := ds.ListReadyJobs(ctx, now)
+	if err != nil {
+		t.Fatalf("failed to list ready jobs: %+v", err)
+	}
+	if len(jobs) != 0 {
+		t.Fatalf("deferred job must not be ready, but got %d jobs", len(jobs))
+	}
+
+	deleted, err := ds.DeleteJobsBulk(ctx, []uuid.UUID{testJobID, delayedID, uuid.NewV4()})
+	if err != nil {
+		t.Fatalf("failed to delete jobs: %+v", err)
+	}
+	if deleted != 2 {
+		t.Fatalf("want 2 deleted jobs, but got %d", deleted)
+	}
+}
@@ -0,0 +1,18 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// GetLock get lock.
+// SQLite is for single-node deployments, so lock is always available.
+func (s *SQLite) GetLock(ctx context.Context) error {
+	return nil
+}
+
+// IsLocked return status of lock
+func (s *SQLite) IsLocked(ctx context.Context) (string, error) {
+	return datastore.IsNotLocked, nil
+}
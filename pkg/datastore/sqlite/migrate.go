@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"context"
+	"embed"
+	"fmt"
+
+	"github.com/whywaita/myshoes/pkg/datastore/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+func (s *SQLite) newMigrator() (*migrate.Migrator, error) {
+	migrations, err := migrate.Load(migrationFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	// SQLite driver can execute multiple statements, and trigger has ";" in body
+	return migrate.New(s.Conn, migrations, false), nil
+}
+
+// Migrate apply all migrations that not applied yet
+func (s *SQLite) Migrate(ctx context.Context) error {
+	migrator, err := s.newMigrator()
+	if err != nil {
+		return fmt.Errorf("failed to create migrator: %w", err)
+	}
+	return migrator.Up(ctx)
+}
+
+// MigrateDown rollback migrations, the number of steps from latest
+func (s *SQLite) MigrateDown(ctx context.Context, steps int) error {
+	migrator, err := s.newMigrator()
+	if err != nil {
+		return fmt.Errorf("failed to create migrator: %w", err)
+	}
+	return migrator.Down(ctx, steps)
+}
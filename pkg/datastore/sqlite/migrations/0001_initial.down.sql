@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS `jobs`;
+DROP TABLE IF EXISTS `runners_deleted`;
+DROP TABLE IF EXISTS `runners_running`;
+DROP TRIGGER IF EXISTS `runner_detail_updated_at`;
+DROP TABLE IF EXISTS `runner_detail`;
+DROP TABLE IF EXISTS `runners`;
+DROP TRIGGER IF EXISTS `targets_updated_at`;
+DROP TABLE IF EXISTS `targets`;
@@ -0,0 +1,74 @@
+CREATE TABLE IF NOT EXISTS `targets` (
+    `uuid` TEXT NOT NULL PRIMARY KEY,
+    `scope` TEXT NOT NULL,
+    `ghe_domain` TEXT,
+    `github_token` TEXT NOT NULL,
+    `token_expired_at` TIMESTAMP NOT NULL,
+    `resource_type` TEXT NOT NULL CHECK (`resource_type` IN ('nano', 'micro', 'small', 'medium', 'large', 'xlarge', '2xlarge', '3xlarge', '4xlarge')),
+    `provider_url` TEXT,
+    `status` TEXT NOT NULL DEFAULT 'active',
+    `status_description` TEXT,
+    `created_at` TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    UNIQUE (`ghe_domain`, `scope`)
+);
+
+CREATE TRIGGER IF NOT EXISTS `targets_updated_at` AFTER UPDATE ON `targets`
+FOR EACH ROW WHEN NEW.`updated_at` = OLD.`updated_at`
+BEGIN
+    UPDATE `targets` SET `updated_at` = current_timestamp WHERE `uuid` = OLD.`uuid`;
+END;
+
+CREATE TABLE IF NOT EXISTS `runners` (
+    `uuid` TEXT NOT NULL PRIMARY KEY,
+    `created_at` TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+CREATE TABLE IF NOT EXISTS `runner_detail` (
+    `runner_id` TEXT NOT NULL REFERENCES `runners`(`uuid`) ON DELETE RESTRICT,
+    `shoes_type` TEXT NOT NULL,
+    `ip_address` TEXT NOT NULL,
+    `target_id` TEXT NOT NULL REFERENCES `targets`(`uuid`) ON DELETE RESTRICT,
+    `cloud_id` TEXT NOT NULL,
+    `resource_type` TEXT NOT NULL CHECK (`resource_type` IN ('nano', 'micro', 'small', 'medium', 'large', 'xlarge', '2xlarge', '3xlarge', '4xlarge')),
+    `runner_user` TEXT,
+    `provider_url` TEXT,
+    `repository_url` TEXT NOT NULL,
+    `request_webhook` TEXT NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+CREATE INDEX IF NOT EXISTS `idx_runner_detail_runner_id` ON `runner_detail` (`runner_id`);
+CREATE INDEX IF NOT EXISTS `idx_runner_detail_target_id` ON `runner_detail` (`target_id`);
+
+CREATE TRIGGER IF NOT EXISTS `runner_detail_updated_at` AFTER UPDATE ON `runner_detail`
+FOR EACH ROW WHEN NEW.`updated_at` = OLD.`updated_at`
+BEGIN
+    UPDATE `runner_detail` SET `updated_at` = current_timestamp WHERE `runner_id` = OLD.`runner_id`;
+END;
+
+CREATE TABLE IF NOT EXISTS `runners_running` (
+    `runner_id` TEXT NOT NULL REFERENCES `runners`(`uuid`) ON DELETE CASCADE,
+    `created_at` TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+CREATE INDEX IF NOT EXISTS `idx_runners_running_runner_id` ON `runners_running` (`runner_id`);
+
+CREATE TABLE IF NOT EXISTS `runners_deleted` (
+    `runner_id` TEXT NOT NULL REFERENCES `runners`(`uuid`) ON DELETE CASCADE,
+    `created_at` TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    `reason` TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_runners_deleted_runner_id` ON `runners_deleted` (`runner_id`);
+
+CREATE TABLE IF NOT EXISTS `jobs` (
+    `uuid` TEXT NOT NULL PRIMARY KEY,
+    `ghe_domain` TEXT,
+    `repository` TEXT NOT NULL,
+    `check_event` TEXT NOT NULL,
+    `target_id` TEXT NOT NULL REFERENCES `targets`(`uuid`) ON DELETE RESTRICT,
+    `not_before` TIMESTAMP,
+    `created_at` TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+CREATE INDEX IF NOT EXISTS `idx_jobs_target_id` ON `jobs` (`target_id`);
+CREATE INDEX IF NOT EXISTS `idx_jobs_not_before` ON `jobs` (`not_before`);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS `idx_jobs_external_ref`;
+ALTER TABLE `jobs` DROP COLUMN `external_ref`;
+
+DROP INDEX IF EXISTS `idx_targets_external_ref`;
+ALTER TABLE `targets` DROP COLUMN `external_ref`;
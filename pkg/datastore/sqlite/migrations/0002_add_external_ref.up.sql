@@ -0,0 +1,5 @@
+ALTER TABLE `targets` ADD COLUMN `external_ref` TEXT;
+CREATE INDEX IF NOT EXISTS `idx_targets_external_ref` ON `targets` (`external_ref`);
+
+ALTER TABLE `jobs` ADD COLUMN `external_ref` TEXT;
+CREATE INDEX IF NOT EXISTS `idx_jobs_external_ref` ON `jobs` (`external_ref`);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `runner_hook_results` (
+    `runner_id` TEXT NOT NULL,
+    `event` TEXT NOT NULL,
+    `success` BOOLEAN NOT NULL,
+    `blocking` BOOLEAN NOT NULL,
+    `status_code` INTEGER NOT NULL DEFAULT 0,
+    `message` TEXT NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+CREATE INDEX IF NOT EXISTS `idx_runner_hook_results_runner_id` ON `runner_hook_results` (`runner_id`);
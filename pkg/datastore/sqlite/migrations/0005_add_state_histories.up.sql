@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `state_histories` (
+    `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+    `resource_type` TEXT NOT NULL,
+    `resource_id` TEXT NOT NULL,
+    `status` TEXT NOT NULL,
+    `reason` TEXT NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE INDEX IF NOT EXISTS `idx_state_histories_resource_id` ON `state_histories` (`resource_id`);
+CREATE INDEX IF NOT EXISTS `idx_state_histories_created_at` ON `state_histories` (`created_at`);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_jobs_dedup_key`;
+ALTER TABLE `jobs` DROP COLUMN `dedup_key`;
@@ -0,0 +1,2 @@
+ALTER TABLE `jobs` ADD COLUMN `dedup_key` TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_jobs_dedup_key` ON `jobs` (`dedup_key`);
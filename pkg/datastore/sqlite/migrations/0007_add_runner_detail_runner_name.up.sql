@@ -0,0 +1 @@
+ALTER TABLE `runner_detail` ADD COLUMN `runner_name` TEXT;
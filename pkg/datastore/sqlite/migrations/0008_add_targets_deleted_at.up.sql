@@ -0,0 +1,2 @@
+ALTER TABLE `targets` ADD COLUMN `deleted_at` TIMESTAMP;
+UPDATE `targets` SET `deleted_at` = `updated_at` WHERE `status` = 'deleted';
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `job_histories` (
+    `job_id` TEXT NOT NULL PRIMARY KEY,
+    `target_id` TEXT NOT NULL,
+    `github_job_id` INTEGER,
+    `received_at` TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    `runner_created_at` TIMESTAMP,
+    `runner_registered_at` TIMESTAMP,
+    `started_at` TIMESTAMP,
+    `completed_at` TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS `idx_job_histories_target_id` ON `job_histories` (`target_id`);
+CREATE INDEX IF NOT EXISTS `idx_job_histories_github_job_id` ON `job_histories` (`github_job_id`);
+CREATE INDEX IF NOT EXISTS `idx_job_histories_received_at` ON `job_histories` (`received_at`);
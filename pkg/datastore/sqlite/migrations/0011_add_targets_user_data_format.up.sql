@@ -0,0 +1 @@
+ALTER TABLE `targets` ADD COLUMN `user_data_format` VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE `runner_detail` ADD COLUMN `version` INTEGER NOT NULL DEFAULT 0;
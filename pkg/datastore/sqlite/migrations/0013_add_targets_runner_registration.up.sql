@@ -0,0 +1 @@
+ALTER TABLE `targets` ADD COLUMN `runner_registration` VARCHAR(255) NOT NULL DEFAULT '';
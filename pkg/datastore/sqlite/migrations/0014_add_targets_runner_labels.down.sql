@@ -0,0 +1 @@
+ALTER TABLE `targets` DROP COLUMN `runner_labels`;
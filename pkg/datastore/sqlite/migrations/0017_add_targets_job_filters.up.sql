@@ -0,0 +1 @@
+ALTER TABLE `targets` ADD COLUMN `job_filters` TEXT;
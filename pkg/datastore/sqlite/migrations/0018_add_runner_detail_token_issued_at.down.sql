@@ -0,0 +1 @@
+ALTER TABLE `runner_detail` DROP COLUMN `token_issued_at`;
@@ -0,0 +1 @@
+ALTER TABLE `runner_detail` ADD COLUMN `token_issued_at` TIMESTAMP;
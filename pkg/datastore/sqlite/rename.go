@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// RenameScope rename scope of targets in scope and repository of jobs in GitHub host of gheDomain in a transaction
+func (s *SQLite) RenameScope(ctx context.Context, gheDomain, scope, newScope string) (int64, error) {
+	tx, err := s.Conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	renamed, err := renameScope(ctx, tx, datastore.NullGHEDomain(gheDomain), scope, newScope)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to execute COMMIT: %w", err)
+	}
+	return renamed, nil
+}
+
+// renameScope rename targets and jobs in GitHub host of gheDomain in tx
+func renameScope(ctx context.Context, tx *sqlx.Tx, gheDomain sql.NullString, scope, newScope string) (int64, error) {
+	var targets []struct {
+		UUID  string `db:"uuid"`
+		Scope string `db:"scope"`
+	}
+	condition, args := datastore.ScopeCondition("scope", scope)
+	if err := tx.SelectContext(ctx, &targets, `SELECT uuid, scope FROM targets WHERE ghe_domain IS ? AND `+condition, append([]interface{}{gheDomain}, args...)...); err != nil {
+		return 0, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+	if len(targets) == 0 {
+		return 0, datastore.ErrNotFound
+	}
+
+	newCondition, newArgs := datastore.ScopeCondition("scope", newScope)
+	var exists int
+	if err := tx.GetContext(ctx, &exists, `SELECT COUNT(*) FROM targets WHERE ghe_domain IS ? AND `+newCondition, append([]interface{}{gheDomain}, newArgs...)...); err != nil {
+		return 0, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+	if exists != 0 {
+		return 0, fmt.Errorf("target already exists in %s: %w", newScope, datastore.ErrConflict)
+	}
+
+	for _, t := range targets {
+		if _, err := tx.ExecContext(ctx, `UPDATE targets SET scope = ? WHERE uuid = ?`, datastore.RenamedScope(t.Scope, scope, newScope), t.UUID); err != nil {
+			return 0, fmt.Errorf("failed to execute UPDATE query: %w", err)
+		}
+	}
+
+	var jobs []struct {
+		UUID       string `db:"uuid"`
+		Repository string `db:"repository"`
+	}
+	jobCondition, jobArgs := datastore.ScopeCondition("repository", scope)
+	if err := tx.SelectContext(ctx, &jobs, `SELECT uuid, repository FROM jobs WHERE ghe_domain IS ? AND `+jobCondition, append([]interface{}{gheDomain}, jobArgs...)...); err != nil {
+		return 0, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+	for _, j := range jobs {
+		if _, err := tx.ExecContext(ctx, `UPDATE jobs SET repository = ? WHERE uuid = ?`, datastore.RenamedScope(j.Repository, scope, newScope), j.UUID); err != nil {
+			return 0, fmt.Errorf("failed to execute UPDATE query: %w", err)
+		}
+	}
+
+	return int64(len(targets)), nil
+}
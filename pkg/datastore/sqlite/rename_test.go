@@ -0,0 +1,82 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+func TestSQLite_RenameScopeInHosts(t *testing.T) {
+	ds := newTestSQLite(t, nil)
+	ctx := context.Background()
+	gheDomain := "https://github.example.com"
+
+	gheTargetID := uuid.FromStringOrNil("0d3b9a8e-3a53-4d2e-9a5c-4c1b2b0b7e11")
+	gheJobID := uuid.FromStringOrNil("5f0c6a3e-8d3b-4b8e-9a3c-2b7d1e4c6f10")
+	for _, target := range []datastore.Target{
+		{UUID: testTargetID, Scope: testScopeRepo},
+		{UUID: gheTargetID, Scope: testScopeRepo, GHEDomain: sql.NullString{String: gheDomain, Valid: true}},
+	} {
+		target.GitHubToken = "gho_xxxxxxxxxx"
+		target.TokenExpiredAt = testTime
+		target.ResourceType = datastore.ResourceTypeNano
+		if err := ds.CreateTarget(ctx, target); err != nil {
+			t.Fatalf("failed to create target: %+v", err)
+		}
+	}
+	for _, job := range []datastore.Job{
+		{UUID: testJobID, TargetID: testTargetID},
+		{UUID: gheJobID, TargetID: gheTargetID, GHEDomain: sql.NullString{String: gheDomain, Valid: true}},
+	} {
+		job.Repository = testScopeRepo
+		job.CheckEventJSON = "{}"
+		if _, err := ds.EnqueueJob(ctx, job); err != nil {
+			t.Fatalf("failed to enqueue job: %+v", err)
+		}
+	}
+
+	// only scope in GitHub host of webhook is renamed
+	got, err := ds.RenameScope(ctx, gheDomain, "octocat", "octo-org")
+	if err != nil {
+		t.Fatalf("failed to rename scope: %+v", err)
+	}
+	if got != 1 {
+		t.Errorf("must rename 1 target, but got %d", got)
+	}
+
+	for id, want := range map[uuid.UUID]string{testTargetID: testScopeRepo, gheTargetID: "octo-org/hello-world"} {
+		target, err := ds.GetTarget(ctx, id)
+		if err != nil {
+			t.Fatalf("failed to get target: %+v", err)
+		}
+		if target.Scope != want {
+			t.Errorf("want scope %s of target %s, but got %s", want, id, target.Scope)
+		}
+	}
+	jobs, err := ds.ListJobs(ctx, datastore.ListOption{})
+	if err != nil {
+		t.Fatalf("failed to list jobs: %+v", err)
+	}
+	for _, j := range jobs {
+		want := testScopeRepo
+		if uuid.Equal(j.UUID, gheJobID) {
+			want = "octo-org/hello-world"
+		}
+		if j.Repository != want {
+			t.Errorf("want repository %s of job %s, but got %s", want, j.UUID, j.Repository)
+		}
+	}
+
+	// renamed scope in other host is not conflicted
+	if _, err := ds.RenameScope(ctx, "", "octocat", "octo-org"); err != nil {
+		t.Fatalf("failed to rename scope: %+v", err)
+	}
+	if _, err := ds.RenameScope(ctx, "", "octocat", "octo-org"); !errors.Is(err, datastore.ErrNotFound) {
+		t.Errorf("RenameScope must return ErrNotFound if no target in scope, but got: %+v", err)
+	}
+}
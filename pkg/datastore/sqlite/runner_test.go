@@ -0,0 +1,226 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/sqlite"
+)
+
+func createTestRunner(t *testing.T, ds *sqlite.SQLite, id uuid.UUID) {
+	t.Helper()
+
+	if err := ds.CreateRunner(context.Background(), datastore.Runner{
+		UUID:           id,
+		ShoesType:      "shoes-test",
+		TargetID:       testTargetID,
+		CloudID:        "mycloud-" + id.String(),
+		ResourceType:   datastore.ResourceTypeNano,
+		RunnerUser:     sql.NullString{String: "runner", Valid: true},
+		RepositoryURL:  "https://github.com/octocat/hello-world",
+		RequestWebhook: "{}",
+	}); err != nil {
+		t.Fatalf("failed to create runner: %+v", err)
+	}
+}
+
+func TestSQLite_Runner(t *testing.T) {
+	ds := newTestSQLite(t, nil)
+	ctx := context.Background()
+	createTestTarget(t, ds)
+	createTestRunner(t, ds, testRunnerID)
+
+	got, err := ds.GetRunner(ctx, testRunnerID)
+	if err != nil {
+		t.Fatalf("failed to get runner: %+v", err)
+	}
+	if got.CloudID != "mycloud-"+testRunnerID.String() || got.RunnerUser.String != "runner" || got.Version != 0 {
+		t.Fatalf("mismatch runner: %+v", got)
+	}
+
+	runners, err := ds.ListRunnersByTargetID(ctx, testTargetID)
+	if err != nil {
+		t.Fatalf("failed to list runners: %+v", err)
+	}
+	if len(runners) != 1 {
+		t.Fatalf("want 1 runner, but got %d", len(runners))
+	}
+
+	if err := ds.DeleteRunner(ctx, testRunnerID, time.Now().UTC(), datastore.RunnerStatusCompleted); err != nil {
+		t.Fatalf("failed to delete runner: %+v", err)
+	}
+	runners, err = ds.ListRunners(ctx, datastore.ListOption{})
+	if err != nil {
+		t.Fatalf("failed to list runners: %+v", err)
+	}
+	if len(runners) != 0 {
+		t.Fatalf("deleted runner must not be listed, but got %d runners", len(runners))
+	}
+	got, err = ds.GetRunner(ctx, testRunnerID)
+	if err != nil {
+		t.Fatalf("failed to get deleted runner: %+v", err)
+	}
+	if got.Version != 1 {
+		t.Fatalf("version must be incremented by delete, but got %d", got.Version)
+	}
+
+	if _, err := ds.GetRunner(ctx, uuid.NewV4()); !errors.Is(err, datastore.ErrNotFound) {
+		t.Fatalf("want ErrNotFound, but got %+v", err)
+	}
+}
+
+func TestSQLite_DeleteRunnerWithVersion(t *testing.T) {
+	ds := newTestSQLite(t, nil)
+	ctx := context.Background()
+	createTestTarget(t, ds)
+	createTestRunner(t, ds, testRunnerID)
+
+	tests := []struct {
+		version int64
+		want    error
+	}{
+		{version: 1, want: datastore.ErrConflict},
+		{version: 0, want: nil},
+		// already deleted, version is incremented
+		{version: 0, want: datastore.ErrConflict},
+	}
+	for _, test := range tests {
+		err := ds.DeleteRunnerWithVersion(ctx, testRunnerID, test.version, time.Now().UTC(), datastore.RunnerStatusCompleted)
+		if !errors.Is(err, test.want) {
+			t.Errorf("want %v by version %d, but got %+v", test.want, test.version, err)
+		}
+	}
+}
+
+func TestSQLite_UpdateRunnerWithVersion(t *testing.T) {
+	ds := newTestSQLite(t, nil)
+	ctx := context.Background()
+	createTestTarget(t, ds)
+	createTestRunner(t, ds, testRunnerID)
+
+	r, err := ds.GetRunner(ctx, testRunnerID)
+	if err != nil {
+		t.Fatalf("failed to get runner: %+v", err)
+	}
+
+	tests := []struct {
+		version int64
+		cloudID string
+		want    error
+	}{
+		{version: 0, cloudID: "mycloud-updated", want: nil},
+		// stale version, updated by other process
+		{version: 0, cloudID: "mycloud-stale", want: datastore.ErrConflict},
+		{version: 1, cloudID: "mycloud-updated-again", want: nil},
+	}
+	for _, test := range tests {
+		r.Version = test.version
+		r.CloudID = test.cloudID
+		err := ds.UpdateRunnerWithVersion(ctx, *r)
+		if !errors.Is(err, test.want) {
+			t.Errorf("want %v by version %d, but got %+v", test.want, test.version, err)
+		}
+	}
+
+	got, err := ds.GetRunner(ctx, testRunnerID)
+	if err != nil {
+		t.Fatalf("failed to get runner: %+v", err)
+	}
+	if got.CloudID != "mycloud-updated-again" || got.Version != 2 {
+		t.Fatalf("want cloud ID mycloud-updated-again and version 2, but got %s and %d", got.CloudID, got.Version)
+	}
+
+	// runner that read before update can not be deleted
+	if err := ds.DeleteRunnerWithVersion(ctx, testRunnerID, 1, time.Now().UTC(), datastore.RunnerStatusCompleted); !errors.Is(err, datastore.ErrConflict) {
+		t.Fatalf("want ErrConflict, but got %+v", err)
+	}
+	if err := ds.DeleteRunner(ctx, testRunnerID, time.Now().UTC(), datastore.RunnerStatusCompleted); err != nil {
+		t.Fatalf("failed to delete runner: %+v", err)
+	}
+	r.Version = 3
+	if err := ds.UpdateRunnerWithVersion(ctx, *r); !errors.Is(err, datastore.ErrConflict) {
+		t.Fatalf("deleted runner must not be updated, but got %+v", err)
+	}
+}
+
+func TestSQLite_MarkRunnerTokenIssued(t *testing.T) {
+	ds := newTestSQLite(t, nil)
+	ctx := context.Background()
+	createTestTarget(t, ds)
+	createTestRunner(t, ds, testRunnerID)
+
+	if err := ds.MarkRunnerTokenIssued(ctx, testRunnerID, time.Now()); err != nil {
+		t.Fatalf("failed to mark token issued: %+v", err)
+	}
+	if err := ds.MarkRunnerTokenIssued(ctx, testRunnerID, time.Now()); !errors.Is(err, datastore.ErrConflict) {
+		t.Fatalf("token must be issued only once, want ErrConflict but got %+v", err)
+	}
+	if err := ds.MarkRunnerTokenIssued(ctx, uuid.NewV4(), time.Now()); !errors.Is(err, datastore.ErrConflict) {
+		t.Fatalf("want ErrConflict for runner that is not running, but got %+v", err)
+	}
+}
+
+func TestSQLite_DeleteRunnersBulk(t *testing.T) {
+	ds := newTestSQLite(t, nil)
+	ctx := context.Background()
+	createTestTarget(t, ds)
+	otherID, updatedID := uuid.NewV4(), uuid.NewV4()
+	var runners []datastore.Runner
+	for _, id := range []uuid.UUID{testRunnerID, otherID, updatedID} {
+		createTestRunner(t, ds, id)
+		r, err := ds.GetRunner(ctx, id)
+		if err != nil {
+			t.Fatalf("failed to get runner: %+v", err)
+		}
+		runners = append(runners, *r)
+	}
+
+	if err := ds.DeleteRunner(ctx, otherID, time.Now().UTC(), datastore.RunnerStatusCompleted); err != nil {
+		t.Fatalf("failed to delete runner: %+v", err)
+	}
+	// updated by other process after read
+	if err := ds.UpdateRunnerWithVersion(ctx, runners[2]); err != nil {
+		t.Fatalf("failed to update runner: %+v", err)
+	}
+
+	deleted, err := ds.DeleteRunnersBulk(ctx, runners, time.Now().UTC(), datastore.RunnerStatusReachHardLimit)
+	if !errors.Is(err, datastore.ErrConflict) {
+		t.Fatalf("want ErrConflict for updated runner, but got %+v", err)
+	}
+	if len(deleted) != 1 || !uuid.Equal(deleted[0], testRunnerID) {
+		t.Fatalf("want only %s is deleted, but got %v", testRunnerID, deleted)
+	}
+	if _, err := ds.GetRunner(ctx, updatedID); err != nil {
+		t.Fatalf("updated runner must not be deleted, but got %+v", err)
+	}
+
+	// latest version is deleted
+	latest, err := ds.GetRunner(ctx, updatedID)
+	if err != nil {
+		t.Fatalf("failed to get runner: %+v", err)
+	}
+	deleted, err = ds.DeleteRunnersBulk(ctx, []datastore.Runner{*latest}, time.Now().UTC(), datastore.RunnerStatusReachHardLimit)
+	if err != nil {
+		t.Fatalf("failed to delete runners: %+v", err)
+	}
+	if len(deleted) != 1 || !uuid.Equal(deleted[0], updatedID) {
+		t.Fatalf("want %s is deleted, but got %v", updatedID, deleted)
+	}
+
+	purged, err := ds.PurgeDeletedRunners(ctx, time.Now().UTC().Add(1*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("failed to purge deleted runners: %+v", err)
+	}
+	if purged != 3 {
+		t.Fatalf("want 3 purged runners, but got %d", purged)
+	}
+	if _, err := ds.GetRunner(ctx, testRunnerID); !errors.Is(err, datastore.ErrNotFound) {
+		t.Fatalf("want ErrNotFound for purged runner, but got %+v", err)
+	}
+}
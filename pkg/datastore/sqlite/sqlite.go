@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/jmoiron/sqlx"
+	_ "modernc.org/sqlite" // sqlite driver
+)
+
+// SQLite is implement datastore in SQLite.
+// SQLite is for small or single-node deployments.
+type SQLite struct {
+	Conn *sqlx.DB
+
+	notifyEnqueueCh chan<- struct{}
+}
+
+// New create sqlite connection. need to call Migrate for creating tables
+func New(path string, notifyEnqueueCh chan<- struct{}) (*SQLite, error) {
+	conn, err := sqlx.Open("sqlite", getDSN(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sqlite connection: %w", err)
+	}
+
+	return &SQLite{
+		Conn:            conn,
+		notifyEnqueueCh: notifyEnqueueCh,
+	}, nil
+}
+
+func getDSN(path string) string {
+	params := url.Values{}
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "foreign_keys(1)")
+
+	return fmt.Sprintf("file:%s?%s", path, params.Encode())
+}
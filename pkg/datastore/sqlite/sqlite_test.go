@@ -0,0 +1,77 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/sqlite"
+)
+
+var (
+	testTargetID = uuid.FromStringOrNil("8cc1a4d5-4bf1-43d5-8d8f-3a3b7c5a4f7e")
+	testJobID    = uuid.FromStringOrNil("1b4e5b7a-e3c1-4829-9cfd-eac4183f2c95")
+	testRunnerID = uuid.FromStringOrNil("7943c6d4-5b3e-4d57-8fb8-1a5b2b3e9c0d")
+
+	testScopeRepo = "octocat/hello-world"
+	testTime      = time.Date(2037, 9, 3, 0, 0, 0, 0, time.UTC)
+)
+
+// newTestSQLite create SQLite in temporary file that migrated
+func newTestSQLite(t *testing.T, notifyEnqueueCh chan<- struct{}) *sqlite.SQLite {
+	t.Helper()
+
+	ds, err := sqlite.New(filepath.Join(t.TempDir(), "myshoes.db"), notifyEnqueueCh)
+	if err != nil {
+		t.Fatalf("failed to create sqlite: %+v", err)
+	}
+	t.Cleanup(func() { ds.Conn.Close() })
+	if err := ds.Migrate(context.Background()); err != nil {
+		t.Fatalf("failed to migrate: %+v", err)
+	}
+	return ds
+}
+
+func createTestTarget(t *testing.T, ds *sqlite.SQLite) {
+	t.Helper()
+
+	if err := ds.CreateTarget(context.Background(), datastore.Target{
+		UUID:           testTargetID,
+		Scope:          testScopeRepo,
+		GitHubToken:    "gho_xxxxxxxxxx",
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+}
+
+func TestSQLite_Migrate(t *testing.T) {
+	ds := newTestSQLite(t, nil)
+	ctx := context.Background()
+
+	// already migrated, it is no-op
+	if err := ds.Migrate(ctx); err != nil {
+		t.Fatalf("failed to migrate again: %+v", err)
+	}
+
+	if err := ds.MigrateDown(ctx, 1000); err != nil {
+		t.Fatalf("failed to rollback all migrations: %+v", err)
+	}
+	var tables int
+	if err := ds.Conn.GetContext(ctx, &tables, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'targets'`); err != nil {
+		t.Fatalf("failed to count tables: %+v", err)
+	}
+	if tables != 0 {
+		t.Fatalf("targets must be dropped by rollback, but it exists")
+	}
+
+	if err := ds.Migrate(ctx); err != nil {
+		t.Fatalf("failed to migrate after rollback: %+v", err)
+	}
+	createTestTarget(t, ds)
+}
@@ -0,0 +1,233 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// CreateTarget create a target
+func (s *SQLite) CreateTarget(ctx context.Context, target datastore.Target) error {
+	query := `INSERT INTO targets(uuid, scope, ghe_domain, github_token, token_expired_at, resource_type, provider_url, external_ref, placement_params, user_data_format, runner_registration, runner_labels, quiet_hours, rootless, job_filters) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if _, err := s.Conn.ExecContext(
+		ctx,
+		query,
+		target.UUID,
+		target.Scope,
+		target.GHEDomain,
+		target.GitHubToken,
+		target.TokenExpiredAt.UTC(),
+		target.ResourceType,
+		target.ProviderURL,
+		target.ExternalRef,
+		target.PlacementParams,
+		target.UserDataFormat,
+		target.RunnerRegistration,
+		target.RunnerLabels,
+		target.QuietHours,
+		target.Rootless,
+		target.JobFilters,
+	); err != nil {
+		return fmt.Errorf("failed to execute INSERT query: %w", err)
+	}
+
+	return nil
+}
+
+// GetTarget get a target
+func (s *SQLite) GetTarget(ctx context.Context, id uuid.UUID) (*datastore.Target, error) {
+	var t datastore.Target
+	query := `SELECT uuid, scope, ghe_domain, github_token, token_expired_at, resource_type, provider_url, status, status_description, external_ref, placement_params, user_data_format, runner_registration, runner_labels, quiet_hours, rootless, job_filters, deleted_at, created_at, updated_at FROM targets WHERE uuid = ?`
+	if err := s.Conn.GetContext(ctx, &t, query, id.String()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datastore.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	return &t, nil
+}
+
+// GetTargetByScope get a target from scope in GitHub host
+func (s *SQLite) GetTargetByScope(ctx context.Context, gheDomain, scope string) (*datastore.Target, error) {
+	var t datastore.Target
+	query := `SELECT uuid, scope, ghe_domain, github_token, token_expired_at, resource_type, provider_url, status, status_description, external_ref, placement_params, user_data_format, runner_registration, runner_labels, quiet_hours, rootless, job_filters, deleted_at, created_at, updated_at FROM targets WHERE ghe_domain IS ? AND scope = ?`
+	if err := s.Conn.GetContext(ctx, &t, query, datastore.NullGHEDomain(gheDomain), scope); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datastore.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to execute SELECT query: %w", err)
+	}
+
+	return &t, nil
+}
+
+// ListTargets get a page of targets
+func (s *SQLite) ListTargets(ctx context.Context, opt datastore.ListOption) ([]datastore.Target, error) {
+	var ts []datastore.Target
+	query := `SELECT uuid, scope, ghe_domain, github_token, token_expired_at, resource_type, provider_url, status, status_description, external_ref, placement_params, user_data_format, runner_registration, runner_labels, quiet_hours, rootless, job_filters, deleted_at, created_at, updated_at FROM targets`
+	clause, args := opt.Clause("uuid", "deleted_at IS NULL")
+	if err := s.Conn.SelectContext(ctx, &ts, query+clause, args...); err != nil {
+		return nil, fmt.Errorf("failed to SELECT query: %w", err)
+	}
+
+	return ts, nil
+}
+
+// ListDeletedTargets get a page of soft deleted targets
+func (s *SQLite) ListDeletedTargets(ctx context.Context, opt datastore.ListOption) ([]datastore.Target, error) {
+	var ts []datastore.Target
+	query := `SELECT uuid, scope, ghe_domain, github_token, token_expired_at, resource_type, provider_url, status, status_description, external_ref, placement_params, user_data_format, runner_registration, runner_labels, quiet_hours, rootless, job_filters, deleted_at, created_at, updated_at FROM targets`
+	clause, args := opt.Clause("uuid", "deleted_at IS NOT NULL")
+	if err := s.Conn.SelectContext(ctx, &ts, query+clause, args...); err != nil {
+		return nil, fmt.Errorf("failed to SELECT query: %w", err)
+	}
+
+	return ts, nil
+}
+
+// ListTargetsByExternalRef get targets that has external reference ID
+func (s *SQLite) ListTargetsByExternalRef(ctx context.Context, externalRef string) ([]datastore.Target, error) {
+	var ts []datastore.Target
+	query := `SELECT uuid, scope, ghe_domain, github_token, token_expired_at, resource_type, provider_url, status, status_description, external_ref, placement_params, user_data_format, runner_registration, runner_labels, quiet_hours, rootless, job_filters, deleted_at, created_at, updated_at FROM targets WHERE external_ref = ? AND deleted_at IS NULL`
+	if err := s.Conn.SelectContext(ctx, &ts, query, externalRef); err != nil {
+		return nil, fmt.Errorf("failed to SELECT query: %w", err)
+	}
+
+	return ts, nil
+}
+
+// DeleteTarget soft delete a target
+func (s *SQLite) DeleteTarget(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE targets SET status = 'deleted', deleted_at = ? WHERE uuid = ?`
+	if _, err := s.Conn.ExecContext(ctx, query, time.Now().UTC(), id.String()); err != nil {
+		return fmt.Errorf("failed to execute DELETE query: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreTarget restore a soft deleted target
+func (s *SQLite) RestoreTarget(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE targets SET status = 'active', status_description = NULL, deleted_at = NULL WHERE uuid = ? AND deleted_at IS NOT NULL`
+	result, err := s.Conn.ExecContext(ctx, query, id.String())
+	if err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+	restored, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if restored == 0 {
+		return datastore.ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateTargetStatus update status in target
+func (s *SQLite) UpdateTargetStatus(ctx context.Context, targetID uuid.UUID, newStatus datastore.TargetStatus, description string) error {
+	query := `UPDATE targets SET status = ?, status_description = ? WHERE uuid = ?`
+	if _, err := s.Conn.ExecContext(ctx, query, newStatus, description, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateToken update token in target
+func (s *SQLite) UpdateToken(ctx context.Context, targetID uuid.UUID, newToken string, newExpiredAt time.Time) error {
+	query := `UPDATE targets SET github_token = ?, token_expired_at = ? WHERE uuid = ?`
+	if _, err := s.Conn.ExecContext(ctx, query, newToken, newExpiredAt.UTC(), targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTargetParam update parameter of target
+func (s *SQLite) UpdateTargetParam(ctx context.Context, targetID uuid.UUID, newResourceType datastore.ResourceType, newProviderURL sql.NullString) error {
+	query := `UPDATE targets SET resource_type = ?, provider_url = ? WHERE uuid = ?`
+	if _, err := s.Conn.ExecContext(ctx, query, newResourceType, newProviderURL, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTargetPlacementParams update placement parameters of target
+func (s *SQLite) UpdateTargetPlacementParams(ctx context.Context, targetID uuid.UUID, newPlacementParams sql.NullString) error {
+	query := `UPDATE targets SET placement_params = ? WHERE uuid = ?`
+	if _, err := s.Conn.ExecContext(ctx, query, newPlacementParams, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTargetUserDataFormat update format of user data of target
+func (s *SQLite) UpdateTargetUserDataFormat(ctx context.Context, targetID uuid.UUID, newFormat datastore.UserDataFormat) error {
+	query := `UPDATE targets SET user_data_format = ? WHERE uuid = ?`
+	if _, err := s.Conn.ExecContext(ctx, query, newFormat, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTargetRunnerRegistration update method of registering runner of target
+func (s *SQLite) UpdateTargetRunnerRegistration(ctx context.Context, targetID uuid.UUID, newRegistration datastore.RunnerRegistration) error {
+	query := `UPDATE targets SET runner_registration = ? WHERE uuid = ?`
+	if _, err := s.Conn.ExecContext(ctx, query, newRegistration, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTargetRunnerLabels update labels that are injected to runners of target
+func (s *SQLite) UpdateTargetRunnerLabels(ctx context.Context, targetID uuid.UUID, newLabels sql.NullString) error {
+	query := `UPDATE targets SET runner_labels = ? WHERE uuid = ?`
+	if _, err := s.Conn.ExecContext(ctx, query, newLabels, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTargetQuietHours update quiet hours of target
+func (s *SQLite) UpdateTargetQuietHours(ctx context.Context, targetID uuid.UUID, newQuietHours sql.NullString) error {
+	query := `UPDATE targets SET quiet_hours = ? WHERE uuid = ?`
+	if _, err := s.Conn.ExecContext(ctx, query, newQuietHours, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTargetRootless update rootless container runtime of target
+func (s *SQLite) UpdateTargetRootless(ctx context.Context, targetID uuid.UUID, newRuntime datastore.RootlessRuntime) error {
+	query := `UPDATE targets SET rootless = ? WHERE uuid = ?`
+	if _, err := s.Conn.ExecContext(ctx, query, newRuntime, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTargetJobFilters update job filters of target
+func (s *SQLite) UpdateTargetJobFilters(ctx context.Context, targetID uuid.UUID, newJobFilters sql.NullString) error {
+	query := `UPDATE targets SET job_filters = ? WHERE uuid = ?`
+	if _, err := s.Conn.ExecContext(ctx, query, newJobFilters, targetID.String()); err != nil {
+		return fmt.Errorf("failed to execute UPDATE query: %w", err)
+	}
+
+	return nil
+}
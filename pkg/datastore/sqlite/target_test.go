@@ -0,0 +1,177 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/internal/testutils"
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+)
+
+func TestSQLite_Target(t *testing.T) {
+	ds := newTestSQLite(t, nil)
+	ctx := context.Background()
+	createTestTarget(t, ds)
+
+	got, err := ds.GetTargetByScope(ctx, "", testScopeRepo)
+	if err != nil {
+		t.Fatalf("failed to get target by scope: %+v", err)
+	}
+	if !uuid.Equal(got.UUID, testTargetID) || got.Status != datastore.TargetStatusActive || got.ResourceType != datastore.ResourceTypeNano {
+		t.Fatalf("want active target %s, but got %+v", testTargetID, got)
+	}
+	if !got.TokenExpiredAt.Equal(testTime) {
+		t.Fatalf("want token_expired_at %s, but got %s", testTime, got.TokenExpiredAt)
+	}
+
+	if err := ds.UpdateTargetStatus(ctx, testTargetID, datastore.TargetStatusErr, "failed to create runner"); err != nil {
+		t.Fatalf("failed to update status: %+v", err)
+	}
+	got, err = ds.GetTarget(ctx, testTargetID)
+	if err != nil {
+		t.Fatalf("failed to get target: %+v", err)
+	}
+	if got.Status != datastore.TargetStatusErr || got.StatusDescription.String != "failed to create runner" {
+		t.Fatalf("want status %s with description, but got %+v", datastore.TargetStatusErr, got)
+	}
+
+	if err := ds.DeleteTarget(ctx, testTargetID); err != nil {
+		t.Fatalf("failed to delete target: %+v", err)
+	}
+	targets, err := ds.ListTargets(ctx, datastore.ListOption{})
+	if err != nil {
+		t.Fatalf("failed to list targets: %+v", err)
+	}
+	if len(targets) != 0 {
+		t.Fatalf("deleted target must not be listed, but got %d targets", len(targets))
+	}
+	deleted, err := ds.ListDeletedTargets(ctx, datastore.ListOption{})
+	if err != nil {
+		t.Fatalf("failed to list deleted targets: %+v", err)
+	}
+	if len(deleted) != 1 || !deleted[0].DeletedAt.Valid {
+		t.Fatalf("want 1 deleted target, but got %+v", deleted)
+	}
+
+	if err := ds.RestoreTarget(ctx, testTargetID); err != nil {
+		t.Fatalf("failed to restore target: %+v", err)
+	}
+	got, err = ds.GetTarget(ctx, testTargetID)
+	if err != nil {
+		t.Fatalf("failed to get target: %+v", err)
+	}
+	if got.Status != datastore.TargetStatusActive || got.DeletedAt.Valid {
+		t.Fatalf("want restored target, but got %+v", got)
+	}
+	if err := ds.RestoreTarget(ctx, testTargetID); !errors.Is(err, datastore.ErrNotFound) {
+		t.Fatalf("want ErrNotFound for target that is not deleted, but got %+v", err)
+	}
+
+	if _, err := ds.GetTarget(ctx, uuid.NewV4()); !errors.Is(err, datastore.ErrNotFound) {
+		t.Fatalf("want ErrNotFound, but got %+v", err)
+	}
+}
+
+func TestSQLite_TargetGHEDomain(t *testing.T) {
+	ds := newTestSQLite(t, nil)
+	ctx := context.Background()
+	gheDomain := "https://github.example.com"
+	testutils.RegisterGitHubHost(t, gheDomain)
+
+	if err := ds.CreateTarget(ctx, datastore.Target{
+		UUID:           testTargetID,
+		Scope:          testScopeRepo,
+		GHEDomain:      sql.NullString{String: gheDomain, Valid: true},
+		GitHubToken:    "gho_xxxxxxxxxx",
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	got, err := ds.GetTarget(ctx, testTargetID)
+	if err != nil {
+		t.Fatalf("failed to get target: %+v", err)
+	}
+	byScope, err := ds.GetTargetByScope(ctx, gheDomain, testScopeRepo)
+	if err != nil {
+		t.Fatalf("failed to get target by scope: %+v", err)
+	}
+	listed, err := ds.ListTargets(ctx, datastore.ListOption{})
+	if err != nil {
+		t.Fatalf("failed to list targets: %+v", err)
+	}
+	if len(listed) != 1 {
+		t.Fatalf("want 1 target, but got %d", len(listed))
+	}
+
+	for _, target := range []datastore.Target{*got, *byScope, listed[0]} {
+		if target.GHEDomain.String != gheDomain {
+			t.Fatalf("want ghe_domain %s, but got %+v", gheDomain, target.GHEDomain)
+		}
+		hostCtx, err := target.WithGitHubHost(ctx)
+		if err != nil {
+			t.Fatalf("failed to get GitHub host of target: %+v", err)
+		}
+		if h := gh.HostFrom(hostCtx); h.URL() != gheDomain {
+			t.Fatalf("want GitHub host %s, but got %s", gheDomain, h.URL())
+		}
+	}
+}
+
+func TestSQLite_TargetSameScopeInHosts(t *testing.T) {
+	ds := newTestSQLite(t, nil)
+	ctx := context.Background()
+	gheDomain := "https://github.example.com"
+	testutils.RegisterGitHubHost(t, gheDomain)
+	oldURL := config.Config.GitHubURL
+	t.Cleanup(func() { config.Config.GitHubURL = oldURL })
+	config.Config.GitHubURL = "https://github.com"
+
+	gheTargetID := uuid.FromStringOrNil("0d3b9a8e-3a53-4d2e-9a5c-4c1b2b0b7e11")
+	for _, target := range []datastore.Target{
+		{UUID: testTargetID, Scope: "octocat"},
+		{UUID: gheTargetID, Scope: "octocat", GHEDomain: sql.NullString{String: gheDomain, Valid: true}},
+	} {
+		target.GitHubToken = "gho_xxxxxxxxxx"
+		target.TokenExpiredAt = testTime
+		target.ResourceType = datastore.ResourceTypeNano
+		if err := ds.CreateTarget(ctx, target); err != nil {
+			t.Fatalf("failed to create target: %+v", err)
+		}
+	}
+
+	for domain, want := range map[string]uuid.UUID{"": testTargetID, gheDomain: gheTargetID} {
+		got, err := ds.GetTargetByScope(ctx, domain, "octocat")
+		if err != nil {
+			t.Fatalf("failed to get target by scope (ghe_domain: %q): %+v", domain, err)
+		}
+		if !uuid.Equal(got.UUID, want) {
+			t.Errorf("want target %s in ghe_domain %q, but got %s", want, domain, got.UUID)
+		}
+	}
+	if _, err := ds.GetTargetByScope(ctx, "https://other.example.com", "octocat"); !errors.Is(err, datastore.ErrNotFound) {
+		t.Errorf("target in other host must not be found, but got %+v", err)
+	}
+
+	host, err := gh.LookupHost(gheDomain)
+	if err != nil {
+		t.Fatalf("failed to get GitHub host: %+v", err)
+	}
+	// repository in webhook is searched in GitHub host of webhook
+	for hostCtx, want := range map[context.Context]uuid.UUID{ctx: testTargetID, gh.WithHost(ctx, host): gheTargetID} {
+		got, err := datastore.SearchRepo(hostCtx, ds, "octocat/hello-world")
+		if err != nil {
+			t.Fatalf("failed to search target: %+v", err)
+		}
+		if !uuid.Equal(got.UUID, want) {
+			t.Errorf("want target %s in %s, but got %s", want, gh.HostFrom(hostCtx).URL(), got.UUID)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package trace
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// CreateTarget call CreateTarget in a span
+func (d *Datastore) CreateTarget(ctx context.Context, target datastore.Target) error {
+	return doErr(ctx, d, "CreateTarget", func(ctx context.Context) error {
+		return d.Datastore.CreateTarget(ctx, target)
+	})
+}
+
+// GetTarget call GetTarget in a span
+func (d *Datastore) GetTarget(ctx context.Context, id uuid.UUID) (*datastore.Target, error) {
+	return do(ctx, d, "GetTarget", func(ctx context.Context) (*datastore.Target, error) {
+		return d.Datastore.GetTarget(ctx, id)
+	})
+}
+
+// GetTargetByScope call GetTargetByScope in a span
+func (d *Datastore) GetTargetByScope(ctx context.Context, gheDomain, scope string) (*datastore.Target, error) {
+	return do(ctx, d, "GetTargetByScope", func(ctx context.Context) (*datastore.Target, error) {
+		return d.Datastore.GetTargetByScope(ctx, gheDomain, scope)
+	})
+}
+
+// ListTargets call ListTargets in a span
+func (d *Datastore) ListTargets(ctx context.Context, opt datastore.ListOption) ([]datastore.Target, error) {
+	return do(ctx, d, "ListTargets", func(ctx context.Context) ([]datastore.Target, error) {
+		return d.Datastore.ListTargets(ctx, opt)
+	})
+}
+
+// ListDeletedTargets call ListDeletedTargets in a span
+func (d *Datastore) ListDeletedTargets(ctx context.Context, opt datastore.ListOption) ([]datastore.Target, error) {
+	return do(ctx, d, "ListDeletedTargets", func(ctx context.Context) ([]datastore.Target, error) {
+		return d.Datastore.ListDeletedTargets(ctx, opt)
+	})
+}
+
+// ListTargetsByExternalRef call ListTargetsByExternalRef in a span
+func (d *Datastore) ListTargetsByExternalRef(ctx context.Context, externalRef string) ([]datastore.Target, error) {
+	return do(ctx, d, "ListTargetsByExternalRef", func(ctx context.Context) ([]datastore.Target, error) {
+		return d.Datastore.ListTargetsByExternalRef(ctx, externalRef)
+	})
+}
+
+// DeleteTarget call DeleteTarget in a span
+func (d *Datastore) DeleteTarget(ctx context.Context, id uuid.UUID) error {
+	return doErr(ctx, d, "DeleteTarget", func(ctx context.Context) error {
+		return d.Datastore.DeleteTarget(ctx, id)
+	})
+}
+
+// RestoreTarget call RestoreTarget in a span
+func (d *Datastore) RestoreTarget(ctx context.Context, id uuid.UUID) error {
+	return doErr(ctx, d, "RestoreTarget", func(ctx context.Context) error {
+		return d.Datastore.RestoreTarget(ctx, id)
+	})
+}
+
+// UpdateTargetStatus call UpdateTargetStatus in a span
+func (d *Datastore) UpdateTargetStatus(ctx context.Context, targetID uuid.UUID, newStatus datastore.TargetStatus, description string) error {
+	return doErr(ctx, d, "UpdateTargetStatus", func(ctx context.Context) error {
+		return d.Datastore.UpdateTargetStatus(ctx, targetID, newStatus, description)
+	})
+}
+
+// UpdateToken call UpdateToken in a span
+func (d *Datastore) UpdateToken(ctx context.Context, targetID uuid.UUID, newToken string, newExpiredAt time.Time) error {
+	return doErr(ctx, d, "UpdateToken", func(ctx context.Context) error {
+		return d.Datastore.UpdateToken(ctx, targetID, newToken, newExpiredAt)
+	})
+}
+
+// UpdateTargetParam call UpdateTargetParam in a span
+func (d *Datastore) UpdateTargetParam(ctx context.Context, targetID uuid.UUID, newResourceType datastore.ResourceType, newProviderURL sql.NullString) error {
+	return doErr(ctx, d, "UpdateTargetParam", func(ctx context.Context) error {
+		return d.Datastore.UpdateTargetParam(ctx, targetID, newResourceType, newProviderURL)
+	})
+}
+
+// UpdateTargetPlacementParams call UpdateTargetPlacementParams in a span
+func (d *Datastore) UpdateTargetPlacementParams(ctx context.Context, targetID uuid.UUID, newPlacementParams sql.NullString) error {
+	return doErr(ctx, d, "UpdateTargetPlacementParams", func(ctx context.Context) error {
+		return d.Datastore.UpdateTargetPlacementParams(ctx, targetID, newPlacementParams)
+	})
+}
+
+// UpdateTargetUserDataFormat call UpdateTargetUserDataFormat in a span
+func (d *Datastore) UpdateTargetUserDataFormat(ctx context.Context, targetID uuid.UUID, newFormat datastore.UserDataFormat) error {
+	return doErr(ctx, d, "UpdateTargetUserDataFormat", func(ctx context.Context) error {
+		return d.Datastore.UpdateTargetUserDataFormat(ctx, targetID, newFormat)
+	})
+}
+
+// UpdateTargetRunnerRegistration call UpdateTargetRunnerRegistration in a span
+func (d *Datastore) UpdateTargetRunnerRegistration(ctx context.Context, targetID uuid.UUID, newRegistration datastore.RunnerRegistration) error {
+	return doErr(ctx, d, "UpdateTargetRunnerRegistration", func(ctx context.Context) error {
+		return d.Datastore.UpdateTargetRunnerRegistration(ctx, targetID, newRegistration)
+	})
+}
+
+// UpdateTargetRunnerLabels call UpdateTargetRunnerLabels in a span
+func (d *Datastore) UpdateTargetRunnerLabels(ctx context.Context, targetID uuid.UUID, newLabels sql.NullString) error {
+	return doErr(ctx, d, "UpdateTargetRunnerLabels", func(ctx context.Context) error {
+		return d.Datastore.UpdateTargetRunnerLabels(ctx, targetID, newLabels)
+	})
+}
+
+// UpdateTargetQuietHours call UpdateTargetQuietHours in a span
+func (d *Datastore) UpdateTargetQuietHours(ctx context.Context, targetID uuid.UUID, newQuietHours sql.NullString) error {
+	return doErr(ctx, d, "UpdateTargetQuietHours", func(ctx context.Context) error {
+		return d.Datastore.UpdateTargetQuietHours(ctx, targetID, newQuietHours)
+	})
+}
+
+// UpdateTargetRootless call UpdateTargetRootless in a span
+func (d *Datastore) UpdateTargetRootless(ctx context.Context, targetID uuid.UUID, newRuntime datastore.RootlessRuntime) error {
+	return doErr(ctx, d, "UpdateTargetRootless", func(ctx context.Context) error {
+		return d.Datastore.UpdateTargetRootless(ctx, targetID, newRuntime)
+	})
+}
+
+// UpdateTargetJobFilters call UpdateTargetJobFilters in a span
+func (d *Datastore) UpdateTargetJobFilters(ctx context.Context, targetID uuid.UUID, newJobFilters sql.NullString) error {
+	return doErr(ctx, d, "UpdateTargetJobFilters", func(ctx context.Context) error {
+		return d.Datastore.UpdateTargetJobFilters(ctx, targetID, newJobFilters)
+	})
+}
+
+// ExportTargets call ExportTargets in a span
+func (d *Datastore) ExportTargets(ctx context.Context) ([]datastore.Target, error) {
+	return do(ctx, d, "ExportTargets", func(ctx context.Context) ([]datastore.Target, error) {
+		return d.Datastore.ExportTargets(ctx)
+	})
+}
+
+// ImportTargets call ImportTargets in a span
+func (d *Datastore) ImportTargets(ctx context.Context, targets []datastore.Target) (int64, error) {
+	return do(ctx, d, "ImportTargets", func(ctx context.Context) (int64, error) {
+		return d.Datastore.ImportTargets(ctx, targets)
+	})
+}
+
+// PurgeScope call PurgeScope in a span
+func (d *Datastore) PurgeScope(ctx context.Context, scope string) (*datastore.PurgeResult, error) {
+	return do(ctx, d, "PurgeScope", func(ctx context.Context) (*datastore.PurgeResult, error) {
+		return d.Datastore.PurgeScope(ctx, scope)
+	})
+}
+
+// RenameScope call RenameScope in a span
+func (d *Datastore) RenameScope(ctx context.Context, gheDomain, scope, newScope string) (int64, error) {
+	return do(ctx, d, "RenameScope", func(ctx context.Context) (int64, error) {
+		return d.Datastore.RenameScope(ctx, gheDomain, scope, newScope)
+	})
+}
+
+// EnqueueJob call EnqueueJob in a span
+func (d *Datastore) EnqueueJob(ctx context.Context, job datastore.Job) (*datastore.Job, error) {
+	return do(ctx, d, "EnqueueJob", func(ctx context.Context) (*datastore.Job, error) {
+		return d.Datastore.EnqueueJob(ctx, job)
+	})
+}
+
+// GetJobByDedupKey call GetJobByDedupKey in a span
+func (d *Datastore) GetJobByDedupKey(ctx context.Context, dedupKey string) (*datastore.Job, error) {
+	return do(ctx, d, "GetJobByDedupKey", func(ctx context.Context) (*datastore.Job, error) {
+		return d.Datastore.GetJobByDedupKey(ctx, dedupKey)
+	})
+}
+
+// ListJobs call ListJobs in a span
+func (d *Datastore) ListJobs(ctx context.Context, opt datastore.ListOption) ([]datastore.Job, error) {
+	return do(ctx, d, "ListJobs", func(ctx context.Context) ([]datastore.Job, error) {
+		return d.Datastore.ListJobs(ctx, opt)
+	})
+}
+
+// ListReadyJobs call ListReadyJobs in a span
+func (d *Datastore) ListReadyJobs(ctx context.Context, now time.Time) ([]datastore.Job, error) {
+	return do(ctx, d, "ListReadyJobs", func(ctx context.Context) ([]datastore.Job, error) {
+		return d.Datastore.ListReadyJobs(ctx, now)
+	})
+}
+
+// CountPendingJobs call CountPendingJobs in a span
+func (d *Datastore) CountPendingJobs(ctx context.Context, targetID uuid.UUID) (int, error) {
+	return do(ctx, d, "CountPendingJobs", func(ctx context.Context) (int, error) {
+		return d.Datastore.CountPendingJobs(ctx, targetID)
+	})
+}
+
+// OldestPendingJobAge call OldestPendingJobAge in a span
+func (d *Datastore) OldestPendingJobAge(ctx context.Context) (time.Duration, error) {
+	return do(ctx, d, "OldestPendingJobAge", func(ctx context.Context) (time.Duration, error) {
+		return d.Datastore.OldestPendingJobAge(ctx)
+	})
+}
+
+// ListJobsByExternalRef call ListJobsByExternalRef in a span
+func (d *Datastore) ListJobsByExternalRef(ctx context.Context, externalRef string) ([]datastore.Job, error) {
+	return do(ctx, d, "ListJobsByExternalRef", func(ctx context.Context) ([]datastore.Job, error) {
+		return d.Datastore.ListJobsByExternalRef(ctx, externalRef)
+	})
+}
+
+// DeferJob call DeferJob in a span
+func (d *Datastore) DeferJob(ctx context.Context, id uuid.UUID, notBefore time.Time) error {
+	return doErr(ctx, d, "DeferJob", func(ctx context.Context) error {
+		return d.Datastore.DeferJob(ctx, id, notBefore)
+	})
+}
+
+// DeleteJob call DeleteJob in a span
+func (d *Datastore) DeleteJob(ctx context.Context, id uuid.UUID) error {
+	return doErr(ctx, d, "DeleteJob", func(ctx context.Context) error {
+		return d.Datastore.DeleteJob(ctx, id)
+	})
+}
+
+// DeleteJobsBulk call DeleteJobsBulk in a span
+func (d *Datastore) DeleteJobsBulk(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	return do(ctx, d, "DeleteJobsBulk", func(ctx context.Context) (int64, error) {
+		return d.Datastore.DeleteJobsBulk(ctx, ids)
+	})
+}
+
+// CreateRunner call CreateRunner in a span
+func (d *Datastore) CreateRunner(ctx context.Context, runner datastore.Runner) error {
+	return doErr(ctx, d, "CreateRunner", func(ctx context.Context) error {
+		return d.Datastore.CreateRunner(ctx, runner)
+	})
+}
+
+// ListRunners call ListRunners in a span
+func (d *Datastore) ListRunners(ctx context.Context, opt datastore.ListOption) ([]datastore.Runner, error) {
+	return do(ctx, d, "ListRunners", func(ctx context.Context) ([]datastore.Runner, error) {
+		return d.Datastore.ListRunners(ctx, opt)
+	})
+}
+
+// ListRunnersByTargetID call ListRunnersByTargetID in a span
+func (d *Datastore) ListRunnersByTargetID(ctx context.Context, targetID uuid.UUID) ([]datastore.Runner, error) {
+	return do(ctx, d, "ListRunnersByTargetID", func(ctx context.Context) ([]datastore.Runner, error) {
+		return d.Datastore.ListRunnersByTargetID(ctx, targetID)
+	})
+}
+
+// GetRunner call GetRunner in a span
+func (d *Datastore) GetRunner(ctx context.Context, id uuid.UUID) (*datastore.Runner, error) {
+	return do(ctx, d, "GetRunner", func(ctx context.Context) (*datastore.Runner, error) {
+		return d.Datastore.GetRunner(ctx, id)
+	})
+}
+
+// UpdateRunnerWithVersion call UpdateRunnerWithVersion in a span
+func (d *Datastore) UpdateRunnerWithVersion(ctx context.Context, runner datastore.Runner) error {
+	return doErr(ctx, d, "UpdateRunnerWithVersion", func(ctx context.Context) error {
+		return d.Datastore.UpdateRunnerWithVersion(ctx, runner)
+	})
+}
+
+// MarkRunnerTokenIssued call MarkRunnerTokenIssued in a span
+func (d *Datastore) MarkRunnerTokenIssued(ctx context.Context, id uuid.UUID, issuedAt time.Time) error {
+	return doErr(ctx, d, "MarkRunnerTokenIssued", func(ctx context.Context) error {
+		return d.Datastore.MarkRunnerTokenIssued(ctx, id, issuedAt)
+	})
+}
+
+// DeleteRunner call DeleteRunner in a span
+func (d *Datastore) DeleteRunner(ctx context.Context, id uuid.UUID, deletedAt time.Time, reason datastore.RunnerStatus) error {
+	return doErr(ctx, d, "DeleteRunner", func(ctx context.Context) error {
+		return d.Datastore.DeleteRunner(ctx, id, deletedAt, reason)
+	})
+}
+
+// DeleteRunnerWithVersion call DeleteRunnerWithVersion in a span
+func (d *Datastore) DeleteRunnerWithVersion(ctx context.Context, id uuid.UUID, version int64, deletedAt time.Time, reason datastore.RunnerStatus) error {
+	return doErr(ctx, d, "DeleteRunnerWithVersion", func(ctx context.Context) error {
+		return d.Datastore.DeleteRunnerWithVersion(ctx, id, version, deletedAt, reason)
+	})
+}
+
+// DeleteRunnersBulk call DeleteRunnersBulk in a span
+func (d *Datastore) DeleteRunnersBulk(ctx context.Context, runners []datastore.Runner, deletedAt time.Time, reason datastore.RunnerStatus) ([]uuid.UUID, error) {
+	return do(ctx, d, "DeleteRunnersBulk", func(ctx context.Context) ([]uuid.UUID, error) {
+		return d.Datastore.DeleteRunnersBulk(ctx, runners, deletedAt, reason)
+	})
+}
+
+// PurgeDeletedRunners call PurgeDeletedRunners in a span
+func (d *Datastore) PurgeDeletedRunners(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return do(ctx, d, "PurgeDeletedRunners", func(ctx context.Context) (int64, error) {
+		return d.Datastore.PurgeDeletedRunners(ctx, before, limit)
+	})
+}
+
+// CreateRunnerHookResult call CreateRunnerHookResult in a span
+func (d *Datastore) CreateRunnerHookResult(ctx context.Context, result datastore.RunnerHookResult) error {
+	return doErr(ctx, d, "CreateRunnerHookResult", func(ctx context.Context) error {
+		return d.Datastore.CreateRunnerHookResult(ctx, result)
+	})
+}
+
+// ListRunnerHookResults call ListRunnerHookResults in a span
+func (d *Datastore) ListRunnerHookResults(ctx context.Context, runnerID uuid.UUID) ([]datastore.RunnerHookResult, error) {
+	return do(ctx, d, "ListRunnerHookResults", func(ctx context.Context) ([]datastore.RunnerHookResult, error) {
+		return d.Datastore.ListRunnerHookResults(ctx, runnerID)
+	})
+}
+
+// CreateStateHistory call CreateStateHistory in a span
+func (d *Datastore) CreateStateHistory(ctx context.Context, history datastore.StateHistory) error {
+	return doErr(ctx, d, "CreateStateHistory", func(ctx context.Context) error {
+		return d.Datastore.CreateStateHistory(ctx, history)
+	})
+}
+
+// ListStateHistories call ListStateHistories in a span
+func (d *Datastore) ListStateHistories(ctx context.Context, resourceID uuid.UUID) ([]datastore.StateHistory, error) {
+	return do(ctx, d, "ListStateHistories", func(ctx context.Context) ([]datastore.StateHistory, error) {
+		return d.Datastore.ListStateHistories(ctx, resourceID)
+	})
+}
+
+// PurgeStateHistories call PurgeStateHistories in a span
+func (d *Datastore) PurgeStateHistories(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return do(ctx, d, "PurgeStateHistories", func(ctx context.Context) (int64, error) {
+		return d.Datastore.PurgeStateHistories(ctx, before, limit)
+	})
+}
+
+// CreateJobHistory call CreateJobHistory in a span
+func (d *Datastore) CreateJobHistory(ctx context.Context, history datastore.JobHistory) error {
+	return doErr(ctx, d, "CreateJobHistory", func(ctx context.Context) error {
+		return d.Datastore.CreateJobHistory(ctx, history)
+	})
+}
+
+// SetJobHistoryTime call SetJobHistoryTime in a span
+func (d *Datastore) SetJobHistoryTime(ctx context.Context, jobID uuid.UUID, event datastore.JobHistoryEvent, at time.Time) error {
+	return doErr(ctx, d, "SetJobHistoryTime", func(ctx context.Context) error {
+		return d.Datastore.SetJobHistoryTime(ctx, jobID, event, at)
+	})
+}
+
+// SetJobHistoryTimeByGitHubJobID call SetJobHistoryTimeByGitHubJobID in a span
+func (d *Datastore) SetJobHistoryTimeByGitHubJobID(ctx context.Context, githubJobID int64, event datastore.JobHistoryEvent, at time.Time) error {
+	return doErr(ctx, d, "SetJobHistoryTimeByGitHubJobID", func(ctx context.Context) error {
+		return d.Datastore.SetJobHistoryTimeByGitHubJobID(ctx, githubJobID, event, at)
+	})
+}
+
+// ListJobHistories call ListJobHistories in a span
+func (d *Datastore) ListJobHistories(ctx context.Context, targetID uuid.UUID, since time.Time, limit int) ([]datastore.JobHistory, error) {
+	return do(ctx, d, "ListJobHistories", func(ctx context.Context) ([]datastore.JobHistory, error) {
+		return d.Datastore.ListJobHistories(ctx, targetID, since, limit)
+	})
+}
+
+// PurgeJobHistories call PurgeJobHistories in a span
+func (d *Datastore) PurgeJobHistories(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return do(ctx, d, "PurgeJobHistories", func(ctx context.Context) (int64, error) {
+		return d.Datastore.PurgeJobHistories(ctx, before, limit)
+	})
+}
+
+// GetLock call GetLock in a span
+func (d *Datastore) GetLock(ctx context.Context) error {
+	return doErr(ctx, d, "GetLock", func(ctx context.Context) error {
+		return d.Datastore.GetLock(ctx)
+	})
+}
+
+// IsLocked call IsLocked in a span
+func (d *Datastore) IsLocked(ctx context.Context) (string, error) {
+	return do(ctx, d, "IsLocked", func(ctx context.Context) (string, error) {
+		return d.Datastore.IsLocked(ctx)
+	})
+}
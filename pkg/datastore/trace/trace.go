@@ -0,0 +1,120 @@
+package trace
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// Tracer start a span of datastore call, it is adapter to tracing backend (e.g. OpenTelemetry)
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span of datastore call
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// Attribute keys of span
+const (
+	AttributeOperation = "db.operation"
+	AttributeRows      = "db.rows"
+)
+
+// Datastore is datastore.Datastore that create a span around every call.
+// span has only name of method and number of rows, arguments (e.g. tokens in target) are not recorded.
+type Datastore struct {
+	datastore.Datastore
+
+	tracer Tracer
+}
+
+// Wrap wrap datastore by tracer
+func Wrap(ds datastore.Datastore, tracer Tracer) *Datastore {
+	return &Datastore{
+		Datastore: ds,
+		tracer:    tracer,
+	}
+}
+
+// do call fn in a span
+func do[T any](ctx context.Context, d *Datastore, method string, fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx, span := d.tracer.Start(ctx, "datastore."+method)
+	defer span.End()
+	span.SetAttribute(AttributeOperation, method)
+
+	result, err := fn(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return result, err
+	}
+	if rows, ok := countRows(result); ok {
+		span.SetAttribute(AttributeRows, rows)
+	}
+	return result, nil
+}
+
+func doErr(ctx context.Context, d *Datastore, method string, fn func(ctx context.Context) error) error {
+	_, err := do(ctx, d, method, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// countRows return number of rows in result, false if result is not rows (e.g. struct{} of no result)
+func countRows(result interface{}) (int64, bool) {
+	v := reflect.ValueOf(result)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return int64(v.Len()), true
+	case reflect.Ptr:
+		if v.IsNil() {
+			return 0, true
+		}
+		return 1, true
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		// number of affected rows (e.g. purge)
+		return v.Int(), true
+	}
+	return 0, false
+}
+
+// LogTracer is Tracer that log spans slower than threshold, for environments without tracing backend
+type LogTracer struct {
+	Threshold time.Duration
+}
+
+// Start start a span
+func (t LogTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, &logSpan{name: name, threshold: t.Threshold, start: time.Now(), attributes: map[string]interface{}{}}
+}
+
+type logSpan struct {
+	name       string
+	threshold  time.Duration
+	start      time.Time
+	attributes map[string]interface{}
+	err        error
+}
+
+func (s *logSpan) SetAttribute(key string, value interface{}) {
+	s.attributes[key] = value
+}
+
+func (s *logSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *logSpan) End() {
+	elapsed := time.Since(s.start)
+	if elapsed < s.threshold {
+		return
+	}
+	logger.Logf(false, "slow datastore call %s (elapsed: %s, attributes: %v, error: %v)", s.name, elapsed, s.attributes, s.err)
+}
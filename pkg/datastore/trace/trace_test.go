@@ -0,0 +1,75 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
+)
+
+// recorder is Tracer that record ended spans
+type recorder struct {
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	Name       string
+	Attributes map[string]interface{}
+	Err        error
+	Ended      bool
+}
+
+func (r *recorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &recordedSpan{Name: name, Attributes: map[string]interface{}{}}
+	r.spans = append(r.spans, s)
+	return ctx, s
+}
+
+func (s *recordedSpan) SetAttribute(key string, value interface{}) { s.Attributes[key] = value }
+func (s *recordedSpan) RecordError(err error)                      { s.Err = err }
+func (s *recordedSpan) End()                                       { s.Ended = true }
+
+func TestDatastore_Span(t *testing.T) {
+	testTargetID := uuid.FromStringOrNil("8cc1a4d5-4bf1-43d5-8d8f-3a3b7c5a4f7e")
+
+	m, _ := memory.New(nil)
+	r := &recorder{}
+	ds := Wrap(m, r)
+
+	if err := ds.CreateTarget(context.Background(), datastore.Target{UUID: testTargetID, Scope: "octocat"}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+	if _, err := ds.ListTargets(context.Background(), datastore.ListOption{}); err != nil {
+		t.Fatalf("failed to list targets: %+v", err)
+	}
+	if _, err := ds.GetTarget(context.Background(), uuid.NewV4()); !errors.Is(err, datastore.ErrNotFound) {
+		t.Fatalf("want error %+v, but got %+v", datastore.ErrNotFound, err)
+	}
+
+	want := []*recordedSpan{
+		{
+			Name:       "datastore.CreateTarget",
+			Attributes: map[string]interface{}{AttributeOperation: "CreateTarget"},
+			Ended:      true,
+		},
+		{
+			Name:       "datastore.ListTargets",
+			Attributes: map[string]interface{}{AttributeOperation: "ListTargets", AttributeRows: int64(1)},
+			Ended:      true,
+		},
+		{
+			Name:       "datastore.GetTarget",
+			Attributes: map[string]interface{}{AttributeOperation: "GetTarget"},
+			Err:        datastore.ErrNotFound,
+			Ended:      true,
+		},
+	}
+	if diff := cmp.Diff(want, r.spans, cmp.Comparer(func(x, y error) bool { return errors.Is(x, y) || errors.Is(y, x) })); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,25 @@
+package datastore
+
+import "fmt"
+
+// UserDataFormat is format of user data that setup script of runner is passed to shoes-provider
+type UserDataFormat string
+
+// UserDataFormat values
+const (
+	// UserDataFormatShell is a raw shell script, it is default (empty value)
+	UserDataFormatShell UserDataFormat = "shell"
+	// UserDataFormatCloudConfig is cloud-init cloud-config
+	UserDataFormatCloudConfig UserDataFormat = "cloud-config"
+	// UserDataFormatIgnition is Ignition config for Flatcar and Fedora CoreOS
+	UserDataFormatIgnition UserDataFormat = "ignition"
+)
+
+// ValidateUserDataFormat check format is supported, empty is valid as UserDataFormatShell
+func ValidateUserDataFormat(format UserDataFormat) error {
+	switch format {
+	case "", UserDataFormatShell, UserDataFormatCloudConfig, UserDataFormatIgnition:
+		return nil
+	}
+	return fmt.Errorf("user_data_format must be one of %s, %s, %s (got: %s)", UserDataFormatShell, UserDataFormatCloudConfig, UserDataFormatIgnition, format)
+}
@@ -0,0 +1,26 @@
+package datastore
+
+import "testing"
+
+func TestValidateUserDataFormat(t *testing.T) {
+	tests := []struct {
+		input UserDataFormat
+		err   bool
+	}{
+		{input: "", err: false},
+		{input: UserDataFormatShell, err: false},
+		{input: UserDataFormatCloudConfig, err: false},
+		{input: UserDataFormatIgnition, err: false},
+		{input: "cloud-init", err: true},
+	}
+
+	for _, test := range tests {
+		err := ValidateUserDataFormat(test.input)
+		if !test.err && err != nil {
+			t.Fatalf("must not be error (input: %s): %+v", test.input, err)
+		}
+		if test.err && err == nil {
+			t.Fatalf("must be error (input: %s)", test.input)
+		}
+	}
+}
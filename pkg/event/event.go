@@ -0,0 +1,94 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Topic is kind of event
+type Topic string
+
+// Topic values
+const (
+	// TopicAll is used only in Subscribe for receiving events of all topics
+	TopicAll Topic = ""
+	// TopicWebhookReceived is published when webhook from GitHub is received, payload is Webhook
+	TopicWebhookReceived Topic = "webhook.received"
+	// TopicStateChanged is published when status of job or runner is changed, payload is datastore.StateHistory
+	TopicStateChanged Topic = "state.changed"
+	// TopicSchedulerTick is published in every iteration of main loops, payload is Tick
+	TopicSchedulerTick Topic = "scheduler.tick"
+)
+
+// Event is a message in Bus
+type Event struct {
+	Topic       Topic
+	Payload     interface{}
+	PublishedAt time.Time
+}
+
+// Webhook is payload of TopicWebhookReceived
+type Webhook struct {
+	// EventType is value of X-GitHub-Event (e.g. workflow_job)
+	EventType  string          `json:"event_type"`
+	DeliveryID string          `json:"delivery_id"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// Tick is payload of TopicSchedulerTick
+type Tick struct {
+	// Loop is name of loop (e.g. starter, runner)
+	Loop string `json:"loop"`
+}
+
+// Bus is publish/subscribe bus of events
+type Bus interface {
+	// Publish send event to subscribers of topic. Publish must not block by slow subscribers
+	Publish(ctx context.Context, e Event)
+	// Subscribe return channel that receive events of topic, and function for unsubscribe
+	Subscribe(topic Topic, buffer int) (<-chan Event, func())
+}
+
+var (
+	busMu sync.RWMutex
+	bus   Bus = NewInProcess()
+)
+
+// SetBus replace bus that used in Publish and Subscribe. need to call before starting loops
+func SetBus(b Bus) {
+	busMu.Lock()
+	defer busMu.Unlock()
+	bus = b
+}
+
+func current() Bus {
+	busMu.RLock()
+	defer busMu.RUnlock()
+	return bus
+}
+
+// Publish publish payload to topic
+func Publish(ctx context.Context, topic Topic, payload interface{}) {
+	current().Publish(ctx, Event{
+		Topic:       topic,
+		Payload:     payload,
+		PublishedAt: time.Now().UTC(),
+	})
+}
+
+// Dropped return number of events that dropped in bus, return 0 if bus is not count it
+func Dropped() uint64 {
+	d, ok := current().(interface{ Dropped() uint64 })
+	if !ok {
+		return 0
+	}
+	return d.Dropped()
+}
+
+// Subscribe subscribe topic, TopicAll receive events of all topics.
+// events are dropped if buffer is full, so subscriber should receive quickly.
+func Subscribe(topic Topic, buffer int) (<-chan Event, func()) {
+	return current().Subscribe(topic, buffer)
+}
@@ -0,0 +1,88 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/event"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// SchemaVersion is version of Envelope. it is incremented when a field is removed or changed incompatibly
+const SchemaVersion = 1
+
+// bufferSize is buffer of subscription, events are dropped if exporter is slower than it
+const bufferSize = 1024
+
+// Envelope is a message that exported to broker
+type Envelope struct {
+	SchemaVersion int             `json:"schema_version"`
+	Topic         string          `json:"topic"`
+	PublishedAt   time.Time       `json:"published_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// Exporter send message to broker
+type Exporter interface {
+	// Export send data to subject (topic in Kafka)
+	Export(ctx context.Context, subject string, data []byte) error
+	Close() error
+}
+
+// New create Exporter by backend ("nats" or "kafka")
+func New(backend, endpoint string) (Exporter, error) {
+	switch backend {
+	case "nats":
+		return NewNATS(endpoint)
+	case "kafka":
+		return NewKafkaREST(endpoint)
+	}
+	return nil, fmt.Errorf("unknown backend of event export: %s", backend)
+}
+
+// Subject return subject of topic. e.g. myshoes.state.changed
+func Subject(prefix string, topic event.Topic) string {
+	if prefix == "" {
+		return string(topic)
+	}
+	return prefix + "." + string(topic)
+}
+
+// Run subscribe all events and export them until ctx is done.
+// failure of exporting is only logged, events are not retried.
+func Run(ctx context.Context, exporter Exporter, prefix string) error {
+	ch, unsubscribe := event.Subscribe(event.TopicAll, bufferSize)
+	defer unsubscribe()
+	defer exporter.Close()
+
+	for {
+		select {
+		case e := <-ch:
+			data, err := marshal(e)
+			if err != nil {
+				logger.Logf(false, "failed to marshal event (topic: %s): %+v", e.Topic, err)
+				continue
+			}
+			if err := exporter.Export(ctx, Subject(prefix, e.Topic), data); err != nil {
+				logger.Samplef(false, "failed to export event (topic: %s): %+v", e.Topic, err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func marshal(e event.Event) ([]byte, error) {
+	payload, err := json.Marshal(e.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return json.Marshal(Envelope{
+		SchemaVersion: SchemaVersion,
+		Topic:         string(e.Topic),
+		PublishedAt:   e.PublishedAt,
+		Payload:       payload,
+	})
+}
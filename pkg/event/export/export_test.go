@@ -0,0 +1,105 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/event"
+)
+
+func TestNATS_Export(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %+v", err)
+	}
+	defer l.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "PING"):
+				conn.Write([]byte("PONG\r\n"))
+			case strings.HasPrefix(line, "PUB "):
+				payload, _ := r.ReadString('\n')
+				received <- line + payload
+			}
+		}
+	}()
+
+	n, err := NewNATS("nats://" + l.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to create exporter: %+v", err)
+	}
+	defer n.Close()
+	if err := n.Export(context.Background(), "myshoes.scheduler.tick", []byte(`{"loop":"starter"}`)); err != nil {
+		t.Fatalf("failed to export: %+v", err)
+	}
+
+	want := "PUB myshoes.scheduler.tick 18\r\n{\"loop\":\"starter\"}\r\n"
+	select {
+	case got := <-received:
+		if got != want {
+			t.Fatalf("want %q, but got %q", want, got)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("message is not received")
+	}
+}
+
+func TestKafkaREST_Export(t *testing.T) {
+	var gotPath string
+	var got kafkaRecords
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		b, _ := io.ReadAll(r.Body)
+		json.Unmarshal(b, &got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	k, err := NewKafkaREST(ts.URL)
+	if err != nil {
+		t.Fatalf("failed to create exporter: %+v", err)
+	}
+	data, err := marshal(event.Event{Topic: event.TopicSchedulerTick, Payload: event.Tick{Loop: "runner"}})
+	if err != nil {
+		t.Fatalf("failed to marshal: %+v", err)
+	}
+	if err := k.Export(context.Background(), Subject("myshoes", event.TopicSchedulerTick), data); err != nil {
+		t.Fatalf("failed to export: %+v", err)
+	}
+
+	if gotPath != "/topics/myshoes.scheduler.tick" {
+		t.Fatalf("incorrect path: %s", gotPath)
+	}
+	if len(got.Records) != 1 {
+		t.Fatalf("want 1 record, but got %d", len(got.Records))
+	}
+	var envelope Envelope
+	if err := json.Unmarshal(got.Records[0].Value, &envelope); err != nil {
+		t.Fatalf("failed to unmarshal envelope: %+v", err)
+	}
+	if envelope.SchemaVersion != SchemaVersion || string(envelope.Payload) != `{"loop":"runner"}` {
+		t.Fatalf("incorrect envelope: %+v", envelope)
+	}
+}
@@ -0,0 +1,73 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// KafkaREST is Exporter that produce to Kafka via REST Proxy (Confluent REST Proxy API v2)
+type KafkaREST struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewKafkaREST create KafkaREST exporter. endpoint is URL of REST Proxy, user info in URL is used for basic auth
+func NewKafkaREST(endpoint string) (*KafkaREST, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL of Kafka REST Proxy: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("scheme of Kafka REST Proxy URL must be http or https (got: %s)", u.Scheme)
+	}
+	return &KafkaREST{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaRecord struct {
+	Value json.RawMessage `json:"value"`
+}
+
+// Export produce data to topic
+func (k *KafkaREST) Export(ctx context.Context, topic string, data []byte) error {
+	body, err := json.Marshal(kafkaRecords{Records: []kafkaRecord{{Value: data}}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal records: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.endpoint+"/topics/"+url.PathEscape(topic), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, b)
+	}
+	return nil
+}
+
+// Close do nothing
+func (k *KafkaREST) Close() error {
+	return nil
+}
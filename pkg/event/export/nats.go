@@ -0,0 +1,186 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// natsDialTimeout is timeout of connecting to NATS server
+var natsDialTimeout = 5 * time.Second
+
+// NATS is Exporter that publish to NATS by core protocol. connection is established in first Export, and re-established after error.
+type NATS struct {
+	url *url.URL
+
+	mu   sync.Mutex
+	conn net.Conn
+	w    *bufio.Writer
+}
+
+// NewNATS create NATS exporter. endpoint is nats://[user:password@]host:port, or tls://... for TLS
+func NewNATS(endpoint string) (*NATS, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL of NATS: %w", err)
+	}
+	switch u.Scheme {
+	case "nats", "tls":
+	default:
+		return nil, fmt.Errorf("scheme of NATS URL must be nats or tls (got: %s)", u.Scheme)
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	return &NATS{url: u}, nil
+}
+
+type natsConnectOptions struct {
+	Verbose   bool   `json:"verbose"`
+	Pedantic  bool   `json:"pedantic"`
+	Name      string `json:"name"`
+	Lang      string `json:"lang"`
+	Version   string `json:"version"`
+	User      string `json:"user,omitempty"`
+	Pass      string `json:"pass,omitempty"`
+	AuthToken string `json:"auth_token,omitempty"`
+}
+
+// Export publish data to subject
+func (n *NATS) Export(ctx context.Context, subject string, data []byte) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		if err := n.connect(ctx); err != nil {
+			return fmt.Errorf("failed to connect to NATS: %w", err)
+		}
+	}
+
+	fmt.Fprintf(n.w, "PUB %s %d\r\n", subject, len(data))
+	n.w.Write(data)
+	n.w.WriteString("\r\n")
+	if err := n.w.Flush(); err != nil {
+		n.closeLocked()
+		return fmt.Errorf("failed to publish: %w", err)
+	}
+	return nil
+}
+
+// Close close connection
+func (n *NATS) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.closeLocked()
+	return nil
+}
+
+func (n *NATS) closeLocked() {
+	if n.conn != nil {
+		n.conn.Close()
+	}
+	n.conn = nil
+	n.w = nil
+}
+
+// connect dial to server, and handshake (INFO -> CONNECT -> PING -> PONG)
+func (n *NATS) connect(ctx context.Context) error {
+	d := net.Dialer{Timeout: natsDialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", n.url.Host)
+	if err != nil {
+		return fmt.Errorf("failed to dial: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(natsDialTimeout))
+
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to read INFO: %w", err)
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("unexpected message from server: %s", strings.TrimSpace(line))
+	}
+
+	if n.url.Scheme == "tls" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: n.url.Hostname(), MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to TLS handshake: %w", err)
+		}
+		conn = tlsConn
+		r = bufio.NewReader(conn)
+	}
+
+	opts := natsConnectOptions{Name: "myshoes", Lang: "go", Version: "1"}
+	if n.url.User != nil {
+		if pass, ok := n.url.User.Password(); ok {
+			opts.User, opts.Pass = n.url.User.Username(), pass
+		} else {
+			opts.AuthToken = n.url.User.Username()
+		}
+	}
+	b, err := json.Marshal(opts)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to marshal CONNECT options: %w", err)
+	}
+	w := bufio.NewWriter(conn)
+	fmt.Fprintf(w, "CONNECT %s\r\nPING\r\n", b)
+	if err := w.Flush(); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to send CONNECT: %w", err)
+	}
+	line, err = r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to read response of CONNECT: %w", err)
+	}
+	if strings.TrimSpace(line) != "PONG" {
+		conn.Close()
+		return fmt.Errorf("failed to CONNECT: %s", strings.TrimSpace(line))
+	}
+	conn.SetDeadline(time.Time{})
+
+	n.conn = conn
+	n.w = w
+	go n.readLoop(conn, r)
+	return nil
+}
+
+// readLoop reply PONG to PING from server. it returns when connection is closed
+func (n *NATS) readLoop(conn net.Conn, r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			n.mu.Lock()
+			if n.conn == conn {
+				n.closeLocked()
+			}
+			n.mu.Unlock()
+			return
+		}
+
+		switch {
+		case strings.HasPrefix(line, "PING"):
+			n.mu.Lock()
+			if n.conn == conn {
+				n.w.WriteString("PONG\r\n")
+				n.w.Flush()
+			}
+			n.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			logger.Logf(false, "receive error from NATS: %s", strings.TrimSpace(line))
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package event
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// InProcess is Bus in a process
+type InProcess struct {
+	mu          sync.RWMutex
+	subscribers map[Topic]map[*subscriber]struct{}
+
+	dropped atomic.Uint64
+}
+
+type subscriber struct {
+	ch   chan Event
+	once sync.Once
+}
+
+// NewInProcess create InProcess
+func NewInProcess() *InProcess {
+	return &InProcess{
+		subscribers: map[Topic]map[*subscriber]struct{}{},
+	}
+}
+
+// Publish send event to subscribers of topic and TopicAll, drop event if buffer of subscriber is full
+func (b *InProcess) Publish(ctx context.Context, e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, topic := range []Topic{e.Topic, TopicAll} {
+		for s := range b.subscribers[topic] {
+			select {
+			case s.ch <- e:
+			default:
+				b.dropped.Add(1)
+			}
+		}
+	}
+}
+
+// Subscribe return channel that receive events of topic
+func (b *InProcess) Subscribe(topic Topic, buffer int) (<-chan Event, func()) {
+	s := &subscriber{ch: make(chan Event, buffer)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[topic]; !ok {
+		b.subscribers[topic] = map[*subscriber]struct{}{}
+	}
+	b.subscribers[topic][s] = struct{}{}
+
+	unsubscribe := func() {
+		s.once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers[topic], s)
+			close(s.ch)
+		})
+	}
+	return s.ch, unsubscribe
+}
+
+// Dropped return number of events that dropped by full buffer
+func (b *InProcess) Dropped() uint64 {
+	return b.dropped.Load()
+}
@@ -0,0 +1,41 @@
+package event
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInProcess(t *testing.T) {
+	b := NewInProcess()
+
+	tick, unsubscribeTick := b.Subscribe(TopicSchedulerTick, 1)
+	all, unsubscribeAll := b.Subscribe(TopicAll, 2)
+	defer unsubscribeAll()
+
+	b.Publish(context.Background(), Event{Topic: TopicSchedulerTick, Payload: Tick{Loop: "starter"}})
+	b.Publish(context.Background(), Event{Topic: TopicWebhookReceived, Payload: Webhook{EventType: "workflow_job"}})
+
+	if got := (<-tick).Payload.(Tick).Loop; got != "starter" {
+		t.Fatalf("incorrect payload, want: starter but got: %s", got)
+	}
+	if got := (<-all).Topic; got != TopicSchedulerTick {
+		t.Fatalf("incorrect topic, want: %s but got: %s", TopicSchedulerTick, got)
+	}
+	if got := (<-all).Topic; got != TopicWebhookReceived {
+		t.Fatalf("incorrect topic, want: %s but got: %s", TopicWebhookReceived, got)
+	}
+
+	// buffer is full
+	b.Publish(context.Background(), Event{Topic: TopicSchedulerTick})
+	b.Publish(context.Background(), Event{Topic: TopicSchedulerTick})
+	if b.Dropped() != 1 {
+		t.Fatalf("incorrect number of dropped events, want: 1 but got: %d", b.Dropped())
+	}
+
+	unsubscribeTick()
+	unsubscribeTick()
+	<-tick
+	if _, ok := <-tick; ok {
+		t.Fatalf("channel must be closed after unsubscribe")
+	}
+}
@@ -0,0 +1,126 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+var (
+	// ErrBudgetExceeded is error for daily budget of requests to GitHub API is exceeded
+	ErrBudgetExceeded = fmt.Errorf("daily budget of GitHub API requests is exceeded")
+
+	budgets = &budgetTracker{usages: map[string]*budgetUsage{}}
+)
+
+type budgetKey struct{}
+
+// WithBudgetScope set scope to ctx, requests to GitHub API with returned ctx are counted as usage of scope
+func WithBudgetScope(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, budgetKey{}, scope)
+}
+
+func budgetScopeFromContext(ctx context.Context) (string, bool) {
+	scope, ok := ctx.Value(budgetKey{}).(string)
+	return scope, ok && scope != ""
+}
+
+type budgetUsage struct {
+	day   string
+	count int64
+}
+
+// budgetTracker count requests to GitHub API per scope in a day (UTC)
+type budgetTracker struct {
+	mu     sync.Mutex
+	usages map[string]*budgetUsage
+}
+
+func today() string {
+	return time.Now().UTC().Format("2006-01-02")
+}
+
+func (b *budgetTracker) consume(scope string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	u, ok := b.usages[scope]
+	if !ok || u.day != today() {
+		u = &budgetUsage{day: today()}
+		b.usages[scope] = u
+	}
+	u.count++
+
+	if limit := getBudget(scope); limit > 0 && u.count == limit {
+		logger.Logf(false, "daily budget of GitHub API requests is exceeded in %s (budget: %d), will defer non-essential requests", scope, limit)
+	}
+}
+
+func (b *budgetTracker) used(scope string) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	u, ok := b.usages[scope]
+	if !ok || u.day != today() {
+		return 0
+	}
+	return u.count
+}
+
+// getBudget return daily budget of scope, 0 is unlimited
+func getBudget(scope string) int64 {
+	if budget, ok := config.Config.GitHubDailyBudgetOverrides[scope]; ok {
+		return budget
+	}
+	return config.Config.GitHubDailyBudget
+}
+
+// CheckBudget return ErrBudgetExceeded if daily budget of scope is exceeded, or ErrRateLimitPaused if rate limit of installation is exhausted.
+// call it before non-essential requests (e.g. refreshing status, cleanup), provisioning-critical requests must not check it.
+func CheckBudget(scope string) error {
+	if reset, paused := IsRateLimitPaused(scope); paused {
+		return fmt.Errorf("%w (scope: %s, reset: %s)", ErrRateLimitPaused, scope, reset)
+	}
+	limit := getBudget(scope)
+	if limit <= 0 {
+		return nil
+	}
+	if budgets.used(scope) >= limit {
+		return fmt.Errorf("%w (scope: %s, budget: %d)", ErrBudgetExceeded, scope, limit)
+	}
+	return nil
+}
+
+// Budget is a daily budget of GitHub API requests in scope
+type Budget struct {
+	Scope  string `json:"scope"`
+	Budget int64  `json:"budget"`
+	Used   int64  `json:"used"`
+}
+
+// ListBudgets get a list of used budget in today, sorted by scope
+func ListBudgets() []Budget {
+	budgets.mu.Lock()
+	day := today()
+	var r []Budget
+	for scope, u := range budgets.usages {
+		if u.day != day {
+			continue
+		}
+		r = append(r, Budget{Scope: scope, Used: u.count})
+	}
+	budgets.mu.Unlock()
+
+	for i := range r {
+		r[i].Budget = getBudget(r[i].Scope)
+	}
+	sort.SliceStable(r, func(i, j int) bool {
+		return r[i].Scope < r[j].Scope
+	})
+	return r
+}
@@ -0,0 +1,42 @@
+package gh
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+func TestCheckBudget(t *testing.T) {
+	config.Config.GitHubDailyBudget = 3
+	config.Config.GitHubDailyBudgetOverrides = map[string]int64{
+		"octo-org/unlimited": 0,
+		"octo-org/small":     1,
+	}
+	defer func() {
+		config.Config.GitHubDailyBudget = 0
+		config.Config.GitHubDailyBudgetOverrides = nil
+	}()
+
+	tests := []struct {
+		scope   string
+		consume int
+		wantErr bool
+	}{
+		{scope: "octo-org/default", consume: 2, wantErr: false},
+		{scope: "octo-org/exceeded", consume: 3, wantErr: true},
+		{scope: "octo-org/unlimited", consume: 10, wantErr: false},
+		{scope: "octo-org/small", consume: 1, wantErr: true},
+	}
+
+	for _, test := range tests {
+		for i := 0; i < test.consume; i++ {
+			budgets.consume(test.scope)
+		}
+
+		err := CheckBudget(test.scope)
+		if test.wantErr != errors.Is(err, ErrBudgetExceeded) {
+			t.Fatalf("scope %s: want error %t, but got %+v", test.scope, test.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HookDelivery is a delivery of webhook of GitHub Apps
+type HookDelivery struct {
+	ID          int64     `json:"id"`
+	GUID        string    `json:"guid"` // same as X-GitHub-Delivery, redelivery has same GUID
+	DeliveredAt time.Time `json:"delivered_at"`
+	Redelivery  bool      `json:"redelivery"`
+	StatusCode  int       `json:"status_code"` // 0 is not delivered (e.g. timeout, connection refused)
+	Event       string    `json:"event"`
+	Action      string    `json:"action"`
+}
+
+// IsSucceeded return true if myshoes responded 2xx to delivery
+func (d HookDelivery) IsSucceeded() bool {
+	return http.StatusOK <= d.StatusCode && d.StatusCode < http.StatusMultipleChoices
+}
+
+// ListHookDeliveries list deliveries of webhook of GitHub Apps that delivered after since, sorted by newest first
+// docs: https://docs.github.com/en/rest/apps/webhooks#list-deliveries-for-an-app-webhook
+func ListHookDeliveries(ctx context.Context, since time.Time) ([]HookDelivery, error) {
+	if IsPATMode() {
+		return nil, ErrNotAvailableInPATMode
+	}
+	clientApps, err := NewClientGitHubApps()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a client Apps: %w", err)
+	}
+
+	var deliveries []HookDelivery
+	u := "app/hook/deliveries?per_page=100"
+	for u != "" {
+		req, err := clientApps.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		var page []HookDelivery
+		resp, err := clientApps.Do(ctx, req, &page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deliveries: %w", err)
+		}
+		for _, d := range page {
+			if d.DeliveredAt.Before(since) {
+				return deliveries, nil
+			}
+			deliveries = append(deliveries, d)
+		}
+		u = nextLink(resp.Header.Get("Link"))
+	}
+	return deliveries, nil
+}
+
+// GetHookDeliveryPayload get payload of a delivery
+// docs: https://docs.github.com/en/rest/apps/webhooks#get-a-delivery-for-an-app-webhook
+func GetHookDeliveryPayload(ctx context.Context, deliveryID int64) ([]byte, error) {
+	clientApps, err := NewClientGitHubApps()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a client Apps: %w", err)
+	}
+
+	req, err := clientApps.NewRequest(http.MethodGet, fmt.Sprintf("app/hook/deliveries/%d", deliveryID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	var d struct {
+		Request struct {
+			Payload json.RawMessage `json:"payload"`
+		} `json:"request"`
+	}
+	if _, err := clientApps.Do(ctx, req, &d); err != nil {
+		return nil, fmt.Errorf("failed to get delivery (id: %d): %w", deliveryID, err)
+	}
+	return d.Request.Payload, nil
+}
+
+// MissedDeliveries return deliveries of event that myshoes did not process (e.g. myshoes was down).
+// a delivery is not missed if it is succeeded in redelivery. deliveries are sorted by oldest first
+func MissedDeliveries(deliveries []HookDelivery, event string) []HookDelivery {
+	succeeded := map[string]struct{}{}
+	for _, d := range deliveries {
+		if d.IsSucceeded() {
+			succeeded[d.GUID] = struct{}{}
+		}
+	}
+
+	var missed []HookDelivery
+	seen := map[string]struct{}{}
+	for i := len(deliveries) - 1; i >= 0; i-- {
+		d := deliveries[i]
+		if !strings.EqualFold(d.Event, event) {
+			continue
+		}
+		if _, ok := succeeded[d.GUID]; ok {
+			continue
+		}
+		if _, ok := seen[d.GUID]; ok {
+			// failed in redelivery too
+			continue
+		}
+		seen[d.GUID] = struct{}{}
+		missed = append(missed, d)
+	}
+	return missed
+}
+
+// nextLink return URL of next page in Link header, return empty if it is last page
+func nextLink(link string) string {
+	for _, l := range strings.Split(link, ",") {
+		segments := strings.Split(strings.TrimSpace(l), ";")
+		if len(segments) < 2 {
+			continue
+		}
+		for _, s := range segments[1:] {
+			if strings.TrimSpace(s) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(segments[0]), "<>")
+			}
+		}
+	}
+	return ""
+}
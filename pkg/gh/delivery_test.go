@@ -0,0 +1,53 @@
+package gh
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMissedDeliveries(t *testing.T) {
+	// sorted by newest first, same as API
+	deliveries := []HookDelivery{
+		{ID: 6, GUID: "c", StatusCode: 0, Event: "workflow_job", Redelivery: true},
+		{ID: 5, GUID: "b", StatusCode: 200, Event: "workflow_job", Redelivery: true},
+		{ID: 4, GUID: "e", StatusCode: 502, Event: "check_run"},
+		{ID: 3, GUID: "d", StatusCode: 202, Event: "workflow_job"},
+		{ID: 2, GUID: "c", StatusCode: 0, Event: "workflow_job"},
+		{ID: 1, GUID: "b", StatusCode: 502, Event: "workflow_job"},
+		{ID: 0, GUID: "a", StatusCode: 0, Event: "workflow_job"},
+	}
+
+	got := MissedDeliveries(deliveries, "workflow_job")
+	var ids []int64
+	for _, d := range got {
+		ids = append(ids, d.ID)
+	}
+	if diff := cmp.Diff([]int64{0, 2}, ids); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "", want: ""},
+		{
+			input: `<https://api.github.com/app/hook/deliveries?per_page=100&cursor=v1_2>; rel="next"`,
+			want:  "https://api.github.com/app/hook/deliveries?per_page=100&cursor=v1_2",
+		},
+		{
+			input: `<https://api.github.com/app/hook/deliveries?per_page=100>; rel="first", <https://api.github.com/app/hook/deliveries?per_page=100&cursor=v1_3>; rel="next"`,
+			want:  "https://api.github.com/app/hook/deliveries?per_page=100&cursor=v1_3",
+		},
+		{input: `<https://api.github.com/app/hook/deliveries?per_page=100>; rel="first"`, want: ""},
+	}
+
+	for _, test := range tests {
+		if got := nextLink(test.input); got != test.want {
+			t.Errorf("want %s, but got %s", test.want, got)
+		}
+	}
+}
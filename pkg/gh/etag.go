@@ -0,0 +1,146 @@
+package gh
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// headerFromCache is set to response that served from ETag cache
+const headerFromCache = "X-From-Cache"
+
+var (
+	// etagCache is cache of responses that have ETag or Last-Modified
+	// key: method, URL and credential of request, value: *etagEntry
+	etagCache = cache.New(1*time.Hour, 10*time.Minute)
+
+	etagHits   atomic.Int64
+	etagMisses atomic.Int64
+)
+
+// GetETagCacheCounts return number of conditional requests per result ("hit" is 304, "miss" is changed)
+func GetETagCacheCounts() map[string]int64 {
+	return map[string]int64{
+		"hit":  etagHits.Load(),
+		"miss": etagMisses.Load(),
+	}
+}
+
+type noCacheKey struct{}
+
+// withoutCache set to context that request is sent without ETag cache (e.g. probing GitHub API), and response is not cached
+func withoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+func isWithoutCache(ctx context.Context) bool {
+	noCache, _ := ctx.Value(noCacheKey{}).(bool)
+	return noCache
+}
+
+type etagEntry struct {
+	etag         string
+	lastModified string
+	statusCode   int
+	header       http.Header
+	body         []byte
+}
+
+// etagTransport is transport that sends conditional requests by ETag of cached response.
+// 304 Not Modified is served from cache, it does not count against rate limit of GitHub API.
+type etagTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implement http.RoundTripper
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" || isWithoutCache(req.Context()) {
+		return t.base.RoundTrip(req)
+	}
+
+	key := etagKey(req)
+	var entry *etagEntry
+	if cached, found := etagCache.Get(key); found {
+		entry = cached.(*etagEntry)
+		req = req.Clone(req.Context())
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		etagHits.Add(1)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return entry.toResponse(req, resp.Header), nil
+	}
+	if entry != nil {
+		etagMisses.Add(1)
+	}
+
+	if resp.StatusCode != http.StatusOK || (resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "") {
+		if entry != nil {
+			etagCache.Delete(key)
+		}
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	etagCache.SetDefault(key, &etagEntry{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		statusCode:   resp.StatusCode,
+		header:       resp.Header.Clone(),
+		body:         body,
+	})
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// toResponse return cached response, rate limit headers are updated by 304 response
+func (e *etagEntry) toResponse(req *http.Request, notModified http.Header) *http.Response {
+	header := e.header.Clone()
+	for _, h := range []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-RateLimit-Used", "Date"} {
+		if v := notModified.Get(h); v != "" {
+			header.Set(h, v)
+		}
+	}
+	header.Set(headerFromCache, "1")
+
+	return &http.Response{
+		Status:        http.StatusText(e.statusCode),
+		StatusCode:    e.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// etagKey return key of cache, credential is hashed because a response differs in each installation
+func etagKey(req *http.Request) string {
+	credential := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return req.Method + " " + req.URL.String() + " " + hex.EncodeToString(credential[:8])
+}
@@ -0,0 +1,57 @@
+package gh
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestETagTransport(t *testing.T) {
+	var calls []string
+	tr := &etagTransport{base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls = append(calls, req.Header.Get("If-None-Match"))
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			return newResponse(http.StatusNotModified, map[string]string{"X-RateLimit-Remaining": "4999"}), nil
+		}
+		resp := newResponse(http.StatusOK, map[string]string{"ETag": `"v1"`, "X-RateLimit-Remaining": "5000"})
+		resp.Body = io.NopCloser(strings.NewReader("body"))
+		return resp, nil
+	})}
+
+	tests := []struct {
+		authorization string
+		noCache       bool
+		wantCondition string
+		wantCache     bool
+	}{
+		{authorization: "token a", wantCondition: "", wantCache: false},
+		{authorization: "token a", wantCondition: `"v1"`, wantCache: true},
+		{authorization: "token b", wantCondition: "", wantCache: false},
+		{authorization: "token a", noCache: true, wantCondition: "", wantCache: false},
+	}
+
+	for i, test := range tests {
+		ctx := context.Background()
+		if test.noCache {
+			ctx = withoutCache(ctx)
+		}
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.github.com/installation/repositories", nil)
+		req.Header.Set("Authorization", test.authorization)
+		resp, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("failed to round trip: %+v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != "body" {
+			t.Errorf("want 200 and body, but got %d and %q", resp.StatusCode, body)
+		}
+		if calls[i] != test.wantCondition {
+			t.Errorf("want If-None-Match %q, but got %q", test.wantCondition, calls[i])
+		}
+		if got := resp.Header.Get(headerFromCache) != ""; got != test.wantCache {
+			t.Errorf("want served from cache %t, but got %t", test.wantCache, got)
+		}
+	}
+}
@@ -20,6 +20,14 @@ func TestDetectScope(t *testing.T) {
 			input: "org",
 			want:  Organization,
 		},
+		{
+			input: "enterprises/octo-enterprise",
+			want:  Enterprise,
+		},
+		{
+			input: "enterprises/",
+			want:  Repository,
+		},
 		{
 			input: "org/repo/whats",
 			want:  Unknown,
@@ -42,6 +50,7 @@ func TestDetectScope(t *testing.T) {
 type TestGetRepositoryURLInput struct {
 	scope     string
 	gheDomain string
+	apiURL    string
 }
 
 func TestGetRepositoryURL(t *testing.T) {
@@ -98,6 +107,15 @@ func TestGetRepositoryURL(t *testing.T) {
 			want: "https://github-enterprise.example.com/github/api/v3/repos/org/repo",
 			err:  nil,
 		},
+		{
+			input: TestGetRepositoryURLInput{
+				scope:     "org/repo",
+				gheDomain: "https://github-enterprise.example.com",
+				apiURL:    "https://api.github-enterprise.example.com",
+			},
+			want: "https://api.github-enterprise.example.com/repos/org/repo",
+			err:  nil,
+		},
 	}
 
 	for _, test := range tests {
@@ -106,6 +124,10 @@ func TestGetRepositoryURL(t *testing.T) {
 				t.Setenv("GITHUB_URL", test.input.gheDomain)
 				defer os.Unsetenv("GITHUB_URL")
 			}
+			if test.input.apiURL != "" {
+				t.Setenv("GITHUB_API_URL", test.input.apiURL)
+				defer os.Unsetenv("GITHUB_API_URL")
+			}
 
 			config.LoadWithDefault()
 
@@ -0,0 +1,211 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// graphqlPath is path of GraphQL API from base URL of REST API.
+// https://api.github.com/ -> https://api.github.com/graphql, https://github.example.com/api/v3/ -> https://github.example.com/api/graphql
+const graphqlPath = "../graphql"
+
+type graphqlRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+type graphqlError struct {
+	Message string `json:"message"`
+}
+
+// queryGraphQL send query to GraphQL API, and decode data of response to v
+func queryGraphQL(ctx context.Context, client *github.Client, query string, variables map[string]any, v any) error {
+	req, err := client.NewRequest(http.MethodPost, graphqlPath, graphqlRequest{Query: query, Variables: variables})
+	if err != nil {
+		return fmt.Errorf("failed to create request of GraphQL: %w", err)
+	}
+
+	var resp struct {
+		Data   any            `json:"data"`
+		Errors []graphqlError `json:"errors"`
+	}
+	resp.Data = v
+	if _, err := client.Do(ctx, req, &resp); err != nil {
+		return fmt.Errorf("failed to query GraphQL: %w", err)
+	}
+	if len(resp.Errors) != 0 {
+		var messages []string
+		for _, e := range resp.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("failed to query GraphQL: %s", strings.Join(messages, ", "))
+	}
+	return nil
+}
+
+// queuedCheckRunsFragment is fragment of repository that has queued check runs in heads of recent branches.
+// ID of check run is same as ID of workflow job in GitHub Actions
+const queuedCheckRunsFragment = `
+fragment queuedCheckRuns on Repository {
+  databaseId
+  name
+  nameWithOwner
+  url
+  owner { login }
+  refs(refPrefix: "refs/heads/", first: 10, orderBy: {field: TAG_COMMIT_DATE, direction: DESC}) {
+    nodes {
+      target {
+        ... on Commit {
+          checkSuites(first: 10) {
+            nodes {
+              status
+              app { slug }
+              checkRuns(first: 20, filterBy: {status: QUEUED, checkType: LATEST}) {
+                nodes { databaseId }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+const queuedCheckRunsInRepositoryQuery = `
+query($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) { ...queuedCheckRuns }
+}` + queuedCheckRunsFragment
+
+// queuedCheckRunsInOrganizationQuery search recently pushed repositories in organization
+const queuedCheckRunsInOrganizationQuery = `
+query($owner: String!) {
+  organization(login: $owner) {
+    repositories(first: 25, orderBy: {field: PUSHED_AT, direction: DESC}) {
+      nodes { ...queuedCheckRuns }
+    }
+  }
+}` + queuedCheckRunsFragment
+
+type graphqlRepository struct {
+	DatabaseID    int64  `json:"databaseId"`
+	Name          string `json:"name"`
+	NameWithOwner string `json:"nameWithOwner"`
+	URL           string `json:"url"`
+	Owner         struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	Refs struct {
+		Nodes []struct {
+			Target struct {
+				CheckSuites struct {
+					Nodes []struct {
+						Status string `json:"status"`
+						App    struct {
+							Slug string `json:"slug"`
+						} `json:"app"`
+						CheckRuns struct {
+							Nodes []struct {
+								DatabaseID int64 `json:"databaseId"`
+							} `json:"nodes"`
+						} `json:"checkRuns"`
+					} `json:"nodes"`
+				} `json:"checkSuites"`
+			} `json:"target"`
+		} `json:"nodes"`
+	} `json:"refs"`
+}
+
+func (r graphqlRepository) toRepository() *github.Repository {
+	return &github.Repository{
+		ID:       github.Int64(r.DatabaseID),
+		Name:     github.String(r.Name),
+		FullName: github.String(r.NameWithOwner),
+		HTMLURL:  github.String(r.URL),
+		Owner:    &github.User{Login: github.String(r.Owner.Login)},
+	}
+}
+
+// actionsAppSlug is slug of GitHub Apps that creates check suites of GitHub Actions
+const actionsAppSlug = "github-actions"
+
+// queuedCheckRunIDs return IDs of queued check runs in check suites of GitHub Actions that are not completed
+func (r graphqlRepository) queuedCheckRunIDs() []int64 {
+	var ids []int64
+	seen := map[int64]struct{}{}
+	for _, ref := range r.Refs.Nodes {
+		for _, suite := range ref.Target.CheckSuites.Nodes {
+			if suite.Status == "COMPLETED" || suite.App.Slug != actionsAppSlug {
+				continue
+			}
+			for _, run := range suite.CheckRuns.Nodes {
+				if _, ok := seen[run.DatabaseID]; ok {
+					continue
+				}
+				seen[run.DatabaseID] = struct{}{}
+				ids = append(ids, run.DatabaseID)
+			}
+		}
+	}
+	return ids
+}
+
+// listQueuedJobsByGraphQL list queued workflow jobs in scope by GraphQL API.
+// GraphQL find IDs of queued jobs in one query, and only queued jobs are fetched by REST API for labels.
+func listQueuedJobsByGraphQL(ctx context.Context, client *github.Client, scope string) ([]QueuedJob, error) {
+	owner, repoName := DivideScope(scope)
+	var repositories []graphqlRepository
+	switch DetectScope(scope) {
+	case Repository:
+		var data struct {
+			Repository *graphqlRepository `json:"repository"`
+		}
+		if err := queryGraphQL(ctx, client, queuedCheckRunsInRepositoryQuery, map[string]any{"owner": owner, "name": repoName}, &data); err != nil {
+			return nil, err
+		}
+		if data.Repository == nil {
+			return nil, fmt.Errorf("%s: %w", scope, ErrNotFound)
+		}
+		repositories = append(repositories, *data.Repository)
+	case Organization:
+		var data struct {
+			Organization *struct {
+				Repositories struct {
+					Nodes []graphqlRepository `json:"nodes"`
+				} `json:"repositories"`
+			} `json:"organization"`
+		}
+		if err := queryGraphQL(ctx, client, queuedCheckRunsInOrganizationQuery, map[string]any{"owner": owner}, &data); err != nil {
+			return nil, err
+		}
+		if data.Organization == nil {
+			return nil, fmt.Errorf("%s: %w", scope, ErrNotFound)
+		}
+		repositories = data.Organization.Repositories.Nodes
+	default:
+		return nil, fmt.Errorf("%s is not supported scope to list queued jobs", scope)
+	}
+
+	var queued []QueuedJob
+	for _, r := range repositories {
+		repo := r.toRepository()
+		for _, id := range r.queuedCheckRunIDs() {
+			job, resp, err := client.Actions.GetWorkflowJobByID(ctx, r.Owner.Login, r.Name, id)
+			if err != nil {
+				if resp != nil && resp.StatusCode == http.StatusNotFound {
+					// job is deleted
+					continue
+				}
+				return nil, fmt.Errorf("failed to get workflow job (job ID: %d): %w", id, err)
+			}
+			storeRateLimit(getRateLimitKey(r.Owner.Login, r.Name), resp.Rate)
+			if job.GetStatus() == "queued" {
+				queued = append(queued, QueuedJob{Repository: repo, Job: job})
+			}
+		}
+	}
+	return queued, nil
+}
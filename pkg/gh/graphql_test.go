@@ -0,0 +1,44 @@
+package gh
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestGraphqlRepository_QueuedCheckRunIDs(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []int64
+	}{
+		{
+			input: `{"refs": {"nodes": [
+  {"target": {"checkSuites": {"nodes": [
+    {"status": "QUEUED", "app": {"slug": "github-actions"}, "checkRuns": {"nodes": [{"databaseId": 1}, {"databaseId": 2}]}},
+    {"status": "IN_PROGRESS", "app": {"slug": "other-app"}, "checkRuns": {"nodes": [{"databaseId": 3}]}}
+  ]}}},
+  {"target": {"checkSuites": {"nodes": [
+    {"status": "IN_PROGRESS", "app": {"slug": "github-actions"}, "checkRuns": {"nodes": [{"databaseId": 2}, {"databaseId": 4}]}},
+    {"status": "COMPLETED", "app": {"slug": "github-actions"}, "checkRuns": {"nodes": [{"databaseId": 5}]}}
+  ]}}},
+  {"target": {}}
+]}}`,
+			want: []int64{1, 2, 4},
+		},
+		{
+			input: `{"refs": {"nodes": []}}`,
+			want:  nil,
+		},
+	}
+
+	for _, test := range tests {
+		var r graphqlRepository
+		if err := json.Unmarshal([]byte(test.input), &r); err != nil {
+			t.Fatalf("failed to unmarshal: %+v", err)
+		}
+		got := r.queuedCheckRunIDs()
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf("want %v, but got %v", test.want, got)
+		}
+	}
+}
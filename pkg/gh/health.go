@@ -0,0 +1,161 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+var (
+	// ErrGitHubDegraded is error for GitHub API is degraded
+	ErrGitHubDegraded = fmt.Errorf("GitHub API is degraded")
+
+	// DegradedWindow is window of counting requests to GitHub API
+	DegradedWindow = 1 * time.Minute
+	// DegradedMinRequests is minimum number of requests in DegradedWindow to detect degraded
+	DegradedMinRequests = 10
+	// DegradedErrorRate is threshold of error rate to detect degraded
+	DegradedErrorRate = 0.5
+	// RecoveryProbeInterval is interval time of probing GitHub API in degraded
+	RecoveryProbeInterval = 30 * time.Second
+
+	health = &healthTracker{}
+)
+
+type requestResult struct {
+	at     time.Time
+	failed bool
+}
+
+// healthTracker tracks results of requests to GitHub API
+type healthTracker struct {
+	mu       sync.Mutex
+	results  []requestResult
+	degraded bool
+}
+
+func (h *healthTracker) record(failed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	h.results = append(h.results, requestResult{at: now, failed: failed})
+	h.prune(now)
+
+	if h.degraded || len(h.results) < DegradedMinRequests {
+		return
+	}
+
+	var failures int
+	for _, r := range h.results {
+		if r.failed {
+			failures++
+		}
+	}
+	if float64(failures)/float64(len(h.results)) >= DegradedErrorRate {
+		logger.Logf(false, "GitHub API is degraded (failed %d of %d requests in %s), will pause provisioning", failures, len(h.results), DegradedWindow)
+		h.degraded = true
+	}
+}
+
+// prune remove results that older than DegradedWindow, need to lock before call
+func (h *healthTracker) prune(now time.Time) {
+	i := 0
+	for ; i < len(h.results); i++ {
+		if now.Sub(h.results[i].at) < DegradedWindow {
+			break
+		}
+	}
+	h.results = h.results[i:]
+}
+
+func (h *healthTracker) isDegraded() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.degraded
+}
+
+func (h *healthTracker) recover() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.degraded = false
+	h.results = nil
+}
+
+// IsDegraded return true if GitHub API is degraded
+func IsDegraded() bool {
+	return health.isDegraded()
+}
+
+// healthTransport is transport that records results of requests to GitHub API.
+// requests are also counted as usage of budget if scope is set by WithBudgetScope, except 304 Not Modified.
+type healthTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implement http.RoundTripper
+func (t *healthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if scope, ok := budgetScopeFromContext(req.Context()); ok && (err != nil || resp.StatusCode != http.StatusNotModified) {
+		// 304 Not Modified does not count against rate limit
+		budgets.consume(scope)
+	}
+	if err != nil {
+		health.record(true)
+		return nil, err
+	}
+
+	// 4xx (e.g. rate limit) is not an outage
+	health.record(resp.StatusCode >= http.StatusInternalServerError)
+	return resp, nil
+}
+
+// LoopRecoveryProbe probe GitHub API in degraded, and recover if probe is succeeded
+func LoopRecoveryProbe(ctx context.Context) error {
+	ticker := time.NewTicker(RecoveryProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !IsDegraded() {
+				continue
+			}
+
+			if err := probe(ctx); err != nil {
+				logger.Logf(false, "GitHub API is still degraded: %+v", err)
+				continue
+			}
+			logger.Logf(false, "GitHub API is recovered, will resume provisioning")
+			health.recover()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// probe request to GitHub API without cache, cached response does not mean GitHub API is available
+func probe(ctx context.Context) error {
+	ctx = withoutCache(ctx)
+	clientApps, err := NewClientGitHubApps()
+	if err != nil {
+		return fmt.Errorf("failed to create a client Apps: %w", err)
+	}
+
+	if IsPATMode() {
+		if _, _, err := clientApps.Users.Get(ctx, ""); err != nil {
+			return fmt.Errorf("failed to get authenticated user: %w", err)
+		}
+		return nil
+	}
+	if _, _, err := clientApps.Apps.Get(ctx, ""); err != nil {
+		return fmt.Errorf("failed to get GitHub Apps: %w", err)
+	}
+	return nil
+}
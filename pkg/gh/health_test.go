@@ -0,0 +1,41 @@
+package gh
+
+import (
+	"testing"
+)
+
+func TestHealthTracker(t *testing.T) {
+	tests := []struct {
+		input []bool // failed
+		want  bool
+	}{
+		{
+			input: []bool{true, true, true},
+			want:  false, // less than DegradedMinRequests
+		},
+		{
+			input: []bool{false, false, false, false, false, false, false, false, false, true},
+			want:  false,
+		},
+		{
+			input: []bool{true, false, true, false, true, false, true, false, true, false},
+			want:  true,
+		},
+	}
+
+	for _, test := range tests {
+		h := &healthTracker{}
+		for _, failed := range test.input {
+			h.record(failed)
+		}
+
+		if got := h.isDegraded(); got != test.want {
+			t.Fatalf("want %t, but got %t", test.want, got)
+		}
+
+		h.recover()
+		if h.isDegraded() {
+			t.Fatalf("must not be degraded after recover")
+		}
+	}
+}
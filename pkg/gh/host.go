@@ -0,0 +1,316 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/google/go-github/v47/github"
+	"golang.org/x/oauth2"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+// githubDotCom is URL of github.com
+const githubDotCom = "https://github.com"
+
+// ErrUnknownHost is error for GitHub host that is not configured
+var ErrUnknownHost = fmt.Errorf("GitHub host is not configured")
+
+// Host is a GitHub (github.com or GitHub Enterprise Server) that myshoes serves, it has own GitHub Apps.
+// primary host is GITHUB_URL, additional hosts are GITHUB_HOSTS.
+type Host struct {
+	primary bool
+	conf    config.GitHubHost // only in additional host
+
+	// appTransport is transport for GitHub Apps
+	appTransport ghinstallation.AppsTransport
+	// installationTransports is map of ghinstallation.Transport for cache token of installation.
+	// key: installationID, value: ghinstallation.Transport
+	installationTransports sync.Map
+}
+
+var (
+	primaryHost = &Host{primary: true}
+	// additionalHosts is hosts in GITHUB_HOSTS. key: normalized URL, value: *Host
+	additionalHosts = sync.Map{}
+)
+
+type hostKey struct{}
+
+// WithHost set GitHub host to context, functions that receive context use it
+func WithHost(ctx context.Context, h *Host) context.Context {
+	return context.WithValue(ctx, hostKey{}, h)
+}
+
+// HostFrom return GitHub host in context, return primary host if not set
+func HostFrom(ctx context.Context) *Host {
+	if h, ok := ctx.Value(hostKey{}).(*Host); ok && h != nil {
+		return h
+	}
+	return primaryHost
+}
+
+// PrimaryHost return GitHub host of GITHUB_URL
+func PrimaryHost() *Host {
+	return primaryHost
+}
+
+// LookupHost return GitHub host of domain (e.g. ghe_domain of target).
+// empty domain is github.com as same as URL of repository in job, it is primary host if github.com is not configured.
+func LookupHost(domain string) (*Host, error) {
+	if domain == "" {
+		domain = githubDotCom
+	}
+	key := normalizeHostURL(domain)
+	if key == normalizeHostURL(primaryHost.URL()) {
+		return primaryHost, nil
+	}
+	if h, ok := additionalHosts.Load(key); ok {
+		return h.(*Host), nil
+	}
+	if key == githubDotCom {
+		return primaryHost, nil
+	}
+	return nil, fmt.Errorf("%s: %w", domain, ErrUnknownHost)
+}
+
+// Hosts return all GitHub hosts, first one is primary host
+func Hosts() []*Host {
+	hosts := []*Host{primaryHost}
+	additionalHosts.Range(func(_, value any) bool {
+		hosts = append(hosts, value.(*Host))
+		return true
+	})
+	return hosts
+}
+
+func normalizeHostURL(u string) string {
+	return strings.ToLower(strings.TrimSuffix(u, "/"))
+}
+
+// initializeAdditionalHosts create transports of GitHub Apps in additional hosts
+func initializeAdditionalHosts(hosts []config.GitHubHost) error {
+	for _, conf := range hosts {
+		h := &Host{conf: conf}
+		itr, err := ghinstallation.NewAppsTransport(newBaseTransport(), conf.App.AppID, conf.App.PEMByte)
+		if err != nil {
+			return fmt.Errorf("failed to create Apps transport (host: %s): %w", conf.URL, err)
+		}
+		h.appTransport = *itr
+		additionalHosts.Store(normalizeHostURL(conf.URL), h)
+	}
+	return nil
+}
+
+// URL return URL of GitHub (e.g. https://github.com)
+func (h *Host) URL() string {
+	if h.primary {
+		return config.Config.GitHubURL
+	}
+	return h.conf.URL
+}
+
+// Hostname return host name of GitHub (e.g. github.example.com)
+func (h *Host) Hostname() string {
+	u, err := url.Parse(h.URL())
+	if err != nil {
+		return h.URL()
+	}
+	return u.Host
+}
+
+// IsGHES return true if host is GitHub Enterprise Server
+func (h *Host) IsGHES() bool {
+	return normalizeHostURL(h.URL()) != githubDotCom
+}
+
+// GHEDomain return value of ghe_domain in datastore, it is empty in github.com
+func (h *Host) GHEDomain() string {
+	if !h.IsGHES() {
+		return ""
+	}
+	return strings.TrimSuffix(h.URL(), "/")
+}
+
+// Secrets return secrets of webhook in GitHub Apps of host
+func (h *Host) Secrets() [][]byte {
+	if h.primary {
+		return config.Config.GitHub.Secrets()
+	}
+	return h.conf.App.Secrets()
+}
+
+func (h *Host) apiURL() string {
+	if h.primary {
+		return config.Config.GitHubAPIURL
+	}
+	return h.conf.APIURL
+}
+
+func (h *Host) uploadURL() string {
+	if h.primary {
+		return config.Config.GitHubUploadURL
+	}
+	return h.conf.UploadURL
+}
+
+// NewClient create a client of GitHub
+func (h *Host) NewClient(token string) (*github.Client, error) {
+	transport := &oauth2.Transport{
+		Source: oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: token},
+		),
+		Base: newBaseTransport(),
+	}
+
+	if !h.IsGHES() {
+		return github.NewClient(newHTTPClient(transport)), nil
+	}
+
+	baseURL, uploadURL := h.enterpriseURLs()
+	return github.NewEnterpriseClient(baseURL, uploadURL, newHTTPClient(transport))
+}
+
+// NewClientGitHubApps create a client of GitHub using Private Key from GitHub Apps of host
+func (h *Host) NewClientGitHubApps() (*github.Client, error) {
+	if IsPATMode() {
+		return h.NewClient(config.Config.GitHubPAT)
+	}
+	if !h.IsGHES() {
+		return github.NewClient(newHTTPClient(&h.appTransport)), nil
+	}
+
+	apiEndpoint, err := h.apiEndpoint()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GitHub API Endpoint: %w", err)
+	}
+
+	itr := h.appTransport
+	itr.BaseURL = strings.TrimSuffix(apiEndpoint.String(), "/")
+	baseURL, uploadURL := h.enterpriseURLs()
+	return github.NewEnterpriseClient(baseURL, uploadURL, newHTTPClient(&itr))
+}
+
+// NewClientInstallation create a client of GitHub using installation ID from GitHub Apps of host
+func (h *Host) NewClientInstallation(installationID int64) (*github.Client, error) {
+	if IsPATMode() {
+		return h.NewClient(config.Config.GitHubPAT)
+	}
+	itr := h.getInstallationTransport(installationID)
+
+	if !h.IsGHES() {
+		return github.NewClient(newHTTPClient(itr)), nil
+	}
+	apiEndpoint, err := h.apiEndpoint()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GitHub API Endpoint: %w", err)
+	}
+	itr.BaseURL = strings.TrimSuffix(apiEndpoint.String(), "/")
+	baseURL, uploadURL := h.enterpriseURLs()
+	return github.NewEnterpriseClient(baseURL, uploadURL, newHTTPClient(itr))
+}
+
+// CheckSignature check trust installation id from event.
+func (h *Host) CheckSignature(installationID int64) error {
+	if IsPATMode() {
+		return nil
+	}
+	if itr := ghinstallation.NewFromAppsTransport(&h.appTransport, installationID); itr == nil {
+		return fmt.Errorf("failed to create GitHub installation")
+	}
+
+	return nil
+}
+
+func (h *Host) getInstallationTransport(installationID int64) *ghinstallation.Transport {
+	got, found := h.installationTransports.Load(installationID)
+	if !found {
+		return h.generateInstallationTransport(installationID)
+	}
+
+	itr, ok := got.(ghinstallation.Transport)
+	if !ok {
+		return h.generateInstallationTransport(installationID)
+	}
+	return &itr
+}
+
+func (h *Host) generateInstallationTransport(installationID int64) *ghinstallation.Transport {
+	itr := ghinstallation.NewFromAppsTransport(&h.appTransport, installationID)
+	h.installationTransports.Store(installationID, *itr)
+	return itr
+}
+
+// enterpriseURLs return base URL and upload URL for GitHub Enterprise Server.
+// API URL and upload URL are used if set, URL of host is used if not.
+func (h *Host) enterpriseURLs() (string, string) {
+	baseURL := h.URL()
+	if h.apiURL() != "" {
+		baseURL = h.apiURL()
+	}
+
+	uploadURL := h.URL()
+	if h.uploadURL() != "" {
+		uploadURL = h.uploadURL()
+	}
+
+	return baseURL, uploadURL
+}
+
+func (h *Host) apiEndpoint() (*url.URL, error) {
+	var apiEndpoint *url.URL
+	if h.apiURL() != "" {
+		u, err := url.Parse(h.apiURL())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse GitHub API url: %w", err)
+		}
+		apiEndpoint = u
+	} else if h.IsGHES() {
+		u, err := url.Parse(h.URL())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse GHE url: %w", err)
+		}
+
+		p := u.Path
+		p = path.Join(p, "api", "v3")
+		u.Path = p
+		apiEndpoint = u
+	} else {
+		u, err := url.Parse("https://api.github.com")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse github.com: %w", err)
+		}
+		apiEndpoint = u
+	}
+
+	return apiEndpoint, nil
+}
+
+func (h *Host) repositoryURL(scope string) (string, error) {
+	// github.com
+	//   => https://api.github.com/repos/:owner/:repo
+	//   => https://api.github.com/orgs/:owner
+	// GitHub Enterprise Server
+	//   => https://{your_ghe_server_url}/api/repos/:owner/:repo
+	//   => https://{your_ghe_server_url}/api/orgs/:owner
+
+	s := DetectScope(scope)
+	if s == Unknown {
+		return "", fmt.Errorf("failed to detect valid scope")
+	}
+
+	apiEndpoint, err := h.apiEndpoint()
+	if err != nil {
+		return "", fmt.Errorf("failed to get API Endpoint: %w", err)
+	}
+
+	p := path.Join(apiEndpoint.Path, s.String(), scope)
+	apiEndpoint.Path = p
+
+	return apiEndpoint.String(), nil
+}
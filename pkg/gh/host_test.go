@@ -0,0 +1,52 @@
+package gh
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+func TestLookupHost(t *testing.T) {
+	config.Config.GitHubURL = "https://github.com"
+	ghes := &Host{conf: config.GitHubHost{URL: "https://github.example.com"}}
+	additionalHosts.Store(normalizeHostURL(ghes.conf.URL), ghes)
+	defer additionalHosts.Delete(normalizeHostURL(ghes.conf.URL))
+
+	tests := []struct {
+		input string
+		want  *Host
+		err   error
+	}{
+		{
+			input: "",
+			want:  primaryHost,
+		},
+		{
+			input: "https://github.com",
+			want:  primaryHost,
+		},
+		{
+			input: "https://github.example.com",
+			want:  ghes,
+		},
+		{
+			input: "https://GitHub.example.com/",
+			want:  ghes,
+		},
+		{
+			input: "https://unknown.example.com",
+			err:   ErrUnknownHost,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := LookupHost(test.input)
+		if !errors.Is(err, test.err) {
+			t.Fatalf("LookupHost(%q) want err %+v, but return err %+v", test.input, test.err, err)
+		}
+		if got != test.want {
+			t.Errorf("LookupHost(%q) return unexpected host (want: %+v, got: %+v)", test.input, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v47/github"
+)
+
+var (
+	// ErrJITConfigUnsupported is error for GitHub that not support JIT config (e.g. GHES older than 3.10)
+	ErrJITConfigUnsupported = fmt.Errorf("JIT config is not supported")
+)
+
+// defaultRunnerGroupID is ID of Default runner group
+const defaultRunnerGroupID = 1
+
+type jitConfigRequest struct {
+	Name          string   `json:"name"`
+	RunnerGroupID int64    `json:"runner_group_id"`
+	Labels        []string `json:"labels"`
+	WorkFolder    string   `json:"work_folder"`
+}
+
+type jitConfigResponse struct {
+	EncodedJITConfig string `json:"encoded_jit_config"`
+}
+
+// GenerateJITConfig generate just-in-time config of a runner. config is single-use and registers only a runner that has runnerName.
+// return ErrJITConfigUnsupported if GitHub does not have the API.
+// docs: https://docs.github.com/en/rest/actions/self-hosted-runners#create-configuration-for-a-just-in-time-runner-for-an-organization
+func GenerateJITConfig(ctx context.Context, installationID int64, scope, runnerName string, labels []string) (string, error) {
+	if IsDegraded() {
+		return "", ErrGitHubDegraded
+	}
+	if !HostFrom(ctx).IsSupported(FeatureJITConfig) {
+		return "", ErrJITConfigUnsupported
+	}
+
+	clientInstallation, err := HostFrom(ctx).NewClientInstallation(installationID)
+	if err != nil {
+		return "", fmt.Errorf("failed to create a client installation: %w", err)
+	}
+
+	var u string
+	switch DetectScope(scope) {
+	case Organization:
+		u = fmt.Sprintf("orgs/%s/actions/runners/generate-jitconfig", scope)
+	case Repository:
+		owner, repo := DivideScope(scope)
+		u = fmt.Sprintf("repos/%s/%s/actions/runners/generate-jitconfig", owner, repo)
+	case Enterprise:
+		u = fmt.Sprintf("enterprises/%s/actions/runners/generate-jitconfig", EnterpriseSlug(scope))
+	default:
+		return "", fmt.Errorf("failed to detect scope (scope: %s)", scope)
+	}
+
+	req, err := clientInstallation.NewRequest(http.MethodPost, u, jitConfigRequest{
+		Name:          runnerName,
+		RunnerGroupID: defaultRunnerGroupID,
+		Labels:        labels,
+		WorkFolder:    "_work",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	var resp jitConfigResponse
+	if _, err := clientInstallation.Do(ctx, req, &resp); err != nil {
+		var errResp *github.ErrorResponse
+		if errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound {
+			return "", ErrJITConfigUnsupported
+		}
+		return "", fmt.Errorf("failed to generate JIT config (scope: %s): %w", scope, err)
+	}
+	return resp.EncodedJITConfig, nil
+}
@@ -3,15 +3,18 @@ package gh
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
-	"github.com/whywaita/myshoes/pkg/config"
 	"github.com/whywaita/myshoes/pkg/logger"
 
 	"github.com/google/go-github/v47/github"
 )
 
+// ErrUserOwnerScope is error for scope of user account, user-owned repositories support only repository scope
+var ErrUserOwnerScope = fmt.Errorf("user account can not be a scope, set a repository of user (:owner/:repo) as a scope")
+
 // function pointers (for testing)
 var (
 	GHlistInstallations     = listInstallations
@@ -20,32 +23,52 @@ var (
 
 // GenerateGitHubAppsToken generate token of GitHub Apps using private key
 // clientApps needs to response of `NewClientGitHubApps()`
+// token is cached and refreshed before expiry by LoopRefreshInstallationTokens
 func GenerateGitHubAppsToken(ctx context.Context, clientApps *github.Client, installationID int64, scope string) (string, *time.Time, error) {
-	token, resp, err := clientApps.Apps.CreateInstallationToken(ctx, installationID, nil)
-	if err != nil {
-		return "", nil, fmt.Errorf("failed to generate token from API: %w", err)
+	if IsDegraded() {
+		return "", nil, ErrGitHubDegraded
 	}
-	storeRateLimit(scope, resp.Rate)
-	return *token.Token, token.ExpiresAt, nil
+	if IsPATMode() {
+		token, expiredAt := patToken()
+		return token, expiredAt, nil
+	}
+
+	return getInstallationToken(ctx, clientApps, installationID, scope)
 }
 
 // IsInstalledGitHubApp check installed GitHub Apps in gheDomain + inputScope
 // clientApps needs to response of `NewClientGitHubApps()`
+// in PAT mode, it does not check and return PATInstallationID, permission of PAT is checked by API
 func IsInstalledGitHubApp(ctx context.Context, inputScope string) (int64, error) {
+	if IsPATMode() {
+		return PATInstallationID, nil
+	}
 	installations, err := GHlistInstallations(ctx)
 	if err != nil {
 		return -1, fmt.Errorf("failed to get list of installations: %w", err)
 	}
 
+	owner, _ := DivideScope(inputScope)
 	for _, i := range installations {
 		if i.SuspendedAt != nil {
 			continue
 		}
 
-		if strings.HasPrefix(inputScope, *i.Account.Login) {
+		if DetectScope(inputScope) == Enterprise {
+			if isEnterpriseInstallation(i, inputScope) {
+				return *i.ID, nil
+			}
+			continue
+		}
+
+		// account of enterprise installation does not have login
+		if login := i.GetAccount().GetLogin(); login != "" && strings.EqualFold(login, owner) {
 			// i.Account.Login is username or Organization name.
 			// e.g.) `https://github.com/example/sample` -> `example/sample`
-			// strings.HasPrefix search scope include i.Account.Login.
+			if isUserInstallation(i) && DetectScope(inputScope) == Organization {
+				// user account does not have runners in owner level, only repository level.
+				return -1, fmt.Errorf("%s: %w", inputScope, ErrUserOwnerScope)
+			}
 
 			switch {
 			case strings.EqualFold(*i.RepositorySelection, "all"):
@@ -63,7 +86,44 @@ func IsInstalledGitHubApp(ctx context.Context, inputScope string) (int64, error)
 		}
 	}
 
-	return -1, fmt.Errorf("%s/%s is not installed configured GitHub Apps", config.Config.GitHubURL, inputScope)
+	return -1, fmt.Errorf("%s/%s is not installed configured GitHub Apps", HostFrom(ctx).URL(), inputScope)
+}
+
+// InstallationScope return scope of target that installation covers (organization or enterprise).
+// return false if installation is installed to user account, it has not scope of owner level
+func InstallationScope(i *github.Installation) (string, bool) {
+	if strings.EqualFold(i.GetTargetType(), "Enterprise") {
+		u, err := url.Parse(i.GetAccount().GetHTMLURL())
+		if err != nil || DetectScope(strings.Trim(u.Path, "/")) != Enterprise {
+			return "", false
+		}
+		return strings.Trim(u.Path, "/"), true
+	}
+	if isUserInstallation(i) || i.GetAccount().GetLogin() == "" {
+		return "", false
+	}
+	return i.GetAccount().GetLogin(), true
+}
+
+// isUserInstallation return true if installation is installed to user account (not organization)
+func isUserInstallation(i *github.Installation) bool {
+	if t := i.GetTargetType(); t != "" {
+		return strings.EqualFold(t, "User")
+	}
+	return strings.EqualFold(i.GetAccount().GetType(), "User")
+}
+
+// isEnterpriseInstallation return true if installation is installed to enterprise of scope.
+// account of enterprise is detected by URL (e.g. https://github.com/enterprises/:enterprise), it does not have login.
+func isEnterpriseInstallation(i *github.Installation, scope string) bool {
+	if !strings.EqualFold(i.GetTargetType(), "Enterprise") {
+		return false
+	}
+	u, err := url.Parse(i.GetAccount().GetHTMLURL())
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(strings.Trim(u.Path, "/"), scope)
 }
 
 func isInstalledGitHubAppSelected(ctx context.Context, inputScope string, installationID int64) error {
@@ -95,7 +155,7 @@ func isInstalledGitHubAppSelected(ctx context.Context, inputScope string, instal
 }
 
 func listAppsInstalledRepo(ctx context.Context, installationID int64) ([]*github.Repository, error) {
-	clientInstallation, err := NewClientInstallation(installationID)
+	clientInstallation, err := HostFrom(ctx).NewClientInstallation(installationID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a client installation: %w", err)
 	}
@@ -123,7 +183,10 @@ func listAppsInstalledRepo(ctx context.Context, installationID int64) ([]*github
 }
 
 func listInstallations(ctx context.Context) ([]*github.Installation, error) {
-	clientApps, err := NewClientGitHubApps()
+	if IsPATMode() {
+		return nil, ErrNotAvailableInPATMode
+	}
+	clientApps, err := HostFrom(ctx).NewClientGitHubApps()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a client Apps: %w", err)
 	}
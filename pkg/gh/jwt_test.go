@@ -13,11 +13,17 @@ func setStubFunctions() {
 		i10 := int64(10)
 		i11 := int64(11)
 		i12 := int64(12)
+		i13 := int64(13)
+		i14 := int64(14)
 		all := "all"
 		selected := "selected"
 		exampleAll := "example-all"
 		exampleSelected := "example-selected"
 		exampleSuspented := "example-suspended"
+		enterprise := "Enterprise"
+		enterpriseURL := "https://github.com/enterprises/octo-enterprise"
+		exampleUser := "example-user"
+		user := "User"
 
 		return []*github.Installation{
 			{
@@ -46,6 +52,23 @@ func setStubFunctions() {
 					Time: time.Now(),
 				},
 			},
+			{
+				ID: &i13,
+				Account: &github.User{
+					HTMLURL: &enterpriseURL,
+				},
+				RepositorySelection: &all,
+				TargetType:          &enterprise,
+			},
+			{
+				ID: &i14,
+				Account: &github.User{
+					Login: &exampleUser,
+					Type:  &user,
+				},
+				RepositorySelection: &all,
+				TargetType:          &user,
+			},
 		}, nil
 	}
 
@@ -118,6 +141,46 @@ func Test_IsInstalledGitHubApp(t *testing.T) {
 			want: -1,
 			err:  true,
 		},
+		{
+			input: struct {
+				gheDomain string
+				scope     string
+			}{gheDomain: "", scope: "enterprises/octo-enterprise"},
+			want: 13,
+			err:  false,
+		},
+		{
+			input: struct {
+				gheDomain string
+				scope     string
+			}{gheDomain: "", scope: "enterprises/other-enterprise"},
+			want: -1,
+			err:  true,
+		},
+		{
+			input: struct {
+				gheDomain string
+				scope     string
+			}{gheDomain: "", scope: "example-all-other/sample"},
+			want: -1,
+			err:  true,
+		},
+		{
+			input: struct {
+				gheDomain string
+				scope     string
+			}{gheDomain: "", scope: "example-user/sample"},
+			want: 14,
+			err:  false,
+		},
+		{
+			input: struct {
+				gheDomain string
+				scope     string
+			}{gheDomain: "", scope: "example-user"},
+			want: -1,
+			err:  true,
+		},
 	}
 
 	for _, test := range tests {
@@ -131,3 +194,22 @@ func Test_IsInstalledGitHubApp(t *testing.T) {
 		}
 	}
 }
+
+func TestInstallationScope(t *testing.T) {
+	setStubFunctions()
+	installations, _ := GHlistInstallations(context.Background())
+
+	want := map[int64]string{
+		10: "example-all",
+		11: "example-selected",
+		12: "example-suspended",
+		13: "enterprises/octo-enterprise",
+	}
+	for _, i := range installations {
+		got, ok := InstallationScope(i)
+		w, wantOK := want[i.GetID()]
+		if ok != wantOK || got != w {
+			t.Errorf("installation %d: want (%q, %t), but got (%q, %t)", i.GetID(), w, wantOK, got, ok)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package gh
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	apiRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "myshoes",
+		Subsystem: "github",
+		Name:      "api_requests_total",
+		Help:      "Total number of requests to GitHub API per endpoint group and status code.",
+	}, []string{"endpoint", "code"})
+	apiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "myshoes",
+		Subsystem: "github",
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of requests to GitHub API per endpoint group.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+)
+
+func init() {
+	prometheus.MustRegister(apiRequests, apiRequestDuration)
+}
+
+// endpointGroup is group of GitHub API endpoints, it is label of metrics
+type endpointGroup struct {
+	name   string
+	method string
+	path   *regexp.Regexp
+}
+
+// endpointGroups is ordered list of endpoint groups, first matched group is used.
+// path is without prefix of GitHub Enterprise Server (/api/v3)
+var endpointGroups = []endpointGroup{
+	{name: "create-installation-token", method: http.MethodPost, path: regexp.MustCompile(`^/app/installations/\d+/access_tokens$`)},
+	{name: "list-installations", method: http.MethodGet, path: regexp.MustCompile(`^/app/installations$`)},
+	{name: "get-app", method: http.MethodGet, path: regexp.MustCompile(`^/app$`)},
+	{name: "list-installation-repositories", method: http.MethodGet, path: regexp.MustCompile(`^/installation/repositories$`)},
+	{name: "list-deliveries", method: http.MethodGet, path: regexp.MustCompile(`^/app/hook/deliveries(/\d+)?$`)},
+	{name: "redeliver", method: http.MethodPost, path: regexp.MustCompile(`^/app/hook/deliveries/\d+/attempts$`)},
+	{name: "create-registration-token", method: http.MethodPost, path: regexp.MustCompile(`/actions/runners/registration-token$`)},
+	{name: "create-remove-token", method: http.MethodPost, path: regexp.MustCompile(`/actions/runners/remove-token$`)},
+	{name: "generate-jitconfig", method: http.MethodPost, path: regexp.MustCompile(`/actions/runners/generate-jitconfig$`)},
+	{name: "list-runner-downloads", method: http.MethodGet, path: regexp.MustCompile(`/actions/runners/downloads$`)},
+	{name: "list-runners", method: http.MethodGet, path: regexp.MustCompile(`/actions/runners$`)},
+	{name: "get-runner", method: http.MethodGet, path: regexp.MustCompile(`/actions/runners/\d+$`)},
+	{name: "remove-runner", method: http.MethodDelete, path: regexp.MustCompile(`/actions/runners/\d+$`)},
+	{name: "list-workflow-jobs", method: http.MethodGet, path: regexp.MustCompile(`/actions/runs/\d+/jobs$`)},
+	{name: "list-workflow-runs", method: http.MethodGet, path: regexp.MustCompile(`/actions/runs$`)},
+	{name: "get-workflow-run", method: http.MethodGet, path: regexp.MustCompile(`/actions/runs/\d+$`)},
+	{name: "list-organization-repositories", method: http.MethodGet, path: regexp.MustCompile(`^/orgs/[^/]+/repos$`)},
+	{name: "get-repository", method: http.MethodGet, path: regexp.MustCompile(`^/repos/[^/]+/[^/]+$`)},
+	{name: "graphql", method: http.MethodPost, path: regexp.MustCompile(`^(/api)?/graphql$`)},
+	{name: "rate-limit", method: http.MethodGet, path: regexp.MustCompile(`^/rate_limit$`)},
+	{name: "meta", method: http.MethodGet, path: regexp.MustCompile(`^/meta$`)},
+	{name: "runner-scale-set", method: "", path: regexp.MustCompile(`/_apis/runtime/|/actions/runner-registration$`)},
+}
+
+// toEndpointGroup return name of endpoint group of request, "other" if not matched
+func toEndpointGroup(method, path string) string {
+	path = strings.TrimSuffix(strings.TrimPrefix(path, "/api/v3"), "/")
+	for _, g := range endpointGroups {
+		if g.method != "" && g.method != method {
+			continue
+		}
+		if g.path.MatchString(path) {
+			return g.name
+		}
+	}
+	return "other"
+}
+
+// metricTransport is transport that records count and latency of requests to GitHub API.
+// it records each attempt of request, So retried requests are counted as usage of rate limit.
+type metricTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implement http.RoundTripper
+func (t *metricTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := toEndpointGroup(req.Method, req.URL.Path)
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	apiRequestDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	apiRequests.WithLabelValues(endpoint, code).Inc()
+	return resp, err
+}
@@ -0,0 +1,33 @@
+package gh
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestToEndpointGroup(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{method: http.MethodPost, path: "/repos/octocat/hello-world/actions/runners/registration-token", want: "create-registration-token"},
+		{method: http.MethodPost, path: "/api/v3/orgs/octocat/actions/runners/registration-token", want: "create-registration-token"},
+		{method: http.MethodGet, path: "/orgs/octocat/actions/runners", want: "list-runners"},
+		{method: http.MethodGet, path: "/repos/octocat/hello-world/actions/runners/10", want: "get-runner"},
+		{method: http.MethodDelete, path: "/repos/octocat/hello-world/actions/runners/10", want: "remove-runner"},
+		{method: http.MethodGet, path: "/repos/octocat/hello-world/actions/runs/30", want: "get-workflow-run"},
+		{method: http.MethodGet, path: "/app/installations", want: "list-installations"},
+		{method: http.MethodPost, path: "/app/installations/100/access_tokens", want: "create-installation-token"},
+		{method: http.MethodGet, path: "/repos/octocat/hello-world/actions/runs/10/jobs", want: "list-workflow-jobs"},
+		{method: http.MethodGet, path: "/repos/octocat/hello-world", want: "get-repository"},
+		{method: http.MethodGet, path: "/users/octocat", want: "other"},
+	}
+
+	for _, test := range tests {
+		got := toEndpointGroup(test.method, test.path)
+		if got != test.want {
+			t.Errorf("%s %s: want %s, but got %s", test.method, test.path, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package gh
+
+import (
+	"errors"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+// PATInstallationID is installation ID in personal access token mode.
+// webhooks that are not from GitHub Apps (e.g. webhook of repository) have no installation
+const PATInstallationID int64 = 0
+
+// patTokenLifetime is lifetime of token in personal access token mode.
+// expiration of PAT is unknown, So it is refreshed by same loop as installation token
+const patTokenLifetime = 1 * time.Hour
+
+// ErrNotAvailableInPATMode is error for features that need GitHub Apps
+var ErrNotAvailableInPATMode = errors.New("not available in personal access token mode, it needs GitHub Apps")
+
+// IsPATMode return true if myshoes authenticates by a personal access token instead of GitHub Apps.
+// PAT mode is limited: all requests share rate limit of a user, and installation discovery
+// and sync of webhook deliveries are not available
+func IsPATMode() bool {
+	return config.Config.GitHubAuthMode == config.GitHubAuthModePAT
+}
+
+// patToken return personal access token and its (pseudo) expiration
+func patToken() (string, *time.Time) {
+	expiredAt := time.Now().Add(patTokenLifetime)
+	return config.Config.GitHubPAT, &expiredAt
+}
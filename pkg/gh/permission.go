@@ -0,0 +1,150 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+// ErrInsufficientPermissions is error for GitHub Apps that does not have permissions or events that myshoes needs
+var ErrInsufficientPermissions = errors.New("GitHub Apps does not have required permissions or events")
+
+// requiredPermission is a permission of GitHub Apps that myshoes needs
+type requiredPermission struct {
+	name  string
+	level string
+	get   func(p *github.InstallationPermissions) string
+}
+
+func (r requiredPermission) String() string {
+	return fmt.Sprintf("%s:%s", r.name, r.level)
+}
+
+var (
+	permissionMetadata = requiredPermission{name: "metadata", level: "read", get: (*github.InstallationPermissions).GetMetadata}
+	// actions:write is for re-running workflows that pending runners, and reading workflow jobs
+	permissionActions        = requiredPermission{name: "actions", level: "write", get: (*github.InstallationPermissions).GetActions}
+	permissionChecks         = requiredPermission{name: "checks", level: "read", get: (*github.InstallationPermissions).GetChecks}
+	permissionAdministration = requiredPermission{name: "administration", level: "write", get: (*github.InstallationPermissions).GetAdministration}
+	permissionOrgRunners     = requiredPermission{name: "organization_self_hosted_runners", level: "write", get: (*github.InstallationPermissions).GetOrganizationSelfHostedRunners}
+)
+
+// permissionLevel return order of permission level, none < read < write < admin
+func permissionLevel(level string) int {
+	switch level {
+	case "read":
+		return 1
+	case "write":
+		return 2
+	case "admin":
+		return 3
+	}
+	return 0
+}
+
+func (r requiredPermission) satisfied(p *github.InstallationPermissions) bool {
+	return permissionLevel(r.get(p)) >= permissionLevel(r.level)
+}
+
+// requiredEvent return webhook event that myshoes needs in MODE_WEBHOOK_TYPE
+func requiredEvent() string {
+	if config.Config.ModeWebhookType == config.ModeWebhookTypeCheckRun {
+		return "check_run"
+	}
+	return "workflow_job"
+}
+
+// MissingPermissions return permissions and events that are required in scope but not granted.
+// scope is empty for GitHub Apps itself, then one of permissions for repository or organization runners is required
+func MissingPermissions(p *github.InstallationPermissions, events []string, scope string) []string {
+	if p == nil {
+		p = &github.InstallationPermissions{}
+	}
+
+	required := []requiredPermission{permissionMetadata, permissionActions}
+	if config.Config.ModeWebhookType == config.ModeWebhookTypeCheckRun {
+		required = append(required, permissionChecks)
+	}
+	var missing []string
+	switch {
+	case scope == "":
+		if !permissionAdministration.satisfied(p) && !permissionOrgRunners.satisfied(p) {
+			missing = append(missing, fmt.Sprintf("permission %s or %s", permissionAdministration, permissionOrgRunners))
+		}
+	case DetectScope(scope) == Repository:
+		required = append(required, permissionAdministration)
+	case DetectScope(scope) == Organization:
+		required = append(required, permissionOrgRunners)
+	case DetectScope(scope) == Enterprise:
+		// runners of enterprise are managed by permission of enterprise, it is not in API of installation
+	}
+	for _, r := range required {
+		if !r.satisfied(p) {
+			missing = append(missing, fmt.Sprintf("permission %s (granted: %q)", r, r.get(p)))
+		}
+	}
+
+	event := requiredEvent()
+	found := false
+	for _, e := range events {
+		if e == event {
+			found = true
+			break
+		}
+	}
+	if !found {
+		missing = append(missing, fmt.Sprintf("event %s", event))
+	}
+	return missing
+}
+
+// ValidateAppPermissions check permissions and subscribed events of GitHub Apps in all hosts
+func ValidateAppPermissions(ctx context.Context) error {
+	if IsPATMode() {
+		return nil
+	}
+
+	var errs []error
+	for _, h := range Hosts() {
+		clientApps, err := h.NewClientGitHubApps()
+		if err != nil {
+			return fmt.Errorf("failed to create a client Apps (%s): %w", h.URL(), err)
+		}
+		app, _, err := clientApps.Apps.Get(WithHost(ctx, h), "")
+		if err != nil {
+			return fmt.Errorf("failed to get GitHub Apps (%s): %w", h.URL(), err)
+		}
+		if missing := MissingPermissions(app.Permissions, app.Events, ""); len(missing) != 0 {
+			errs = append(errs, fmt.Errorf("%w (app: %s, host: %s): %s", ErrInsufficientPermissions, app.GetSlug(), h.URL(), strings.Join(missing, ", ")))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckInstallationPermissions check permissions and subscribed events that are granted to installation of scope.
+// permissions of installation may be older than GitHub Apps until owner of installation accepts new permissions
+func CheckInstallationPermissions(ctx context.Context, installationID int64, scope string) error {
+	if IsPATMode() {
+		return nil
+	}
+
+	installations, err := GHlistInstallations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get list of installations: %w", err)
+	}
+	for _, i := range installations {
+		if i.GetID() != installationID {
+			continue
+		}
+		if missing := MissingPermissions(i.Permissions, i.Events, scope); len(missing) != 0 {
+			return fmt.Errorf("%w (installation ID: %d, scope: %s): %s", ErrInsufficientPermissions, installationID, scope, strings.Join(missing, ", "))
+		}
+		return nil
+	}
+	return fmt.Errorf("installation is not found (installation ID: %d): %w", installationID, ErrNotFound)
+}
@@ -0,0 +1,63 @@
+package gh
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v47/github"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+func TestMissingPermissions(t *testing.T) {
+	config.Config.ModeWebhookType = config.ModeWebhookTypeWorkflowJob
+
+	granted := &github.InstallationPermissions{
+		Metadata:       github.String("read"),
+		Actions:        github.String("write"),
+		Administration: github.String("write"),
+	}
+
+	tests := []struct {
+		permissions *github.InstallationPermissions
+		events      []string
+		scope       string
+		want        []string
+	}{
+		{permissions: granted, events: []string{"workflow_job"}, scope: "", want: nil},
+		{permissions: granted, events: []string{"workflow_job"}, scope: "octocat/hello-world", want: nil},
+		{
+			permissions: granted,
+			events:      []string{"workflow_job"},
+			scope:       "octocat",
+			want:        []string{`permission organization_self_hosted_runners:write (granted: "")`},
+		},
+		{
+			permissions: &github.InstallationPermissions{Metadata: github.String("read"), Actions: github.String("read")},
+			events:      []string{"check_run"},
+			scope:       "",
+			want: []string{
+				"permission administration:write or organization_self_hosted_runners:write",
+				`permission actions:write (granted: "read")`,
+				"event workflow_job",
+			},
+		},
+		{
+			permissions: nil,
+			events:      nil,
+			scope:       "enterprises/example",
+			want: []string{
+				`permission metadata:read (granted: "")`,
+				`permission actions:write (granted: "")`,
+				"event workflow_job",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got := MissingPermissions(test.permissions, test.events, test.scope)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("want %q, but got %q (scope: %q)", test.want, got, test.scope)
+		}
+	}
+}
@@ -2,9 +2,14 @@ package gh
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/go-github/v47/github"
+
+	"github.com/whywaita/myshoes/pkg/logger"
 )
 
 func storeRateLimit(scope string, rateLimit github.Rate) {
@@ -15,6 +20,69 @@ func storeRateLimit(scope string, rateLimit github.Rate) {
 
 	rateLimitLimit.Store(scope, rateLimit.Limit)
 	rateLimitRemain.Store(scope, rateLimit.Remaining)
+	rateLimitReset.Store(scope, rateLimit.Reset.Time)
+	storeRateLimitPause(scope, rateLimit)
+}
+
+// ErrRateLimitPaused is error for rate limit of installation is exhausted, requests are paused until reset
+var ErrRateLimitPaused = fmt.Errorf("rate limit of GitHub API is exhausted")
+
+// rateLimitPauses is installations that rate limit is exhausted, provisioning is paused until reset.
+// rate limit of GitHub Apps is shared in installation, so key is account of installation (owner of scope).
+// key: account, value: reset time
+var rateLimitPauses = sync.Map{}
+
+func installationAccount(scope string) string {
+	owner, _ := DivideScope(scope)
+	return strings.ToLower(owner)
+}
+
+func storeRateLimitPause(scope string, rateLimit github.Rate) {
+	account := installationAccount(scope)
+	if rateLimit.Remaining > 0 {
+		rateLimitPauses.Delete(account)
+		return
+	}
+	if _, loaded := rateLimitPauses.Swap(account, rateLimit.Reset.Time); !loaded {
+		logger.Logf(false, "rate limit of GitHub API is exhausted in %s, pause provisioning until %s", account, rateLimit.Reset.Time)
+	}
+}
+
+// IsRateLimitPaused return reset time and true if provisioning in installation of scope is paused by exhausted rate limit
+func IsRateLimitPaused(scope string) (time.Time, bool) {
+	return isRateLimitPaused(installationAccount(scope))
+}
+
+func isRateLimitPaused(account string) (time.Time, bool) {
+	v, ok := rateLimitPauses.Load(account)
+	if !ok {
+		return time.Time{}, false
+	}
+	reset := v.(time.Time)
+	if !time.Now().Before(reset) {
+		rateLimitPauses.CompareAndDelete(account, reset)
+		return time.Time{}, false
+	}
+	return reset, true
+}
+
+// GetRateLimitPauses get a list of installations that provisioning is paused by exhausted rate limit
+// key: account of installation, value: reset time
+func GetRateLimitPauses() map[string]time.Time {
+	m := map[string]time.Time{}
+
+	rateLimitPauses.Range(func(key, value interface{}) bool {
+		k, ok := key.(string)
+		if !ok {
+			return false
+		}
+		if reset, paused := isRateLimitPaused(k); paused {
+			m[k] = reset
+		}
+		return true
+	})
+
+	return m
 }
 
 func getRateLimitKey(org, repo string) string {
@@ -73,3 +141,59 @@ func GetRateLimitLimit() map[string]int {
 
 	return m
 }
+
+// GetRateLimitReset get a list of time that rate limit will reset
+// key: scope, value: reset time
+func GetRateLimitReset() map[string]time.Time {
+	m := map[string]time.Time{}
+
+	rateLimitReset.Range(func(key, value interface{}) bool {
+		k, ok := key.(string)
+		if !ok {
+			return false
+		}
+		v, ok := value.(time.Time)
+		if !ok {
+			return false
+		}
+
+		m[k] = v
+		return true
+	})
+
+	return m
+}
+
+// RateLimit is a tracked rate limit of scope
+type RateLimit struct {
+	Scope     string    `json:"scope"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+	Paused    bool      `json:"paused"` // provisioning in installation of scope is paused until reset
+}
+
+// ListRateLimits get a list of tracked rate limit, sorted by scope
+func ListRateLimits() []RateLimit {
+	limits := GetRateLimitLimit()
+	remains := GetRateLimitRemain()
+	resets := GetRateLimitReset()
+
+	var rateLimits []RateLimit
+	for scope, limit := range limits {
+		_, paused := IsRateLimitPaused(scope)
+		rateLimits = append(rateLimits, RateLimit{
+			Scope:     scope,
+			Limit:     limit,
+			Remaining: remains[scope],
+			ResetAt:   resets[scope],
+			Paused:    paused,
+		})
+	}
+
+	sort.SliceStable(rateLimits, func(i, j int) bool {
+		return rateLimits[i].Scope < rateLimits[j].Scope
+	})
+
+	return rateLimits
+}
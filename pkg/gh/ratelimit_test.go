@@ -0,0 +1,46 @@
+package gh
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+)
+
+func TestIsRateLimitPaused(t *testing.T) {
+	defer func() {
+		rateLimitPauses = sync.Map{}
+	}()
+
+	reset := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	storeRateLimit("octo-org/exhausted", github.Rate{Limit: 5000, Remaining: 0, Reset: github.Timestamp{Time: reset}})
+	storeRateLimit("other-org/repo", github.Rate{Limit: 5000, Remaining: 10, Reset: github.Timestamp{Time: reset}})
+	storeRateLimit("reset-org/repo", github.Rate{Limit: 5000, Remaining: 0, Reset: github.Timestamp{Time: time.Now().Add(-1 * time.Minute)}})
+
+	tests := []struct {
+		scope string
+		want  bool
+	}{
+		{scope: "octo-org/exhausted", want: true},
+		{scope: "octo-org/another-repo", want: true},
+		{scope: "Octo-Org", want: true},
+		{scope: "other-org/repo", want: false},
+		{scope: "reset-org/repo", want: false},
+	}
+
+	for _, test := range tests {
+		got, paused := IsRateLimitPaused(test.scope)
+		if paused != test.want {
+			t.Fatalf("scope %s: want paused %t, but got %t", test.scope, test.want, paused)
+		}
+		if paused && !got.Equal(reset) {
+			t.Fatalf("scope %s: want reset %s, but got %s", test.scope, reset, got)
+		}
+	}
+
+	storeRateLimit("octo-org/exhausted", github.Rate{Limit: 5000, Remaining: 5000, Reset: github.Timestamp{Time: reset.Add(time.Hour)}})
+	if _, paused := IsRateLimitPaused("octo-org/exhausted"); paused {
+		t.Fatalf("want resumed after rate limit is reset, but paused")
+	}
+}
@@ -0,0 +1,118 @@
+package gh
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// RetryBaseDelay is base delay of exponential backoff in rate limited without hint of GitHub
+var RetryBaseDelay = 1 * time.Second
+
+var (
+	throttledRetried atomic.Int64
+	throttledGaveUp  atomic.Int64
+)
+
+// GetThrottledCounts return number of throttled requests per result ("retried" or "gave_up")
+func GetThrottledCounts() map[string]int64 {
+	return map[string]int64{
+		"retried": throttledRetried.Load(),
+		"gave_up": throttledGaveUp.Load(),
+	}
+}
+
+// retryTransport is transport that retries a request that is rate limited by GitHub API.
+// it waits Retry-After or X-RateLimit-Reset if set, exponential backoff with jitter if not.
+type retryTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implement http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var waited time.Duration
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 {
+			r = req.Clone(req.Context())
+			if req.Body != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				r.Body = body
+			}
+		}
+
+		resp, err := t.base.RoundTrip(r)
+		if err != nil || !isThrottled(resp) {
+			return resp, err
+		}
+
+		wait := retryWait(resp, attempt, time.Now())
+		if attempt >= config.Config.GitHubRetryMax || waited+wait > config.Config.GitHubRetryMaxWait || !canRetry(req) {
+			throttledGaveUp.Add(1)
+			return resp, nil
+		}
+		throttledRetried.Add(1)
+		logger.Logf(true, "rate limited by GitHub API (%s %s), will retry after %s", req.Method, req.URL.Path, wait)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+		waited += wait
+	}
+}
+
+// canRetry return true if body of request can be sent again
+func canRetry(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// isThrottled return true if response is rate limited (primary or secondary)
+func isThrottled(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		// 403 is also returned in lack of permission, it has not hint of rate limit
+		return resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0"
+	default:
+		return false
+	}
+}
+
+// retryWait return duration until retry.
+// Retry-After is for secondary rate limits, X-RateLimit-Reset is for primary rate limits.
+func retryWait(resp *http.Response, attempt int, now time.Time) time.Duration {
+	jitter := time.Duration(rand.Int63n(int64(RetryBaseDelay)))
+
+	if s := resp.Header.Get("Retry-After"); s != "" {
+		if sec, err := strconv.Atoi(s); err == nil && sec >= 0 {
+			return time.Duration(sec)*time.Second + jitter
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			if d := time.Unix(reset, 0).Sub(now); d > 0 {
+				return d + jitter
+			}
+			return jitter
+		}
+	}
+
+	// full jitter
+	return time.Duration(rand.Int63n(int64(RetryBaseDelay<<attempt))) + 1
+}
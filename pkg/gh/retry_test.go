@@ -0,0 +1,122 @@
+package gh
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newResponse(code int, header map[string]string) *http.Response {
+	resp := &http.Response{StatusCode: code, Header: http.Header{}, Body: http.NoBody}
+	for k, v := range header {
+		resp.Header.Set(k, v)
+	}
+	return resp
+}
+
+func TestIsThrottled(t *testing.T) {
+	tests := []struct {
+		input *http.Response
+		want  bool
+	}{
+		{input: newResponse(http.StatusOK, nil), want: false},
+		{input: newResponse(http.StatusTooManyRequests, nil), want: true},
+		{input: newResponse(http.StatusForbidden, nil), want: false},
+		{input: newResponse(http.StatusForbidden, map[string]string{"Retry-After": "10"}), want: true},
+		{input: newResponse(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0"}), want: true},
+		{input: newResponse(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "10"}), want: false},
+	}
+
+	for _, test := range tests {
+		if got := isThrottled(test.input); got != test.want {
+			t.Errorf("want %t, but got %t (code: %d, header: %v)", test.want, got, test.input.StatusCode, test.input.Header)
+		}
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		input   *http.Response
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{
+			input: newResponse(http.StatusForbidden, map[string]string{"Retry-After": "10"}),
+			min:   10 * time.Second,
+			max:   10*time.Second + RetryBaseDelay,
+		},
+		{
+			input: newResponse(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(now.Add(5*time.Second).Unix(), 10)}),
+			min:   5 * time.Second,
+			max:   5*time.Second + RetryBaseDelay,
+		},
+		{
+			input: newResponse(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(now.Add(-5*time.Second).Unix(), 10)}),
+			min:   0,
+			max:   RetryBaseDelay,
+		},
+		{
+			input:   newResponse(http.StatusTooManyRequests, nil),
+			attempt: 2,
+			min:     1,
+			max:     4 * RetryBaseDelay,
+		},
+	}
+
+	for _, test := range tests {
+		got := retryWait(test.input, test.attempt, now)
+		if got < test.min || got > test.max {
+			t.Errorf("want between %s and %s, but got %s", test.min, test.max, got)
+		}
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	RetryBaseDelay = 1 * time.Millisecond
+	config.Config.GitHubRetryMaxWait = 1 * time.Second
+
+	tests := []struct {
+		retryMax  int
+		responses []int
+		want      int
+		wantCalls int
+	}{
+		{retryMax: 3, responses: []int{http.StatusOK}, want: http.StatusOK, wantCalls: 1},
+		{retryMax: 3, responses: []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK}, want: http.StatusOK, wantCalls: 3},
+		{retryMax: 1, responses: []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK}, want: http.StatusTooManyRequests, wantCalls: 2},
+		{retryMax: 0, responses: []int{http.StatusTooManyRequests, http.StatusOK}, want: http.StatusTooManyRequests, wantCalls: 1},
+	}
+
+	for _, test := range tests {
+		config.Config.GitHubRetryMax = test.retryMax
+		var calls int
+		tr := &retryTransport{base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			code := test.responses[calls]
+			calls++
+			return newResponse(code, nil), nil
+		})}
+
+		req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/app", nil)
+		resp, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("failed to round trip: %+v", err)
+		}
+		if resp.StatusCode != test.want {
+			t.Errorf("want %d, but got %d", test.want, resp.StatusCode)
+		}
+		if calls != test.wantCalls {
+			t.Errorf("want %d calls, but got %d", test.wantCalls, calls)
+		}
+	}
+}
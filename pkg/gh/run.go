@@ -3,10 +3,12 @@ package gh
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/go-github/v47/github"
+	"github.com/whywaita/myshoes/pkg/config"
 	"github.com/whywaita/myshoes/pkg/logger"
 )
 
@@ -24,29 +26,36 @@ func listRuns(ctx context.Context, client *github.Client, owner, repo string, op
 	return runs, resp, nil
 }
 
-// ListRuns get workflow runs that registered repository
-func ListRuns(owner, repo string) ([]*github.WorkflowRun, error) {
-	if cachedRs, expiration, found := responseCache.GetWithExpiration(getRunsCacheKey(owner, repo)); found {
+// ListRuns get workflow runs that registered repository.
+// cache is refreshed in background, it is not canceled by ctx but has timeout of GitHub API.
+func ListRuns(ctx context.Context, owner, repo string) ([]*github.WorkflowRun, error) {
+	if cachedRs, expiration, found := responseCache.GetWithExpiration(getRunsCacheKey(ctx, owner, repo)); found {
 		if time.Until(expiration).Minutes() <= 1 {
-			go updateCache(context.Background(), owner, repo)
+			go updateCache(context.WithoutCancel(ctx), owner, repo)
 		}
 		logger.Logf(true, "found workflow runs (cache hit: expiration: %s) in %s/%s", expiration.Format("2006/01/02 15:04:05.000 -0700"), owner, repo)
 		return cachedRs.([]*github.WorkflowRun), nil
 	}
-	go updateCache(context.Background(), owner, repo)
+	go updateCache(context.WithoutCancel(ctx), owner, repo)
 	return []*github.WorkflowRun{}, nil
 }
 
-func getRunsCacheKey(owner, repo string) string {
-	return fmt.Sprintf("runs-owner-%s-repo-%s", owner, repo)
+func getRunsCacheKey(ctx context.Context, owner, repo string) string {
+	return fmt.Sprintf("runs-host-%s-owner-%s-repo-%s", HostFrom(ctx).Hostname(), owner, repo)
 }
 
 // ClearRunsCache clear github workflow run caches
-func ClearRunsCache(owner, repo string) {
-	responseCache.Delete(getRunsCacheKey(owner, repo))
+func ClearRunsCache(ctx context.Context, owner, repo string) {
+	responseCache.Delete(getRunsCacheKey(ctx, owner, repo))
 }
 
 func updateCache(ctx context.Context, owner, repo string) {
+	if config.Config.GitHubTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Config.GitHubTimeout)
+		defer cancel()
+	}
+
 	var opts = &github.ListWorkflowRunsOptions{
 		ListOptions: github.ListOptions{
 			Page:    0,
@@ -60,6 +69,12 @@ func updateCache(ctx context.Context, owner, repo string) {
 		return
 	}
 	installationID := activeTarget.(int64)
+	scope := fmt.Sprintf("%s/%s", owner, repo)
+	if err := CheckBudget(scope); err != nil {
+		logger.Logf(true, "defer to refresh workflow runs (%s): %+v", scope, err)
+		return
+	}
+	ctx = WithBudgetScope(ctx, scope)
 	client, err := NewClientInstallation(installationID)
 	if err != nil {
 		logger.Logf(false, "failed to list workflow runs (%s/%s): %+v", owner, repo, err)
@@ -71,6 +86,138 @@ func updateCache(ctx context.Context, owner, repo string) {
 		return
 	}
 	storeRateLimit(getRateLimitKey(owner, repo), resp.Rate)
-	responseCache.Set(getRunsCacheKey(owner, repo), runs.WorkflowRuns, 15*time.Minute)
+	responseCache.Set(getRunsCacheKey(ctx, owner, repo), runs.WorkflowRuns, 15*time.Minute)
 	logger.Logf(true, "found %d workflow runs in %s/%s", len(runs.WorkflowRuns), owner, repo)
 }
+
+// QueuedJob is a workflow job that is queued in a repository
+type QueuedJob struct {
+	Repository *github.Repository
+	Job        *github.WorkflowJob
+}
+
+// ListQueuedJobs list workflow jobs that are queued now in scope (repository or organization).
+// queued jobs are found by GraphQL API, and by workflow runs in REST API if GraphQL API is failed.
+func ListQueuedJobs(ctx context.Context, installationID int64, scope string) ([]QueuedJob, error) {
+	client, err := HostFrom(ctx).NewClientInstallation(installationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a client installation: %w", err)
+	}
+
+	queued, err := listQueuedJobsByGraphQL(ctx, client, scope)
+	if err == nil {
+		return queued, nil
+	}
+	logger.Logf(false, "failed to list queued jobs by GraphQL API, will list by REST API (scope: %s): %+v", scope, err)
+	return listQueuedJobsByREST(ctx, client, installationID, scope)
+}
+
+// listQueuedJobsByREST list queued workflow jobs in scope by REST API.
+// queued job is found in queued runs and in_progress runs (e.g. a part of matrix is running)
+func listQueuedJobsByREST(ctx context.Context, client *github.Client, installationID int64, scope string) ([]QueuedJob, error) {
+	owner, repoName := DivideScope(scope)
+	var repositories []*github.Repository
+	switch DetectScope(scope) {
+	case Repository:
+		repo, _, err := client.Repositories.Get(ctx, owner, repoName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get repository: %w", err)
+		}
+		repositories = append(repositories, repo)
+	case Organization:
+		if IsPATMode() {
+			repos, _, err := client.Repositories.ListByOrg(ctx, owner, &github.RepositoryListByOrgOptions{
+				ListOptions: github.ListOptions{PerPage: 100},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list repositories of organization: %w", err)
+			}
+			repositories = repos
+			break
+		}
+		installed, err := GHlistAppsInstalledRepo(ctx, installationID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get list of installed repositories: %w", err)
+		}
+		for _, repo := range installed {
+			if strings.EqualFold(repo.GetOwner().GetLogin(), owner) {
+				repositories = append(repositories, repo)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%s is not supported scope to list queued jobs", scope)
+	}
+
+	var queued []QueuedJob
+	for _, repo := range repositories {
+		jobs, err := listQueuedJobsInRepository(ctx, client, repo.GetOwner().GetLogin(), repo.GetName())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list queued jobs (%s): %w", repo.GetFullName(), err)
+		}
+		for _, job := range jobs {
+			queued = append(queued, QueuedJob{Repository: repo, Job: job})
+		}
+	}
+	return queued, nil
+}
+
+func listQueuedJobsInRepository(ctx context.Context, client *github.Client, owner, repo string) ([]*github.WorkflowJob, error) {
+	var queued []*github.WorkflowJob
+	for _, status := range []string{"queued", "in_progress"} {
+		opts := &github.ListWorkflowRunsOptions{
+			Status: status,
+			ListOptions: github.ListOptions{
+				PerPage: 100,
+			},
+		}
+		runs, resp, err := listRuns(ctx, client, owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		storeRateLimit(getRateLimitKey(owner, repo), resp.Rate)
+
+		for _, run := range runs.WorkflowRuns {
+			jobs, err := listQueuedJobsInRun(ctx, client, owner, repo, run.GetID())
+			if err != nil {
+				return nil, err
+			}
+			queued = append(queued, jobs...)
+		}
+	}
+	return queued, nil
+}
+
+// ListQueuedJobsInRun list workflow jobs that are queued now in a workflow run
+func ListQueuedJobsInRun(ctx context.Context, installationID int64, owner, repo string, runID int64) ([]*github.WorkflowJob, error) {
+	client, err := HostFrom(ctx).NewClientInstallation(installationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a client installation: %w", err)
+	}
+	return listQueuedJobsInRun(ctx, client, owner, repo, runID)
+}
+
+func listQueuedJobsInRun(ctx context.Context, client *github.Client, owner, repo string, runID int64) ([]*github.WorkflowJob, error) {
+	var queued []*github.WorkflowJob
+	opts := &github.ListWorkflowJobsOptions{
+		Filter: "latest",
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for {
+		jobs, resp, err := client.Actions.ListWorkflowJobs(ctx, owner, repo, runID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list workflow jobs (run ID: %d): %w", runID, err)
+		}
+		storeRateLimit(getRateLimitKey(owner, repo), resp.Rate)
+		for _, job := range jobs.Jobs {
+			if job.GetStatus() == "queued" {
+				queued = append(queued, job)
+			}
+		}
+		if resp.NextPage == 0 {
+			return queued, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,124 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/patrickmn/go-cache"
+)
+
+// WorkflowRunContext is context of workflow run that received by workflow_run webhook
+type WorkflowRunContext struct {
+	InstallationID int64
+	Run            *github.WorkflowRun
+	UpdatedAt      time.Time
+}
+
+// workflowRuns stores not completed workflow runs by workflow_run webhook, key is ID of run.
+// a run that completed event is lost is expired
+var workflowRuns = cache.New(24*time.Hour, 1*time.Hour)
+
+// StoreWorkflowRun store context of workflow run, completed run is removed
+func StoreWorkflowRun(ctx context.Context, installationID int64, run *github.WorkflowRun) {
+	key := strconv.FormatInt(run.GetID(), 10)
+	if run.GetStatus() == "completed" {
+		workflowRuns.Delete(key)
+		forgetPendingRun(installationID, run.GetID())
+	} else {
+		workflowRuns.SetDefault(key, WorkflowRunContext{
+			InstallationID: installationID,
+			Run:            run,
+			UpdatedAt:      time.Now(),
+		})
+	}
+	upsertRunsCache(ctx, run)
+}
+
+// ListWorkflowRuns return not completed workflow runs that received by webhook, order by created_at
+func ListWorkflowRuns() []WorkflowRunContext {
+	var runs []WorkflowRunContext
+	for _, item := range workflowRuns.Items() {
+		runs = append(runs, item.Object.(WorkflowRunContext))
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].Run.GetCreatedAt().Before(runs[j].Run.GetCreatedAt().Time)
+	})
+	return runs
+}
+
+// GetWorkflowRun return workflow run that received by webhook, or get it from API if not received
+func GetWorkflowRun(ctx context.Context, installationID int64, owner, repo string, runID int64) (*github.WorkflowRun, error) {
+	if v, ok := workflowRuns.Get(strconv.FormatInt(runID, 10)); ok {
+		return v.(WorkflowRunContext).Run, nil
+	}
+
+	client, err := HostFrom(ctx).NewClientInstallation(installationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a client of installation: %w", err)
+	}
+	run, resp, err := client.Actions.GetWorkflowRunByID(ctx, owner, repo, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow run (run ID: %d): %w", runID, err)
+	}
+	storeRateLimit(getRateLimitKey(owner, repo), resp.Rate)
+	return run, nil
+}
+
+// IsForkRun return true if workflow run is triggered by a pull request from fork repository
+func IsForkRun(run *github.WorkflowRun) bool {
+	head := run.GetHeadRepository().GetFullName()
+	return head != "" && !strings.EqualFold(head, run.GetRepository().GetFullName())
+}
+
+// StorePendingRuns store workflow runs that are queued over pendingTime to PendingRuns for rescue.
+// it works without scraping metrics of workflow runs.
+func StorePendingRuns(pendingTime time.Duration) {
+	for _, rc := range ListWorkflowRuns() {
+		status := rc.Run.GetStatus()
+		if status != "queued" && status != "pending" {
+			continue
+		}
+		if time.Since(rc.Run.GetCreatedAt().Time) >= pendingTime {
+			PendingRuns.Store(rc.InstallationID, rc.Run)
+		}
+	}
+}
+
+// forgetPendingRun delete run from PendingRuns if it is stored
+func forgetPendingRun(installationID, runID int64) {
+	v, ok := PendingRuns.Load(installationID)
+	if !ok {
+		return
+	}
+	if run, ok := v.(*github.WorkflowRun); ok && run.GetID() == runID {
+		PendingRuns.Delete(installationID)
+	}
+}
+
+// upsertRunsCache update run in cache of ListRuns, it does nothing if cache is not found
+func upsertRunsCache(ctx context.Context, run *github.WorkflowRun) {
+	owner := run.GetRepository().GetOwner().GetLogin()
+	repo := run.GetRepository().GetName()
+	key := getRunsCacheKey(ctx, owner, repo)
+
+	cached, expiration, found := responseCache.GetWithExpiration(key)
+	if !found {
+		return
+	}
+	runs := cached.([]*github.WorkflowRun)
+	updated := make([]*github.WorkflowRun, 0, len(runs)+1)
+	updated = append(updated, run)
+	for _, r := range runs {
+		if r.GetID() != run.GetID() {
+			updated = append(updated, r)
+		}
+	}
+	if d := time.Until(expiration); d > 0 {
+		responseCache.Set(key, updated, d)
+	}
+}
@@ -0,0 +1,63 @@
+package gh
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+)
+
+func TestStorePendingRuns(t *testing.T) {
+	tests := []struct {
+		input []*github.WorkflowRun
+		want  int64 // ID of pending run, 0 is not stored
+	}{
+		{
+			input: []*github.WorkflowRun{
+				{ID: github.Int64(1), Status: github.String("queued"), CreatedAt: &github.Timestamp{Time: time.Now().Add(-1 * time.Hour)}},
+			},
+			want: 1,
+		},
+		{
+			input: []*github.WorkflowRun{
+				{ID: github.Int64(2), Status: github.String("queued"), CreatedAt: &github.Timestamp{Time: time.Now()}},
+			},
+			want: 0,
+		},
+		{
+			input: []*github.WorkflowRun{
+				{ID: github.Int64(3), Status: github.String("queued"), CreatedAt: &github.Timestamp{Time: time.Now().Add(-1 * time.Hour)}},
+				{ID: github.Int64(3), Status: github.String("completed"), CreatedAt: &github.Timestamp{Time: time.Now().Add(-1 * time.Hour)}},
+			},
+			want: 0,
+		},
+		{
+			input: []*github.WorkflowRun{
+				{ID: github.Int64(4), Status: github.String("in_progress"), CreatedAt: &github.Timestamp{Time: time.Now().Add(-1 * time.Hour)}},
+			},
+			want: 0,
+		},
+	}
+
+	const installationID = 100
+	for _, test := range tests {
+		workflowRuns.Flush()
+		PendingRuns.Delete(int64(installationID))
+
+		for _, run := range test.input {
+			StoreWorkflowRun(context.Background(), installationID, run)
+		}
+		StorePendingRuns(30 * time.Minute)
+
+		var got int64
+		if v, ok := PendingRuns.Load(int64(installationID)); ok {
+			got = v.(*github.WorkflowRun).GetID()
+		}
+		if got != test.want {
+			t.Errorf("want pending run %d, but got %d", test.want, got)
+		}
+	}
+	workflowRuns.Flush()
+	PendingRuns.Delete(int64(installationID))
+}
@@ -3,20 +3,32 @@ package gh
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
-	"time"
 
 	"github.com/google/go-github/v47/github"
+	"github.com/patrickmn/go-cache"
+
+	"github.com/whywaita/myshoes/pkg/config"
 	"github.com/whywaita/myshoes/pkg/logger"
 )
 
-// ExistGitHubRunner check exist registered of GitHub runner
+// ExistGitHubRunner check exist registered of GitHub runner.
+// a list of runners is fetched again if runner is not found in cached list, runner may be registered after caching
 func ExistGitHubRunner(ctx context.Context, client *github.Client, owner, repo, runnerName string) (*github.Runner, error) {
 	runners, err := ListRunners(ctx, client, owner, repo)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list of runners: %w", err)
 	}
+	if r, err := ExistGitHubRunnerWithRunner(runners, runnerName); err == nil {
+		return r, nil
+	}
 
+	runners, err = RefreshRunners(ctx, client, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list of runners: %w", err)
+	}
 	return ExistGitHubRunnerWithRunner(runners, runnerName)
 }
 
@@ -31,12 +43,17 @@ func ExistGitHubRunnerWithRunner(runners []*github.Runner, runnerName string) (*
 	return nil, ErrNotFound
 }
 
-// ListRunners get runners that registered repository or org
+// ListRunners get runners that registered repository or org.
+// list is cached in GITHUB_RUNNERS_CACHE_TTL per scope
 func ListRunners(ctx context.Context, client *github.Client, owner, repo string) ([]*github.Runner, error) {
-	if cachedRs, found := responseCache.Get(getCacheKey(owner, repo)); found {
+	if cachedRs, found := responseCache.Get(getRunnersCacheKey(ctx, owner, repo)); found {
 		return cachedRs.([]*github.Runner), nil
 	}
+	return RefreshRunners(ctx, client, owner, repo)
+}
 
+// RefreshRunners get all pages of runners from GitHub without cache, and store it to cache
+func RefreshRunners(ctx context.Context, client *github.Client, owner, repo string) ([]*github.Runner, error) {
 	var opts = &github.ListOptions{
 		Page:    0,
 		PerPage: 100,
@@ -58,17 +75,33 @@ func ListRunners(ctx context.Context, client *github.Client, owner, repo string)
 		opts.Page = resp.NextPage
 	}
 
-	responseCache.Set(getCacheKey(owner, repo), rs, 1*time.Second)
+	responseCache.Set(getRunnersCacheKey(ctx, owner, repo), rs, config.Config.GitHubRunnersCacheTTL)
 	logger.Logf(true, "found %d runners in GitHub", len(rs))
 
 	return rs, nil
 }
 
-func getCacheKey(owner, repo string) string {
-	return fmt.Sprintf("owner-%s-repo-%s", owner, repo)
+// InvalidateRunnersCache delete cached list of runners in scope. call it if a runner is registered or removed
+func InvalidateRunnersCache(ctx context.Context, owner, repo string) {
+	responseCache.Delete(getRunnersCacheKey(ctx, owner, repo))
+}
+
+// getRunnersCacheKey return key of cached runners per host and scope, IDs of runners are only unique in a host
+func getRunnersCacheKey(ctx context.Context, owner, repo string) string {
+	if repo == "" {
+		return fmt.Sprintf("runners-%s-%s", HostFrom(ctx).Hostname(), owner)
+	}
+	return fmt.Sprintf("runners-%s-%s/%s", HostFrom(ctx).Hostname(), owner, repo)
 }
 
 func listRunners(ctx context.Context, client *github.Client, owner, repo string, opts *github.ListOptions) (*github.Runners, *github.Response, error) {
+	if DetectScope(owner) == Enterprise {
+		runners, resp, err := client.Enterprise.ListRunners(ctx, EnterpriseSlug(owner), opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list enterprise runners: %w", err)
+		}
+		return runners, resp, nil
+	}
 	if repo == "" {
 		runners, resp, err := client.Actions.ListOrganizationRunners(ctx, owner, opts)
 		if err != nil {
@@ -84,43 +117,175 @@ func listRunners(ctx context.Context, client *github.Client, owner, repo string,
 	return runners, resp, nil
 }
 
+// GetRunner get latest state of a runner from GitHub without cache. repo is empty in organization and enterprise scope
+func GetRunner(ctx context.Context, client *github.Client, owner, repo string, runnerID int64) (*github.Runner, error) {
+	switch {
+	case DetectScope(owner) == Enterprise:
+		// API for getting an enterprise runner is not supported in go-github, find it from list
+		runners, err := RefreshRunners(ctx, client, owner, repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get list of enterprise runners: %w", err)
+		}
+		for _, r := range runners {
+			if r.GetID() == runnerID {
+				return r, nil
+			}
+		}
+		return nil, ErrNotFound
+	case repo == "":
+		r, resp, err := client.Actions.GetOrganizationRunner(ctx, owner, runnerID)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				return nil, ErrNotFound
+			}
+			return nil, fmt.Errorf("failed to get organization runner: %w", err)
+		}
+		return r, nil
+	default:
+		r, resp, err := client.Actions.GetRunner(ctx, owner, repo, runnerID)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				return nil, ErrNotFound
+			}
+			return nil, fmt.Errorf("failed to get repository runner: %w", err)
+		}
+		return r, nil
+	}
+}
+
+// RemoveRunner remove a runner from GitHub. repo is empty in organization and enterprise scope
+func RemoveRunner(ctx context.Context, client *github.Client, owner, repo string, runnerID int64) error {
+	switch {
+	case DetectScope(owner) == Enterprise:
+		if _, err := client.Enterprise.RemoveRunner(ctx, EnterpriseSlug(owner), runnerID); err != nil {
+			return fmt.Errorf("failed to remove enterprise runner: %w", err)
+		}
+	case repo == "":
+		if _, err := client.Actions.RemoveOrganizationRunner(ctx, owner, runnerID); err != nil {
+			return fmt.Errorf("failed to remove organization runner: %w", err)
+		}
+	default:
+		if _, err := client.Actions.RemoveRunner(ctx, owner, repo, runnerID); err != nil {
+			return fmt.Errorf("failed to remove repository runner: %w", err)
+		}
+	}
+	InvalidateRunnersCache(ctx, owner, repo)
+	return nil
+}
+
 // GetLatestRunnerVersion get a latest version of actions/runner
 func GetLatestRunnerVersion(ctx context.Context, scope string) (string, error) {
-	clientApps, err := NewClientGitHubApps()
+	applications, err := listRunnerApplicationDownloads(ctx, scope)
 	if err != nil {
-		return "", fmt.Errorf("failed to create a client from Apps: %+v", err)
+		return "", fmt.Errorf("failed to get latest runner version: %w", err)
+	}
+	return getRunnerVersion(applications)
+}
+
+// GetLocalRunnerDownloadURLs get download URLs of actions/runner that served by GitHub Enterprise Server itself.
+// GHES serves runner binaries if GitHub Connect runner downloads is enabled, then runner does not need to access github.com.
+// return map of file name to URL, it is empty in github.com or if GHES does not serve runner binaries.
+func GetLocalRunnerDownloadURLs(ctx context.Context, scope string) (map[string]string, error) {
+	if !HostFrom(ctx).IsGHES() {
+		return map[string]string{}, nil
+	}
+	u, err := url.Parse(HostFrom(ctx).URL())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub URL: %w", err)
+	}
+
+	applications, err := listRunnerApplicationDownloads(ctx, scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get runner downloads: %w", err)
+	}
+	return filterLocalDownloads(applications, u.Hostname()), nil
+}
+
+func filterLocalDownloads(applications []*github.RunnerApplicationDownload, host string) map[string]string {
+	urls := map[string]string{}
+	for _, app := range applications {
+		u, err := url.Parse(app.GetDownloadURL())
+		if err != nil || !strings.EqualFold(u.Hostname(), host) {
+			continue
+		}
+		urls[app.GetFilename()] = app.GetDownloadURL()
+	}
+	return urls
+}
+
+func getRunnerDownloadsCacheKey(ctx context.Context, scope string) string {
+	return fmt.Sprintf("runner-downloads-%s-%s", HostFrom(ctx).Hostname(), scope)
+}
+
+// listEnterpriseRunnerApplicationDownloads get downloads of actions/runner in enterprise, go-github does not have it
+// docs: https://docs.github.com/en/enterprise-cloud@latest/rest/actions/self-hosted-runners#list-runner-applications-for-an-enterprise
+func listEnterpriseRunnerApplicationDownloads(ctx context.Context, client *github.Client, enterprise string) ([]*github.RunnerApplicationDownload, *github.Response, error) {
+	u := fmt.Sprintf("enterprises/%s/actions/runners/downloads", enterprise)
+	req, err := client.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	var applications []*github.RunnerApplicationDownload
+	resp, err := client.Do(ctx, req, &applications)
+	if err != nil {
+		return nil, resp, err
+	}
+	return applications, resp, nil
+}
+
+// listRunnerApplicationDownloads get downloads of actions/runner, it is cached
+func listRunnerApplicationDownloads(ctx context.Context, scope string) ([]*github.RunnerApplicationDownload, error) {
+	if cached, found := responseCache.Get(getRunnerDownloadsCacheKey(ctx, scope)); found {
+		return cached.([]*github.RunnerApplicationDownload), nil
+	}
+
+	clientApps, err := HostFrom(ctx).NewClientGitHubApps()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a client from Apps: %+v", err)
 	}
 	installationID, err := IsInstalledGitHubApp(ctx, scope)
 	if err != nil {
-		return "", fmt.Errorf("failed to get installlation id: %w", err)
+		return nil, fmt.Errorf("failed to get installlation id: %w", err)
 	}
 	token, _, err := GenerateGitHubAppsToken(ctx, clientApps, installationID, scope)
 	if err != nil {
-		return "", fmt.Errorf("failed to get registration token: %w", err)
+		return nil, fmt.Errorf("failed to get registration token: %w", err)
 	}
-	client, err := NewClient(token)
+	client, err := HostFrom(ctx).NewClient(token)
 	if err != nil {
-		return "", fmt.Errorf("failed to create GitHub client: %w", err)
+		return nil, fmt.Errorf("failed to create GitHub client: %w", err)
 	}
 
+	var applications []*github.RunnerApplicationDownload
 	switch DetectScope(scope) {
 	case Repository:
 		owner, repo := DivideScope(scope)
-		applications, resp, err := client.Actions.ListRunnerApplicationDownloads(ctx, owner, repo)
+		apps, resp, err := client.Actions.ListRunnerApplicationDownloads(ctx, owner, repo)
 		if err != nil {
-			return "", fmt.Errorf("failed to get latest runner version: %w", err)
+			return nil, fmt.Errorf("failed to list runner application downloads: %w", err)
 		}
 		storeRateLimit(getRateLimitKey(owner, repo), resp.Rate)
-		return getRunnerVersion(applications)
+		applications = apps
 	case Organization:
-		applications, resp, err := client.Actions.ListOrganizationRunnerApplicationDownloads(ctx, scope)
+		apps, resp, err := client.Actions.ListOrganizationRunnerApplicationDownloads(ctx, scope)
 		if err != nil {
-			return "", fmt.Errorf("failed to get latest runner version: %w", err)
+			return nil, fmt.Errorf("failed to list runner application downloads: %w", err)
 		}
 		storeRateLimit(getRateLimitKey(scope, ""), resp.Rate)
-		return getRunnerVersion(applications)
+		applications = apps
+	case Enterprise:
+		apps, resp, err := listEnterpriseRunnerApplicationDownloads(ctx, client, EnterpriseSlug(scope))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list runner application downloads: %w", err)
+		}
+		storeRateLimit(getRateLimitKey(scope, ""), resp.Rate)
+		applications = apps
+	default:
+		return nil, fmt.Errorf("invalid scope: %s", scope)
 	}
-	return "", fmt.Errorf("invalid scope: %s", scope)
+
+	responseCache.Set(getRunnerDownloadsCacheKey(ctx, scope), applications, cache.DefaultExpiration)
+	return applications, nil
 }
 
 func getRunnerVersion(applications []*github.RunnerApplicationDownload) (string, error) {
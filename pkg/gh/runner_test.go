@@ -0,0 +1,164 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v47/github"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+func TestFilterLocalDownloads(t *testing.T) {
+	applications := []*github.RunnerApplicationDownload{
+		{
+			OS:           github.String("linux"),
+			Architecture: github.String("x64"),
+			Filename:     github.String("actions-runner-linux-x64-2.300.0.tar.gz"),
+			DownloadURL:  github.String("https://ghes.example.com/_services/pipelines/_apis/distributedtask/packages/agent/actions-runner-linux-x64-2.300.0.tar.gz"),
+		},
+		{
+			OS:           github.String("osx"),
+			Architecture: github.String("arm64"),
+			Filename:     github.String("actions-runner-osx-arm64-2.300.0.tar.gz"),
+			DownloadURL:  github.String("https://github.com/actions/runner/releases/download/v2.300.0/actions-runner-osx-arm64-2.300.0.tar.gz"),
+		},
+	}
+
+	tests := []struct {
+		input string
+		want  map[string]string
+	}{
+		{
+			input: "ghes.example.com",
+			want: map[string]string{
+				"actions-runner-linux-x64-2.300.0.tar.gz": "https://ghes.example.com/_services/pipelines/_apis/distributedtask/packages/agent/actions-runner-linux-x64-2.300.0.tar.gz",
+			},
+		},
+		{
+			input: "other.example.com",
+			want:  map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		got := filterLocalDownloads(applications, test.input)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestListEnterpriseRunnerApplicationDownloads(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/enterprises/example/actions/runners/downloads", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("want method GET, but got %s", r.Method)
+		}
+		io.WriteString(w, `[{"os":"linux","architecture":"x64","download_url":"https://github.com/actions/runner/releases/download/v2.300.0/actions-runner-linux-x64-2.300.0.tar.gz","filename":"actions-runner-linux-x64-2.300.0.tar.gz"}]`)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(ts.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to parse URL: %+v", err)
+	}
+	client.BaseURL = baseURL
+
+	tests := []struct {
+		enterprise string
+		want       []*github.RunnerApplicationDownload
+		err        bool
+	}{
+		{
+			enterprise: "example",
+			want: []*github.RunnerApplicationDownload{
+				{
+					OS:           github.String("linux"),
+					Architecture: github.String("x64"),
+					DownloadURL:  github.String("https://github.com/actions/runner/releases/download/v2.300.0/actions-runner-linux-x64-2.300.0.tar.gz"),
+					Filename:     github.String("actions-runner-linux-x64-2.300.0.tar.gz"),
+				},
+			},
+		},
+		{
+			enterprise: "not-exist",
+			err:        true,
+		},
+	}
+
+	for _, test := range tests {
+		got, _, err := listEnterpriseRunnerApplicationDownloads(context.Background(), client, test.enterprise)
+		if !test.err && err != nil {
+			t.Fatalf("failed to list runner application downloads: %+v", err)
+		}
+		if test.err && err == nil {
+			t.Fatalf("must be error, but not error (enterprise: %s)", test.enterprise)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestListRunners_CachePerHost(t *testing.T) {
+	config.Config.GitHubURL = "https://github.com"
+	ctx := context.Background()
+	ghes := WithHost(ctx, &Host{conf: config.GitHubHost{URL: "https://github.example.com"}})
+	t.Cleanup(func() {
+		InvalidateRunnersCache(ctx, "octocat", "hello-world")
+		InvalidateRunnersCache(ghes, "octocat", "hello-world")
+	})
+
+	// newClient return client of GitHub host that has a runner of runnerID
+	newClient := func(runnerID int64) *github.Client {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/octocat/hello-world/actions/runners", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"total_count":1,"runners":[{"id":%d,"name":"myshoes-runner"}]}`, runnerID)
+		})
+		ts := httptest.NewServer(mux)
+		t.Cleanup(ts.Close)
+
+		client := github.NewClient(nil)
+		baseURL, err := url.Parse(ts.URL + "/")
+		if err != nil {
+			t.Fatalf("failed to parse URL: %+v", err)
+		}
+		client.BaseURL = baseURL
+		return client
+	}
+	dotcom, enterprise := newClient(1), newClient(2)
+
+	listRunnerID := func(ctx context.Context, client *github.Client) int64 {
+		t.Helper()
+		runners, err := ListRunners(ctx, client, "octocat", "hello-world")
+		if err != nil {
+			t.Fatalf("failed to list runners: %+v", err)
+		}
+		if len(runners) != 1 {
+			t.Fatalf("want 1 runner, but got %d", len(runners))
+		}
+		return runners[0].GetID()
+	}
+
+	if got := listRunnerID(ctx, dotcom); got != 1 {
+		t.Fatalf("want runner 1 in github.com, but got %d", got)
+	}
+	// same owner/repo in other host must not use cache of github.com
+	if got := listRunnerID(ghes, enterprise); got != 2 {
+		t.Fatalf("want runner 2 in GitHub Enterprise Server, but got %d", got)
+	}
+
+	InvalidateRunnersCache(ghes, "octocat", "hello-world")
+	if got := listRunnerID(ctx, enterprise); got != 1 {
+		t.Errorf("cache of github.com must not be invalidated by other host, but got runner %d", got)
+	}
+}
@@ -0,0 +1,391 @@
+package gh
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+// Runner scale set is autoscaling model of GitHub Actions (used by actions-runner-controller).
+// scale set long-polls job assignments from Actions service, runners are registered by JIT config without registration token.
+// docs: https://docs.github.com/en/actions/hosting-your-own-runners/managing-self-hosted-runners-with-actions-runner-controller
+
+const scaleSetAPIVersion = "6.0-preview"
+
+// Type of message from Actions service
+const (
+	ScaleSetMessageTypeJobMessages = "RunnerScaleSetJobMessages"
+
+	ScaleSetJobAvailable = "JobAvailable"
+	ScaleSetJobAssigned  = "JobAssigned"
+	ScaleSetJobStarted   = "JobStarted"
+	ScaleSetJobCompleted = "JobCompleted"
+)
+
+// ScaleSet is runner scale set
+type ScaleSet struct {
+	ID            int              `json:"id,omitempty"`
+	Name          string           `json:"name"`
+	RunnerGroupID int              `json:"runnerGroupId"`
+	Labels        []ScaleSetLabel  `json:"labels"`
+	RunnerSetting ScaleSetSettings `json:"runnerSetting"`
+}
+
+// ScaleSetLabel is label of scale set
+type ScaleSetLabel struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ScaleSetSettings is setting of runners in scale set
+type ScaleSetSettings struct {
+	Ephemeral     bool `json:"ephemeral"`
+	DisableUpdate bool `json:"disableUpdate"`
+}
+
+// ScaleSetSession is message session of scale set, only one session can be created in a scale set
+type ScaleSetSession struct {
+	SessionID               string    `json:"sessionId"`
+	OwnerName               string    `json:"ownerName"`
+	RunnerScaleSet          *ScaleSet `json:"runnerScaleSet"`
+	MessageQueueURL         string    `json:"messageQueueUrl"`
+	MessageQueueAccessToken string    `json:"messageQueueAccessToken"`
+}
+
+// ScaleSetMessage is message from Actions service
+type ScaleSetMessage struct {
+	MessageID   int64  `json:"messageId"`
+	MessageType string `json:"messageType"`
+	Body        string `json:"body"`
+}
+
+// ScaleSetJobMessage is job message in body of ScaleSetMessage
+type ScaleSetJobMessage struct {
+	MessageType     string   `json:"messageType"`
+	RunnerRequestID int64    `json:"runnerRequestId"`
+	RepositoryName  string   `json:"repositoryName"`
+	OwnerName       string   `json:"ownerName"`
+	JobWorkflowRef  string   `json:"jobWorkflowRef"`
+	JobDisplayName  string   `json:"jobDisplayName"`
+	WorkflowRunID   int64    `json:"workflowRunId"`
+	EventName       string   `json:"eventName"`
+	RequestLabels   []string `json:"requestLabels"`
+	RunnerName      string   `json:"runnerName"`
+	Result          string   `json:"result"`
+}
+
+// JobMessages parse job messages in body
+func (m *ScaleSetMessage) JobMessages() ([]ScaleSetJobMessage, error) {
+	if m.MessageType != ScaleSetMessageTypeJobMessages {
+		return nil, fmt.Errorf("message is not job messages (type: %s)", m.MessageType)
+	}
+	var jobs []ScaleSetJobMessage
+	if err := json.Unmarshal([]byte(m.Body), &jobs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job messages: %w", err)
+	}
+	return jobs, nil
+}
+
+// ScaleSetClient is client of Actions service for runner scale set in a scope
+type ScaleSetClient struct {
+	installationID int64
+	scope          string
+	client         *http.Client
+
+	mu         sync.Mutex
+	serviceURL string // URL of Actions service
+	token      string // token for Actions service, refreshed before expired
+	expiresAt  time.Time
+}
+
+// NewScaleSetClient create a client of Actions service. scope is repository (:owner/:repo) or organization
+func NewScaleSetClient(installationID int64, scope string) *ScaleSetClient {
+	return &ScaleSetClient{
+		installationID: installationID,
+		scope:          scope,
+		client:         newHTTPClient(newBaseTransport()),
+	}
+}
+
+type runnerRegistrationResponse struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+// connect return URL and token of Actions service, it exchanges registration token to token of Actions service
+func (c *ScaleSetClient) connect(ctx context.Context) (string, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Until(c.expiresAt) > 5*time.Minute {
+		return c.serviceURL, c.token, nil
+	}
+
+	registrationToken, err := GetRunnerRegistrationToken(ctx, c.installationID, c.scope)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get registration token: %w", err)
+	}
+	apiEndpoint, err := primaryHost.apiEndpoint()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get API endpoint: %w", err)
+	}
+	body, err := json.Marshal(map[string]string{
+		"url":          strings.TrimSuffix(config.Config.GitHubURL, "/") + "/" + c.scope,
+		"runner_event": "register",
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(apiEndpoint.String(), "/")+"/actions/runner-registration", bytes.NewReader(body))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "RemoteAuth "+registrationToken)
+
+	var resp runnerRegistrationResponse
+	if err := c.do(req, &resp); err != nil {
+		return "", "", fmt.Errorf("failed to get connection of Actions service: %w", err)
+	}
+	expiresAt, err := tokenExpiresAt(resp.Token)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get expiration of token: %w", err)
+	}
+
+	c.serviceURL = strings.TrimSuffix(resp.URL, "/")
+	c.token = resp.Token
+	c.expiresAt = expiresAt
+	return c.serviceURL, c.token, nil
+}
+
+// tokenExpiresAt return exp claim in JWT, signature is not verified
+func tokenExpiresAt(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("token is not JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode payload of JWT: %w", err)
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("failed to unmarshal claims of JWT: %w", err)
+	}
+	return time.Unix(claims.Exp, 0), nil
+}
+
+// request send request to Actions service. out is ignored if nil
+func (c *ScaleSetClient) request(ctx context.Context, method, p string, in, out interface{}) error {
+	serviceURL, token, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(serviceURL + p)
+	if err != nil {
+		return fmt.Errorf("failed to parse URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("api-version", scaleSetAPIVersion)
+	u.RawQuery = q.Encode()
+
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return c.do(req, out)
+}
+
+// do send request and decode response. return ErrNotFound if 404
+func (c *ScaleSetClient) do(req *http.Request, out interface{}) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("invalid status code (code: %d, body: %s)", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	if out == nil || len(b) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// GetScaleSet get scale set by name. return ErrNotFound if not exist
+func (c *ScaleSetClient) GetScaleSet(ctx context.Context, runnerGroupID int, name string) (*ScaleSet, error) {
+	var resp struct {
+		Count int        `json:"count"`
+		Value []ScaleSet `json:"value"`
+	}
+	p := fmt.Sprintf("/_apis/runtime/runnerscalesets?runnerGroupId=%d&name=%s", runnerGroupID, url.QueryEscape(name))
+	if err := c.request(ctx, http.MethodGet, p, nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get scale set: %w", err)
+	}
+	if resp.Count == 0 || len(resp.Value) == 0 {
+		return nil, ErrNotFound
+	}
+	return &resp.Value[0], nil
+}
+
+// CreateScaleSet create scale set
+func (c *ScaleSetClient) CreateScaleSet(ctx context.Context, scaleSet ScaleSet) (*ScaleSet, error) {
+	var created ScaleSet
+	if err := c.request(ctx, http.MethodPost, "/_apis/runtime/runnerscalesets", scaleSet, &created); err != nil {
+		return nil, fmt.Errorf("failed to create scale set: %w", err)
+	}
+	return &created, nil
+}
+
+// CreateSession create message session of scale set
+func (c *ScaleSetClient) CreateSession(ctx context.Context, scaleSetID int, owner string) (*ScaleSetSession, error) {
+	var session ScaleSetSession
+	in := map[string]string{"ownerName": owner}
+	if err := c.request(ctx, http.MethodPost, fmt.Sprintf("/_apis/runtime/runnerscalesets/%d/sessions", scaleSetID), in, &session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return &session, nil
+}
+
+// DeleteSession delete message session of scale set
+func (c *ScaleSetClient) DeleteSession(ctx context.Context, scaleSetID int, sessionID string) error {
+	if err := c.request(ctx, http.MethodDelete, fmt.Sprintf("/_apis/runtime/runnerscalesets/%d/sessions/%s", scaleSetID, sessionID), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// GetMessage long-poll a message after lastMessageID. return nil if no message in polling time
+func (c *ScaleSetClient) GetMessage(ctx context.Context, session *ScaleSetSession, lastMessageID int64) (*ScaleSetMessage, error) {
+	u, err := url.Parse(session.MessageQueueURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL of message queue: %w", err)
+	}
+	q := u.Query()
+	q.Set("lastMessageId", fmt.Sprintf("%d", lastMessageID))
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json; api-version="+scaleSetAPIVersion)
+	req.Header.Set("Authorization", "Bearer "+session.MessageQueueAccessToken)
+
+	// message queue holds request up to 50 seconds, do not use client that has timeout for GitHub API
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted:
+		return nil, nil
+	case http.StatusOK:
+	default:
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("invalid status code (code: %d, body: %s)", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+
+	var message ScaleSetMessage
+	if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
+		return nil, fmt.Errorf("failed to decode message: %w", err)
+	}
+	return &message, nil
+}
+
+// DeleteMessage delete a message that is processed
+func (c *ScaleSetClient) DeleteMessage(ctx context.Context, session *ScaleSetSession, messageID int64) error {
+	u, err := url.Parse(session.MessageQueueURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse URL of message queue: %w", err)
+	}
+	u.Path = fmt.Sprintf("%s/%d", u.Path, messageID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+session.MessageQueueAccessToken)
+	if err := c.do(req, nil); err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+	return nil
+}
+
+// AcquireJobs acquire available jobs to scale set, return IDs of runner request that are acquired
+func (c *ScaleSetClient) AcquireJobs(ctx context.Context, scaleSetID int, session *ScaleSetSession, requestIDs []int64) ([]int64, error) {
+	serviceURL, _, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(requestIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	u := fmt.Sprintf("%s/_apis/runtime/runnerscalesets/%d/acquirejobs?api-version=%s", serviceURL, scaleSetID, scaleSetAPIVersion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+session.MessageQueueAccessToken)
+
+	var resp struct {
+		Count int     `json:"count"`
+		Value []int64 `json:"value"`
+	}
+	if err := c.do(req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to acquire jobs: %w", err)
+	}
+	return resp.Value, nil
+}
+
+// GenerateJITConfig generate JIT config of a runner in scale set, runner is registered by `run.sh --jitconfig`
+func (c *ScaleSetClient) GenerateJITConfig(ctx context.Context, scaleSetID int, runnerName string) (string, error) {
+	in := map[string]string{"name": runnerName, "workFolder": "_work"}
+	var resp struct {
+		EncodedJITConfig string `json:"encodedJITConfig"`
+	}
+	if err := c.request(ctx, http.MethodPost, fmt.Sprintf("/_apis/runtime/runnerscalesets/%d/generatejitconfig", scaleSetID), in, &resp); err != nil {
+		return "", fmt.Errorf("failed to generate JIT config: %w", err)
+	}
+	return resp.EncodedJITConfig, nil
+}
@@ -0,0 +1,144 @@
+package gh
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestScaleSetClient(serviceURL string) *ScaleSetClient {
+	return &ScaleSetClient{
+		scope:      "octocat",
+		client:     http.DefaultClient,
+		serviceURL: serviceURL,
+		token:      "service-token",
+		expiresAt:  time.Now().Add(1 * time.Hour),
+	}
+}
+
+func TestScaleSetClient(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_apis/runtime/runnerscalesets", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer service-token" {
+			t.Errorf("invalid Authorization header: %s", got)
+		}
+		if got := r.URL.Query().Get("api-version"); got != scaleSetAPIVersion {
+			t.Errorf("invalid api-version: %s", got)
+		}
+		switch r.URL.Query().Get("name") {
+		case "exist":
+			io.WriteString(w, `{"count":1,"value":[{"id":3,"name":"exist","runnerGroupId":1}]}`)
+		default:
+			io.WriteString(w, `{"count":0,"value":[]}`)
+		}
+	})
+	mux.HandleFunc("/_apis/runtime/runnerscalesets/3/sessions", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"sessionId":"s1","ownerName":"myshoes","messageQueueUrl":"`+"http://"+r.Host+`/queue","messageQueueAccessToken":"queue-token"}`)
+	})
+	mux.HandleFunc("/queue", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer queue-token" {
+			t.Errorf("invalid Authorization header: %s", got)
+		}
+		if r.URL.Query().Get("lastMessageId") == "0" {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		io.WriteString(w, `{"messageId":2,"messageType":"RunnerScaleSetJobMessages","body":"[{\"messageType\":\"JobAssigned\",\"runnerRequestId\":10,\"repositoryName\":\"repo\",\"ownerName\":\"octocat\",\"requestLabels\":[\"scale\"]}]"}`)
+	})
+	mux.HandleFunc("/_apis/runtime/runnerscalesets/3/acquirejobs", func(w http.ResponseWriter, r *http.Request) {
+		var ids []int64
+		json.NewDecoder(r.Body).Decode(&ids)
+		b, _ := json.Marshal(map[string]interface{}{"count": len(ids), "value": ids})
+		w.Write(b)
+	})
+	mux.HandleFunc("/_apis/runtime/runnerscalesets/3/generatejitconfig", func(w http.ResponseWriter, r *http.Request) {
+		var in map[string]string
+		json.NewDecoder(r.Body).Decode(&in)
+		io.WriteString(w, `{"runner":{"id":1,"name":"`+in["name"]+`"},"encodedJITConfig":"jit-`+in["name"]+`"}`)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	ctx := context.Background()
+	c := newTestScaleSetClient(ts.URL)
+
+	if _, err := c.GetScaleSet(ctx, 1, "not-exist"); err == nil {
+		t.Fatalf("want error of not found, but got nil")
+	}
+	scaleSet, err := c.GetScaleSet(ctx, 1, "exist")
+	if err != nil {
+		t.Fatalf("failed to get scale set: %+v", err)
+	}
+	if scaleSet.ID != 3 {
+		t.Fatalf("want ID 3, but got %d", scaleSet.ID)
+	}
+
+	session, err := c.CreateSession(ctx, scaleSet.ID, "myshoes")
+	if err != nil {
+		t.Fatalf("failed to create session: %+v", err)
+	}
+
+	message, err := c.GetMessage(ctx, session, 0)
+	if err != nil {
+		t.Fatalf("failed to get message: %+v", err)
+	}
+	if message != nil {
+		t.Fatalf("want no message, but got %+v", message)
+	}
+	message, err = c.GetMessage(ctx, session, 1)
+	if err != nil {
+		t.Fatalf("failed to get message: %+v", err)
+	}
+	jobs, err := message.JobMessages()
+	if err != nil {
+		t.Fatalf("failed to parse job messages: %+v", err)
+	}
+	wantJobs := []ScaleSetJobMessage{{
+		MessageType:     ScaleSetJobAssigned,
+		RunnerRequestID: 10,
+		RepositoryName:  "repo",
+		OwnerName:       "octocat",
+		RequestLabels:   []string{"scale"},
+	}}
+	if diff := cmp.Diff(wantJobs, jobs); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	acquired, err := c.AcquireJobs(ctx, scaleSet.ID, session, []int64{10, 11})
+	if err != nil {
+		t.Fatalf("failed to acquire jobs: %+v", err)
+	}
+	if diff := cmp.Diff([]int64{10, 11}, acquired); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	jitConfig, err := c.GenerateJITConfig(ctx, scaleSet.ID, "myshoes-runner")
+	if err != nil {
+		t.Fatalf("failed to generate JIT config: %+v", err)
+	}
+	if jitConfig != "jit-myshoes-runner" {
+		t.Fatalf("want jit-myshoes-runner, but got %s", jitConfig)
+	}
+}
+
+func TestTokenExpiresAt(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"exp":1700000000}`))
+	got, err := tokenExpiresAt("header." + payload + ".signature")
+	if err != nil {
+		t.Fatalf("failed to get expiration: %+v", err)
+	}
+	if !got.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("want %s, but got %s", time.Unix(1700000000, 0), got)
+	}
+
+	if _, err := tokenExpiresAt("not-jwt"); err == nil {
+		t.Fatalf("want error, but got nil")
+	}
+}
@@ -10,8 +10,12 @@ const (
 	Unknown Scope = iota
 	Repository
 	Organization
+	Enterprise
 )
 
+// enterpriseScopePrefix is prefix of enterprise scope (enterprises/:enterprise)
+const enterpriseScopePrefix = "enterprises/"
+
 // String is fmt.Stringer interface
 func (s Scope) String() string {
 	switch s {
@@ -19,30 +23,37 @@ func (s Scope) String() string {
 		return "repos"
 	case Organization:
 		return "orgs"
+	case Enterprise:
+		return "enterprises"
 	default:
 		return "unknown"
 	}
 }
 
-// DetectScope detect a scope (repo or org)
+// DetectScope detect a scope (repo, org or enterprise).
+// enterprise scope is "enterprises/:enterprise", :enterprise is slug of enterprise
 func DetectScope(scope string) Scope {
 	sep := strings.Split(scope, "/")
 	switch len(sep) {
 	case 1:
 		return Organization
 	case 2:
+		if strings.HasPrefix(scope, enterpriseScopePrefix) && sep[1] != "" {
+			return Enterprise
+		}
 		return Repository
 	default:
 		return Unknown
 	}
 }
 
-// DivideScope divide scope to owner and repo
+// DivideScope divide scope to owner and repo.
+// owner is scope itself and repo is empty in enterprise scope
 func DivideScope(scope string) (string, string) {
 	var owner, repo string
 
 	switch DetectScope(scope) {
-	case Organization:
+	case Organization, Enterprise:
 		owner = scope
 		repo = ""
 	case Repository:
@@ -53,3 +64,16 @@ func DivideScope(scope string) (string, string) {
 
 	return owner, repo
 }
+
+// EnterpriseScope return scope of enterprise
+func EnterpriseScope(slug string) string {
+	return enterpriseScopePrefix + slug
+}
+
+// EnterpriseSlug return slug of enterprise in scope, return empty if scope is not enterprise
+func EnterpriseSlug(scope string) string {
+	if DetectScope(scope) != Enterprise {
+		return ""
+	}
+	return strings.TrimPrefix(scope, enterpriseScopePrefix)
+}
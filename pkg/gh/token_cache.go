@@ -0,0 +1,154 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+var (
+	// TokenRefreshInterval is interval time of refreshing cached installation tokens
+	TokenRefreshInterval = 1 * time.Minute
+	// tokenIdleTimeout is time that unused token is evicted from cache, it is not refreshed anymore
+	tokenIdleTimeout = 2 * time.Hour
+
+	tokens = &tokenCache{entries: map[tokenKey]*tokenEntry{}}
+)
+
+type tokenKey struct {
+	host           *Host
+	installationID int64
+}
+
+type tokenEntry struct {
+	token     string
+	expiresAt time.Time
+	scope     string // scope for storing rate limit
+	lastUsed  time.Time
+}
+
+// tokenCache stores installation tokens of GitHub Apps per host and installation
+type tokenCache struct {
+	mu      sync.Mutex
+	entries map[tokenKey]*tokenEntry
+}
+
+// get return token that is not expired within refreshBefore
+func (c *tokenCache) get(key tokenKey, now time.Time) (string, *time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || e.expiresAt.Sub(now) <= config.Config.GitHubTokenRefreshBefore {
+		return "", nil, false
+	}
+	e.lastUsed = now
+	expiresAt := e.expiresAt
+	return e.token, &expiresAt, true
+}
+
+func (c *tokenCache) set(key tokenKey, token string, expiresAt time.Time, scope string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lastUsed := now
+	if e, ok := c.entries[key]; ok {
+		lastUsed = e.lastUsed
+	}
+	c.entries[key] = &tokenEntry{token: token, expiresAt: expiresAt, scope: scope, lastUsed: lastUsed}
+}
+
+// needRefresh return keys of tokens that will expire by next refresh, and evict tokens that are not used
+func (c *tokenCache) needRefresh(now time.Time) map[tokenKey]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := map[tokenKey]string{}
+	for key, e := range c.entries {
+		if now.Sub(e.lastUsed) > tokenIdleTimeout {
+			delete(c.entries, key)
+			continue
+		}
+		if e.expiresAt.Sub(now) <= config.Config.GitHubTokenRefreshBefore+TokenRefreshInterval {
+			keys[key] = e.scope
+		}
+	}
+	return keys
+}
+
+// getInstallationToken return cached installation token, or generate it if not cached
+func getInstallationToken(ctx context.Context, clientApps *github.Client, installationID int64, scope string) (string, *time.Time, error) {
+	if config.Config.GitHubTokenRefreshBefore <= 0 {
+		return createInstallationToken(ctx, clientApps, installationID, scope)
+	}
+
+	key := tokenKey{host: HostFrom(ctx), installationID: installationID}
+	if token, expiresAt, ok := tokens.get(key, time.Now()); ok {
+		return token, expiresAt, nil
+	}
+	token, expiresAt, err := createInstallationToken(ctx, clientApps, installationID, scope)
+	if err != nil {
+		return "", nil, err
+	}
+	if expiresAt != nil {
+		tokens.set(key, token, *expiresAt, scope, time.Now())
+	}
+	return token, expiresAt, nil
+}
+
+func createInstallationToken(ctx context.Context, clientApps *github.Client, installationID int64, scope string) (string, *time.Time, error) {
+	token, resp, err := clientApps.Apps.CreateInstallationToken(ctx, installationID, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate token from API: %w", err)
+	}
+	storeRateLimit(scope, resp.Rate)
+	return token.GetToken(), token.ExpiresAt, nil
+}
+
+// LoopRefreshInstallationTokens refresh cached installation tokens before expiry,
+// So provisioning does not wait generating token after expiry
+func LoopRefreshInstallationTokens(ctx context.Context) error {
+	if IsPATMode() || config.Config.GitHubTokenRefreshBefore <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(TokenRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			refreshInstallationTokens(ctx)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func refreshInstallationTokens(ctx context.Context) {
+	if IsDegraded() {
+		return
+	}
+	for key, scope := range tokens.needRefresh(time.Now()) {
+		clientApps, err := key.host.NewClientGitHubApps()
+		if err != nil {
+			logger.Logf(false, "failed to create a client from Apps: %+v", err)
+			continue
+		}
+		token, expiresAt, err := createInstallationToken(WithHost(ctx, key.host), clientApps, key.installationID, scope)
+		if err != nil {
+			logger.Logf(false, "failed to refresh installation token (installation ID: %d): %+v", key.installationID, err)
+			continue
+		}
+		if expiresAt != nil {
+			tokens.set(key, token, *expiresAt, scope, time.Now())
+		}
+		logger.Logf(true, "refreshed installation token (installation ID: %d)", key.installationID)
+	}
+}
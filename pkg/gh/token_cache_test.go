@@ -0,0 +1,40 @@
+package gh
+
+import (
+	"testing"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+func TestTokenCache(t *testing.T) {
+	config.Config.GitHubTokenRefreshBefore = 15 * time.Minute
+	now := time.Now()
+
+	tests := []struct {
+		expiresAt   time.Time
+		lastUsed    time.Time
+		wantCached  bool
+		wantRefresh bool
+	}{
+		{expiresAt: now.Add(1 * time.Hour), lastUsed: now, wantCached: true, wantRefresh: false},
+		{expiresAt: now.Add(16 * time.Minute), lastUsed: now, wantCached: true, wantRefresh: true},
+		{expiresAt: now.Add(10 * time.Minute), lastUsed: now, wantCached: false, wantRefresh: true},
+		{expiresAt: now.Add(10 * time.Minute), lastUsed: now.Add(-3 * time.Hour), wantCached: false, wantRefresh: false},
+	}
+
+	for _, test := range tests {
+		c := &tokenCache{entries: map[tokenKey]*tokenEntry{}}
+		key := tokenKey{host: primaryHost, installationID: 1}
+		c.set(key, "token", test.expiresAt, "octocat", test.lastUsed)
+
+		_, refresh := c.needRefresh(now)[key]
+		if refresh != test.wantRefresh {
+			t.Errorf("want refresh %t, but got %t (expires at: %s)", test.wantRefresh, refresh, test.expiresAt)
+		}
+		_, _, cached := c.get(key, now)
+		if cached != test.wantCached {
+			t.Errorf("want cached %t, but got %t (expires at: %s)", test.wantCached, cached, test.expiresAt)
+		}
+	}
+}
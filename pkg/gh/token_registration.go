@@ -3,6 +3,7 @@ package gh
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/patrickmn/go-cache"
@@ -10,12 +11,16 @@ import (
 
 var (
 	cacheRegistrationToken = cache.New(1*time.Hour, 1*time.Hour)
+
+	// RegistrationTokenRefreshBefore is margin before expiry of cached registration token.
+	// token is reused until this margin, So instance can register runner by token after boot
+	RegistrationTokenRefreshBefore = 6 * time.Minute
 )
 
-// GetRunnerRegistrationToken get token for register runner
-// clientInstallation needs to response of `NewClientInstallation()`
+// GetRunnerRegistrationToken get token for register runner.
+// token is valid for an hour and reusable, So it is cached per scope until RegistrationTokenRefreshBefore of expiry
 func GetRunnerRegistrationToken(ctx context.Context, installationID int64, scope string) (string, error) {
-	cachedToken := getRunnerRegisterTokenFromCache(installationID, scope)
+	cachedToken := getRunnerRegisterTokenFromCache(ctx, scope)
 	if cachedToken != "" {
 		return cachedToken, nil
 	}
@@ -24,45 +29,65 @@ func GetRunnerRegistrationToken(ctx context.Context, installationID int64, scope
 	if err != nil {
 		return "", fmt.Errorf("failed to generate runner register token: %w", err)
 	}
-	setRunnerRegisterTokenCache(installationID, scope, rrToken, *expiresAt)
+	setRunnerRegisterTokenCache(ctx, scope, rrToken, *expiresAt)
 	return rrToken, nil
 }
 
+// InvalidateRunnerRegistrationToken delete cached registration token of scope (e.g. runner failed to register by the token)
+func InvalidateRunnerRegistrationToken(ctx context.Context, scope string) {
+	cacheRegistrationToken.Delete(getCacheKeyRegistrationToken(ctx, scope))
+}
+
 // generateRunnerRegistrationToken generate token for register runner
 // clientInstallation needs to response of `NewClientInstallation()`
 func generateRunnerRegisterToken(ctx context.Context, installationID int64, scope string) (string, *time.Time, error) {
-	clientInstallation, err := NewClientInstallation(installationID)
+	if IsDegraded() {
+		return "", nil, ErrGitHubDegraded
+	}
+
+	clientInstallation, err := HostFrom(ctx).NewClientInstallation(installationID)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to create a client installation: %w", err)
 	}
 
 	switch DetectScope(scope) {
 	case Organization:
-		token, _, err := clientInstallation.Actions.CreateOrganizationRegistrationToken(ctx, scope)
+		token, resp, err := clientInstallation.Actions.CreateOrganizationRegistrationToken(ctx, scope)
 		if err != nil {
 			return "", nil, fmt.Errorf("failed to generate registration token for organization (scope: %s): %w", scope, err)
 		}
+		storeRateLimit(scope, resp.Rate)
 		return *token.Token, &token.ExpiresAt.Time, nil
 	case Repository:
 		owner, repo := DivideScope(scope)
-		token, _, err := clientInstallation.Actions.CreateRegistrationToken(ctx, owner, repo)
+		token, resp, err := clientInstallation.Actions.CreateRegistrationToken(ctx, owner, repo)
 		if err != nil {
 			return "", nil, fmt.Errorf("failed to generate registration token for repository (scope: %s): %w", scope, err)
 		}
+		storeRateLimit(scope, resp.Rate)
+		return *token.Token, &token.ExpiresAt.Time, nil
+	case Enterprise:
+		token, _, err := clientInstallation.Enterprise.CreateRegistrationToken(ctx, EnterpriseSlug(scope))
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to generate registration token for enterprise (scope: %s): %w", scope, err)
+		}
 		return *token.Token, &token.ExpiresAt.Time, nil
 	default:
 		return "", nil, fmt.Errorf("failed to detect scope (scope: %s)", scope)
 	}
 }
 
-func setRunnerRegisterTokenCache(installationID int64, scope, token string, expiresAt time.Time) {
-	expiresDuration := time.Until(expiresAt.Add(-6 * time.Minute))
+func setRunnerRegisterTokenCache(ctx context.Context, scope, token string, expiresAt time.Time) {
+	expiresDuration := time.Until(expiresAt.Add(-RegistrationTokenRefreshBefore))
+	if expiresDuration <= 0 {
+		return
+	}
 
-	cacheRegistrationToken.Set(getCacheKeyRegistrationToken(installationID, scope), token, expiresDuration)
+	cacheRegistrationToken.Set(getCacheKeyRegistrationToken(ctx, scope), token, expiresDuration)
 }
 
-func getRunnerRegisterTokenFromCache(installationID int64, scope string) string {
-	got, found := cacheRegistrationToken.Get(getCacheKeyRegistrationToken(installationID, scope))
+func getRunnerRegisterTokenFromCache(ctx context.Context, scope string) string {
+	got, found := cacheRegistrationToken.Get(getCacheKeyRegistrationToken(ctx, scope))
 	if !found {
 		return ""
 	}
@@ -73,6 +98,7 @@ func getRunnerRegisterTokenFromCache(installationID int64, scope string) string
 	return token
 }
 
-func getCacheKeyRegistrationToken(installationID int64, scope string) string {
-	return fmt.Sprintf("%s-%d", scope, installationID)
+// getCacheKeyRegistrationToken return key of cache per host and scope, scope is case-insensitive in GitHub
+func getCacheKeyRegistrationToken(ctx context.Context, scope string) string {
+	return fmt.Sprintf("%s-%s", HostFrom(ctx).Hostname(), strings.ToLower(scope))
 }
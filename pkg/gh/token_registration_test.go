@@ -0,0 +1,40 @@
+package gh
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+func TestRunnerRegisterTokenCache(t *testing.T) {
+	config.Config.GitHubURL = "https://github.com"
+	ctx := context.Background()
+	ghes := WithHost(ctx, &Host{conf: config.GitHubHost{URL: "https://github.example.com"}})
+
+	tests := []struct {
+		expiresAt time.Time
+		want      string
+	}{
+		{expiresAt: time.Now().Add(1 * time.Hour), want: "token"},
+		{expiresAt: time.Now().Add(5 * time.Minute), want: ""},
+	}
+
+	for _, test := range tests {
+		cacheRegistrationToken.Flush()
+		setRunnerRegisterTokenCache(ctx, "Octocat/Hello-World", "token", test.expiresAt)
+
+		if got := getRunnerRegisterTokenFromCache(ctx, "octocat/hello-world"); got != test.want {
+			t.Errorf("want %q, but got %q (expires at: %s)", test.want, got, test.expiresAt)
+		}
+		if got := getRunnerRegisterTokenFromCache(ghes, "octocat/hello-world"); got != "" {
+			t.Errorf("token of other host must not be cached, but got %q", got)
+		}
+	}
+
+	InvalidateRunnerRegistrationToken(ctx, "octocat/hello-world")
+	if got := getRunnerRegisterTokenFromCache(ctx, "octocat/hello-world"); got != "" {
+		t.Errorf("token must be invalidated, but got %q", got)
+	}
+}
@@ -0,0 +1,128 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/hashicorp/go-version"
+
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// Feature is optional feature of GitHub that older GHES does not support
+type Feature string
+
+// Feature values
+const (
+	// FeatureJITConfig is API of generating just-in-time config of runner
+	FeatureJITConfig Feature = "jit_config"
+	// FeatureRunnerScaleSet is runner scale set that is registered by listener
+	FeatureRunnerScaleSet Feature = "runner_scale_set"
+)
+
+// featureMinVersions is minimum version of GHES that support features
+var featureMinVersions = map[Feature]*version.Version{
+	FeatureJITConfig:      version.Must(version.NewVersion("3.10.0")),
+	FeatureRunnerScaleSet: version.Must(version.NewVersion("3.10.0")),
+}
+
+// ghesVersions is detected versions of GHES. key: host, value: *version.Version
+var ghesVersions = sync.Map{}
+
+type metaResponse struct {
+	InstalledVersion string `json:"installed_version"`
+}
+
+// DetectGHESVersion detect version of GHES by meta API in each host, and cache it for gating features.
+// do nothing in github.com
+func DetectGHESVersion(ctx context.Context) error {
+	var errs []error
+	for _, h := range Hosts() {
+		if err := h.detectGHESVersion(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.URL(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *Host) detectGHESVersion(ctx context.Context) error {
+	if !h.IsGHES() {
+		return nil
+	}
+
+	clientApps, err := h.NewClientGitHubApps()
+	if err != nil {
+		return fmt.Errorf("failed to create a client from Apps: %w", err)
+	}
+	req, err := clientApps.NewRequest(http.MethodGet, "meta", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	var meta metaResponse
+	resp, err := clientApps.Do(ctx, req, &meta)
+	if err != nil {
+		return fmt.Errorf("failed to get meta: %w", err)
+	}
+
+	installed := meta.InstalledVersion
+	if installed == "" {
+		// older GHES does not return installed_version, but all responses have the header
+		installed = resp.Header.Get("X-GitHub-Enterprise-Version")
+	}
+	v, err := version.NewVersion(installed)
+	if err != nil {
+		return fmt.Errorf("failed to parse version of GHES (version: %q): %w", installed, err)
+	}
+
+	ghesVersions.Store(h.Hostname(), v)
+	logger.Logf(false, "detected version of GitHub Enterprise Server (%s): %s", h.URL(), v)
+	for f, minVersion := range featureMinVersions {
+		if v.LessThan(minVersion) {
+			logger.Logf(false, "%s is disabled in %s, it needs GitHub Enterprise Server %s or later", f, h.URL(), minVersion)
+		}
+	}
+	return nil
+}
+
+// GHESVersion return detected version of GHES in primary host, return empty if github.com or version is not detected
+func GHESVersion() string {
+	return primaryHost.GHESVersion()
+}
+
+// GHESVersion return detected version of GHES, return empty if github.com or version is not detected
+func (h *Host) GHESVersion() string {
+	v, ok := ghesVersions.Load(h.Hostname())
+	if !ok {
+		return ""
+	}
+	return v.(*version.Version).String()
+}
+
+// IsSupported return true if GitHub of primary host supports the feature.
+func IsSupported(f Feature) bool {
+	return primaryHost.IsSupported(f)
+}
+
+// IsSupported return true if GitHub supports the feature.
+// it is always true in github.com, and in GHES that version is not detected (API returns error as before)
+func (h *Host) IsSupported(f Feature) bool {
+	if !h.IsGHES() {
+		return true
+	}
+	v, ok := ghesVersions.Load(h.Hostname())
+	if !ok {
+		return true
+	}
+	return isSupportedVersion(v.(*version.Version), f)
+}
+
+func isSupportedVersion(v *version.Version, f Feature) bool {
+	minVersion, ok := featureMinVersions[f]
+	if !ok {
+		return true
+	}
+	return v.GreaterThanOrEqual(minVersion)
+}
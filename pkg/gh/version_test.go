@@ -0,0 +1,27 @@
+package gh
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-version"
+)
+
+func TestIsSupportedVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		feature Feature
+		want    bool
+	}{
+		{version: "3.9.5", feature: FeatureJITConfig, want: false},
+		{version: "3.10.0", feature: FeatureJITConfig, want: true},
+		{version: "3.12.1", feature: FeatureRunnerScaleSet, want: true},
+		{version: "3.9.5", feature: Feature("unknown"), want: true},
+	}
+
+	for _, test := range tests {
+		v := version.Must(version.NewVersion(test.version))
+		if got := isSupportedVersion(v, test.feature); got != test.want {
+			t.Errorf("mismatch (version: %s, feature: %s, want: %t, got: %t)", test.version, test.feature, test.want, got)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package gh
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/google/go-github/v47/github"
 )
@@ -22,6 +23,12 @@ func parseEventJSON(in []byte) (interface{}, error) {
 		return workflowJobEvent, nil
 	}
 
+	var repositoryDispatch *github.RepositoryDispatchEvent
+	err = json.Unmarshal(in, &repositoryDispatch)
+	if err == nil && repositoryDispatch.GetAction() != "" {
+		return repositoryDispatch, nil
+	}
+
 	var workflowJob *github.WorkflowJob
 	err = json.Unmarshal(in, &workflowJob)
 	if err == nil && workflowJob != nil {
@@ -44,7 +51,206 @@ func ExtractRunsOnLabels(in []byte) ([]string, error) {
 		return t.GetWorkflowJob().Labels, nil
 	case *github.WorkflowJob:
 		return t.Labels, nil
+	case *github.RepositoryDispatchEvent:
+		// repository_dispatch has labels in client_payload
+		payload, err := ParseDispatchPayload(t.ClientPayload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse client_payload: %w", err)
+		}
+		return payload.Labels, nil
 	}
 
 	return []string{}, nil
 }
+
+// ExtractJobID extract ID of job in GitHub (ID of workflow job or check run), return 0 if event has no job
+func ExtractJobID(in []byte) int64 {
+	event, err := parseEventJSON(in)
+	if err != nil {
+		return 0
+	}
+
+	switch t := event.(type) {
+	case *github.WorkflowJobEvent:
+		return t.GetWorkflowJob().GetID()
+	case *github.WorkflowJob:
+		return t.GetID()
+	case *github.CheckRunEvent:
+		return t.GetCheckRun().GetID()
+	}
+	return 0
+}
+
+// ExtractRunID extract ID of workflow run that job belongs to, return 0 if event has no workflow run
+func ExtractRunID(in []byte) int64 {
+	event, err := parseEventJSON(in)
+	if err != nil {
+		return 0
+	}
+
+	switch t := event.(type) {
+	case *github.WorkflowJobEvent:
+		return t.GetWorkflowJob().GetRunID()
+	case *github.WorkflowJob:
+		return t.GetRunID()
+	}
+	return 0
+}
+
+// CapacityEventType is event_type of repository_dispatch that synthesized by capacity API
+const CapacityEventType = "myshoes_capacity"
+
+// MaxDispatchCount is max number of instances in a request
+const MaxDispatchCount = 100
+
+// DispatchPayload is client_payload of repository_dispatch that request a runner to myshoes
+type DispatchPayload struct {
+	Labels []string `json:"labels"`
+	// Count is number of instances, one instance if not set
+	Count int `json:"count,omitempty"`
+	// TTL is lifetime of instances (e.g. "1h"), instances are deleted in TTL regardless of runner status
+	TTL string `json:"ttl,omitempty"`
+}
+
+// GetCount return number of requested instances
+func (p *DispatchPayload) GetCount() int {
+	if p.Count <= 0 {
+		return 1
+	}
+	return p.Count
+}
+
+// GetTTL return lifetime of instances, return 0 if not set
+func (p *DispatchPayload) GetTTL() (time.Duration, error) {
+	if p.TTL == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(p.TTL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse ttl: %w", err)
+	}
+	if ttl < 0 {
+		return 0, fmt.Errorf("ttl must be positive (ttl: %s)", p.TTL)
+	}
+	return ttl, nil
+}
+
+// Validate check values in payload
+func (p *DispatchPayload) Validate() error {
+	if p.Count < 0 || p.Count > MaxDispatchCount {
+		return fmt.Errorf("count must be between 1 and %d (count: %d)", MaxDispatchCount, p.Count)
+	}
+	if _, err := p.GetTTL(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseDispatchPayload parse client_payload of repository_dispatch
+func ParseDispatchPayload(in json.RawMessage) (*DispatchPayload, error) {
+	var payload DispatchPayload
+	if len(in) == 0 {
+		return &payload, nil
+	}
+	if err := json.Unmarshal(in, &payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal client_payload: %w", err)
+	}
+	if err := payload.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid client_payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// ExtractTTL extract lifetime of instance from request of runner.
+// return false if request is not bounded by TTL.
+func ExtractTTL(in []byte) (time.Duration, bool) {
+	event, err := parseEventJSON(in)
+	if err != nil {
+		return 0, false
+	}
+	dispatch, ok := event.(*github.RepositoryDispatchEvent)
+	if !ok {
+		return 0, false
+	}
+	payload, err := ParseDispatchPayload(dispatch.ClientPayload)
+	if err != nil {
+		return 0, false
+	}
+	ttl, err := payload.GetTTL()
+	if err != nil || ttl == 0 {
+		return 0, false
+	}
+	return ttl, true
+}
+
+// ExtractEnterpriseSlug extract slug of enterprise that repository of webhook belongs to.
+// return empty if repository does not belong to enterprise
+func ExtractEnterpriseSlug(payload []byte) string {
+	var p struct {
+		Enterprise struct {
+			Slug string `json:"slug"`
+		} `json:"enterprise"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return ""
+	}
+	return p.Enterprise.Slug
+}
+
+// ExtractScopeChange extract scope before and after change from webhook of renamed or transferred repository (repository event),
+// or renamed organization (organization event). return false if payload is not change of scope
+func ExtractScopeChange(payload []byte) (string, string, bool) {
+	var p struct {
+		Action  string `json:"action"`
+		Changes struct {
+			Repository struct {
+				Name struct {
+					From string `json:"from"`
+				} `json:"name"`
+			} `json:"repository"`
+			Owner struct {
+				From struct {
+					Organization struct {
+						Login string `json:"login"`
+					} `json:"organization"`
+					User struct {
+						Login string `json:"login"`
+					} `json:"user"`
+				} `json:"from"`
+			} `json:"owner"`
+			Login struct {
+				From string `json:"from"`
+			} `json:"login"`
+		} `json:"changes"`
+		Repository *struct {
+			Name  string `json:"name"`
+			Owner struct {
+				Login string `json:"login"`
+			} `json:"owner"`
+		} `json:"repository"`
+		Organization *struct {
+			Login string `json:"login"`
+		} `json:"organization"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return "", "", false
+	}
+
+	switch {
+	case p.Repository != nil && p.Action == "renamed" && p.Changes.Repository.Name.From != "":
+		owner := p.Repository.Owner.Login
+		return owner + "/" + p.Changes.Repository.Name.From, owner + "/" + p.Repository.Name, true
+	case p.Repository != nil && p.Action == "transferred":
+		from := p.Changes.Owner.From.Organization.Login
+		if from == "" {
+			from = p.Changes.Owner.From.User.Login
+		}
+		if from == "" {
+			return "", "", false
+		}
+		return from + "/" + p.Repository.Name, p.Repository.Owner.Login + "/" + p.Repository.Name, true
+	case p.Repository == nil && p.Organization != nil && p.Action == "renamed" && p.Changes.Login.From != "":
+		return p.Changes.Login.From, p.Organization.Login, true
+	}
+	return "", "", false
+}
@@ -0,0 +1,215 @@
+package gh
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v47/github"
+)
+
+func TestExtractRunsOnLabels(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+		err   bool
+	}{
+		{
+			input: `{"action": "queued", "workflow_job": {"id": 1, "labels": ["self-hosted", "myshoes"]}}`,
+			want:  []string{"self-hosted", "myshoes"},
+			err:   false,
+		},
+		{
+			input: `{"action": "prebuild", "branch": "main", "client_payload": {"labels": ["myshoes", "large"]}}`,
+			want:  []string{"myshoes", "large"},
+			err:   false,
+		},
+		{
+			input: `{"action": "prebuild", "branch": "main", "client_payload": {}}`,
+			want:  nil,
+			err:   false,
+		},
+		{
+			input: `{"action": "prebuild", "branch": "main"}`,
+			want:  nil,
+			err:   false,
+		},
+		{
+			input: `{"action": "prebuild", "client_payload": {"labels": "myshoes"}}`,
+			want:  nil,
+			err:   true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ExtractRunsOnLabels([]byte(test.input))
+		if !test.err && err != nil {
+			t.Fatalf("failed to extract labels: %+v", err)
+		}
+		if test.err && err == nil {
+			t.Fatalf("must be error, but not error (input: %s)", test.input)
+		}
+
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestParseEventJSON_RepositoryDispatch(t *testing.T) {
+	tests := []string{
+		`{"action": "prebuild", "branch": "main", "client_payload": {"labels": ["myshoes"]}}`,
+		// client_payload is optional in dispatch request
+		`{"action": "prebuild", "branch": "main"}`,
+	}
+
+	for _, input := range tests {
+		event, err := parseEventJSON([]byte(input))
+		if err != nil {
+			t.Fatalf("failed to parse event json: %+v", err)
+		}
+		if _, ok := event.(*github.RepositoryDispatchEvent); !ok {
+			t.Errorf("must be parsed as repository_dispatch (input: %s, got: %T)", input, event)
+		}
+	}
+}
+
+func TestExtractTTL(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantTTL time.Duration
+		wantOK  bool
+	}{
+		{
+			input:   `{"action": "capacity", "client_payload": {"labels": ["myshoes"], "count": 3, "ttl": "2h"}}`,
+			wantTTL: 2 * time.Hour,
+			wantOK:  true,
+		},
+		{
+			input:   `{"action": "capacity", "client_payload": {"labels": ["myshoes"]}}`,
+			wantTTL: 0,
+			wantOK:  false,
+		},
+		{
+			input:   `{"action": "capacity", "client_payload": {"ttl": "-1h"}}`,
+			wantTTL: 0,
+			wantOK:  false,
+		},
+		{
+			input:   `{"action": "queued", "workflow_job": {"id": 1, "labels": ["self-hosted", "myshoes"]}}`,
+			wantTTL: 0,
+			wantOK:  false,
+		},
+	}
+
+	for _, test := range tests {
+		gotTTL, gotOK := ExtractTTL([]byte(test.input))
+		if gotOK != test.wantOK {
+			t.Fatalf("mismatch ok (input: %s, want: %t, got: %t)", test.input, test.wantOK, gotOK)
+		}
+		if gotTTL != test.wantTTL {
+			t.Errorf("mismatch ttl (input: %s, want: %s, got: %s)", test.input, test.wantTTL, gotTTL)
+		}
+	}
+}
+
+func TestExtractRunID(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{
+			input: `{"action": "queued", "workflow_job": {"id": 1, "run_id": 42, "labels": ["self-hosted", "myshoes"]}}`,
+			want:  42,
+		},
+		{
+			input: `{"action": "capacity", "client_payload": {"labels": ["myshoes"]}}`,
+			want:  0,
+		},
+		{
+			input: `invalid`,
+			want:  0,
+		},
+	}
+
+	for _, test := range tests {
+		if got := ExtractRunID([]byte(test.input)); got != test.want {
+			t.Errorf("mismatch (input: %s, want: %d, got: %d)", test.input, test.want, got)
+		}
+	}
+}
+
+func TestExtractEnterpriseSlug(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{
+			input: `{"action": "queued", "enterprise": {"id": 1, "slug": "octo-enterprise"}, "workflow_job": {"id": 1}}`,
+			want:  "octo-enterprise",
+		},
+		{
+			input: `{"action": "queued", "workflow_job": {"id": 1}}`,
+			want:  "",
+		},
+		{
+			input: `invalid`,
+			want:  "",
+		},
+	}
+
+	for _, test := range tests {
+		if got := ExtractEnterpriseSlug([]byte(test.input)); got != test.want {
+			t.Errorf("mismatch (input: %s, want: %s, got: %s)", test.input, test.want, got)
+		}
+	}
+}
+
+func TestExtractScopeChange(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantOld  string
+		wantNew  string
+		wantBool bool
+	}{
+		{
+			input:    `{"action": "renamed", "changes": {"repository": {"name": {"from": "old-repo"}}}, "repository": {"name": "new-repo", "owner": {"login": "octocat"}}}`,
+			wantOld:  "octocat/old-repo",
+			wantNew:  "octocat/new-repo",
+			wantBool: true,
+		},
+		{
+			input:    `{"action": "transferred", "changes": {"owner": {"from": {"organization": {"login": "old-org"}}}}, "repository": {"name": "repo", "owner": {"login": "new-org"}}}`,
+			wantOld:  "old-org/repo",
+			wantNew:  "new-org/repo",
+			wantBool: true,
+		},
+		{
+			input:    `{"action": "transferred", "changes": {"owner": {"from": {"user": {"login": "octocat"}}}}, "repository": {"name": "repo", "owner": {"login": "new-org"}}}`,
+			wantOld:  "octocat/repo",
+			wantNew:  "new-org/repo",
+			wantBool: true,
+		},
+		{
+			input:    `{"action": "renamed", "changes": {"login": {"from": "old-org"}}, "organization": {"login": "new-org"}}`,
+			wantOld:  "old-org",
+			wantNew:  "new-org",
+			wantBool: true,
+		},
+		{
+			input:    `{"action": "edited", "repository": {"name": "repo", "owner": {"login": "octocat"}}, "organization": {"login": "octocat"}}`,
+			wantBool: false,
+		},
+		{
+			input:    `invalid`,
+			wantBool: false,
+		},
+	}
+
+	for _, test := range tests {
+		gotOld, gotNew, gotBool := ExtractScopeChange([]byte(test.input))
+		if gotOld != test.wantOld || gotNew != test.wantNew || gotBool != test.wantBool {
+			t.Errorf("want (%s, %s, %t), but got (%s, %s, %t)", test.wantOld, test.wantNew, test.wantBool, gotOld, gotNew, gotBool)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// Event is a point of runner lifecycle that hook is called
+type Event string
+
+// Event values
+const (
+	EventPostCreate Event = "post_create"
+	EventPreDelete  Event = "pre_delete"
+)
+
+// HeaderSignature is header name of HMAC-SHA256 signature for payload
+const HeaderSignature = "X-Myshoes-Signature-256"
+
+// ErrHookFailed is error that hook is failed in blocking mode
+var ErrHookFailed = errors.New("runner hook is failed")
+
+// Payload is request body of hook
+type Payload struct {
+	Event        Event     `json:"event"`
+	RunnerID     string    `json:"runner_id"`
+	RunnerName   string    `json:"runner_name"`
+	TargetID     string    `json:"target_id"`
+	Scope        string    `json:"scope"`
+	CloudID      string    `json:"cloud_id"`
+	IPAddress    string    `json:"ip_address"`
+	ShoesType    string    `json:"shoes_type"`
+	ResourceType string    `json:"resource_type"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// newPayload create Payload from runner
+func newPayload(event Event, runner datastore.Runner, runnerName, scope string) Payload {
+	return Payload{
+		Event:        event,
+		RunnerID:     runner.UUID.String(),
+		RunnerName:   runnerName,
+		TargetID:     runner.TargetID.String(),
+		Scope:        scope,
+		CloudID:      runner.CloudID,
+		IPAddress:    runner.IPAddress,
+		ShoesType:    runner.ShoesType,
+		ResourceType: runner.ResourceType.String(),
+		Timestamp:    time.Now().UTC(),
+	}
+}
+
+// IsEnabled return true if hook is configured
+func IsEnabled() bool {
+	return config.Config.RunnerHookURL != ""
+}
+
+// Fire send event of runner to hook and record result to datastore.
+// return ErrHookFailed only if hook is failed and RUNNER_HOOK_BLOCKING is true.
+func Fire(ctx context.Context, ds datastore.Datastore, event Event, runner datastore.Runner, runnerName, scope string) error {
+	if !IsEnabled() {
+		return nil
+	}
+
+	result := datastore.RunnerHookResult{
+		RunnerID: runner.UUID,
+		Event:    string(event),
+		Success:  true,
+		Blocking: config.Config.RunnerHookBlocking,
+	}
+
+	statusCode, err := send(ctx, newPayload(event, runner, runnerName, scope))
+	result.StatusCode = statusCode
+	if err != nil {
+		logger.Logf(false, "failed to call runner hook (event: %s, runner: %s): %+v", event, runner.UUID, err)
+		result.Success = false
+		result.Message = err.Error()
+	}
+
+	if err := ds.CreateRunnerHookResult(ctx, result); err != nil {
+		logger.Logf(false, "failed to record result of runner hook (event: %s, runner: %s): %+v", event, runner.UUID, err)
+	}
+
+	if !result.Success && config.Config.RunnerHookBlocking {
+		return fmt.Errorf("%w (event: %s): %s", ErrHookFailed, event, result.Message)
+	}
+	return nil
+}
+
+// send POST payload to hook URL, return status code of response
+func send(ctx context.Context, payload Payload) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to json.Marshal: %w", err)
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, config.Config.RunnerHookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(cctx, http.MethodPost, config.Config.RunnerHookURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(config.Config.RunnerHookSecret) != 0 {
+		req.Header.Set(HeaderSignature, "sha256="+sign(body, config.Config.RunnerHookSecret))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to do request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("hook return invalid status code %d: %s", resp.StatusCode, respBody)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func sign(body, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
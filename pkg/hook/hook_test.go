@@ -0,0 +1,93 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
+)
+
+func TestFire(t *testing.T) {
+	testRunner := datastore.Runner{
+		UUID:         uuid.FromStringOrNil("7943c6d4-5b3e-4d57-8fb8-1a5b2b3e9c0d"),
+		TargetID:     uuid.FromStringOrNil("8cc1a4d5-4bf1-43d5-8d8f-3a3b7c5a4f7e"),
+		CloudID:      "cloud-id",
+		IPAddress:    "192.0.2.1",
+		ResourceType: datastore.ResourceTypeNano,
+	}
+
+	tests := []struct {
+		status   int
+		blocking bool
+		want     bool // success
+		err      bool
+	}{
+		{status: http.StatusOK, blocking: true, want: true, err: false},
+		{status: http.StatusInternalServerError, blocking: false, want: false, err: false},
+		{status: http.StatusInternalServerError, blocking: true, want: false, err: true},
+	}
+
+	for _, test := range tests {
+		var got Payload
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(HeaderSignature) != "sha256="+sign(mustReadBody(t, r, &got), []byte("secret")) {
+				t.Errorf("invalid signature")
+			}
+			w.WriteHeader(test.status)
+		}))
+
+		config.Config.RunnerHookURL = ts.URL
+		config.Config.RunnerHookSecret = []byte("secret")
+		config.Config.RunnerHookBlocking = test.blocking
+		config.Config.RunnerHookTimeout = 1 * time.Second
+
+		ds, _ := memory.New(nil)
+		err := Fire(context.Background(), ds, EventPostCreate, testRunner, "myshoes-test", "octocat")
+		ts.Close()
+
+		if !test.err && err != nil {
+			t.Fatalf("failed to fire hook: %+v", err)
+		}
+		if test.err && !errors.Is(err, ErrHookFailed) {
+			t.Fatalf("must be ErrHookFailed, but got %+v", err)
+		}
+		if got.Event != EventPostCreate || got.CloudID != "cloud-id" || got.Scope != "octocat" {
+			t.Fatalf("invalid payload: %+v", got)
+		}
+
+		results, err := ds.ListRunnerHookResults(context.Background(), testRunner.UUID)
+		if err != nil {
+			t.Fatalf("failed to list hook results: %+v", err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("incorrect length results, want: 1 but got: %d", len(results))
+		}
+		if results[0].Success != test.want || results[0].StatusCode != test.status {
+			t.Fatalf("invalid result: %+v", results[0])
+		}
+	}
+
+	config.Config.RunnerHookURL = ""
+}
+
+func mustReadBody(t *testing.T, r *http.Request, out *Payload) []byte {
+	t.Helper()
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		t.Fatalf("failed to decode body: %+v", err)
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		t.Fatalf("failed to unmarshal body: %+v", err)
+	}
+	return raw
+}
@@ -0,0 +1,115 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+var (
+	// IsLeader is true if this instance is leader
+	IsLeader atomic.Bool
+
+	// ElectionInterval is interval of trying to be leader
+	ElectionInterval = 1 * time.Second
+)
+
+// Elector elect a leader from myshoes replicas by Locker
+type Elector struct {
+	locker Locker
+}
+
+// NewElector create Elector
+func NewElector(locker Locker) *Elector {
+	return &Elector{
+		locker: locker,
+	}
+}
+
+// Run block until ctx is done, and call lead while this instance is leader.
+// ctx of lead is canceled when leadership is lost, then this instance goes back to standby.
+// Run return error if lead return error.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context) error) error {
+	for {
+		if err := e.campaign(ctx); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return fmt.Errorf("failed to campaign: %w", err)
+		}
+
+		lost, err := e.lead(ctx, lead)
+		if err != nil {
+			return err
+		}
+		if !lost {
+			return nil
+		}
+		logger.Logf(false, "leadership is lost, back to standby")
+	}
+}
+
+// campaign block until this instance is leader
+func (e *Elector) campaign(ctx context.Context) error {
+	logger.Samplef(false, "start getting lock...")
+
+	ticker := time.NewTicker(ElectionInterval)
+	defer ticker.Stop()
+
+	for {
+		isLocked, err := e.locker.IsLocked(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to check lock: %w", err)
+		}
+
+		if strings.EqualFold(isLocked, datastore.IsNotLocked) {
+			err := e.locker.GetLock(ctx)
+			if err == nil {
+				logger.Logf(false, "get lock successfully! this instance is leader")
+				return nil
+			}
+			if !errors.Is(err, ErrNotAcquired) {
+				return fmt.Errorf("failed to get lock: %w", err)
+			}
+			// other instance get lock before me, retry
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// lead call lead until leadership is lost. return true if leadership is lost
+func (e *Elector) lead(ctx context.Context, lead func(ctx context.Context) error) (bool, error) {
+	IsLeader.Store(true)
+	defer IsLeader.Store(false)
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var lost atomic.Bool
+	if keeper, ok := e.locker.(Keeper); ok {
+		go func() {
+			select {
+			case <-keeper.Lost():
+				lost.Store(true)
+				cancel()
+			case <-leaderCtx.Done():
+			}
+		}()
+	}
+
+	if err := lead(leaderCtx); err != nil && !lost.Load() {
+		return false, err
+	}
+	return lost.Load(), nil
+}
@@ -0,0 +1,90 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// fakeKeeper is Keeper on memory, lost is closed by test
+type fakeKeeper struct {
+	mu     sync.Mutex
+	locked bool
+	lost   chan struct{}
+}
+
+func (f *fakeKeeper) GetLock(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.locked {
+		return ErrNotAcquired
+	}
+	f.locked = true
+	f.lost = make(chan struct{})
+	return nil
+}
+
+func (f *fakeKeeper) IsLocked(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.locked {
+		return datastore.IsLocked, nil
+	}
+	return datastore.IsNotLocked, nil
+}
+
+func (f *fakeKeeper) Lost() <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lost
+}
+
+// expire release lock and close lost channel
+func (f *fakeKeeper) expire() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.locked = false
+	close(f.lost)
+}
+
+func TestElector_Run(t *testing.T) {
+	ElectionInterval = 10 * time.Millisecond
+
+	locker := &fakeKeeper{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leadCount := make(chan struct{}, 10)
+	errCh := make(chan error)
+	go func() {
+		errCh <- NewElector(locker).Run(ctx, func(ctx context.Context) error {
+			leadCount <- struct{}{}
+			<-ctx.Done()
+			return nil
+		})
+	}()
+
+	<-leadCount
+	if !IsLeader.Load() {
+		t.Fatalf("must be leader")
+	}
+
+	// leadership is lost, and elected again
+	locker.expire()
+	select {
+	case <-leadCount:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("must be elected again after lost")
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf("failed to run elector: %+v", err)
+	}
+	if IsLeader.Load() {
+		t.Fatalf("must not be leader after stopped")
+	}
+}
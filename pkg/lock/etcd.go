@@ -0,0 +1,221 @@
+package lock
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// Etcd is Locker that use lease in etcd.
+// Etcd access to etcd via gRPC gateway (JSON API of etcd v3).
+type Etcd struct {
+	endpoint string
+	key      string
+	ttl      time.Duration
+	value    string
+	client   *http.Client
+
+	leaseID string
+	mu      sync.Mutex
+	lost    chan struct{}
+}
+
+// NewEtcd create Etcd locker. endpoint is URL of etcd (e.g. http://127.0.0.1:2379)
+func NewEtcd(endpoint, key string, ttl time.Duration) *Etcd {
+	return &Etcd{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		key:      key,
+		ttl:      ttl,
+		value:    ownerID(),
+		client:   &http.Client{Timeout: ttl},
+		lost:     make(chan struct{}),
+	}
+}
+
+type etcdLeaseGrantResponse struct {
+	ID  string `json:"ID"`
+	TTL string `json:"TTL"`
+}
+
+type etcdLeaseKeepAliveResponse struct {
+	Result struct {
+		ID  string `json:"ID"`
+		TTL string `json:"TTL"`
+	} `json:"result"`
+}
+
+type etcdTxnResponse struct {
+	Succeeded bool `json:"succeeded"`
+}
+
+type etcdRangeResponse struct {
+	Count string `json:"count"`
+}
+
+// GetLock get lock with lease, and keep alive lease until ctx is done. return ErrNotAcquired if lock is held by other
+func (e *Etcd) GetLock(ctx context.Context) error {
+	ttlSeconds := int64(e.ttl.Seconds())
+	if ttlSeconds < 1 {
+		ttlSeconds = 1
+	}
+
+	sent := time.Now()
+	var lease etcdLeaseGrantResponse
+	if err := e.post(ctx, "/v3/lease/grant", map[string]interface{}{"TTL": ttlSeconds}, &lease); err != nil {
+		return fmt.Errorf("failed to grant lease: %w", err)
+	}
+
+	key := base64.StdEncoding.EncodeToString([]byte(e.key))
+	txn := map[string]interface{}{
+		"compare": []map[string]interface{}{
+			{
+				"key":             key,
+				"result":          "EQUAL",
+				"target":          "CREATE",
+				"create_revision": "0",
+			},
+		},
+		"success": []map[string]interface{}{
+			{
+				"request_put": map[string]interface{}{
+					"key":   key,
+					"value": base64.StdEncoding.EncodeToString([]byte(e.value)),
+					"lease": lease.ID,
+				},
+			},
+		},
+	}
+	var txnResp etcdTxnResponse
+	if err := e.post(ctx, "/v3/kv/txn", txn, &txnResp); err != nil {
+		e.revoke(lease.ID)
+		return fmt.Errorf("failed to put key: %w", err)
+	}
+	if !txnResp.Succeeded {
+		e.revoke(lease.ID)
+		return ErrNotAcquired
+	}
+
+	e.leaseID = lease.ID
+	lost := make(chan struct{})
+	e.mu.Lock()
+	e.lost = lost
+	e.mu.Unlock()
+
+	go e.keepalive(ctx, lost, sent.Add(time.Duration(ttlSeconds)*time.Second))
+	return nil
+}
+
+// IsLocked return status of lock
+func (e *Etcd) IsLocked(ctx context.Context) (string, error) {
+	var resp etcdRangeResponse
+	req := map[string]interface{}{
+		"key":        base64.StdEncoding.EncodeToString([]byte(e.key)),
+		"count_only": true,
+	}
+	if err := e.post(ctx, "/v3/kv/range", req, &resp); err != nil {
+		return "", fmt.Errorf("failed to get key: %w", err)
+	}
+
+	count, _ := strconv.Atoi(resp.Count)
+	if count == 0 {
+		return datastore.IsNotLocked, nil
+	}
+	return datastore.IsLocked, nil
+}
+
+// Lost return channel that closed when lock is lost
+func (e *Etcd) Lost() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lost
+}
+
+// keepalive keep alive lease until ctx is done. lost is closed if lease is expired,
+// or if lease is not kept alive until just before expireAt, so this instance step down before other instance get lock.
+func (e *Etcd) keepalive(ctx context.Context, lost chan struct{}, expireAt time.Time) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+	stepDown := time.NewTimer(UntilStepDown(e.ttl, expireAt))
+	defer func() { stepDown.Stop() }()
+
+	for {
+		select {
+		case <-ticker.C:
+			sent := time.Now()
+			keepaliveCtx, cancel := context.WithDeadline(ctx, expireAt)
+			var resp etcdLeaseKeepAliveResponse
+			err := e.post(keepaliveCtx, "/v3/lease/keepalive", map[string]interface{}{"ID": e.leaseID}, &resp)
+			cancel()
+			if err != nil {
+				// retry in next tick, lease is alive until expireAt
+				logger.Logf(false, "failed to keep alive lease in etcd: %+v", err)
+				continue
+			}
+			ttl, _ := strconv.Atoi(resp.Result.TTL)
+			if ttl <= 0 {
+				logger.Logf(false, "lease in etcd is expired (key: %s, lease: %s)", e.key, e.leaseID)
+				close(lost)
+				return
+			}
+			expireAt = sent.Add(time.Duration(ttl) * time.Second)
+			stepDown.Stop()
+			stepDown = time.NewTimer(UntilStepDown(e.ttl, expireAt))
+		case <-stepDown.C:
+			logger.Logf(false, "lease in etcd is not kept alive until it expires (key: %s, lease: %s)", e.key, e.leaseID)
+			close(lost)
+			return
+		case <-ctx.Done():
+			e.revoke(e.leaseID)
+			return
+		}
+	}
+}
+
+// revoke revoke lease, key that attached to lease is deleted
+func (e *Etcd) revoke(leaseID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := e.post(ctx, "/v3/lease/revoke", map[string]interface{}{"ID": leaseID}, nil); err != nil {
+		logger.Logf(false, "failed to revoke lease in etcd: %+v", err)
+	}
+}
+
+func (e *Etcd) post(ctx context.Context, path string, in interface{}, out interface{}) error {
+	jb, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to json.Marshal: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint+path, bytes.NewBuffer(jb))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("invalid status code (%d)", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
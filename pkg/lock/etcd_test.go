@@ -0,0 +1,108 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// fakeEtcd is a minimum implementation of etcd gRPC gateway for lock
+type fakeEtcd struct {
+	mu      sync.Mutex
+	kv      map[string]string // key -> lease ID
+	ttl     string
+	leaseID int
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var in map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&in)
+
+	switch r.URL.Path {
+	case "/v3/lease/grant":
+		f.leaseID++
+		json.NewEncoder(w).Encode(map[string]string{"ID": strconv.Itoa(f.leaseID), "TTL": "15"})
+	case "/v3/lease/keepalive":
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]string{"ID": in["ID"].(string), "TTL": f.ttl}})
+	case "/v3/lease/revoke":
+		for key, leaseID := range f.kv {
+			if leaseID == in["ID"].(string) {
+				delete(f.kv, key)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]string{})
+	case "/v3/kv/txn":
+		compare := in["compare"].([]interface{})[0].(map[string]interface{})
+		key := compare["key"].(string)
+		if _, ok := f.kv[key]; ok {
+			json.NewEncoder(w).Encode(map[string]bool{"succeeded": false})
+			return
+		}
+		put := in["success"].([]interface{})[0].(map[string]interface{})["request_put"].(map[string]interface{})
+		f.kv[key] = put["lease"].(string)
+		json.NewEncoder(w).Encode(map[string]bool{"succeeded": true})
+	case "/v3/kv/range":
+		if _, ok := f.kv[in["key"].(string)]; ok {
+			json.NewEncoder(w).Encode(map[string]string{"count": "1"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestEtcd_GetLock(t *testing.T) {
+	fake := &fakeEtcd{kv: map[string]string{}, ttl: "15"}
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := NewEtcd(ts.URL, "myshoes", 300*time.Millisecond)
+	second := NewEtcd(ts.URL, "myshoes", 300*time.Millisecond)
+
+	status, err := first.IsLocked(ctx)
+	if err != nil {
+		t.Fatalf("failed to check lock: %+v", err)
+	}
+	if status != datastore.IsNotLocked {
+		t.Fatalf("must be not locked, but got %s", status)
+	}
+
+	if err := first.GetLock(ctx); err != nil {
+		t.Fatalf("failed to get lock: %+v", err)
+	}
+	if err := second.GetLock(ctx); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("must be ErrNotAcquired, but got %+v", err)
+	}
+	status, err = second.IsLocked(ctx)
+	if err != nil {
+		t.Fatalf("failed to check lock: %+v", err)
+	}
+	if status != datastore.IsLocked {
+		t.Fatalf("must be locked, but got %s", status)
+	}
+
+	// lease is expired
+	fake.mu.Lock()
+	fake.ttl = "0"
+	fake.mu.Unlock()
+	select {
+	case <-first.Lost():
+	case <-time.After(3 * time.Second):
+		t.Fatalf("lost channel must be closed")
+	}
+}
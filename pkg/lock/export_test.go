@@ -0,0 +1,11 @@
+package lock
+
+import (
+	"net"
+	"testing"
+)
+
+// ServeFakeRedis serve a minimum implementation of redis for lock in l
+func ServeFakeRedis(t *testing.T, l net.Listener) {
+	(&fakeRedis{kv: map[string]string{}}).serve(t, l)
+}
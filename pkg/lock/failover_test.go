@@ -0,0 +1,57 @@
+package lock_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/whywaita/myshoes/internal/testutils"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
+	"github.com/whywaita/myshoes/pkg/lock"
+)
+
+// TestFailover run replicas that dispatch jobs by starter with a fake redis, partition leader from redis while jobs
+// are enqueued, and verify that standby take over and all jobs are dispatched exactly once.
+// lock in MySQL is tested by TestMySQL_Failover in pkg/datastore/mysql.
+// run `make test-failover` to repeat it with race detector.
+func TestFailover(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		newServer func(t *testing.T) string
+		newLocker func(addr string, ttl time.Duration) lock.Locker
+	}{
+		{
+			name: "redis",
+			ttl:  500 * time.Millisecond,
+			newServer: func(t *testing.T) string {
+				l, err := net.Listen("tcp", "127.0.0.1:0")
+				if err != nil {
+					t.Fatalf("failed to listen: %+v", err)
+				}
+				t.Cleanup(func() { l.Close() })
+				go lock.ServeFakeRedis(t, l)
+				return l.Addr().String()
+			},
+			newLocker: func(addr string, ttl time.Duration) lock.Locker {
+				return lock.NewRedis(addr, "", "myshoes", ttl)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ds, err := memory.New(nil)
+			if err != nil {
+				t.Fatalf("failed to create datastore: %+v", err)
+			}
+			testutils.RunFailover(t, ds, testutils.FailoverBackend{
+				Addr: test.newServer(t),
+				TTL:  test.ttl,
+				NewLocker: func(t *testing.T, addr string) lock.Locker {
+					return test.newLocker(addr, test.ttl)
+				},
+			})
+		})
+	}
+}
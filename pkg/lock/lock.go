@@ -0,0 +1,79 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// Backend values
+const (
+	BackendDatastore = "datastore"
+	BackendRedis     = "redis"
+	BackendEtcd      = "etcd"
+)
+
+// stepDownMarginDivisor is divisor of TTL. if lock can not be refreshed, leader step down TTL/stepDownMarginDivisor
+// before lock expires, to absorb delay of stopping work as leader before other instance get lock.
+const stepDownMarginDivisor = 5
+
+// Error values
+var (
+	// ErrNotAcquired is error that lock is held by other instance
+	ErrNotAcquired = errors.New("lock is not acquired")
+)
+
+// Locker is lock for running only one active myshoes.
+// datastore.Datastore satisfy this interface.
+type Locker interface {
+	GetLock(ctx context.Context) error
+	IsLocked(ctx context.Context) (string, error)
+}
+
+// Keeper is Locker that hold lock with TTL.
+// Lost is closed when lock is lost (e.g. fail to refresh TTL), myshoes must stop working if it is closed.
+type Keeper interface {
+	Locker
+	Lost() <-chan struct{}
+}
+
+// New create Locker from config. datastore is used as lock if backend is datastore.
+func New(ds datastore.Datastore) (Locker, error) {
+	switch config.Config.LockBackend {
+	case "", BackendDatastore:
+		return ds, nil
+	case BackendRedis:
+		return NewRedis(config.Config.LockEndpoint, config.Config.LockPassword, config.Config.LockKey, config.Config.LockTTL), nil
+	case BackendEtcd:
+		return NewEtcd(config.Config.LockEndpoint, config.Config.LockKey, config.Config.LockTTL), nil
+	}
+
+	return nil, fmt.Errorf("unknown lock backend: %s", config.Config.LockBackend)
+}
+
+// UntilStepDown return duration until leader must step down if lock that has ttl is not refreshed until expireAt.
+// it is also used by lock in datastore that has TTL (e.g. lease in MySQL)
+func UntilStepDown(ttl time.Duration, expireAt time.Time) time.Duration {
+	return time.Until(expireAt) - ttl/stepDownMarginDivisor
+}
+
+// ownerID generate a value that identify this process
+func ownerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%s-%d", hostname, time.Now().UnixNano())
+	}
+	return fmt.Sprintf("%s-%s", hostname, hex.EncodeToString(b))
+}
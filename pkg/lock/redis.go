@@ -0,0 +1,210 @@
+package lock
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// refreshScript extend TTL only if lock is held by own
+const refreshScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`
+
+// releaseScript delete lock only if lock is held by own
+const releaseScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+
+// Redis is Locker that use SET NX with TTL in Redis
+type Redis struct {
+	addr     string
+	password string
+	key      string
+	ttl      time.Duration
+	value    string
+
+	mu   sync.Mutex
+	lost chan struct{}
+}
+
+// NewRedis create Redis locker
+func NewRedis(addr, password, key string, ttl time.Duration) *Redis {
+	return &Redis{
+		addr:     addr,
+		password: password,
+		key:      key,
+		ttl:      ttl,
+		value:    ownerID(),
+		lost:     make(chan struct{}),
+	}
+}
+
+// GetLock get lock, and refresh TTL until ctx is done. return ErrNotAcquired if lock is held by other
+func (r *Redis) GetLock(ctx context.Context) error {
+	sent := time.Now()
+	resp, err := r.do(ctx, "SET", r.key, r.value, "NX", "PX", strconv.FormatInt(r.ttl.Milliseconds(), 10))
+	if err != nil {
+		return fmt.Errorf("failed to SET: %w", err)
+	}
+	if resp != "OK" {
+		return ErrNotAcquired
+	}
+
+	lost := make(chan struct{})
+	r.mu.Lock()
+	r.lost = lost
+	r.mu.Unlock()
+
+	go r.keepalive(ctx, lost, sent.Add(r.ttl))
+	return nil
+}
+
+// IsLocked return status of lock
+func (r *Redis) IsLocked(ctx context.Context) (string, error) {
+	resp, err := r.do(ctx, "EXISTS", r.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to EXISTS: %w", err)
+	}
+
+	if resp == "0" {
+		return datastore.IsNotLocked, nil
+	}
+	return datastore.IsLocked, nil
+}
+
+// Lost return channel that closed when lock is lost
+func (r *Redis) Lost() <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lost
+}
+
+// keepalive refresh TTL of lock until ctx is done. lost is closed if lock is held by other,
+// or if lock is not refreshed until just before expireAt, so this instance step down before other instance get lock.
+func (r *Redis) keepalive(ctx context.Context, lost chan struct{}, expireAt time.Time) {
+	ticker := time.NewTicker(r.ttl / 3)
+	defer ticker.Stop()
+	stepDown := time.NewTimer(UntilStepDown(r.ttl, expireAt))
+	defer func() { stepDown.Stop() }()
+
+	for {
+		select {
+		case <-ticker.C:
+			sent := time.Now()
+			refreshCtx, cancel := context.WithDeadline(ctx, expireAt)
+			resp, err := r.do(refreshCtx, "EVAL", refreshScript, "1", r.key, r.value, strconv.FormatInt(r.ttl.Milliseconds(), 10))
+			cancel()
+			if err != nil {
+				// retry in next tick, lock is alive until expireAt
+				logger.Logf(false, "failed to refresh lock in redis: %+v", err)
+				continue
+			}
+			if resp != "1" {
+				logger.Logf(false, "lock in redis is lost (key: %s)", r.key)
+				close(lost)
+				return
+			}
+			expireAt = sent.Add(r.ttl)
+			stepDown.Stop()
+			stepDown = time.NewTimer(UntilStepDown(r.ttl, expireAt))
+		case <-stepDown.C:
+			logger.Logf(false, "lock in redis is not refreshed until it expires (key: %s)", r.key)
+			close(lost)
+			return
+		case <-ctx.Done():
+			r.release()
+			return
+		}
+	}
+}
+
+// release delete lock if it is held by own
+func (r *Redis) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := r.do(ctx, "EVAL", releaseScript, "1", r.key, r.value); err != nil {
+		logger.Logf(false, "failed to release lock in redis: %+v", err)
+	}
+}
+
+// do send a command to redis and return a reply.
+// integer reply and simple string reply are returned as string, nil reply is returned as empty string.
+func (r *Redis) do(ctx context.Context, args ...string) (string, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", r.addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect redis: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(r.ttl))
+	}
+
+	reader := bufio.NewReader(conn)
+	if r.password != "" {
+		if _, err := conn.Write(encodeCommand("AUTH", r.password)); err != nil {
+			return "", fmt.Errorf("failed to write AUTH: %w", err)
+		}
+		if _, err := readReply(reader); err != nil {
+			return "", fmt.Errorf("failed to AUTH: %w", err)
+		}
+	}
+
+	if _, err := conn.Write(encodeCommand(args...)); err != nil {
+		return "", fmt.Errorf("failed to write command: %w", err)
+	}
+	return readReply(reader)
+}
+
+// encodeCommand encode command as RESP array of bulk strings
+func encodeCommand(args ...string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return []byte(b.String())
+}
+
+// readReply read a reply that is not array
+func readReply(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read reply: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return "", fmt.Errorf("empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", fmt.Errorf("error reply: %s", line[1:])
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid bulk string size: %w", err)
+		}
+		if size < 0 {
+			return "", nil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return "", fmt.Errorf("failed to read bulk string: %w", err)
+		}
+		return string(buf[:size]), nil
+	}
+
+	return "", fmt.Errorf("unsupported reply: %s", line)
+}
@@ -0,0 +1,228 @@
+package lock
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// fakeRedis is a minimum implementation of redis for lock
+type fakeRedis struct {
+	mu  sync.Mutex
+	kv  map[string]string
+	exp map[string]time.Time
+}
+
+func (f *fakeRedis) serve(t *testing.T, l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			args, err := readCommand(bufio.NewReader(conn))
+			if err != nil {
+				t.Logf("failed to read command: %+v", err)
+				return
+			}
+			conn.Write([]byte(f.handle(args)))
+		}(conn)
+	}
+}
+
+func (f *fakeRedis) handle(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	for k, exp := range f.exp {
+		if now.After(exp) {
+			delete(f.kv, k)
+			delete(f.exp, k)
+		}
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "SET":
+		if _, ok := f.kv[args[1]]; ok {
+			return "$-1\r\n"
+		}
+		f.kv[args[1]] = args[2]
+		if len(args) == 6 && strings.EqualFold(args[4], "PX") {
+			f.setExpire(args[1], args[5], now)
+		}
+		return "+OK\r\n"
+	case "EXISTS":
+		if _, ok := f.kv[args[1]]; ok {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "EVAL":
+		// refresh or release
+		if f.kv[args[3]] != args[4] {
+			return ":0\r\n"
+		}
+		if args[1] == releaseScript {
+			delete(f.kv, args[3])
+			delete(f.exp, args[3])
+		} else {
+			f.setExpire(args[3], args[5], now)
+		}
+		return ":1\r\n"
+	}
+	return "-ERR unknown command\r\n"
+}
+
+// setExpire set TTL of key in milliseconds
+func (f *fakeRedis) setExpire(key, ms string, now time.Time) {
+	ttl, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return
+	}
+	if f.exp == nil {
+		f.exp = map[string]time.Time{}
+	}
+	f.exp[key] = now.Add(time.Duration(ttl) * time.Millisecond)
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	for i := 0; i < n; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+func TestRedis_GetLock(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %+v", err)
+	}
+	defer l.Close()
+	fake := &fakeRedis{kv: map[string]string{}}
+	go fake.serve(t, l)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := NewRedis(l.Addr().String(), "", "myshoes", 300*time.Millisecond)
+	second := NewRedis(l.Addr().String(), "", "myshoes", 300*time.Millisecond)
+
+	status, err := first.IsLocked(ctx)
+	if err != nil {
+		t.Fatalf("failed to check lock: %+v", err)
+	}
+	if status != datastore.IsNotLocked {
+		t.Fatalf("must be not locked, but got %s", status)
+	}
+
+	if err := first.GetLock(ctx); err != nil {
+		t.Fatalf("failed to get lock: %+v", err)
+	}
+	if err := second.GetLock(ctx); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("must be ErrNotAcquired, but got %+v", err)
+	}
+	status, err = second.IsLocked(ctx)
+	if err != nil {
+		t.Fatalf("failed to check lock: %+v", err)
+	}
+	if status != datastore.IsLocked {
+		t.Fatalf("must be locked, but got %s", status)
+	}
+
+	// lock is taken by other
+	fake.mu.Lock()
+	fake.kv["myshoes"] = "other"
+	fake.mu.Unlock()
+	select {
+	case <-first.Lost():
+	case <-time.After(3 * time.Second):
+		t.Fatalf("lost channel must be closed")
+	}
+}
+
+func TestRedis_StepDown(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %+v", err)
+	}
+	go (&fakeRedis{kv: map[string]string{}}).serve(t, l)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ttl := 300 * time.Millisecond
+	locker := NewRedis(l.Addr().String(), "", "myshoes", ttl)
+	got := time.Now()
+	if err := locker.GetLock(ctx); err != nil {
+		t.Fatalf("failed to get lock: %+v", err)
+	}
+
+	// redis is unreachable, lock can not be refreshed
+	l.Close()
+	select {
+	case <-locker.Lost():
+		if elapsed := time.Since(got); elapsed >= ttl {
+			t.Fatalf("must step down before lock expires, but stepped down after %s", elapsed)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("lost channel must be closed")
+	}
+}
+
+func TestReadReply(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		err   bool
+	}{
+		{input: "+OK\r\n", want: "OK"},
+		{input: ":1\r\n", want: "1"},
+		{input: "$5\r\nhello\r\n", want: "hello"},
+		{input: "$-1\r\n", want: ""},
+		{input: "-ERR wrong\r\n", err: true},
+	}
+
+	for _, test := range tests {
+		got, err := readReply(bufio.NewReader(strings.NewReader(test.input)))
+		if !test.err && err != nil {
+			t.Fatalf("failed to read reply: %+v", err)
+		}
+		if test.err && err == nil {
+			t.Fatalf("must be error, but not error (input: %q)", test.input)
+		}
+		if got != test.want {
+			t.Errorf("mismatch (input: %q, want: %q, got: %q)", test.input, test.want, got)
+		}
+	}
+}
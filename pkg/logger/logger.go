@@ -1,40 +1,99 @@
 package logger
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/whywaita/myshoes/pkg/config"
 )
 
+// Log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
 var (
-	logger = log.New(os.Stderr, "", log.LstdFlags)
-	logMu  sync.Mutex
+	level  = new(slog.LevelVar)
+	logger = slog.New(newHandler(os.Stderr, FormatText))
+	logMu  sync.RWMutex
 )
 
-// SetLogger set logger in outside of library
+func newHandler(w io.Writer, format string) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == FormatJSON {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// Setup set format and level of default logger
+func Setup(format string, l slog.Level) {
+	SetLevel(l)
+	SetHandler(newHandler(os.Stderr, format))
+}
+
+// SetLogger set logger in outside of library, lines are written to output of l as text
 func SetLogger(l *log.Logger) {
 	if l == nil {
-		l = log.New(os.Stderr, "", log.LstdFlags)
+		SetHandler(nil)
+		return
+	}
+	SetHandler(newHandler(l.Writer(), FormatText))
+}
+
+// SetHandler set backend of logger (e.g. slog.NewJSONHandler), nil is default handler
+func SetHandler(h slog.Handler) {
+	if h == nil {
+		h = newHandler(os.Stderr, FormatText)
 	}
 	logMu.Lock()
-	logger = l
+	logger = slog.New(h)
 	logMu.Unlock()
 }
 
-// Logf is interface for logger
+// SetLevel change level of logger at runtime
+func SetLevel(l slog.Level) {
+	level.Set(l)
+	config.Config.Debug = l <= slog.LevelDebug
+}
+
+// Level return current level of logger
+func Level() slog.Level {
+	return level.Level()
+}
+
+// ParseLevel parse level string (debug, info, warn, error)
+func ParseLevel(in string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(in)); err != nil {
+		return 0, fmt.Errorf("failed to parse log level %s: %w", in, err)
+	}
+	return l, nil
+}
+
+// Logf is interface for logger, debug lines are logged in slog.LevelDebug
 func Logf(isDebug bool, format string, v ...interface{}) {
-	logMu.Lock()
-	defer logMu.Unlock()
-
-	switch {
-	case !isDebug:
-		// normal logging
-		logger.Printf(format, v...)
-	case isDebug && config.Config.Debug:
-		// debug logging
-		format = "[DEBUG] " + format
-		logger.Printf(format, v...)
+	l := toLevel(isDebug)
+	if l < level.Level() {
+		return
+	}
+
+	logMu.RLock()
+	defer logMu.RUnlock()
+
+	logger.Log(context.Background(), l, strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
+
+func toLevel(isDebug bool) slog.Level {
+	if isDebug {
+		return slog.LevelDebug
 	}
+	return slog.LevelInfo
 }
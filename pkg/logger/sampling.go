@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+var sampler = newLogSampler()
+
+// logSampler record last time of logged lines for sampling
+type logSampler struct {
+	mu        sync.Mutex
+	lines     map[string]*sampledLine
+	lastPrune time.Time
+
+	// suppressed is number of suppressed lines per format
+	suppressed map[string]uint64
+}
+
+type sampledLine struct {
+	loggedAt   time.Time
+	suppressed uint64
+}
+
+func newLogSampler() *logSampler {
+	return &logSampler{
+		lines:      map[string]*sampledLine{},
+		suppressed: map[string]uint64{},
+	}
+}
+
+// Samplef is Logf for repetitive lines (e.g. in loop).
+// identical lines are logged once in config.Config.LogSamplingInterval, and number of suppressed lines is appended to next line.
+func Samplef(isDebug bool, format string, v ...interface{}) {
+	if toLevel(isDebug) < level.Level() {
+		return
+	}
+	interval := config.Config.LogSamplingInterval
+	if interval == 0 {
+		Logf(isDebug, format, v...)
+		return
+	}
+
+	msg := fmt.Sprintf(format, v...)
+	suppressed, ok := sampler.allow(msg, format, time.Now(), interval)
+	if !ok {
+		return
+	}
+	if suppressed > 0 {
+		msg = fmt.Sprintf("%s (suppressed %d identical lines)", msg, suppressed)
+	}
+	Logf(isDebug, "%s", msg)
+}
+
+// allow return true if msg is not logged in interval, and number of suppressed lines since last logged
+func (s *logSampler) allow(msg, format string, now time.Time, interval time.Duration) (uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now, interval)
+
+	line, ok := s.lines[msg]
+	if ok && now.Sub(line.loggedAt) < interval {
+		line.suppressed++
+		s.suppressed[format]++
+		return 0, false
+	}
+
+	var suppressed uint64
+	if ok {
+		suppressed = line.suppressed
+	}
+	s.lines[msg] = &sampledLine{loggedAt: now}
+	return suppressed, true
+}
+
+// prune delete lines that not logged recently, lines are not grown by variable messages
+func (s *logSampler) prune(now time.Time, interval time.Duration) {
+	if now.Sub(s.lastPrune) < interval {
+		return
+	}
+	s.lastPrune = now
+
+	for msg, line := range s.lines {
+		if now.Sub(line.loggedAt) >= 2*interval {
+			delete(s.lines, msg)
+		}
+	}
+}
+
+// SuppressedLines return number of suppressed lines per format
+func SuppressedLines() map[string]uint64 {
+	sampler.mu.Lock()
+	defer sampler.mu.Unlock()
+
+	r := make(map[string]uint64, len(sampler.suppressed))
+	for format, count := range sampler.suppressed {
+		r[format] = count
+	}
+	return r
+}
@@ -0,0 +1,12 @@
+package metric
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Go runtime (go_*) and process (process_*) metrics are registered in default registry by client_golang.
+// build information (go_build_info) is registered in addition to them.
+func init() {
+	prometheus.MustRegister(collectors.NewBuildInfoCollector())
+}
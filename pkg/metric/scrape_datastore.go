@@ -3,11 +3,11 @@ package metric
 import (
 	"context"
 	"fmt"
-	"sort"
 	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	uuid "github.com/satori/go.uuid"
 
 	"github.com/whywaita/myshoes/pkg/datastore"
 	"github.com/whywaita/myshoes/pkg/gh"
@@ -33,11 +33,31 @@ var (
 		"Duration time of oldest job",
 		[]string{"job_id", "runs_on"}, nil,
 	)
+	datastorePendingJobsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, datastoreName, "pending_jobs"),
+		"Number of jobs in queue",
+		nil, nil,
+	)
+	datastoreOldestPendingJobAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, datastoreName, "oldest_pending_job_age_seconds"),
+		"Elapsed time since the oldest job in queue was created",
+		nil, nil,
+	)
 	datastoreDeletedJobsDesc = prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, datastoreName, "deleted_jobs"),
 		"Number of deleted jobs",
 		[]string{"runs_on"}, nil,
 	)
+	datastoreExpiredJobsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, datastoreName, "expired_jobs"),
+		"Number of expired jobs",
+		[]string{"runs_on"}, nil,
+	)
+	datastoreUnderPressureDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, datastoreName, "under_pressure"),
+		"Whether datastore is under pressure and non-critical writes are shed (1 for under pressure, 0 for healthy)",
+		nil, nil,
+	)
 	datastoreRunnersRunningDesc = prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, datastoreName, "runners_running"),
 		"Number of runners running",
@@ -60,6 +80,19 @@ func (ScraperDatastore) Help() string {
 
 // Scrape scrape metrics
 func (ScraperDatastore) Scrape(ctx context.Context, ds datastore.Datastore, ch chan<- prometheus.Metric) error {
+	var underPressure float64
+	if datastore.IsUnderPressure() {
+		underPressure = 1
+	}
+	ch <- prometheus.MustNewConstMetric(datastoreUnderPressureDesc, prometheus.GaugeValue, underPressure)
+	if datastore.IsUnderPressure() {
+		// aggregation of all rows is deferred, only depth of queue is collected
+		if err := scrapeQueueDepth(ctx, ds, ch); err != nil {
+			return fmt.Errorf("failed to scrape queue depth: %w", err)
+		}
+		return nil
+	}
+
 	if err := scrapeJobs(ctx, ds, ch); err != nil {
 		return fmt.Errorf("failed to scrape jobs: %w", err)
 	}
@@ -77,56 +110,57 @@ func scrapeJobs(ctx context.Context, ds datastore.Datastore, ch chan<- prometheu
 	if err := scrapeJobCounter(ctx, ds, ch); err != nil {
 		return fmt.Errorf("failed to scrape job counter: %w", err)
 	}
-
-	jobs, err := ds.ListJobs(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to list jobs: %w", err)
-	}
-
-	if len(jobs) == 0 {
-		ch <- prometheus.MustNewConstMetric(
-			datastoreJobsDesc, prometheus.GaugeValue, 0, "none", "none",
-		)
-		return nil
+	if err := scrapeQueueDepth(ctx, ds, ch); err != nil {
+		return fmt.Errorf("failed to scrape queue depth: %w", err)
 	}
 
-	sort.SliceStable(jobs, func(i, j int) bool {
-		// oldest job is first
-		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
-	})
 	type storedValue struct {
 		OldestJob datastore.Job
 		Count     float64
 	}
 
 	stored := map[string]storedValue{}
+	var count int
 	// job separate target_id and runs-on labels
-	for _, j := range jobs {
-		runsOnConcat, err := gh.ConcatLabels(j.CheckEventJSON)
-		if err != nil {
-			logger.Logf(false, "failed to concat labels: %+v", err)
-			continue
-		}
-		key := fmt.Sprintf("%s-_-%s", j.TargetID.String(), runsOnConcat)
-		v, ok := stored[key]
-		if !ok {
-			stored[key] = storedValue{
-				OldestJob: j,
-				Count:     1,
+	if err := datastore.WalkJobs(ctx, ds, func(jobs []datastore.Job) error {
+		count += len(jobs)
+		for _, j := range jobs {
+			runsOnConcat, err := gh.ConcatLabels(j.CheckEventJSON)
+			if err != nil {
+				logger.Logf(false, "failed to concat labels: %+v", err)
+				continue
 			}
-		} else {
-			if j.CreatedAt.Before(v.OldestJob.CreatedAt) {
+			key := fmt.Sprintf("%s-_-%s", j.TargetID.String(), runsOnConcat)
+			v, ok := stored[key]
+			if !ok {
 				stored[key] = storedValue{
 					OldestJob: j,
-					Count:     v.Count + 1,
+					Count:     1,
 				}
 			} else {
-				stored[key] = storedValue{
-					OldestJob: v.OldestJob,
-					Count:     v.Count + 1,
+				if j.CreatedAt.Before(v.OldestJob.CreatedAt) {
+					stored[key] = storedValue{
+						OldestJob: j,
+						Count:     v.Count + 1,
+					}
+				} else {
+					stored[key] = storedValue{
+						OldestJob: v.OldestJob,
+						Count:     v.Count + 1,
+					}
 				}
 			}
 		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	if count == 0 {
+		ch <- prometheus.MustNewConstMetric(
+			datastoreJobsDesc, prometheus.GaugeValue, 0, "none", "none",
+		)
+		return nil
 	}
 	for key, value := range stored {
 		// key: target_id-_-runs-on
@@ -152,6 +186,25 @@ func scrapeJobs(ctx context.Context, ds datastore.Datastore, ch chan<- prometheu
 	return nil
 }
 
+func scrapeQueueDepth(ctx context.Context, ds datastore.Datastore, ch chan<- prometheus.Metric) error {
+	pending, err := ds.CountPendingJobs(ctx, uuid.Nil)
+	if err != nil {
+		return fmt.Errorf("failed to count pending jobs: %w", err)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		datastorePendingJobsDesc, prometheus.GaugeValue, float64(pending),
+	)
+
+	age, err := ds.OldestPendingJobAge(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get age of oldest pending job: %w", err)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		datastoreOldestPendingJobAgeDesc, prometheus.GaugeValue, age.Seconds(),
+	)
+	return nil
+}
+
 func scrapeJobCounter(ctx context.Context, ds datastore.Datastore, ch chan<- prometheus.Metric) error {
 	starter.DeletedJobMap.Range(func(key, value interface{}) bool {
 		runsOn := key.(string)
@@ -161,18 +214,26 @@ func scrapeJobCounter(ctx context.Context, ds datastore.Datastore, ch chan<- pro
 		)
 		return true
 	})
+	starter.ExpiredJobMap.Range(func(key, value interface{}) bool {
+		runsOn := key.(string)
+		number := value.(int)
+		ch <- prometheus.MustNewConstMetric(
+			datastoreExpiredJobsDesc, prometheus.CounterValue, float64(number), runsOn,
+		)
+		return true
+	})
 	return nil
 }
 
 func scrapeTargets(ctx context.Context, ds datastore.Datastore, ch chan<- prometheus.Metric) error {
-	targets, err := datastore.ListTargets(ctx, ds)
-	if err != nil {
-		return fmt.Errorf("failed to list targets: %w", err)
-	}
-
 	result := map[string]float64{} // key: resource_type, value: number
-	for _, t := range targets {
-		result[t.ResourceType.String()]++
+	if err := datastore.WalkTargets(ctx, ds, func(targets []datastore.Target) error {
+		for _, t := range targets {
+			result[t.ResourceType.String()]++
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to list targets: %w", err)
 	}
 	for rt, number := range result {
 		ch <- prometheus.MustNewConstMetric(
@@ -184,14 +245,14 @@ func scrapeTargets(ctx context.Context, ds datastore.Datastore, ch chan<- promet
 }
 
 func scrapeRunners(ctx context.Context, ds datastore.Datastore, ch chan<- prometheus.Metric) error {
-	runners, err := ds.ListRunners(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to list runners: %w", err)
-	}
-
 	result := map[string]float64{} // key: target_id, value: number
-	for _, r := range runners {
-		result[r.TargetID.String()]++
+	if err := datastore.WalkRunners(ctx, ds, func(runners []datastore.Runner) error {
+		for _, r := range runners {
+			result[r.TargetID.String()]++
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to list runners: %w", err)
 	}
 	for targetID, number := range result {
 		ch <- prometheus.MustNewConstMetric(
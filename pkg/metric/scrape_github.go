@@ -19,6 +19,31 @@ var (
 		"Number of pending runs",
 		[]string{"target_id", "scope"}, nil,
 	)
+	githubBudgetUsedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, githubName, "budget_used"),
+		"Number of requests to GitHub API in today (UTC) per scope",
+		[]string{"scope"}, nil,
+	)
+	githubBudgetLimitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, githubName, "budget_limit"),
+		"Daily budget of requests to GitHub API per scope (0 is unlimited)",
+		[]string{"scope"}, nil,
+	)
+	githubDegradedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, githubName, "degraded"),
+		"Whether GitHub API is degraded (1 for degraded, 0 for healthy)",
+		[]string{}, nil,
+	)
+	githubThrottledDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, githubName, "throttled_requests_total"),
+		"Total number of requests that are rate limited by GitHub API per result (retried or gave_up)",
+		[]string{"result"}, nil,
+	)
+	githubConditionalRequestsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, githubName, "conditional_requests_total"),
+		"Total number of conditional requests by ETag per result (hit is 304 Not Modified, miss is changed)",
+		[]string{"result"}, nil,
+	)
 )
 
 // ScraperGitHub is scraper implement for GitHub
@@ -39,15 +64,47 @@ func (s ScraperGitHub) Scrape(ctx context.Context, ds datastore.Datastore, ch ch
 	if err := scrapePendingRuns(ctx, ds, ch); err != nil {
 		return fmt.Errorf("failed to scrape pending runs: %w", err)
 	}
+	scrapeDegraded(ch)
+	scrapeBudgets(ch)
+	scrapeThrottled(ch)
+	scrapeConditionalRequests(ch)
 	return nil
 }
 
+func scrapeConditionalRequests(ch chan<- prometheus.Metric) {
+	for result, count := range gh.GetETagCacheCounts() {
+		ch <- prometheus.MustNewConstMetric(githubConditionalRequestsDesc, prometheus.CounterValue, float64(count), result)
+	}
+}
+
+func scrapeThrottled(ch chan<- prometheus.Metric) {
+	for result, count := range gh.GetThrottledCounts() {
+		ch <- prometheus.MustNewConstMetric(githubThrottledDesc, prometheus.CounterValue, float64(count), result)
+	}
+}
+
+func scrapeBudgets(ch chan<- prometheus.Metric) {
+	for _, b := range gh.ListBudgets() {
+		ch <- prometheus.MustNewConstMetric(githubBudgetUsedDesc, prometheus.GaugeValue, float64(b.Used), b.Scope)
+		ch <- prometheus.MustNewConstMetric(githubBudgetLimitDesc, prometheus.GaugeValue, float64(b.Budget), b.Scope)
+	}
+}
+
+func scrapeDegraded(ch chan<- prometheus.Metric) {
+	var degraded float64
+	if gh.IsDegraded() {
+		degraded = 1
+	}
+	ch <- prometheus.MustNewConstMetric(githubDegradedDesc, prometheus.GaugeValue, degraded)
+}
+
 func scrapePendingRuns(ctx context.Context, ds datastore.Datastore, ch chan<- prometheus.Metric) error {
 	gh.ActiveTargets.Range(func(key, value any) bool {
 		var pendings float64
 		scope := key.(string)
 		installationID := value.(int64)
-		target, err := ds.GetTargetByScope(ctx, scope)
+		// active targets are only in primary host
+		target, err := ds.GetTargetByScope(ctx, gh.PrimaryHost().GHEDomain(), scope)
 		if err != nil {
 			logger.Logf(false, "failed to get target by scope (%s): %+v", scope, err)
 			return true
@@ -56,7 +113,7 @@ func scrapePendingRuns(ctx context.Context, ds datastore.Datastore, ch chan<- pr
 		if repo == "" {
 			return true
 		}
-		runs, err := gh.ListRuns(owner, repo)
+		runs, err := gh.ListRuns(ctx, owner, repo)
 		if err != nil {
 			logger.Logf(false, "failed to list pending runs: %+v", err)
 			return true
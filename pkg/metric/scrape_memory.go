@@ -3,13 +3,19 @@ package metric
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/whywaita/myshoes/pkg/config"
 	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/event"
 	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/lock"
+	"github.com/whywaita/myshoes/pkg/logger"
 	"github.com/whywaita/myshoes/pkg/runner"
+	"github.com/whywaita/myshoes/pkg/shoes"
 	"github.com/whywaita/myshoes/pkg/starter"
+	"github.com/whywaita/myshoes/pkg/watchdog"
 )
 
 const memoryName = "memory"
@@ -45,6 +51,11 @@ var (
 		"The number of rate limit max",
 		[]string{"scope"}, nil,
 	)
+	memoryGitHubRateLimitPaused = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, memoryName, "github_rate_limit_paused"),
+		"Unix time of rate limit reset in installations that provisioning is paused by exhausted rate limit",
+		[]string{"account"}, nil,
+	)
 	memoryRunnerMaxConcurrencyDeleting = prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, memoryName, "runner_max_concurrency_deleting"),
 		"The number of max concurrency deleting in runner (Config)",
@@ -55,6 +66,41 @@ var (
 		"deleting concurrency in runner",
 		[]string{"runner"}, nil,
 	)
+	memoryRunnerDeferredDeletions = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, memoryName, "runner_deferred_deletions"),
+		"The number of runners that deleting is deferred by quiet hours in last cycle",
+		[]string{"scope"}, nil,
+	)
+	memoryLoopLastIterationAge = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, memoryName, "loop_last_iteration_age_seconds"),
+		"Seconds since last successful iteration of loop",
+		[]string{"loop"}, nil,
+	)
+	memoryLoopRestarts = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, memoryName, "loop_restarts"),
+		"The number of restart of wedged loop by watchdog",
+		[]string{"loop"}, nil,
+	)
+	memoryPluginRestarts = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, memoryName, "plugin_restarts"),
+		"The number of restart of crashed or hanged plugin process",
+		[]string{"plugin"}, nil,
+	)
+	memoryLogSuppressedLines = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, memoryName, "log_suppressed_lines_total"),
+		"The number of log lines suppressed by sampling",
+		[]string{"format"}, nil,
+	)
+	memoryEventDropped = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, memoryName, "event_dropped_total"),
+		"The number of events dropped by slow subscribers",
+		[]string{}, nil,
+	)
+	memoryLeader = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, memoryName, "leader"),
+		"1 if this instance is leader",
+		[]string{}, nil,
+	)
 )
 
 // ScraperMemory is scraper implement for memory
@@ -81,6 +127,18 @@ func (ScraperMemory) Scrape(ctx context.Context, ds datastore.Datastore, ch chan
 	if err := scrapeRecoveredRuns(ch); err != nil {
 		return fmt.Errorf("failed to scrape recovered runs: %w", err)
 	}
+	if err := scrapeLoopValues(ch); err != nil {
+		return fmt.Errorf("failed to scrape loop values: %w", err)
+	}
+	if err := scrapeLeader(ch); err != nil {
+		return fmt.Errorf("failed to scrape leader: %w", err)
+	}
+	if err := scrapeLogValues(ch); err != nil {
+		return fmt.Errorf("failed to scrape log values: %w", err)
+	}
+	if err := scrapeEventValues(ch); err != nil {
+		return fmt.Errorf("failed to scrape event values: %w", err)
+	}
 
 	return nil
 }
@@ -110,6 +168,13 @@ func scrapeStarterValues(ch chan<- prometheus.Metric) error {
 	ch <- prometheus.MustNewConstMetric(
 		memoryRunnerQueueConcurrencyDeleting, prometheus.GaugeValue, float64(countRunnerDeletingNow), labelRunner)
 
+	if report := runner.LastGCReport(); report != nil {
+		for scope, count := range report.Deferred {
+			ch <- prometheus.MustNewConstMetric(
+				memoryRunnerDeferredDeletions, prometheus.GaugeValue, float64(count), scope)
+		}
+	}
+
 	return nil
 }
 
@@ -123,6 +188,52 @@ func scrapeRecoveredRuns(ch chan<- prometheus.Metric) error {
 	return nil
 }
 
+func scrapeLoopValues(ch chan<- prometheus.Metric) error {
+	now := time.Now()
+	for loop, last := range watchdog.LastBeats() {
+		ch <- prometheus.MustNewConstMetric(
+			memoryLoopLastIterationAge, prometheus.GaugeValue, now.Sub(last).Seconds(), loop,
+		)
+	}
+	for loop, count := range watchdog.Restarts() {
+		ch <- prometheus.MustNewConstMetric(
+			memoryLoopRestarts, prometheus.CounterValue, float64(count), loop,
+		)
+	}
+	for plugin, count := range shoes.Restarts() {
+		ch <- prometheus.MustNewConstMetric(
+			memoryPluginRestarts, prometheus.CounterValue, float64(count), plugin,
+		)
+	}
+	return nil
+}
+
+func scrapeLeader(ch chan<- prometheus.Metric) error {
+	var isLeader float64
+	if lock.IsLeader.Load() {
+		isLeader = 1
+	}
+	ch <- prometheus.MustNewConstMetric(
+		memoryLeader, prometheus.GaugeValue, isLeader)
+	return nil
+}
+
+func scrapeLogValues(ch chan<- prometheus.Metric) error {
+	for format, count := range logger.SuppressedLines() {
+		ch <- prometheus.MustNewConstMetric(
+			memoryLogSuppressedLines, prometheus.CounterValue, float64(count), format,
+		)
+	}
+	return nil
+}
+
+func scrapeEventValues(ch chan<- prometheus.Metric) error {
+	ch <- prometheus.MustNewConstMetric(
+		memoryEventDropped, prometheus.CounterValue, float64(event.Dropped()),
+	)
+	return nil
+}
+
 func scrapeGitHubValues(ch chan<- prometheus.Metric) error {
 	rateLimitRemain := gh.GetRateLimitRemain()
 	for scope, remain := range rateLimitRemain {
@@ -138,6 +249,12 @@ func scrapeGitHubValues(ch chan<- prometheus.Metric) error {
 		)
 	}
 
+	for account, reset := range gh.GetRateLimitPauses() {
+		ch <- prometheus.MustNewConstMetric(
+			memoryGitHubRateLimitPaused, prometheus.GaugeValue, float64(reset.Unix()), account,
+		)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,18 @@
+// Package profiling provide continuous profiling of myshoes.
+// profiling is compiled only in build with `-tags profiling`, it is not included in default build.
+package profiling
+
+import (
+	"context"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+// Start start continuous profiling by config, it blocks until ctx is done.
+// return nil immediately if profiling is disabled
+func Start(ctx context.Context) error {
+	if config.Config.ProfilingBackend == "" {
+		return nil
+	}
+	return start(ctx, config.Config.ProfilingBackend, config.Config.ProfilingEndpoint, config.Config.ProfilingInterval)
+}
@@ -0,0 +1,13 @@
+//go:build !profiling
+
+package profiling
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func start(_ context.Context, backend, _ string, _ time.Duration) error {
+	return fmt.Errorf("profiling backend %s is set, but myshoes is built without profiling (build with -tags profiling)", backend)
+}
@@ -0,0 +1,68 @@
+//go:build profiling
+
+package profiling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+const (
+	// blockProfileRate is rate of block profile, sample one blocking event per 10µs
+	blockProfileRate = 10000
+	// mutexProfileFraction is fraction of mutex profile, sample 1/5 of contention events
+	mutexProfileFraction = 5
+)
+
+func start(ctx context.Context, backend, endpoint string, interval time.Duration) error {
+	// block and mutex profiles have overhead, So enable them only in profiling
+	runtime.SetBlockProfileRate(blockProfileRate)
+	runtime.SetMutexProfileFraction(mutexProfileFraction)
+
+	switch backend {
+	case config.ProfilingBackendPprof:
+		return servePprof(ctx, endpoint)
+	case config.ProfilingBackendPyroscope:
+		return pushPyroscope(ctx, endpoint, interval)
+	default:
+		return fmt.Errorf("unknown profiling backend: %s", backend)
+	}
+}
+
+// servePprof serve net/http/pprof in listenAddress, it is not served in port of webhook for not exposing
+func servePprof(ctx context.Context, listenAddress string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	s := &http.Server{
+		Addr:    listenAddress,
+		Handler: mux,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		logger.Logf(false, "start pprof server, listen %s", listenAddress)
+		if err := s.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- fmt.Errorf("failed to listen and serve pprof: %w", err)
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return s.Close()
+	case err := <-errCh:
+		return err
+	}
+}
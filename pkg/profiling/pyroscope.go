@@ -0,0 +1,105 @@
+//go:build profiling
+
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// applicationName is name of application in Pyroscope
+const applicationName = "myshoes"
+
+// pushedProfiles is profiles that pushed in addition to CPU profile
+var pushedProfiles = []string{"heap", "goroutine", "mutex", "block"}
+
+// pushPyroscope collect profiles in each interval and push them to Pyroscope.
+// CPU profile is collected during interval, other profiles are snapshot at end of interval.
+func pushPyroscope(ctx context.Context, serverURL string, interval time.Duration) error {
+	hostname, _ := os.Hostname()
+	name := fmt.Sprintf("%s{hostname=%s}", applicationName, hostname)
+	client := &http.Client{Timeout: 30 * time.Second}
+	logger.Logf(false, "start to push profiles to Pyroscope (%s) in every %s", serverURL, interval)
+
+	for {
+		from := time.Now()
+		var cpu bytes.Buffer
+		if err := pprof.StartCPUProfile(&cpu); err != nil {
+			return fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			pprof.StopCPUProfile()
+			return nil
+		}
+		pprof.StopCPUProfile()
+		until := time.Now()
+
+		if err := ingest(ctx, client, serverURL, name, from, until, cpu.Bytes()); err != nil {
+			logger.Logf(false, "failed to push CPU profile to Pyroscope: %+v", err)
+		}
+		for _, p := range pushedProfiles {
+			var b bytes.Buffer
+			if err := pprof.Lookup(p).WriteTo(&b, 0); err != nil {
+				logger.Logf(false, "failed to collect %s profile: %+v", p, err)
+				continue
+			}
+			if err := ingest(ctx, client, serverURL, name, from, until, b.Bytes()); err != nil {
+				logger.Logf(false, "failed to push %s profile to Pyroscope: %+v", p, err)
+			}
+		}
+	}
+}
+
+// ingest push a profile in pprof format to ingest API of Pyroscope
+func ingest(ctx context.Context, client *http.Client, serverURL, name string, from, until time.Time, profile []byte) error {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	fw, err := w.CreateFormFile("profile", "profile.pprof")
+	if err != nil {
+		return fmt.Errorf("failed to create form: %w", err)
+	}
+	if _, err := fw.Write(profile); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to close form: %w", err)
+	}
+
+	q := url.Values{}
+	q.Set("name", name)
+	q.Set("from", strconv.FormatInt(from.Unix(), 10))
+	q.Set("until", strconv.FormatInt(until.Unix(), 10))
+	q.Set("spyName", "gospy")
+	u := strings.TrimSuffix(serverURL, "/") + "/ingest?" + q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to do request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("invalid response code (%d)", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// ShoesTypeAdopted is shoes type of runner that registered outside myshoes and adopted
+const ShoesTypeAdopted = "adopted"
+
+// AdoptOption is condition of runners that will be adopted
+type AdoptOption struct {
+	// NamePrefix is prefix of runner name in GitHub
+	NamePrefix string
+	// Labels is labels that runner must have all of
+	Labels []string
+	// CloudIDs is map of runner name to cloud ID in shoes-provider, instance of runner that not in CloudIDs is not deleted by myshoes
+	CloudIDs map[string]string
+	// DryRun is true if not create runners in datastore
+	DryRun bool
+}
+
+// Adopt take over management (status tracking, GC, deletion) of runners that registered outside myshoes in target.
+// return runners that adopted.
+func Adopt(ctx context.Context, ds datastore.Datastore, t datastore.Target, opt AdoptOption) ([]datastore.Runner, error) {
+	if opt.NamePrefix == "" && len(opt.Labels) == 0 {
+		return nil, fmt.Errorf("name prefix or labels must be set")
+	}
+
+	ctx, err := t.WithGitHubHost(ctx)
+	if err != nil {
+		return nil, err
+	}
+	owner, repo := t.OwnerRepo()
+	client, err := gh.HostFrom(ctx).NewClient(t.GitHubToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create github client: %w", err)
+	}
+	ghRunners, err := gh.ListRunners(ctx, client, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list of runner in GitHub: %w", err)
+	}
+
+	managed, err := ds.ListRunnersByTargetID(ctx, t.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve list of runner: %w", err)
+	}
+	managedNames := make(map[string]datastore.Runner, len(managed))
+	for _, r := range managed {
+		managedNames[RunnerName(r)] = r
+	}
+
+	repositoryURL := (&datastore.Job{GHEDomain: t.GHEDomain, Repository: t.Scope}).RepoURL()
+
+	var adopted []datastore.Runner
+	for _, ghRunner := range ghRunners {
+		name := ghRunner.GetName()
+		if r, ok := managedNames[name]; ok {
+			// already adopted, fill cloud ID if it is passed now
+			if cloudID, ok := opt.CloudIDs[name]; ok && r.ShoesType == ShoesTypeAdopted && r.CloudID != cloudID && !opt.DryRun {
+				r.CloudID = cloudID
+				if err := ds.UpdateRunnerWithVersion(ctx, r); err != nil {
+					return adopted, fmt.Errorf("failed to update cloud ID of runner (name: %s): %w", name, err)
+				}
+				logger.Logf(false, "updated cloud ID of adopted runner %s in %s (runner ID: %s)", name, t.Scope, r.UUID)
+			}
+			continue
+		}
+		if strings.HasPrefix(name, ToName("")) {
+			// already managed by myshoes
+			continue
+		}
+		if !isAdoptable(ghRunner, opt) {
+			continue
+		}
+
+		labels := make([]string, 0, len(ghRunner.Labels))
+		for _, l := range ghRunner.Labels {
+			labels = append(labels, l.GetName())
+		}
+		requestWebhook, err := json.Marshal(github.WorkflowJob{Labels: labels, RunnerName: github.String(name)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal labels of runner: %w", err)
+		}
+
+		r := datastore.Runner{
+			UUID:           datastore.NewID(),
+			ShoesType:      ShoesTypeAdopted,
+			TargetID:       t.UUID,
+			CloudID:        opt.CloudIDs[name],
+			ResourceType:   t.ResourceType,
+			RunnerUser:     sql.NullString{String: config.Config.RunnerUser, Valid: true},
+			ProviderURL:    t.ProviderURL,
+			RepositoryURL:  repositoryURL,
+			RequestWebhook: string(requestWebhook),
+			RunnerName:     sql.NullString{String: name, Valid: true},
+		}
+		if !opt.DryRun {
+			if err := ds.CreateRunner(ctx, r); err != nil {
+				return adopted, fmt.Errorf("failed to create runner (name: %s): %w", name, err)
+			}
+			datastore.RecordHistory(ctx, ds, datastore.HistoryResourceRunner, r.UUID, datastore.HistoryStatusRegistered, fmt.Sprintf("adopted %s", name))
+			logger.Logf(false, "adopted runner %s in %s (runner ID: %s)", name, t.Scope, r.UUID)
+		}
+		adopted = append(adopted, r)
+	}
+
+	return adopted, nil
+}
+
+func isAdoptable(ghRunner *github.Runner, opt AdoptOption) bool {
+	if !strings.HasPrefix(ghRunner.GetName(), opt.NamePrefix) {
+		return false
+	}
+
+	for _, want := range opt.Labels {
+		found := false
+		for _, l := range ghRunner.Labels {
+			if strings.EqualFold(l.GetName(), want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
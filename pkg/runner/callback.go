@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+// ErrInvalidTicket is error for ticket that is not issued for runner or expired
+var ErrInvalidTicket = errors.New("invalid ticket")
+
+// CallbackToken return token that setup script of runner use for reporting progress to myshoes.
+// token is HMAC of runner name by GitHub App secret, So myshoes can verify it without storing.
+func CallbackToken(runnerName string) string {
+	return sign("callback", runnerName)
+}
+
+// VerifyCallbackToken check token is issued for runnerName
+func VerifyCallbackToken(runnerName, token string) bool {
+	return verify(token, "callback", runnerName)
+}
+
+// IssueTokenTicket return ticket that setup script of runner use for fetching registration token from myshoes.
+// ticket is "<expired unix time>.<HMAC of runner name and expired time>", it is bound to runner and expires in ttl.
+func IssueTokenTicket(runnerName string, now time.Time, ttl time.Duration) string {
+	expiredAt := strconv.FormatInt(now.Add(ttl).Unix(), 10)
+	return expiredAt + "." + sign("token", runnerName, expiredAt)
+}
+
+// VerifyTokenTicket check ticket is issued for runnerName and not expired
+func VerifyTokenTicket(runnerName, ticket string, now time.Time) error {
+	expiredAt, mac, found := strings.Cut(ticket, ".")
+	if !found {
+		return ErrInvalidTicket
+	}
+	if !verify(mac, "token", runnerName, expiredAt) {
+		return ErrInvalidTicket
+	}
+	unix, err := strconv.ParseInt(expiredAt, 10, 64)
+	if err != nil {
+		return ErrInvalidTicket
+	}
+	if now.After(time.Unix(unix, 0)) {
+		return fmt.Errorf("ticket is expired at %s: %w", time.Unix(unix, 0).UTC(), ErrInvalidTicket)
+	}
+	return nil
+}
+
+// verify return true if mac is signed by one of GitHub App secrets, token signed by old secret is valid in rotation
+func verify(mac, purpose string, fields ...string) bool {
+	for _, secret := range config.Config.GitHub.Secrets() {
+		if hmac.Equal([]byte(signWith(secret, purpose, fields...)), []byte(mac)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sign return HMAC of fields by current GitHub App secret, purpose separates tokens for each use
+func sign(purpose string, fields ...string) string {
+	return signWith(config.Config.GitHub.AppSecret, purpose, fields...)
+}
+
+func signWith(secret []byte, purpose string, fields ...string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	for _, f := range fields {
+		mac.Write([]byte{0})
+		mac.Write([]byte(f))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+func TestVerifyTokenTicket(t *testing.T) {
+	config.Config.GitHub.AppSecret, config.Config.GitHub.AppSecrets = []byte("secret"), nil
+	now := time.Date(2037, 9, 3, 0, 0, 0, 0, time.UTC)
+	runnerName := "myshoes-8cc1a4d5-4bf1-43d5-8d8f-3a3b7c5a4f7e"
+	ticket := IssueTokenTicket(runnerName, now, 5*time.Minute)
+
+	tests := []struct {
+		name       string
+		runnerName string
+		ticket     string
+		now        time.Time
+		err        error
+	}{
+		{
+			name:       "valid",
+			runnerName: runnerName,
+			ticket:     ticket,
+			now:        now.Add(time.Minute),
+		},
+		{
+			name:       "expired",
+			runnerName: runnerName,
+			ticket:     ticket,
+			now:        now.Add(6 * time.Minute),
+			err:        ErrInvalidTicket,
+		},
+		{
+			name:       "wrong runner",
+			runnerName: "myshoes-7943c6d4-5b3e-4d57-8fb8-1a5b2b3e9c0d",
+			ticket:     ticket,
+			now:        now,
+			err:        ErrInvalidTicket,
+		},
+		{
+			name:       "tampered expiration",
+			runnerName: runnerName,
+			ticket:     "9999999999" + ticket[len("9999999999"):],
+			now:        now,
+			err:        ErrInvalidTicket,
+		},
+		{
+			name:       "malformed",
+			runnerName: runnerName,
+			ticket:     "not-a-ticket",
+			now:        now,
+			err:        ErrInvalidTicket,
+		},
+	}
+
+	for _, test := range tests {
+		err := VerifyTokenTicket(test.runnerName, test.ticket, test.now)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: want err %+v, but got %+v", test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/shoes"
+)
+
+// GCReason is reason of deleting runner by runner manager
+type GCReason string
+
+// GCReason values
+const (
+	// GCReasonZombie is runner that not found in GitHub (completed or never registered)
+	GCReasonZombie GCReason = "zombie"
+	// GCReasonOffline is runner that offline in GitHub
+	GCReasonOffline GCReason = "offline"
+	// GCReasonIdle is runner that idle over MustGoalTime
+	GCReasonIdle GCReason = "idle"
+	// GCReasonTTL is runner that reached TTL of request
+	GCReasonTTL GCReason = "ttl"
+	// GCReasonOrphan is runner that offline in GitHub and has no instance (e.g. instance is deleted out-of-band)
+	GCReasonOrphan GCReason = "orphan"
+)
+
+// DeleteReason convert to reason that passed to shoes-provider
+func (r GCReason) DeleteReason() shoes.DeleteReason {
+	switch r {
+	case GCReasonZombie:
+		return shoes.DeleteReasonZombie
+	case GCReasonIdle, GCReasonTTL:
+		return shoes.DeleteReasonTTL
+	}
+	return shoes.DeleteReasonJobCompleted
+}
+
+// IsUrgent return true if runner must be deleted even in quiet hours of target.
+// zombie and offline runners hold instances that will never run a job, idle and TTL runners can wait.
+func (r GCReason) IsUrgent() bool {
+	switch r {
+	case GCReasonIdle, GCReasonTTL:
+		return false
+	}
+	return true
+}
+
+// GCCandidate is a runner that deleted (or will be deleted in dry-run) by runner manager
+type GCCandidate struct {
+	RunnerID   uuid.UUID `json:"runner_id"`
+	RunnerName string    `json:"runner_name"`
+	TargetID   uuid.UUID `json:"target_id"`
+	CloudID    string    `json:"cloud_id"`
+	Reason     GCReason  `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// GCReport is a report of one cycle in runner manager
+type GCReport struct {
+	DryRun     bool          `json:"dry_run"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Candidates []GCCandidate `json:"candidates"`
+	// Deferred is number of runners that deleting is deferred by quiet hours, key is scope of target
+	Deferred map[string]int64 `json:"deferred"`
+
+	mu sync.Mutex
+}
+
+func newGCReport(dryRun bool) *GCReport {
+	return &GCReport{
+		DryRun:     dryRun,
+		StartedAt:  time.Now().UTC(),
+		Candidates: []GCCandidate{},
+		Deferred:   map[string]int64{},
+	}
+}
+
+func (r *GCReport) add(runner datastore.Runner, reason GCReason) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Candidates = append(r.Candidates, GCCandidate{
+		RunnerID:   runner.UUID,
+		RunnerName: RunnerName(runner),
+		TargetID:   runner.TargetID,
+		CloudID:    runner.CloudID,
+		Reason:     reason,
+		CreatedAt:  runner.CreatedAt,
+	})
+}
+
+func (r *GCReport) addDeferred(scope string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Deferred[scope]++
+}
+
+var (
+	lastGCReportMu sync.RWMutex
+	lastGCReport   *GCReport
+)
+
+func storeGCReport(r *GCReport) {
+	r.FinishedAt = time.Now().UTC()
+
+	lastGCReportMu.Lock()
+	defer lastGCReportMu.Unlock()
+	lastGCReport = r
+}
+
+// LastGCReport return a report of last cycle in runner manager, return nil if runner manager is not finished a cycle yet
+func LastGCReport() *GCReport {
+	lastGCReportMu.RLock()
+	defer lastGCReportMu.RUnlock()
+	return lastGCReport
+}
+
+// toGCReason detect reason of deleting. inGitHub is true if runner is registered in GitHub
+func toGCReason(runner datastore.Runner, runnerStatus string, inGitHub bool) GCReason {
+	if _, ok := gh.ExtractTTL([]byte(runner.RequestWebhook)); ok {
+		return GCReasonTTL
+	}
+	if !inGitHub {
+		return GCReasonZombie
+	}
+	if runnerStatus == StatusSleep {
+		return GCReasonIdle
+	}
+	return GCReasonOffline
+}
@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// ErrIdentityMismatch is error for caller that is not the instance of runner
+var ErrIdentityMismatch = errors.New("identity of caller is mismatched")
+
+// CallerIdentity is identity of caller of runner callback (e.g. setup script in instance)
+type CallerIdentity struct {
+	RemoteIP string
+	Header   http.Header // header of request, for verifier that check a document attested by shoes-provider
+}
+
+// IdentityVerifier verify that caller of runner callback is the instance that shoes-provider reported
+type IdentityVerifier interface {
+	Verify(r datastore.Runner, caller CallerIdentity) error
+}
+
+// IdentityVerifierFunc is an adapter to use function as IdentityVerifier
+type IdentityVerifierFunc func(r datastore.Runner, caller CallerIdentity) error
+
+// Verify call f(r, caller)
+func (f IdentityVerifierFunc) Verify(r datastore.Runner, caller CallerIdentity) error {
+	return f(r, caller)
+}
+
+var (
+	identityVerifiersMu sync.RWMutex
+	identityVerifiers   = map[string]IdentityVerifier{
+		config.RunnerIdentityToken: IdentityVerifierFunc(verifyNothing),
+		config.RunnerIdentityIP:    IdentityVerifierFunc(verifyIPAddress),
+	}
+)
+
+// RegisterIdentityVerifier register verifier as name, it can be used by RUNNER_IDENTITY_VERIFICATION
+func RegisterIdentityVerifier(name string, v IdentityVerifier) {
+	identityVerifiersMu.Lock()
+	defer identityVerifiersMu.Unlock()
+	identityVerifiers[name] = v
+}
+
+// GetIdentityVerifier return verifier that registered as name
+func GetIdentityVerifier(name string) (IdentityVerifier, error) {
+	identityVerifiersMu.RLock()
+	defer identityVerifiersMu.RUnlock()
+	v, ok := identityVerifiers[name]
+	if !ok {
+		return nil, fmt.Errorf("identity verifier %q is not registered", name)
+	}
+	return v, nil
+}
+
+// NeedIdentityVerification return true if caller is verified more than signed token
+func NeedIdentityVerification() bool {
+	return config.Config.RunnerIdentityVerification != config.RunnerIdentityToken
+}
+
+// VerifyIdentity verify caller is the instance of runner by configured verifier
+func VerifyIdentity(r datastore.Runner, caller CallerIdentity) error {
+	v, err := GetIdentityVerifier(config.Config.RunnerIdentityVerification)
+	if err != nil {
+		return fmt.Errorf("failed to get identity verifier: %w", err)
+	}
+	return v.Verify(r, caller)
+}
+
+// verifyNothing is verifier for "token", signed token is already verified by caller
+func verifyNothing(_ datastore.Runner, _ CallerIdentity) error {
+	return nil
+}
+
+func verifyIPAddress(r datastore.Runner, caller CallerIdentity) error {
+	if r.IPAddress == "" {
+		return fmt.Errorf("shoes-provider did not report IP address of runner: %w", ErrIdentityMismatch)
+	}
+	want, got := net.ParseIP(r.IPAddress), net.ParseIP(caller.RemoteIP)
+	if want == nil || got == nil || !want.Equal(got) {
+		return fmt.Errorf("IP address is %s, but runner is %s: %w", caller.RemoteIP, r.IPAddress, ErrIdentityMismatch)
+	}
+	return nil
+}
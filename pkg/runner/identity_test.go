@@ -0,0 +1,138 @@
+package runner
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+func TestVerifyIPAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		runnerIP string
+		callerIP string
+		err      error
+	}{
+		{
+			name:     "match",
+			runnerIP: "10.0.0.1",
+			callerIP: "10.0.0.1",
+		},
+		{
+			name:     "mismatch",
+			runnerIP: "10.0.0.1",
+			callerIP: "10.0.0.2",
+			err:      ErrIdentityMismatch,
+		},
+		{
+			name:     "runner has no IP address",
+			runnerIP: "",
+			callerIP: "10.0.0.1",
+			err:      ErrIdentityMismatch,
+		},
+		{
+			name:     "caller has no IP address",
+			runnerIP: "10.0.0.1",
+			callerIP: "",
+			err:      ErrIdentityMismatch,
+		},
+		{
+			name:     "IPv6 in different notation",
+			runnerIP: "2001:db8::1",
+			callerIP: "2001:0db8:0000:0000:0000:0000:0000:0001",
+		},
+		{
+			name:     "IPv6 mismatch",
+			runnerIP: "2001:db8::1",
+			callerIP: "2001:db8::2",
+			err:      ErrIdentityMismatch,
+		},
+		{
+			name:     "IPv4-mapped IPv6",
+			runnerIP: "10.0.0.1",
+			callerIP: "::ffff:10.0.0.1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := verifyIPAddress(datastore.Runner{IPAddress: test.runnerIP}, CallerIdentity{RemoteIP: test.callerIP})
+			if !errors.Is(err, test.err) {
+				t.Errorf("want %v, but got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestVerifyIdentity(t *testing.T) {
+	old := config.Config.RunnerIdentityVerification
+	t.Cleanup(func() { config.Config.RunnerIdentityVerification = old })
+
+	errCustom := errors.New("custom verifier is failed")
+	RegisterIdentityVerifier("test-custom", IdentityVerifierFunc(func(r datastore.Runner, caller CallerIdentity) error {
+		if caller.Header.Get("X-Test-Document") != r.CloudID {
+			return errCustom
+		}
+		return nil
+	}))
+
+	runner := datastore.Runner{IPAddress: "10.0.0.1", CloudID: "i-1"}
+	tests := []struct {
+		name         string
+		verification string
+		caller       CallerIdentity
+		wantErr      bool
+		err          error
+	}{
+		{
+			name:         "token does not check caller",
+			verification: config.RunnerIdentityToken,
+			caller:       CallerIdentity{RemoteIP: "192.0.2.1"},
+		},
+		{
+			name:         "ip match",
+			verification: config.RunnerIdentityIP,
+			caller:       CallerIdentity{RemoteIP: "10.0.0.1"},
+		},
+		{
+			name:         "ip mismatch",
+			verification: config.RunnerIdentityIP,
+			caller:       CallerIdentity{RemoteIP: "192.0.2.1"},
+			wantErr:      true,
+			err:          ErrIdentityMismatch,
+		},
+		{
+			name:         "registered verifier",
+			verification: "test-custom",
+			caller:       CallerIdentity{Header: http.Header{"X-Test-Document": {"i-1"}}},
+		},
+		{
+			name:         "registered verifier is failed",
+			verification: "test-custom",
+			caller:       CallerIdentity{Header: http.Header{"X-Test-Document": {"i-2"}}},
+			wantErr:      true,
+			err:          errCustom,
+		},
+		{
+			name:         "not registered verifier",
+			verification: "not-registered",
+			wantErr:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config.Config.RunnerIdentityVerification = test.verification
+			err := VerifyIdentity(runner, test.caller)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("want error: %t, but got %v", test.wantErr, err)
+			}
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("want %v, but got %v", test.err, err)
+			}
+		})
+	}
+}
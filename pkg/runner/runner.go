@@ -28,6 +28,8 @@ var (
 type Manager struct {
 	ds            datastore.Datastore
 	runnerVersion string
+
+	report *GCReport // report of current cycle
 }
 
 // New create a Manager
@@ -11,9 +11,12 @@ import (
 	"github.com/google/go-github/v47/github"
 	"github.com/whywaita/myshoes/pkg/config"
 	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/event"
 	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/hook"
 	"github.com/whywaita/myshoes/pkg/logger"
 	"github.com/whywaita/myshoes/pkg/shoes"
+	"github.com/whywaita/myshoes/pkg/watchdog"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
 	"google.golang.org/grpc/codes"
@@ -33,19 +36,40 @@ var (
 )
 
 func (m *Manager) do(ctx context.Context) error {
-	logger.Logf(true, "start runner manager")
-
-	targets, err := datastore.ListTargets(ctx, m.ds)
-	if err != nil {
+	logger.Samplef(true, "start runner manager")
+	watchdog.Beat(ctx)
+	event.Publish(ctx, event.TopicSchedulerTick, event.Tick{Loop: "runner"})
+	m.report = newGCReport(config.Config.GCDryRun)
+	defer storeGCReport(m.report)
+
+	if err := datastore.WalkTargets(ctx, m.ds, func(targets []datastore.Target) error {
+		logger.Samplef(true, "found %d targets in datastore", len(targets))
+		for _, target := range targets {
+			watchdog.Beat(ctx)
+			logger.Samplef(true, "start to search runner in %s", target.Scope)
+			if err := gh.CheckBudget(target.Scope); err != nil {
+				logger.Logf(false, "defer to delete runners (target: %s): %+v", target.Scope, err)
+				continue
+			}
+			ctx, err := target.WithGitHubHost(gh.WithBudgetScope(ctx, target.Scope))
+			if err != nil {
+				logger.Logf(false, "failed to delete runners (target: %s): %+v", target.Scope, err)
+				continue
+			}
+			if target.InQuietHours(time.Now()) {
+				ctx = withQuietHours(ctx, target.Scope)
+			}
+			if err := m.removeRunners(ctx, target); err != nil {
+				logger.Logf(false, "failed to delete runners (target: %s): %+v", target.Scope, err)
+			}
+		}
+		return nil
+	}); err != nil {
 		return fmt.Errorf("failed to get targets: %w", err)
 	}
 
-	logger.Logf(true, "found %d targets in datastore", len(targets))
-	for _, target := range targets {
-		logger.Logf(true, "start to search runner in %s", target.Scope)
-		if err := m.removeRunners(ctx, target); err != nil {
-			logger.Logf(false, "failed to delete runners (target: %s): %+v", target.Scope, err)
-		}
+	if err := m.doDeletedTargets(ctx); err != nil {
+		return fmt.Errorf("failed to tear down deleted targets: %w", err)
 	}
 
 	return nil
@@ -76,7 +100,7 @@ func (m *Manager) removeRunners(ctx context.Context, t datastore.Target) error {
 	if len(ghRunners) == 0 && len(runners) == 0 {
 		switch mode {
 		case TemporaryOnce:
-			logger.Logf(false, "runner for queueing is not found in %s", t.Scope)
+			logger.Samplef(false, "runner for queueing is not found in %s", t.Scope)
 			if err := datastore.UpdateTargetStatus(ctx, m.ds, t.UUID, datastore.TargetStatusErr, ErrDescriptionRunnerForQueueingIsNotFound); err != nil {
 				logger.Logf(false, "failed to update target status (target ID: %s): %+v\n", t.UUID, err)
 			}
@@ -94,6 +118,8 @@ func (m *Manager) removeRunners(ctx context.Context, t datastore.Target) error {
 	sem := semaphore.NewWeighted(config.Config.MaxConcurrencyDeleting)
 	var eg errgroup.Group
 	ConcurrencyDeleting.Store(0)
+	ctx, batch := withDeleteBatch(ctx)
+	ctx = withBusyChecker(ctx)
 
 	for _, runner := range runners {
 		runner := runner
@@ -119,6 +145,13 @@ func (m *Manager) removeRunners(ctx context.Context, t datastore.Target) error {
 	if err := eg.Wait(); err != nil {
 		return fmt.Errorf("failed to wait errgroup.Wait(): %w", err)
 	}
+	if err := batch.flush(ctx, m.ds); err != nil {
+		logger.Logf(false, "failed to remove runners from datastore, will retry in next loop: %+v", err)
+	}
+
+	if err := m.removeOrphanRunners(ctx, t, ghRunners, runners); err != nil {
+		logger.Logf(false, "failed to remove offline runners that have no instance: %+v", err)
+	}
 
 	if t.Status == datastore.TargetStatusRunning {
 		if err := datastore.UpdateTargetStatus(ctx, m.ds, t.UUID, datastore.TargetStatusActive, ""); err != nil {
@@ -130,6 +163,13 @@ func (m *Manager) removeRunners(ctx context.Context, t datastore.Target) error {
 }
 
 func (m *Manager) removeRunner(ctx context.Context, t datastore.Target, runner datastore.Runner, ghRunners []*github.Runner) error {
+	if ttl, ok := gh.ExtractTTL([]byte(runner.RequestWebhook)); ok {
+		if err := m.removeRunnerWithTTL(ctx, t, runner, ttl, ghRunners); err != nil {
+			return fmt.Errorf("failed to remove runner (with TTL): %w", err)
+		}
+		return nil
+	}
+
 	if err := sanitizeRunnerMustRunningTime(runner); errors.Is(err, ErrNotWillDeleteRunner) {
 		logger.Logf(false, "%s is not running MustRunningTime", runner.UUID)
 		return nil
@@ -161,7 +201,7 @@ func (m *Manager) removeRunner(ctx context.Context, t datastore.Target, runner d
 
 func isRegisteredRunnerZeroInGitHub(ctx context.Context, t datastore.Target) ([]*github.Runner, error) {
 	owner, repo := t.OwnerRepo()
-	client, err := gh.NewClient(t.GitHubToken)
+	client, err := gh.HostFrom(ctx).NewClient(t.GitHubToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create github client: %w", err)
 	}
@@ -200,13 +240,13 @@ func sanitizeGitHubRunner(ghRunner github.Runner, dsRunner datastore.Runner) err
 	switch ghRunner.GetStatus() {
 	case StatusWillDelete:
 		if err := sanitizeRunner(dsRunner, MustRunningTime); err != nil {
-			logger.Logf(false, "%s is offline and not running %s, so not will delete (created_at: %s, now: %s)", dsRunner.UUID, MustRunningTime, dsRunner.CreatedAt, time.Now().UTC())
+			logger.Samplef(false, "%s is offline and not running %s, so not will delete (created_at: %s, now: %s)", dsRunner.UUID, MustRunningTime, dsRunner.CreatedAt, time.Now().UTC())
 			return fmt.Errorf("failed to sanitize will delete runner: %w", err)
 		}
 		return nil
 	case StatusSleep:
 		if err := sanitizeRunner(dsRunner, MustGoalTime); err != nil {
-			logger.Logf(false, "%s is idle and not running %s, so not will delete (created_at: %s, now: %s)", dsRunner.UUID, MustGoalTime, dsRunner.CreatedAt, time.Now().UTC())
+			logger.Samplef(false, "%s is idle and not running %s, so not will delete (created_at: %s, now: %s)", dsRunner.UUID, MustGoalTime, dsRunner.CreatedAt, time.Now().UTC())
 			return fmt.Errorf("failed to sanitize idle runner: %w", err)
 		}
 		return nil
@@ -232,55 +272,137 @@ func sanitizeRunner(runner datastore.Runner, needTime time.Duration) error {
 // deleteRunnerWithGitHub delete runner in github, shoes, datastore.
 // runnerUUID is uuid in datastore, runnerID is id from GitHub.
 func (m *Manager) deleteRunnerWithGitHub(ctx context.Context, githubClient *github.Client, runner datastore.Runner, runnerID int64, owner, repo, runnerStatus string) error {
-	logger.Logf(false, "will delete runner with GitHub: %s", runner.UUID.String())
-	isOrg := false
-	if repo == "" {
-		isOrg = true
+	reason := toGCReason(runner, runnerStatus, true)
+	if m.deferDeletion(ctx, runner, reason) {
+		return nil
+	}
+	busy, err := isBusyRunner(ctx, githubClient, owner, repo, runnerID)
+	if err != nil {
+		return fmt.Errorf("failed to check busy state of runner (runner uuid: %s): %w", runner.UUID.String(), err)
+	}
+	if busy {
+		logger.Logf(false, "%s picked up a job after listing runners, will not delete in this cycle", runner.UUID)
+		return nil
+	}
+	if m.recordGC(runner, reason) {
+		return nil
 	}
 
-	if isOrg {
-		if _, err := githubClient.Actions.RemoveOrganizationRunner(ctx, owner, runnerID); err != nil {
-			return fmt.Errorf("failed to remove organization runner (runner uuid: %s): %+v", runner.UUID.String(), err)
-		}
-	} else {
-		if _, err := githubClient.Actions.RemoveRunner(ctx, owner, repo, runnerID); err != nil {
-			return fmt.Errorf("failed to remove repository runner (runner uuid: %s): %+v", runner.UUID.String(), err)
-		}
+	logger.Logf(false, "will delete runner with GitHub: %s", runner.UUID.String())
+	if err := gh.RemoveRunner(ctx, githubClient, owner, repo, runnerID); err != nil {
+		return fmt.Errorf("failed to remove runner (runner uuid: %s): %+v", runner.UUID.String(), err)
 	}
 
-	if err := m.deleteRunner(ctx, runner, runnerStatus); err != nil {
+	if err := m.deleteRunnerInShoes(ctx, runner, runnerStatus, reason.DeleteReason()); err != nil {
 		return fmt.Errorf("failed to delete runner: %w", err)
 	}
 	return nil
 }
 
-// deleteRunner delete runner in shoes, datastore.
+// isBusyRunner return true if runner is running a job now.
+// list of runners is fetched at start of cycle (or cached), So runner may pick up a job after listing
+func isBusyRunner(ctx context.Context, githubClient *github.Client, owner, repo string, runnerID int64) (bool, error) {
+	if c := busyCheckerFrom(ctx); c != nil {
+		return c.isBusy(ctx, githubClient, owner, repo, runnerID)
+	}
+
+	ghRunner, err := gh.GetRunner(ctx, githubClient, owner, repo, runnerID)
+	switch {
+	case errors.Is(err, gh.ErrNotFound):
+		// already removed from GitHub, job is completed
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return ghRunner.GetBusy(), nil
+}
+
+// deleteRunner delete runner in shoes, datastore. runner is not registered in GitHub.
 func (m *Manager) deleteRunner(ctx context.Context, runner datastore.Runner, runnerStatus string) error {
+	reason := toGCReason(runner, runnerStatus, false)
+	if m.deferDeletion(ctx, runner, reason) {
+		return nil
+	}
+	if m.recordGC(runner, reason) {
+		return nil
+	}
+
+	return m.deleteRunnerInShoes(ctx, runner, runnerStatus, reason.DeleteReason())
+}
+
+// recordGC record runner to report of GC, return true if runner must not be deleted (dry-run)
+func (m *Manager) recordGC(runner datastore.Runner, reason GCReason) bool {
+	if m.report == nil {
+		return config.Config.GCDryRun
+	}
+
+	m.report.add(runner, reason)
+	if m.report.DryRun {
+		logger.Logf(false, "dry-run: %s will be deleted (reason: %s)", runner.UUID, reason)
+		return true
+	}
+	return false
+}
+
+// deleteRunnerInShoes delete instance of runner with reason for shoes-provider, and remove runner from datastore
+func (m *Manager) deleteRunnerInShoes(ctx context.Context, runner datastore.Runner, runnerStatus string, reason shoes.DeleteReason) error {
 	logger.Logf(false, "will delete runner: %s", runner.UUID.String())
+	datastore.RecordHistory(ctx, m.ds, datastore.HistoryResourceRunner, runner.UUID, datastore.HistoryStatusDeleting, runnerStatus)
 
-	client, teardown, err := shoes.GetClient()
+	labels, err := gh.ExtractRunsOnLabels([]byte(runner.RequestWebhook))
+	if err != nil {
+		return fmt.Errorf("failed to extract labels: %w", err)
+	}
+	var scope string
+	if t, err := m.ds.GetTarget(ctx, runner.TargetID); err == nil {
+		scope = t.Scope
+	}
+
+	client, teardown, err := shoes.GetClientFor(scope, runner.ResourceType, labels)
 	if err != nil {
 		return fmt.Errorf("failed to get plugin client: %w", err)
 	}
 	defer teardown()
 
-	labels, err := gh.ExtractRunsOnLabels([]byte(runner.RequestWebhook))
-	if err != nil {
-		return fmt.Errorf("failed to extract labels: %w", err)
+	if hook.IsEnabled() {
+		if err := hook.Fire(ctx, m.ds, hook.EventPreDelete, runner, RunnerName(runner), scope); err != nil {
+			datastore.RecordHistory(ctx, m.ds, datastore.HistoryResourceRunner, runner.UUID, datastore.HistoryStatusFailed, fmt.Sprintf("failed to call pre delete hook: %s", err))
+			// will retry in next loop
+			return fmt.Errorf("failed to call pre delete hook: %w", err)
+		}
 	}
 
-	if err := client.DeleteInstance(ctx, runner.CloudID, labels); err != nil {
+	cctx, cancel := context.WithTimeout(ctx, MustRunningTime)
+	defer cancel()
+	if runner.CloudID == "" {
+		// adopted runner that instance is not managed by shoes-provider
+		logger.Logf(false, "%s has no cloud ID, will not delete instance", runner.UUID)
+	} else if err := client.DeleteInstance(cctx, runner.CloudID, labels, reason); err != nil {
 		if status.Code(errors.Unwrap(err)) == codes.NotFound {
 			logger.Logf(true, "%s is not found, will ignore from shoes", runner.UUID)
 		} else {
+			datastore.RecordHistory(ctx, m.ds, datastore.HistoryResourceRunner, runner.UUID, datastore.HistoryStatusFailed, fmt.Sprintf("failed to delete instance: %s", err))
 			return fmt.Errorf("failed to delete instance: %w", err)
 		}
 	}
 
-	now := time.Now().UTC()
-	if err := m.ds.DeleteRunner(ctx, runner.UUID, now, ToReason(runnerStatus)); err != nil {
-		return fmt.Errorf("failed to remove runner from datastore (runner uuid: %s): %+v", runner.UUID.String(), err)
+	if b := deleteBatchFrom(ctx); b != nil {
+		// removed from datastore with other runners in flush
+		b.add(runner, ToReason(runnerStatus))
+		return nil
+	}
+	err = m.ds.DeleteRunnerWithVersion(ctx, runner.UUID, runner.Version, time.Now().UTC(), ToReason(runnerStatus))
+	switch {
+	case errors.Is(err, datastore.ErrNotFound):
+		logger.Logf(false, "%s is already removed from datastore by other process", runner.UUID)
+		return nil
+	case errors.Is(err, datastore.ErrConflict):
+		// runner is updated by other process after read, do not overwrite it. will retry with latest runner in next loop
+		return fmt.Errorf("runner is updated by other process (runner uuid: %s, version: %d): %w", runner.UUID.String(), runner.Version, err)
+	case err != nil:
+		return fmt.Errorf("failed to remove runner from datastore (runner uuid: %s): %w", runner.UUID.String(), err)
 	}
+	datastore.RecordHistory(ctx, m.ds, datastore.HistoryResourceRunner, runner.UUID, datastore.HistoryStatusDeleted, string(ToReason(runnerStatus)))
 
 	return nil
 }
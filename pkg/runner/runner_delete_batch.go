@@ -0,0 +1,70 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// deleteBatch collect runners that instance is deleted, and remove them from datastore at once
+type deleteBatch struct {
+	mu      sync.Mutex
+	runners map[datastore.RunnerStatus][]datastore.Runner
+}
+
+type deleteBatchKey struct{}
+
+// withDeleteBatch return ctx that runners are removed from datastore in batch, it must be flushed after deleting
+func withDeleteBatch(ctx context.Context) (context.Context, *deleteBatch) {
+	b := &deleteBatch{runners: map[datastore.RunnerStatus][]datastore.Runner{}}
+	return context.WithValue(ctx, deleteBatchKey{}, b), b
+}
+
+func deleteBatchFrom(ctx context.Context) *deleteBatch {
+	b, _ := ctx.Value(deleteBatchKey{}).(*deleteBatch)
+	return b
+}
+
+// add collect runner that is read, it is removed only if version is not changed in flush
+func (b *deleteBatch) add(runner datastore.Runner, reason datastore.RunnerStatus) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.runners[reason] = append(b.runners[reason], runner)
+}
+
+// flush remove collected runners from datastore, a statement deletes up to datastore.DefaultPageSize runners.
+// runners that are updated by other process are not removed, will retry with latest runner in next loop
+func (b *deleteBatch) flush(ctx context.Context, ds datastore.Datastore) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var errs []error
+	for reason, runners := range b.runners {
+		for len(runners) > 0 {
+			chunk := runners[:min(len(runners), datastore.DefaultPageSize)]
+			runners = runners[len(chunk):]
+
+			deleted, err := ds.DeleteRunnersBulk(ctx, chunk, time.Now().UTC(), reason)
+			switch {
+			case errors.Is(err, datastore.ErrConflict):
+				errs = append(errs, err)
+			case err != nil:
+				errs = append(errs, fmt.Errorf("failed to remove %d runners from datastore: %w", len(chunk), err))
+				continue
+			case len(deleted) != len(chunk):
+				logger.Logf(false, "%d runners are already removed from datastore by other process", len(chunk)-len(deleted))
+			}
+			for _, id := range deleted {
+				datastore.RecordHistory(ctx, ds, datastore.HistoryResourceRunner, id, datastore.HistoryStatusDeleted, string(reason))
+			}
+		}
+	}
+	b.runners = map[datastore.RunnerStatus][]datastore.Runner{}
+	return errors.Join(errs...)
+}
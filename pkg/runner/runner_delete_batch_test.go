@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
+)
+
+func TestDeleteBatch_FlushConflict(t *testing.T) {
+	ctx := context.Background()
+	ds, err := memory.New(nil)
+	if err != nil {
+		t.Fatalf("failed to create datastore: %+v", err)
+	}
+	target := datastore.Target{UUID: datastore.NewID(), Scope: "octocat", ResourceType: datastore.ResourceTypeNano}
+	if err := ds.CreateTarget(ctx, target); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	ctx, b := withDeleteBatch(ctx)
+	var read []datastore.Runner
+	for i := 0; i < 2; i++ {
+		r := datastore.Runner{UUID: uuid.NewV4(), TargetID: target.UUID, ResourceType: datastore.ResourceTypeNano}
+		if err := ds.CreateRunner(ctx, r); err != nil {
+			t.Fatalf("failed to create runner: %+v", err)
+		}
+		read = append(read, r)
+		b.add(r, datastore.RunnerStatusCompleted)
+	}
+	// updated by other process after read
+	if err := ds.UpdateRunnerWithVersion(ctx, read[1]); err != nil {
+		t.Fatalf("failed to update runner: %+v", err)
+	}
+
+	if err := b.flush(ctx, ds); !errors.Is(err, datastore.ErrConflict) {
+		t.Fatalf("want ErrConflict, but got %+v", err)
+	}
+	if r, err := ds.GetRunner(ctx, read[0].UUID); err != nil || !r.Deleted {
+		t.Errorf("%s must be deleted (err: %+v)", read[0].UUID, err)
+	}
+	if r, err := ds.GetRunner(ctx, read[1].UUID); err != nil || r.Deleted {
+		t.Errorf("%s is updated by other process, must not be deleted (err: %+v)", read[1].UUID, err)
+	}
+}
@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/go-github/v47/github"
+
+	"github.com/whywaita/myshoes/pkg/gh"
+)
+
+// busyChecker check busy state of runners in a target against a listing that is fetched once in a cycle.
+// getting each runner from GitHub is a request per runner, and enterprise runners are only found in list
+type busyChecker struct {
+	mu      sync.Mutex
+	fetched bool
+	runners map[int64]*github.Runner
+	err     error
+}
+
+type busyCheckerKey struct{}
+
+// withBusyChecker return ctx that busy state of runners is checked against a fresh listing
+func withBusyChecker(ctx context.Context) context.Context {
+	return context.WithValue(ctx, busyCheckerKey{}, &busyChecker{})
+}
+
+func busyCheckerFrom(ctx context.Context) *busyChecker {
+	c, _ := ctx.Value(busyCheckerKey{}).(*busyChecker)
+	return c
+}
+
+// isBusy return true if runner is running a job now, list of runners is refreshed in first call only
+func (c *busyChecker) isBusy(ctx context.Context, githubClient *github.Client, owner, repo string, runnerID int64) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.fetched {
+		c.fetched = true
+		runners, err := gh.RefreshRunners(ctx, githubClient, owner, repo)
+		if err != nil {
+			c.err = fmt.Errorf("failed to refresh list of runners: %w", err)
+		}
+		c.runners = make(map[int64]*github.Runner, len(runners))
+		for _, r := range runners {
+			c.runners[r.GetID()] = r
+		}
+	}
+	if c.err != nil {
+		return false, c.err
+	}
+
+	r, ok := c.runners[runnerID]
+	if !ok {
+		// already removed from GitHub, job is completed
+		return false, nil
+	}
+	return r.GetBusy(), nil
+}
@@ -0,0 +1,58 @@
+package runner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v47/github"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+)
+
+func TestDeleteRunnerWithGitHub_Busy(t *testing.T) {
+	config.Config.GitHubURL = "https://github.com"
+	ctx := withBusyChecker(context.Background())
+	t.Cleanup(func() { gh.InvalidateRunnersCache(ctx, "octocat", "hello-world") })
+
+	var listed, removed atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/octocat/hello-world/actions/runners", func(w http.ResponseWriter, r *http.Request) {
+		listed.Add(1)
+		w.Write([]byte(`{"total_count":2,"runners":[{"id":1,"status":"online","busy":true},{"id":2,"status":"online","busy":true}]}`))
+	})
+	mux.HandleFunc("/repos/octocat/hello-world/actions/runners/", func(w http.ResponseWriter, r *http.Request) {
+		removed.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(ts.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to parse URL: %+v", err)
+	}
+	client.BaseURL = baseURL
+
+	m := New(nil, "latest")
+	for _, runnerID := range []int64{1, 2} {
+		runner := datastore.Runner{UUID: uuid.NewV4(), RequestWebhook: "{}"}
+		// runner picked up a job after listing at start of cycle
+		if err := m.deleteRunnerWithGitHub(ctx, client, runner, runnerID, "octocat", "hello-world", StatusSleep); err != nil {
+			t.Fatalf("failed to delete runner: %+v", err)
+		}
+	}
+
+	if got := removed.Load(); got != 0 {
+		t.Errorf("busy runners must not be removed, but removed %d runners", got)
+	}
+	if got := listed.Load(); got != 1 {
+		t.Errorf("runners must be listed once in a cycle, but listed %d times", got)
+	}
+}
@@ -15,12 +15,12 @@ import (
 // --ephemeral flag is delete self-hosted runner when end of job. So, The origin list of runner from datastore.
 func (m *Manager) removeRunnerModeEphemeral(ctx context.Context, t datastore.Target, runner datastore.Runner, ghRunners []*github.Runner) error {
 	owner, repo := t.OwnerRepo()
-	client, err := gh.NewClient(t.GitHubToken)
+	client, err := gh.HostFrom(ctx).NewClient(t.GitHubToken)
 	if err != nil {
 		return fmt.Errorf("failed to create github client: %w", err)
 	}
 
-	ghRunner, err := gh.ExistGitHubRunnerWithRunner(ghRunners, ToName(runner.UUID.String()))
+	ghRunner, err := gh.ExistGitHubRunnerWithRunner(ghRunners, RunnerName(runner))
 	switch {
 	case errors.Is(err, gh.ErrNotFound):
 		// deleted in GitHub, It's completed
@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+var (
+	// orphanSeenAt is time that offline runner without instance is found first, key is name of runner.
+	// a runner is registered in GitHub before it is stored to datastore, So it is removed after MustRunningTime
+	orphanSeenAt   = map[string]time.Time{}
+	orphanSeenAtMu sync.Mutex
+)
+
+// removeOrphanRunners remove offline runners in GitHub that created by myshoes and have no instance.
+// runners is alive runners of target in datastore.
+func (m *Manager) removeOrphanRunners(ctx context.Context, t datastore.Target, ghRunners []*github.Runner, runners []datastore.Runner) error {
+	alive := make(map[uuid.UUID]struct{}, len(runners))
+	for _, r := range runners {
+		alive[r.UUID] = struct{}{}
+	}
+
+	var orphans []*github.Runner
+	for _, ghRunner := range ghRunners {
+		u, ok := orphanUUID(ghRunner)
+		if !ok {
+			continue
+		}
+		if _, found := alive[u]; found {
+			continue
+		}
+		orphans = append(orphans, ghRunner)
+	}
+	orphans = filterOrphansOverGrace(t.UUID, orphans, time.Now())
+	if len(orphans) == 0 {
+		return nil
+	}
+
+	owner, repo := t.OwnerRepo()
+	client, err := gh.HostFrom(ctx).NewClient(t.GitHubToken)
+	if err != nil {
+		return fmt.Errorf("failed to create github client: %w", err)
+	}
+	for _, ghRunner := range orphans {
+		u, _ := orphanUUID(ghRunner)
+		if m.recordGC(datastore.Runner{UUID: u, TargetID: t.UUID}, GCReasonOrphan) {
+			continue
+		}
+
+		logger.Logf(false, "will remove offline runner that has no instance in GitHub: %s (target: %s)", ghRunner.GetName(), t.Scope)
+		if err := gh.RemoveRunner(ctx, client, owner, repo, ghRunner.GetID()); err != nil {
+			logger.Logf(false, "failed to remove offline runner (name: %s): %+v", ghRunner.GetName(), err)
+			continue
+		}
+		forgetOrphan(t.UUID, ghRunner.GetName())
+	}
+	return nil
+}
+
+// orphanUUID return uuid of runner if runner is created by myshoes and offline (not busy)
+func orphanUUID(ghRunner *github.Runner) (uuid.UUID, bool) {
+	if ghRunner.GetStatus() != StatusWillDelete || ghRunner.GetBusy() {
+		return uuid.UUID{}, false
+	}
+	if !strings.HasPrefix(ghRunner.GetName(), ToName("")) {
+		// adopted or not managed by myshoes
+		return uuid.UUID{}, false
+	}
+	u, err := ToUUID(ghRunner.GetName())
+	if err != nil {
+		return uuid.UUID{}, false
+	}
+	return u, true
+}
+
+// filterOrphansOverGrace return orphans that are found over MustRunningTime ago, and forget runners that are not orphan anymore
+func filterOrphansOverGrace(targetID uuid.UUID, orphans []*github.Runner, now time.Time) []*github.Runner {
+	orphanSeenAtMu.Lock()
+	defer orphanSeenAtMu.Unlock()
+
+	prefix := targetID.String() + "/"
+	current := make(map[string]struct{}, len(orphans))
+	var over []*github.Runner
+	for _, ghRunner := range orphans {
+		key := prefix + ghRunner.GetName()
+		current[key] = struct{}{}
+
+		seenAt, ok := orphanSeenAt[key]
+		if !ok {
+			orphanSeenAt[key] = now
+			continue
+		}
+		if now.Sub(seenAt) > MustRunningTime {
+			over = append(over, ghRunner)
+		}
+	}
+	for key := range orphanSeenAt {
+		if _, ok := current[key]; !ok && strings.HasPrefix(key, prefix) {
+			delete(orphanSeenAt, key)
+		}
+	}
+	return over
+}
+
+func forgetOrphan(targetID uuid.UUID, name string) {
+	orphanSeenAtMu.Lock()
+	defer orphanSeenAtMu.Unlock()
+	delete(orphanSeenAt, targetID.String()+"/"+name)
+}
@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"context"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+type quietHoursKey struct{}
+
+// withQuietHours return ctx that non-urgent deletions of runners in target are deferred
+func withQuietHours(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, quietHoursKey{}, scope)
+}
+
+func quietHoursFrom(ctx context.Context) (string, bool) {
+	scope, ok := ctx.Value(quietHoursKey{}).(string)
+	return scope, ok
+}
+
+// deferDeletion return true if deleting runner is deferred to the end of quiet hours.
+// deferred runners are checked again in next loop.
+func (m *Manager) deferDeletion(ctx context.Context, runner datastore.Runner, reason GCReason) bool {
+	scope, ok := quietHoursFrom(ctx)
+	if !ok || reason.IsUrgent() {
+		return false
+	}
+
+	if m.report != nil {
+		m.report.addDeferred(scope)
+	}
+	logger.Logf(true, "%s is in quiet hours of %s, will defer to delete (reason: %s)", runner.UUID, scope, reason)
+	return true
+}
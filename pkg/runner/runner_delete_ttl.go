@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// removeRunnerWithTTL remove runner that requested with TTL (e.g. generic instances from capacity request).
+// these runner is not deleted until TTL regardless of status in GitHub, and deleted after TTL even if it is busy.
+func (m *Manager) removeRunnerWithTTL(ctx context.Context, t datastore.Target, runner datastore.Runner, ttl time.Duration, ghRunners []*github.Runner) error {
+	if err := sanitizeRunner(runner, ttl); err != nil {
+		logger.Logf(true, "%s is not reached TTL (%s), so not will delete", runner.UUID, ttl)
+		return nil
+	}
+
+	ghRunner, err := gh.ExistGitHubRunnerWithRunner(ghRunners, RunnerName(runner))
+	switch {
+	case errors.Is(err, gh.ErrNotFound):
+		if err := m.deleteRunner(ctx, runner, StatusSleep); err != nil {
+			return fmt.Errorf("failed to delete runner: %w", err)
+		}
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to check runner exist in GitHub (runner: %s): %w", runner.UUID, err)
+	}
+
+	owner, repo := t.OwnerRepo()
+	client, err := gh.HostFrom(ctx).NewClient(t.GitHubToken)
+	if err != nil {
+		return fmt.Errorf("failed to create github client: %w", err)
+	}
+	if err := m.deleteRunnerWithGitHub(ctx, client, runner, ghRunner.GetID(), owner, repo, StatusSleep); err != nil {
+		return fmt.Errorf("failed to delete runner with GitHub: %w", err)
+	}
+	return nil
+}
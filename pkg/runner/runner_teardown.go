@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/logger"
+	"github.com/whywaita/myshoes/pkg/shoes"
+)
+
+// StatusTargetDeleted is status of runner that deleted by deleting target
+var StatusTargetDeleted = "target_deleted"
+
+// doDeletedTargets tear down runners of deleted targets
+func (m *Manager) doDeletedTargets(ctx context.Context) error {
+	opt := datastore.ListOption{Limit: datastore.DefaultPageSize}
+	for {
+		targets, err := m.ds.ListDeletedTargets(ctx, opt)
+		if err != nil {
+			return fmt.Errorf("failed to get deleted targets from datastore: %w", err)
+		}
+		for _, t := range targets {
+			if err := m.teardownTarget(ctx, t); err != nil {
+				logger.Logf(false, "failed to tear down deleted target (target: %s): %+v", t.Scope, err)
+			}
+		}
+		if len(targets) < opt.Limit {
+			return nil
+		}
+		opt.Cursor = targets[len(targets)-1].UUID
+	}
+}
+
+// teardownTarget deregister all runners of deleted target in GitHub, and delete instances and records.
+// runners are deleted regardless of status in GitHub (busy, idle) because target is already deleted.
+func (m *Manager) teardownTarget(ctx context.Context, t datastore.Target) error {
+	runners, err := m.ds.ListRunnersByTargetID(ctx, t.UUID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve list of runner: %w", err)
+	}
+	if len(runners) == 0 {
+		return nil
+	}
+	logger.Logf(false, "target %s is deleted, will tear down %d runners", t.Scope, len(runners))
+
+	// token of deleted target is not refreshed, so use a client from installation
+	owner, repo := t.OwnerRepo()
+	var client *github.Client
+	var ghRunners []*github.Runner
+	hctx, err := t.WithGitHubHost(ctx)
+	if err == nil {
+		client, ghRunners, err = listRunnersForTeardown(hctx, t)
+	}
+	if err != nil {
+		// instances must be deleted even if GitHub is not reachable, offline runners are removed by GitHub later
+		logger.Logf(false, "failed to get runners in GitHub, will delete only instances (target: %s): %+v", t.Scope, err)
+	}
+
+	var errs []error
+	ctx, batch := withDeleteBatch(ctx)
+	for _, runner := range runners {
+		if err := m.teardownRunner(ctx, client, runner, ghRunners, owner, repo); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := batch.flush(ctx, m.ds); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) teardownRunner(ctx context.Context, client *github.Client, runner datastore.Runner, ghRunners []*github.Runner, owner, repo string) error {
+	if client != nil {
+		ghRunner, err := gh.ExistGitHubRunnerWithRunner(ghRunners, RunnerName(runner))
+		if err == nil {
+			if err := gh.RemoveRunner(ctx, client, owner, repo, ghRunner.GetID()); err != nil {
+				return fmt.Errorf("failed to deregister runner (runner uuid: %s): %w", runner.UUID, err)
+			}
+		} else if !errors.Is(err, gh.ErrNotFound) {
+			return fmt.Errorf("failed to check runner exist in GitHub (runner uuid: %s): %w", runner.UUID, err)
+		}
+	}
+
+	if err := m.deleteRunnerInShoes(ctx, runner, StatusTargetDeleted, shoes.DeleteReasonDrain); err != nil {
+		return fmt.Errorf("failed to delete runner (runner uuid: %s): %w", runner.UUID, err)
+	}
+	return nil
+}
+
+func listRunnersForTeardown(ctx context.Context, t datastore.Target) (*github.Client, []*github.Runner, error) {
+	installationID, err := gh.IsInstalledGitHubApp(ctx, t.Scope)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get installation id: %w", err)
+	}
+	client, err := gh.HostFrom(ctx).NewClientInstallation(installationID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create github client: %w", err)
+	}
+	owner, repo := t.OwnerRepo()
+	ghRunners, err := gh.ListRunners(ctx, client, owner, repo)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get list of runner in GitHub: %w", err)
+	}
+	return client, ghRunners, nil
+}
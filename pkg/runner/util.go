@@ -13,6 +13,14 @@ func ToName(u string) string {
 	return fmt.Sprintf("myshoes-%s", u)
 }
 
+// RunnerName return name of runner in GitHub. adopted runner has original name
+func RunnerName(runner datastore.Runner) string {
+	if runner.RunnerName.Valid {
+		return runner.RunnerName.String
+	}
+	return ToName(runner.UUID.String())
+}
+
 // ToUUID convert runner name to uuid
 func ToUUID(name string) (uuid.UUID, error) {
 	u := strings.TrimPrefix(name, "myshoes-")
@@ -28,6 +36,8 @@ func ToReason(status string) datastore.RunnerStatus {
 	case StatusSleep:
 		// is idle, reach hard limit
 		return datastore.RunnerStatusReachHardLimit
+	case StatusTargetDeleted:
+		return datastore.RunnerStatusTargetDeleted
 	}
 
 	return ""
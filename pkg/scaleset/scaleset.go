@@ -0,0 +1,324 @@
+package scaleset
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+var (
+	// syncInterval is interval of starting and stopping listeners by targets
+	syncInterval = 1 * time.Minute
+	// retryInterval is interval of restarting a listener that is failed
+	retryInterval = 10 * time.Second
+
+	// listeners is running listeners. key: target ID, value: *listener
+	listeners = sync.Map{}
+)
+
+// dedupKeyPrefix is prefix of datastore.Job.DedupKey that is assigned by scale set
+const dedupKeyPrefix = "scale_set:"
+
+// Manager run a listener of runner scale set for each target.
+// listener registers a scale set per target, long-polls job assignments and enqueue them as jobs.
+type Manager struct {
+	ds            datastore.Datastore
+	name          string
+	runnerGroupID int
+}
+
+// New create a manager of scale sets
+func New(ds datastore.Datastore, name string, runnerGroupID int) *Manager {
+	return &Manager{
+		ds:            ds,
+		name:          name,
+		runnerGroupID: runnerGroupID,
+	}
+}
+
+// Loop start and stop listeners by targets
+func (m *Manager) Loop(ctx context.Context) error {
+	if !gh.IsSupported(gh.FeatureRunnerScaleSet) {
+		logger.Logf(false, "runner scale set is not supported in GitHub Enterprise Server %s, scale set manager is disabled", gh.GHESVersion())
+		return nil
+	}
+	logger.Logf(false, "start scale set manager loop")
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	cancels := map[uuid.UUID]context.CancelFunc{}
+
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.sync(ctx, &wg, cancels); err != nil {
+			logger.Logf(false, "failed to sync scale sets: %+v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// sync start listeners of new targets, and stop listeners of targets that cannot receive job
+func (m *Manager) sync(ctx context.Context, wg *sync.WaitGroup, cancels map[uuid.UUID]context.CancelFunc) error {
+	targets, err := datastore.ListTargets(ctx, m.ds)
+	if err != nil {
+		return fmt.Errorf("failed to get targets: %w", err)
+	}
+
+	active := map[uuid.UUID]struct{}{}
+	for _, t := range targets {
+		if !t.CanReceiveJob() {
+			continue
+		}
+		active[t.UUID] = struct{}{}
+		if _, ok := cancels[t.UUID]; ok {
+			continue
+		}
+
+		lctx, cancel := context.WithCancel(ctx)
+		cancels[t.UUID] = cancel
+		l := &listener{ds: m.ds, target: t, name: m.name, runnerGroupID: m.runnerGroupID}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.loop(lctx)
+		}()
+	}
+
+	for id, cancel := range cancels {
+		if _, ok := active[id]; !ok {
+			logger.Logf(false, "target cannot receive job, stop scale set listener (target ID: %s)", id)
+			cancel()
+			delete(cancels, id)
+		}
+	}
+	return nil
+}
+
+// scaleSetClient is client of Actions service that listener uses, it is implemented by *gh.ScaleSetClient
+type scaleSetClient interface {
+	GetScaleSet(ctx context.Context, runnerGroupID int, name string) (*gh.ScaleSet, error)
+	CreateScaleSet(ctx context.Context, scaleSet gh.ScaleSet) (*gh.ScaleSet, error)
+	CreateSession(ctx context.Context, scaleSetID int, owner string) (*gh.ScaleSetSession, error)
+	DeleteSession(ctx context.Context, scaleSetID int, sessionID string) error
+	GetMessage(ctx context.Context, session *gh.ScaleSetSession, lastMessageID int64) (*gh.ScaleSetMessage, error)
+	DeleteMessage(ctx context.Context, session *gh.ScaleSetSession, messageID int64) error
+	AcquireJobs(ctx context.Context, scaleSetID int, session *gh.ScaleSetSession, requestIDs []int64) ([]int64, error)
+	GenerateJITConfig(ctx context.Context, scaleSetID int, runnerName string) (string, error)
+}
+
+// listener is listener of a scale set in a target
+type listener struct {
+	ds            datastore.Datastore
+	target        datastore.Target
+	name          string
+	runnerGroupID int
+
+	client   scaleSetClient
+	scaleSet *gh.ScaleSet
+}
+
+// loop run listener until ctx is done, listener is restarted if failed
+func (l *listener) loop(ctx context.Context) {
+	for {
+		if err := l.run(ctx); err != nil {
+			logger.Logf(false, "failed to listen scale set, will retry (target ID: %s): %+v", l.target.UUID, err)
+		}
+
+		select {
+		case <-time.After(retryInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// run register a scale set, and process messages in a session
+func (l *listener) run(ctx context.Context) error {
+	installationID, err := gh.IsInstalledGitHubApp(ctx, l.target.Scope)
+	if err != nil {
+		return fmt.Errorf("failed to get installation id: %w", err)
+	}
+	l.client = gh.NewScaleSetClient(installationID, l.target.Scope)
+
+	scaleSet, err := l.client.GetScaleSet(ctx, l.runnerGroupID, l.name)
+	switch {
+	case errors.Is(err, gh.ErrNotFound):
+		labels := []gh.ScaleSetLabel{{Name: l.name, Type: "System"}}
+		// injected labels are set in registering, runners of scale set have labels of scale set
+		for _, ls := range [][]string{config.Config.RunnerLabels, l.target.InjectedRunnerLabels()} {
+			for _, name := range ls {
+				if !containsLabel(labels, name) {
+					labels = append(labels, gh.ScaleSetLabel{Name: name, Type: "User"})
+				}
+			}
+		}
+		scaleSet, err = l.client.CreateScaleSet(ctx, gh.ScaleSet{
+			Name:          l.name,
+			RunnerGroupID: l.runnerGroupID,
+			Labels:        labels,
+			RunnerSetting: gh.ScaleSetSettings{Ephemeral: true, DisableUpdate: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to register scale set: %w", err)
+		}
+		logger.Logf(false, "scale set is registered (target ID: %s, scale set ID: %d)", l.target.UUID, scaleSet.ID)
+	case err != nil:
+		return fmt.Errorf("failed to get scale set: %w", err)
+	}
+	l.scaleSet = scaleSet
+
+	hostname, _ := os.Hostname()
+	session, err := l.client.CreateSession(ctx, scaleSet.ID, hostname)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	listeners.Store(l.target.UUID, l)
+	defer func() {
+		listeners.Delete(l.target.UUID)
+		// session must be deleted for next session, ctx may be already canceled
+		cctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := l.client.DeleteSession(cctx, scaleSet.ID, session.SessionID); err != nil {
+			logger.Logf(false, "failed to delete session of scale set (target ID: %s): %+v", l.target.UUID, err)
+		}
+	}()
+
+	var lastMessageID int64
+	for {
+		message, err := l.client.GetMessage(ctx, session, lastMessageID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to get message: %w", err)
+		}
+		if message == nil {
+			continue
+		}
+
+		if err := l.handleMessage(ctx, session, message); err != nil {
+			return fmt.Errorf("failed to handle message (message ID: %d): %w", message.MessageID, err)
+		}
+		if err := l.client.DeleteMessage(ctx, session, message.MessageID); err != nil {
+			return fmt.Errorf("failed to delete message (message ID: %d): %w", message.MessageID, err)
+		}
+		lastMessageID = message.MessageID
+	}
+}
+
+// handleMessage acquire available jobs, and enqueue assigned jobs
+func (l *listener) handleMessage(ctx context.Context, session *gh.ScaleSetSession, message *gh.ScaleSetMessage) error {
+	if message.MessageType != gh.ScaleSetMessageTypeJobMessages {
+		logger.Logf(true, "ignore message of scale set (type: %s)", message.MessageType)
+		return nil
+	}
+	jobs, err := message.JobMessages()
+	if err != nil {
+		return fmt.Errorf("failed to parse job messages: %w", err)
+	}
+
+	var available []int64
+	for _, j := range jobs {
+		switch j.MessageType {
+		case gh.ScaleSetJobAvailable:
+			available = append(available, j.RunnerRequestID)
+		case gh.ScaleSetJobAssigned:
+			if err := l.enqueue(ctx, j); err != nil {
+				return fmt.Errorf("failed to enqueue job (runner request ID: %d): %w", j.RunnerRequestID, err)
+			}
+		default:
+			logger.Logf(true, "receive %s of scale set (runner request ID: %d, runner: %s)", j.MessageType, j.RunnerRequestID, j.RunnerName)
+		}
+	}
+
+	if len(available) > 0 {
+		acquired, err := l.client.AcquireJobs(ctx, l.scaleSet.ID, session, available)
+		if err != nil {
+			return fmt.Errorf("failed to acquire jobs: %w", err)
+		}
+		logger.Logf(true, "acquire %d of %d jobs in scale set (target ID: %s)", len(acquired), len(available), l.target.UUID)
+	}
+	return nil
+}
+
+// enqueue store an assigned job, starter create a runner for it by JIT config
+func (l *listener) enqueue(ctx context.Context, j gh.ScaleSetJobMessage) error {
+	repoName := fmt.Sprintf("%s/%s", j.OwnerName, j.RepositoryName)
+	jobJSON, err := json.Marshal(github.WorkflowJob{
+		RunID:  github.Int64(j.WorkflowRunID),
+		Name:   github.String(j.JobDisplayName),
+		Labels: j.RequestLabels,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	// job is run in GitHub host of target, it may not be GITHUB_URL if GITHUB_HOSTS is set
+	job := datastore.Job{
+		UUID:           datastore.NewID(),
+		GHEDomain:      l.target.GHEDomain,
+		Repository:     repoName,
+		CheckEventJSON: string(jobJSON),
+		TargetID:       l.target.UUID,
+		DedupKey:       sql.NullString{String: fmt.Sprintf("%s%d:%d", dedupKeyPrefix, l.scaleSet.ID, j.RunnerRequestID), Valid: true},
+	}
+	stored, err := l.ds.EnqueueJob(ctx, job)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	if !uuid.Equal(stored.UUID, job.UUID) {
+		logger.Logf(true, "job is already enqueued (job ID: %s)", stored.UUID)
+		return nil
+	}
+	datastore.RecordHistory(ctx, l.ds, datastore.HistoryResourceJob, job.UUID, datastore.HistoryStatusEnqueued, "assigned to runner scale set")
+	return nil
+}
+
+func containsLabel(labels []gh.ScaleSetLabel, name string) bool {
+	for _, l := range labels {
+		if strings.EqualFold(l.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsScaleSetJob return true if job is assigned by scale set
+func IsScaleSetJob(job datastore.Job) bool {
+	return job.DedupKey.Valid && strings.HasPrefix(job.DedupKey.String, dedupKeyPrefix)
+}
+
+// GenerateJITConfig generate JIT config of runner for a job that is assigned by scale set
+func GenerateJITConfig(ctx context.Context, job datastore.Job, runnerName string) (string, error) {
+	v, ok := listeners.Load(job.TargetID)
+	if !ok {
+		return "", fmt.Errorf("scale set listener is not running (target ID: %s)", job.TargetID)
+	}
+	l := v.(*listener)
+	jitConfig, err := l.client.GenerateJITConfig(ctx, l.scaleSet.ID, runnerName)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate JIT config: %w", err)
+	}
+	return jitConfig, nil
+}
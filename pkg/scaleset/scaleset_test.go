@@ -0,0 +1,126 @@
+package scaleset
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v47/github"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
+	"github.com/whywaita/myshoes/pkg/gh"
+)
+
+// fakeScaleSetClient record requests of acquiring jobs
+type fakeScaleSetClient struct {
+	scaleSetClient // other methods are not called in tests
+
+	acquired [][]int64
+}
+
+func (c *fakeScaleSetClient) AcquireJobs(ctx context.Context, scaleSetID int, session *gh.ScaleSetSession, requestIDs []int64) ([]int64, error) {
+	c.acquired = append(c.acquired, requestIDs)
+	return requestIDs, nil
+}
+
+func newJobMessage(t *testing.T, jobs ...gh.ScaleSetJobMessage) *gh.ScaleSetMessage {
+	t.Helper()
+
+	body, err := json.Marshal(jobs)
+	if err != nil {
+		t.Fatalf("failed to marshal job messages: %+v", err)
+	}
+	return &gh.ScaleSetMessage{MessageID: 1, MessageType: gh.ScaleSetMessageTypeJobMessages, Body: string(body)}
+}
+
+func TestListener_handleMessage(t *testing.T) {
+	ctx := context.Background()
+	ds, err := memory.New(nil)
+	if err != nil {
+		t.Fatalf("failed to create datastore: %+v", err)
+	}
+	client := &fakeScaleSetClient{}
+	target := datastore.Target{
+		UUID:      datastore.NewID(),
+		Scope:     "octocat",
+		GHEDomain: sql.NullString{String: "https://github.example.com", Valid: true},
+	}
+	l := &listener{
+		ds:       ds,
+		target:   target,
+		name:     "myshoes",
+		client:   client,
+		scaleSet: &gh.ScaleSet{ID: 3},
+	}
+	session := &gh.ScaleSetSession{SessionID: "s1"}
+
+	message := newJobMessage(t,
+		gh.ScaleSetJobMessage{MessageType: gh.ScaleSetJobAvailable, RunnerRequestID: 10},
+		gh.ScaleSetJobMessage{MessageType: gh.ScaleSetJobAvailable, RunnerRequestID: 11},
+		gh.ScaleSetJobMessage{
+			MessageType:     gh.ScaleSetJobAssigned,
+			RunnerRequestID: 12,
+			OwnerName:       "octocat",
+			RepositoryName:  "hello-world",
+			JobDisplayName:  "build",
+			WorkflowRunID:   100,
+			RequestLabels:   []string{"myshoes"},
+		},
+		gh.ScaleSetJobMessage{MessageType: gh.ScaleSetJobStarted, RunnerRequestID: 9, RunnerName: "myshoes-runner"},
+	)
+	if err := l.handleMessage(ctx, session, message); err != nil {
+		t.Fatalf("failed to handle message: %+v", err)
+	}
+	// same assignment is delivered again after restarting session
+	if err := l.handleMessage(ctx, session, newJobMessage(t, gh.ScaleSetJobMessage{
+		MessageType:     gh.ScaleSetJobAssigned,
+		RunnerRequestID: 12,
+		OwnerName:       "octocat",
+		RepositoryName:  "hello-world",
+	})); err != nil {
+		t.Fatalf("failed to handle message: %+v", err)
+	}
+
+	if diff := cmp.Diff([][]int64{{10, 11}}, client.acquired); diff != "" {
+		t.Errorf("mismatch acquired jobs (-want +got):\n%s", diff)
+	}
+
+	jobs, err := ds.ListJobs(ctx, datastore.ListOption{})
+	if err != nil {
+		t.Fatalf("failed to list jobs: %+v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("assigned job must be enqueued only once, but got %d jobs", len(jobs))
+	}
+	job := jobs[0]
+	if !IsScaleSetJob(job) || job.DedupKey.String != "scale_set:3:12" {
+		t.Errorf("mismatch dedup key: %+v", job.DedupKey)
+	}
+	if job.GHEDomain != target.GHEDomain {
+		t.Errorf("GHE domain must be domain of target (want: %+v, got: %+v)", target.GHEDomain, job.GHEDomain)
+	}
+	if job.Repository != "octocat/hello-world" || job.TargetID != target.UUID {
+		t.Errorf("mismatch job (repository: %s, target ID: %s)", job.Repository, job.TargetID)
+	}
+	var workflowJob github.WorkflowJob
+	if err := json.Unmarshal([]byte(job.CheckEventJSON), &workflowJob); err != nil {
+		t.Fatalf("failed to unmarshal job: %+v", err)
+	}
+	if workflowJob.GetRunID() != 100 || workflowJob.GetName() != "build" || !cmp.Equal(workflowJob.Labels, []string{"myshoes"}) {
+		t.Errorf("mismatch workflow job: %+v", workflowJob)
+	}
+}
+
+func TestListener_handleMessage_IgnoreOtherType(t *testing.T) {
+	client := &fakeScaleSetClient{}
+	l := &listener{client: client, scaleSet: &gh.ScaleSet{ID: 3}}
+	if err := l.handleMessage(context.Background(), &gh.ScaleSetSession{}, &gh.ScaleSetMessage{MessageType: "RunnerScaleSetJobsStatistic"}); err != nil {
+		t.Fatalf("failed to handle message: %+v", err)
+	}
+	if len(client.acquired) != 0 {
+		t.Errorf("jobs must not be acquired: %+v", client.acquired)
+	}
+}
@@ -0,0 +1,80 @@
+package shoes
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/whywaita/myshoes/pkg/config"
+)
+
+var (
+	builtinsMu sync.Mutex
+	// builtins is providers that built in myshoes, key: name of provider (e.g. ec2)
+	builtins = map[string]func() (Client, error){}
+	// builtinClients is created clients of built-in providers, they are shared as same as plugin processes
+	builtinClients = map[string]Client{}
+)
+
+// RegisterBuiltin register a provider that built in myshoes, it is used by plugin path "builtin:<name>"
+func RegisterBuiltin(name string, newClient func() (Client, error)) {
+	builtinsMu.Lock()
+	defer builtinsMu.Unlock()
+	builtins[name] = newClient
+}
+
+// ValidateBuiltins create clients of built-in providers in PLUGIN and ADDITIONAL_PLUGINS, for checking configuration before serving
+func ValidateBuiltins() error {
+	paths := []string{config.Config.ShoesPluginPath}
+	for _, p := range config.Config.ShoesPlugins {
+		paths = append(paths, p)
+	}
+	for _, p := range paths {
+		name, ok := builtinName(p)
+		if !ok {
+			continue
+		}
+		if _, err := getBuiltinClient(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewCapabilities create Capabilities of current protocol, for built-in providers
+func NewCapabilities(capabilities ...Capability) Capabilities {
+	c := Capabilities{
+		ProtocolVersion: ProtocolVersion,
+		capabilities:    map[Capability]struct{}{},
+	}
+	for _, capability := range capabilities {
+		c.capabilities[capability] = struct{}{}
+	}
+	return c
+}
+
+func builtinName(pluginPath string) (string, bool) {
+	if !strings.HasPrefix(pluginPath, config.BuiltinPluginPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(pluginPath, config.BuiltinPluginPrefix), true
+}
+
+func getBuiltinClient(name string) (Client, error) {
+	builtinsMu.Lock()
+	defer builtinsMu.Unlock()
+
+	if c, ok := builtinClients[name]; ok {
+		return c, nil
+	}
+	newClient, ok := builtins[name]
+	if !ok {
+		return nil, fmt.Errorf("built-in provider %s is not found", name)
+	}
+	c, err := newClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create built-in provider %s: %w", name, err)
+	}
+	builtinClients[name] = c
+	return c, nil
+}
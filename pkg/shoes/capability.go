@@ -0,0 +1,109 @@
+package shoes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/whywaita/myshoes/api/proto.go"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// ProtocolVersion is version of protocol that myshoes speaks to shoes-provider
+	ProtocolVersion uint32 = 2
+	// MinProtocolVersion is min version of protocol of shoes-provider that myshoes supports.
+	// version 1 is shoes-provider that does not implement GetCapabilities
+	MinProtocolVersion uint32 = 1
+)
+
+// ErrIncompatibleProtocol is error for version of protocol is not compatible between myshoes and shoes-provider
+var ErrIncompatibleProtocol = errors.New("incompatible protocol version between myshoes and shoes-provider")
+
+// Capability is a feature of protocol, new fields of requests are used only if shoes-provider advertises capability of them
+type Capability string
+
+const (
+	// CapabilityJobMetadata is capability that receive metadata of job in AddInstance
+	CapabilityJobMetadata Capability = "job_metadata"
+	// CapabilityCapacity is capability that report capacity by GetCapacity
+	CapabilityCapacity Capability = "capacity"
+)
+
+// keys of metadata of job in AddInstance
+const (
+	MetadataKeyRepository = "repository"     // repository of job (:owner/:repo)
+	MetadataKeyScope      = "scope"          // scope of target (:owner or :owner/:repo)
+	MetadataKeyGHEDomain  = "ghe_domain"     // domain of GitHub Enterprise Server, empty is github.com
+	MetadataKeyRunID      = "run_id"         // ID of workflow run in GitHub
+	MetadataKeyJobID      = "job_id"         // ID of workflow job (or check run) in GitHub
+	MetadataKeyMyshoesJob = "myshoes_job_id" // UUID of job in myshoes
+)
+
+// supportedCapabilities is capabilities that myshoes supports, it is sent to shoes-provider in negotiation
+var supportedCapabilities = []Capability{
+	CapabilityJobMetadata,
+	CapabilityCapacity,
+}
+
+// Capabilities is result of negotiation with shoes-provider
+type Capabilities struct {
+	ProtocolVersion uint32
+	capabilities    map[Capability]struct{}
+}
+
+// Has return true if shoes-provider advertises capability
+func (c Capabilities) Has(capability Capability) bool {
+	_, ok := c.capabilities[capability]
+	return ok
+}
+
+// negotiate exchange version of protocol and capabilities with shoes-provider
+func negotiate(ctx context.Context, client pb.ShoesClient) (Capabilities, error) {
+	req := &pb.GetCapabilitiesRequest{ProtocolVersion: ProtocolVersion}
+	for _, c := range supportedCapabilities {
+		req.Capabilities = append(req.Capabilities, string(c))
+	}
+
+	resp, err := client.GetCapabilities(ctx, req)
+	if status.Code(err) == codes.Unimplemented {
+		// shoes-provider that released before negotiation
+		return Capabilities{ProtocolVersion: 1}, nil
+	}
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("failed to GetCapabilities: %w", err)
+	}
+
+	if resp.GetMinProtocolVersion() > ProtocolVersion {
+		return Capabilities{}, fmt.Errorf("%w: shoes-provider requires protocol version %d or later, but myshoes speaks %d. please upgrade myshoes", ErrIncompatibleProtocol, resp.GetMinProtocolVersion(), ProtocolVersion)
+	}
+	if resp.GetProtocolVersion() < MinProtocolVersion {
+		return Capabilities{}, fmt.Errorf("%w: shoes-provider speaks protocol version %d, but myshoes requires %d or later. please upgrade shoes-provider", ErrIncompatibleProtocol, resp.GetProtocolVersion(), MinProtocolVersion)
+	}
+
+	// speak lower version of both, and use capabilities that both support
+	capabilities := Capabilities{
+		ProtocolVersion: resp.GetProtocolVersion(),
+		capabilities:    map[Capability]struct{}{},
+	}
+	if capabilities.ProtocolVersion > ProtocolVersion {
+		capabilities.ProtocolVersion = ProtocolVersion
+	}
+	for _, c := range resp.GetCapabilities() {
+		if isSupportedCapability(Capability(c)) {
+			capabilities.capabilities[Capability(c)] = struct{}{}
+		}
+	}
+	return capabilities, nil
+}
+
+func isSupportedCapability(capability Capability) bool {
+	for _, c := range supportedCapabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
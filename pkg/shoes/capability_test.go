@@ -0,0 +1,162 @@
+package shoes
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pb "github.com/whywaita/myshoes/api/proto.go"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// negotiateServer is shoes-provider that respond resp to GetCapabilities
+type negotiateServer struct {
+	pb.UnimplementedShoesServer
+
+	resp *pb.GetCapabilitiesResponse
+	req  *pb.GetCapabilitiesRequest
+}
+
+func (s *negotiateServer) GetCapabilities(ctx context.Context, req *pb.GetCapabilitiesRequest) (*pb.GetCapabilitiesResponse, error) {
+	s.req = req
+	return s.resp, nil
+}
+
+// legacyServer is shoes-provider that released before negotiation, it does not implement GetCapabilities
+type legacyServer struct {
+	pb.UnimplementedShoesServer
+}
+
+// dialShoesServer serve srv in-process, and return client that connect to it
+func dialShoesServer(t *testing.T, srv pb.ShoesServer) pb.ShoesClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	pb.RegisterShoesServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %+v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewShoesClient(conn)
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name             string
+		resp             *pb.GetCapabilitiesResponse
+		wantVersion      uint32
+		wantCapabilities []Capability
+		err              error
+	}{
+		{
+			name: "same version",
+			resp: &pb.GetCapabilitiesResponse{
+				ProtocolVersion:    ProtocolVersion,
+				MinProtocolVersion: MinProtocolVersion,
+				Capabilities:       []string{string(CapabilityJobMetadata), string(CapabilityCapacity)},
+			},
+			wantVersion:      ProtocolVersion,
+			wantCapabilities: []Capability{CapabilityJobMetadata, CapabilityCapacity},
+		},
+		{
+			name: "capabilities that myshoes does not support are ignored",
+			resp: &pb.GetCapabilitiesResponse{
+				ProtocolVersion: ProtocolVersion,
+				Capabilities:    []string{string(CapabilityCapacity), "future_capability"},
+			},
+			wantVersion:      ProtocolVersion,
+			wantCapabilities: []Capability{CapabilityCapacity},
+		},
+		{
+			name: "newer shoes-provider speaks version of myshoes",
+			resp: &pb.GetCapabilitiesResponse{
+				ProtocolVersion:    ProtocolVersion + 1,
+				MinProtocolVersion: ProtocolVersion,
+				Capabilities:       []string{string(CapabilityJobMetadata)},
+			},
+			wantVersion:      ProtocolVersion,
+			wantCapabilities: []Capability{CapabilityJobMetadata},
+		},
+		{
+			name: "shoes-provider requires newer myshoes",
+			resp: &pb.GetCapabilitiesResponse{
+				ProtocolVersion:    ProtocolVersion + 1,
+				MinProtocolVersion: ProtocolVersion + 1,
+			},
+			err: ErrIncompatibleProtocol,
+		},
+		{
+			name: "shoes-provider is older than myshoes supports",
+			resp: &pb.GetCapabilitiesResponse{
+				ProtocolVersion: MinProtocolVersion - 1,
+			},
+			err: ErrIncompatibleProtocol,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := &negotiateServer{resp: test.resp}
+			got, err := negotiate(context.Background(), dialShoesServer(t, srv))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("want %v, but got %+v", test.err, err)
+			}
+			if test.err != nil {
+				return
+			}
+
+			if srv.req.GetProtocolVersion() != ProtocolVersion {
+				t.Errorf("myshoes must send own protocol version, but got %d", srv.req.GetProtocolVersion())
+			}
+			if diff := cmp.Diff([]string{string(CapabilityJobMetadata), string(CapabilityCapacity)}, srv.req.GetCapabilities()); diff != "" {
+				t.Errorf("mismatch capabilities in request (-want +got):\n%s", diff)
+			}
+
+			if got.ProtocolVersion != test.wantVersion {
+				t.Errorf("want protocol version %d, but got %d", test.wantVersion, got.ProtocolVersion)
+			}
+			for _, c := range supportedCapabilities {
+				want := false
+				for _, w := range test.wantCapabilities {
+					want = want || w == c
+				}
+				if got.Has(c) != want {
+					t.Errorf("mismatch capability %s (want: %t, got: %t)", c, want, got.Has(c))
+				}
+			}
+			if got.Has("future_capability") {
+				t.Errorf("capability that myshoes does not support must not be used")
+			}
+		})
+	}
+}
+
+func TestNegotiate_Unimplemented(t *testing.T) {
+	got, err := negotiate(context.Background(), dialShoesServer(t, &legacyServer{}))
+	if err != nil {
+		t.Fatalf("shoes-provider that does not implement GetCapabilities must be compatible, but got %+v", err)
+	}
+	if got.ProtocolVersion != 1 {
+		t.Errorf("want protocol version 1, but got %d", got.ProtocolVersion)
+	}
+	for _, c := range supportedCapabilities {
+		if got.Has(c) {
+			t.Errorf("shoes-provider of version 1 must not have capability %s", c)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package shoes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/whywaita/myshoes/api/proto.go"
+)
+
+// ErrCapacityNotSupported is error for shoes-provider does not advertise CapabilityCapacity
+var ErrCapacityNotSupported = errors.New("shoes-provider does not support to report capacity")
+
+// Capacity is number of instances that shoes-provider reports
+type Capacity struct {
+	Current uint32 // number of instances that shoes-provider runs now
+	Max     uint32 // max number of instances that shoes-provider can run, 0 is unlimited
+}
+
+// IsSaturated return true if shoes-provider can not run more instances
+func (c Capacity) IsSaturated() bool {
+	return c.Max != 0 && c.Current >= c.Max
+}
+
+// GetCapacity get capacity of shoes-provider, return ErrCapacityNotSupported if shoes-provider does not advertise CapabilityCapacity
+func (c *GRPCClient) GetCapacity(ctx context.Context) (Capacity, error) {
+	if !c.capabilities.Has(CapabilityCapacity) {
+		return Capacity{}, ErrCapacityNotSupported
+	}
+
+	resp, err := c.client.GetCapacity(ctx, &pb.GetCapacityRequest{})
+	if err != nil {
+		return Capacity{}, fmt.Errorf("failed to GetCapacity: %w", err)
+	}
+	return Capacity{
+		Current: resp.GetCurrentCapacity(),
+		Max:     resp.GetMaxCapacity(),
+	}, nil
+}
@@ -0,0 +1,229 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// errNotFound is error for container or image is not found in Docker daemon
+var errNotFound = errors.New("not found")
+
+// api is client of Docker Engine API
+type api struct {
+	baseURL    string
+	socketPath string // path of unix socket, empty if daemon is connected by TCP
+	client     *http.Client
+}
+
+// newAPI create client of Docker Engine API, host is unix:///path/to/docker.sock or tcp://host:port
+func newAPI(host string) (*api, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host of Docker daemon: %w", err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socketPath := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socketPath)
+			},
+		}
+		return &api{
+			baseURL:    "http://docker",
+			socketPath: socketPath,
+			client:     &http.Client{Transport: transport, Timeout: 5 * time.Minute},
+		}, nil
+	case "tcp", "http":
+		return &api{
+			baseURL: "http://" + u.Host,
+			client:  &http.Client{Timeout: 5 * time.Minute},
+		}, nil
+	}
+	return nil, fmt.Errorf("scheme of host of Docker daemon must be unix or tcp (got: %s)", host)
+}
+
+// do send request to Docker daemon, and decode response to out. return errNotFound if status is 404
+func (a *api) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+	u := a.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s %s: %w", method, path, errNotFound)
+	}
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		b, _ := io.ReadAll(resp.Body)
+		if err := json.Unmarshal(b, &e); err != nil || e.Message == "" {
+			e.Message = strings.TrimSpace(string(b))
+		}
+		return fmt.Errorf("failed to request %s %s (status: %d): %s", method, path, resp.StatusCode, e.Message)
+	}
+
+	if out == nil {
+		// body of pulling image is stream of progress, and error is reported in it
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var progress struct {
+				Error string `json:"error"`
+			}
+			err := dec.Decode(&progress)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to decode response of %s %s: %w", method, path, err)
+			}
+			if progress.Error != "" {
+				return fmt.Errorf("failed to request %s %s: %s", method, path, progress.Error)
+			}
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response of %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// containerConfig is request of creating container, subset of Docker Engine API
+type containerConfig struct {
+	Image      string            `json:"Image"`
+	Hostname   string            `json:"Hostname"`
+	Cmd        []string          `json:"Cmd"`
+	Env        []string          `json:"Env"`
+	Labels     map[string]string `json:"Labels"`
+	HostConfig hostConfig        `json:"HostConfig"`
+}
+
+type hostConfig struct {
+	Binds       []string `json:"Binds,omitempty"`
+	NetworkMode string   `json:"NetworkMode,omitempty"`
+}
+
+type container struct {
+	ID              string `json:"Id"`
+	NetworkSettings struct {
+		IPAddress string `json:"IPAddress"`
+		Networks  map[string]struct {
+			IPAddress string `json:"IPAddress"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// ipAddress return IP address of container in any network
+func (c container) ipAddress() string {
+	if c.NetworkSettings.IPAddress != "" {
+		return c.NetworkSettings.IPAddress
+	}
+	for _, n := range c.NetworkSettings.Networks {
+		if n.IPAddress != "" {
+			return n.IPAddress
+		}
+	}
+	return ""
+}
+
+// ensureImage pull image if it is not found in Docker daemon.
+// image without tag is pulled as latest, Docker pulls all tags of it otherwise
+func (a *api) ensureImage(ctx context.Context, image string) error {
+	if !strings.Contains(image[strings.LastIndex(image, "/")+1:], ":") && !strings.Contains(image, "@") {
+		image += ":latest"
+	}
+	err := a.do(ctx, http.MethodGet, "/images/"+image+"/json", nil, nil, &struct{}{})
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, errNotFound) {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("fromImage", image)
+	if err := a.do(ctx, http.MethodPost, "/images/create", query, nil, nil); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
+	}
+	return nil
+}
+
+// runContainer create and start a container, return inspected container
+func (a *api) runContainer(ctx context.Context, name string, config containerConfig) (*container, error) {
+	query := url.Values{}
+	query.Set("name", name)
+	var created container
+	if err := a.do(ctx, http.MethodPost, "/containers/create", query, config, &created); err != nil {
+		return nil, fmt.Errorf("failed to create container: %w", err)
+	}
+	if err := a.do(ctx, http.MethodPost, "/containers/"+created.ID+"/start", nil, nil, nil); err != nil {
+		if err := a.removeContainer(ctx, created.ID); err != nil {
+			logger.Logf(false, "failed to remove container that failed to start (ID: %s): %+v", created.ID, err)
+		}
+		return nil, fmt.Errorf("failed to start container (ID: %s): %w", created.ID, err)
+	}
+
+	var inspected container
+	if err := a.do(ctx, http.MethodGet, "/containers/"+created.ID+"/json", nil, nil, &inspected); err != nil {
+		return nil, fmt.Errorf("failed to inspect container (ID: %s): %w", created.ID, err)
+	}
+	return &inspected, nil
+}
+
+// removeContainer remove a container with its anonymous volumes even if it is running
+func (a *api) removeContainer(ctx context.Context, id string) error {
+	query := url.Values{}
+	query.Set("force", "true")
+	query.Set("v", "true")
+	return a.do(ctx, http.MethodDelete, "/containers/"+id, query, nil, nil)
+}
+
+// countContainers count running containers that have label
+func (a *api) countContainers(ctx context.Context, label string) (int, error) {
+	filters, err := json.Marshal(map[string][]string{"label": {label}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal filters: %w", err)
+	}
+	query := url.Values{}
+	query.Set("filters", string(filters))
+	var containers []container
+	if err := a.do(ctx, http.MethodGet, "/containers/json", query, nil, &containers); err != nil {
+		return 0, fmt.Errorf("failed to list containers: %w", err)
+	}
+	return len(containers), nil
+}
@@ -0,0 +1,145 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+	"github.com/whywaita/myshoes/pkg/shoes"
+)
+
+// Name is name of built-in provider, it is used as plugin path "builtin:docker"
+const Name = "docker"
+
+const (
+	// labelRunnerName is label key of runner name, containers that have it are managed by myshoes
+	labelRunnerName = "myshoes.runner-name"
+	// labelMetadataPrefix is prefix of label keys of job metadata (e.g. myshoes.repository)
+	labelMetadataPrefix = "myshoes."
+	// envSetupScript is environment variable that has setup script encoded by base64
+	envSetupScript = "MYSHOES_SETUP_SCRIPT"
+	// envRunnerUser is environment variable that has user of runner, it is created if image does not have it
+	envRunnerUser = "MYSHOES_RUNNER_USER"
+)
+
+// entrypoint install dependencies of setup script and create user of runner if image does not have them, and execute setup script.
+// runner is ephemeral, so container exits when a job is completed
+const entrypoint = `set -e
+if ! command -v curl > /dev/null || ! command -v sudo > /dev/null; then
+  if command -v apt-get > /dev/null; then
+    apt-get update -qq
+    DEBIAN_FRONTEND=noninteractive apt-get install -y -qq curl sudo jq ca-certificates git libicu-dev > /dev/null
+  fi
+fi
+if [ -n "${MYSHOES_RUNNER_USER}" ] && ! id "${MYSHOES_RUNNER_USER}" > /dev/null 2>&1; then
+  useradd -m -s /bin/bash "${MYSHOES_RUNNER_USER}"
+fi
+echo "${MYSHOES_SETUP_SCRIPT}" | base64 -d > /tmp/myshoes-setup.sh
+exec bash /tmp/myshoes-setup.sh`
+
+// Provider is built-in shoes-provider that run runner containers in Docker daemon
+type Provider struct {
+	api *api
+
+	image         string
+	network       string
+	mountSocket   bool
+	maxContainers int
+}
+
+// New create Provider from DOCKER_* configuration
+func New() (shoes.Client, error) {
+	c := config.Config
+	a, err := newAPI(c.DockerHost)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", config.EnvDockerHost, err)
+	}
+	if c.DockerMountSocket && a.socketPath == "" {
+		return nil, fmt.Errorf("%s needs unix socket in %s (got: %s)", config.EnvDockerMountSocket, config.EnvDockerHost, c.DockerHost)
+	}
+
+	return &Provider{
+		api:           a,
+		image:         c.DockerRunnerImage,
+		network:       c.DockerRunnerNetwork,
+		mountSocket:   c.DockerMountSocket,
+		maxContainers: c.DockerMaxContainers,
+	}, nil
+}
+
+// Capabilities return capabilities of Provider, capacity is reported only if DOCKER_MAX_CONTAINERS is set
+func (p *Provider) Capabilities() shoes.Capabilities {
+	if p.maxContainers > 0 {
+		return shoes.NewCapabilities(shoes.CapabilityJobMetadata, shoes.CapabilityCapacity)
+	}
+	return shoes.NewCapabilities(shoes.CapabilityJobMetadata)
+}
+
+// AddInstance run a runner container that execute setup script as entrypoint.
+// resource type and placement params are ignored, containers share resources of host
+func (p *Provider) AddInstance(ctx context.Context, runnerName, setupScript string, resourceType datastore.ResourceType, labels []string, placementParams string, metadata map[string]string) (string, string, string, datastore.ResourceType, error) {
+	if err := p.api.ensureImage(ctx, p.image); err != nil {
+		return "", "", "", datastore.ResourceTypeUnknown, fmt.Errorf("failed to prepare image: %w", err)
+	}
+
+	containerLabels := map[string]string{
+		labelRunnerName: runnerName,
+	}
+	for k, v := range metadata {
+		containerLabels[labelMetadataPrefix+k] = v
+	}
+	cfg := containerConfig{
+		Image: p.image,
+		// hostname is same as name of container, so logs of runner can be matched to container
+		Hostname: runnerName,
+		Cmd:      []string{"bash", "-c", entrypoint},
+		Env: []string{
+			fmt.Sprintf("%s=%s", envSetupScript, base64.StdEncoding.EncodeToString([]byte(setupScript))),
+			fmt.Sprintf("%s=%s", envRunnerUser, config.Config.RunnerUser),
+		},
+		Labels: containerLabels,
+		HostConfig: hostConfig{
+			NetworkMode: p.network,
+		},
+	}
+	if p.mountSocket {
+		cfg.HostConfig.Binds = []string{fmt.Sprintf("%s:/var/run/docker.sock", p.api.socketPath)}
+	}
+
+	c, err := p.api.runContainer(ctx, runnerName, cfg)
+	if err != nil {
+		return "", "", "", datastore.ResourceTypeUnknown, fmt.Errorf("failed to run container: %w", err)
+	}
+	// name of container is used as cloud ID, it is same as hostname
+	return runnerName, c.ipAddress(), Name, resourceType, nil
+}
+
+// DeleteInstance remove a runner container, container that already removed is ignored
+func (p *Provider) DeleteInstance(ctx context.Context, cloudID string, labels []string, reason shoes.DeleteReason) error {
+	err := p.api.removeContainer(ctx, cloudID)
+	if errors.Is(err, errNotFound) {
+		logger.Logf(false, "container is already removed (cloud ID: %s)", cloudID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove container (reason: %s): %w", reason, err)
+	}
+	return nil
+}
+
+// GetCapacity count running containers that managed by myshoes
+func (p *Provider) GetCapacity(ctx context.Context) (shoes.Capacity, error) {
+	if p.maxContainers <= 0 {
+		return shoes.Capacity{}, shoes.ErrCapacityNotSupported
+	}
+
+	current, err := p.api.countContainers(ctx, labelRunnerName)
+	if err != nil {
+		return shoes.Capacity{}, err
+	}
+	return shoes.Capacity{Current: uint32(current), Max: uint32(p.maxContainers)}, nil
+}
@@ -0,0 +1,439 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/shoes"
+)
+
+const testImage = "myshoes/runner"
+
+// fakeDocker is a minimum implementation of Docker Engine API for Provider
+type fakeDocker struct {
+	mu         sync.Mutex
+	images     map[string]struct{}
+	pulled     []string
+	pullError  string // error in progress of pulling image
+	failStart  bool
+	containers map[string]*fakeContainer // key: ID
+}
+
+type fakeContainer struct {
+	name    string
+	config  containerConfig
+	running bool
+}
+
+func newFakeDocker() *fakeDocker {
+	return &fakeDocker{
+		images:     map[string]struct{}{},
+		containers: map[string]*fakeContainer{},
+	}
+}
+
+// lookup find container by ID or name, f.mu must be locked
+func (f *fakeDocker) lookup(idOrName string) (string, *fakeContainer) {
+	if c, ok := f.containers[idOrName]; ok {
+		return idOrName, c
+	}
+	for id, c := range f.containers {
+		if c.name == idOrName {
+			return id, c
+		}
+	}
+	return "", nil
+}
+
+func (f *fakeDocker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := r.URL.Path
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/images/") && strings.HasSuffix(path, "/json"):
+		image := strings.TrimSuffix(strings.TrimPrefix(path, "/images/"), "/json")
+		if _, ok := f.images[image]; !ok {
+			writeError(w, http.StatusNotFound, "No such image: "+image)
+			return
+		}
+		io.WriteString(w, `{}`)
+	case r.Method == http.MethodPost && path == "/images/create":
+		image := r.URL.Query().Get("fromImage")
+		f.pulled = append(f.pulled, image)
+		io.WriteString(w, `{"status":"Pulling from `+image+`"}`+"\n")
+		if f.pullError != "" {
+			io.WriteString(w, `{"error":"`+f.pullError+`"}`+"\n")
+			return
+		}
+		f.images[image] = struct{}{}
+		io.WriteString(w, `{"status":"Downloaded newer image"}`+"\n")
+	case r.Method == http.MethodPost && path == "/containers/create":
+		name := r.URL.Query().Get("name")
+		if _, c := f.lookup(name); c != nil {
+			writeError(w, http.StatusConflict, "Conflict. The container name is already in use")
+			return
+		}
+		var cfg containerConfig
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		id := "id-" + name
+		f.containers[id] = &fakeContainer{name: name, config: cfg}
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"Id":"`+id+`"}`)
+	case r.Method == http.MethodPost && strings.HasPrefix(path, "/containers/") && strings.HasSuffix(path, "/start"):
+		_, c := f.lookup(strings.TrimSuffix(strings.TrimPrefix(path, "/containers/"), "/start"))
+		if c == nil {
+			writeError(w, http.StatusNotFound, "No such container")
+			return
+		}
+		if f.failStart {
+			writeError(w, http.StatusInternalServerError, "failed to create task for container")
+			return
+		}
+		c.running = true
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && path == "/containers/json":
+		var filters map[string][]string
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var list []container
+		for id, c := range f.containers {
+			if _, ok := c.config.Labels[filters["label"][0]]; ok && c.running {
+				list = append(list, container{ID: id})
+			}
+		}
+		json.NewEncoder(w).Encode(list)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/containers/") && strings.HasSuffix(path, "/json"):
+		id, c := f.lookup(strings.TrimSuffix(strings.TrimPrefix(path, "/containers/"), "/json"))
+		if c == nil {
+			writeError(w, http.StatusNotFound, "No such container")
+			return
+		}
+		io.WriteString(w, `{"Id":"`+id+`","NetworkSettings":{"IPAddress":"","Networks":{"myshoes":{"IPAddress":"172.18.0.2"}}}}`)
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, "/containers/"):
+		if r.URL.Query().Get("force") != "true" || r.URL.Query().Get("v") != "true" {
+			writeError(w, http.StatusConflict, "You cannot remove a running container")
+			return
+		}
+		id, c := f.lookup(strings.TrimPrefix(path, "/containers/"))
+		if c == nil {
+			writeError(w, http.StatusNotFound, "No such container")
+			return
+		}
+		delete(f.containers, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotImplemented, fmt.Sprintf("%s %s is not implemented", r.Method, path))
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// newTestProvider create Provider that connect to fake via TCP
+func newTestProvider(t *testing.T, fake *fakeDocker, maxContainers int) *Provider {
+	t.Helper()
+
+	ts := httptest.NewServer(fake)
+	t.Cleanup(ts.Close)
+
+	defaultConfig := config.Config
+	t.Cleanup(func() { config.Config = defaultConfig })
+	config.Config.DockerHost = "tcp://" + ts.Listener.Addr().String()
+	config.Config.DockerRunnerImage = testImage
+	config.Config.DockerRunnerNetwork = "myshoes"
+	config.Config.DockerMaxContainers = maxContainers
+	config.Config.RunnerUser = "runner"
+
+	p, err := New()
+	if err != nil {
+		t.Fatalf("failed to create provider: %+v", err)
+	}
+	return p.(*Provider)
+}
+
+func TestNew(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "docker.sock")
+
+	tests := []struct {
+		host        string
+		mountSocket bool
+		want        *api
+		err         bool
+	}{
+		{
+			host: "tcp://127.0.0.1:2375",
+			want: &api{baseURL: "http://127.0.0.1:2375"},
+		},
+		{
+			host:        "unix://" + socketPath,
+			mountSocket: true,
+			want:        &api{baseURL: "http://docker", socketPath: socketPath},
+		},
+		{
+			// socket of TCP daemon can not be mounted
+			host:        "tcp://127.0.0.1:2375",
+			mountSocket: true,
+			err:         true,
+		},
+		{
+			host: "ssh://docker.example.com",
+			err:  true,
+		},
+	}
+
+	defer func(c config.Conf) { config.Config = c }(config.Config)
+	for _, test := range tests {
+		config.Config.DockerHost = test.host
+		config.Config.DockerMountSocket = test.mountSocket
+
+		got, err := New()
+		if !test.err && err != nil {
+			t.Fatalf("failed to create provider: %+v", err)
+		}
+		if test.err {
+			if err == nil {
+				t.Fatalf("must be error, but not error (host: %s)", test.host)
+			}
+			continue
+		}
+		a := got.(*Provider).api
+		if diff := cmp.Diff(test.want, &api{baseURL: a.baseURL, socketPath: a.socketPath}, cmp.AllowUnexported(api{})); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestProvider_AddInstance(t *testing.T) {
+	fake := newFakeDocker()
+	p := newTestProvider(t, fake, 0)
+	ctx := context.Background()
+
+	cloudID, ipAddress, shoesType, resourceType, err := p.AddInstance(ctx, "myshoes-runner-1", "#!/bin/bash\necho setup", datastore.ResourceTypeNano, nil, "", map[string]string{"repository": "octocat/hello-world"})
+	if err != nil {
+		t.Fatalf("failed to add instance: %+v", err)
+	}
+	if cloudID != "myshoes-runner-1" || ipAddress != "172.18.0.2" || shoesType != Name || resourceType != datastore.ResourceTypeNano {
+		t.Fatalf("mismatch instance (cloud ID: %s, IP address: %s, shoes type: %s, resource type: %s)", cloudID, ipAddress, shoesType, resourceType)
+	}
+
+	fake.mu.Lock()
+	_, c := fake.lookup("myshoes-runner-1")
+	pulled := fake.pulled
+	fake.mu.Unlock()
+	if c == nil || !c.running {
+		t.Fatalf("container must be running")
+	}
+	if diff := cmp.Diff([]string{testImage + ":latest"}, pulled); diff != "" {
+		t.Errorf("mismatch pulled images (-want +got):\n%s", diff)
+	}
+	want := containerConfig{
+		Image:    testImage,
+		Hostname: "myshoes-runner-1",
+		Cmd:      []string{"bash", "-c", entrypoint},
+		Env: []string{
+			envSetupScript + "=" + base64.StdEncoding.EncodeToString([]byte("#!/bin/bash\necho setup")),
+			envRunnerUser + "=runner",
+		},
+		Labels: map[string]string{
+			labelRunnerName:                    "myshoes-runner-1",
+			labelMetadataPrefix + "repository": "octocat/hello-world",
+		},
+		HostConfig: hostConfig{NetworkMode: "myshoes"},
+	}
+	if diff := cmp.Diff(want, c.config); diff != "" {
+		t.Errorf("mismatch container config (-want +got):\n%s", diff)
+	}
+
+	// image is pulled only once
+	if _, _, _, _, err := p.AddInstance(ctx, "myshoes-runner-2", "", datastore.ResourceTypeNano, nil, "", nil); err != nil {
+		t.Fatalf("failed to add instance: %+v", err)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.pulled) != 1 {
+		t.Errorf("image must be pulled only once, but pulled %d times", len(fake.pulled))
+	}
+}
+
+func TestProvider_AddInstance_Error(t *testing.T) {
+	tests := []struct {
+		name      string
+		pullError string
+		failStart bool
+		existing  string
+	}{
+		{
+			name:      "pull",
+			pullError: "pull access denied",
+		},
+		{
+			name:      "start",
+			failStart: true,
+		},
+		{
+			name:     "conflict",
+			existing: "myshoes-runner-1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := newFakeDocker()
+			fake.pullError = test.pullError
+			fake.failStart = test.failStart
+			if test.existing != "" {
+				fake.containers["id-"+test.existing] = &fakeContainer{name: test.existing, running: true}
+			}
+			p := newTestProvider(t, fake, 0)
+
+			if _, _, _, _, err := p.AddInstance(context.Background(), "myshoes-runner-1", "", datastore.ResourceTypeNano, nil, "", nil); err == nil {
+				t.Fatalf("must be error, but not error")
+			}
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			_, c := fake.lookup("myshoes-runner-1")
+			switch {
+			case test.existing != "" && c == nil:
+				t.Errorf("existing container must not be removed")
+			case test.existing == "" && c != nil:
+				t.Errorf("container that failed to run must be removed")
+			}
+		})
+	}
+}
+
+func TestProvider_DeleteInstance(t *testing.T) {
+	fake := newFakeDocker()
+	fake.containers["id-myshoes-runner-1"] = &fakeContainer{name: "myshoes-runner-1", running: true}
+	p := newTestProvider(t, fake, 0)
+
+	for _, cloudID := range []string{"myshoes-runner-1", "myshoes-runner-1", "not-exist"} {
+		// container that already removed is ignored
+		if err := p.DeleteInstance(context.Background(), cloudID, nil, shoes.DeleteReasonJobCompleted); err != nil {
+			t.Fatalf("failed to delete instance (cloud ID: %s): %+v", cloudID, err)
+		}
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.containers) != 0 {
+		t.Fatalf("container must be removed, but %d containers exist", len(fake.containers))
+	}
+}
+
+func TestProvider_GetCapacity(t *testing.T) {
+	tests := []struct {
+		maxContainers  int
+		want           shoes.Capacity
+		wantCapability bool
+		err            error
+	}{
+		{
+			maxContainers: 0,
+			err:           shoes.ErrCapacityNotSupported,
+		},
+		{
+			maxContainers:  3,
+			want:           shoes.Capacity{Current: 2, Max: 3},
+			wantCapability: true,
+		},
+	}
+
+	for _, test := range tests {
+		fake := newFakeDocker()
+		fake.containers["id-myshoes-runner-1"] = &fakeContainer{name: "myshoes-runner-1", running: true, config: containerConfig{Labels: map[string]string{labelRunnerName: "myshoes-runner-1"}}}
+		fake.containers["id-myshoes-runner-2"] = &fakeContainer{name: "myshoes-runner-2", running: true, config: containerConfig{Labels: map[string]string{labelRunnerName: "myshoes-runner-2"}}}
+		// not managed by myshoes
+		fake.containers["id-other"] = &fakeContainer{name: "other", running: true}
+		p := newTestProvider(t, fake, test.maxContainers)
+
+		if got := p.Capabilities().Has(shoes.CapabilityCapacity); got != test.wantCapability {
+			t.Errorf("want capacity capability %t, but got %t", test.wantCapability, got)
+		}
+		got, err := p.GetCapacity(context.Background())
+		if !errors.Is(err, test.err) {
+			t.Fatalf("want error %+v, but got %+v", test.err, err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestAPI_EnsureImage(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{image: "ubuntu", want: "ubuntu:latest"},
+		{image: "ubuntu:22.04", want: "ubuntu:22.04"},
+		{image: "localhost:5000/runner", want: "localhost:5000/runner:latest"},
+		{image: "ubuntu@sha256:0123456789abcdef", want: "ubuntu@sha256:0123456789abcdef"},
+	}
+
+	for _, test := range tests {
+		fake := newFakeDocker()
+		ts := httptest.NewServer(fake)
+		a, err := newAPI("tcp://" + ts.Listener.Addr().String())
+		if err != nil {
+			t.Fatalf("failed to create api: %+v", err)
+		}
+
+		if err := a.ensureImage(context.Background(), test.image); err != nil {
+			t.Fatalf("failed to ensure image: %+v", err)
+		}
+		ts.Close()
+		if diff := cmp.Diff([]string{test.want}, fake.pulled); diff != "" {
+			t.Errorf("mismatch pulled images of %s (-want +got):\n%s", test.image, diff)
+		}
+	}
+}
+
+func TestAPI_UnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "docker.sock")
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to listen: %+v", err)
+	}
+	fake := newFakeDocker()
+	fake.containers["id-myshoes-runner-1"] = &fakeContainer{name: "myshoes-runner-1", running: true, config: containerConfig{Labels: map[string]string{labelRunnerName: "myshoes-runner-1"}}}
+	ts := httptest.NewUnstartedServer(fake)
+	ts.Listener = l
+	ts.Start()
+	defer ts.Close()
+
+	a, err := newAPI("unix://" + socketPath)
+	if err != nil {
+		t.Fatalf("failed to create api: %+v", err)
+	}
+	got, err := a.countContainers(context.Background(), labelRunnerName)
+	if err != nil {
+		t.Fatalf("failed to count containers: %+v", err)
+	}
+	if got != 1 {
+		t.Fatalf("want 1 container, but got %d", got)
+	}
+}
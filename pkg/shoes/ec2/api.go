@@ -0,0 +1,177 @@
+package ec2
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// apiVersion is version of EC2 Query API
+const apiVersion = "2016-11-15"
+
+// endpointFormat is endpoint of EC2 API, %s is region
+var endpointFormat = "https://ec2.%s.amazonaws.com/"
+
+// awsCredentials is credentials of AWS that sign requests
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// newCredentialsProvider return provider of the default credential chain of AWS SDK
+// (environment, shared config and credentials files, web identity, ECS task role, and EC2 instance profile by IMDSv2).
+// credentials are cached and refreshed before expiry.
+func newCredentialsProvider(ctx context.Context, region string) (aws.CredentialsProvider, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if cfg.Credentials == nil {
+		return nil, fmt.Errorf("AWS credentials are not found")
+	}
+	return cfg.Credentials, nil
+}
+
+// apiError is error response of EC2 API
+type apiError struct {
+	Code      string `xml:"Errors>Error>Code"`
+	Message   string `xml:"Errors>Error>Message"`
+	RequestID string `xml:"RequestID"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: %s (request ID: %s)", e.Code, e.Message, e.RequestID)
+}
+
+// api is client of EC2 Query API
+type api struct {
+	region      string
+	client      *http.Client
+	credentials aws.CredentialsProvider
+}
+
+// call invoke action of EC2 API, and decode response to out
+func (a *api) call(ctx context.Context, action string, params url.Values, out interface{}) error {
+	c, err := a.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	creds := awsCredentials{AccessKeyID: c.AccessKeyID, SecretAccessKey: c.SecretAccessKey, SessionToken: c.SessionToken}
+
+	params.Set("Action", action)
+	params.Set("Version", apiVersion)
+	body := params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(endpointFormat, a.region), strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signRequest(req, []byte(body), "ec2", a.region, creds, time.Now())
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", action, err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response of %s: %w", action, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e apiError
+		if err := xml.Unmarshal(b, &e); err != nil || e.Code == "" {
+			return fmt.Errorf("failed to call %s (status: %d): %s", action, resp.StatusCode, string(b))
+		}
+		return &e
+	}
+	if out == nil {
+		return nil
+	}
+	if err := xml.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", action, err)
+	}
+	return nil
+}
+
+// signRequest add Authorization header of Signature Version 4 to request
+func signRequest(req *http.Request, body []byte, service, region string, creds awsCredentials, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{
+		"host":       req.URL.Host,
+		"x-amz-date": amzDate,
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		headers["content-type"] = ct
+	}
+	if creds.SessionToken != "" {
+		headers["x-amz-security-token"] = creds.SessionToken
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
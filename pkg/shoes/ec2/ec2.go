@@ -0,0 +1,275 @@
+package ec2
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+	"github.com/whywaita/myshoes/pkg/shoes"
+)
+
+// Name is name of built-in provider, it is used as plugin path "builtin:ec2"
+const Name = "ec2"
+
+const (
+	// tagRunnerName is tag key of runner name, instances that have it are managed by myshoes
+	tagRunnerName = "myshoes:runner-name"
+	// tagMetadataPrefix is prefix of tag keys of job metadata (e.g. myshoes:repository)
+	tagMetadataPrefix = "myshoes:"
+)
+
+// spotUnavailableCodes is error codes that spot instance can not be launched now, instance is launched as on-demand instead
+var spotUnavailableCodes = []string{
+	"InsufficientInstanceCapacity",
+	"MaxSpotInstanceCountExceeded",
+	"SpotMaxPriceTooLow",
+}
+
+// Provider is built-in shoes-provider that launch EC2 instances from a launch template
+type Provider struct {
+	api *api
+
+	launchTemplate        string
+	launchTemplateVersion string
+	instanceTypes         map[datastore.ResourceType]string
+	spot                  bool
+	tags                  map[string]string
+	maxInstances          int
+}
+
+// placementParams is placement parameters of target that Provider supports
+type placementParams struct {
+	SubnetID         string   `json:"subnet_id"`
+	SecurityGroupIDs []string `json:"security_group_ids"`
+	Zone             string   `json:"zone"`
+	InstanceType     string   `json:"instance_type"` // overrides EC2_INSTANCE_TYPES
+}
+
+// New create Provider from EC2_* configuration
+func New() (shoes.Client, error) {
+	c := config.Config
+	if c.EC2Region == "" {
+		return nil, fmt.Errorf("%s or AWS_REGION must be set", config.EnvEC2Region)
+	}
+	if c.EC2LaunchTemplate == "" {
+		return nil, fmt.Errorf("%s must be set", config.EnvEC2LaunchTemplate)
+	}
+	// fail fast if no credentials are available in the chain
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	credentials, err := newCredentialsProvider(ctx, c.EC2Region)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := credentials.Retrieve(ctx); err != nil {
+		return nil, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	instanceTypes := map[datastore.ResourceType]string{}
+	for rt, instanceType := range c.EC2InstanceTypes {
+		resourceType := datastore.UnmarshalResourceTypeString(rt)
+		if resourceType == datastore.ResourceTypeUnknown {
+			return nil, fmt.Errorf("invalid resource type in %s: %s", config.EnvEC2InstanceTypes, rt)
+		}
+		instanceTypes[resourceType] = instanceType
+	}
+
+	return &Provider{
+		api: &api{
+			region:      c.EC2Region,
+			client:      &http.Client{Timeout: 1 * time.Minute},
+			credentials: credentials,
+		},
+		launchTemplate:        c.EC2LaunchTemplate,
+		launchTemplateVersion: c.EC2LaunchTemplateVersion,
+		instanceTypes:         instanceTypes,
+		spot:                  c.EC2Spot,
+		tags:                  c.EC2Tags,
+		maxInstances:          c.EC2MaxInstances,
+	}, nil
+}
+
+// Capabilities return capabilities of Provider, capacity is reported only if EC2_MAX_INSTANCES is set
+func (p *Provider) Capabilities() shoes.Capabilities {
+	if p.maxInstances > 0 {
+		return shoes.NewCapabilities(shoes.CapabilityJobMetadata, shoes.CapabilityCapacity)
+	}
+	return shoes.NewCapabilities(shoes.CapabilityJobMetadata)
+}
+
+// AddInstance launch an instance from launch template
+func (p *Provider) AddInstance(ctx context.Context, runnerName, setupScript string, resourceType datastore.ResourceType, labels []string, placementParamsJSON string, metadata map[string]string) (string, string, string, datastore.ResourceType, error) {
+	var placement placementParams
+	if placementParamsJSON != "" {
+		if err := json.Unmarshal([]byte(placementParamsJSON), &placement); err != nil {
+			return "", "", "", datastore.ResourceTypeUnknown, fmt.Errorf("failed to unmarshal placement params: %w", err)
+		}
+	}
+
+	params := p.runInstancesParams(runnerName, setupScript, resourceType, placement, metadata)
+	if p.spot {
+		params.Set("InstanceMarketOptions.MarketType", "spot")
+	}
+
+	var resp runInstancesResponse
+	err := p.api.call(ctx, "RunInstances", params, &resp)
+	var apiErr *apiError
+	if p.spot && errors.As(err, &apiErr) && isSpotUnavailable(apiErr.Code) {
+		logger.Logf(false, "spot instance is not available, launch on-demand instance instead (runner: %s): %+v", runnerName, err)
+		params.Del("InstanceMarketOptions.MarketType")
+		resp = runInstancesResponse{}
+		err = p.api.call(ctx, "RunInstances", params, &resp)
+	}
+	if err != nil {
+		return "", "", "", datastore.ResourceTypeUnknown, fmt.Errorf("failed to run instance: %w", err)
+	}
+	if len(resp.Instances) == 0 {
+		return "", "", "", datastore.ResourceTypeUnknown, fmt.Errorf("failed to run instance: response has no instance (request ID: %s)", resp.RequestID)
+	}
+
+	instance := resp.Instances[0]
+	return instance.InstanceID, instance.PrivateIPAddress, Name, resourceType, nil
+}
+
+func (p *Provider) runInstancesParams(runnerName, setupScript string, resourceType datastore.ResourceType, placement placementParams, metadata map[string]string) url.Values {
+	params := url.Values{}
+	params.Set("MinCount", "1")
+	params.Set("MaxCount", "1")
+	if strings.HasPrefix(p.launchTemplate, "lt-") {
+		params.Set("LaunchTemplate.LaunchTemplateId", p.launchTemplate)
+	} else {
+		params.Set("LaunchTemplate.LaunchTemplateName", p.launchTemplate)
+	}
+	params.Set("LaunchTemplate.Version", p.launchTemplateVersion)
+	params.Set("UserData", base64.StdEncoding.EncodeToString([]byte(setupScript)))
+
+	instanceType := p.instanceTypes[resourceType]
+	if placement.InstanceType != "" {
+		instanceType = placement.InstanceType
+	}
+	if instanceType != "" {
+		params.Set("InstanceType", instanceType)
+	}
+	if placement.SubnetID != "" {
+		params.Set("SubnetId", placement.SubnetID)
+	}
+	for i, sg := range placement.SecurityGroupIDs {
+		params.Set(fmt.Sprintf("SecurityGroupId.%d", i+1), sg)
+	}
+	if placement.Zone != "" {
+		params.Set("Placement.AvailabilityZone", placement.Zone)
+	}
+
+	tags := map[string]string{
+		"Name":        runnerName,
+		tagRunnerName: runnerName,
+	}
+	for k, v := range metadata {
+		tags[tagMetadataPrefix+k] = v
+	}
+	for k, v := range p.tags {
+		tags[k] = v
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, resource := range []string{"instance", "volume"} {
+		prefix := fmt.Sprintf("TagSpecification.%d.", i+1)
+		params.Set(prefix+"ResourceType", resource)
+		for j, k := range keys {
+			params.Set(fmt.Sprintf("%sTag.%d.Key", prefix, j+1), k)
+			params.Set(fmt.Sprintf("%sTag.%d.Value", prefix, j+1), tags[k])
+		}
+	}
+	return params
+}
+
+// DeleteInstance terminate an instance, instance that already terminated is ignored
+func (p *Provider) DeleteInstance(ctx context.Context, cloudID string, labels []string, reason shoes.DeleteReason) error {
+	params := url.Values{}
+	params.Set("InstanceId.1", cloudID)
+	err := p.api.call(ctx, "TerminateInstances", params, nil)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.Code == "InvalidInstanceID.NotFound" {
+		logger.Logf(false, "instance is already terminated (cloud ID: %s)", cloudID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to terminate instance (reason: %s): %w", reason, err)
+	}
+	return nil
+}
+
+// GetCapacity count running instances that managed by myshoes
+func (p *Provider) GetCapacity(ctx context.Context) (shoes.Capacity, error) {
+	if p.maxInstances <= 0 {
+		return shoes.Capacity{}, shoes.ErrCapacityNotSupported
+	}
+
+	var current uint32
+	var nextToken string
+	for {
+		params := url.Values{}
+		params.Set("Filter.1.Name", "tag-key")
+		params.Set("Filter.1.Value.1", tagRunnerName)
+		params.Set("Filter.2.Name", "instance-state-name")
+		params.Set("Filter.2.Value.1", "pending")
+		params.Set("Filter.2.Value.2", "running")
+		params.Set("MaxResults", "1000")
+		if nextToken != "" {
+			params.Set("NextToken", nextToken)
+		}
+
+		var resp describeInstancesResponse
+		if err := p.api.call(ctx, "DescribeInstances", params, &resp); err != nil {
+			return shoes.Capacity{}, fmt.Errorf("failed to describe instances: %w", err)
+		}
+		for _, r := range resp.Reservations {
+			current += uint32(len(r.Instances))
+		}
+		if resp.NextToken == "" {
+			break
+		}
+		nextToken = resp.NextToken
+	}
+
+	return shoes.Capacity{Current: current, Max: uint32(p.maxInstances)}, nil
+}
+
+func isSpotUnavailable(code string) bool {
+	for _, c := range spotUnavailableCodes {
+		if code == c {
+			return true
+		}
+	}
+	return false
+}
+
+type instance struct {
+	InstanceID       string `xml:"instanceId"`
+	PrivateIPAddress string `xml:"privateIpAddress"`
+}
+
+type runInstancesResponse struct {
+	RequestID string     `xml:"requestId"`
+	Instances []instance `xml:"instancesSet>item"`
+}
+
+type describeInstancesResponse struct {
+	Reservations []struct {
+		Instances []instance `xml:"instancesSet>item"`
+	} `xml:"reservationSet>item"`
+	NextToken string `xml:"nextToken"`
+}
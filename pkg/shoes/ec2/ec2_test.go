@@ -0,0 +1,237 @@
+package ec2
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/google/go-cmp/cmp"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+func TestProvider_runInstancesParams(t *testing.T) {
+	p := &Provider{
+		launchTemplate:        "lt-0123456789abcdef0",
+		launchTemplateVersion: "$Latest",
+		instanceTypes: map[datastore.ResourceType]string{
+			datastore.ResourceTypeNano:  "t3.nano",
+			datastore.ResourceTypeLarge: "c5.large",
+		},
+		tags: map[string]string{"team": "ci"},
+	}
+
+	tests := []struct {
+		name         string
+		resourceType datastore.ResourceType
+		placement    placementParams
+		want         map[string]string
+		notWant      []string
+	}{
+		{
+			name:         "instance type from resource type",
+			resourceType: datastore.ResourceTypeLarge,
+			want: map[string]string{
+				"InstanceType": "c5.large",
+			},
+			notWant: []string{"SubnetId", "SecurityGroupId.1", "Placement.AvailabilityZone"},
+		},
+		{
+			name:         "unknown resource type uses launch template",
+			resourceType: datastore.ResourceTypeXLarge,
+			notWant:      []string{"InstanceType"},
+		},
+		{
+			name:         "placement overrides instance type",
+			resourceType: datastore.ResourceTypeNano,
+			placement: placementParams{
+				SubnetID:         "subnet-1",
+				SecurityGroupIDs: []string{"sg-1", "sg-2"},
+				Zone:             "ap-northeast-1a",
+				InstanceType:     "m5.xlarge",
+			},
+			want: map[string]string{
+				"InstanceType":               "m5.xlarge",
+				"SubnetId":                   "subnet-1",
+				"SecurityGroupId.1":          "sg-1",
+				"SecurityGroupId.2":          "sg-2",
+				"Placement.AvailabilityZone": "ap-northeast-1a",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := p.runInstancesParams("myshoes-runner", "#!/bin/bash", test.resourceType, test.placement, map[string]string{"repository": "octocat/hello-world"})
+
+			common := map[string]string{
+				"MinCount":                        "1",
+				"MaxCount":                        "1",
+				"LaunchTemplate.LaunchTemplateId": "lt-0123456789abcdef0",
+				"LaunchTemplate.Version":          "$Latest",
+				"UserData":                        base64.StdEncoding.EncodeToString([]byte("#!/bin/bash")),
+			}
+			for k, v := range common {
+				if got := params.Get(k); got != v {
+					t.Errorf("mismatch %s (want: %s, got: %s)", k, v, got)
+				}
+			}
+			for k, v := range test.want {
+				if got := params.Get(k); got != v {
+					t.Errorf("mismatch %s (want: %s, got: %s)", k, v, got)
+				}
+			}
+			for _, k := range test.notWant {
+				if params.Has(k) {
+					t.Errorf("%s must not be set (got: %s)", k, params.Get(k))
+				}
+			}
+		})
+	}
+}
+
+func TestProvider_runInstancesParams_Tags(t *testing.T) {
+	p := &Provider{
+		launchTemplate: "myshoes-template",
+		tags:           map[string]string{"team": "ci", "Name": "overridden"},
+	}
+	params := p.runInstancesParams("myshoes-runner", "", datastore.ResourceTypeNano, placementParams{}, map[string]string{"repository": "octocat/hello-world"})
+
+	if got := params.Get("LaunchTemplate.LaunchTemplateName"); got != "myshoes-template" {
+		t.Errorf("mismatch LaunchTemplate.LaunchTemplateName (got: %s)", got)
+	}
+
+	// tags are sorted by key, and EC2_TAGS overrides default tags
+	want := map[string]string{
+		"Name":                "overridden",
+		"myshoes:repository":  "octocat/hello-world",
+		"myshoes:runner-name": "myshoes-runner",
+		"team":                "ci",
+	}
+	wantKeys := []string{"Name", "myshoes:repository", "myshoes:runner-name", "team"}
+	for i, resource := range []string{"instance", "volume"} {
+		prefix := fmt.Sprintf("TagSpecification.%d.", i+1)
+		if got := params.Get(prefix + "ResourceType"); got != resource {
+			t.Errorf("mismatch %sResourceType (want: %s, got: %s)", prefix, resource, got)
+		}
+		var gotKeys []string
+		got := map[string]string{}
+		for j := 1; params.Has(fmt.Sprintf("%sTag.%d.Key", prefix, j)); j++ {
+			k := params.Get(fmt.Sprintf("%sTag.%d.Key", prefix, j))
+			gotKeys = append(gotKeys, k)
+			got[k] = params.Get(fmt.Sprintf("%sTag.%d.Value", prefix, j))
+		}
+		if diff := cmp.Diff(wantKeys, gotKeys); diff != "" {
+			t.Errorf("mismatch tag keys of %s (-want +got):\n%s", resource, diff)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch tags of %s (-want +got):\n%s", resource, diff)
+		}
+	}
+}
+
+func TestProvider_AddInstance_SpotFallback(t *testing.T) {
+	tests := []struct {
+		name        string
+		spotError   string
+		wantErr     bool
+		wantCalls   []string // market type of each RunInstances
+		wantCloudID string
+	}{
+		{
+			name:        "spot is launched",
+			wantCalls:   []string{"spot"},
+			wantCloudID: "i-1",
+		},
+		{
+			name:        "fallback to on-demand if capacity is insufficient",
+			spotError:   "InsufficientInstanceCapacity",
+			wantCalls:   []string{"spot", ""},
+			wantCloudID: "i-1",
+		},
+		{
+			name:      "other error is not fallback",
+			spotError: "UnauthorizedOperation",
+			wantErr:   true,
+			wantCalls: []string{"spot"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var calls []string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+					t.Errorf("request is not signed by provided credentials: %s", r.Header.Get("Authorization"))
+				}
+				if got := r.Header.Get("X-Amz-Security-Token"); got != "session-token" {
+					t.Errorf("mismatch X-Amz-Security-Token (got: %s)", got)
+				}
+				if err := r.ParseForm(); err != nil {
+					t.Errorf("failed to parse form: %+v", err)
+					return
+				}
+				if got := r.PostForm.Get("Action"); got != "RunInstances" {
+					t.Errorf("unexpected action: %s", got)
+				}
+				marketType := r.PostForm.Get("InstanceMarketOptions.MarketType")
+				mu.Lock()
+				calls = append(calls, marketType)
+				mu.Unlock()
+
+				if marketType == "spot" && test.spotError != "" {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`<Response><Errors><Error><Code>` + test.spotError + `</Code><Message>error</Message></Error></Errors><RequestID>req-1</RequestID></Response>`))
+					return
+				}
+				_, _ = w.Write([]byte(`<RunInstancesResponse><requestId>req-2</requestId><instancesSet><item><instanceId>i-1</instanceId><privateIpAddress>10.0.0.1</privateIpAddress></item></instancesSet></RunInstancesResponse>`))
+			}))
+			defer ts.Close()
+			setEndpoint(t, ts.URL)
+
+			p := &Provider{
+				api: &api{
+					region: "ap-northeast-1",
+					client: ts.Client(),
+					credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+						return aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret", SessionToken: "session-token"}, nil
+					}),
+				},
+				launchTemplate: "lt-0123456789abcdef0",
+				spot:           true,
+			}
+
+			cloudID, ipAddress, shoesType, _, err := p.AddInstance(context.Background(), "myshoes-runner", "", datastore.ResourceTypeNano, nil, "", nil)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("must be error")
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("failed to add instance: %+v", err)
+				}
+				if cloudID != test.wantCloudID || ipAddress != "10.0.0.1" || shoesType != Name {
+					t.Errorf("mismatch instance (cloud ID: %s, IP address: %s, shoes type: %s)", cloudID, ipAddress, shoesType)
+				}
+			}
+			if diff := cmp.Diff(test.wantCalls, calls); diff != "" {
+				t.Errorf("mismatch RunInstances calls (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// setEndpoint replace endpoint of EC2 API to test server
+func setEndpoint(t *testing.T, u string) {
+	t.Helper()
+
+	old := endpointFormat
+	t.Cleanup(func() { endpointFormat = old })
+	endpointFormat = u + "/%s/"
+}
@@ -0,0 +1,39 @@
+package shoes
+
+import (
+	pb "github.com/whywaita/myshoes/api/proto.go"
+)
+
+// DeleteReason is reason of deleting instance, it is passed to shoes-provider
+type DeleteReason string
+
+// DeleteReason values
+const (
+	// DeleteReasonJobCompleted is runner that completed a job
+	DeleteReasonJobCompleted DeleteReason = "job_completed"
+	// DeleteReasonZombie is runner that not registered in GitHub (e.g. failed to start)
+	DeleteReasonZombie DeleteReason = "zombie"
+	// DeleteReasonTTL is runner that idle over limit or reached TTL of request
+	DeleteReasonTTL DeleteReason = "ttl"
+	// DeleteReasonDrain is runner that target is deleted
+	DeleteReasonDrain DeleteReason = "drain"
+	// DeleteReasonManual is runner that deleted by administrator
+	DeleteReasonManual DeleteReason = "manual"
+)
+
+// ToPb convert to type of protobuf
+func (r DeleteReason) ToPb() pb.DeleteReason {
+	switch r {
+	case DeleteReasonJobCompleted:
+		return pb.DeleteReason_JobCompleted
+	case DeleteReasonZombie:
+		return pb.DeleteReason_Zombie
+	case DeleteReasonTTL:
+		return pb.DeleteReason_TTL
+	case DeleteReasonDrain:
+		return pb.DeleteReason_Drain
+	case DeleteReasonManual:
+		return pb.DeleteReason_Manual
+	}
+	return pb.DeleteReason_UnknownReason
+}
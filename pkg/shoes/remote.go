@@ -0,0 +1,164 @@
+package shoes
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	pb "github.com/whywaita/myshoes/api/proto.go"
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/logger"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// remote keep a connection to plugin server that running remotely (grpc://host:port).
+// the server is not managed by myshoes, so it is not restarted. connection is re-established and re-negotiated if not healthy
+type remote struct {
+	pluginPath  string
+	dialOptions []grpc.DialOption // additional options of dial (e.g. dialer in tests)
+
+	mu    sync.Mutex
+	conn  *grpc.ClientConn
+	shoes *GRPCClient
+}
+
+var (
+	remotesMu sync.Mutex
+	// remotes is connection per remote plugin, key: plugin path
+	remotes = map[string]*remote{}
+)
+
+// remoteOf get remote of plugin path, it is created at first time
+func remoteOf(pluginPath string) *remote {
+	remotesMu.Lock()
+	defer remotesMu.Unlock()
+
+	r, ok := remotes[pluginPath]
+	if !ok {
+		r = &remote{pluginPath: pluginPath}
+		remotes[pluginPath] = r
+	}
+	return r
+}
+
+func listRemotes() []*remote {
+	remotesMu.Lock()
+	defer remotesMu.Unlock()
+
+	r := make([]*remote, 0, len(remotes))
+	for _, rem := range remotes {
+		r = append(r, rem)
+	}
+	return r
+}
+
+// get return client of plugin server, connect and negotiate if not connected
+func (r *remote) get() (Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.shoes != nil {
+		return r.shoes, nil
+	}
+
+	conn, err := dialRemote(r.pluginPath, r.dialOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to remote plugin (%s): %w", r.pluginPath, err)
+	}
+	shoes := &GRPCClient{client: pb.NewShoesClient(conn)}
+	ctx, cancel := context.WithTimeout(context.Background(), PingTimeout)
+	defer cancel()
+	capabilities, err := negotiate(ctx, shoes.client)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to negotiate with shoes-provider (%s): %w", r.pluginPath, err)
+	}
+	shoes.capabilities = capabilities
+	logger.Logf(false, "connect to remote plugin (%s), protocol version: %d", r.pluginPath, capabilities.ProtocolVersion)
+
+	r.conn = conn
+	r.shoes = shoes
+	return shoes, nil
+}
+
+// check negotiate with plugin server again, and close connection if not healthy. it is reconnected in next get
+func (r *remote) check() {
+	r.mu.Lock()
+	shoes := r.shoes
+	r.mu.Unlock()
+	if shoes == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), PingTimeout)
+	defer cancel()
+	_, err := negotiate(ctx, shoes.client)
+	if err == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.shoes != shoes {
+		// already reconnected
+		return
+	}
+	logger.Logf(false, "ALERT: remote plugin (%s) is not healthy, will reconnect: %+v", r.pluginPath, err)
+	r.closeLocked()
+}
+
+// closeLocked close connection to plugin server, r.mu must be locked
+func (r *remote) closeLocked() {
+	if r.conn != nil {
+		r.conn.Close()
+	}
+	r.conn = nil
+	r.shoes = nil
+}
+
+// dialRemote create connection to plugin server, grpcs:// uses TLS that configured by PLUGIN_TLS_*
+func dialRemote(pluginPath string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if strings.HasPrefix(pluginPath, config.RemotePluginTLSScheme) {
+		tlsConfig, err := remoteTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+		return grpc.Dial(strings.TrimPrefix(pluginPath, config.RemotePluginTLSScheme), opts...)
+	}
+	opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	return grpc.Dial(strings.TrimPrefix(pluginPath, config.RemotePluginScheme), opts...)
+}
+
+// remoteTLSConfig create TLS config for plugin server, server is verified by system root CAs if PLUGIN_TLS_CA_PATH is not set
+func remoteTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if config.Config.ShoesPluginTLSCAPath != "" {
+		ca, err := os.ReadFile(config.Config.ShoesPluginTLSCAPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("failed to parse CA certificate (path: %s)", config.Config.ShoesPluginTLSCAPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.Config.ShoesPluginTLSCertPath != "" {
+		cert, err := tls.LoadX509KeyPair(config.Config.ShoesPluginTLSCertPath, config.Config.ShoesPluginTLSKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,313 @@
+package shoes
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/whywaita/myshoes/api/proto.go"
+	"github.com/whywaita/myshoes/pkg/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeShoesServer is plugin server that running remotely
+type fakeShoesServer struct {
+	pb.UnimplementedShoesServer
+
+	mu                 sync.Mutex
+	healthy            bool
+	minProtocolVersion uint32
+	negotiated         int
+}
+
+func (s *fakeShoesServer) GetCapabilities(ctx context.Context, req *pb.GetCapabilitiesRequest) (*pb.GetCapabilitiesResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.negotiated++
+	if !s.healthy {
+		return nil, status.Error(codes.Unavailable, "shoes-provider is not healthy")
+	}
+	return &pb.GetCapabilitiesResponse{
+		ProtocolVersion:    ProtocolVersion,
+		MinProtocolVersion: s.minProtocolVersion,
+		Capabilities:       []string{string(CapabilityCapacity)},
+	}, nil
+}
+
+func (s *fakeShoesServer) GetCapacity(ctx context.Context, req *pb.GetCapacityRequest) (*pb.GetCapacityResponse, error) {
+	return &pb.GetCapacityResponse{CurrentCapacity: 3, MaxCapacity: 5}, nil
+}
+
+func (s *fakeShoesServer) setHealthy(healthy bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.healthy = healthy
+}
+
+func (s *fakeShoesServer) getNegotiated() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.negotiated
+}
+
+// serveFakeShoes serve fake in-process, return remote that connect to it by pluginPath
+func serveFakeShoes(t *testing.T, fake *fakeShoesServer, pluginPath string, opts ...grpc.ServerOption) *remote {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer(opts...)
+	pb.RegisterShoesServer(s, fake)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	r := &remote{
+		pluginPath: pluginPath,
+		dialOptions: []grpc.DialOption{
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return lis.DialContext(ctx)
+			}),
+		},
+	}
+	t.Cleanup(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.closeLocked()
+	})
+	return r
+}
+
+func TestRemote_Get(t *testing.T) {
+	fake := &fakeShoesServer{healthy: true}
+	r := serveFakeShoes(t, fake, config.RemotePluginScheme+"bufnet")
+
+	client, err := r.get()
+	if err != nil {
+		t.Fatalf("failed to get client: %+v", err)
+	}
+	if got := client.Capabilities(); got.ProtocolVersion != ProtocolVersion || !got.Has(CapabilityCapacity) {
+		t.Fatalf("want negotiated capabilities, but got %+v", got)
+	}
+	capacity, err := client.GetCapacity(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get capacity: %+v", err)
+	}
+	if capacity.Current != 3 || capacity.Max != 5 {
+		t.Fatalf("want capacity 3/5, but got %d/%d", capacity.Current, capacity.Max)
+	}
+
+	// connection is shared
+	again, err := r.get()
+	if err != nil {
+		t.Fatalf("failed to get client: %+v", err)
+	}
+	if again != client {
+		t.Fatalf("connection must be reused, but reconnected")
+	}
+	if n := fake.getNegotiated(); n != 1 {
+		t.Fatalf("want negotiated once, but got %d", n)
+	}
+}
+
+func TestRemote_Get_Incompatible(t *testing.T) {
+	fake := &fakeShoesServer{healthy: true, minProtocolVersion: ProtocolVersion + 1}
+	r := serveFakeShoes(t, fake, config.RemotePluginScheme+"bufnet")
+
+	if _, err := r.get(); !errors.Is(err, ErrIncompatibleProtocol) {
+		t.Fatalf("want ErrIncompatibleProtocol, but got %+v", err)
+	}
+	if r.shoes != nil || r.conn != nil {
+		t.Fatalf("connection must be closed if negotiation is failed")
+	}
+}
+
+func TestRemote_Check(t *testing.T) {
+	fake := &fakeShoesServer{healthy: true}
+	r := serveFakeShoes(t, fake, config.RemotePluginScheme+"bufnet")
+
+	client, err := r.get()
+	if err != nil {
+		t.Fatalf("failed to get client: %+v", err)
+	}
+
+	// healthy, connection is kept
+	r.check()
+	if got, err := r.get(); err != nil || got != client {
+		t.Fatalf("connection must be kept while healthy (err: %+v)", err)
+	}
+
+	// not healthy, connection is closed
+	fake.setHealthy(false)
+	r.check()
+	if r.shoes != nil {
+		t.Fatalf("connection must be closed if not healthy")
+	}
+	if _, err := r.get(); err == nil {
+		t.Fatalf("must be error while not healthy, but not error")
+	}
+
+	// reconnect and negotiate again after recovery
+	fake.setHealthy(true)
+	reconnected, err := r.get()
+	if err != nil {
+		t.Fatalf("failed to reconnect: %+v", err)
+	}
+	if reconnected == client {
+		t.Fatalf("want new connection, but got old one")
+	}
+	if !reconnected.Capabilities().Has(CapabilityCapacity) {
+		t.Fatalf("capabilities must be negotiated again, but got %+v", reconnected.Capabilities())
+	}
+}
+
+func TestRemote_Get_TLS(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeTestCertificate(t, dir, "bufnet")
+	serverCert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("failed to load certificate: %+v", err)
+	}
+
+	defer func(c config.Conf) { config.Config = c }(config.Config)
+	config.Config.ShoesPluginTLSCAPath = certPath
+
+	fake := &fakeShoesServer{healthy: true}
+	r := serveFakeShoes(t, fake, config.RemotePluginTLSScheme+"bufnet", grpc.Creds(credentials.NewServerTLSFromCert(&serverCert)))
+
+	client, err := r.get()
+	if err != nil {
+		t.Fatalf("failed to get client over TLS: %+v", err)
+	}
+	if !client.Capabilities().Has(CapabilityCapacity) {
+		t.Fatalf("want negotiated capabilities, but got %+v", client.Capabilities())
+	}
+}
+
+func TestRemoteTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeTestCertificate(t, dir, "shoes.example.com")
+	invalidPath := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalidPath, []byte("invalid"), 0600); err != nil {
+		t.Fatalf("failed to write file: %+v", err)
+	}
+
+	tests := []struct {
+		caPath    string
+		certPath  string
+		keyPath   string
+		wantRoots bool
+		wantCerts int
+		err       bool
+	}{
+		{
+			// system root CAs
+		},
+		{
+			caPath:    certPath,
+			wantRoots: true,
+		},
+		{
+			caPath:    certPath,
+			certPath:  certPath,
+			keyPath:   keyPath,
+			wantRoots: true,
+			wantCerts: 1,
+		},
+		{
+			caPath: invalidPath,
+			err:    true,
+		},
+		{
+			caPath: filepath.Join(dir, "not-exist.pem"),
+			err:    true,
+		},
+		{
+			certPath: certPath,
+			keyPath:  invalidPath,
+			err:      true,
+		},
+	}
+
+	defer func(c config.Conf) { config.Config = c }(config.Config)
+	for _, test := range tests {
+		config.Config.ShoesPluginTLSCAPath = test.caPath
+		config.Config.ShoesPluginTLSCertPath = test.certPath
+		config.Config.ShoesPluginTLSKeyPath = test.keyPath
+
+		got, err := remoteTLSConfig()
+		if !test.err && err != nil {
+			t.Fatalf("failed to create TLS config: %+v", err)
+		}
+		if test.err {
+			if err == nil {
+				t.Fatalf("must be error, but not error (ca: %s, cert: %s, key: %s)", test.caPath, test.certPath, test.keyPath)
+			}
+			continue
+		}
+		if got.MinVersion != tls.VersionTLS12 {
+			t.Errorf("want min version TLS 1.2, but got %x", got.MinVersion)
+		}
+		if (got.RootCAs != nil) != test.wantRoots {
+			t.Errorf("want root CAs is set %t, but got %t (ca: %s)", test.wantRoots, got.RootCAs != nil, test.caPath)
+		}
+		if len(got.Certificates) != test.wantCerts {
+			t.Errorf("want %d client certificates, but got %d", test.wantCerts, len(got.Certificates))
+		}
+	}
+}
+
+// writeTestCertificate write self-signed certificate for host and key of it into dir
+func writeTestCertificate(t *testing.T, dir, host string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %+v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: host},
+		DNSNames:              []string{host},
+		NotBefore:             time.Now().Add(-1 * time.Hour),
+		NotAfter:              time.Now().Add(1 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %+v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %+v", err)
+	}
+
+	certPath := filepath.Join(dir, host+".crt")
+	keyPath := filepath.Join(dir, host+".key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("failed to write certificate: %+v", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("failed to write key: %+v", err)
+	}
+	return certPath, keyPath
+}
@@ -0,0 +1,52 @@
+package shoes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// ValidatePluginRoutes check resource types in PLUGIN_ROUTES
+func ValidatePluginRoutes() error {
+	for _, r := range config.Config.ShoesPluginRoutes {
+		if r.Kind == config.ShoesPluginRouteResourceType && datastore.UnmarshalResourceTypeString(r.Value) == datastore.ResourceTypeUnknown {
+			return fmt.Errorf("invalid resource type of route to %s: %s", r.Plugin, r.Value)
+		}
+	}
+	return nil
+}
+
+// PluginFor return name of plugin that handles instance of job, empty is default plugin.
+// routes in PLUGIN_ROUTES are evaluated in order, and first matched route is used.
+// route of target matches scope of target and repositories in it (e.g. octo-org matches octo-org/octo-repo)
+func PluginFor(scope string, resourceType datastore.ResourceType, labels []string) string {
+	for _, r := range config.Config.ShoesPluginRoutes {
+		if matchRoute(r, scope, resourceType, labels) {
+			return r.Plugin
+		}
+	}
+	return ""
+}
+
+func matchRoute(r config.ShoesPluginRoute, scope string, resourceType datastore.ResourceType, labels []string) bool {
+	switch r.Kind {
+	case config.ShoesPluginRouteTarget:
+		return strings.EqualFold(scope, r.Value) || strings.HasPrefix(strings.ToLower(scope), strings.ToLower(r.Value)+"/")
+	case config.ShoesPluginRouteResourceType:
+		return resourceType != datastore.ResourceTypeUnknown && resourceType == datastore.UnmarshalResourceTypeString(r.Value)
+	case config.ShoesPluginRouteLabel:
+		for _, l := range labels {
+			if strings.EqualFold(l, r.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetClientFor retrieve ShoesClient use shoes-plugin that routed by scope, resource type and labels of job
+func GetClientFor(scope string, resourceType datastore.ResourceType, labels []string) (Client, func(), error) {
+	return GetClientByName(PluginFor(scope, resourceType, labels))
+}
@@ -0,0 +1,150 @@
+package shoes
+
+import (
+	"testing"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+func TestPluginFor(t *testing.T) {
+	old := config.Config.ShoesPluginRoutes
+	t.Cleanup(func() { config.Config.ShoesPluginRoutes = old })
+	config.Config.ShoesPluginRoutes = []config.ShoesPluginRoute{
+		{Kind: config.ShoesPluginRouteLabel, Value: "windows", Plugin: "windows"},
+		{Kind: config.ShoesPluginRouteTarget, Value: "octo-org/special", Plugin: "special"},
+		{Kind: config.ShoesPluginRouteTarget, Value: "octo-org", Plugin: "org"},
+		{Kind: config.ShoesPluginRouteResourceType, Value: "2xlarge", Plugin: "large"},
+	}
+
+	tests := []struct {
+		name         string
+		scope        string
+		resourceType datastore.ResourceType
+		labels       []string
+		want         string
+	}{
+		{
+			name:  "target matches scope of organization",
+			scope: "octo-org",
+			want:  "org",
+		},
+		{
+			name:  "target of organization matches repository in it",
+			scope: "octo-org/octo-repo",
+			want:  "org",
+		},
+		{
+			name:  "target is case insensitive",
+			scope: "Octo-Org/Octo-Repo",
+			want:  "org",
+		},
+		{
+			name:  "target of organization does not match other organization that has same prefix",
+			scope: "octo-org2/octo-repo",
+			want:  "",
+		},
+		{
+			name:  "target of repository matches only the repository",
+			scope: "octo-org/special",
+			want:  "special",
+		},
+		{
+			name:  "target of repository does not match other repository that has same prefix",
+			scope: "octo-org/special-repo",
+			want:  "org",
+		},
+		{
+			name:         "resource type",
+			scope:        "other-org/repo",
+			resourceType: datastore.ResourceType2XLarge,
+			want:         "large",
+		},
+		{
+			name:         "other resource type",
+			scope:        "other-org/repo",
+			resourceType: datastore.ResourceTypeLarge,
+			want:         "",
+		},
+		{
+			name:   "label is case insensitive",
+			scope:  "other-org/repo",
+			labels: []string{"self-hosted", "Windows"},
+			want:   "windows",
+		},
+		{
+			name:         "first matched route wins",
+			scope:        "octo-org/special",
+			resourceType: datastore.ResourceType2XLarge,
+			labels:       []string{"windows"},
+			want:         "windows",
+		},
+		{
+			name:         "target is prior to resource type by order",
+			scope:        "octo-org/octo-repo",
+			resourceType: datastore.ResourceType2XLarge,
+			want:         "org",
+		},
+		{
+			name:         "default plugin if no route is matched",
+			scope:        "other-org/repo",
+			resourceType: datastore.ResourceTypeNano,
+			labels:       []string{"self-hosted", "linux"},
+			want:         "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := PluginFor(test.scope, test.resourceType, test.labels); got != test.want {
+				t.Errorf("want %q, but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestPluginFor_NoRoute(t *testing.T) {
+	old := config.Config.ShoesPluginRoutes
+	t.Cleanup(func() { config.Config.ShoesPluginRoutes = old })
+	config.Config.ShoesPluginRoutes = nil
+
+	if got := PluginFor("octo-org/octo-repo", datastore.ResourceTypeLarge, []string{"windows"}); got != "" {
+		t.Errorf("want default plugin, but got %q", got)
+	}
+}
+
+func TestMatchRoute(t *testing.T) {
+	tests := []struct {
+		name         string
+		route        config.ShoesPluginRoute
+		resourceType datastore.ResourceType
+		want         bool
+	}{
+		{
+			name:         "unknown resource type does not match invalid value",
+			route:        config.ShoesPluginRoute{Kind: config.ShoesPluginRouteResourceType, Value: "invalid"},
+			resourceType: datastore.ResourceTypeUnknown,
+			want:         false,
+		},
+		{
+			name:         "resource type",
+			route:        config.ShoesPluginRoute{Kind: config.ShoesPluginRouteResourceType, Value: "nano"},
+			resourceType: datastore.ResourceTypeNano,
+			want:         true,
+		},
+		{
+			name:         "unknown kind",
+			route:        config.ShoesPluginRoute{Kind: "unknown", Value: "octo-org"},
+			resourceType: datastore.ResourceTypeNano,
+			want:         false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := matchRoute(test.route, "octo-org", test.resourceType, nil); got != test.want {
+				t.Errorf("want %t, but got %t", test.want, got)
+			}
+		})
+	}
+}
@@ -3,8 +3,6 @@ package shoes
 import (
 	"context"
 	"fmt"
-	"os"
-	"os/exec"
 
 	"github.com/hashicorp/go-plugin"
 
@@ -17,39 +15,55 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-// GetClient retrieve ShoesClient use shoes-plugin
+// GetClient retrieve ShoesClient use default shoes-plugin
 func GetClient() (Client, func(), error) {
-	Handshake := plugin.HandshakeConfig{
-		ProtocolVersion:  1,
-		MagicCookieKey:   "SHOES_PLUGIN_MAGIC_COOKIE",
-		MagicCookieValue: "are_you_a_shoes?",
+	return getClient("", config.Config.ShoesPluginPath)
+}
+
+// GetClientByName retrieve ShoesClient use shoes-plugin that named in ADDITIONAL_PLUGINS, empty is default shoes-plugin
+func GetClientByName(name string) (Client, func(), error) {
+	if name == "" {
+		return GetClient()
 	}
-	PluginMap := map[string]plugin.Plugin{
-		"shoes_grpc": &Plugin{},
+	pluginPath, ok := config.Config.ShoesPlugins[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("plugin %s is not found", name)
 	}
+	return getClient(name, pluginPath)
+}
 
-	client := plugin.NewClient(&plugin.ClientConfig{
-		HandshakeConfig:  Handshake,
-		Plugins:          PluginMap,
-		Cmd:              exec.Command(config.Config.ShoesPluginPath),
-		Managed:          true,
-		Stderr:           os.Stderr,
-		SyncStdout:       os.Stdout,
-		SyncStderr:       os.Stderr,
-		AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
-	})
-
-	rpcClient, err := client.Client()
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get shoes client: %w", err)
+// getClient get client of plugin process that supervised, built-in provider or remote plugin server, teardown is no-op because client is shared
+func getClient(name, pluginPath string) (Client, func(), error) {
+	if builtin, ok := builtinName(pluginPath); ok {
+		client, err := getBuiltinClient(builtin)
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, func() {}, nil
+	}
+	if config.IsRemotePlugin(pluginPath) {
+		client, err := remoteOf(pluginPath).get()
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, func() {}, nil
 	}
 
-	raw, err := rpcClient.Dispense("shoes_grpc")
+	client, err := supervisorOf(name, pluginPath).get()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to shoes client instance: %w", err)
+		return nil, nil, err
 	}
+	return client, func() {}, nil
+}
+
+var handshake = plugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "SHOES_PLUGIN_MAGIC_COOKIE",
+	MagicCookieValue: "are_you_a_shoes?",
+}
 
-	return raw.(Client), client.Kill, nil
+var pluginMap = map[string]plugin.Plugin{
+	"shoes_grpc": &Plugin{},
 }
 
 // Plugin is plugin implement
@@ -71,22 +85,35 @@ func (p *Plugin) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker, c *g
 
 // Client is plugin client interface
 type Client interface {
-	AddInstance(ctx context.Context, runnerID, setupScript string, resourceType datastore.ResourceType, labels []string) (string, string, string, datastore.ResourceType, error)
-	DeleteInstance(ctx context.Context, cloudID string, labels []string) error
+	AddInstance(ctx context.Context, runnerID, setupScript string, resourceType datastore.ResourceType, labels []string, placementParams string, metadata map[string]string) (string, string, string, datastore.ResourceType, error)
+	DeleteInstance(ctx context.Context, cloudID string, labels []string, reason DeleteReason) error
+	Capabilities() Capabilities
+	GetCapacity(ctx context.Context) (Capacity, error)
 }
 
 // GRPCClient is plugin client implement
 type GRPCClient struct {
-	client pb.ShoesClient
+	client       pb.ShoesClient
+	capabilities Capabilities
+}
+
+// Capabilities return result of negotiation with shoes-provider
+func (c *GRPCClient) Capabilities() Capabilities {
+	return c.capabilities
 }
 
 // AddInstance create instance for runner
-func (c *GRPCClient) AddInstance(ctx context.Context, runnerName, setupScript string, resourceType datastore.ResourceType, labels []string) (string, string, string, datastore.ResourceType, error) {
+// metadata is sent only if shoes-provider advertises CapabilityJobMetadata
+func (c *GRPCClient) AddInstance(ctx context.Context, runnerName, setupScript string, resourceType datastore.ResourceType, labels []string, placementParams string, metadata map[string]string) (string, string, string, datastore.ResourceType, error) {
 	req := &pb.AddInstanceRequest{
-		RunnerName:   runnerName,
-		SetupScript:  setupScript,
-		ResourceType: resourceType.ToPb(),
-		Labels:       labels,
+		RunnerName:      runnerName,
+		SetupScript:     setupScript,
+		ResourceType:    resourceType.ToPb(),
+		Labels:          labels,
+		PlacementParams: placementParams,
+	}
+	if c.capabilities.Has(CapabilityJobMetadata) {
+		req.Metadata = metadata
 	}
 	resp, err := c.client.AddInstance(ctx, req)
 	if err != nil {
@@ -101,10 +128,11 @@ func (c *GRPCClient) AddInstance(ctx context.Context, runnerName, setupScript st
 }
 
 // DeleteInstance delete instance for runner
-func (c *GRPCClient) DeleteInstance(ctx context.Context, cloudID string, labels []string) error {
+func (c *GRPCClient) DeleteInstance(ctx context.Context, cloudID string, labels []string, reason DeleteReason) error {
 	req := &pb.DeleteInstanceRequest{
 		CloudId: cloudID,
 		Labels:  labels,
+		Reason:  reason.ToPb(),
 	}
 	_, err := c.client.DeleteInstance(ctx, req)
 	if err != nil {
@@ -0,0 +1,264 @@
+package shoes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+var (
+	// PingTimeout is timeout of health check to plugin process, the process is hanged if exceeded
+	PingTimeout = 10 * time.Second
+	// MaxRestartBackoff is max interval of restarting plugin process that continuously fails
+	MaxRestartBackoff = 1 * time.Minute
+)
+
+// supervisor keep a plugin process alive, and restart it with backoff if crashed or hanged
+type supervisor struct {
+	name       string
+	pluginPath string
+
+	mu        sync.Mutex
+	client    *plugin.Client
+	shoes     Client
+	failures  int       // number of continuous failures
+	nextStart time.Time // process is not started until it in backoff
+	restarts  int64
+}
+
+var (
+	supervisorsMu sync.Mutex
+	// supervisors is supervisor per plugin, key: name of plugin (empty is default plugin)
+	supervisors = map[string]*supervisor{}
+)
+
+// supervisorOf get supervisor of plugin, it is created at first time
+func supervisorOf(name, pluginPath string) *supervisor {
+	supervisorsMu.Lock()
+	defer supervisorsMu.Unlock()
+
+	s, ok := supervisors[name]
+	if !ok || s.pluginPath != pluginPath {
+		s = &supervisor{name: name, pluginPath: pluginPath}
+		supervisors[name] = s
+	}
+	return s
+}
+
+func listSupervisors() []*supervisor {
+	supervisorsMu.Lock()
+	defer supervisorsMu.Unlock()
+
+	r := make([]*supervisor, 0, len(supervisors))
+	for _, s := range supervisors {
+		r = append(r, s)
+	}
+	return r
+}
+
+// get return client of running plugin process, start process if not running
+func (s *supervisor) get() (Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client != nil && !s.client.Exited() {
+		return s.shoes, nil
+	}
+	if s.client != nil {
+		logger.Logf(false, "ALERT: plugin process (%s) is exited, will restart it", s.label())
+		s.stopLocked(true)
+	}
+	if wait := time.Until(s.nextStart); wait > 0 {
+		return nil, fmt.Errorf("plugin process (%s) is in backoff of restarting, retry after %s", s.label(), wait.Round(time.Second))
+	}
+
+	client, shoes, err := startPlugin(s.label(), s.pluginPath)
+	if err != nil {
+		s.failures++
+		s.nextStart = time.Now().Add(restartBackoff(s.failures))
+		return nil, err
+	}
+	s.client = client
+	s.shoes = shoes
+	return shoes, nil
+}
+
+// check ping to plugin process, and kill it if not healthy. killed process is restarted in next get
+func (s *supervisor) check() {
+	s.mu.Lock()
+	client := s.client
+	s.mu.Unlock()
+	if client == nil {
+		return
+	}
+
+	err := ping(client)
+	if err == nil {
+		s.mu.Lock()
+		s.failures = 0
+		s.mu.Unlock()
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != client {
+		// already restarted
+		return
+	}
+	logger.Logf(false, "ALERT: plugin process (%s) is not healthy, will restart it: %+v", s.label(), err)
+	s.stopLocked(true)
+}
+
+// stopLocked kill plugin process, s.mu must be locked.
+// if failed is true, it is counted as restart and next start is delayed by backoff
+func (s *supervisor) stopLocked(failed bool) {
+	if s.client != nil {
+		s.client.Kill()
+	}
+	s.client = nil
+	s.shoes = nil
+	if failed {
+		s.restarts++
+		s.failures++
+		s.nextStart = time.Now().Add(restartBackoff(s.failures))
+	}
+}
+
+func (s *supervisor) label() string {
+	if s.name == "" {
+		return "default"
+	}
+	return s.name
+}
+
+// restartBackoff return interval of restart after continuous failures.
+// first failure is restarted immediately, and interval is doubled in each failure up to MaxRestartBackoff
+func restartBackoff(failures int) time.Duration {
+	if failures <= 1 {
+		return 0
+	}
+	backoff := time.Second
+	for i := 2; i < failures && backoff < MaxRestartBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > MaxRestartBackoff {
+		return MaxRestartBackoff
+	}
+	return backoff
+}
+
+func ping(client *plugin.Client) error {
+	rpcClient, err := client.Client()
+	if err != nil {
+		return fmt.Errorf("failed to get rpc client: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- rpcClient.Ping()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(PingTimeout):
+		return fmt.Errorf("ping is not responded in %s", PingTimeout)
+	}
+}
+
+// startPlugin start plugin process, dispense client of it and negotiate version of protocol
+func startPlugin(name, pluginPath string) (*plugin.Client, Client, error) {
+	client := plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig:  handshake,
+		Plugins:          pluginMap,
+		Cmd:              exec.Command(pluginPath),
+		Managed:          true,
+		Stderr:           os.Stderr,
+		SyncStdout:       os.Stdout,
+		SyncStderr:       os.Stderr,
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
+	})
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, nil, fmt.Errorf("failed to get shoes client: %w", err)
+	}
+
+	raw, err := rpcClient.Dispense("shoes_grpc")
+	if err != nil {
+		client.Kill()
+		return nil, nil, fmt.Errorf("failed to shoes client instance: %w", err)
+	}
+
+	shoes := raw.(*GRPCClient)
+	ctx, cancel := context.WithTimeout(context.Background(), PingTimeout)
+	defer cancel()
+	capabilities, err := negotiate(ctx, shoes.client)
+	if err != nil {
+		client.Kill()
+		return nil, nil, fmt.Errorf("failed to negotiate with shoes-provider (%s): %w", name, err)
+	}
+	shoes.capabilities = capabilities
+	logger.Logf(false, "start plugin process (%s), protocol version: %d", name, capabilities.ProtocolVersion)
+
+	return client, shoes, nil
+}
+
+// LoopHealthCheck check health of plugin processes and remote plugins periodically, and kill all processes when ctx is done
+func LoopHealthCheck(ctx context.Context, interval time.Duration) error {
+	defer KillAll()
+	if interval == 0 {
+		logger.Logf(true, "health check of plugin processes is disabled")
+		<-ctx.Done()
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, s := range listSupervisors() {
+				s.check()
+			}
+			for _, r := range listRemotes() {
+				r.check()
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// KillAll kill all plugin processes, and close connections to remote plugins
+func KillAll() {
+	for _, s := range listSupervisors() {
+		s.mu.Lock()
+		s.stopLocked(false)
+		s.mu.Unlock()
+	}
+	for _, r := range listRemotes() {
+		r.mu.Lock()
+		r.closeLocked()
+		r.mu.Unlock()
+	}
+}
+
+// Restarts return number of restart per plugin, key: name of plugin ("default" is PLUGIN)
+func Restarts() map[string]int64 {
+	r := map[string]int64{}
+	for _, s := range listSupervisors() {
+		s.mu.Lock()
+		r[s.label()] = s.restarts
+		s.mu.Unlock()
+	}
+	return r
+}
@@ -0,0 +1,192 @@
+package shoes
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	pb "github.com/whywaita/myshoes/api/proto.go"
+
+	"google.golang.org/grpc"
+)
+
+// fakePluginEnv is environment variable that run test binary as fake plugin process
+const fakePluginEnv = "MYSHOES_TEST_FAKE_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakePluginEnv) != "" {
+		serveFakePlugin()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// fakePlugin serve fakeShoesServer in plugin process
+type fakePlugin struct {
+	Plugin
+}
+
+func (p *fakePlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) error {
+	pb.RegisterShoesServer(s, &fakeShoesServer{healthy: true})
+	return nil
+}
+
+func serveFakePlugin() {
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: handshake,
+		Plugins:         map[string]plugin.Plugin{"shoes_grpc": &fakePlugin{}},
+		GRPCServer:      plugin.DefaultGRPCServer,
+	})
+}
+
+// newFakePluginSupervisor return supervisor of test binary that run as fake plugin process
+func newFakePluginSupervisor(t *testing.T) *supervisor {
+	t.Helper()
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("failed to get path of test binary: %+v", err)
+	}
+	t.Setenv(fakePluginEnv, "1")
+
+	s := &supervisor{name: "fake", pluginPath: exe}
+	t.Cleanup(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.stopLocked(false)
+	})
+	return s
+}
+
+// killPluginProcess kill running plugin process without supervisor (e.g. crashed by OOM killer)
+func killPluginProcess(t *testing.T, s *supervisor) *plugin.Client {
+	t.Helper()
+
+	s.mu.Lock()
+	client := s.client
+	s.mu.Unlock()
+	if client == nil {
+		t.Fatalf("plugin process is not running")
+	}
+	p, err := os.FindProcess(client.ReattachConfig().Pid)
+	if err != nil {
+		t.Fatalf("failed to find plugin process: %+v", err)
+	}
+	if err := p.Kill(); err != nil {
+		t.Fatalf("failed to kill plugin process: %+v", err)
+	}
+	return client
+}
+
+func TestRestartBackoff(t *testing.T) {
+	old := MaxRestartBackoff
+	t.Cleanup(func() { MaxRestartBackoff = old })
+	MaxRestartBackoff = 10 * time.Second
+
+	want := []time.Duration{
+		0,                // 0
+		0,                // 1: first failure is restarted immediately
+		1 * time.Second,  // 2
+		2 * time.Second,  // 3
+		4 * time.Second,  // 4
+		8 * time.Second,  // 5
+		10 * time.Second, // 6: capped
+		10 * time.Second, // 7
+	}
+	for failures, w := range want {
+		if got := restartBackoff(failures); got != w {
+			t.Errorf("mismatch backoff (failures: %d, want: %s, got: %s)", failures, w, got)
+		}
+	}
+	if got := restartBackoff(100); got != MaxRestartBackoff {
+		t.Errorf("backoff must be capped by MaxRestartBackoff, but got %s", got)
+	}
+}
+
+func TestSupervisor_RestartExited(t *testing.T) {
+	s := newFakePluginSupervisor(t)
+
+	client, err := s.get()
+	if err != nil {
+		t.Fatalf("failed to start plugin: %+v", err)
+	}
+	if got := client.Capabilities(); got.ProtocolVersion != ProtocolVersion {
+		t.Fatalf("want negotiated protocol version %d, but got %d", ProtocolVersion, got.ProtocolVersion)
+	}
+	if again, err := s.get(); err != nil || again != client {
+		t.Fatalf("running plugin process must be reused (err: %+v)", err)
+	}
+	if s.restarts != 0 {
+		t.Fatalf("process is not restarted yet, but got %d restarts", s.restarts)
+	}
+
+	exited := killPluginProcess(t, s)
+	deadline := time.Now().Add(10 * time.Second)
+	for !exited.Exited() {
+		if time.Now().After(deadline) {
+			t.Fatalf("plugin process is not exited")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	restarted, err := s.get()
+	if err != nil {
+		t.Fatalf("failed to restart plugin: %+v", err)
+	}
+	if restarted == client {
+		t.Fatalf("exited plugin process must be restarted")
+	}
+	if s.restarts != 1 {
+		t.Errorf("want 1 restart, but got %d", s.restarts)
+	}
+}
+
+func TestSupervisor_RestartNotHealthy(t *testing.T) {
+	s := newFakePluginSupervisor(t)
+
+	if _, err := s.get(); err != nil {
+		t.Fatalf("failed to start plugin: %+v", err)
+	}
+	// healthy process is not restarted
+	s.check()
+	if s.restarts != 0 || s.client == nil {
+		t.Fatalf("healthy plugin process must not be restarted (restarts: %d)", s.restarts)
+	}
+
+	// first failure is restarted immediately
+	killPluginProcess(t, s)
+	s.check()
+	if s.restarts != 1 || s.client != nil {
+		t.Fatalf("plugin process that fails ping must be stopped (restarts: %d)", s.restarts)
+	}
+	if _, err := s.get(); err != nil {
+		t.Fatalf("failed to restart plugin: %+v", err)
+	}
+
+	// continuous failure is restarted after backoff
+	killPluginProcess(t, s)
+	s.check()
+	if s.restarts != 2 {
+		t.Fatalf("want 2 restarts, but got %d", s.restarts)
+	}
+	if _, err := s.get(); err == nil || !strings.Contains(err.Error(), "backoff") {
+		t.Fatalf("plugin process must not be started in backoff, but got %+v", err)
+	}
+
+	// healthy process reset continuous failures
+	s.mu.Lock()
+	s.nextStart = time.Now()
+	s.mu.Unlock()
+	if _, err := s.get(); err != nil {
+		t.Fatalf("failed to restart plugin after backoff: %+v", err)
+	}
+	s.check()
+	if s.failures != 0 {
+		t.Errorf("failures must be reset by healthy plugin process, but got %d", s.failures)
+	}
+	if s.restarts != 2 {
+		t.Errorf("want 2 restarts, but got %d", s.restarts)
+	}
+}
@@ -11,6 +11,8 @@ import (
 var (
 	// DeletedJobMap is map for deleted jobs. key: runs_on, value: number of deleted jobs
 	DeletedJobMap = sync.Map{}
+	// ExpiredJobMap is map for expired jobs. key: runs_on, value: number of expired jobs
+	ExpiredJobMap = sync.Map{}
 )
 
 func incrementDeleteJobMap(j datastore.Job) error {
@@ -27,3 +29,18 @@ func incrementDeleteJobMap(j datastore.Job) error {
 	DeletedJobMap.Store(runsOnConcat, v.(int)+1)
 	return nil
 }
+
+func incrementExpiredJobMap(j datastore.Job) error {
+	runsOnConcat, err := gh.ConcatLabels(j.CheckEventJSON)
+	if err != nil {
+		return fmt.Errorf("failed to concat labels: %+v", err)
+	}
+	v, ok := ExpiredJobMap.Load(runsOnConcat)
+	if !ok {
+		ExpiredJobMap.Store(runsOnConcat, 1)
+		return nil
+	}
+
+	ExpiredJobMap.Store(runsOnConcat, v.(int)+1)
+	return nil
+}
@@ -0,0 +1,154 @@
+package starter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/lock"
+)
+
+var (
+	// ProvisionRateWindow is window for calculating rate of provisioning
+	ProvisionRateWindow = 15 * time.Minute
+
+	provisioned = &provisionTracker{}
+)
+
+// ErrNotLeader is error that queue position is requested to a standby replica.
+// jobs provisioning now and provisioned recently are only known by starter in the leader
+var ErrNotLeader = errors.New("queue position is only available in the leader")
+
+// provisionTracker record time of provisioned jobs in ProvisionRateWindow
+type provisionTracker struct {
+	mu    sync.Mutex
+	times []time.Time
+}
+
+func (p *provisionTracker) record(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.times = append(p.times, now)
+	p.prune(now)
+}
+
+func (p *provisionTracker) prune(now time.Time) {
+	threshold := now.Add(-ProvisionRateWindow)
+	i := sort.Search(len(p.times), func(i int) bool {
+		return p.times[i].After(threshold)
+	})
+	p.times = p.times[i:]
+}
+
+// rate return number of provisioned jobs per second in window
+func (p *provisionTracker) rate(now time.Time) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune(now)
+	return float64(len(p.times)) / ProvisionRateWindow.Seconds()
+}
+
+// ProvisionRate return number of provisioned jobs per second in ProvisionRateWindow
+func ProvisionRate() float64 {
+	return provisioned.rate(time.Now())
+}
+
+// QueueStatus values
+const (
+	QueueStatusQueued       = "queued"
+	QueueStatusDeferred     = "deferred"
+	QueueStatusProvisioning = "provisioning"
+)
+
+// QueuePosition is position of a job in queue
+type QueuePosition struct {
+	Job    datastore.Job
+	Status string
+	// Position is 1-origin position in ready jobs, 0 is provisioning now
+	Position int
+	// ETA is estimated duration until provisioned, nil if it can not estimate (no jobs provisioned recently)
+	ETA *time.Duration
+}
+
+// GetQueuePosition get position and ETA of job in queue. return datastore.ErrNotFound if job is not queued.
+// status and ETA are calculated from state of starter in process, return ErrNotLeader if this replica is standby
+func GetQueuePosition(ctx context.Context, ds datastore.Datastore, jobID uuid.UUID) (*QueuePosition, error) {
+	if !lock.IsLeader.Load() {
+		return nil, ErrNotLeader
+	}
+
+	now := time.Now().UTC()
+	ready, err := ds.ListReadyJobs(ctx, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ready jobs: %w", err)
+	}
+	sort.SliceStable(ready, func(i, j int) bool {
+		return ready[i].CreatedAt.Before(ready[j].CreatedAt)
+	})
+
+	position := 0
+	for _, j := range ready {
+		if _, ok := inProgress.Load(j.UUID); ok {
+			if uuid.Equal(j.UUID, jobID) {
+				return &QueuePosition{Job: j, Status: QueueStatusProvisioning}, nil
+			}
+			continue
+		}
+
+		position++
+		if uuid.Equal(j.UUID, jobID) {
+			return &QueuePosition{
+				Job:      j,
+				Status:   QueueStatusQueued,
+				Position: position,
+				ETA:      estimate(position, provisioned.rate(now)),
+			}, nil
+		}
+	}
+
+	// not ready yet (not_before is not passed)
+	var found *datastore.Job
+	if err := datastore.WalkJobs(ctx, ds, func(jobs []datastore.Job) error {
+		for _, j := range jobs {
+			if uuid.Equal(j.UUID, jobID) {
+				j := j
+				found = &j
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to get jobs: %w", err)
+	}
+	if found == nil {
+		return nil, datastore.ErrNotFound
+	}
+
+	qp := &QueuePosition{
+		Job:      *found,
+		Status:   QueueStatusDeferred,
+		Position: position + 1,
+	}
+	if eta := estimate(qp.Position, provisioned.rate(now)); eta != nil && found.NotBefore.Valid {
+		// will be dispatched after not_before at least
+		if untilReady := found.NotBefore.Time.Sub(now); untilReady > *eta {
+			eta = &untilReady
+		}
+		qp.ETA = eta
+	}
+	return qp, nil
+}
+
+func estimate(position int, rate float64) *time.Duration {
+	if rate <= 0 {
+		return nil
+	}
+	eta := time.Duration(float64(position) / rate * float64(time.Second))
+	return &eta
+}
@@ -0,0 +1,99 @@
+package starter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
+	"github.com/whywaita/myshoes/pkg/lock"
+)
+
+func TestGetQueuePosition(t *testing.T) {
+	ds, _ := memory.New(nil)
+	ctx := context.Background()
+	targetID := uuid.NewV4()
+	if err := ds.CreateTarget(ctx, datastore.Target{
+		UUID:         targetID,
+		Scope:        "octocat/hello-world",
+		ResourceType: datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+
+	now := time.Now().UTC()
+	provisioningJob := datastore.Job{UUID: uuid.NewV4(), CreatedAt: now.Add(-3 * time.Minute)}
+	queuedJob := datastore.Job{UUID: uuid.NewV4(), CreatedAt: now.Add(-2 * time.Minute)}
+	deferredJob := datastore.Job{
+		UUID:      uuid.NewV4(),
+		CreatedAt: now.Add(-1 * time.Minute),
+		NotBefore: sql.NullTime{Time: now.Add(1 * time.Hour), Valid: true},
+	}
+	for _, j := range []datastore.Job{provisioningJob, queuedJob, deferredJob} {
+		j.TargetID = targetID
+		j.CheckEventJSON = "{}"
+		if _, err := ds.EnqueueJob(ctx, j); err != nil {
+			t.Fatalf("failed to enqueue job: %+v", err)
+		}
+	}
+	inProgress.Store(provisioningJob.UUID, struct{}{})
+	defer inProgress.Delete(provisioningJob.UUID)
+	oldProvisioned := provisioned
+	defer func() { provisioned = oldProvisioned }()
+	provisioned = &provisionTracker{}
+
+	lock.IsLeader.Store(true)
+	defer lock.IsLeader.Store(false)
+
+	// no jobs provisioned recently, can not estimate
+	qp, err := GetQueuePosition(ctx, ds, queuedJob.UUID)
+	if err != nil {
+		t.Fatalf("failed to get queue position: %+v", err)
+	}
+	if qp.Status != QueueStatusQueued || qp.Position != 1 || qp.ETA != nil {
+		t.Errorf("want queued in position 1 without ETA, but got %+v", qp)
+	}
+
+	provisioned.record(now)
+	qp, err = GetQueuePosition(ctx, ds, queuedJob.UUID)
+	if err != nil {
+		t.Fatalf("failed to get queue position: %+v", err)
+	}
+	if qp.ETA == nil || *qp.ETA != ProvisionRateWindow {
+		t.Errorf("want ETA %s by 1 job in window, but got %v", ProvisionRateWindow, qp.ETA)
+	}
+
+	qp, err = GetQueuePosition(ctx, ds, provisioningJob.UUID)
+	if err != nil {
+		t.Fatalf("failed to get queue position: %+v", err)
+	}
+	if qp.Status != QueueStatusProvisioning || qp.Position != 0 {
+		t.Errorf("want provisioning, but got %+v", qp)
+	}
+
+	qp, err = GetQueuePosition(ctx, ds, deferredJob.UUID)
+	if err != nil {
+		t.Fatalf("failed to get queue position: %+v", err)
+	}
+	if qp.Status != QueueStatusDeferred || qp.Position != 2 {
+		t.Errorf("want deferred in position 2, but got %+v", qp)
+	}
+	if qp.ETA == nil || *qp.ETA < 59*time.Minute {
+		t.Errorf("want ETA after not_before, but got %v", qp.ETA)
+	}
+
+	if _, err := GetQueuePosition(ctx, ds, uuid.NewV4()); !errors.Is(err, datastore.ErrNotFound) {
+		t.Errorf("want ErrNotFound for job not in queue, but got %+v", err)
+	}
+
+	// state of provisioning is only in the leader
+	lock.IsLeader.Store(false)
+	if _, err := GetQueuePosition(ctx, ds, queuedJob.UUID); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("want ErrNotLeader in standby, but got %+v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package starter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// ValidateResourceTypeLabels check resource types in RESOURCE_TYPE_LABELS
+func ValidateResourceTypeLabels() error {
+	for _, m := range config.Config.ResourceTypeLabels {
+		if datastore.UnmarshalResourceTypeString(m.ResourceType) == datastore.ResourceTypeUnknown {
+			return fmt.Errorf("invalid resource type of label %s: %s", m.Label, m.ResourceType)
+		}
+	}
+	return nil
+}
+
+// resourceTypeFromLabels return resource type and label that job requests by runs-on.
+// first matched mapping in RESOURCE_TYPE_LABELS is used, return ResourceTypeUnknown if no label is matched
+func resourceTypeFromLabels(labels []string) (datastore.ResourceType, string) {
+	for _, m := range config.Config.ResourceTypeLabels {
+		for _, l := range labels {
+			if strings.EqualFold(l, m.Label) {
+				return datastore.UnmarshalResourceTypeString(m.ResourceType), m.Label
+			}
+		}
+	}
+	return datastore.ResourceTypeUnknown, ""
+}
@@ -0,0 +1,167 @@
+package capacity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/logger"
+	"github.com/whywaita/myshoes/pkg/shoes"
+)
+
+var (
+	// CacheTTL is interval of asking capacity to shoes-provider, runners that created in it are counted by myshoes
+	CacheTTL = 10 * time.Second
+	// Timeout is timeout of checking capacity
+	Timeout = 10 * time.Second
+)
+
+// Capacity is implement of safety.
+// Capacity does not create a runner while shoes-provider reports that it is saturated.
+// shoes-provider that does not support to report capacity is not limited, same as unlimited.
+type Capacity struct {
+	ds datastore.Datastore
+
+	mu      sync.Mutex                 // lock of plugins, it is not held while asking capacity to shoes-provider
+	plugins map[string]*pluginCapacity // key: name of plugin (empty is default plugin)
+}
+
+// pluginCapacity is capacity of a shoes-provider that cached
+type pluginCapacity struct {
+	mu sync.Mutex // held while asking capacity, so slow shoes-provider does not block others
+
+	capacity  shoes.Capacity
+	supported bool
+	fetchedAt time.Time
+	admitted  uint32 // number of runners that admitted after fetched
+}
+
+// New create Capacity
+func New(ds datastore.Datastore) *Capacity {
+	return &Capacity{
+		ds:      ds,
+		plugins: map[string]*pluginCapacity{},
+	}
+}
+
+// Check check that shoes-provider of job is not saturated
+func (c *Capacity) Check(job *datastore.Job) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	name, err := c.pluginFor(ctx, job)
+	if err != nil {
+		return false, fmt.Errorf("failed to get plugin of job: %w", err)
+	}
+
+	p := c.plugin(name)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.fetchedAt.IsZero() || time.Since(p.fetchedAt) > CacheTTL {
+		p.fetch(ctx, name)
+	}
+	if !p.supported {
+		return true, nil
+	}
+
+	current := shoes.Capacity{Current: p.capacity.Current + p.admitted, Max: p.capacity.Max}
+	if current.IsSaturated() {
+		logger.Logf(true, "shoes-provider (%s) is saturated, job is waiting (job ID: %s, current: %d, max: %d)", label(name), job.UUID, current.Current, current.Max)
+		return false, nil
+	}
+	p.admitted++
+	return true, nil
+}
+
+// plugin return cached capacity of plugin, it is created if not exist
+func (c *Capacity) plugin(name string) *pluginCapacity {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.plugins[name]
+	if !ok {
+		p = &pluginCapacity{}
+		c.plugins[name] = p
+	}
+	return p
+}
+
+// pluginFor return name of plugin that create a runner for job, as same as starter
+func (c *Capacity) pluginFor(ctx context.Context, job *datastore.Job) (string, error) {
+	target, err := c.ds.GetTarget(ctx, job.TargetID)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve relational target (target ID: %s): %w", job.TargetID, err)
+	}
+	scope := target.Scope
+	if scope == "" {
+		scope = job.Repository
+	}
+
+	labels, err := gh.ExtractRunsOnLabels([]byte(job.CheckEventJSON))
+	if err != nil {
+		return "", fmt.Errorf("failed to extract labels: %w", err)
+	}
+	resourceType := target.ResourceType
+	for _, m := range config.Config.ResourceTypeLabels {
+		if containsLabel(labels, m.Label) {
+			resourceType = datastore.UnmarshalResourceTypeString(m.ResourceType)
+			break
+		}
+	}
+
+	return shoes.PluginFor(scope, resourceType, labels), nil
+}
+
+// fetch ask capacity to shoes-provider and reset admitted runners. shoes-provider is not limited if failed to ask.
+// p.mu must be held
+func (p *pluginCapacity) fetch(ctx context.Context, name string) {
+	p.capacity = shoes.Capacity{}
+	p.supported = false
+	p.admitted = 0
+	p.fetchedAt = time.Now()
+
+	client, teardown, err := shoes.GetClientByName(name)
+	if err != nil {
+		logger.Logf(false, "failed to get plugin client, capacity is not checked (plugin: %s): %+v", label(name), err)
+		return
+	}
+	defer teardown()
+
+	capacity, err := client.GetCapacity(ctx)
+	switch {
+	case errors.Is(err, shoes.ErrCapacityNotSupported):
+		return
+	case err != nil:
+		logger.Logf(false, "failed to get capacity of shoes-provider, capacity is not checked (plugin: %s): %+v", label(name), err)
+		return
+	}
+
+	if capacity.IsSaturated() {
+		logger.Logf(false, "shoes-provider (%s) is saturated, stop to create runners (current: %d, max: %d)", label(name), capacity.Current, capacity.Max)
+	}
+	p.capacity = capacity
+	p.supported = true
+}
+
+func containsLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if strings.EqualFold(l, label) {
+			return true
+		}
+	}
+	return false
+}
+
+func label(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
@@ -0,0 +1,242 @@
+package capacity_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
+	"github.com/whywaita/myshoes/pkg/shoes"
+	"github.com/whywaita/myshoes/pkg/starter/safety/capacity"
+)
+
+var testTargetID = uuid.FromStringOrNil("8cc1a4d5-4bf1-43d5-8d8f-3a3b7c5a4f7e")
+
+// fakeProvider is shoes-provider that report capacity
+type fakeProvider struct {
+	mu       sync.Mutex
+	capacity shoes.Capacity
+	err      error
+	calls    int
+	block    chan struct{} // if not nil, GetCapacity block until it is closed
+}
+
+func (f *fakeProvider) AddInstance(ctx context.Context, runnerID, setupScript string, resourceType datastore.ResourceType, labels []string, placementParams string, metadata map[string]string) (string, string, string, datastore.ResourceType, error) {
+	return "", "", "", datastore.ResourceTypeUnknown, errors.New("not implemented")
+}
+
+func (f *fakeProvider) DeleteInstance(ctx context.Context, cloudID string, labels []string, reason shoes.DeleteReason) error {
+	return errors.New("not implemented")
+}
+
+func (f *fakeProvider) Capabilities() shoes.Capabilities {
+	return shoes.NewCapabilities(shoes.CapabilityCapacity)
+}
+
+func (f *fakeProvider) GetCapacity(ctx context.Context) (shoes.Capacity, error) {
+	if f.block != nil {
+		select {
+		case <-f.block:
+		case <-ctx.Done():
+			return shoes.Capacity{}, ctx.Err()
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.capacity, f.err
+}
+
+func (f *fakeProvider) set(c shoes.Capacity) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.capacity = c
+}
+
+func (f *fakeProvider) getCalls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+// setupPlugin register fake as built-in provider of plugin, jobs that have label of name are routed to it
+func setupPlugin(t *testing.T, name string, fake *fakeProvider) {
+	t.Helper()
+
+	builtin := fmt.Sprintf("capacity-test-%s-%s", name, uuid.NewV4())
+	shoes.RegisterBuiltin(builtin, func() (shoes.Client, error) { return fake, nil })
+	if config.Config.ShoesPlugins == nil {
+		config.Config.ShoesPlugins = map[string]string{}
+	}
+	config.Config.ShoesPlugins[name] = config.BuiltinPluginPrefix + builtin
+	config.Config.ShoesPluginRoutes = append(config.Config.ShoesPluginRoutes, config.ShoesPluginRoute{
+		Kind:   config.ShoesPluginRouteLabel,
+		Value:  name,
+		Plugin: name,
+	})
+}
+
+func newTestCapacity(t *testing.T) *capacity.Capacity {
+	t.Helper()
+
+	ds, err := memory.New(nil)
+	if err != nil {
+		t.Fatalf("failed to create datastore: %+v", err)
+	}
+	if err := ds.CreateTarget(context.Background(), datastore.Target{
+		UUID:         testTargetID,
+		Scope:        "octocat/hello-world",
+		ResourceType: datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+	return capacity.New(ds)
+}
+
+// jobFor create a job that routed to plugin
+func jobFor(plugin string) *datastore.Job {
+	return &datastore.Job{
+		UUID:           datastore.NewID(),
+		TargetID:       testTargetID,
+		Repository:     "octocat/hello-world",
+		CheckEventJSON: fmt.Sprintf(`{"labels": ["self-hosted", %q]}`, plugin),
+	}
+}
+
+func TestCapacity_Check(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity shoes.Capacity
+		err      error
+		want     []bool // results of Check in order, in a cache of capacity
+	}{
+		{
+			name:     "saturated",
+			capacity: shoes.Capacity{Current: 5, Max: 5},
+			want:     []bool{false, false},
+		},
+		{
+			// admitted runners are counted until next fetch
+			name:     "available",
+			capacity: shoes.Capacity{Current: 3, Max: 5},
+			want:     []bool{true, true, false},
+		},
+		{
+			name:     "unlimited",
+			capacity: shoes.Capacity{Current: 10, Max: 0},
+			want:     []bool{true, true, true},
+		},
+		{
+			name: "unsupported",
+			err:  shoes.ErrCapacityNotSupported,
+			want: []bool{true, true},
+		},
+		{
+			// not limited if failed to ask capacity
+			name: "error",
+			err:  errors.New("provider is unavailable"),
+			want: []bool{true, true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := &fakeProvider{capacity: test.capacity, err: test.err}
+			setupPlugin(t, test.name, fake)
+			c := newTestCapacity(t)
+
+			for i, want := range test.want {
+				got, err := c.Check(jobFor(test.name))
+				if err != nil {
+					t.Fatalf("failed to check: %+v", err)
+				}
+				if got != want {
+					t.Errorf("Check #%d: want %t, but got %t", i, want, got)
+				}
+			}
+			if calls := fake.getCalls(); calls != 1 {
+				t.Errorf("capacity must be cached, but asked %d times", calls)
+			}
+		})
+	}
+}
+
+func TestCapacity_Check_Refetch(t *testing.T) {
+	defaultCacheTTL := capacity.CacheTTL
+	capacity.CacheTTL = 50 * time.Millisecond
+	defer func() { capacity.CacheTTL = defaultCacheTTL }()
+
+	fake := &fakeProvider{capacity: shoes.Capacity{Current: 3, Max: 4}}
+	setupPlugin(t, "refetch", fake)
+	c := newTestCapacity(t)
+
+	for i, want := range []bool{true, false} {
+		got, err := c.Check(jobFor("refetch"))
+		if err != nil {
+			t.Fatalf("failed to check: %+v", err)
+		}
+		if got != want {
+			t.Errorf("Check #%d: want %t, but got %t", i, want, got)
+		}
+	}
+
+	// admitted runners are reported by shoes-provider after cache is expired
+	fake.set(shoes.Capacity{Current: 3, Max: 5})
+	time.Sleep(2 * capacity.CacheTTL)
+	got, err := c.Check(jobFor("refetch"))
+	if err != nil {
+		t.Fatalf("failed to check: %+v", err)
+	}
+	if !got {
+		t.Errorf("count of admitted runners must be reset by fetch, but job is not admitted")
+	}
+	if calls := fake.getCalls(); calls != 2 {
+		t.Errorf("want capacity is asked 2 times, but got %d", calls)
+	}
+}
+
+func TestCapacity_Check_SlowPlugin(t *testing.T) {
+	slow := &fakeProvider{capacity: shoes.Capacity{Current: 0, Max: 1}, block: make(chan struct{})}
+	fast := &fakeProvider{capacity: shoes.Capacity{Current: 0, Max: 1}}
+	setupPlugin(t, "slow", slow)
+	setupPlugin(t, "fast", fast)
+	c := newTestCapacity(t)
+
+	slowDone := make(chan struct{})
+	go func() {
+		defer close(slowDone)
+		if _, err := c.Check(jobFor("slow")); err != nil {
+			t.Errorf("failed to check: %+v", err)
+		}
+	}()
+	defer func() {
+		close(slow.block)
+		<-slowDone
+	}()
+
+	fastDone := make(chan bool)
+	go func() {
+		got, err := c.Check(jobFor("fast"))
+		if err != nil {
+			t.Errorf("failed to check: %+v", err)
+		}
+		fastDone <- got
+	}()
+
+	select {
+	case got := <-fastDone:
+		if !got {
+			t.Errorf("job of fast plugin must be admitted")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("checking fast plugin is blocked by slow plugin")
+	}
+}
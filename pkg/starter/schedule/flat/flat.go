@@ -0,0 +1,17 @@
+package flat
+
+import (
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/starter/schedule"
+)
+
+// Flat is implement of schedule.
+// Flat has no cheaper time, so provision a job immediately in target.
+type Flat struct{}
+
+// Decide is always provision now
+func (f Flat) Decide(job datastore.Job, target datastore.Target, now time.Time) (schedule.Decision, error) {
+	return schedule.Decision{}, nil
+}
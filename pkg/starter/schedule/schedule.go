@@ -0,0 +1,40 @@
+package schedule
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// Decision is when and where a job is provisioned
+type Decision struct {
+	// NotBefore is time that job is deferred to, zero value is provisioning now
+	NotBefore time.Time
+	// ResourceType override resource type of target, datastore.ResourceTypeUnknown is not override
+	ResourceType datastore.ResourceType
+	// PlacementParams override placement parameters of target, empty is not override
+	PlacementParams string
+}
+
+// IsDeferred return true if job should be deferred
+func (d Decision) IsDeferred() bool {
+	return !d.NotBefore.IsZero()
+}
+
+// Apply return target that overridden by decision
+func (d Decision) Apply(target datastore.Target) datastore.Target {
+	if d.ResourceType != datastore.ResourceTypeUnknown {
+		target.ResourceType = d.ResourceType
+	}
+	if d.PlacementParams != "" {
+		target.PlacementParams = sql.NullString{String: d.PlacementParams, Valid: true}
+	}
+	return target
+}
+
+// Schedule is interface for cost schedule
+type Schedule interface {
+	// Decide decide when and where the job is provisioned at now.
+	Decide(job datastore.Job, target datastore.Target, now time.Time) (Decision, error)
+}
@@ -0,0 +1,152 @@
+package window
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/starter/schedule"
+)
+
+// Window is implement of schedule.
+// Window defer low priority jobs to time-of-day windows that provisioning is cheaper, within max delay.
+type Window struct {
+	windows  []costWindow
+	location *time.Location
+	maxDelay time.Duration
+	label    string
+}
+
+type costWindow struct {
+	start, end      int // minutes from midnight
+	resourceType    datastore.ResourceType
+	placementParams map[string]json.RawMessage
+}
+
+// New create Window. jobs that have label in runs-on are low priority
+func New(windows []config.CostWindow, location *time.Location, maxDelay time.Duration, label string) (*Window, error) {
+	w := &Window{
+		location: location,
+		maxDelay: maxDelay,
+		label:    label,
+	}
+	for _, cw := range windows {
+		start, err := datastore.ParseTimeOfDay(cw.Start)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse start: %w", err)
+		}
+		end, err := datastore.ParseTimeOfDay(cw.End)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse end: %w", err)
+		}
+		c := costWindow{start: start, end: end}
+
+		if cw.ResourceType != "" {
+			c.resourceType = datastore.UnmarshalResourceTypeString(cw.ResourceType)
+			if c.resourceType == datastore.ResourceTypeUnknown {
+				return nil, fmt.Errorf("invalid resource type: %s", cw.ResourceType)
+			}
+		}
+		if len(cw.PlacementParams) != 0 {
+			if err := datastore.ValidatePlacementParams(cw.PlacementParams); err != nil {
+				return nil, fmt.Errorf("invalid placement parameters: %w", err)
+			}
+			if err := json.Unmarshal(cw.PlacementParams, &c.placementParams); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal placement parameters: %w", err)
+			}
+		}
+		w.windows = append(w.windows, c)
+	}
+	return w, nil
+}
+
+// Decide provision a low priority job in a window, or defer it to the next window if it starts within max delay.
+// other jobs are provisioned immediately.
+func (w *Window) Decide(job datastore.Job, target datastore.Target, now time.Time) (schedule.Decision, error) {
+	labels, err := gh.ExtractRunsOnLabels([]byte(job.CheckEventJSON))
+	if err != nil {
+		return schedule.Decision{}, fmt.Errorf("failed to extract labels: %w", err)
+	}
+	if !w.isLowPriority(labels) {
+		return schedule.Decision{}, nil
+	}
+
+	local := now.In(w.location)
+	if cw, ok := w.current(local); ok {
+		return cw.decision(target)
+	}
+
+	next, ok := w.next(local)
+	if !ok || next.After(job.CreatedAt.Add(w.maxDelay)) {
+		// can not wait for the next window
+		return schedule.Decision{}, nil
+	}
+	return schedule.Decision{NotBefore: next.UTC()}, nil
+}
+
+func (w *Window) isLowPriority(labels []string) bool {
+	for _, l := range labels {
+		if strings.EqualFold(l, w.label) {
+			return true
+		}
+	}
+	return false
+}
+
+// current return the window that contains t
+func (w *Window) current(t time.Time) (costWindow, bool) {
+	minute := t.Hour()*60 + t.Minute()
+	for _, cw := range w.windows {
+		if cw.contains(minute) {
+			return cw, true
+		}
+	}
+	return costWindow{}, false
+}
+
+// next return start time of the nearest window after t
+func (w *Window) next(t time.Time) (time.Time, bool) {
+	var next time.Time
+	for _, cw := range w.windows {
+		start := time.Date(t.Year(), t.Month(), t.Day(), cw.start/60, cw.start%60, 0, 0, t.Location())
+		if !start.After(t) {
+			start = start.AddDate(0, 0, 1)
+		}
+		if next.IsZero() || start.Before(next) {
+			next = start
+		}
+	}
+	return next, !next.IsZero()
+}
+
+func (cw costWindow) contains(minute int) bool {
+	return datastore.InTimeWindow(cw.start, cw.end, minute)
+}
+
+// decision return decision that override target by window
+func (cw costWindow) decision(target datastore.Target) (schedule.Decision, error) {
+	d := schedule.Decision{ResourceType: cw.resourceType}
+	if len(cw.placementParams) == 0 {
+		return d, nil
+	}
+
+	params := map[string]json.RawMessage{}
+	if target.PlacementParams.Valid && target.PlacementParams.String != "" {
+		if err := json.Unmarshal([]byte(target.PlacementParams.String), &params); err != nil {
+			return schedule.Decision{}, fmt.Errorf("failed to unmarshal placement parameters of target: %w", err)
+		}
+	}
+	for k, v := range cw.placementParams {
+		params[k] = v
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return schedule.Decision{}, fmt.Errorf("failed to marshal placement parameters: %w", err)
+	}
+	d.PlacementParams = string(b)
+	return d, nil
+}
@@ -6,12 +6,16 @@ import (
 	"context"
 	_ "embed" // TODO:
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
 	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/logger"
 	"github.com/whywaita/myshoes/pkg/runner"
 )
 
@@ -22,8 +26,11 @@ func getPatchedFiles() (string, error) {
 	return runnerService, nil
 }
 
-func (s *Starter) getSetupScript(ctx context.Context, targetScope, runnerName string) (string, error) {
-	rawScript, err := s.getSetupRawScript(ctx, targetScope, runnerName)
+// getSetupScript return setup script. runner is configured by jitConfig if set, registration token is used if not.
+// injectedLabels are added to runner in registration by token, JIT config has labels in itself.
+// runner and container runtime run under non-root user if rootless is set.
+func (s *Starter) getSetupScript(ctx context.Context, targetScope, runnerName, jitConfig string, injectedLabels []string, rootless datastore.RootlessRuntime) (string, error) {
+	rawScript, err := s.getSetupRawScript(ctx, targetScope, runnerName, jitConfig, injectedLabels, rootless)
 	if err != nil {
 		return "", fmt.Errorf("failed to get raw setup scripts: %w", err)
 	}
@@ -44,9 +51,9 @@ func (s *Starter) getSetupScript(ctx context.Context, targetScope, runnerName st
 	return fmt.Sprintf(templateCompressedScript, encoded), nil
 }
 
-func (s *Starter) getSetupRawScript(ctx context.Context, targetScope, runnerName string) (string, error) {
+func (s *Starter) getSetupRawScript(ctx context.Context, targetScope, runnerName, jitConfig string, injectedLabels []string, rootless datastore.RootlessRuntime) (string, error) {
 	runnerUser := config.Config.RunnerUser
-	githubURL := config.Config.GitHubURL
+	host := gh.HostFrom(ctx)
 
 	targetRunnerVersion := s.runnerVersion
 	if strings.EqualFold(s.runnerVersion, "latest") {
@@ -62,28 +69,50 @@ func (s *Starter) getSetupRawScript(ctx context.Context, targetScope, runnerName
 		return "", fmt.Errorf("failed to get runner version: %w", err)
 	}
 
-	runnerServiceJs, err := getPatchedFiles()
+	// pull runner from GHES itself if it serves runner binaries, runner does not need to access github.com
+	downloadURLs, err := gh.GetLocalRunnerDownloadURLs(ctx, targetScope)
 	if err != nil {
-		return "", fmt.Errorf("failed to get patched files: %w", err)
+		logger.Logf(false, "failed to get runner downloads in GHES, will download from github.com: %+v", err)
+		downloadURLs = map[string]string{}
 	}
 
-	installationID, err := gh.IsInstalledGitHubApp(ctx, targetScope)
+	runnerServiceJs, err := getPatchedFiles()
 	if err != nil {
-		return "", fmt.Errorf("failed to get installlation id: %w", err)
+		return "", fmt.Errorf("failed to get patched files: %w", err)
 	}
-	token, err := gh.GetRunnerRegistrationToken(ctx, installationID, targetScope)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate runner register token: %w", err)
+
+	// registration token is not embedded in callback mode, instance fetches it from myshoes by ticket at boot
+	var token, ticket string
+	switch {
+	case jitConfig != "":
+		// JIT config registers runner by itself, registration token is not needed
+	case config.Config.RunnerTokenDelivery == config.RunnerTokenDeliveryCallback:
+		ticket = runner.IssueTokenTicket(runnerName, time.Now(), config.Config.RunnerTokenTicketTTL)
+	default:
+		installationID, err := gh.IsInstalledGitHubApp(ctx, targetScope)
+		if err != nil {
+			return "", fmt.Errorf("failed to get installlation id: %w", err)
+		}
+		token, err = gh.GetRunnerRegistrationToken(ctx, installationID, targetScope)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate runner register token: %w", err)
+		}
 	}
 
 	var labels []string
-	if githubURL != "" && githubURL != "https://github.com" {
+	if host.IsGHES() {
 		labels = append(labels, "dependabot")
 	}
+	for _, l := range injectedLabels {
+		// myshoes is always set in config.sh
+		if !strings.EqualFold(l, "myshoes") && !containsLabel(labels, l) {
+			labels = append(labels, l)
+		}
+	}
 
 	v := templateCreateLatestRunnerOnceValue{
 		Scope:                   targetScope,
-		GHEDomain:               config.Config.GitHubURL,
+		GHEDomain:               host.URL(),
 		RunnerRegistrationToken: token,
 		RunnerName:              runnerName,
 		RunnerUser:              runnerUser,
@@ -91,6 +120,12 @@ func (s *Starter) getSetupRawScript(ctx context.Context, targetScope, runnerName
 		RunnerServiceJS:         runnerServiceJs,
 		RunnerArg:               runnerTemporaryMode.StringFlag(),
 		AdditionalLabels:        labelsToOneLine(labels),
+		CallbackURL:             config.Config.RunnerCallbackURL,
+		CallbackToken:           runner.CallbackToken(runnerName),
+		TokenTicket:             ticket,
+		RunnerDownloadURLs:      downloadURLs,
+		JITConfig:               jitConfig,
+		Rootless:                string(rootless),
 	}
 
 	t, err := template.New("templateCreateLatestRunnerOnce").Parse(templateCreateLatestRunnerOnce)
@@ -104,6 +139,60 @@ func (s *Starter) getSetupRawScript(ctx context.Context, targetScope, runnerName
 	return buff.String(), nil
 }
 
+// getJITConfig generate JIT config of runner that has labels requested by job and injected labels.
+// return empty if GitHub does not support JIT config, registration token is used instead.
+func getJITConfig(ctx context.Context, targetScope, runnerName string, runsOn, injectedLabels []string) (string, error) {
+	installationID, err := gh.IsInstalledGitHubApp(ctx, targetScope)
+	if err != nil {
+		return "", fmt.Errorf("failed to get installlation id: %w", err)
+	}
+
+	// config.sh is not run in JIT, so all labels that job requests are needed
+	labels := []string{"self-hosted", "myshoes"}
+	if gh.HostFrom(ctx).IsGHES() {
+		labels = append(labels, "dependabot")
+	}
+	for _, ls := range [][]string{runsOn, injectedLabels} {
+		for _, l := range ls {
+			if !containsLabel(labels, l) {
+				labels = append(labels, l)
+			}
+		}
+	}
+
+	jitConfig, err := gh.GenerateJITConfig(ctx, installationID, targetScope, runnerName, labels)
+	if errors.Is(err, gh.ErrJITConfigUnsupported) {
+		logger.Logf(false, "JIT config is not supported in GitHub, will use registration token (scope: %s)", targetScope)
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to generate JIT config: %w", err)
+	}
+	return jitConfig, nil
+}
+
+// getInjectedLabels return labels that are injected to runners of target, labels in config are shared by all targets
+func getInjectedLabels(target datastore.Target) []string {
+	var labels []string
+	for _, ls := range [][]string{config.Config.RunnerLabels, target.InjectedRunnerLabels()} {
+		for _, l := range ls {
+			if !containsLabel(labels, l) {
+				labels = append(labels, l)
+			}
+		}
+	}
+	return labels
+}
+
+func containsLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if strings.EqualFold(l, label) {
+			return true
+		}
+	}
+	return false
+}
+
 func labelsToOneLine(labels []string) string {
 	if len(labels) == 0 {
 		return ""
@@ -114,7 +203,7 @@ func labelsToOneLine(labels []string) string {
 
 const templateCompressedScript = `#!/bin/bash
 
-set -e
+set -euo pipefail
 
 # main script compressed base64 and gzip
 export COMPRESSED_SCRIPT=%s
@@ -135,14 +224,21 @@ type templateCreateLatestRunnerOnceValue struct {
 	RunnerServiceJS         string
 	RunnerArg               string
 	AdditionalLabels        string
+	CallbackURL             string
+	CallbackToken           string
+	TokenTicket             string
+	RunnerDownloadURLs      map[string]string // file name to URL of runner served by GHES
+	JITConfig               string            // encoded JIT config, config.sh is skipped if set
+	Rootless                string            // container runtime of rootless runner (docker or podman), empty is rootful docker
 }
 
 // templateCreateLatestRunnerOnce is script template of setup runner.
 // need to set runnerUser if execute using root permission. (for example, use cloud-init)
+// script stops at first error, and reports progress and result to myshoes if CallbackURL is set.
 // original script: https://github.com/actions/runner/blob/80bf68db812beb298b7534012b261e6f222e004a/scripts/create-latest-svc.sh
 const templateCreateLatestRunnerOnce = `#!/bin/bash
 
-set -e
+set -eEuo pipefail
 
 runner_scope={{.Scope}}
 ghe_hostname={{.GHEDomain}}
@@ -151,6 +247,68 @@ RUNNER_TOKEN={{.RunnerRegistrationToken}}
 RUNNER_USER={{.RunnerUser}}
 RUNNER_VERSION={{.RunnerVersion}}
 RUNNER_BASE_DIRECTORY=/tmp  # /tmp is path of all user writable.
+ROOTLESS_RUNTIME={{.Rootless}}
+MYSHOES_CALLBACK_URL={{.CallbackURL}}
+MYSHOES_CALLBACK_TOKEN={{.CallbackToken}}
+current_phase=start
+
+#---------------------------------------
+# Report progress
+#---------------------------------------
+function report()
+{
+    # report status (progress, success, failure) of phase to myshoes.
+    # failure of reporting must not stop setup.
+    local status=$1
+    local phase=$2
+    local message=${3:-}
+
+    echo "[myshoes] ${status}: ${phase} ${message}"
+    if [ -z "${MYSHOES_CALLBACK_URL}" ]; then
+        return 0
+    fi
+    curl -sS -o /dev/null -m 10 --retry 3 -X POST \
+        -H "Authorization: Bearer ${MYSHOES_CALLBACK_TOKEN}" \
+        -H "Content-Type: application/json" \
+        -d "{\"status\":\"${status}\",\"phase\":\"${phase}\",\"message\":\"${message}\"}" \
+        "${MYSHOES_CALLBACK_URL}/runners/${runner_name}/bootstrap" || true
+}
+
+function phase()
+{
+    current_phase=$1
+    report progress "${current_phase}"
+}
+
+function on_error()
+{
+    local code=$1
+    local line=$2
+    trap - ERR
+    report failure "${current_phase}" "exit ${code} at line ${line}"
+    exit ${code}
+}
+trap 'on_error $? ${LINENO}' ERR
+
+function retry()
+{
+    # retry command with exponential backoff (2s, 4s, 8s, 16s)
+    local max=5
+    local delay=2
+    local n=1
+    until "$@"; do
+        if [ ${n} -ge ${max} ]; then
+            echo "failed after ${n} attempts: $*" >&2
+            return 1
+        fi
+        echo "attempt ${n} failed, retry in ${delay}s: $*" >&2
+        sleep ${delay}
+        n=$((n + 1))
+        delay=$((delay * 2))
+    done
+}
+
+phase start
 
 sudo_prefix=""
 if [ $(id -u) -eq 0 ]; then  # if root
@@ -168,6 +326,8 @@ runner_plat=linux
 function fatal()
 {
    echo "error: $1" >&2
+   trap - ERR
+   report failure "${current_phase}" "$1"
    exit 1
 }
 
@@ -179,18 +339,100 @@ function configure_environment()
 	fi
 }
 
+function ensure_runner_user()
+{
+    # rootless runner must not run as root, create runner user if not exists
+    if [ -z "${RUNNER_USER}" ] || [ "${RUNNER_USER}" = "root" ]; then
+        RUNNER_USER=runner
+    fi
+    if ! id "${RUNNER_USER}" > /dev/null 2>&1; then
+        useradd -m -s /bin/bash "${RUNNER_USER}"
+    fi
+    sudo_prefix="sudo -E -u ${RUNNER_USER} "
+}
+
+function configure_rootless_environment()
+{
+    # systemd user services and container socket of runner user are in XDG_RUNTIME_DIR
+    local runtime=$1
+    local uid=$2
+
+    export XDG_RUNTIME_DIR=/run/user/${uid}
+    export DBUS_SESSION_BUS_ADDRESS=unix:path=${XDG_RUNTIME_DIR}/bus
+    if [ "${runtime}" = "podman" ]; then
+        export DOCKER_HOST=unix://${XDG_RUNTIME_DIR}/podman/podman.sock
+    else
+        export DOCKER_HOST=unix://${XDG_RUNTIME_DIR}/docker.sock
+    fi
+}
+
+function setup_rootless()
+{
+    # run container runtime under runner user with user namespaces, runner user does not need root and docker group
+    local runtime=$1
+
+    if [ "${runner_plat}" = "osx" ]; then
+        fatal "rootless ${runtime} is not supported in macOS"
+    fi
+    if [ $(id -u) -ne 0 ]; then
+        echo "not running as root, rootless ${runtime} must be set up in image"
+        configure_rootless_environment ${runtime} $(id -u)
+        return 0
+    fi
+
+    ensure_runner_user
+    local uid=$(id -u ${RUNNER_USER})
+
+    if [ -e /etc/debian_version ] || [ -e /etc/debian_release ]; then
+        retry apt-get update -y -qq
+        retry apt-get install -y uidmap dbus-user-session slirp4netns fuse-overlayfs
+        if [ "${runtime}" = "podman" ]; then
+            which podman || retry apt-get install -y podman
+        fi
+    elif [ -e /etc/redhat-release ]; then
+        retry yum install -y shadow-utils slirp4netns fuse-overlayfs
+        if [ "${runtime}" = "podman" ]; then
+            which podman || retry yum install -y podman
+        fi
+    fi
+
+    # subordinate IDs for user namespaces
+    grep -q "^${RUNNER_USER}:" /etc/subuid || echo "${RUNNER_USER}:100000:65536" >> /etc/subuid
+    grep -q "^${RUNNER_USER}:" /etc/subgid || echo "${RUNNER_USER}:100000:65536" >> /etc/subgid
+
+    # systemd user manager of runner user must keep running without login session
+    loginctl enable-linger ${RUNNER_USER}
+    retry test -S /run/user/${uid}/bus
+    configure_rootless_environment ${runtime} ${uid}
+
+    if [ "${runtime}" = "podman" ]; then
+        ${sudo_prefix}systemctl --user enable --now podman.socket
+    else
+        # rootful dockerd must not be used by runner
+        systemctl disable --now docker.service docker.socket > /dev/null 2>&1 || true
+        if which dockerd-rootless-setuptool.sh; then
+            ${sudo_prefix}dockerd-rootless-setuptool.sh install
+        else
+            retry curl -fsSL --connect-timeout 10 -o /tmp/docker-rootless.sh https://get.docker.com/rootless
+            ${sudo_prefix}sh /tmp/docker-rootless.sh
+            export PATH=/home/${RUNNER_USER}/bin:${PATH}
+        fi
+    fi
+    retry test -S ${DOCKER_HOST#unix://}
+}
+
 function install_jq()
 {
     echo "jq is not installed, will be install jq."
     if [ -e /etc/debian_version ] || [ -e /etc/debian_release ]; then
-        sudo apt-get update -y -qq
-        sudo apt-get install -y jq
+        retry sudo apt-get update -y -qq
+        retry sudo apt-get install -y jq
     elif [ -e /etc/redhat-release ]; then
-        sudo yum install -y jq
+        retry sudo yum install -y jq
     fi
 
 	if [ "${runner_plat}" = "osx" ]; then
-		brew install jq
+		retry brew install jq
 	fi
 }
 
@@ -198,8 +440,8 @@ function install_docker()
 {
 	echo "docker is not installed, will be install docker."
 	if [ -e /etc/debian_version ] || [ -e /etc/debian_release ]; then
-		sudo apt-get update -y -qq
-		sudo apt-get install -y docker.io
+		retry sudo apt-get update -y -qq
+		retry sudo apt-get install -y docker.io
 	fi
 
 	if [ "${runner_plat}" = "osx" ]; then
@@ -230,12 +472,18 @@ function download_runner()
     runner_version=$1
     runner_file=$2
 
-    runner_url="https://github.com/actions/runner/releases/download/${runner_version}/${runner_file}"
+    case "${runner_file}" in
+{{- range $file, $url := .RunnerDownloadURLs }}
+    "{{ $file }}") runner_url="{{ $url }}" ;;
+{{- end }}
+    *) runner_url="https://github.com/actions/runner/releases/download/${runner_version}/${runner_file}" ;;
+    esac
 
     echo "Downloading ${runner_version} for ${runner_plat} ..."
     echo $runner_url
 
-    curl -O -L ${runner_url}
+    retry curl -fsSL --connect-timeout 10 -o ${runner_file} ${runner_url}
+    tar tzf ${runner_file} > /dev/null || fatal "downloaded ${runner_file} is broken"
 
     ls -la *.tar.gz
 }
@@ -257,10 +505,16 @@ function extract_runner()
 
 if [ -z "${runner_scope}" ]; then fatal "supply scope as argument 1"; fi
 
+phase dependencies
 which curl || fatal "curl required.  Please install in PATH with apt-get, brew, etc"
 which jq || install_jq
 which jq || fatal "jq required.  Please install in PATH with apt-get, brew, etc"
-which docker || install_docker
+if [ -n "${ROOTLESS_RUNTIME}" ]; then
+    phase rootless
+    setup_rootless ${ROOTLESS_RUNTIME}
+else
+    which docker || install_docker
+fi
 
 configure_environment
 
@@ -270,6 +524,7 @@ ${sudo_prefix}mkdir -p runner
 #---------------------------------------
 # Download latest released and extract
 #---------------------------------------
+phase download
 echo
 echo "Downloading latest runner ..."
 
@@ -299,11 +554,22 @@ cd ${RUNNER_BASE_DIRECTORY}/runner
 #---------------------------------------
 # Unattend config
 #---------------------------------------
+phase configure
 runner_url="https://github.com/${runner_scope}"
 if [ -n "${ghe_hostname}" ]; then
     runner_url="${ghe_hostname}/${runner_scope}"
 fi
 
+{{ if .JITConfig -}}
+# runner is registered by JIT config at start, config.sh is not needed
+echo "Runner ${runner_name} is configured by JIT config"
+{{ else -}}
+{{ if .TokenTicket -}}
+# registration token is fetched from myshoes by one-time ticket, it is not embedded in user data
+echo "Fetching registration token from myshoes"
+RUNNER_TOKEN=$(retry curl -fsS -m 10 -H "Authorization: Bearer {{.TokenTicket}}" "${MYSHOES_CALLBACK_URL}/runners/${runner_name}/token")
+{{ end -}}
+
 echo
 echo "Configuring ${runner_name} @ $runner_url"
 {{ if eq .RunnerArg "--once" -}}
@@ -312,12 +578,14 @@ ${sudo_prefix}./config.sh --unattended --url $runner_url --token $RUNNER_TOKEN -
 {{ else -}}
 echo "./config.sh --unattended --url $runner_url --token *** --name $runner_name --labels myshoes {{.RunnerArg}}"
 ${sudo_prefix}./config.sh --unattended --url $runner_url --token $RUNNER_TOKEN --name $runner_name --labels myshoes{{.AdditionalLabels}} {{.RunnerArg}}
+{{ end -}}
 {{ end }}
 
 
 #---------------------------------------
 # patch once commands
 #---------------------------------------
+phase patch
 echo "apply patch file"
 cat << EOF > ./bin/runsvc.sh
 #!/bin/bash
@@ -354,6 +622,15 @@ EOF
 # Configure run commands
 #---------------------------------------
 
+# jobs use container runtime of runner user in rootless
+if [ -n "${ROOTLESS_RUNTIME}" ]; then
+    echo "DOCKER_HOST=${DOCKER_HOST}" >> ./.env
+    echo "XDG_RUNTIME_DIR=${XDG_RUNTIME_DIR}" >> ./.env
+    if [ $(id -u) -eq 0 ]; then
+        chown ${RUNNER_USER} ./.env
+    fi
+fi
+
 # Configure job management hooks if script files exist
 if [ -e "/myshoes-actions-runner-hook-job-started.sh" ]; then
 	export ACTIONS_RUNNER_HOOK_JOB_STARTED="/myshoes-actions-runner-hook-job-started.sh"
@@ -365,7 +642,12 @@ fi
 #---------------------------------------
 # run!
 #---------------------------------------
-{{ if eq .RunnerArg "--once" -}}
+report success bootstrap
+current_phase=run
+{{ if .JITConfig -}}
+echo "./bin/runsvc.sh --jitconfig ***"
+${sudo_prefix}./bin/runsvc.sh --jitconfig {{.JITConfig}}
+{{ else if eq .RunnerArg "--once" -}}
 echo "./bin/runsvc.sh {{.RunnerArg}}"
 ${sudo_prefix}./bin/runsvc.sh {{.RunnerArg}}
 {{ else -}}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -21,11 +22,16 @@ import (
 	uuid "github.com/satori/go.uuid"
 	"github.com/whywaita/myshoes/pkg/config"
 	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/event"
 	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/hook"
 	"github.com/whywaita/myshoes/pkg/logger"
 	"github.com/whywaita/myshoes/pkg/runner"
+	"github.com/whywaita/myshoes/pkg/scaleset"
 	"github.com/whywaita/myshoes/pkg/shoes"
 	"github.com/whywaita/myshoes/pkg/starter/safety"
+	"github.com/whywaita/myshoes/pkg/starter/schedule"
+	"github.com/whywaita/myshoes/pkg/watchdog"
 )
 
 var (
@@ -46,15 +52,29 @@ var (
 type Starter struct {
 	ds              datastore.Datastore
 	safety          safety.Safety
+	schedule        schedule.Schedule
 	runnerVersion   string
 	notifyEnqueueCh <-chan struct{}
+
+	// processed is time when a job is processed, key: job ID, value: time.Time.
+	// job that is processed after it is listed is not processed again, dispatcher may send it in listed jobs.
+	processed sync.Map
+	// lastListedAt is time when dispatcher listed jobs previously
+	lastListedAt time.Time
+}
+
+// listedJob is a job that dispatcher listed at listedAt
+type listedJob struct {
+	datastore.Job
+	listedAt time.Time
 }
 
 // New create starter instance
-func New(ds datastore.Datastore, s safety.Safety, runnerVersion string, notifyEnqueueCh <-chan struct{}) *Starter {
+func New(ds datastore.Datastore, s safety.Safety, sc schedule.Schedule, runnerVersion string, notifyEnqueueCh <-chan struct{}) *Starter {
 	return &Starter{
 		ds:              ds,
 		safety:          s,
+		schedule:        sc,
 		runnerVersion:   runnerVersion,
 		notifyEnqueueCh: notifyEnqueueCh,
 	}
@@ -63,7 +83,7 @@ func New(ds datastore.Datastore, s safety.Safety, runnerVersion string, notifyEn
 // Loop is main loop for starter
 func (s *Starter) Loop(ctx context.Context) error {
 	logger.Logf(false, "start starter loop")
-	ch := make(chan datastore.Job)
+	ch := make(chan listedJob)
 
 	eg, ctx := errgroup.WithContext(ctx)
 
@@ -114,23 +134,85 @@ func (s *Starter) Loop(ctx context.Context) error {
 	return nil
 }
 
-func (s *Starter) dispatcher(ctx context.Context, ch chan datastore.Job) error {
-	logger.Logf(true, "start to check starter")
-	jobs, err := s.ds.ListJobs(ctx)
+func (s *Starter) dispatcher(ctx context.Context, ch chan listedJob) error {
+	logger.Samplef(true, "start to check starter")
+	watchdog.Beat(ctx)
+	event.Publish(ctx, event.TopicSchedulerTick, event.Tick{Loop: "starter"})
+	if gh.IsDegraded() {
+		logger.Samplef(false, "GitHub API is degraded, pause to dispatch jobs")
+		return nil
+	}
+
+	now := time.Now().UTC()
+	jobs, err := s.ds.ListReadyJobs(ctx, now)
 	if err != nil {
 		return fmt.Errorf("failed to get jobs: %w", err)
 	}
 
+	// all jobs that listed previously are received by processor, so jobs that processed before it are not needed
+	s.processed.Range(func(key, value any) bool {
+		if value.(time.Time).Before(s.lastListedAt) {
+			s.processed.Delete(key)
+		}
+		return true
+	})
+	s.lastListedAt = now
+
 	for _, j := range jobs {
-		// send to processor
-		ch <- j
+		if isExpiredJob(j, now) {
+			if err := s.expireJob(ctx, j); err != nil {
+				logger.Logf(false, "failed to expire job: %+v", err)
+			}
+			continue
+		}
+
+		// send to processor, processor is stopped if ctx is done (e.g. leadership is lost)
+		select {
+		case ch <- listedJob{Job: j, listedAt: now}:
+		case <-ctx.Done():
+			return nil
+		}
+		watchdog.Beat(ctx)
 	}
 
 	return nil
 }
 
-func (s *Starter) run(ctx context.Context, ch chan datastore.Job) error {
+// isExpiredJob check job is older than config.Config.JobTTL.
+// age of job that delayed by not_before is counted from not_before, it is not dispatched until then
+func isExpiredJob(j datastore.Job, now time.Time) bool {
+	if config.Config.JobTTL == 0 {
+		return false
+	}
+	since := j.CreatedAt
+	if j.NotBefore.Valid && j.NotBefore.Time.After(since) {
+		since = j.NotBefore.Time
+	}
+	return since.Add(config.Config.JobTTL).Before(now)
+}
+
+// expireJob remove job that GitHub will have timed out, myshoes do not provision for it
+func (s *Starter) expireJob(ctx context.Context, j datastore.Job) error {
+	if _, ok := inProgress.Load(j.UUID); ok {
+		return nil
+	}
+
+	logger.Logf(false, "job is expired (job ID: %s, created_at: %s, not_before: %s, ttl: %s), will delete", j.UUID, j.CreatedAt, j.NotBefore.Time, config.Config.JobTTL)
+	if err := s.ds.DeleteJob(ctx, j.UUID); err != nil {
+		return fmt.Errorf("failed to delete expired job (job ID: %s): %w", j.UUID, err)
+	}
+	datastore.RecordHistory(ctx, s.ds, datastore.HistoryResourceJob, j.UUID, datastore.HistoryStatusExpired, fmt.Sprintf("older than JOB_TTL (%s)", config.Config.JobTTL))
+	if err := incrementExpiredJobMap(j); err != nil {
+		return fmt.Errorf("failed to increment expired metrics: %w", err)
+	}
+	return nil
+}
+
+func (s *Starter) run(ctx context.Context, ch chan listedJob) error {
 	sem := semaphore.NewWeighted(config.Config.MaxConnectionsToBackend)
+	// wait in-progress jobs, these are canceled by ctx
+	var wg sync.WaitGroup
+	defer wg.Wait()
 
 	// Processor
 	for {
@@ -142,6 +224,10 @@ func (s *Starter) run(ctx context.Context, ch chan datastore.Job) error {
 				// this job is in progress, skip
 				continue
 			}
+			if processedAt, ok := s.processed.Load(job.UUID); ok && processedAt.(time.Time).After(job.listedAt) {
+				// this job is already processed after it is listed, skip
+				continue
+			}
 
 			logger.Logf(true, "found new job: %s", job.UUID)
 			CountWaiting.Add(1)
@@ -153,9 +239,12 @@ func (s *Starter) run(ctx context.Context, ch chan datastore.Job) error {
 
 			inProgress.Store(job.UUID, struct{}{})
 
+			wg.Add(1)
 			go func(job datastore.Job) {
 				defer func() {
+					wg.Done()
 					sem.Release(1)
+					s.processed.Store(job.UUID, time.Now().UTC())
 					inProgress.Delete(job.UUID)
 					CountRunning.Add(-1)
 				}()
@@ -163,7 +252,7 @@ func (s *Starter) run(ctx context.Context, ch chan datastore.Job) error {
 				if err := s.ProcessJob(ctx, job); err != nil {
 					logger.Logf(false, "failed to process job: %+v\n", err)
 				}
-			}(job)
+			}(job.Job)
 
 		case <-ctx.Done():
 			return nil
@@ -186,19 +275,68 @@ func (s *Starter) ProcessJob(ctx context.Context, job datastore.Job) error {
 	if err := datastore.UpdateTargetStatus(ctx, s.ds, job.TargetID, datastore.TargetStatusRunning, ""); err != nil {
 		return fmt.Errorf("failed to update target status (target ID: %s, job ID: %s): %w", job.TargetID, job.UUID, err)
 	}
+	datastore.RecordHistory(ctx, s.ds, datastore.HistoryResourceJob, job.UUID, datastore.HistoryStatusDispatched, "")
 
 	target, err := s.ds.GetTarget(ctx, job.TargetID)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve relational target: (target ID: %s, job ID: %s): %w", job.TargetID, job.UUID, err)
 	}
 
+	if target.Status == datastore.TargetStatusDeleted {
+		logger.Logf(false, "target is deleted, so will delete job (target ID: %s, job ID: %s)", job.TargetID, job.UUID)
+		if err := s.ds.DeleteJob(ctx, job.UUID); err != nil {
+			return fmt.Errorf("failed to delete job (job ID: %s): %w", job.UUID, err)
+		}
+		datastore.RecordHistory(ctx, s.ds, datastore.HistoryResourceJob, job.UUID, datastore.HistoryStatusDeleted, "target is deleted")
+		return nil
+	}
+
+	if reset, paused := gh.IsRateLimitPaused(target.Scope); paused {
+		logger.Logf(false, "rate limit of GitHub API is exhausted, job is deferred until reset (job ID: %s, reset: %s)", job.UUID, reset)
+		if err := s.ds.DeferJob(ctx, job.UUID, reset); err != nil {
+			return fmt.Errorf("failed to defer job (job ID: %s): %w", job.UUID, err)
+		}
+		datastore.RecordHistory(ctx, s.ds, datastore.HistoryResourceJob, job.UUID, datastore.HistoryStatusDeferred, fmt.Sprintf("wait for reset of GitHub API rate limit until %s", reset))
+		return nil
+	}
+
+	// label in runs-on overrides resource type of target, cost schedule is decided in the resource type
+	if labels, err := gh.ExtractRunsOnLabels([]byte(job.CheckEventJSON)); err == nil {
+		if rt, label := resourceTypeFromLabels(labels); rt != datastore.ResourceTypeUnknown {
+			logger.Logf(false, "job requests resource type %s by label %s (target ID: %s, job ID: %s)", rt, label, job.TargetID, job.UUID)
+			target.ResourceType = rt
+		}
+	}
+	decision, err := s.schedule.Decide(job, *target, time.Now().UTC())
+	if err != nil {
+		// schedule is optimization, provision in target as is
+		logger.Logf(false, "failed to decide schedule, will provision now (target ID: %s, job ID: %s): %+v", job.TargetID, job.UUID, err)
+		decision = schedule.Decision{}
+	}
+	if decision.IsDeferred() {
+		logger.Logf(false, "job is deferred to cheaper time (job ID: %s, not_before: %s)", job.UUID, decision.NotBefore)
+		if err := s.ds.DeferJob(ctx, job.UUID, decision.NotBefore); err != nil {
+			return fmt.Errorf("failed to defer job (job ID: %s): %w", job.UUID, err)
+		}
+		datastore.RecordHistory(ctx, s.ds, datastore.HistoryResourceJob, job.UUID, datastore.HistoryStatusDeferred, fmt.Sprintf("wait for cost schedule until %s", decision.NotBefore))
+		return nil
+	}
+	placed := decision.Apply(*target)
+
 	CountRecovered.LoadOrStore(target.Scope, 0)
+	// provisioning is essential, requests are counted but not deferred by budget
+	ctx = gh.WithBudgetScope(ctx, target.Scope)
+	ctx, err = target.WithGitHubHost(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to process job (job ID: %s): %w", job.UUID, err)
+	}
 
 	cctx, cancel := context.WithTimeout(ctx, runner.MustRunningTime)
 	defer cancel()
-	cloudID, ipAddress, shoesType, resourceType, err := s.bung(cctx, job, *target)
+	cloudID, ipAddress, shoesType, resourceType, err := s.bung(cctx, job, placed)
 	if err != nil {
 		logger.Logf(false, "failed to bung (target ID: %s, job ID: %s): %+v\n", job.TargetID, job.UUID, err)
+		datastore.RecordHistory(ctx, s.ds, datastore.HistoryResourceJob, job.UUID, datastore.HistoryStatusFailed, fmt.Sprintf("failed to create an instance: %s", err))
 
 		if stat, _ := status.FromError(err); stat.Code() == codes.InvalidArgument {
 			logger.Logf(false, "invalid argument. so will delete (job ID: %s)", job.UUID)
@@ -223,15 +361,19 @@ func (s *Starter) ProcessJob(ctx context.Context, job datastore.Job) error {
 		return fmt.Errorf("failed to bung (target ID: %s, job ID: %s): %w", job.TargetID, job.UUID, err)
 	}
 	if resourceType == datastore.ResourceTypeUnknown {
-		resourceType = target.ResourceType
+		resourceType = placed.ResourceType
 	}
+	datastore.RecordJobEvent(ctx, s.ds, job.UUID, datastore.JobHistoryRunnerCreated)
 
 	runnerName := runner.ToName(job.UUID.String())
 	if config.Config.Strict {
 		if err := s.checkRegisteredRunner(ctx, runnerName, *target); err != nil {
 			logger.Logf(false, "failed to check to register runner (target ID: %s, job ID: %s): %+v\n", job.TargetID, job.UUID, err)
+			datastore.RecordHistory(ctx, s.ds, datastore.HistoryResourceRunner, job.UUID, datastore.HistoryStatusFailed, fmt.Sprintf("cannot register runner to GitHub: %s", err))
+			// registration token may be revoked, generate new one for next runner
+			gh.InvalidateRunnerRegistrationToken(ctx, target.Scope)
 
-			if err := deleteInstance(ctx, cloudID, job.CheckEventJSON); err != nil {
+			if err := deleteInstance(ctx, cloudID, job.CheckEventJSON, placed); err != nil {
 				logger.Logf(false, "failed to delete an instance that not registered instance (target ID: %s, cloud ID: %s): %+v\n", job.TargetID, cloudID, err)
 				// not return, need to update target status if err.
 			}
@@ -242,6 +384,8 @@ func (s *Starter) ProcessJob(ctx context.Context, job datastore.Job) error {
 
 			return fmt.Errorf("failed to check to register runner (target ID: %s, job ID: %s): %w", job.TargetID, job.UUID, err)
 		}
+		datastore.RecordHistory(ctx, s.ds, datastore.HistoryResourceRunner, job.UUID, datastore.HistoryStatusRegistered, "")
+		datastore.RecordJobEvent(ctx, s.ds, job.UUID, datastore.JobHistoryRunnerRegistered)
 	}
 
 	r := datastore.Runner{
@@ -259,6 +403,21 @@ func (s *Starter) ProcessJob(ctx context.Context, job datastore.Job) error {
 		RepositoryURL:  job.RepoURL(),
 		RequestWebhook: job.CheckEventJSON,
 	}
+	if err := hook.Fire(ctx, s.ds, hook.EventPostCreate, r, runnerName, target.Scope); err != nil {
+		logger.Logf(false, "failed to call post create hook (target ID: %s, job ID: %s): %+v\n", job.TargetID, job.UUID, err)
+		datastore.RecordHistory(ctx, s.ds, datastore.HistoryResourceRunner, job.UUID, datastore.HistoryStatusFailed, fmt.Sprintf("failed to call post create hook: %s", err))
+
+		if err := deleteInstance(ctx, cloudID, job.CheckEventJSON, placed); err != nil {
+			logger.Logf(false, "failed to delete an instance that failed to call hook (target ID: %s, cloud ID: %s): %+v\n", job.TargetID, cloudID, err)
+			// not return, need to update target status if err.
+		}
+
+		if err := datastore.UpdateTargetStatus(ctx, s.ds, job.TargetID, datastore.TargetStatusErr, fmt.Sprintf("failed to call post create hook (job ID: %s)", job.UUID)); err != nil {
+			return fmt.Errorf("failed to update target status (target ID: %s, job ID: %s): %w", job.TargetID, job.UUID, err)
+		}
+
+		return fmt.Errorf("failed to call post create hook (target ID: %s, job ID: %s): %w", job.TargetID, job.UUID, err)
+	}
 	if err := s.ds.CreateRunner(ctx, r); err != nil {
 		logger.Logf(false, "failed to save runner to datastore (target ID: %s, job ID: %s): %+v\n", job.TargetID, job.UUID, err)
 
@@ -268,6 +427,7 @@ func (s *Starter) ProcessJob(ctx context.Context, job datastore.Job) error {
 
 		return fmt.Errorf("failed to save runner to datastore (target ID: %s, job ID: %s): %w", job.TargetID, job.UUID, err)
 	}
+	datastore.RecordHistory(ctx, s.ds, datastore.HistoryResourceRunner, r.UUID, datastore.HistoryStatusCreated, fmt.Sprintf("cloud ID: %s", cloudID))
 
 	if err := s.ds.DeleteJob(ctx, job.UUID); err != nil {
 		logger.Logf(false, "failed to delete job: %+v\n", err)
@@ -278,6 +438,8 @@ func (s *Starter) ProcessJob(ctx context.Context, job datastore.Job) error {
 
 		return fmt.Errorf("failed to delete job: %w", err)
 	}
+	datastore.RecordHistory(ctx, s.ds, datastore.HistoryResourceJob, job.UUID, datastore.HistoryStatusDeleted, "runner is created")
+	provisioned.record(time.Now())
 
 	return nil
 }
@@ -288,23 +450,48 @@ func (s *Starter) bung(ctx context.Context, job datastore.Job, target datastore.
 	runnerName := runner.ToName(job.UUID.String())
 
 	targetScope := getTargetScope(target, job)
-	script, err := s.getSetupScript(ctx, targetScope, runnerName)
+	labels, err := gh.ExtractRunsOnLabels([]byte(job.CheckEventJSON))
 	if err != nil {
-		return "", "", "", datastore.ResourceTypeUnknown, fmt.Errorf("failed to get setup scripts: %w", err)
+		return "", "", "", datastore.ResourceTypeUnknown, fmt.Errorf("failed to extract labels: %w", err)
 	}
 
-	client, teardown, err := shoes.GetClient()
+	injectedLabels := getInjectedLabels(target)
+	if _, label := resourceTypeFromLabels(labels); label != "" && !containsLabel(injectedLabels, label) {
+		// runner must have the label to run the job
+		injectedLabels = append(injectedLabels, label)
+	}
+	var jitConfig string
+	switch {
+	case scaleset.IsScaleSetJob(job):
+		// runner of scale set is registered by JIT config that is bound to the scale set
+		c, err := scaleset.GenerateJITConfig(ctx, job, runnerName)
+		if err != nil {
+			return "", "", "", datastore.ResourceTypeUnknown, fmt.Errorf("failed to generate JIT config: %w", err)
+		}
+		jitConfig = c
+	case target.RunnerRegistration == datastore.RunnerRegistrationJIT:
+		c, err := getJITConfig(ctx, targetScope, runnerName, labels, injectedLabels)
+		if err != nil {
+			return "", "", "", datastore.ResourceTypeUnknown, fmt.Errorf("failed to get JIT config: %w", err)
+		}
+		jitConfig = c
+	}
+	script, err := s.getSetupScript(ctx, targetScope, runnerName, jitConfig, injectedLabels, target.Rootless)
 	if err != nil {
-		return "", "", "", datastore.ResourceTypeUnknown, fmt.Errorf("failed to get plugin client: %w", err)
+		return "", "", "", datastore.ResourceTypeUnknown, fmt.Errorf("failed to get setup scripts: %w", err)
+	}
+	userData, err := toUserData(target.UserDataFormat, script)
+	if err != nil {
+		return "", "", "", datastore.ResourceTypeUnknown, fmt.Errorf("failed to convert setup scripts to user data: %w", err)
 	}
-	defer teardown()
 
-	labels, err := gh.ExtractRunsOnLabels([]byte(job.CheckEventJSON))
+	client, teardown, err := shoes.GetClientFor(targetScope, target.ResourceType, labels)
 	if err != nil {
-		return "", "", "", datastore.ResourceTypeUnknown, fmt.Errorf("failed to extract labels: %w", err)
+		return "", "", "", datastore.ResourceTypeUnknown, fmt.Errorf("failed to get plugin client: %w", err)
 	}
+	defer teardown()
 
-	cloudID, ipAddress, shoesType, resourceType, err := client.AddInstance(ctx, runnerName, script, target.ResourceType, labels)
+	cloudID, ipAddress, shoesType, resourceType, err := client.AddInstance(ctx, runnerName, userData, target.ResourceType, labels, target.PlacementParams.String, jobMetadata(job, targetScope))
 	if err != nil {
 		if stat, _ := status.FromError(err); stat.Code() == codes.InvalidArgument {
 			return "", "", "", datastore.ResourceTypeUnknown, err
@@ -313,6 +500,9 @@ func (s *Starter) bung(ctx context.Context, job datastore.Job, target datastore.
 	}
 
 	logger.Logf(false, "instance create successfully! (job: %s, cloud ID: %s)", job.UUID, cloudID)
+	// runner will be registered soon
+	owner, repo := gh.DivideScope(getTargetScope(target, job))
+	gh.InvalidateRunnersCache(ctx, owner, repo)
 
 	return cloudID, ipAddress, shoesType, resourceType, nil
 }
@@ -326,19 +516,43 @@ func getTargetScope(target datastore.Target, job datastore.Job) string {
 	return target.Scope
 }
 
-func deleteInstance(ctx context.Context, cloudID, checkEventJSON string) error {
-	client, teardown, err := shoes.GetClient()
-	if err != nil {
-		return fmt.Errorf("failed to get plugin client: %w", err)
+// jobMetadata return metadata of job for shoes-provider, e.g. to pick an image or to tag cloud resources.
+// keys that value is unknown are omitted
+func jobMetadata(job datastore.Job, targetScope string) map[string]string {
+	metadata := map[string]string{
+		shoes.MetadataKeyRepository: job.Repository,
+		shoes.MetadataKeyScope:      targetScope,
+		shoes.MetadataKeyMyshoesJob: job.UUID.String(),
 	}
-	defer teardown()
+	if job.GHEDomain.Valid {
+		metadata[shoes.MetadataKeyGHEDomain] = job.GHEDomain.String
+	}
+	if runID := gh.ExtractRunID([]byte(job.CheckEventJSON)); runID != 0 {
+		metadata[shoes.MetadataKeyRunID] = strconv.FormatInt(runID, 10)
+	}
+	if jobID := gh.ExtractJobID([]byte(job.CheckEventJSON)); jobID != 0 {
+		metadata[shoes.MetadataKeyJobID] = strconv.FormatInt(jobID, 10)
+	}
+	return metadata
+}
 
+// deleteInstance delete instance in shoes-plugin that created it for target
+func deleteInstance(ctx context.Context, cloudID, checkEventJSON string, target datastore.Target) error {
 	labels, err := gh.ExtractRunsOnLabels([]byte(checkEventJSON))
 	if err != nil {
 		return fmt.Errorf("failed to extract labels: %w", err)
 	}
 
-	if err := client.DeleteInstance(ctx, cloudID, labels); err != nil {
+	client, teardown, err := shoes.GetClientFor(target.Scope, target.ResourceType, labels)
+	if err != nil {
+		return fmt.Errorf("failed to get plugin client: %w", err)
+	}
+	defer teardown()
+
+	cctx, cancel := context.WithTimeout(ctx, runner.MustRunningTime)
+	defer cancel()
+	// runner is not registered in GitHub
+	if err := client.DeleteInstance(cctx, cloudID, labels, shoes.DeleteReasonZombie); err != nil {
 		return fmt.Errorf("failed to delete instance: %w", err)
 	}
 
@@ -348,7 +562,7 @@ func deleteInstance(ctx context.Context, cloudID, checkEventJSON string) error {
 
 // checkRegisteredRunner check to register runner to GitHub
 func (s *Starter) checkRegisteredRunner(ctx context.Context, runnerName string, target datastore.Target) error {
-	client, err := gh.NewClient(target.GitHubToken)
+	client, err := gh.HostFrom(ctx).NewClient(target.GitHubToken)
 	if err != nil {
 		return fmt.Errorf("failed to create github client: %w", err)
 	}
@@ -381,6 +595,7 @@ func (s *Starter) checkRegisteredRunner(ctx context.Context, runnerName string,
 }
 
 func (s *Starter) reRunWorkflow(ctx context.Context) {
+	gh.StorePendingRuns(30 * time.Minute)
 	gh.PendingRuns.Range(func(key, value any) bool {
 		installationID := key.(int64)
 		run := value.(*github.WorkflowRun)
@@ -393,6 +608,11 @@ func (s *Starter) reRunWorkflow(ctx context.Context) {
 		owner := run.GetRepository().GetOwner().GetLogin()
 		repo := run.GetRepository().GetName()
 		repoName := run.GetRepository().GetFullName()
+		if err := gh.CheckBudget(repoName); err != nil {
+			logger.Logf(true, "defer to check pending run (%s): %+v", repoName, err)
+			return true
+		}
+		ctx := gh.WithBudgetScope(ctx, repoName)
 
 		jobs, _, err := client.Actions.ListWorkflowJobs(ctx, owner, repo, run.GetID(), &github.ListWorkflowJobsOptions{
 			Filter: "latest",
@@ -438,25 +658,32 @@ func (s *Starter) reRunWorkflow(ctx context.Context) {
 					logger.Logf(false, "failed to search registered target: %+v", err)
 					continue
 				}
-				jobID := uuid.NewV4()
+				jobID := datastore.NewID()
 				jobJSON, _ := json.Marshal(j)
 				job := datastore.Job{
 					UUID:           jobID,
 					TargetID:       target.UUID,
 					Repository:     repoName,
 					CheckEventJSON: string(jobJSON),
+					DedupKey:       sql.NullString{String: fmt.Sprintf("workflow_job:%d", j.GetID()), Valid: true},
 				}
-				if err := s.ds.EnqueueJob(ctx, job); err != nil {
+				stored, err := s.ds.EnqueueJob(ctx, job)
+				if err != nil {
 					logger.Logf(false, "failed to enqueue job: %+v", err)
 					continue
 				}
+				if !uuid.Equal(stored.UUID, job.UUID) {
+					logger.Logf(true, "job is already enqueued (job ID: %s)", stored.UUID)
+					continue
+				}
+				datastore.RecordHistory(ctx, s.ds, datastore.HistoryResourceJob, job.UUID, datastore.HistoryStatusEnqueued, "rescued from pending workflow run")
 				reQueuedJobs.Store(j.GetID(), time.Now().Add(12*time.Hour))
 				countRecovered, _ := CountRecovered.LoadOrStore(target.Scope, 0)
 				CountRecovered.Store(target.Scope, countRecovered.(int)+1)
 			}
 		}
 		gh.PendingRuns.Delete(installationID)
-		gh.ClearRunsCache(owner, repo)
+		gh.ClearRunsCache(ctx, owner, repo)
 		return true
 	})
 }
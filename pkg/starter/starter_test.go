@@ -0,0 +1,130 @@
+package starter
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
+)
+
+func TestIsExpiredJob(t *testing.T) {
+	now := time.Date(2037, 9, 3, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		job  datastore.Job
+		want bool
+	}{
+		{
+			name: "ttl is disabled",
+			ttl:  0,
+			job:  datastore.Job{CreatedAt: now.Add(-100 * time.Hour)},
+			want: false,
+		},
+		{
+			name: "not expired",
+			ttl:  24 * time.Hour,
+			job:  datastore.Job{CreatedAt: now.Add(-1 * time.Hour)},
+			want: false,
+		},
+		{
+			name: "expired",
+			ttl:  24 * time.Hour,
+			job:  datastore.Job{CreatedAt: now.Add(-25 * time.Hour)},
+			want: true,
+		},
+		{
+			name: "delayed by not_before further than ttl",
+			ttl:  24 * time.Hour,
+			job: datastore.Job{
+				CreatedAt: now.Add(-48 * time.Hour),
+				NotBefore: sql.NullTime{Time: now.Add(-1 * time.Hour), Valid: true},
+			},
+			want: false,
+		},
+		{
+			name: "expired after not_before",
+			ttl:  24 * time.Hour,
+			job: datastore.Job{
+				CreatedAt: now.Add(-48 * time.Hour),
+				NotBefore: sql.NullTime{Time: now.Add(-25 * time.Hour), Valid: true},
+			},
+			want: true,
+		},
+	}
+
+	oldTTL := config.Config.JobTTL
+	defer func() { config.Config.JobTTL = oldTTL }()
+	for _, test := range tests {
+		config.Config.JobTTL = test.ttl
+		if got := isExpiredJob(test.job, now); got != test.want {
+			t.Errorf("%s: want %t, but got %t", test.name, test.want, got)
+		}
+	}
+}
+
+func TestStarter_expireJob(t *testing.T) {
+	ds, _ := memory.New(nil)
+	ctx := context.Background()
+	targetID := uuid.NewV4()
+	if err := ds.CreateTarget(ctx, datastore.Target{
+		UUID:         targetID,
+		Scope:        "octocat/hello-world",
+		ResourceType: datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+	s := &Starter{ds: ds}
+
+	inProgressJob := datastore.Job{
+		UUID:           uuid.NewV4(),
+		TargetID:       targetID,
+		CheckEventJSON: `{"action": "queued", "workflow_job": {"id": 1, "labels": ["self-hosted", "expire-in-progress"]}}`,
+	}
+	expiredJob := datastore.Job{
+		UUID:           uuid.NewV4(),
+		TargetID:       targetID,
+		CheckEventJSON: `{"action": "queued", "workflow_job": {"id": 2, "labels": ["self-hosted", "expire-test"]}}`,
+	}
+	for _, j := range []datastore.Job{inProgressJob, expiredJob} {
+		if _, err := ds.EnqueueJob(ctx, j); err != nil {
+			t.Fatalf("failed to enqueue job: %+v", err)
+		}
+	}
+
+	// job that is processing is not expired
+	inProgress.Store(inProgressJob.UUID, struct{}{})
+	defer inProgress.Delete(inProgressJob.UUID)
+	if err := s.expireJob(ctx, inProgressJob); err != nil {
+		t.Fatalf("failed to expire job: %+v", err)
+	}
+	if err := s.expireJob(ctx, expiredJob); err != nil {
+		t.Fatalf("failed to expire job: %+v", err)
+	}
+
+	jobs, err := ds.ListJobs(ctx, datastore.ListOption{})
+	if err != nil {
+		t.Fatalf("failed to list jobs: %+v", err)
+	}
+	if len(jobs) != 1 || !uuid.Equal(jobs[0].UUID, inProgressJob.UUID) {
+		t.Fatalf("only in-progress job must be left, but got %+v", jobs)
+	}
+	histories, err := ds.ListStateHistories(ctx, expiredJob.UUID)
+	if err != nil {
+		t.Fatalf("failed to list histories: %+v", err)
+	}
+	if len(histories) != 1 || histories[0].Status != datastore.HistoryStatusExpired {
+		t.Fatalf("want expired history, but got %+v", histories)
+	}
+	if v, ok := ExpiredJobMap.Load("self-hosted,expire-test"); !ok || v.(int) != 1 {
+		t.Fatalf("want 1 expired job in metrics, but got %v", v)
+	}
+
+}
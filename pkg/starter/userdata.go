@@ -0,0 +1,96 @@
+package starter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+)
+
+// userDataScriptPath is path of setup script that written by cloud-init or Ignition, /var is writable in Flatcar and Fedora CoreOS
+const userDataScriptPath = "/var/lib/myshoes/setup.sh"
+
+// toUserData convert setup script to user data in format
+func toUserData(format datastore.UserDataFormat, script string) (string, error) {
+	switch format {
+	case "", datastore.UserDataFormatShell:
+		return script, nil
+	case datastore.UserDataFormatCloudConfig:
+		return fmt.Sprintf(templateCloudConfig, userDataScriptPath, base64.StdEncoding.EncodeToString([]byte(script)), userDataScriptPath), nil
+	case datastore.UserDataFormatIgnition:
+		return toIgnition(script)
+	}
+	return "", fmt.Errorf("unknown user data format: %s", format)
+}
+
+const templateCloudConfig = `#cloud-config
+write_files:
+  - path: %s
+    permissions: '0755'
+    encoding: b64
+    content: %s
+runcmd:
+  - [bash, %s]
+`
+
+// ignitionConfig is subset of Ignition config spec v3.3.0
+type ignitionConfig struct {
+	Ignition struct {
+		Version string `json:"version"`
+	} `json:"ignition"`
+	Storage struct {
+		Files []ignitionFile `json:"files"`
+	} `json:"storage"`
+	Systemd struct {
+		Units []ignitionUnit `json:"units"`
+	} `json:"systemd"`
+}
+
+type ignitionFile struct {
+	Path     string `json:"path"`
+	Mode     int    `json:"mode"`
+	Contents struct {
+		Source string `json:"source"`
+	} `json:"contents"`
+}
+
+type ignitionUnit struct {
+	Name     string `json:"name"`
+	Enabled  bool   `json:"enabled"`
+	Contents string `json:"contents"`
+}
+
+// ignitionUnitContents run setup script after network is online, Ignition can't run commands directly
+const ignitionUnitContents = `[Unit]
+Description=Setup runner of myshoes
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=simple
+ExecStart=/bin/bash %s
+
+[Install]
+WantedBy=multi-user.target
+`
+
+func toIgnition(script string) (string, error) {
+	var c ignitionConfig
+	c.Ignition.Version = "3.3.0"
+
+	f := ignitionFile{Path: userDataScriptPath, Mode: 0755}
+	f.Contents.Source = "data:;base64," + base64.StdEncoding.EncodeToString([]byte(script))
+	c.Storage.Files = []ignitionFile{f}
+	c.Systemd.Units = []ignitionUnit{{
+		Name:     "myshoes-setup.service",
+		Enabled:  true,
+		Contents: fmt.Sprintf(ignitionUnitContents, userDataScriptPath),
+	}}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal ignition config: %w", err)
+	}
+	return string(b), nil
+}
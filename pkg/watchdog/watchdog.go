@@ -0,0 +1,139 @@
+package watchdog
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+var (
+	// CheckInterval is interval of checking liveness of loops
+	CheckInterval = 10 * time.Second
+	// RestartGracePeriod is time of waiting a wedged loop exits after canceled
+	RestartGracePeriod = 30 * time.Second
+)
+
+type watchKey struct{}
+
+type watched struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+var (
+	statsMu     sync.RWMutex
+	restarts    = map[string]int64{}
+	watchedLoop = map[string]*watched{}
+)
+
+// Beat report liveness of a loop that supervised by Supervise. call it in every iteration of a loop.
+// Beat do nothing if ctx is not supervised.
+func Beat(ctx context.Context) {
+	w, ok := ctx.Value(watchKey{}).(*watched)
+	if !ok {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.last = time.Now()
+}
+
+func (w *watched) lastBeat() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.last
+}
+
+// Supervise run loop, and restart it if Beat is not called over timeout.
+// Supervise return when loop return (error of loop is returned as is), or ctx is done.
+// timeout 0 is disabled watchdog, loop is only called.
+func Supervise(ctx context.Context, name string, timeout time.Duration, loop func(ctx context.Context) error) error {
+	if timeout == 0 {
+		return loop(ctx)
+	}
+
+	for {
+		w := &watched{last: time.Now()}
+		statsMu.Lock()
+		watchedLoop[name] = w
+		statsMu.Unlock()
+
+		loopCtx, cancel := context.WithCancel(context.WithValue(ctx, watchKey{}, w))
+		done := make(chan error, 1)
+		go func() {
+			done <- loop(loopCtx)
+		}()
+
+		wedged, err := wait(ctx, name, timeout, w, done)
+		cancel()
+		if !wedged {
+			return err
+		}
+
+		logger.Logf(false, "ALERT: %s loop is not alive over %s, will restart it", name, timeout)
+		statsMu.Lock()
+		restarts[name]++
+		statsMu.Unlock()
+
+		select {
+		case <-done:
+		case <-time.After(RestartGracePeriod):
+			// loop is not respect ctx, leave it and start new one
+			logger.Logf(false, "%s loop is not exited in %s after canceled, start a new loop", name, RestartGracePeriod)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// wait wait to exit loop, return true if loop is wedged
+func wait(ctx context.Context, name string, timeout time.Duration, w *watched, done <-chan error) (bool, error) {
+	ticker := time.NewTicker(CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			return false, err
+		case <-ticker.C:
+			if time.Since(w.lastBeat()) > timeout {
+				return true, nil
+			}
+		case <-ctx.Done():
+			select {
+			case err := <-done:
+				return false, err
+			case <-time.After(RestartGracePeriod):
+				logger.Logf(false, "%s loop is not exited in %s after canceled", name, RestartGracePeriod)
+				return false, nil
+			}
+		}
+	}
+}
+
+// LastBeats return last time of Beat per loop
+func LastBeats() map[string]time.Time {
+	statsMu.RLock()
+	defer statsMu.RUnlock()
+
+	r := make(map[string]time.Time, len(watchedLoop))
+	for name, w := range watchedLoop {
+		r[name] = w.lastBeat()
+	}
+	return r
+}
+
+// Restarts return the number of restart per loop
+func Restarts() map[string]int64 {
+	statsMu.RLock()
+	defer statsMu.RUnlock()
+
+	r := make(map[string]int64, len(restarts))
+	for name, count := range restarts {
+		r[name] = count
+	}
+	return r
+}
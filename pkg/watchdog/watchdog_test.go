@@ -0,0 +1,61 @@
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSupervise(t *testing.T) {
+	CheckInterval = 10 * time.Millisecond
+	RestartGracePeriod = 100 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{}, 10)
+	errCh := make(chan error)
+	go func() {
+		errCh <- Supervise(ctx, "test", 50*time.Millisecond, func(ctx context.Context) error {
+			started <- struct{}{}
+			// wedged, not call Beat
+			<-ctx.Done()
+			return nil
+		})
+	}()
+
+	<-started
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("wedged loop must be restarted")
+	}
+	if Restarts()["test"] == 0 {
+		t.Fatalf("restart must be counted")
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf("failed to supervise: %+v", err)
+	}
+}
+
+func TestSupervise_Alive(t *testing.T) {
+	CheckInterval = 10 * time.Millisecond
+
+	wantErr := errors.New("loop error")
+	err := Supervise(context.Background(), "alive", 50*time.Millisecond, func(ctx context.Context) error {
+		for i := 0; i < 10; i++ {
+			Beat(ctx)
+			time.Sleep(20 * time.Millisecond)
+		}
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("must return error of loop, but got %+v", err)
+	}
+	if Restarts()["alive"] != 0 {
+		t.Fatalf("alive loop must not be restarted")
+	}
+}
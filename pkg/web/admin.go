@@ -0,0 +1,43 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// LogLevel is request and response of /admin/loglevel
+type LogLevel struct {
+	// Level is one of debug, info, warn, error
+	Level string `json:"level"`
+}
+
+func handleLogLevelRead(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(LogLevel{Level: strings.ToLower(logger.Level().String())})
+}
+
+func handleLogLevelUpdate(w http.ResponseWriter, r *http.Request) {
+	i := LogLevel{}
+	if err := json.NewDecoder(r.Body).Decode(&i); err != nil {
+		logger.Logf(false, "failed to decode request body: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
+		return
+	}
+
+	l, err := logger.ParseLevel(i.Level)
+	if err != nil {
+		outputErrorMsg(w, http.StatusBadRequest, "level must be one of debug, info, warn, error")
+		return
+	}
+	old := logger.Level()
+	logger.SetLevel(l)
+	logger.Logf(false, "switch log level from %s to %s", old, l)
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(LogLevel{Level: strings.ToLower(l.String())})
+}
@@ -0,0 +1,71 @@
+package web_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/whywaita/myshoes/internal/testutils"
+	"github.com/whywaita/myshoes/pkg/logger"
+	"github.com/whywaita/myshoes/pkg/web"
+)
+
+func Test_handleLogLevelUpdate(t *testing.T) {
+	testURL := testutils.GetTestURL()
+	defer logger.SetLevel(slog.LevelInfo)
+
+	tests := []struct {
+		input    string
+		wantCode int
+		want     slog.Level
+	}{
+		{
+			input:    `{"level": "debug"}`,
+			wantCode: http.StatusOK,
+			want:     slog.LevelDebug,
+		},
+		{
+			input:    `{"level": "WARN"}`,
+			wantCode: http.StatusOK,
+			want:     slog.LevelWarn,
+		},
+		{
+			input:    `{"level": "verbose"}`,
+			wantCode: http.StatusBadRequest,
+			want:     slog.LevelWarn,
+		},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodPut, testURL+"/admin/loglevel", bytes.NewBufferString(test.input))
+		if err != nil {
+			t.Fatalf("failed to create request: %+v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to PUT request: %+v", err)
+		}
+		content, code := parseResponse(resp)
+		if code != test.wantCode {
+			t.Fatalf("must be response statuscode is %d, but got %d: %+v", test.wantCode, code, string(content))
+		}
+		if got := logger.Level(); got != test.want {
+			t.Errorf("want %s, but got %s", test.want, got)
+		}
+	}
+
+	resp, err := http.Get(testURL + "/admin/loglevel")
+	if err != nil {
+		t.Fatalf("failed to GET request: %+v", err)
+	}
+	content, _ := parseResponse(resp)
+	var got web.LogLevel
+	if err := json.Unmarshal(content, &got); err != nil {
+		t.Fatalf("failed to unmarshal response JSON: %+v", err)
+	}
+	if got.Level != "warn" {
+		t.Errorf("want warn, but got %s", got.Level)
+	}
+}
@@ -0,0 +1,101 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+	"github.com/whywaita/myshoes/pkg/runner"
+)
+
+// AdoptRequestParam is parameter for adopting runners that registered outside myshoes
+type AdoptRequestParam struct {
+	// NamePrefix is prefix of runner name in GitHub
+	NamePrefix string `json:"name_prefix"`
+	// Labels is labels that runner must have all of
+	Labels []string `json:"labels"`
+	// CloudIDs is map of runner name to cloud ID in shoes-provider
+	CloudIDs map[string]string `json:"cloud_ids"`
+	// DryRun is true if only list runners that will be adopted
+	DryRun bool `json:"dry_run"`
+}
+
+// AdoptedRunner is a runner that adopted
+type AdoptedRunner struct {
+	RunnerID   uuid.UUID `json:"runner_id"`
+	RunnerName string    `json:"runner_name"`
+	CloudID    string    `json:"cloud_id"`
+}
+
+// AdoptResponse is response of adopt request
+type AdoptResponse struct {
+	DryRun  bool            `json:"dry_run"`
+	Runners []AdoptedRunner `json:"runners"`
+}
+
+func handleAdoptRequest(w http.ResponseWriter, r *http.Request, ds datastore.Datastore) {
+	ctx := r.Context()
+	targetID, err := parseReqTargetID(r)
+	if err != nil {
+		logger.Logf(false, "failed to decode request body: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, "incorrect target id")
+		return
+	}
+
+	input := AdoptRequestParam{}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Logf(false, "failed to decode request body: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
+		return
+	}
+	if input.NamePrefix == "" && len(input.Labels) == 0 {
+		outputErrorMsg(w, http.StatusBadRequest, "name_prefix or labels is required")
+		return
+	}
+
+	target, err := ds.GetTarget(ctx, targetID)
+	if err != nil {
+		logger.Logf(false, "failed to get target: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, "incorrect target id (not found)")
+		return
+	}
+	if !target.CanReceiveJob() {
+		outputErrorMsg(w, http.StatusBadRequest, fmt.Sprintf("target is %s now, can not adopt runners", target.Status))
+		return
+	}
+
+	adopted, err := runner.Adopt(ctx, ds, *target, runner.AdoptOption{
+		NamePrefix: input.NamePrefix,
+		Labels:     input.Labels,
+		CloudIDs:   input.CloudIDs,
+		DryRun:     input.DryRun,
+	})
+	switch {
+	case errors.Is(err, datastore.ErrConflict):
+		logger.Logf(false, "failed to adopt runners: %+v", err)
+		outputErrorMsg(w, http.StatusConflict, "runner is updated by other process, retry later")
+		return
+	case err != nil:
+		logger.Logf(false, "failed to adopt runners: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "failed to adopt runners")
+		return
+	}
+
+	resp := AdoptResponse{DryRun: input.DryRun, Runners: []AdoptedRunner{}}
+	for _, a := range adopted {
+		resp.Runners = append(resp.Runners, AdoptedRunner{
+			RunnerID:   a.UUID,
+			RunnerName: a.RunnerName.String,
+			CloudID:    a.CloudID,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,189 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"goji.io/pat"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+	"github.com/whywaita/myshoes/pkg/runner"
+)
+
+// BootstrapStatus values that reported by setup script of runner
+const (
+	BootstrapStatusProgress = "progress"
+	BootstrapStatusSuccess  = "success"
+	BootstrapStatusFailure  = "failure"
+)
+
+var bootstrapReports = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "myshoes",
+	Subsystem: "runner",
+	Name:      "bootstrap_reports_total",
+	Help:      "Total number of reports from setup script of runner per phase and status.",
+}, []string{"phase", "status"})
+
+func init() {
+	prometheus.MustRegister(bootstrapReports)
+}
+
+// BootstrapReport is request body of POST /runners/:name/bootstrap
+type BootstrapReport struct {
+	Status  string `json:"status"`
+	Phase   string `json:"phase"`
+	Message string `json:"message"`
+}
+
+func handleRunnerBootstrap(w http.ResponseWriter, r *http.Request, ds datastore.Datastore) {
+	ctx := r.Context()
+	runnerName := pat.Param(r, "name")
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !runner.VerifyCallbackToken(runnerName, token) {
+		outputErrorMsg(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+	runnerID, err := runner.ToUUID(runnerName)
+	if err != nil {
+		outputErrorMsg(w, http.StatusBadRequest, "incorrect runner name")
+		return
+	}
+
+	if runner.NeedIdentityVerification() {
+		rr, err := ds.GetRunner(ctx, runnerID)
+		switch {
+		case errors.Is(err, datastore.ErrNotFound):
+			outputErrorMsg(w, http.StatusNotFound, "runner is not found")
+			return
+		case err != nil:
+			logger.Logf(false, "failed to get runner: %+v", err)
+			outputErrorMsg(w, http.StatusInternalServerError, "datastore read error")
+			return
+		}
+		if err := runner.VerifyIdentity(*rr, callerIdentity(r)); err != nil {
+			logger.Logf(false, "failed to verify identity of runner %s, reject report: %+v", runnerName, err)
+			outputErrorMsg(w, http.StatusForbidden, "identity of runner is not verified")
+			return
+		}
+	}
+
+	var report BootstrapReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
+		return
+	}
+
+	switch report.Status {
+	case BootstrapStatusProgress:
+		logger.Logf(true, "setup of runner %s: %s", runnerName, report.Phase)
+	case BootstrapStatusSuccess:
+		logger.Logf(false, "setup of runner %s is succeeded", runnerName)
+	case BootstrapStatusFailure:
+		logger.Logf(false, "setup of runner %s is failed in %s: %s", runnerName, report.Phase, report.Message)
+		datastore.RecordHistory(ctx, ds, datastore.HistoryResourceRunner, runnerID, datastore.HistoryStatusFailed, fmt.Sprintf("setup failed in %s: %s", report.Phase, report.Message))
+	default:
+		outputErrorMsg(w, http.StatusBadRequest, fmt.Sprintf("unknown status: %s", report.Status))
+		return
+	}
+	bootstrapReports.WithLabelValues(report.Phase, report.Status).Inc()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRunnerToken return registration token of runner to instance at boot in callback mode.
+// ticket is bound to runner and short-lived, and token is issued only once per runner.
+func handleRunnerToken(w http.ResponseWriter, r *http.Request, ds datastore.Datastore) {
+	ctx := r.Context()
+	runnerName := pat.Param(r, "name")
+
+	ticket := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if err := runner.VerifyTokenTicket(runnerName, ticket, time.Now()); err != nil {
+		logger.Logf(false, "failed to verify ticket of runner %s: %+v", runnerName, err)
+		outputErrorMsg(w, http.StatusUnauthorized, "invalid ticket")
+		return
+	}
+	runnerID, err := runner.ToUUID(runnerName)
+	if err != nil {
+		outputErrorMsg(w, http.StatusBadRequest, "incorrect runner name")
+		return
+	}
+
+	// runner is created in datastore after instance is created, So instance should retry if not found
+	rr, err := ds.GetRunner(ctx, runnerID)
+	switch {
+	case errors.Is(err, datastore.ErrNotFound):
+		outputErrorMsg(w, http.StatusNotFound, "runner is not found")
+		return
+	case err != nil:
+		logger.Logf(false, "failed to get runner: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "datastore read error")
+		return
+	}
+	if err := runner.VerifyIdentity(*rr, callerIdentity(r)); err != nil {
+		logger.Logf(false, "failed to verify identity of runner %s, reject request from %s: %+v", runnerName, r.RemoteAddr, err)
+		outputErrorMsg(w, http.StatusForbidden, "identity of runner is not verified")
+		return
+	}
+	target, err := ds.GetTarget(ctx, rr.TargetID)
+	if err != nil {
+		logger.Logf(false, "failed to get target: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "datastore read error")
+		return
+	}
+	ctx, err = target.WithGitHubHost(ctx)
+	if err != nil {
+		logger.Logf(false, "failed to get GitHub host: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "failed to get GitHub host")
+		return
+	}
+	installationID, err := GHIsInstalledGitHubApp(ctx, target.Scope)
+	if err != nil {
+		logger.Logf(false, "failed to get installation id: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "failed to get installation")
+		return
+	}
+	token, err := GHGetRunnerRegistrationToken(ctx, installationID, target.Scope)
+	if err != nil {
+		logger.Logf(false, "failed to generate runner registration token: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "failed to generate registration token")
+		return
+	}
+
+	// mark atomically before response for issuing only once, concurrent requests by same ticket get conflict
+	if err := ds.MarkRunnerTokenIssued(ctx, runnerID, time.Now()); err != nil {
+		if errors.Is(err, datastore.ErrConflict) {
+			logger.Logf(false, "registration token of runner %s is already issued, reject request from %s", runnerName, r.RemoteAddr)
+			outputErrorMsg(w, http.StatusGone, "token is already issued")
+			return
+		}
+		logger.Logf(false, "failed to mark token issued: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "datastore write error")
+		return
+	}
+	datastore.RecordHistory(ctx, ds, datastore.HistoryResourceRunner, runnerID, datastore.HistoryStatusTokenIssued, fmt.Sprintf("requested from %s", r.RemoteAddr))
+
+	w.Header().Set("Content-Type", "text/plain;charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(token))
+}
+
+// callerIdentity return identity of caller of runner callback
+func callerIdentity(r *http.Request) runner.CallerIdentity {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return runner.CallerIdentity{
+		RemoteIP: host,
+		Header:   r.Header,
+	}
+}
@@ -0,0 +1,228 @@
+package web_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
+	"github.com/whywaita/myshoes/pkg/runner"
+	"github.com/whywaita/myshoes/pkg/web"
+)
+
+var (
+	testBootstrapTargetID = uuid.FromStringOrNil("8cc1a4d5-4bf1-43d5-8d8f-3a3b7c5a4f7e")
+	testBootstrapRunnerID = uuid.FromStringOrNil("7943c6d4-5b3e-4d57-8fb8-1a5b2b3e9c0d")
+)
+
+// newBootstrapServer create server that has a target and a runner in memory datastore
+func newBootstrapServer(t *testing.T, runnerIP string) *httptest.Server {
+	t.Helper()
+
+	config.Config.RunnerIdentityVerification = config.RunnerIdentityToken
+	setStubFunctions()
+	web.GHGetRunnerRegistrationToken = func(ctx context.Context, installationID int64, scope string) (string, error) {
+		return "registration-token", nil
+	}
+
+	ds, err := memory.New(nil)
+	if err != nil {
+		t.Fatalf("failed to create datastore: %+v", err)
+	}
+	ctx := context.Background()
+	if err := ds.CreateTarget(ctx, datastore.Target{
+		UUID:           testBootstrapTargetID,
+		Scope:          "octocat/hello-world",
+		GitHubToken:    testGitHubAppToken,
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+	if err := ds.CreateRunner(ctx, datastore.Runner{
+		UUID:         testBootstrapRunnerID,
+		ShoesType:    "shoes-mock",
+		IPAddress:    runnerIP,
+		TargetID:     testBootstrapTargetID,
+		CloudID:      "mock-cloud-id",
+		ResourceType: datastore.ResourceTypeNano,
+	}); err != nil {
+		t.Fatalf("failed to create runner: %+v", err)
+	}
+
+	ts := httptest.NewServer(web.NewMux(ds))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func getRunnerToken(t *testing.T, ts *httptest.Server, runnerName, ticket string) int {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/runners/"+runnerName+"/token", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %+v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+ticket)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to GET request: %+v", err)
+	}
+	_, code := parseResponse(resp)
+	return code
+}
+
+func Test_handleRunnerToken(t *testing.T) {
+	config.Config.GitHub.AppSecret, config.Config.GitHub.AppSecrets = []byte("secret"), nil
+	runnerName := runner.ToName(testBootstrapRunnerID.String())
+	otherName := runner.ToName(uuid.NewV4().String())
+
+	tests := []struct {
+		name   string
+		ticket string
+		want   []int // status code of each fetch
+	}{
+		{
+			name:   "issued only once",
+			ticket: runner.IssueTokenTicket(runnerName, time.Now(), time.Minute),
+			want:   []int{http.StatusOK, http.StatusGone},
+		},
+		{
+			name:   "expired",
+			ticket: runner.IssueTokenTicket(runnerName, time.Now().Add(-time.Hour), time.Minute),
+			want:   []int{http.StatusUnauthorized},
+		},
+		{
+			name:   "ticket of other runner",
+			ticket: runner.IssueTokenTicket(otherName, time.Now(), time.Minute),
+			want:   []int{http.StatusUnauthorized},
+		},
+	}
+
+	for _, test := range tests {
+		ts := newBootstrapServer(t, "127.0.0.1")
+		for i, want := range test.want {
+			if got := getRunnerToken(t, ts, runnerName, test.ticket); got != want {
+				t.Errorf("%s: fetch #%d want %d, but got %d", test.name, i, want, got)
+			}
+		}
+	}
+}
+
+func Test_handleRunnerToken_Concurrent(t *testing.T) {
+	config.Config.GitHub.AppSecret, config.Config.GitHub.AppSecrets = []byte("secret"), nil
+	ts := newBootstrapServer(t, "127.0.0.1")
+	runnerName := runner.ToName(testBootstrapRunnerID.String())
+	ticket := runner.IssueTokenTicket(runnerName, time.Now(), time.Minute)
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 10)
+	for i := 0; i < cap(codes); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- getRunnerToken(t, ts, runnerName, ticket)
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	issued := 0
+	for code := range codes {
+		switch code {
+		case http.StatusOK:
+			issued++
+		case http.StatusGone:
+		default:
+			t.Errorf("want %d or %d, but got %d", http.StatusOK, http.StatusGone, code)
+		}
+	}
+	if issued != 1 {
+		t.Errorf("token must be issued only once, but issued %d times", issued)
+	}
+}
+
+func Test_handleRunnerToken_IdentityIP(t *testing.T) {
+	config.Config.GitHub.AppSecret, config.Config.GitHub.AppSecrets = []byte("secret"), nil
+	runnerName := runner.ToName(testBootstrapRunnerID.String())
+
+	tests := []struct {
+		name     string
+		runnerIP string
+		want     int
+	}{
+		{
+			name:     "match",
+			runnerIP: "127.0.0.1",
+			want:     http.StatusOK,
+		},
+		{
+			name:     "mismatch",
+			runnerIP: "192.0.2.1",
+			want:     http.StatusForbidden,
+		},
+		{
+			name:     "not reported yet",
+			runnerIP: "",
+			want:     http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		ts := newBootstrapServer(t, test.runnerIP)
+		config.Config.RunnerIdentityVerification = config.RunnerIdentityIP
+		ticket := runner.IssueTokenTicket(runnerName, time.Now(), time.Minute)
+		if got := getRunnerToken(t, ts, runnerName, ticket); got != test.want {
+			t.Errorf("%s: want %d, but got %d", test.name, test.want, got)
+		}
+	}
+	config.Config.RunnerIdentityVerification = config.RunnerIdentityToken
+}
+
+func Test_handleRunnerBootstrap_IdentityIP(t *testing.T) {
+	config.Config.GitHub.AppSecret, config.Config.GitHub.AppSecrets = []byte("secret"), nil
+	runnerName := runner.ToName(testBootstrapRunnerID.String())
+
+	tests := []struct {
+		name     string
+		runnerIP string
+		want     int
+	}{
+		{
+			name:     "match",
+			runnerIP: "127.0.0.1",
+			want:     http.StatusNoContent,
+		},
+		{
+			name:     "mismatch",
+			runnerIP: "192.0.2.1",
+			want:     http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		ts := newBootstrapServer(t, test.runnerIP)
+		config.Config.RunnerIdentityVerification = config.RunnerIdentityIP
+
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/runners/"+runnerName+"/bootstrap", strings.NewReader(`{"status":"success","phase":"done"}`))
+		if err != nil {
+			t.Fatalf("failed to create request: %+v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+runner.CallbackToken(runnerName))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to POST request: %+v", err)
+		}
+		if _, got := parseResponse(resp); got != test.want {
+			t.Errorf("%s: want %d, but got %d", test.name, test.want, got)
+		}
+	}
+	config.Config.RunnerIdentityVerification = config.RunnerIdentityToken
+}
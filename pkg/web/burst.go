@@ -0,0 +1,84 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/patrickmn/go-cache"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// burstRuns is IDs of workflow runs per GitHub host that are already pre-scaled, a run is pre-scaled only once
+var burstRuns = cache.New(6*time.Hour, 1*time.Hour)
+
+// preScaleWorkflowRun enqueue queued jobs of workflow run at once in background, up to BurstMaxRunners.
+// jobs are processed as same as received webhook, so jobs that webhook is received later are deduplicated.
+func preScaleWorkflowRun(ctx context.Context, ds datastore.Datastore, installationID int64, repo *github.Repository, runID int64) {
+	if config.Config.BurstMaxRunners <= 0 || runID == 0 {
+		return
+	}
+	key := fmt.Sprintf("%s-%d", gh.HostFrom(ctx).Hostname(), runID)
+	if err := burstRuns.Add(key, struct{}{}, cache.DefaultExpiration); err != nil {
+		// already pre-scaled
+		return
+	}
+
+	go func(ctx context.Context) {
+		enqueued, err := preScaleJobs(ctx, ds, installationID, repo, runID)
+		if err != nil {
+			logger.Logf(false, "failed to pre-scale workflow run (repository: %s, run ID: %d): %+v", repo.GetFullName(), runID, err)
+			return
+		}
+		if enqueued > 0 {
+			logger.Logf(false, "pre-scaled %d runners for workflow run (repository: %s, run ID: %d)", enqueued, repo.GetFullName(), runID)
+		}
+	}(context.WithoutCancel(ctx))
+}
+
+// preScaleJobs enqueue queued jobs of workflow run, return number of enqueued jobs
+func preScaleJobs(ctx context.Context, ds datastore.Datastore, installationID int64, repo *github.Repository, runID int64) (int, error) {
+	repoName := repo.GetFullName()
+	if _, err := datastore.SearchRepoWithEnterprise(ctx, ds, repoName, getEnterprise(ctx)); err != nil {
+		logger.Logf(true, "target of %s is not found, ignore pre-scaling: %+v", repoName, err)
+		return 0, nil
+	}
+	if err := gh.CheckBudget(repoName); err != nil {
+		logger.Logf(true, "skip to pre-scale workflow run (%s): %+v", repoName, err)
+		return 0, nil
+	}
+	ctx = gh.WithBudgetScope(ctx, repoName)
+	if config.Config.GitHubTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Config.GitHubTimeout)
+		defer cancel()
+	}
+
+	jobs, err := GHListQueuedJobsInRun(ctx, installationID, repo.GetOwner().GetLogin(), repo.GetName(), runID)
+	if err != nil {
+		return 0, err
+	}
+
+	var enqueued int
+	for _, j := range jobs {
+		if enqueued >= config.Config.BurstMaxRunners {
+			logger.Logf(false, "number of queued jobs in workflow run exceeds %s, rest of jobs are provisioned by webhook (repository: %s, run ID: %d, queued: %d)", config.EnvBurstMaxRunners, repoName, runID, len(jobs))
+			break
+		}
+		if !isRequestedMyshoesLabel(j.Labels) || isScaleSetLabel(j.Labels) {
+			continue
+		}
+		q := gh.QueuedJob{Repository: repo, Job: j}
+		if err := receiveWorkflowJobWebhook(ctx, toQueuedEvent(q, installationID), ds); err != nil {
+			logger.Logf(false, "failed to enqueue pre-scaled job (repository: %s, job ID: %d): %+v", repoName, j.GetID(), err)
+			continue
+		}
+		enqueued++
+	}
+	return enqueued, nil
+}
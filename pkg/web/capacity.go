@@ -0,0 +1,118 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v47/github"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// CapacityRequestParam is parameter for requesting generic instances
+type CapacityRequestParam struct {
+	// Repository is :owner/:repo, required if target is organization
+	Repository string `json:"repository"`
+	gh.DispatchPayload
+}
+
+// CapacityResponse is response of capacity request
+type CapacityResponse struct {
+	JobIDs []uuid.UUID `json:"job_ids"`
+}
+
+func handleCapacityRequest(w http.ResponseWriter, r *http.Request, ds datastore.Datastore) {
+	ctx := r.Context()
+	targetID, err := parseReqTargetID(r)
+	if err != nil {
+		logger.Logf(false, "failed to decode request body: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, "incorrect target id")
+		return
+	}
+
+	input := CapacityRequestParam{}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Logf(false, "failed to decode request body: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
+		return
+	}
+	if err := input.Validate(); err != nil {
+		logger.Logf(false, "failed to validate input: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	target, err := ds.GetTarget(ctx, targetID)
+	if err != nil {
+		logger.Logf(false, "failed to get target: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, "incorrect target id (not found)")
+		return
+	}
+	if !target.CanReceiveJob() {
+		outputErrorMsg(w, http.StatusBadRequest, fmt.Sprintf("target is %s now, can not receive request", target.Status))
+		return
+	}
+
+	repoName := input.Repository
+	if gh.DetectScope(target.Scope) == gh.Repository {
+		repoName = target.Scope
+	}
+	if repoName == "" {
+		outputErrorMsg(w, http.StatusBadRequest, "repository is required if target is organization")
+		return
+	}
+
+	jobIDs, err := enqueueCapacityJobs(ctx, ds, *target, repoName, input.DispatchPayload)
+	if err != nil {
+		logger.Logf(false, "failed to enqueue capacity jobs: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "datastore create error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CapacityResponse{JobIDs: jobIDs})
+}
+
+// enqueueCapacityJobs enqueue jobs as repository_dispatch event, so these are processed like jobs from webhook
+func enqueueCapacityJobs(ctx context.Context, ds datastore.Datastore, target datastore.Target, repoName string, payload gh.DispatchPayload) ([]uuid.UUID, error) {
+	clientPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal client_payload: %w", err)
+	}
+	event := github.RepositoryDispatchEvent{
+		Action:        github.String(gh.CapacityEventType),
+		ClientPayload: clientPayload,
+		Repo: &github.Repository{
+			FullName: github.String(repoName),
+		},
+	}
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	var jobIDs []uuid.UUID
+	for i := 0; i < payload.GetCount(); i++ {
+		j := datastore.Job{
+			UUID:           datastore.NewID(),
+			GHEDomain:      target.GHEDomain,
+			Repository:     repoName,
+			CheckEventJSON: string(eventJSON),
+			TargetID:       target.UUID,
+		}
+		if _, err := ds.EnqueueJob(ctx, j); err != nil {
+			return jobIDs, fmt.Errorf("failed to enqueue job: %w", err)
+		}
+		datastore.RecordHistory(ctx, ds, datastore.HistoryResourceJob, j.UUID, datastore.HistoryStatusEnqueued, "received capacity request")
+		datastore.RecordJobReceived(ctx, ds, j, 0)
+		jobIDs = append(jobIDs, j.UUID)
+	}
+
+	return jobIDs, nil
+}
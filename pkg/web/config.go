@@ -2,6 +2,7 @@ package web
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
 
 	"github.com/whywaita/myshoes/pkg/config"
@@ -16,6 +17,10 @@ type inputConfigStrict struct {
 	Strict bool `json:"strict"`
 }
 
+type inputConfigGCDryRun struct {
+	DryRun bool `json:"dry_run"`
+}
+
 func handleConfigDebug(w http.ResponseWriter, r *http.Request) {
 	i := inputConfigDebug{}
 
@@ -25,7 +30,11 @@ func handleConfigDebug(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	config.Config.Debug = i.Debug
+	if i.Debug {
+		logger.SetLevel(slog.LevelDebug)
+	} else {
+		logger.SetLevel(slog.LevelInfo)
+	}
 	logger.Logf(false, "switch debug mode to %t", i.Debug)
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -43,3 +52,22 @@ func handleConfigStrict(w http.ResponseWriter, r *http.Request) {
 	logger.Logf(false, "switch strict mode to %t", i.Strict)
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// handleConfigGCDryRun switch dry-run mode of runner manager in the leader.
+// it is not persisted, new leader after failover use GC_DRY_RUN
+func handleConfigGCDryRun(w http.ResponseWriter, r *http.Request) {
+	if !requireLeader(w, "switching dry-run mode of GC") {
+		return
+	}
+	i := inputConfigGCDryRun{}
+
+	if err := json.NewDecoder(r.Body).Decode(&i); err != nil {
+		logger.Logf(false, "failed to decode request body: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
+		return
+	}
+
+	config.Config.GCDryRun = i.DryRun
+	logger.Logf(false, "switch dry-run mode of GC to %t", i.DryRun)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,110 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// deliverySyncOverlap is overlap of periodic sync, a delivery that is in flight in previous sync is checked again
+const deliverySyncOverlap = 1 * time.Minute
+
+// LoopSyncDeliveries sync missed webhook deliveries on startup, and periodically.
+// on startup, deliveries since the last received job (up to lookback) are synced.
+func LoopSyncDeliveries(ctx context.Context, ds datastore.Datastore, lookback, interval time.Duration) error {
+	if lookback == 0 {
+		logger.Logf(true, "sync of webhook deliveries is disabled")
+		return nil
+	}
+	if gh.IsPATMode() {
+		logger.Logf(false, "sync of webhook deliveries is not available in personal access token mode")
+		return nil
+	}
+	since := lastReceivedAt(ctx, ds, time.Now().UTC().Add(-lookback))
+	logger.Logf(false, "start to sync missed webhook deliveries since %s", since)
+
+	for {
+		startedAt := time.Now().UTC()
+		synced, err := SyncMissedDeliveries(ctx, ds, since)
+		if err != nil {
+			logger.Logf(false, "failed to sync missed webhook deliveries, will retry: %+v", err)
+		} else {
+			if synced > 0 {
+				logger.Logf(false, "re-ingested %d missed webhook deliveries since %s", synced, since)
+			}
+			since = startedAt.Add(-deliverySyncOverlap)
+		}
+
+		if interval == 0 {
+			return nil
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// lastReceivedAt return time of the last job that myshoes received, return floor if no job is received after floor
+func lastReceivedAt(ctx context.Context, ds datastore.Datastore, floor time.Time) time.Time {
+	histories, err := ds.ListJobHistories(ctx, uuid.Nil, floor, 1)
+	if err != nil {
+		logger.Logf(false, "failed to get last received job, will sync since %s: %+v", floor, err)
+		return floor
+	}
+	if len(histories) == 0 || histories[0].ReceivedAt.Before(floor) {
+		return floor
+	}
+	return histories[0].ReceivedAt
+}
+
+// SyncMissedDeliveries re-ingest events that failed to deliver to myshoes since `since` (e.g. myshoes was down).
+// jobs that already enqueued are deduplicated by ID of job in GitHub. return number of re-ingested deliveries
+func SyncMissedDeliveries(ctx context.Context, ds datastore.Datastore, since time.Time) (int, error) {
+	deliveries, err := gh.ListHookDeliveries(ctx, since)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	var synced int
+	for _, d := range gh.MissedDeliveries(deliveries, config.Config.ModeWebhookType.String()) {
+		payload, err := gh.GetHookDeliveryPayload(ctx, d.ID)
+		if err != nil {
+			logger.Logf(false, "failed to get payload of missed delivery (guid: %s): %+v", d.GUID, err)
+			continue
+		}
+		if err := ingestDelivery(ctx, ds, d, payload); err != nil {
+			logger.Logf(false, "failed to re-ingest missed delivery (guid: %s): %+v", d.GUID, err)
+			continue
+		}
+		synced++
+	}
+	return synced, nil
+}
+
+// ingestDelivery process payload of delivery same as received webhook
+func ingestDelivery(ctx context.Context, ds datastore.Datastore, d gh.HookDelivery, payload []byte) error {
+	ctx = withDeliveryID(ctx, d.GUID)
+	ctx = withEnterprise(ctx, gh.ExtractEnterpriseSlug(payload))
+
+	webhookEvent, err := github.ParseWebHook(d.Event, payload)
+	if err != nil {
+		return fmt.Errorf("failed to parse webhook payload: %w", err)
+	}
+	switch event := webhookEvent.(type) {
+	case *github.WorkflowJobEvent:
+		return receiveWorkflowJobWebhook(ctx, event, ds)
+	case *github.CheckRunEvent:
+		return receiveCheckRunWebhook(ctx, event, ds)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/whywaita/myshoes/pkg/runner"
+)
+
+// handleGCReport return report of last cycle of runner manager, it is only in the leader
+func handleGCReport(w http.ResponseWriter, r *http.Request) {
+	if !requireLeader(w, "report of GC") {
+		return
+	}
+	report := runner.LastGCReport()
+	if report == nil {
+		outputErrorMsg(w, http.StatusNotFound, "runner manager is not finished a cycle yet")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
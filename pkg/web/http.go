@@ -9,6 +9,7 @@ import (
 
 	"github.com/whywaita/myshoes/pkg/config"
 	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/lock"
 	"github.com/whywaita/myshoes/pkg/logger"
 
 	goji "goji.io"
@@ -23,10 +24,19 @@ func NewMux(ds datastore.Datastore) *goji.Mux {
 		w.Header().Set("Content-Type", "application/json;charset=utf-8")
 		w.WriteHeader(http.StatusOK)
 
+		// datastore under pressure is still healthy, only non-critical writes are shed
+		datastoreState := "ok"
+		if datastore.IsUnderPressure() {
+			datastoreState = "under_pressure"
+		}
 		h := struct {
-			Health string `json:"health"`
+			Health    string `json:"health"`
+			Datastore string `json:"datastore"`
+			Leader    bool   `json:"leader"` // true if this replica is leader, some endpoints are only available in the leader
 		}{
-			Health: "ok",
+			Health:    "ok",
+			Datastore: datastoreState,
+			Leader:    lock.IsLeader.Load(),
 		}
 
 		json.NewEncoder(w).Encode(h)
@@ -58,6 +68,59 @@ func NewMux(ds datastore.Datastore) *goji.Mux {
 		apacheLogging(r)
 		handleTargetDelete(w, r, ds)
 	})
+	mux.HandleFunc(pat.Get("/targets/export"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleTargetExport(w, r, ds)
+	})
+	mux.HandleFunc(pat.Post("/targets/import"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleTargetImport(w, r, ds)
+	})
+	mux.HandleFunc(pat.Get("/targets/deleted"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleTargetDeletedList(w, r, ds)
+	})
+	mux.HandleFunc(pat.Post("/target/:id/restore"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleTargetRestore(w, r, ds)
+	})
+	mux.HandleFunc(pat.Post("/target/:id/capacity"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleCapacityRequest(w, r, ds)
+	})
+	mux.HandleFunc(pat.Post("/target/:id/adopt"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleAdoptRequest(w, r, ds)
+	})
+
+	// Job endpoints
+	// registered before /jobs/:id
+	mux.HandleFunc(pat.Get("/jobs/unmatched"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleUnmatchedJobList(w, r)
+	})
+	mux.HandleFunc(pat.Get("/jobs/:id"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleJobRead(w, r, ds)
+	})
+	mux.HandleFunc(pat.Get("/job_histories"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleJobHistoryList(w, r, ds)
+	})
+
+	// Admin endpoints
+	mux.HandleFunc(pat.Get("/admin/loglevel"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleLogLevelRead(w, r)
+	})
+	mux.HandleFunc(pat.Put("/admin/loglevel"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleLogLevelUpdate(w, r)
+	})
+	mux.HandleFunc(pat.Delete("/data"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleDataPurge(w, r, ds)
+	})
 
 	// Config endpoints
 	mux.HandleFunc(pat.Post("/config/debug"), func(w http.ResponseWriter, r *http.Request) {
@@ -68,6 +131,32 @@ func NewMux(ds datastore.Datastore) *goji.Mux {
 		apacheLogging(r)
 		handleConfigStrict(w, r)
 	})
+	mux.HandleFunc(pat.Post("/config/gc-dry-run"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleConfigGCDryRun(w, r)
+	})
+
+	// Runner callback endpoints, called from setup script in instance
+	mux.HandleFunc(pat.Post("/runners/:name/bootstrap"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleRunnerBootstrap(w, r, ds)
+	})
+	mux.HandleFunc(pat.Get("/runners/:name/token"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleRunnerToken(w, r, ds)
+	})
+
+	// GC report endpoint
+	mux.HandleFunc(pat.Get("/runners/gc-report"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleGCReport(w, r)
+	})
+
+	// GitHub rate limit endpoint
+	mux.HandleFunc(pat.Get("/rate-limits"), func(w http.ResponseWriter, r *http.Request) {
+		apacheLogging(r)
+		handleRateLimitList(w, r)
+	})
 
 	// metrics endpoint
 	mux.HandleFunc(pat.Get("/metrics"), func(w http.ResponseWriter, r *http.Request) {
@@ -78,6 +167,9 @@ func NewMux(ds datastore.Datastore) *goji.Mux {
 	return mux
 }
 
+// ShutdownTimeout is timeout of waiting in-flight requests in shutdown
+var ShutdownTimeout = 30 * time.Second
+
 // Serve start webhook receiver
 func Serve(ctx context.Context, ds datastore.Datastore) error {
 	mux := NewMux(ds)
@@ -87,7 +179,7 @@ func Serve(ctx context.Context, ds datastore.Datastore) error {
 		Handler: mux,
 	}
 
-	errCh := make(chan error)
+	errCh := make(chan error, 1)
 	go func() {
 		defer close(errCh)
 		logger.Logf(false, "start webhook receiver, listen %s", listenAddress)
@@ -98,7 +190,10 @@ func Serve(ctx context.Context, ds datastore.Datastore) error {
 
 	select {
 	case <-ctx.Done():
-		return s.Shutdown(ctx)
+		// ctx is already done, wait in-flight requests with new context
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+		defer cancel()
+		return s.Shutdown(shutdownCtx)
 	case err := <-errCh:
 		return fmt.Errorf("occurred error in web serve: %w", err)
 	}
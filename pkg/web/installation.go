@@ -0,0 +1,150 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// removedRepositoryDescription is status description of target whose repository is removed from installation of GitHub Apps
+const removedRepositoryDescription = "repository is removed from GitHub Apps"
+
+// receiveInstallationWebhook suspend (or delete) targets when GitHub Apps is uninstalled or suspended,
+// and reactivate or create targets when it is installed
+func receiveInstallationWebhook(ctx context.Context, event *github.InstallationEvent, ds datastore.Datastore) error {
+	i := event.GetInstallation()
+	account := installationAccount(i)
+	if account == "" {
+		logger.Logf(true, "account of installation is not found, ignore")
+		return nil
+	}
+
+	switch event.GetAction() {
+	case "deleted", "suspend":
+		del := event.GetAction() == "deleted" && config.Config.TargetDeleteOnUninstall
+		return walkTargetsInScope(ctx, ds, account, func(t datastore.Target) error {
+			return suspendTarget(ctx, ds, t, uninstalledDescription, del)
+		})
+	case "created", "unsuspend":
+		if err := walkTargetsInScope(ctx, ds, account, func(t datastore.Target) error {
+			return reactivateTarget(ctx, ds, t, uninstalledDescription)
+		}); err != nil {
+			return err
+		}
+		return registerInstallationByWebhook(ctx, ds, i)
+	default:
+		logger.Logf(true, "installation actions is %s, ignore", event.GetAction())
+		return nil
+	}
+}
+
+// receiveInstallationRepositoriesWebhook suspend targets of repositories that removed from installation of GitHub Apps,
+// and reactivate targets of repositories that added again
+func receiveInstallationRepositoriesWebhook(ctx context.Context, event *github.InstallationRepositoriesEvent, ds datastore.Datastore) error {
+	for _, repo := range event.RepositoriesRemoved {
+		if err := walkTargetsInScope(ctx, ds, repo.GetFullName(), func(t datastore.Target) error {
+			return suspendTarget(ctx, ds, t, removedRepositoryDescription, false)
+		}); err != nil {
+			return err
+		}
+	}
+	for _, repo := range event.RepositoriesAdded {
+		if err := walkTargetsInScope(ctx, ds, repo.GetFullName(), func(t datastore.Target) error {
+			return reactivateTarget(ctx, ds, t, removedRepositoryDescription)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// installationAccount return scope of account that installed GitHub Apps (organization, user or enterprise)
+func installationAccount(i *github.Installation) string {
+	if scope, ok := gh.InstallationScope(i); ok {
+		return scope
+	}
+	return i.GetAccount().GetLogin()
+}
+
+// walkTargetsInScope call fn with not deleted targets in scope of host in context.
+// suspended targets are included for reactivating or deleting them
+func walkTargetsInScope(ctx context.Context, ds datastore.Datastore, scope string, fn func(t datastore.Target) error) error {
+	opt := datastore.ListOption{Limit: datastore.DefaultPageSize}
+	for {
+		targets, err := ds.ListTargets(ctx, opt)
+		if err != nil {
+			return fmt.Errorf("failed to get list of targets: %w", err)
+		}
+		for _, t := range targets {
+			if !datastore.InScope(strings.ToLower(t.Scope), strings.ToLower(scope)) {
+				continue
+			}
+			tctx, err := t.WithGitHubHost(ctx)
+			if err != nil || gh.HostFrom(tctx) != gh.HostFrom(ctx) {
+				continue
+			}
+			if err := fn(t); err != nil {
+				logger.Logf(false, "failed to update status of target (scope: %s): %+v", t.Scope, err)
+			}
+		}
+		if len(targets) < opt.Limit {
+			return nil
+		}
+		opt.Cursor = targets[len(targets)-1].UUID
+	}
+}
+
+// suspendTarget suspend target with description, or delete target if del is true
+func suspendTarget(ctx context.Context, ds datastore.Datastore, t datastore.Target, description string, del bool) error {
+	if del {
+		logger.Logf(false, "GitHub Apps is uninstalled in %s, delete target", t.Scope)
+		if err := ds.DeleteTarget(ctx, t.UUID); err != nil {
+			return fmt.Errorf("failed to delete target: %w", err)
+		}
+		if _, err := datastore.DeleteJobsByTargetID(ctx, ds, t.UUID); err != nil {
+			return fmt.Errorf("failed to delete queued jobs of target: %w", err)
+		}
+		return nil
+	}
+	if !t.CanReceiveJob() {
+		return nil
+	}
+	logger.Logf(false, "%s in %s, suspend target", description, t.Scope)
+	return datastore.UpdateTargetStatus(ctx, ds, t.UUID, datastore.TargetStatusSuspend, description)
+}
+
+// reactivateTarget reactivate target that is suspended with description
+func reactivateTarget(ctx context.Context, ds datastore.Datastore, t datastore.Target, description string) error {
+	if t.Status != datastore.TargetStatusSuspend || t.StatusDescription.String != description {
+		return nil
+	}
+	logger.Logf(false, "GitHub Apps is installed again in %s, reactivate target", t.Scope)
+	return ds.UpdateTargetStatus(ctx, t.UUID, datastore.TargetStatusActive, "")
+}
+
+// registerInstallationByWebhook create a target of new installation if auto registration is enabled, it is only in primary host
+func registerInstallationByWebhook(ctx context.Context, ds datastore.Datastore, i *github.Installation) error {
+	if config.Config.TargetAutoRegisterResourceType == "" || gh.HostFrom(ctx) != gh.PrimaryHost() {
+		return nil
+	}
+	rt := datastore.UnmarshalResourceTypeString(config.Config.TargetAutoRegisterResourceType)
+	if rt == datastore.ResourceTypeUnknown {
+		return fmt.Errorf("invalid resource type of auto registration: %s", config.Config.TargetAutoRegisterResourceType)
+	}
+	scope, ok := gh.InstallationScope(i)
+	if !ok {
+		logger.Logf(true, "installation to user account is not registered")
+		return nil
+	}
+	if err := registerInstallation(ctx, ds, i, scope, rt); err != nil {
+		return fmt.Errorf("failed to register target of installation (scope: %s): %w", scope, err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
+	"github.com/whywaita/myshoes/pkg/web"
+)
+
+// newInstallationTestDatastore return datastore that has an active target of organization
+func newInstallationTestDatastore(t *testing.T) (datastore.Datastore, datastore.Target) {
+	t.Helper()
+
+	oldConfig := config.Config
+	t.Cleanup(func() { config.Config = oldConfig })
+	config.Config.GitHubURL = "https://github.com"
+	config.Config.GitHub.AppSecrets = [][]byte{[]byte("secret")}
+	config.Config.GitHub.AppSecret = config.Config.GitHub.AppSecrets[0]
+	config.Config.TargetAutoRegisterResourceType = ""
+
+	ds, err := memory.New(nil)
+	if err != nil {
+		t.Fatalf("failed to create datastore: %+v", err)
+	}
+	target := datastore.Target{
+		UUID:           datastore.NewID(),
+		Scope:          "octo-install",
+		GitHubToken:    testGitHubAppToken,
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+	}
+	if err := ds.CreateTarget(context.Background(), target); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+	return ds, target
+}
+
+// sendInstallationEvent send installation event of action to organization of target
+func sendInstallationEvent(t *testing.T, ds datastore.Datastore, action string) {
+	t.Helper()
+
+	payload := []byte(fmt.Sprintf(`{"action": %q, "installation": {"id": 1, "target_type": "Organization", "account": {"login": "octo-install", "type": "Organization"}}}`, action))
+	req := httptest.NewRequest(http.MethodPost, "/github/events", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", "installation")
+	req.Header.Set("X-Hub-Signature-256", signPayload([]byte("secret"), payload))
+	w := httptest.NewRecorder()
+
+	web.HandleGitHubEvent(w, req, ds)
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to process installation event (action: %s): %d", action, w.Code)
+	}
+}
+
+// assertTargetStatus check status of target
+func assertTargetStatus(t *testing.T, ds datastore.Datastore, target datastore.Target, want datastore.TargetStatus) {
+	t.Helper()
+
+	got, err := ds.GetTarget(context.Background(), target.UUID)
+	if err != nil {
+		t.Fatalf("failed to get target: %+v", err)
+	}
+	if got.Status != want {
+		t.Fatalf("want status %s, but got %s", want, got.Status)
+	}
+}
+
+func Test_receiveInstallationWebhook_Reactivate(t *testing.T) {
+	ds, target := newInstallationTestDatastore(t)
+
+	sendInstallationEvent(t, ds, "suspend")
+	assertTargetStatus(t, ds, target, datastore.TargetStatusSuspend)
+
+	// suspended target is reactivated by installing again
+	sendInstallationEvent(t, ds, "unsuspend")
+	assertTargetStatus(t, ds, target, datastore.TargetStatusActive)
+
+	sendInstallationEvent(t, ds, "deleted")
+	assertTargetStatus(t, ds, target, datastore.TargetStatusSuspend)
+	sendInstallationEvent(t, ds, "created")
+	assertTargetStatus(t, ds, target, datastore.TargetStatusActive)
+}
+
+func Test_receiveInstallationWebhook_DeleteSuspended(t *testing.T) {
+	ds, target := newInstallationTestDatastore(t)
+	config.Config.TargetDeleteOnUninstall = true
+
+	sendInstallationEvent(t, ds, "suspend")
+	assertTargetStatus(t, ds, target, datastore.TargetStatusSuspend)
+
+	// suspended target is deleted by uninstalling
+	sendInstallationEvent(t, ds, "deleted")
+	assertTargetStatus(t, ds, target, datastore.TargetStatusDeleted)
+}
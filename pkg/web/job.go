@@ -0,0 +1,75 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"goji.io/pat"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+	"github.com/whywaita/myshoes/pkg/starter"
+)
+
+// JobQueueStatus is status of a job in queue
+type JobQueueStatus struct {
+	JobID    uuid.UUID `json:"job_id"`
+	TargetID uuid.UUID `json:"target_id"`
+	// Status is one of queued, deferred, provisioning
+	Status string `json:"status"`
+	// Position is 1-origin position in queue, 0 is provisioning now
+	Position int `json:"position"`
+	// ETASeconds is estimated seconds until provisioned, null if it can not estimate
+	ETASeconds  *int64     `json:"eta_seconds"`
+	EstimatedAt *time.Time `json:"estimated_at"`
+	NotBefore   *time.Time `json:"not_before,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func handleJobRead(w http.ResponseWriter, r *http.Request, ds datastore.Datastore) {
+	ctx := r.Context()
+	jobID, err := uuid.FromString(pat.Param(r, "id"))
+	if err != nil {
+		logger.Logf(false, "failed to parse job id: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, "incorrect job id")
+		return
+	}
+
+	qp, err := starter.GetQueuePosition(ctx, ds, jobID)
+	switch {
+	case errors.Is(err, datastore.ErrNotFound):
+		outputErrorMsg(w, http.StatusNotFound, "job is not found (already provisioned or deleted)")
+		return
+	case errors.Is(err, starter.ErrNotLeader):
+		outputErrorMsg(w, http.StatusServiceUnavailable, "queue position is only available in the leader, this replica is standby")
+		return
+	case err != nil:
+		logger.Logf(false, "failed to get queue position: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "datastore read error")
+		return
+	}
+
+	status := JobQueueStatus{
+		JobID:     qp.Job.UUID,
+		TargetID:  qp.Job.TargetID,
+		Status:    qp.Status,
+		Position:  qp.Position,
+		CreatedAt: qp.Job.CreatedAt,
+	}
+	if qp.ETA != nil {
+		seconds := int64(qp.ETA.Seconds())
+		estimatedAt := time.Now().UTC().Add(*qp.ETA)
+		status.ETASeconds = &seconds
+		status.EstimatedAt = &estimatedAt
+	}
+	if qp.Job.NotBefore.Valid {
+		status.NotBefore = &qp.Job.NotBefore.Time
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(status)
+}
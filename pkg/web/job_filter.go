@@ -0,0 +1,79 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+var jobFilterDecisionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "myshoes",
+	Subsystem: "job",
+	Name:      "filter_decisions_total",
+	Help:      "Total number of decisions of job filters per target, decision is allowed or denied.",
+}, []string{"target_id", "scope", "decision", "rule"})
+
+var botJobsSkippedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "myshoes",
+	Subsystem: "job",
+	Name:      "bot_skipped_total",
+	Help:      "Total number of jobs that are not provisioned because these are triggered by known bots.",
+}, []string{"target_id", "scope", "actor"})
+
+func init() {
+	prometheus.MustRegister(jobFilterDecisionsTotal, botJobsSkippedTotal)
+}
+
+// checkJobFilters evaluate job filters of target, and return true if job in requestJSON is refused.
+// requestJSON is payload of workflow_job or check_run event, it is always allowed if target has no filters
+func checkJobFilters(ctx context.Context, target datastore.Target, repoName string, installationID int64, requestJSON []byte) bool {
+	filters := target.ParsedJobFilters()
+	if filters == nil {
+		return false
+	}
+
+	// sender and repository are same fields in workflow_job and check_run
+	var event github.WorkflowJobEvent
+	if err := json.Unmarshal(requestJSON, &event); err != nil {
+		logger.Logf(false, "failed to unmarshal event for job filters, allow it: %+v", err)
+		return false
+	}
+	job := datastore.JobAttributes{
+		Actor:      event.GetSender().GetLogin(),
+		Repository: repoName,
+	}
+	if wj := event.GetWorkflowJob(); wj != nil {
+		job.Labels = wj.Labels
+		if filters.UseFork() {
+			job.Fork = isForkJob(ctx, installationID, event.GetRepo(), wj)
+		}
+	}
+
+	allowed, rule := filters.Evaluate(job)
+	decision := "allowed"
+	if !allowed {
+		decision = "denied"
+	}
+	jobFilterDecisionsTotal.WithLabelValues(target.UUID.String(), target.Scope, decision, rule).Inc()
+	if rule == datastore.JobFilterRuleExcludeBots {
+		botJobsSkippedTotal.WithLabelValues(target.UUID.String(), target.Scope, job.Actor).Inc()
+	}
+	logger.Logf(allowed, "job is %s by job filters (repository: %s, actor: %s, fork: %t, labels: %s, rule: %s)", decision, repoName, job.Actor, job.Fork, job.Labels, rule)
+	return !allowed
+}
+
+// isForkJob return true if workflow job is triggered by a pull request from fork repository
+func isForkJob(ctx context.Context, installationID int64, repo *github.Repository, wj *github.WorkflowJob) bool {
+	run, err := gh.GetWorkflowRun(ctx, installationID, repo.GetOwner().GetLogin(), repo.GetName(), wj.GetRunID())
+	if err != nil {
+		logger.Logf(false, "failed to get workflow run, job is not regarded as fork (run ID: %d): %+v", wj.GetRunID(), err)
+		return false
+	}
+	return gh.IsForkRun(run)
+}
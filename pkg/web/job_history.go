@@ -0,0 +1,141 @@
+package web
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// DefaultJobHistorySince is default period of GET /job_histories
+const DefaultJobHistorySince = 24 * time.Hour
+
+// JobHistory is response of a job history with durations of each phase.
+// durations are seconds, null if the phase is not finished yet.
+type JobHistory struct {
+	JobID       uuid.UUID `json:"job_id"`
+	TargetID    uuid.UUID `json:"target_id"`
+	GitHubJobID *int64    `json:"github_job_id"`
+
+	ReceivedAt         time.Time  `json:"received_at"`
+	RunnerCreatedAt    *time.Time `json:"runner_created_at"`
+	RunnerRegisteredAt *time.Time `json:"runner_registered_at"`
+	StartedAt          *time.Time `json:"started_at"`
+	CompletedAt        *time.Time `json:"completed_at"`
+
+	// QueueSeconds is duration from received webhook to created runner
+	QueueSeconds *float64 `json:"queue_seconds"`
+	// ProvisionSeconds is duration from created runner to registered runner in GitHub
+	ProvisionSeconds *float64 `json:"provision_seconds"`
+	// PickupSeconds is duration from received webhook to started job
+	PickupSeconds *float64 `json:"pickup_seconds"`
+	// RunSeconds is duration from started job to completed job
+	RunSeconds *float64 `json:"run_seconds"`
+}
+
+func toJobHistory(h datastore.JobHistory) JobHistory {
+	received := sql.NullTime{Time: h.ReceivedAt, Valid: true}
+	jh := JobHistory{
+		JobID:              h.JobID,
+		TargetID:           h.TargetID,
+		ReceivedAt:         h.ReceivedAt,
+		RunnerCreatedAt:    nullTime(h.RunnerCreatedAt),
+		RunnerRegisteredAt: nullTime(h.RunnerRegisteredAt),
+		StartedAt:          nullTime(h.StartedAt),
+		CompletedAt:        nullTime(h.CompletedAt),
+
+		QueueSeconds:     between(received, h.RunnerCreatedAt),
+		ProvisionSeconds: between(h.RunnerCreatedAt, h.RunnerRegisteredAt),
+		PickupSeconds:    between(received, h.StartedAt),
+		RunSeconds:       between(h.StartedAt, h.CompletedAt),
+	}
+	if h.GitHubJobID.Valid {
+		jh.GitHubJobID = &h.GitHubJobID.Int64
+	}
+	return jh
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func between(from, to sql.NullTime) *float64 {
+	if !from.Valid || !to.Valid {
+		return nil
+	}
+	seconds := to.Time.Sub(from.Time).Seconds()
+	return &seconds
+}
+
+// parseJobHistoryQuery parse query parameters of GET /job_histories (target_id, since, limit).
+// since is RFC3339 time or duration before now (e.g. 1h)
+func parseJobHistoryQuery(r *http.Request, now time.Time) (uuid.UUID, time.Time, int, error) {
+	q := r.URL.Query()
+
+	targetID := uuid.Nil
+	if t := q.Get("target_id"); t != "" {
+		id, err := uuid.FromString(t)
+		if err != nil {
+			return uuid.Nil, time.Time{}, 0, fmt.Errorf("failed to parse target_id: %w", err)
+		}
+		targetID = id
+	}
+
+	since := now.Add(-DefaultJobHistorySince)
+	if s := q.Get("since"); s != "" {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			since = t
+		} else if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+			since = now.Add(-d)
+		} else {
+			return uuid.Nil, time.Time{}, 0, fmt.Errorf("invalid since: %s", s)
+		}
+	}
+
+	var limit int
+	if l := q.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 0 {
+			return uuid.Nil, time.Time{}, 0, fmt.Errorf("invalid limit: %s", l)
+		}
+		limit = n
+	}
+
+	return targetID, since.UTC(), limit, nil
+}
+
+func handleJobHistoryList(w http.ResponseWriter, r *http.Request, ds datastore.Datastore) {
+	ctx := r.Context()
+
+	targetID, since, limit, err := parseJobHistoryQuery(r, time.Now())
+	if err != nil {
+		outputErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	histories, err := ds.ListJobHistories(ctx, targetID, since, limit)
+	if err != nil {
+		logger.Logf(false, "failed to list job histories: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "datastore read error")
+		return
+	}
+
+	resp := make([]JobHistory, 0, len(histories))
+	for _, h := range histories {
+		resp = append(resp, toJobHistory(h))
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,124 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// trackedJobsLookback is range of job histories that used to detect jobs already received
+const trackedJobsLookback = 24 * time.Hour
+
+// polledJobs is IDs of workflow jobs that enqueued by poller.
+// history of job is not recorded under pressure of datastore, So poller remembers jobs by itself
+var polledJobs = cache.New(trackedJobsLookback, 1*time.Hour)
+
+// LoopPollQueuedJobs poll queued workflow jobs of targets periodically, and enqueue jobs that webhook is lost
+func LoopPollQueuedJobs(ctx context.Context, ds datastore.Datastore, interval time.Duration) error {
+	if interval == 0 {
+		logger.Logf(true, "polling of queued jobs is disabled")
+		return nil
+	}
+	logger.Logf(false, "start to poll queued jobs in every %s", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := PollQueuedJobs(ctx, ds); err != nil {
+				logger.Logf(false, "failed to poll queued jobs: %+v", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// PollQueuedJobs enqueue queued workflow jobs in all targets that are not received yet
+func PollQueuedJobs(ctx context.Context, ds datastore.Datastore) error {
+	return datastore.WalkTargets(ctx, ds, func(targets []datastore.Target) error {
+		for _, t := range targets {
+			if !t.CanReceiveJob() || gh.DetectScope(t.Scope) == gh.Enterprise {
+				continue
+			}
+			if err := gh.CheckBudget(t.Scope); err != nil {
+				logger.Logf(true, "defer to poll queued jobs (target: %s): %+v", t.Scope, err)
+				continue
+			}
+			ctx, err := t.WithGitHubHost(gh.WithBudgetScope(ctx, t.Scope))
+			if err != nil {
+				logger.Logf(false, "failed to poll queued jobs (target: %s): %+v", t.Scope, err)
+				continue
+			}
+
+			enqueued, err := pollQueuedJobs(ctx, ds, t)
+			if err != nil {
+				logger.Logf(false, "failed to poll queued jobs (target: %s): %+v", t.Scope, err)
+				continue
+			}
+			if enqueued > 0 {
+				logger.Logf(false, "enqueued %d queued jobs that webhook is not received (target: %s)", enqueued, t.Scope)
+			}
+		}
+		return nil
+	})
+}
+
+// pollQueuedJobs enqueue queued workflow jobs in target that are not tracked, return number of enqueued jobs
+func pollQueuedJobs(ctx context.Context, ds datastore.Datastore, t datastore.Target) (int, error) {
+	if config.Config.GitHubTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Config.GitHubTimeout)
+		defer cancel()
+	}
+
+	installationID, err := GHIsInstalledGitHubApp(ctx, t.Scope)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get installation id: %w", err)
+	}
+	queued, err := GHListQueuedJobs(ctx, installationID, t.Scope)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list queued jobs: %w", err)
+	}
+	if len(queued) == 0 {
+		return 0, nil
+	}
+
+	histories, err := ds.ListJobHistories(ctx, t.UUID, time.Now().UTC().Add(-trackedJobsLookback), 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get histories of jobs: %w", err)
+	}
+	tracked := make(map[int64]struct{}, len(histories))
+	for _, h := range histories {
+		if h.GitHubJobID.Valid {
+			tracked[h.GitHubJobID.Int64] = struct{}{}
+		}
+	}
+
+	var enqueued int
+	for _, q := range queued {
+		jobID := q.Job.GetID()
+		if _, ok := tracked[jobID]; ok {
+			continue
+		}
+		key := fmt.Sprintf("%d", jobID)
+		if _, found := polledJobs.Get(key); found {
+			continue
+		}
+		if err := receiveWorkflowJobWebhook(ctx, toQueuedEvent(q, installationID), ds); err != nil {
+			logger.Logf(false, "failed to enqueue polled job (repository: %s, job ID: %d): %+v", q.Repository.GetFullName(), jobID, err)
+			continue
+		}
+		polledJobs.SetDefault(key, struct{}{})
+		enqueued++
+	}
+	return enqueued, nil
+}
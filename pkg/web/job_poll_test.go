@@ -0,0 +1,91 @@
+package web_test
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v47/github"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/web"
+)
+
+func TestPollQueuedJobs(t *testing.T) {
+	setStubFunctions()
+	ctx := context.Background()
+	ds, err := memory.New(nil)
+	if err != nil {
+		t.Fatalf("failed to create datastore: %+v", err)
+	}
+
+	target := datastore.Target{
+		UUID:           datastore.NewID(),
+		Scope:          "octo-poll",
+		GitHubToken:    testGitHubAppToken,
+		TokenExpiredAt: testTime,
+		ResourceType:   datastore.ResourceTypeNano,
+		Status:         datastore.TargetStatusActive,
+	}
+	if err := ds.CreateTarget(ctx, target); err != nil {
+		t.Fatalf("failed to create target: %+v", err)
+	}
+	// webhook of job 9001 is already received
+	if err := ds.CreateJobHistory(ctx, datastore.JobHistory{
+		JobID:       datastore.NewID(),
+		TargetID:    target.UUID,
+		GitHubJobID: sql.NullInt64{Int64: 9001, Valid: true},
+		ReceivedAt:  time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("failed to create job history: %+v", err)
+	}
+
+	var listed int
+	web.GHListQueuedJobs = func(ctx context.Context, installationID int64, scope string) ([]gh.QueuedJob, error) {
+		listed++
+		repo := &github.Repository{
+			FullName: github.String("octo-poll/hello-world"),
+			HTMLURL:  github.String("https://github.com/octo-poll/hello-world"),
+		}
+		var jobs []gh.QueuedJob
+		for _, id := range []int64{9001, 9002, 9003} {
+			jobs = append(jobs, gh.QueuedJob{
+				Repository: repo,
+				Job:        &github.WorkflowJob{ID: github.Int64(id), RunID: github.Int64(1), Labels: []string{"self-hosted"}},
+			})
+		}
+		return jobs, nil
+	}
+	t.Cleanup(setStubFunctions)
+
+	// webhook of polled jobs is not received yet in second polling
+	for i := 0; i < 2; i++ {
+		if err := web.PollQueuedJobs(ctx, ds); err != nil {
+			t.Fatalf("failed to poll queued jobs: %+v", err)
+		}
+	}
+	if listed != 2 {
+		t.Fatalf("want queued jobs are listed in each polling, but got %d", listed)
+	}
+
+	jobs, err := ds.ListJobs(ctx, datastore.ListOption{})
+	if err != nil {
+		t.Fatalf("failed to list jobs: %+v", err)
+	}
+	var got []int64
+	for _, j := range jobs {
+		if j.TargetID != target.UUID {
+			t.Errorf("job must be enqueued to target (job ID: %s, target ID: %s)", j.UUID, j.TargetID)
+		}
+		got = append(got, gh.ExtractJobID([]byte(j.CheckEventJSON)))
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	if diff := cmp.Diff([]int64{9002, 9003}, got); diff != "" {
+		t.Errorf("untracked jobs must be enqueued exactly once (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,18 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/whywaita/myshoes/pkg/lock"
+)
+
+// requireLeader write error and return false if this replica is standby.
+// name is feature that use state in process of the leader, the leader is exposed by "leader" in /healthz
+func requireLeader(w http.ResponseWriter, name string) bool {
+	if lock.IsLeader.Load() {
+		return true
+	}
+	outputErrorMsg(w, http.StatusServiceUnavailable, fmt.Sprintf("%s is only available in the leader, this replica is standby", name))
+	return false
+}
@@ -0,0 +1,82 @@
+package web_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore/memory"
+	"github.com/whywaita/myshoes/pkg/lock"
+	"github.com/whywaita/myshoes/pkg/web"
+)
+
+func Test_leaderOnlyEndpoints(t *testing.T) {
+	ds, err := memory.New(nil)
+	if err != nil {
+		t.Fatalf("failed to create datastore: %+v", err)
+	}
+	ts := httptest.NewServer(web.NewMux(ds))
+	defer ts.Close()
+	oldDryRun := config.Config.GCDryRun
+	defer func() { config.Config.GCDryRun = oldDryRun }()
+	defer lock.IsLeader.Store(false)
+
+	isLeader := func() bool {
+		t.Helper()
+		resp, err := http.Get(ts.URL + "/healthz")
+		if err != nil {
+			t.Fatalf("failed to GET request: %+v", err)
+		}
+		content, _ := parseResponse(resp)
+		var h struct {
+			Leader bool `json:"leader"`
+		}
+		if err := json.Unmarshal(content, &h); err != nil {
+			t.Fatalf("failed to unmarshal response JSON: %+v", err)
+		}
+		return h.Leader
+	}
+	switchDryRun := func() int {
+		t.Helper()
+		resp, err := http.Post(ts.URL+"/config/gc-dry-run", "application/json", bytes.NewBufferString(`{"dry_run": true}`))
+		if err != nil {
+			t.Fatalf("failed to POST request: %+v", err)
+		}
+		_, code := parseResponse(resp)
+		return code
+	}
+
+	// state of GC is only in process of the leader
+	lock.IsLeader.Store(false)
+	config.Config.GCDryRun = false
+	if isLeader() {
+		t.Errorf("standby replica must not be exposed as leader")
+	}
+	if code := switchDryRun(); code != http.StatusServiceUnavailable {
+		t.Errorf("want %d in standby, but got %d", http.StatusServiceUnavailable, code)
+	}
+	if config.Config.GCDryRun {
+		t.Errorf("dry-run mode must not be switched in standby")
+	}
+	resp, err := http.Get(ts.URL + "/runners/gc-report")
+	if err != nil {
+		t.Fatalf("failed to GET request: %+v", err)
+	}
+	if _, code := parseResponse(resp); code != http.StatusServiceUnavailable {
+		t.Errorf("want %d in standby, but got %d", http.StatusServiceUnavailable, code)
+	}
+
+	lock.IsLeader.Store(true)
+	if !isLeader() {
+		t.Errorf("leader must be exposed in /healthz")
+	}
+	if code := switchDryRun(); code != http.StatusNoContent {
+		t.Errorf("want %d in leader, but got %d", http.StatusNoContent, code)
+	}
+	if !config.Config.GCDryRun {
+		t.Errorf("dry-run mode must be switched in leader")
+	}
+}
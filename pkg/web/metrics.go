@@ -13,7 +13,8 @@ import (
 
 // HandleMetrics handle metrics endpoint
 func HandleMetrics(w http.ResponseWriter, r *http.Request, ds datastore.Datastore) {
-	ctx := r.Context()
+	// metrics allow stale data
+	ctx := datastore.WithReadReplica(r.Context())
 
 	registry := prometheus.NewRegistry()
 	registry.MustRegister(metric.NewCollector(ctx, ds))
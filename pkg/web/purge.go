@@ -0,0 +1,86 @@
+package web
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// checkAdminToken check bearer token in request is ADMIN_TOKEN, and output error if not
+func checkAdminToken(w http.ResponseWriter, r *http.Request) bool {
+	if config.Config.AdminToken == "" {
+		outputErrorMsg(w, http.StatusForbidden, fmt.Sprintf("%s is not configured", config.EnvAdminToken))
+		return false
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(config.Config.AdminToken)) != 1 {
+		outputErrorMsg(w, http.StatusUnauthorized, "invalid token")
+		return false
+	}
+	return true
+}
+
+// handleDataPurge delete all data of a scope (repository, or organization and its repositories) for compliance.
+// targets in scope must be deleted and its runners must be torn down before, because instances of runner are not deleted.
+func handleDataPurge(w http.ResponseWriter, r *http.Request, ds datastore.Datastore) {
+	ctx := r.Context()
+	if !checkAdminToken(w, r) {
+		return
+	}
+
+	scope := r.URL.Query().Get("scope")
+	if scope == "" || strings.Count(scope, "/") > 1 {
+		outputErrorMsg(w, http.StatusBadRequest, "scope must be org or org/repo")
+		return
+	}
+
+	targets, err := ds.ExportTargets(ctx)
+	if err != nil {
+		logger.Logf(false, "failed to get targets: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "datastore read error")
+		return
+	}
+	for _, t := range targets {
+		if !datastore.InScope(t.Scope, scope) {
+			continue
+		}
+		if !t.DeletedAt.Valid {
+			outputErrorMsg(w, http.StatusConflict, fmt.Sprintf("target %s is not deleted, delete it before purge", t.Scope))
+			return
+		}
+		runners, err := ds.ListRunnersByTargetID(ctx, t.UUID)
+		if err != nil {
+			logger.Logf(false, "failed to get runners: %+v", err)
+			outputErrorMsg(w, http.StatusInternalServerError, "datastore read error")
+			return
+		}
+		if len(runners) != 0 {
+			outputErrorMsg(w, http.StatusConflict, fmt.Sprintf("%d runners of %s are not torn down yet, retry later", len(runners), t.Scope))
+			return
+		}
+	}
+
+	result, err := ds.PurgeScope(ctx, scope)
+	switch {
+	case errors.Is(err, datastore.ErrNotFound):
+		outputErrorMsg(w, http.StatusNotFound, "no target in scope")
+		return
+	case err != nil:
+		logger.Logf(false, "failed to purge data of %s: %+v", scope, err)
+		outputErrorMsg(w, http.StatusInternalServerError, "datastore delete error")
+		return
+	}
+	logger.Logf(false, "purged data of %s (targets: %d, runners: %d, jobs: %d, histories: %d)", scope, result.Targets, result.Runners, result.Jobs, result.Histories)
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
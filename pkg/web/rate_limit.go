@@ -0,0 +1,19 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/whywaita/myshoes/pkg/gh"
+)
+
+func handleRateLimitList(w http.ResponseWriter, r *http.Request) {
+	rateLimits := gh.ListRateLimits()
+	if rateLimits == nil {
+		rateLimits = []gh.RateLimit{}
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rateLimits)
+}
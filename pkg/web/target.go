@@ -1,14 +1,17 @@
 package web
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/google/go-github/v47/github"
 	"github.com/r3labs/diff/v2"
 	uuid "github.com/satori/go.uuid"
 
@@ -23,22 +26,38 @@ import (
 type TargetCreateParam struct {
 	datastore.Target
 
-	GHEDomain   *string `json:"ghe_domain"`   // ignore
+	GHEDomain   *string `json:"ghe_domain"`   // nullable, URL of GitHub host in GITHUB_URL or GITHUB_HOSTS, only set in creating
 	RunnerUser  *string `json:"runner_user"`  // nullable
 	ProviderURL *string `json:"provider_url"` // nullable
+	ExternalRef *string `json:"external_ref"` // nullable, only set in creating
+
+	PlacementParams json.RawMessage            `json:"placement_params"` // nullable, JSON object
+	RunnerLabels    []string                   `json:"runner_labels"`    // nullable, empty list clear labels in updating
+	QuietHours      json.RawMessage            `json:"quiet_hours"`      // nullable, JSON object
+	Rootless        *datastore.RootlessRuntime `json:"rootless"`         // nullable, empty string disable rootless in updating
+	JobFilters      json.RawMessage            `json:"job_filters"`      // nullable, JSON object
 }
 
 // UserTarget is format for user
 type UserTarget struct {
-	UUID              uuid.UUID              `json:"id"`
-	Scope             string                 `json:"scope"`
-	TokenExpiredAt    time.Time              `json:"token_expired_at"`
-	ResourceType      string                 `json:"resource_type"`
-	ProviderURL       string                 `json:"provider_url"`
-	Status            datastore.TargetStatus `json:"status"`
-	StatusDescription string                 `json:"status_description"`
-	CreatedAt         time.Time              `json:"created_at"`
-	UpdatedAt         time.Time              `json:"updated_at"`
+	UUID               uuid.UUID                    `json:"id"`
+	Scope              string                       `json:"scope"`
+	TokenExpiredAt     time.Time                    `json:"token_expired_at"`
+	ResourceType       string                       `json:"resource_type"`
+	ProviderURL        string                       `json:"provider_url"`
+	Status             datastore.TargetStatus       `json:"status"`
+	StatusDescription  string                       `json:"status_description"`
+	ExternalRef        string                       `json:"external_ref"`
+	PlacementParams    json.RawMessage              `json:"placement_params,omitempty"`
+	UserDataFormat     datastore.UserDataFormat     `json:"user_data_format,omitempty"`
+	RunnerRegistration datastore.RunnerRegistration `json:"runner_registration,omitempty"`
+	RunnerLabels       []string                     `json:"runner_labels,omitempty"`
+	QuietHours         json.RawMessage              `json:"quiet_hours,omitempty"`
+	Rootless           datastore.RootlessRuntime    `json:"rootless,omitempty"`
+	JobFilters         json.RawMessage              `json:"job_filters,omitempty"`
+	DeletedAt          *time.Time                   `json:"deleted_at,omitempty"`
+	CreatedAt          time.Time                    `json:"created_at"`
+	UpdatedAt          time.Time                    `json:"updated_at"`
 }
 
 func sortUserTarget(uts []UserTarget) []UserTarget {
@@ -58,21 +77,49 @@ func sortUserTarget(uts []UserTarget) []UserTarget {
 
 // function pointer (for testing)
 var (
-	GHExistGitHubRepositoryFunc = gh.ExistGitHubRepository
-	GHExistRunnerReleases       = gh.ExistRunnerReleases
-	GHListRunnersFunc           = gh.ListRunners
-	GHIsInstalledGitHubApp      = gh.IsInstalledGitHubApp
-	GHGenerateGitHubAppsToken   = gh.GenerateGitHubAppsToken
-	GHNewClientApps             = gh.NewClientGitHubApps
+	GHExistGitHubRepositoryFunc    = gh.ExistGitHubRepository
+	GHExistRunnerReleases          = gh.ExistRunnerReleases
+	GHListRunnersFunc              = gh.ListRunners
+	GHIsInstalledGitHubApp         = gh.IsInstalledGitHubApp
+	GHCheckInstallationPermissions = gh.CheckInstallationPermissions
+	GHGenerateGitHubAppsToken      = gh.GenerateGitHubAppsToken
+	GHNewClientApps                = newClientApps
+	GHGetRunnerRegistrationToken   = gh.GetRunnerRegistrationToken
+	GHListQueuedJobs               = gh.ListQueuedJobs
+	GHListQueuedJobsInRun          = gh.ListQueuedJobsInRun
 )
 
+// newClientApps create a client of GitHub Apps in GitHub host of context
+func newClientApps(ctx context.Context) (*github.Client, error) {
+	return gh.HostFrom(ctx).NewClientGitHubApps()
+}
+
 func handleTargetList(w http.ResponseWriter, r *http.Request, ds datastore.Datastore) {
-	ctx := r.Context()
+	ctx := datastore.WithReadReplica(r.Context())
 
-	ts, err := datastore.ListTargets(ctx, ds)
+	opt, err := parseListOption(r)
+	if err != nil {
+		logger.Logf(false, "failed to parse pagination parameters: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, "incorrect pagination parameters")
+		return
+	}
+
+	var ts []datastore.Target
+	if externalRef := r.URL.Query().Get("external_ref"); externalRef != "" {
+		ts, err = datastore.ListTargetsByExternalRef(ctx, ds, externalRef)
+	} else if opt.Limit > 0 {
+		var next uuid.UUID
+		ts, next, err = datastore.ListTargetsPage(ctx, ds, opt)
+		if !uuid.Equal(next, uuid.Nil) {
+			w.Header().Set(HeaderNextCursor, next.String())
+		}
+	} else {
+		ts, err = datastore.ListTargets(ctx, ds)
+	}
 	if err != nil {
 		logger.Logf(false, "failed to retrieve list of target: %+v", err)
 		outputErrorMsg(w, http.StatusInternalServerError, "datastore read error")
+		return
 	}
 
 	fmt.Println(ts)
@@ -90,7 +137,7 @@ func handleTargetList(w http.ResponseWriter, r *http.Request, ds datastore.Datas
 }
 
 func handleTargetRead(w http.ResponseWriter, r *http.Request, ds datastore.Datastore) {
-	ctx := r.Context()
+	ctx := datastore.WithReadReplica(r.Context())
 	targetID, err := parseReqTargetID(r)
 	if err != nil {
 		logger.Logf(false, "failed to decode request body: %+v", err)
@@ -114,15 +161,32 @@ func handleTargetRead(w http.ResponseWriter, r *http.Request, ds datastore.Datas
 
 func sanitizeTarget(t datastore.Target) UserTarget {
 	ut := UserTarget{
-		UUID:              t.UUID,
-		Scope:             t.Scope,
-		TokenExpiredAt:    t.TokenExpiredAt,
-		ResourceType:      t.ResourceType.String(),
-		ProviderURL:       t.ProviderURL.String,
-		Status:            t.Status,
-		StatusDescription: t.StatusDescription.String,
-		CreatedAt:         t.CreatedAt,
-		UpdatedAt:         t.UpdatedAt,
+		UUID:               t.UUID,
+		Scope:              t.Scope,
+		TokenExpiredAt:     t.TokenExpiredAt,
+		ResourceType:       t.ResourceType.String(),
+		ProviderURL:        t.ProviderURL.String,
+		Status:             t.Status,
+		StatusDescription:  t.StatusDescription.String,
+		ExternalRef:        t.ExternalRef.String,
+		UserDataFormat:     t.UserDataFormat,
+		RunnerRegistration: t.RunnerRegistration,
+		RunnerLabels:       t.InjectedRunnerLabels(),
+		Rootless:           t.Rootless,
+		CreatedAt:          t.CreatedAt,
+		UpdatedAt:          t.UpdatedAt,
+	}
+	if t.PlacementParams.Valid {
+		ut.PlacementParams = json.RawMessage(t.PlacementParams.String)
+	}
+	if t.QuietHours.Valid {
+		ut.QuietHours = json.RawMessage(t.QuietHours.String)
+	}
+	if t.JobFilters.Valid {
+		ut.JobFilters = json.RawMessage(t.JobFilters.String)
+	}
+	if t.DeletedAt.Valid {
+		ut.DeletedAt = &t.DeletedAt.Time
 	}
 
 	return ut
@@ -143,6 +207,41 @@ func handleTargetUpdate(w http.ResponseWriter, r *http.Request, ds datastore.Dat
 		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
 		return
 	}
+	if err := isValidPlacementParams(inputTarget.PlacementParams); err != nil {
+		logger.Logf(false, "failed to validate input: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := datastore.ValidateUserDataFormat(inputTarget.UserDataFormat); err != nil {
+		logger.Logf(false, "failed to validate input: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := datastore.ValidateRunnerRegistration(inputTarget.RunnerRegistration); err != nil {
+		logger.Logf(false, "failed to validate input: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := datastore.ValidateRunnerLabels(inputTarget.RunnerLabels); err != nil {
+		logger.Logf(false, "failed to validate input: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := isValidQuietHours(inputTarget.QuietHours); err != nil {
+		logger.Logf(false, "failed to validate input: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := isValidRootless(inputTarget.Rootless); err != nil {
+		logger.Logf(false, "failed to validate input: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := isValidJobFilters(inputTarget.JobFilters); err != nil {
+		logger.Logf(false, "failed to validate input: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	newTarget := inputTarget.ToDS("", time.Time{})
 
 	oldTarget, err := ds.GetTarget(ctx, targetID)
@@ -169,6 +268,55 @@ func handleTargetUpdate(w http.ResponseWriter, r *http.Request, ds datastore.Dat
 		outputErrorMsg(w, http.StatusInternalServerError, "datastore update error")
 		return
 	}
+	if inputTarget.PlacementParams != nil {
+		if err := ds.UpdateTargetPlacementParams(ctx, targetID, newTarget.PlacementParams); err != nil {
+			logger.Logf(false, "failed to ds.UpdateTargetPlacementParams: %+v", err)
+			outputErrorMsg(w, http.StatusInternalServerError, "datastore update error")
+			return
+		}
+	}
+	if inputTarget.UserDataFormat != "" {
+		if err := ds.UpdateTargetUserDataFormat(ctx, targetID, inputTarget.UserDataFormat); err != nil {
+			logger.Logf(false, "failed to ds.UpdateTargetUserDataFormat: %+v", err)
+			outputErrorMsg(w, http.StatusInternalServerError, "datastore update error")
+			return
+		}
+	}
+	if inputTarget.RunnerRegistration != "" {
+		if err := ds.UpdateTargetRunnerRegistration(ctx, targetID, inputTarget.RunnerRegistration); err != nil {
+			logger.Logf(false, "failed to ds.UpdateTargetRunnerRegistration: %+v", err)
+			outputErrorMsg(w, http.StatusInternalServerError, "datastore update error")
+			return
+		}
+	}
+	if inputTarget.RunnerLabels != nil {
+		if err := ds.UpdateTargetRunnerLabels(ctx, targetID, newTarget.RunnerLabels); err != nil {
+			logger.Logf(false, "failed to ds.UpdateTargetRunnerLabels: %+v", err)
+			outputErrorMsg(w, http.StatusInternalServerError, "datastore update error")
+			return
+		}
+	}
+	if inputTarget.QuietHours != nil {
+		if err := ds.UpdateTargetQuietHours(ctx, targetID, newTarget.QuietHours); err != nil {
+			logger.Logf(false, "failed to ds.UpdateTargetQuietHours: %+v", err)
+			outputErrorMsg(w, http.StatusInternalServerError, "datastore update error")
+			return
+		}
+	}
+	if inputTarget.Rootless != nil {
+		if err := ds.UpdateTargetRootless(ctx, targetID, *inputTarget.Rootless); err != nil {
+			logger.Logf(false, "failed to ds.UpdateTargetRootless: %+v", err)
+			outputErrorMsg(w, http.StatusInternalServerError, "datastore update error")
+			return
+		}
+	}
+	if inputTarget.JobFilters != nil {
+		if err := ds.UpdateTargetJobFilters(ctx, targetID, newTarget.JobFilters); err != nil {
+			logger.Logf(false, "failed to ds.UpdateTargetJobFilters: %+v", err)
+			outputErrorMsg(w, http.StatusInternalServerError, "datastore update error")
+			return
+		}
+	}
 
 	updatedTarget, err := ds.GetTarget(ctx, targetID)
 	if err != nil {
@@ -198,21 +346,22 @@ func handleTargetDelete(w http.ResponseWriter, r *http.Request, ds datastore.Dat
 		outputErrorMsg(w, http.StatusBadRequest, "incorrect target id (not found)")
 		return
 	}
-	switch target.Status {
-	case datastore.TargetStatusRunning:
-		logger.Logf(true, "%s is running now", targetID)
-		outputErrorMsg(w, http.StatusBadRequest, "target has running runner now, please stop all runner")
-		return
-	case datastore.TargetStatusDeleted:
+	if target.Status == datastore.TargetStatusDeleted {
 		outputErrorMsg(w, http.StatusBadRequest, "target is already deleted")
 		return
 	}
 
+	// stop provisioning first, runners of target are torn down by runner manager
 	if err := ds.DeleteTarget(ctx, targetID); err != nil {
 		logger.Logf(false, "failed to delete target in datastore: %+v", err)
 		outputErrorMsg(w, http.StatusInternalServerError, "datastore delete error")
 		return
 	}
+	if n, err := datastore.DeleteJobsByTargetID(ctx, ds, targetID); err != nil {
+		logger.Logf(false, "failed to delete queued jobs of target (target ID: %s): %+v", targetID, err)
+	} else if n > 0 {
+		logger.Logf(false, "deleted %d queued jobs of target (target ID: %s)", n, targetID)
+	}
 
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	w.WriteHeader(http.StatusNoContent)
@@ -228,6 +377,31 @@ func parseReqTargetID(r *http.Request) (uuid.UUID, error) {
 	return targetID, nil
 }
 
+// HeaderNextCursor is header of cursor for next page in GET /target
+const HeaderNextCursor = "X-Next-Cursor"
+
+// parseListOption parse query parameters of pagination (limit, cursor)
+func parseListOption(r *http.Request) (datastore.ListOption, error) {
+	var opt datastore.ListOption
+	q := r.URL.Query()
+	if l := q.Get("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil || limit < 0 {
+			return datastore.ListOption{}, fmt.Errorf("invalid limit: %s", l)
+		}
+		opt.Limit = limit
+	}
+	if c := q.Get("cursor"); c != "" {
+		cursor, err := uuid.FromString(c)
+		if err != nil {
+			return datastore.ListOption{}, fmt.Errorf("failed to parse cursor: %w", err)
+		}
+		opt.Cursor = cursor
+	}
+
+	return opt, nil
+}
+
 // ErrorResponse is error response
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -252,6 +426,13 @@ func validateUpdateTarget(old, new datastore.Target) error {
 		// can update variables
 		t.ResourceType = datastore.ResourceTypeUnknown
 		t.ProviderURL = sql.NullString{}
+		t.PlacementParams = sql.NullString{}
+		t.UserDataFormat = ""
+		t.RunnerRegistration = ""
+		t.RunnerLabels = sql.NullString{}
+		t.QuietHours = sql.NullString{}
+		t.Rootless = ""
+		t.JobFilters = sql.NullString{}
 
 		// time
 		t.TokenExpiredAt = time.Time{}
@@ -262,6 +443,9 @@ func validateUpdateTarget(old, new datastore.Target) error {
 		t.Status = ""
 		t.StatusDescription = sql.NullString{}
 		t.GitHubToken = ""
+
+		// can't update, only set in creating
+		t.ExternalRef = sql.NullString{}
 	}
 
 	changelog, err := diff.Diff(oldv, newv)
@@ -292,10 +476,93 @@ func isValidTargetCreateParam(input TargetCreateParam) error {
 	if input.Scope == "" || input.ResourceType == datastore.ResourceTypeUnknown {
 		return fmt.Errorf("scope, resource_type must be set")
 	}
+	if err := isValidPlacementParams(input.PlacementParams); err != nil {
+		return err
+	}
+	if err := datastore.ValidateUserDataFormat(input.UserDataFormat); err != nil {
+		return err
+	}
+	if err := datastore.ValidateRunnerRegistration(input.RunnerRegistration); err != nil {
+		return err
+	}
+	if err := datastore.ValidateRunnerLabels(input.RunnerLabels); err != nil {
+		return err
+	}
+	if err := isValidQuietHours(input.QuietHours); err != nil {
+		return err
+	}
+	if err := isValidRootless(input.Rootless); err != nil {
+		return err
+	}
+	if err := isValidJobFilters(input.JobFilters); err != nil {
+		return err
+	}
+
+	return nil
+}
 
+// isValidPlacementParams check placement_params. nil (not set) and null are valid
+func isValidPlacementParams(input json.RawMessage) error {
+	if input == nil || string(input) == "null" {
+		return nil
+	}
+	if err := datastore.ValidatePlacementParams(input); err != nil {
+		return fmt.Errorf("invalid placement_params: %w", err)
+	}
 	return nil
 }
 
+// isValidQuietHours check quiet_hours. nil (not set) and null are valid
+func isValidQuietHours(input json.RawMessage) error {
+	if input == nil || string(input) == "null" {
+		return nil
+	}
+	if err := datastore.ValidateQuietHours(input); err != nil {
+		return fmt.Errorf("invalid quiet_hours: %w", err)
+	}
+	return nil
+}
+
+// isValidJobFilters check job_filters. nil (not set) and null are valid
+func isValidJobFilters(input json.RawMessage) error {
+	if input == nil || string(input) == "null" {
+		return nil
+	}
+	if err := datastore.ValidateJobFilters(input); err != nil {
+		return fmt.Errorf("invalid job_filters: %w", err)
+	}
+	return nil
+}
+
+// isValidRootless check rootless. nil (not set) is valid
+func isValidRootless(input *datastore.RootlessRuntime) error {
+	if input == nil {
+		return nil
+	}
+	return datastore.ValidateRootlessRuntime(*input)
+}
+
+// toNullJSON convert JSON object (e.g. placement_params). null is converted to NULL
+func toNullJSON(input json.RawMessage) sql.NullString {
+	if input == nil || string(input) == "null" {
+		return sql.NullString{
+			Valid: false,
+		}
+	}
+
+	return sql.NullString{
+		Valid:  true,
+		String: string(input),
+	}
+}
+
+func toRootless(input *datastore.RootlessRuntime) datastore.RootlessRuntime {
+	if input == nil {
+		return datastore.RootlessRuntimeNone
+	}
+	return *input
+}
+
 func toNullString(input *string) sql.NullString {
 	if input == nil || strings.EqualFold(*input, "") {
 		return sql.NullString{
@@ -314,12 +581,20 @@ func (t *TargetCreateParam) ToDS(appToken string, tokenExpired time.Time) datast
 	providerURL := toNullString(t.ProviderURL)
 
 	return datastore.Target{
-		UUID:           t.UUID,
-		Scope:          t.Scope,
-		GitHubToken:    appToken,
-		TokenExpiredAt: tokenExpired,
-		ResourceType:   t.ResourceType,
-		ProviderURL:    providerURL,
+		UUID:               t.UUID,
+		Scope:              t.Scope,
+		GitHubToken:        appToken,
+		TokenExpiredAt:     tokenExpired,
+		ResourceType:       t.ResourceType,
+		ProviderURL:        providerURL,
+		ExternalRef:        toNullString(t.ExternalRef),
+		PlacementParams:    toNullJSON(t.PlacementParams),
+		UserDataFormat:     t.UserDataFormat,
+		RunnerRegistration: t.RunnerRegistration,
+		RunnerLabels:       datastore.ToRunnerLabels(t.RunnerLabels),
+		QuietHours:         toNullJSON(t.QuietHours),
+		Rootless:           toRootless(t.Rootless),
+		JobFilters:         toNullJSON(t.JobFilters),
 	}
 }
 
@@ -2,7 +2,6 @@ package web
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -32,14 +31,35 @@ func handleTargetCreate(w http.ResponseWriter, r *http.Request, ds datastore.Dat
 		outputErrorMsg(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if inputTarget.GHEDomain != nil {
+		// ghe_domain was ignored before multiple hosts, unknown host is still ignored for compatibility
+		host, err := gh.LookupHost(*inputTarget.GHEDomain)
+		if err != nil {
+			logger.Logf(false, "ghe_domain is not configured, use %s instead: %+v", gh.PrimaryHost().URL(), err)
+		} else {
+			ctx = gh.WithHost(ctx, host)
+		}
+	}
 	installationID, err := GHIsInstalledGitHubApp(ctx, inputTarget.Scope)
+	if errors.Is(err, gh.ErrUserOwnerScope) {
+		outputErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		logger.Logf(false, "failed to check installed GitHub App: %+v", err)
 		outputErrorMsg(w, http.StatusBadRequest, "failed to check to install GitHub Apps. Are you installed?")
 		return
 	}
+	if err := GHCheckInstallationPermissions(ctx, installationID, inputTarget.Scope); err != nil {
+		if config.Config.Strict && errors.Is(err, gh.ErrInsufficientPermissions) {
+			logger.Logf(false, "GitHub Apps is misconfigured: %+v", err)
+			outputErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.Logf(false, "WARNING: failed to check permissions of GitHub Apps installation: %+v", err)
+	}
 
-	clientApps, err := GHNewClientApps()
+	clientApps, err := GHNewClientApps(ctx)
 	if err != nil {
 		logger.Logf(false, "failed to client of GitHub Apps: %+v", err)
 		outputErrorMsg(w, http.StatusInternalServerError, "failed to client GitHub Apps")
@@ -58,7 +78,7 @@ func handleTargetCreate(w http.ResponseWriter, r *http.Request, ds datastore.Dat
 		return
 	}
 
-	target, err := ds.GetTargetByScope(ctx, t.Scope)
+	target, err := ds.GetTargetByScope(ctx, gh.HostFrom(ctx).GHEDomain(), t.Scope)
 	var targetUUID uuid.UUID
 
 	switch {
@@ -71,7 +91,7 @@ func handleTargetCreate(w http.ResponseWriter, r *http.Request, ds datastore.Dat
 		}
 		targetUUID = *u
 	case err != nil:
-		logger.Logf(false, "failed to get target by scope [ghe_domain: %s scope: %s]: %+v", config.Config.GitHubURL, t.Scope, err)
+		logger.Logf(false, "failed to get target by scope [ghe_domain: %s scope: %s]: %+v", gh.HostFrom(ctx).URL(), t.Scope, err)
 		outputErrorMsg(w, http.StatusInternalServerError, "datastore error")
 		return
 
@@ -82,8 +102,7 @@ func handleTargetCreate(w http.ResponseWriter, r *http.Request, ds datastore.Dat
 		return
 	case target.Status == datastore.TargetStatusDeleted:
 		// deleted, need to recreate
-		//lint:ignore SA1019 ds.UpdateTargetStatus only use under.
-		if err := ds.UpdateTargetStatus(ctx, target.UUID, datastore.TargetStatusActive, ""); err != nil {
+		if err := ds.RestoreTarget(ctx, target.UUID); err != nil {
 			logger.Logf(false, "failed to recreate target: %+v", err)
 			outputErrorMsg(w, http.StatusInternalServerError, "datastore recreate error")
 			return
@@ -100,6 +119,55 @@ func handleTargetCreate(w http.ResponseWriter, r *http.Request, ds datastore.Dat
 			outputErrorMsg(w, http.StatusInternalServerError, "update resource type error")
 			return
 		}
+		if inputTarget.PlacementParams != nil {
+			if err := ds.UpdateTargetPlacementParams(ctx, target.UUID, t.PlacementParams); err != nil {
+				logger.Logf(false, "failed to update placement params in recreating target: %+v", err)
+				outputErrorMsg(w, http.StatusInternalServerError, "update placement params error")
+				return
+			}
+		}
+		if inputTarget.UserDataFormat != "" {
+			if err := ds.UpdateTargetUserDataFormat(ctx, target.UUID, inputTarget.UserDataFormat); err != nil {
+				logger.Logf(false, "failed to update user data format in recreating target: %+v", err)
+				outputErrorMsg(w, http.StatusInternalServerError, "update user data format error")
+				return
+			}
+		}
+		if inputTarget.RunnerRegistration != "" {
+			if err := ds.UpdateTargetRunnerRegistration(ctx, target.UUID, inputTarget.RunnerRegistration); err != nil {
+				logger.Logf(false, "failed to update runner registration in recreating target: %+v", err)
+				outputErrorMsg(w, http.StatusInternalServerError, "update runner registration error")
+				return
+			}
+		}
+		if inputTarget.RunnerLabels != nil {
+			if err := ds.UpdateTargetRunnerLabels(ctx, target.UUID, t.RunnerLabels); err != nil {
+				logger.Logf(false, "failed to update runner labels in recreating target: %+v", err)
+				outputErrorMsg(w, http.StatusInternalServerError, "update runner labels error")
+				return
+			}
+		}
+		if inputTarget.QuietHours != nil {
+			if err := ds.UpdateTargetQuietHours(ctx, target.UUID, t.QuietHours); err != nil {
+				logger.Logf(false, "failed to update quiet hours in recreating target: %+v", err)
+				outputErrorMsg(w, http.StatusInternalServerError, "update quiet hours error")
+				return
+			}
+		}
+		if inputTarget.Rootless != nil {
+			if err := ds.UpdateTargetRootless(ctx, target.UUID, t.Rootless); err != nil {
+				logger.Logf(false, "failed to update rootless in recreating target: %+v", err)
+				outputErrorMsg(w, http.StatusInternalServerError, "update rootless error")
+				return
+			}
+		}
+		if inputTarget.JobFilters != nil {
+			if err := ds.UpdateTargetJobFilters(ctx, target.UUID, t.JobFilters); err != nil {
+				logger.Logf(false, "failed to update job filters in recreating target: %+v", err)
+				outputErrorMsg(w, http.StatusInternalServerError, "update job filters error")
+				return
+			}
+		}
 
 		targetUUID = target.UUID
 	}
@@ -112,18 +180,21 @@ func handleTargetCreate(w http.ResponseWriter, r *http.Request, ds datastore.Dat
 	}
 	ut := sanitizeTarget(*createdTarget)
 
+	// jobs that were queued before target is registered are not received in webhook
+	go enqueueQueuedJobs(context.WithoutCancel(ctx), ds, installationID, createdTarget.Scope)
+
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(ut)
 }
 
 func isValidScopeAndToken(ctx context.Context, scope, githubPersonalToken string) error {
-	if err := GHExistGitHubRepositoryFunc(scope, githubPersonalToken); err != nil {
+	if err := GHExistGitHubRepositoryFunc(ctx, scope, githubPersonalToken); err != nil {
 		logger.Logf(false, "failed to found github repository: %+v", err)
 		return fmt.Errorf("github scope is invalid (maybe, repository is not found)")
 	}
 
-	client, err := gh.NewClient(githubPersonalToken)
+	client, err := gh.HostFrom(ctx).NewClient(githubPersonalToken)
 	if err != nil {
 		logger.Logf(false, "failed to create GitHub client: %+v", err)
 		return fmt.Errorf("invalid github token in input scope")
@@ -138,18 +209,12 @@ func isValidScopeAndToken(ctx context.Context, scope, githubPersonalToken string
 }
 
 func createNewTarget(ctx context.Context, input datastore.Target, ds datastore.Datastore) (*uuid.UUID, error) {
-	input.UUID = uuid.NewV4()
+	input.UUID = datastore.NewID()
 	now := time.Now().UTC()
 	input.CreatedAt = now
 	input.UpdatedAt = now
 
-	input.GHEDomain = sql.NullString{}
-	if config.Config.GitHubURL != "https://github.com" {
-		input.GHEDomain = sql.NullString{
-			String: config.Config.GitHubURL,
-			Valid:  true,
-		}
-	}
+	input.GHEDomain = datastore.NullGHEDomain(gh.HostFrom(ctx).GHEDomain())
 	if err := ds.CreateTarget(ctx, input); err != nil {
 		logger.Logf(false, "failed to create target in datastore: %+v", err)
 		return nil, fmt.Errorf("datastore create error")
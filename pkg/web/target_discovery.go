@@ -0,0 +1,143 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// uninstalledDescription is status description of target whose GitHub Apps is uninstalled
+const uninstalledDescription = "GitHub Apps is uninstalled"
+
+// LoopDiscoverTargets discover installations of GitHub Apps periodically, and create targets for new installations.
+// targets whose GitHub Apps is uninstalled are suspended.
+func LoopDiscoverTargets(ctx context.Context, ds datastore.Datastore, resourceType string, interval time.Duration) error {
+	if resourceType == "" {
+		logger.Logf(true, "auto registration of targets is disabled")
+		return nil
+	}
+	if gh.IsPATMode() {
+		return fmt.Errorf("auto registration of targets: %w", gh.ErrNotAvailableInPATMode)
+	}
+	rt := datastore.UnmarshalResourceTypeString(resourceType)
+	if rt == datastore.ResourceTypeUnknown {
+		return fmt.Errorf("invalid resource type of auto registration: %s", resourceType)
+	}
+	logger.Logf(false, "start to discover installations of GitHub Apps in every %s", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := DiscoverTargets(ctx, ds, rt); err != nil {
+			logger.Logf(false, "failed to discover installations of GitHub Apps: %+v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// DiscoverTargets create targets for installations of GitHub Apps that not registered,
+// and suspend (or reactivate) targets by whether GitHub Apps is installed.
+func DiscoverTargets(ctx context.Context, ds datastore.Datastore, resourceType datastore.ResourceType) error {
+	installations, err := gh.GHlistInstallations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get list of installations: %w", err)
+	}
+	if len(installations) == 0 {
+		// do not suspend all targets by unexpected response
+		logger.Logf(false, "no installation of GitHub Apps is found, skip discovering")
+		return nil
+	}
+
+	installed := map[string]struct{}{}
+	for _, i := range installations {
+		if i.SuspendedAt != nil {
+			continue
+		}
+		if login := i.GetAccount().GetLogin(); login != "" {
+			installed[strings.ToLower(login)] = struct{}{}
+		}
+		scope, ok := gh.InstallationScope(i)
+		if !ok {
+			continue
+		}
+		installed[strings.ToLower(scope)] = struct{}{}
+		if err := registerInstallation(ctx, ds, i, scope, resourceType); err != nil {
+			logger.Logf(false, "failed to register target of installation (scope: %s): %+v", scope, err)
+		}
+	}
+
+	targets, err := datastore.ListTargets(ctx, ds)
+	if err != nil {
+		return fmt.Errorf("failed to get list of targets: %w", err)
+	}
+	for _, t := range targets {
+		if err := syncInstalledStatus(ctx, ds, t, installed); err != nil {
+			logger.Logf(false, "failed to update status of target (scope: %s): %+v", t.Scope, err)
+		}
+	}
+	return nil
+}
+
+// registerInstallation create a target of installation if it is not registered.
+// deleted target is not created again, it is deleted by user
+func registerInstallation(ctx context.Context, ds datastore.Datastore, i *github.Installation, scope string, resourceType datastore.ResourceType) error {
+	_, err := ds.GetTargetByScope(ctx, gh.HostFrom(ctx).GHEDomain(), scope)
+	switch {
+	case err == nil:
+		return nil
+	case !errors.Is(err, datastore.ErrNotFound):
+		return fmt.Errorf("failed to get target by scope: %w", err)
+	}
+
+	clientApps, err := GHNewClientApps(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create a client of GitHub Apps: %w", err)
+	}
+	token, expiredAt, err := GHGenerateGitHubAppsToken(ctx, clientApps, i.GetID(), scope)
+	if err != nil {
+		return fmt.Errorf("failed to generate GitHub Apps token: %w", err)
+	}
+
+	u, err := createNewTarget(ctx, datastore.Target{
+		Scope:          scope,
+		GitHubToken:    token,
+		TokenExpiredAt: *expiredAt,
+		ResourceType:   resourceType,
+	}, ds)
+	if err != nil {
+		return fmt.Errorf("failed to create target: %w", err)
+	}
+	logger.Logf(false, "registered a target of new installation (scope: %s, target ID: %s)", scope, u)
+	return nil
+}
+
+// syncInstalledStatus suspend target if GitHub Apps is uninstalled, and reactivate it if installed again
+func syncInstalledStatus(ctx context.Context, ds datastore.Datastore, t datastore.Target, installed map[string]struct{}) error {
+	key := t.Scope
+	if gh.DetectScope(t.Scope) != gh.Enterprise {
+		key, _ = gh.DivideScope(t.Scope)
+	}
+	_, isInstalled := installed[strings.ToLower(key)]
+
+	switch {
+	case !isInstalled && t.CanReceiveJob():
+		logger.Logf(false, "GitHub Apps is uninstalled in %s, suspend target", t.Scope)
+		return datastore.UpdateTargetStatus(ctx, ds, t.UUID, datastore.TargetStatusSuspend, uninstalledDescription)
+	case isInstalled && t.Status == datastore.TargetStatusSuspend && t.StatusDescription.String == uninstalledDescription:
+		logger.Logf(false, "GitHub Apps is installed again in %s, reactivate target", t.Scope)
+		return ds.UpdateTargetStatus(ctx, t.UUID, datastore.TargetStatusActive, "")
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package web
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// ExportTarget is a target for backup / restore.
+// GitHub token is not included, it will be generated by token refresher after restore.
+type ExportTarget struct {
+	UUID               uuid.UUID                    `json:"id"`
+	Scope              string                       `json:"scope"`
+	GHEDomain          string                       `json:"ghe_domain,omitempty"`
+	ResourceType       datastore.ResourceType       `json:"resource_type"`
+	ProviderURL        string                       `json:"provider_url,omitempty"`
+	Status             datastore.TargetStatus       `json:"status"`
+	StatusDescription  string                       `json:"status_description,omitempty"`
+	ExternalRef        string                       `json:"external_ref,omitempty"`
+	PlacementParams    json.RawMessage              `json:"placement_params,omitempty"`
+	UserDataFormat     datastore.UserDataFormat     `json:"user_data_format,omitempty"`
+	RunnerRegistration datastore.RunnerRegistration `json:"runner_registration,omitempty"`
+	RunnerLabels       []string                     `json:"runner_labels,omitempty"`
+	QuietHours         json.RawMessage              `json:"quiet_hours,omitempty"`
+	Rootless           datastore.RootlessRuntime    `json:"rootless,omitempty"`
+	JobFilters         json.RawMessage              `json:"job_filters,omitempty"`
+	DeletedAt          *time.Time                   `json:"deleted_at,omitempty"`
+	CreatedAt          time.Time                    `json:"created_at"`
+}
+
+// ImportResult is result of import targets
+type ImportResult struct {
+	Imported int64 `json:"imported"`
+	Skipped  int64 `json:"skipped"`
+}
+
+func handleTargetExport(w http.ResponseWriter, r *http.Request, ds datastore.Datastore) {
+	ctx := datastore.WithReadReplica(r.Context())
+
+	ts, err := ds.ExportTargets(ctx)
+	if err != nil {
+		logger.Logf(false, "failed to export targets: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "datastore read error")
+		return
+	}
+
+	exported := make([]ExportTarget, 0, len(ts))
+	for _, t := range ts {
+		exported = append(exported, toExportTarget(t))
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(exported)
+}
+
+func handleTargetImport(w http.ResponseWriter, r *http.Request, ds datastore.Datastore) {
+	ctx := r.Context()
+
+	var input []ExportTarget
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Logf(false, "failed to decode request body: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
+		return
+	}
+
+	ts := make([]datastore.Target, 0, len(input))
+	for _, et := range input {
+		t, err := et.ToDS()
+		if err != nil {
+			logger.Logf(false, "failed to validate input: %+v", err)
+			outputErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		ts = append(ts, *t)
+	}
+
+	imported, err := ds.ImportTargets(ctx, ts)
+	if err != nil {
+		logger.Logf(false, "failed to import targets: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "datastore create error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ImportResult{
+		Imported: imported,
+		Skipped:  int64(len(ts)) - imported,
+	})
+}
+
+func toExportTarget(t datastore.Target) ExportTarget {
+	et := ExportTarget{
+		UUID:               t.UUID,
+		Scope:              t.Scope,
+		GHEDomain:          t.GHEDomain.String,
+		ResourceType:       t.ResourceType,
+		ProviderURL:        t.ProviderURL.String,
+		Status:             t.Status,
+		StatusDescription:  t.StatusDescription.String,
+		ExternalRef:        t.ExternalRef.String,
+		UserDataFormat:     t.UserDataFormat,
+		RunnerRegistration: t.RunnerRegistration,
+		RunnerLabels:       t.InjectedRunnerLabels(),
+		Rootless:           t.Rootless,
+		CreatedAt:          t.CreatedAt,
+	}
+	if t.PlacementParams.Valid {
+		et.PlacementParams = json.RawMessage(t.PlacementParams.String)
+	}
+	if t.QuietHours.Valid {
+		et.QuietHours = json.RawMessage(t.QuietHours.String)
+	}
+	if t.JobFilters.Valid {
+		et.JobFilters = json.RawMessage(t.JobFilters.String)
+	}
+	if t.DeletedAt.Valid {
+		et.DeletedAt = &t.DeletedAt.Time
+	}
+	return et
+}
+
+// ToDS convert to datastore.Target. token is empty and expired, so it will be refreshed soon
+func (et *ExportTarget) ToDS() (*datastore.Target, error) {
+	if et.Scope == "" {
+		return nil, fmt.Errorf("scope must be set")
+	}
+	if uuid.Equal(et.UUID, uuid.Nil) {
+		return nil, fmt.Errorf("id must be set (scope: %s)", et.Scope)
+	}
+	if et.ResourceType == datastore.ResourceTypeUnknown {
+		return nil, fmt.Errorf("resource_type must be set (scope: %s)", et.Scope)
+	}
+	if err := isValidPlacementParams(et.PlacementParams); err != nil {
+		return nil, fmt.Errorf("%w (scope: %s)", err, et.Scope)
+	}
+	if err := datastore.ValidateUserDataFormat(et.UserDataFormat); err != nil {
+		return nil, fmt.Errorf("%w (scope: %s)", err, et.Scope)
+	}
+	if err := datastore.ValidateRunnerRegistration(et.RunnerRegistration); err != nil {
+		return nil, fmt.Errorf("%w (scope: %s)", err, et.Scope)
+	}
+	if err := datastore.ValidateRunnerLabels(et.RunnerLabels); err != nil {
+		return nil, fmt.Errorf("%w (scope: %s)", err, et.Scope)
+	}
+	if err := isValidQuietHours(et.QuietHours); err != nil {
+		return nil, fmt.Errorf("%w (scope: %s)", err, et.Scope)
+	}
+	if err := datastore.ValidateRootlessRuntime(et.Rootless); err != nil {
+		return nil, fmt.Errorf("%w (scope: %s)", err, et.Scope)
+	}
+	if err := isValidJobFilters(et.JobFilters); err != nil {
+		return nil, fmt.Errorf("%w (scope: %s)", err, et.Scope)
+	}
+
+	status := et.Status
+	if status == "" {
+		status = datastore.TargetStatusActive
+	}
+	createdAt := et.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+	var deletedAt sql.NullTime
+	switch {
+	case et.DeletedAt != nil:
+		deletedAt = sql.NullTime{Time: *et.DeletedAt, Valid: true}
+	case status == datastore.TargetStatusDeleted:
+		// exported before soft delete
+		deletedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	}
+
+	return &datastore.Target{
+		UUID:               et.UUID,
+		Scope:              et.Scope,
+		GHEDomain:          toNullString(&et.GHEDomain),
+		GitHubToken:        "",
+		TokenExpiredAt:     time.Now().UTC(),
+		ResourceType:       et.ResourceType,
+		ProviderURL:        toNullString(&et.ProviderURL),
+		Status:             status,
+		StatusDescription:  toNullString(&et.StatusDescription),
+		ExternalRef:        toNullString(&et.ExternalRef),
+		PlacementParams:    toNullJSON(et.PlacementParams),
+		UserDataFormat:     et.UserDataFormat,
+		RunnerRegistration: et.RunnerRegistration,
+		RunnerLabels:       datastore.ToRunnerLabels(et.RunnerLabels),
+		QuietHours:         toNullJSON(et.QuietHours),
+		Rootless:           et.Rootless,
+		JobFilters:         toNullJSON(et.JobFilters),
+		DeletedAt:          deletedAt,
+		CreatedAt:          createdAt,
+	}, nil
+}
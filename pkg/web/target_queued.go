@@ -0,0 +1,55 @@
+package web
+
+import (
+	"context"
+
+	"github.com/google/go-github/v47/github"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// enqueueQueuedJobs enqueue workflow jobs that are queued before target is registered.
+// jobs are processed as same as received webhook, so already enqueued jobs are deduplicated.
+func enqueueQueuedJobs(ctx context.Context, ds datastore.Datastore, installationID int64, scope string) {
+	if gh.DetectScope(scope) == gh.Enterprise {
+		logger.Logf(true, "enqueue queued jobs in enterprise scope is not supported, ignore (scope: %s)", scope)
+		return
+	}
+	if config.Config.GitHubTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Config.GitHubTimeout)
+		defer cancel()
+	}
+
+	queued, err := GHListQueuedJobs(ctx, installationID, scope)
+	if err != nil {
+		logger.Logf(false, "failed to list queued jobs in new target (scope: %s): %+v", scope, err)
+		return
+	}
+
+	var enqueued int
+	for _, q := range queued {
+		if err := receiveWorkflowJobWebhook(ctx, toQueuedEvent(q, installationID), ds); err != nil {
+			logger.Logf(false, "failed to enqueue queued job (repository: %s, job ID: %d): %+v", q.Repository.GetFullName(), q.Job.GetID(), err)
+			continue
+		}
+		enqueued++
+	}
+	if enqueued > 0 {
+		logger.Logf(false, "enqueued %d jobs that were queued before target is registered (scope: %s)", enqueued, scope)
+	}
+}
+
+// toQueuedEvent convert queued job to webhook event of workflow_job
+func toQueuedEvent(q gh.QueuedJob, installationID int64) *github.WorkflowJobEvent {
+	action := "queued"
+	return &github.WorkflowJobEvent{
+		WorkflowJob:  q.Job,
+		Action:       &action,
+		Repo:         q.Repository,
+		Installation: &github.Installation{ID: &installationID},
+	}
+}
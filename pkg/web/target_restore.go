@@ -0,0 +1,72 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+func handleTargetDeletedList(w http.ResponseWriter, r *http.Request, ds datastore.Datastore) {
+	ctx := datastore.WithReadReplica(r.Context())
+
+	opt, err := parseListOption(r)
+	if err != nil {
+		logger.Logf(false, "failed to parse pagination parameters: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, "incorrect pagination parameters")
+		return
+	}
+
+	ts, err := ds.ListDeletedTargets(ctx, opt)
+	if err != nil {
+		logger.Logf(false, "failed to retrieve list of deleted target: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "datastore read error")
+		return
+	}
+	if opt.Limit > 0 && len(ts) == opt.Limit {
+		w.Header().Set(HeaderNextCursor, ts[len(ts)-1].UUID.String())
+	}
+
+	targets := []UserTarget{}
+	for _, t := range ts {
+		targets = append(targets, sanitizeTarget(t))
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(targets)
+}
+
+func handleTargetRestore(w http.ResponseWriter, r *http.Request, ds datastore.Datastore) {
+	ctx := r.Context()
+	targetID, err := parseReqTargetID(r)
+	if err != nil {
+		logger.Logf(false, "failed to decode request body: %+v", err)
+		outputErrorMsg(w, http.StatusBadRequest, "incorrect target id")
+		return
+	}
+
+	if err := ds.RestoreTarget(ctx, targetID); err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			outputErrorMsg(w, http.StatusNotFound, "target is not found or not deleted")
+			return
+		}
+		logger.Logf(false, "failed to restore target: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "datastore restore error")
+		return
+	}
+
+	target, err := ds.GetTarget(ctx, targetID)
+	if err != nil {
+		logger.Logf(false, "failed to get restored target: %+v", err)
+		outputErrorMsg(w, http.StatusInternalServerError, "datastore get error")
+		return
+	}
+	logger.Logf(false, "target %s is restored (target ID: %s)", target.Scope, target.UUID)
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(sanitizeTarget(*target))
+}
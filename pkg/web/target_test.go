@@ -3,6 +3,7 @@ package web_test
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/whywaita/myshoes/internal/testutils"
 	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
 	"github.com/whywaita/myshoes/pkg/web"
 )
 
@@ -35,11 +37,11 @@ func parseResponse(resp *http.Response) ([]byte, int) {
 }
 
 func setStubFunctions() {
-	web.GHExistGitHubRepositoryFunc = func(scope string, githubPersonalToken string) error {
+	web.GHExistGitHubRepositoryFunc = func(ctx context.Context, scope string, githubPersonalToken string) error {
 		return nil
 	}
 
-	web.GHExistRunnerReleases = func(runnerVersion string) error {
+	web.GHExistRunnerReleases = func(ctx context.Context, runnerVersion string) error {
 		return nil
 	}
 
@@ -51,13 +53,21 @@ func setStubFunctions() {
 		return testInstallationID, nil
 	}
 
+	web.GHCheckInstallationPermissions = func(ctx context.Context, installationID int64, scope string) error {
+		return nil
+	}
+
 	web.GHGenerateGitHubAppsToken = func(ctx context.Context, clientInstallation *github.Client, installationID int64, scope string) (string, *time.Time, error) {
 		return testGitHubAppToken, &testTime, nil
 	}
 
-	web.GHNewClientApps = func() (*github.Client, error) {
+	web.GHNewClientApps = func(ctx context.Context) (*github.Client, error) {
 		return &github.Client{}, nil
 	}
+
+	web.GHListQueuedJobs = func(ctx context.Context, installationID int64, scope string) ([]gh.QueuedJob, error) {
+		return nil, nil
+	}
 }
 
 func Test_handleTargetCreate(t *testing.T) {
@@ -101,6 +111,26 @@ func Test_handleTargetCreate(t *testing.T) {
 				Status:         datastore.TargetStatusActive,
 			},
 		},
+		{
+			input: `{"scope": "whywaita/whywaita3", "resource_type": "nano", "runner_user": "runner", "external_ref": "cmdb-1234"}`,
+			want: &web.UserTarget{
+				Scope:          "whywaita/whywaita3",
+				TokenExpiredAt: testTime,
+				ResourceType:   datastore.ResourceTypeNano.String(),
+				Status:         datastore.TargetStatusActive,
+				ExternalRef:    "cmdb-1234",
+			},
+		},
+		{
+			input: `{"scope": "whywaita/whywaita4", "resource_type": "nano", "runner_user": "runner", "placement_params": {"subnet_id": "subnet-xxx", "security_group_ids": ["sg-xxx"]}}`,
+			want: &web.UserTarget{
+				Scope:           "whywaita/whywaita4",
+				TokenExpiredAt:  testTime,
+				ResourceType:    datastore.ResourceTypeNano.String(),
+				Status:          datastore.TargetStatusActive,
+				PlacementParams: json.RawMessage(`{"subnet_id":"subnet-xxx","security_group_ids":["sg-xxx"]}`),
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -162,6 +192,43 @@ func Test_handleTargetCreate_alreadyRegistered(t *testing.T) {
 	}
 }
 
+func Test_handleTargetCreate_otherHost(t *testing.T) {
+	testURL := testutils.GetTestURL()
+	testDatastore, teardown := testutils.GetTestDatastore()
+	defer teardown()
+
+	setStubFunctions()
+	gheDomain := "https://github.example.com"
+	testutils.RegisterGitHubHost(t, gheDomain)
+
+	// same scope in other GitHub host is other target
+	for _, input := range []string{
+		`{"scope": "octocat", "resource_type": "micro", "runner_user": "runner"}`,
+		fmt.Sprintf(`{"scope": "octocat", "resource_type": "micro", "runner_user": "runner", "ghe_domain": %q}`, gheDomain),
+	} {
+		resp, err := http.Post(testURL+"/target", "application/json", bytes.NewBufferString(input))
+		if err != nil {
+			t.Fatalf("failed to POST request: %+v", err)
+		}
+		content, code := parseResponse(resp)
+		if code != http.StatusCreated {
+			t.Fatalf("must be response statuscode is 201, but got %d: %s", code, content)
+		}
+	}
+
+	targets, err := datastore.ListTargets(context.Background(), testDatastore)
+	if err != nil {
+		t.Fatalf("failed to list targets: %+v", err)
+	}
+	domains := map[string]bool{}
+	for _, target := range targets {
+		domains[target.GHEDomain.String] = true
+	}
+	if diff := cmp.Diff(map[string]bool{"": true, gheDomain: true}, domains); diff != "" {
+		t.Errorf("mismatch ghe_domain of targets (-want +got):\n%s", diff)
+	}
+}
+
 func Test_handleTargetCreate_recreated(t *testing.T) {
 	testURL := testutils.GetTestURL()
 	testDatastore, teardown := testutils.GetTestDatastore()
@@ -641,9 +708,13 @@ func Test_handleTargetDelete(t *testing.T) {
 		if err != nil {
 			t.Fatalf("failed to get target from datastore: %+v", err)
 		}
+		if !got.DeletedAt.Valid {
+			t.Errorf("deleted_at must be set when deleted")
+		}
 
 		got.CreatedAt = time.Time{}
 		got.UpdatedAt = time.Time{}
+		got.DeletedAt = sql.NullTime{}
 
 		if diff := cmp.Diff(test.want, got); diff != "" {
 			t.Errorf("mismatch (-want +got):\n%s", diff)
@@ -0,0 +1,150 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/prometheus/client_golang/prometheus"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/gh"
+)
+
+var unmatchedJobsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "myshoes",
+	Subsystem: "job",
+	Name:      "unmatched_labels_total",
+	Help:      "Total number of jobs that are not provisioned because runners of target never have requested labels.",
+}, []string{"target_id", "scope"})
+
+func init() {
+	prometheus.MustRegister(unmatchedJobsTotal)
+}
+
+// platformLabels is labels of OS and architecture that GitHub set to runners.
+// runner registered by JIT config has labels that job requests, but it can not change platform
+var platformLabels = []string{"linux", "windows", "macos", "x64", "x86", "arm", "arm64"}
+
+// maxUnmatchedJobs is max number of unmatched jobs that are kept for API
+const maxUnmatchedJobs = 100
+
+// UnmatchedJob is a job that is not provisioned by labels, response of GET /jobs/unmatched
+type UnmatchedJob struct {
+	TargetID        uuid.UUID `json:"target_id"`
+	Scope           string    `json:"scope"`
+	Repository      string    `json:"repository"`
+	GitHubJobID     int64     `json:"github_job_id"`
+	JobName         string    `json:"job_name"`
+	Labels          []string  `json:"labels"`
+	UnmatchedLabels []string  `json:"unmatched_labels"`
+	ReceivedAt      time.Time `json:"received_at"`
+}
+
+var (
+	// unmatchedJobs is recent unmatched jobs, the oldest one is first
+	unmatchedJobs   []UnmatchedJob
+	unmatchedJobsMu sync.Mutex
+)
+
+func recordUnmatchedJob(j UnmatchedJob) {
+	unmatchedJobsTotal.WithLabelValues(j.TargetID.String(), j.Scope).Inc()
+
+	unmatchedJobsMu.Lock()
+	defer unmatchedJobsMu.Unlock()
+	unmatchedJobs = append(unmatchedJobs, j)
+	if len(unmatchedJobs) > maxUnmatchedJobs {
+		unmatchedJobs = unmatchedJobs[len(unmatchedJobs)-maxUnmatchedJobs:]
+	}
+}
+
+// listUnmatchedJobs return recent unmatched jobs, the newest one is first
+func listUnmatchedJobs() []UnmatchedJob {
+	unmatchedJobsMu.Lock()
+	defer unmatchedJobsMu.Unlock()
+
+	jobs := make([]UnmatchedJob, 0, len(unmatchedJobs))
+	for i := len(unmatchedJobs) - 1; i >= 0; i-- {
+		jobs = append(jobs, unmatchedJobs[i])
+	}
+	return jobs
+}
+
+// availableLabels return labels that runners of target have
+func availableLabels(ctx context.Context, target datastore.Target) []string {
+	labels := []string{"self-hosted", "myshoes"}
+	if gh.HostFrom(ctx).IsGHES() {
+		labels = append(labels, "dependabot")
+	}
+	labels = append(labels, config.Config.RunnerPlatformLabels...)
+	labels = append(labels, config.Config.RunnerLabels...)
+	for _, m := range config.Config.ResourceTypeLabels {
+		// label of resource type is added to runner that created for the job
+		labels = append(labels, m.Label)
+	}
+	return append(labels, target.InjectedRunnerLabels()...)
+}
+
+// unmatchedLabels return labels that job requests but runners of target never have
+func unmatchedLabels(ctx context.Context, target datastore.Target, requested []string) []string {
+	available := availableLabels(ctx, target)
+	var unmatched []string
+	for _, l := range requested {
+		if containsFold(available, l) {
+			continue
+		}
+		if target.RunnerRegistration == datastore.RunnerRegistrationJIT && !containsFold(platformLabels, l) {
+			// runner has labels that job requests in JIT config
+			continue
+		}
+		unmatched = append(unmatched, l)
+	}
+	return unmatched
+}
+
+// checkUnmatchedJob record and return true if runners of target never match labels of workflow job in requestJSON.
+// other events (e.g. check_run, repository_dispatch) are not checked, labels of them are for shoes-provider
+func checkUnmatchedJob(ctx context.Context, target datastore.Target, repoName string, requestJSON []byte) bool {
+	var event github.WorkflowJobEvent
+	if err := json.Unmarshal(requestJSON, &event); err != nil || event.GetWorkflowJob() == nil {
+		return false
+	}
+	labels := event.GetWorkflowJob().Labels
+	unmatched := unmatchedLabels(ctx, target, labels)
+	if len(unmatched) == 0 {
+		return false
+	}
+
+	recordUnmatchedJob(UnmatchedJob{
+		TargetID:        target.UUID,
+		Scope:           target.Scope,
+		Repository:      repoName,
+		GitHubJobID:     event.GetWorkflowJob().GetID(),
+		JobName:         event.GetWorkflowJob().GetName(),
+		Labels:          labels,
+		UnmatchedLabels: unmatched,
+		ReceivedAt:      time.Now().UTC(),
+	})
+	return true
+}
+
+func containsFold(labels []string, label string) bool {
+	for _, l := range labels {
+		if strings.EqualFold(l, label) {
+			return true
+		}
+	}
+	return false
+}
+
+func handleUnmatchedJobList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(listUnmatchedJobs())
+}
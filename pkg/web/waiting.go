@@ -0,0 +1,67 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/logger"
+)
+
+// waitingJobs is workflow jobs that are waiting for approval of deployment environment, key is ID of workflow job.
+// approval of environment is expired in 30 days
+var waitingJobs = cache.New(30*24*time.Hour, 1*time.Hour)
+
+func init() {
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "myshoes",
+		Subsystem: "job",
+		Name:      "waiting_approval",
+		Help:      "Number of workflow jobs that are waiting for approval of deployment environment, runners are not provisioned for them.",
+	}, func() float64 {
+		return float64(waitingJobs.ItemCount())
+	}))
+}
+
+// workflowJobDedupKey return dedup key of job that is enqueued by workflow_job webhook
+func workflowJobDedupKey(githubJobID int64) string {
+	return fmt.Sprintf("workflow_job:%d", githubJobID)
+}
+
+// receiveWaitingJob track workflow job that is waiting for approval of deployment environment.
+// a job that is already enqueued by queued webhook is removed from queue, it is enqueued again when the job transitions to queued
+func receiveWaitingJob(ctx context.Context, ds datastore.Datastore, wj *github.WorkflowJob, repoName string) error {
+	waitingJobs.SetDefault(strconv.FormatInt(wj.GetID(), 10), repoName)
+	logger.Logf(false, "workflow job is waiting for approval of environment, defer provisioning (repository: %s, job ID: %d)", repoName, wj.GetID())
+
+	job, err := ds.GetJobByDedupKey(ctx, workflowJobDedupKey(wj.GetID()))
+	if errors.Is(err, datastore.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get enqueued job: %w", err)
+	}
+	if err := ds.DeleteJob(ctx, job.UUID); err != nil {
+		return fmt.Errorf("failed to delete job that is waiting for approval: %w", err)
+	}
+	datastore.RecordHistory(ctx, ds, datastore.HistoryResourceJob, job.UUID, datastore.HistoryStatusWaiting, "waiting for approval of environment")
+	logger.Logf(false, "removed job from queue until approval (job ID: %s)", job.UUID)
+	return nil
+}
+
+// forgetWaitingJob stop tracking workflow job, return true if the job was waiting for approval
+func forgetWaitingJob(githubJobID int64) bool {
+	key := strconv.FormatInt(githubJobID, 10)
+	if _, ok := waitingJobs.Get(key); !ok {
+		return false
+	}
+	waitingJobs.Delete(key)
+	return true
+}
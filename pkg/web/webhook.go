@@ -1,39 +1,101 @@
 package web
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v47/github"
 	uuid "github.com/satori/go.uuid"
 
 	"github.com/whywaita/myshoes/pkg/config"
 	"github.com/whywaita/myshoes/pkg/datastore"
+	"github.com/whywaita/myshoes/pkg/event"
 	"github.com/whywaita/myshoes/pkg/gh"
 	"github.com/whywaita/myshoes/pkg/logger"
 )
 
+// validatePayload validate signature of webhook by each secret, old and new secret are accepted in rotation
+func validatePayload(r *http.Request, secrets [][]byte) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	var lastErr error
+	for _, secret := range secrets {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		payload, err := github.ValidatePayload(r, secret)
+		if err == nil {
+			return payload, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// headerEnterpriseHost is header of webhook from GitHub Enterprise Server
+const headerEnterpriseHost = "X-GitHub-Enterprise-Host"
+
+// validatePayloadOfHosts validate signature of webhook by secrets of each GitHub host, and return host that signed it.
+// host in X-GitHub-Enterprise-Host (GHES) is tried first
+func validatePayloadOfHosts(r *http.Request) ([]byte, *gh.Host, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	hosts := gh.Hosts()
+	if name := r.Header.Get(headerEnterpriseHost); name != "" {
+		sort.SliceStable(hosts, func(i, j int) bool {
+			return hosts[i].Hostname() == name && hosts[j].Hostname() != name
+		})
+	}
+
+	var lastErr error
+	for _, h := range hosts {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		payload, err := validatePayload(r, h.Secrets())
+		if err == nil {
+			return payload, h, nil
+		}
+		lastErr = err
+	}
+	return nil, nil, lastErr
+}
+
 // HandleGitHubEvent handle GitHub webhook event
 func HandleGitHubEvent(w http.ResponseWriter, r *http.Request, ds datastore.Datastore) {
-	ctx := r.Context()
+	ctx := withDeliveryID(r.Context(), github.DeliveryID(r))
 
-	payload, err := github.ValidatePayload(r, config.Config.GitHub.AppSecret)
+	payload, host, err := validatePayloadOfHosts(r)
 	if err != nil {
 		logger.Logf(false, "failed to validate webhook payload: %+v\n", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	ctx = gh.WithHost(ctx, host)
+	ctx = withEnterprise(ctx, gh.ExtractEnterpriseSlug(payload))
 	webhookEvent, err := github.ParseWebHook(github.WebHookType(r), payload)
 	if err != nil {
 		logger.Logf(false, "failed to parse webhook payload: %+v\n", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	event.Publish(ctx, event.TopicWebhookReceived, event.Webhook{
+		EventType:  github.WebHookType(r),
+		DeliveryID: github.DeliveryID(r),
+		Payload:    payload,
+	})
 
 	switch event := webhookEvent.(type) {
 	case *github.PingEvent:
@@ -71,6 +133,61 @@ func HandleGitHubEvent(w http.ResponseWriter, r *http.Request, ds datastore.Data
 			return
 		}
 
+		w.WriteHeader(http.StatusOK)
+		return
+	case *github.WorkflowRunEvent:
+		if !config.Config.ModeWebhookType.Equal("workflow_job") {
+			logger.Logf(false, "receive WorkflowRunEvent, but set %s. So ignore", config.Config.ModeWebhookType)
+			return
+		}
+
+		if err := receiveWorkflowRunWebhook(ctx, event, ds); err != nil {
+			logger.Logf(false, "failed to process workflow_run event: %+v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		return
+	case *github.InstallationEvent:
+		if err := receiveInstallationWebhook(ctx, event, ds); err != nil {
+			logger.Logf(false, "failed to process installation event: %+v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		return
+	case *github.InstallationRepositoriesEvent:
+		if err := receiveInstallationRepositoriesWebhook(ctx, event, ds); err != nil {
+			logger.Logf(false, "failed to process installation_repositories event: %+v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		return
+	case *github.RepositoryEvent, *github.OrganizationEvent:
+		if err := receiveScopeChangeWebhook(ctx, payload, ds); err != nil {
+			logger.Logf(false, "failed to process %s event: %+v\n", github.WebHookType(r), err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		return
+	case *github.RepositoryDispatchEvent:
+		if len(config.Config.RepositoryDispatchTypes) == 0 {
+			logger.Logf(true, "receive RepositoryDispatchEvent, but %s is not set. So ignore", config.EnvRepositoryDispatchTypes)
+			return
+		}
+
+		if err := receiveRepositoryDispatchWebhook(ctx, event, ds); err != nil {
+			logger.Logf(false, "failed to process repository_dispatch event: %+v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
 		w.WriteHeader(http.StatusOK)
 		return
 	default:
@@ -80,15 +197,48 @@ func HandleGitHubEvent(w http.ResponseWriter, r *http.Request, ds datastore.Data
 	}
 }
 
-func storeActiveTarget(repoName string, installationID int64) {
+type deliveryIDKey struct{}
+
+// withDeliveryID set delivery ID of webhook (X-GitHub-Delivery) to context
+func withDeliveryID(ctx context.Context, deliveryID string) context.Context {
+	return context.WithValue(ctx, deliveryIDKey{}, deliveryID)
+}
+
+// getDeliveryID get delivery ID of webhook from context, return nil if not found
+func getDeliveryID(ctx context.Context) *string {
+	deliveryID, ok := ctx.Value(deliveryIDKey{}).(string)
+	if !ok {
+		return nil
+	}
+	return &deliveryID
+}
+
+type enterpriseKey struct{}
+
+// withEnterprise set slug of enterprise that repository of webhook belongs to
+func withEnterprise(ctx context.Context, enterprise string) context.Context {
+	return context.WithValue(ctx, enterpriseKey{}, enterprise)
+}
+
+// getEnterprise get slug of enterprise from context, return empty if repository does not belong to enterprise
+func getEnterprise(ctx context.Context) string {
+	enterprise, _ := ctx.Value(enterpriseKey{}).(string)
+	return enterprise
+}
+
+// storeActiveTarget store repository for refreshing workflow runs, it is only in primary host
+func storeActiveTarget(ctx context.Context, repoName string, installationID int64) {
+	if gh.HostFrom(ctx) != gh.PrimaryHost() {
+		return
+	}
 	gh.ActiveTargets.Store(repoName, installationID)
 }
 
-func receivePingWebhook(_ context.Context, event *github.PingEvent) error {
+func receivePingWebhook(ctx context.Context, event *github.PingEvent) error {
 	repoName := event.GetRepo().GetFullName()
 	installationID := event.GetInstallation().GetID()
 
-	storeActiveTarget(repoName, installationID)
+	storeActiveTarget(ctx, repoName, installationID)
 	return nil
 }
 
@@ -109,15 +259,18 @@ func receiveCheckRunWebhook(ctx context.Context, event *github.CheckRunEvent, ds
 	if err != nil {
 		return fmt.Errorf("failed to json.Marshal: %w", err)
 	}
-	storeActiveTarget(repoName, installationID)
-	return processCheckRun(ctx, ds, repoName, repoURL, installationID, jb)
+	storeActiveTarget(ctx, repoName, installationID)
+	dedupKey := fmt.Sprintf("check_run:%d", event.GetCheckRun().GetID())
+	return processCheckRun(ctx, ds, repoName, repoURL, installationID, jb, 1, dedupKey)
 }
 
 // processCheckRun process webhook event
 // repoName is :owner/:repo
 // repoURL is https://github.com/:owenr/:repo (in github.com) or https://github.example.com/:owner/:repo (in GitHub Enterprise)
-func processCheckRun(ctx context.Context, ds datastore.Datastore, repoName, repoURL string, installationID int64, requestJSON []byte) error {
-	if err := gh.CheckSignature(installationID); err != nil {
+// count is number of jobs that will be enqueued
+// dedupKey is unique key of event (e.g. ID of workflow job), redelivered events that have same key are enqueued only once. empty is no deduplication
+func processCheckRun(ctx context.Context, ds datastore.Datastore, repoName, repoURL string, installationID int64, requestJSON []byte, count int, dedupKey string) error {
+	if err := gh.HostFrom(ctx).CheckSignature(installationID); err != nil {
 		return fmt.Errorf("failed to create GitHub client: %w", err)
 	}
 
@@ -135,7 +288,7 @@ func processCheckRun(ctx context.Context, ds datastore.Datastore, repoName, repo
 	}
 
 	logger.Logf(false, "receive webhook repository: %s/%s", domain, repoName)
-	target, err := datastore.SearchRepo(ctx, ds, repoName)
+	target, err := datastore.SearchRepoWithEnterprise(ctx, ds, repoName, getEnterprise(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to search registered target: %w", err)
 	}
@@ -145,8 +298,15 @@ func processCheckRun(ctx context.Context, ds datastore.Datastore, repoName, repo
 		logger.Logf(false, "%s/%s is %s now, do nothing", domain, repoName, target.Status)
 		return nil
 	}
+	if checkUnmatchedJob(ctx, *target, repoName, requestJSON) {
+		logger.Logf(false, "runners of target never have labels that job requests, do nothing (repository: %s/%s)", domain, repoName)
+		return nil
+	}
+	if checkJobFilters(ctx, *target, repoName, installationID, requestJSON) {
+		logger.Logf(false, "job is refused by job filters of target, do nothing (repository: %s/%s)", domain, repoName)
+		return nil
+	}
 
-	jobID := uuid.NewV4()
 	var jobDomain sql.NullString
 	if gheDomain == "" {
 		jobDomain = sql.NullString{
@@ -159,15 +319,26 @@ func processCheckRun(ctx context.Context, ds datastore.Datastore, repoName, repo
 		}
 	}
 
-	j := datastore.Job{
-		UUID:           jobID,
-		GHEDomain:      jobDomain,
-		Repository:     repoName,
-		CheckEventJSON: string(requestJSON),
-		TargetID:       target.UUID,
-	}
-	if err := ds.EnqueueJob(ctx, j); err != nil {
-		return fmt.Errorf("failed to enqueue job: %w", err)
+	for i := 0; i < count; i++ {
+		j := datastore.Job{
+			UUID:           datastore.NewID(),
+			GHEDomain:      jobDomain,
+			Repository:     repoName,
+			CheckEventJSON: string(requestJSON),
+			TargetID:       target.UUID,
+			ExternalRef:    toNullString(getDeliveryID(ctx)),
+			DedupKey:       toDedupKey(dedupKey, i),
+		}
+		stored, err := ds.EnqueueJob(ctx, j)
+		if err != nil {
+			return fmt.Errorf("failed to enqueue job: %w", err)
+		}
+		if !uuid.Equal(stored.UUID, j.UUID) {
+			logger.Logf(false, "job is already enqueued, ignore redelivered webhook (job ID: %s, dedup key: %s)", stored.UUID, j.DedupKey.String)
+			continue
+		}
+		datastore.RecordHistory(ctx, ds, datastore.HistoryResourceJob, j.UUID, datastore.HistoryStatusEnqueued, "received webhook")
+		datastore.RecordJobReceived(ctx, ds, j, gh.ExtractJobID(requestJSON))
 	}
 
 	return nil
@@ -182,13 +353,33 @@ func receiveWorkflowJobWebhook(ctx context.Context, event *github.WorkflowJobEve
 	repoURL := repo.GetHTMLURL()
 
 	labels := event.GetWorkflowJob().Labels
+	if isScaleSetLabel(labels) {
+		// job is assigned to runner scale set, listener of scale set enqueues it
+		logger.Logf(true, "job requests runner scale set, so ignore webhook (labels: %s)", labels)
+		return nil
+	}
 	if !isRequestedMyshoesLabel(labels) {
 		// is not request myshoes, So will be ignored
 		logger.Logf(true, "label \"myshoes\" is not found in labels, so ignore (labels: %s)", labels)
 		return nil
 	}
 
-	if action != "queued" {
+	switch action {
+	case "queued":
+		if forgetWaitingJob(event.GetWorkflowJob().GetID()) {
+			logger.Logf(false, "workflow job is approved, provision runner (repository: %s, job ID: %d)", repoName, event.GetWorkflowJob().GetID())
+		}
+	case "waiting":
+		return receiveWaitingJob(ctx, ds, event.GetWorkflowJob(), repoName)
+	case "in_progress":
+		forgetWaitingJob(event.GetWorkflowJob().GetID())
+		datastore.RecordGitHubJobEvent(ctx, ds, event.GetWorkflowJob().GetID(), datastore.JobHistoryStarted, timeOrNow(event.GetWorkflowJob().GetStartedAt()))
+		return nil
+	case "completed":
+		forgetWaitingJob(event.GetWorkflowJob().GetID())
+		datastore.RecordGitHubJobEvent(ctx, ds, event.GetWorkflowJob().GetID(), datastore.JobHistoryCompleted, timeOrNow(event.GetWorkflowJob().GetCompletedAt()))
+		return nil
+	default:
 		logger.Logf(true, "workflow_job actions is not queued, ignore")
 		return nil
 	}
@@ -198,8 +389,131 @@ func receiveWorkflowJobWebhook(ctx context.Context, event *github.WorkflowJobEve
 		return fmt.Errorf("failed to json.Marshal: %w", err)
 	}
 
-	storeActiveTarget(repoName, installationID)
-	return processCheckRun(ctx, ds, repoName, repoURL, installationID, jb)
+	storeActiveTarget(ctx, repoName, installationID)
+	if err := processCheckRun(ctx, ds, repoName, repoURL, installationID, jb, 1, workflowJobDedupKey(event.GetWorkflowJob().GetID())); err != nil {
+		return err
+	}
+	// first job of workflow run triggers pre-scaling of other jobs in the run
+	preScaleWorkflowRun(ctx, ds, installationID, repo, event.GetWorkflowJob().GetRunID())
+	return nil
+}
+
+// receiveWorkflowRunWebhook store context of workflow run in registered target, it is used for rescue of pending runs.
+// queued jobs of the run are pre-provisioned if BurstMaxRunners is set
+func receiveWorkflowRunWebhook(ctx context.Context, event *github.WorkflowRunEvent, ds datastore.Datastore) error {
+	action := event.GetAction()
+	installationID := event.GetInstallation().GetID()
+	repoName := event.GetRepo().GetFullName()
+
+	switch action {
+	case "requested", "in_progress", "completed":
+	default:
+		logger.Logf(true, "workflow_run actions is %s, ignore", action)
+		return nil
+	}
+	if action != "completed" {
+		preScaleWorkflowRun(ctx, ds, installationID, event.GetRepo(), event.GetWorkflowRun().GetID())
+	}
+	if gh.HostFrom(ctx) != gh.PrimaryHost() {
+		// rescue of pending runs is only in primary host
+		return nil
+	}
+
+	if _, err := datastore.SearchRepoWithEnterprise(ctx, ds, repoName, getEnterprise(ctx)); err != nil {
+		// workflow_run is received in all repositories that installed GitHub Apps
+		logger.Logf(true, "target of %s is not found, ignore workflow_run: %+v", repoName, err)
+		return nil
+	}
+
+	run := event.GetWorkflowRun()
+	if run.Repository == nil {
+		run.Repository = event.GetRepo()
+	}
+	storeActiveTarget(ctx, repoName, installationID)
+	gh.StoreWorkflowRun(ctx, installationID, run)
+	return nil
+}
+
+// receiveScopeChangeWebhook rename scope of targets and jobs if repository is renamed or transferred, or organization is renamed
+func receiveScopeChangeWebhook(ctx context.Context, payload []byte, ds datastore.Datastore) error {
+	oldScope, newScope, ok := gh.ExtractScopeChange(payload)
+	if !ok {
+		logger.Logf(true, "scope is not changed, ignore")
+		return nil
+	}
+
+	renamed, err := ds.RenameScope(ctx, gh.HostFrom(ctx).GHEDomain(), oldScope, newScope)
+	switch {
+	case errors.Is(err, datastore.ErrNotFound):
+		logger.Logf(true, "target is not found in %s, ignore change of scope", oldScope)
+		return nil
+	case errors.Is(err, datastore.ErrConflict):
+		logger.Logf(false, "failed to rename scope from %s to %s, need to update target manually: %+v", oldScope, newScope, err)
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to rename scope from %s to %s: %w", oldScope, newScope, err)
+	}
+	logger.Logf(false, "scope is changed from %s to %s, renamed %d targets", oldScope, newScope, renamed)
+	return nil
+}
+
+// timeOrNow return time of timestamp in webhook, or now if it is not set
+func timeOrNow(t github.Timestamp) time.Time {
+	if t.Time.IsZero() {
+		return time.Now().UTC()
+	}
+	return t.Time
+}
+
+// receiveRepositoryDispatchWebhook provision a runner for repository_dispatch event that is sent by API (e.g. warming capacity)
+func receiveRepositoryDispatchWebhook(ctx context.Context, event *github.RepositoryDispatchEvent, ds datastore.Datastore) error {
+	action := event.GetAction() // event_type in dispatch request
+	installationID := event.GetInstallation().GetID()
+
+	repo := event.GetRepo()
+	repoName := repo.GetFullName()
+	repoURL := repo.GetHTMLURL()
+
+	if !isAcceptedDispatchType(action) {
+		logger.Logf(true, "event_type of repository_dispatch is not accepted, ignore (event_type: %s)", action)
+		return nil
+	}
+	payload, err := gh.ParseDispatchPayload(event.ClientPayload)
+	if err != nil {
+		return fmt.Errorf("failed to parse client_payload: %w", err)
+	}
+
+	jb, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to json.Marshal: %w", err)
+	}
+
+	storeActiveTarget(ctx, repoName, installationID)
+	var dedupKey string
+	if deliveryID := getDeliveryID(ctx); deliveryID != nil {
+		dedupKey = fmt.Sprintf("repository_dispatch:%s", *deliveryID)
+	}
+	return processCheckRun(ctx, ds, repoName, repoURL, installationID, jb, payload.GetCount(), dedupKey)
+}
+
+// toDedupKey return key for deduplication of i-th job in an event
+func toDedupKey(dedupKey string, i int) sql.NullString {
+	if dedupKey == "" {
+		return sql.NullString{Valid: false}
+	}
+	if i > 0 {
+		dedupKey = fmt.Sprintf("%s:%d", dedupKey, i)
+	}
+	return sql.NullString{String: dedupKey, Valid: true}
+}
+
+func isAcceptedDispatchType(eventType string) bool {
+	for _, t := range config.Config.RepositoryDispatchTypes {
+		if strings.EqualFold(t, eventType) {
+			return true
+		}
+	}
+	return false
 }
 
 func isRequestedMyshoesLabel(labels []string) bool {
@@ -210,3 +524,8 @@ func isRequestedMyshoesLabel(labels []string) bool {
 	}
 	return false
 }
+
+// isScaleSetLabel return true if job requests only runner scale set
+func isScaleSetLabel(labels []string) bool {
+	return config.Config.ScaleSetName != "" && len(labels) == 1 && strings.EqualFold(labels[0], config.Config.ScaleSetName)
+}
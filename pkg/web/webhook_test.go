@@ -0,0 +1,48 @@
+package web_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/whywaita/myshoes/pkg/config"
+	"github.com/whywaita/myshoes/pkg/web"
+)
+
+func signPayload(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func Test_HandleGitHubEvent_SecretRotation(t *testing.T) {
+	config.Config.GitHub.AppSecrets = [][]byte{[]byte("new-secret"), []byte("old-secret")}
+	config.Config.GitHub.AppSecret = config.Config.GitHub.AppSecrets[0]
+	payload := []byte(`{"zen": "Keep it logically awesome.", "hook_id": 1}`)
+
+	tests := []struct {
+		secret string
+		want   int
+	}{
+		{secret: "new-secret", want: http.StatusOK},
+		{secret: "old-secret", want: http.StatusOK},
+		{secret: "unknown-secret", want: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/github/events", bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", "ping")
+		req.Header.Set("X-Hub-Signature-256", signPayload([]byte(test.secret), payload))
+		w := httptest.NewRecorder()
+
+		web.HandleGitHubEvent(w, req, nil)
+		if w.Code != test.want {
+			t.Errorf("secret %s: want %d, but got %d", test.secret, test.want, w.Code)
+		}
+	}
+}